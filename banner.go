@@ -0,0 +1,159 @@
+package ajan
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"path"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/logfx"
+)
+
+const otlpProtocol = "otlp"
+
+// BuildInfo describes the running binary in the startup banner.
+type BuildInfo struct {
+	BuildTime time.Time
+	AppName   string
+	Version   string
+	Commit    string
+	GoVersion string
+	Features  []string
+	Modified  bool
+}
+
+// Describable is implemented by components that report themselves in the startup banner.
+type Describable interface {
+	Describe() slog.Attr
+}
+
+// DescribeFunc adapts a function to Describable.
+type DescribeFunc func() slog.Attr
+
+func (f DescribeFunc) Describe() slog.Attr {
+	return f()
+}
+
+// DescribeValues describes a list of names, e.g. the enabled middlewares.
+func DescribeValues(key string, values ...string) Describable {
+	return DescribeFunc(func() slog.Attr {
+		return slog.Any(key, values)
+	})
+}
+
+// ReadBuildInfo returns the build information embedded by the Go toolchain: the main
+// module's path and version, and the VCS revision and time when built from a checkout.
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{} //nolint:exhaustruct
+
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.AppName = path.Base(buildInfo.Main.Path)
+	info.Version = buildInfo.Main.Version
+	info.GoVersion = buildInfo.GoVersion
+
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.BuildTime, _ = time.Parse(time.RFC3339, setting.Value)
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+}
+
+// LogStartupBanner logs one structured "application starting" record with the build
+// information and a field for each component. Fields left empty in info are filled from
+// ReadBuildInfo.
+func LogStartupBanner(logger *logfx.Logger, info BuildInfo, components ...Describable) {
+	info = info.withDefaults(ReadBuildInfo())
+
+	attrs := []slog.Attr{
+		slog.String("app", info.AppName),
+		slog.String("version", info.Version),
+		slog.String("commit", info.Commit),
+		slog.String("go_version", info.GoVersion),
+		slog.Any("features", info.Features),
+	}
+
+	if !info.BuildTime.IsZero() {
+		attrs = append(attrs, slog.Time("build_time", info.BuildTime))
+	}
+
+	if info.Modified {
+		attrs = append(attrs, slog.Bool("modified", true))
+	}
+
+	for _, component := range components {
+		attrs = append(attrs, component.Describe())
+	}
+
+	logger.LogAttrs(context.Background(), logfx.LevelInfo, "application starting", attrs...)
+}
+
+// Describe reports the environment, the configured connections and the OTLP endpoints
+// used for telemetry. Credentials in endpoints are redacted with connfx.RedactDSN.
+func (c *BaseConfig) Describe() slog.Attr {
+	names := slices.Sorted(maps.Keys(c.Conn.Targets))
+
+	connections := make([]any, 0, len(names))
+	endpoints := make([]any, 0)
+
+	for _, name := range names {
+		target := c.Conn.Targets[name]
+
+		connections = append(connections, slog.String(name, target.Protocol))
+
+		if target.Protocol == otlpProtocol {
+			endpoints = append(endpoints, slog.String(name, connfx.RedactDSN(target.DSN)))
+		}
+	}
+
+	return slog.Group(
+		"config",
+		slog.String("env", c.AppEnv),
+		slog.Group("connections", connections...),
+		slog.Group(
+			"telemetry",
+			slog.String("logs", c.Log.OTLPConnectionName),
+			slog.String("metrics", c.Metrics.OTLPConnectionName),
+			slog.Group("endpoints", endpoints...),
+		),
+	)
+}
+
+func (info BuildInfo) withDefaults(defaults BuildInfo) BuildInfo {
+	if info.AppName == "" {
+		info.AppName = defaults.AppName
+	}
+
+	if info.Version == "" {
+		info.Version = defaults.Version
+	}
+
+	if info.Commit == "" {
+		info.Commit = defaults.Commit
+		info.Modified = info.Modified || defaults.Modified
+	}
+
+	if info.BuildTime.IsZero() {
+		info.BuildTime = defaults.BuildTime
+	}
+
+	if info.GoVersion == "" {
+		info.GoVersion = defaults.GoVersion
+	}
+
+	return info
+}
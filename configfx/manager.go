@@ -1,6 +1,7 @@
 package configfx
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"reflect"
@@ -12,6 +13,7 @@ import (
 var (
 	ErrNotStruct                  = errors.New("not a struct")
 	ErrMissingRequiredConfigValue = errors.New("missing required config value")
+	ErrInvalidConfigValue         = errors.New("invalid config value")
 )
 
 type ConfigManager struct{}
@@ -229,9 +231,18 @@ func reflectSet( //nolint:cyclop,gocognit,funlen
 
 		// Check if the target map has the key with the child name
 		value, valueOk := (*target)[key].(string)
+
+		// An empty duration or byte size, such as TIMEOUT= in an env file, is unset
+		if valueOk && value == "" && isValidatedType(child.Type) {
+			valueOk = false
+		}
+
 		if !valueOk {
 			if child.HasDefaultValue {
-				reflectSetField(child.Field, child.Type, child.DefaultValue)
+				err := reflectSetField(child.Field, child.Type, child.DefaultValue)
+				if err != nil {
+					return fmt.Errorf("%w (key=%q, source=default)", err, key)
+				}
 
 				continue
 			}
@@ -249,17 +260,33 @@ func reflectSet( //nolint:cyclop,gocognit,funlen
 			continue
 		}
 
-		reflectSetField(child.Field, child.Type, value)
+		err := reflectSetField(child.Field, child.Type, value)
+		if err != nil {
+			return fmt.Errorf("%w (key=%q)", err, key)
+		}
 	}
 
 	return nil
 }
 
+// isValidatedType reports whether reflectSetField rejects malformed values of fieldType.
+func isValidatedType(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	return fieldType == reflect.TypeFor[time.Duration]() ||
+		reflect.PointerTo(fieldType).Implements(reflect.TypeFor[encoding.TextUnmarshaler]())
+}
+
+// reflectSetField converts value to fieldType and assigns it. Durations accept Go
+// duration strings ("30s", "5m"); types implementing encoding.TextUnmarshaler, such as
+// lib.ByteSize ("10MB", "1GiB"), parse themselves. Other scalars are parsed leniently.
 func reflectSetField( //nolint:cyclop,funlen
 	field reflect.Value,
 	fieldType reflect.Type,
 	value string,
-) {
+) error {
 	var finalValue reflect.Value
 
 	switch fieldType {
@@ -312,10 +339,25 @@ func reflectSetField( //nolint:cyclop,funlen
 		boolValue, _ := strconv.ParseBool(value)
 		finalValue = reflect.ValueOf(boolValue)
 	case reflect.TypeFor[time.Duration]():
-		durationValue, _ := time.ParseDuration(value)
+		durationValue, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w (type=%s): %w", ErrInvalidConfigValue, fieldType.String(), err)
+		}
+
 		finalValue = reflect.ValueOf(durationValue)
 	default:
-		return
+		if !reflect.PointerTo(fieldType).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+			return nil
+		}
+
+		textValue := reflect.New(fieldType)
+
+		unmarshaler, _ := textValue.Interface().(encoding.TextUnmarshaler)
+		if err := unmarshaler.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("%w (type=%s): %w", ErrInvalidConfigValue, fieldType.String(), err)
+		}
+
+		finalValue = textValue.Elem()
 	}
 
 	if field.Kind() == reflect.Ptr {
@@ -324,9 +366,11 @@ func reflectSetField( //nolint:cyclop,funlen
 		ptr.Elem().Set(finalValue)
 		field.Set(ptr)
 
-		return
+		return nil
 	}
 
 	// Set the field directly
 	field.Set(finalValue)
+
+	return nil
 }
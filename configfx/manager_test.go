@@ -3,8 +3,10 @@ package configfx_test
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/eser/ajan/configfx"
+	"github.com/eser/ajan/lib"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -26,6 +28,12 @@ type TestConfigNested struct {
 	Array      []TestConfigNestedKV `conf:"arr"`
 }
 
+type TestConfigSizes struct {
+	Timeout   time.Duration `conf:"timeout"    default:"30s"`
+	BodyLimit lib.ByteSize  `conf:"body_limit" default:"10MB"`
+	Buffer    lib.ByteSize  `conf:"buffer"`
+}
+
 func TestLoad(t *testing.T) {
 	t.Parallel()
 
@@ -167,3 +175,78 @@ func TestLoadMeta(t *testing.T) { //nolint:funlen
 		assert.ElementsMatch(t, expected, meta.Children)
 	})
 }
+
+func TestLoad_DurationsAndByteSizes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should parse defaults", func(t *testing.T) {
+		t.Parallel()
+
+		config := TestConfigSizes{} //nolint:exhaustruct
+
+		cl := configfx.NewConfigManager()
+		err := cl.Load(&config)
+
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, config.Timeout)
+		assert.Equal(t, 10*lib.Megabyte, config.BodyLimit)
+		assert.Zero(t, config.Buffer)
+	})
+
+	t.Run("should parse human-friendly values", func(t *testing.T) {
+		t.Parallel()
+
+		config := TestConfigSizes{} //nolint:exhaustruct
+
+		cl := configfx.NewConfigManager()
+		err := cl.Load(
+			&config,
+			cl.FromJSONString(`{"timeout": "5m", "body_limit": "1GiB", "buffer": "64 KiB"}`),
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, config.Timeout)
+		assert.Equal(t, lib.Gibibyte, config.BodyLimit)
+		assert.Equal(t, 64*lib.Kibibyte, config.Buffer)
+	})
+
+	t.Run("should treat empty values as unset", func(t *testing.T) {
+		t.Parallel()
+
+		config := TestConfigSizes{} //nolint:exhaustruct
+
+		cl := configfx.NewConfigManager()
+		err := cl.Load(
+			&config,
+			cl.FromJSONString(`{"timeout": "", "body_limit": "", "buffer": ""}`),
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, config.Timeout)
+		assert.Equal(t, 10*lib.Megabyte, config.BodyLimit)
+		assert.Zero(t, config.Buffer)
+	})
+
+	t.Run("should reject invalid values", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name string
+			json string
+		}{
+			{name: "duration without unit", json: `{"timeout": "30"}`},
+			{name: "malformed duration", json: `{"timeout": "five minutes"}`},
+			{name: "unknown size unit", json: `{"body_limit": "10XB"}`},
+			{name: "negative size", json: `{"body_limit": "-1MB"}`},
+		}
+
+		for _, tt := range tests {
+			config := TestConfigSizes{} //nolint:exhaustruct
+
+			cl := configfx.NewConfigManager()
+			err := cl.Load(&config, cl.FromJSONString(tt.json))
+
+			require.ErrorIs(t, err, configfx.ErrInvalidConfigValue, tt.name)
+		}
+	})
+}
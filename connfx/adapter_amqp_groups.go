@@ -0,0 +1,336 @@
+package connfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var ErrUnknownReceiptHandle = errors.New("no pending AMQP delivery for receipt handle")
+
+// amqpConsumeSession identifies the deliveries of one basic.consume (or one claim) on a
+// channel. Delivery tags are only unique per channel, so receipt handles combine them
+// with the session ID.
+type amqpConsumeSession struct {
+	// queue is the AMQP queue the deliveries come from
+	queue string
+	// topic is the queue name the caller consumes, which differs from queue for groups
+	topic string
+	// group is the consumer group, empty for plain consumers
+	group string
+	id    uint64
+}
+
+// receiptHandle encodes a delivery tag as "<session>.<delivery tag>".
+func (s amqpConsumeSession) receiptHandle(deliveryTag uint64) string {
+	return strconv.FormatUint(s.id, 10) + "." + strconv.FormatUint(deliveryTag, 10)
+}
+
+// amqpPendingDelivery is a delivery handed to the caller that has not been settled yet.
+type amqpPendingDelivery struct {
+	since   time.Time
+	ack     func() error
+	nack    func(requeue bool) error
+	session amqpConsumeSession
+}
+
+// amqpPendingDeliveries indexes unsettled deliveries by receipt handle.
+type amqpPendingDeliveries struct {
+	entries map[string]*amqpPendingDelivery
+	mu      sync.Mutex
+}
+
+func (p *amqpPendingDeliveries) add(receiptHandle string, delivery *amqpPendingDelivery) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.entries == nil {
+		p.entries = make(map[string]*amqpPendingDelivery)
+	}
+
+	p.entries[receiptHandle] = delivery
+}
+
+func (p *amqpPendingDeliveries) get(receiptHandle string) (*amqpPendingDelivery, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delivery, ok := p.entries[receiptHandle]
+
+	return delivery, ok
+}
+
+func (p *amqpPendingDeliveries) remove(receiptHandle string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.entries, receiptHandle)
+}
+
+// dropSession forgets the deliveries of a session whose channel has closed.
+func (p *amqpPendingDeliveries) dropSession(sessionID uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for receiptHandle, delivery := range p.entries {
+		if delivery.session.id == sessionID {
+			delete(p.entries, receiptHandle)
+		}
+	}
+}
+
+// idle returns the deliveries from queue that have been pending for at least minIdleTime.
+func (p *amqpPendingDeliveries) idle(
+	queue string,
+	minIdleTime time.Duration,
+) []*amqpPendingDelivery {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var deliveries []*amqpPendingDelivery
+
+	for _, delivery := range p.entries {
+		if delivery.session.queue == queue && time.Since(delivery.since) >= minIdleTime {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	return deliveries
+}
+
+// amqpGroupQueueName returns the queue holding the consumer group's copy of queueName.
+func amqpGroupQueueName(queueName, consumerGroup string) string {
+	return queueName + "." + consumerGroup
+}
+
+// DeclareConsumerGroup declares a durable topic exchange named after queueName and the
+// group's durable queue "<queueName>.<consumerGroup>" bound to it, and returns the group
+// queue. Consumers of the same group share its queue, while every group receives its own
+// copy of each message published with PublishToGroups. Publishers may declare the groups
+// too, so messages published before the first consumer starts are kept.
+func (aa *AMQPAdapter) DeclareConsumerGroup(
+	ctx context.Context,
+	queueName string,
+	consumerGroup string,
+) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("%w (exchange=%q): %w", ErrFailedToDeclareExchange, queueName, err)
+	}
+
+	channel, err := aa.ensureChannel(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w (queue=%q): %w", ErrAMQPClientNotInitialized, queueName, err)
+	}
+
+	if err := declareGroupExchange(channel, queueName); err != nil {
+		return "", err
+	}
+
+	groupQueue, err := aa.QueueDeclareWithConfig(
+		ctx,
+		amqpGroupQueueName(queueName, consumerGroup),
+		QueueConfig{Durable: true}, //nolint:exhaustruct
+	)
+	if err != nil {
+		return "", err
+	}
+
+	err = channel.QueueBind(groupQueue, queueName, queueName, false, nil)
+	if err != nil {
+		return "", fmt.Errorf(
+			"%w (queue=%q, exchange=%q, routing_key=%q): %w",
+			ErrFailedToBindQueue,
+			groupQueue,
+			queueName,
+			queueName,
+			err,
+		)
+	}
+
+	return groupQueue, nil
+}
+
+// PublishToGroups sends a message through the topic exchange named after queueName, so
+// every consumer group of queueName receives its own copy. The exchange is declared
+// first, so publishers need no group state of their own. A plain queue named queueName
+// does not receive the message; use PublishWithHeaders for it.
+func (aa *AMQPAdapter) PublishToGroups(
+	ctx context.Context,
+	queueName string,
+	body []byte,
+	headers map[string]any,
+) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w (queue=%q): %w", ErrFailedToPublishMessage, queueName, err)
+	}
+
+	channel, err := aa.ensureChannel(ctx)
+	if err != nil {
+		return fmt.Errorf("%w (queue=%q): %w", ErrAMQPClientNotInitialized, queueName, err)
+	}
+
+	if err := declareGroupExchange(channel, queueName); err != nil {
+		return err
+	}
+
+	return aa.publish(ctx, channel, queueName, queueName, body, headers)
+}
+
+// ConsumeWithGroup declares the consumer group (see DeclareConsumerGroup) and consumes
+// its queue. Messages carry the group in ConsumerGroup.
+func (aa *AMQPAdapter) ConsumeWithGroup(
+	ctx context.Context,
+	queueName string,
+	consumerGroup string,
+	consumerName string,
+	config ConsumerConfig,
+) (<-chan Message, <-chan error) {
+	messages, errors, _ := aa.consume(ctx, queueName, consumerGroup, config)
+
+	return messages, errors
+}
+
+// declareGroupExchange declares the durable topic exchange consumer groups of queueName
+// are bound to.
+func declareGroupExchange(channel *amqp.Channel, queueName string) error {
+	err := channel.ExchangeDeclare(
+		queueName,
+		amqp.ExchangeTopic,
+		true,  // durable
+		false, // auto-delete
+		false, // internal
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("%w (exchange=%q): %w", ErrFailedToDeclareExchange, queueName, err)
+	}
+
+	return nil
+}
+
+// ClaimPendingMessages requeues the group's deliveries held by consumers of this
+// connection that have been pending for at least minIdleTime, so the broker can hand them
+// out again, then fetches up to count messages from the group queue. Deliveries held by
+// other connections are requeued by the broker when their channel closes. A claimed
+// message rejected without requeue goes to the group queue's dead-letter exchange, if
+// one is configured.
+func (aa *AMQPAdapter) ClaimPendingMessages(
+	ctx context.Context,
+	queueName string,
+	consumerGroup string,
+	consumerName string,
+	minIdleTime time.Duration,
+	count int,
+) ([]Message, error) {
+	groupQueue := amqpGroupQueueName(queueName, consumerGroup)
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"%w (operation=claim, queue=%q): %w",
+			ErrAMQPOperation,
+			groupQueue,
+			err,
+		)
+	}
+
+	for _, delivery := range aa.pending.idle(groupQueue, minIdleTime) {
+		if err := delivery.nack(true); err != nil {
+			return nil, fmt.Errorf(
+				"%w (operation=requeue, queue=%q): %w",
+				ErrAMQPOperation,
+				groupQueue,
+				err,
+			)
+		}
+	}
+
+	if count <= 0 {
+		return []Message{}, nil
+	}
+
+	channel, err := aa.ensureChannel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w (queue=%q): %w", ErrAMQPClientNotInitialized, groupQueue, err)
+	}
+
+	session := amqpConsumeSession{
+		queue: groupQueue,
+		topic: queueName,
+		group: consumerGroup,
+		id:    aa.sessions.Add(1),
+	}
+	tracker := &consumerTracker{} //nolint:exhaustruct
+	messages := make([]Message, 0, count)
+
+	for len(messages) < count {
+		delivery, ok, err := channel.Get(groupQueue, false)
+		if err != nil {
+			return messages, fmt.Errorf(
+				"%w (operation=get, queue=%q): %w",
+				ErrAMQPOperation,
+				groupQueue,
+				err,
+			)
+		}
+
+		if !ok {
+			break
+		}
+
+		tracker.unsettled.Add(1)
+
+		messages = append(messages, aa.createMessage(delivery, session, false, tracker))
+	}
+
+	return messages, nil
+}
+
+// AckMessage acknowledges a delivery by its receipt handle, out of band from the
+// Message. The delivery must still be pending on this connection: deliveries of a
+// closed channel have already been requeued by the broker.
+func (aa *AMQPAdapter) AckMessage(
+	ctx context.Context,
+	queueName, consumerGroup, receiptHandle string,
+) error {
+	delivery, ok := aa.pending.get(receiptHandle)
+	if !ok || delivery.session.topic != queueName || delivery.session.group != consumerGroup {
+		return fmt.Errorf(
+			"%w (queue=%q, consumer_group=%q, receipt_handle=%q)",
+			ErrUnknownReceiptHandle,
+			queueName,
+			consumerGroup,
+			receiptHandle,
+		)
+	}
+
+	if err := delivery.ack(); err != nil {
+		return fmt.Errorf("%w (operation=ack, queue=%q): %w", ErrAMQPOperation, queueName, err)
+	}
+
+	return nil
+}
+
+// DeleteMessage removes a pending delivery from its queue by acknowledging it.
+func (aa *AMQPAdapter) DeleteMessage(ctx context.Context, queueName, receiptHandle string) error {
+	delivery, ok := aa.pending.get(receiptHandle)
+	if !ok || delivery.session.topic != queueName {
+		return fmt.Errorf(
+			"%w (queue=%q, receipt_handle=%q)",
+			ErrUnknownReceiptHandle,
+			queueName,
+			receiptHandle,
+		)
+	}
+
+	if err := delivery.ack(); err != nil {
+		return fmt.Errorf("%w (operation=delete, queue=%q): %w", ErrAMQPOperation, queueName, err)
+	}
+
+	return nil
+}
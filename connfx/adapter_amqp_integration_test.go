@@ -0,0 +1,174 @@
+//go:build integration
+
+package connfx_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/connfx/conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/rabbitmq"
+)
+
+// newRabbitMQAdapter starts a RabbitMQ container and connects an AMQPAdapter to it.
+// Run with: go test -tags integration ./connfx/...
+func newRabbitMQAdapter(t *testing.T) *connfx.AMQPAdapter {
+	t.Helper()
+
+	container, err := rabbitmq.Run(t.Context(), "rabbitmq:3.13-alpine")
+	testcontainers.CleanupContainer(t, container)
+	require.NoError(t, err)
+
+	url, err := container.AmqpURL(t.Context())
+	require.NoError(t, err)
+
+	conn, err := connfx.NewAMQPConnectionFactory("amqp").CreateConnection(
+		t.Context(),
+		&connfx.ConfigTarget{Protocol: "amqp", DSN: url}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close(t.Context()) })
+
+	adapter, err := connfx.GetTypedConnection[*connfx.AMQPAdapter](conn)
+	require.NoError(t, err)
+
+	return adapter
+}
+
+func TestAMQPAdapter_ConsumerGroups_RabbitMQ(t *testing.T) {
+	t.Parallel()
+
+	adapter := newRabbitMQAdapter(t)
+	ctx := t.Context()
+
+	// Declaring the groups up front keeps messages published before consumers start
+	for _, group := range []string{"billing", "shipping"} {
+		_, err := adapter.DeclareConsumerGroup(ctx, "orders", group)
+		require.NoError(t, err)
+	}
+
+	const published = 6
+
+	for i := range published {
+		require.NoError(t, adapter.PublishToGroups(ctx, "orders", fmt.Appendf(nil, "order-%d", i), nil))
+	}
+
+	received := map[string]map[string]int{"billing": {}, "shipping": {}}
+
+	// Two billing consumers share the group's messages; shipping gets its own copy
+	consumers := []struct{ group, name string }{
+		{"billing", "billing-1"},
+		{"billing", "billing-2"},
+		{"shipping", "shipping-1"},
+	}
+
+	type delivery struct {
+		group string
+		msg   connfx.Message
+	}
+
+	deliveries := make(chan delivery)
+
+	for _, consumer := range consumers {
+		messages, errs := adapter.ConsumeWithGroup(
+			ctx,
+			"orders",
+			consumer.group,
+			consumer.name,
+			connfx.DefaultConsumerConfig(),
+		)
+
+		go func() {
+			for {
+				select {
+				case msg, ok := <-messages:
+					if !ok {
+						return
+					}
+
+					select {
+					case deliveries <- delivery{group: consumer.group, msg: msg}:
+					case <-ctx.Done():
+						return
+					}
+				case err, ok := <-errs:
+					if ok {
+						t.Errorf("consumer %s: %v", consumer.name, err)
+					}
+
+					return
+				}
+			}
+		}()
+	}
+
+	for range 2 * published {
+		select {
+		case d := <-deliveries:
+			received[d.group][string(d.msg.Body)]++
+
+			require.NoError(t, adapter.AckMessage(ctx, "orders", d.group, d.msg.ReceiptHandle))
+		case <-time.After(30 * time.Second):
+			t.Fatalf("received %v, expected %d messages per group", received, published)
+		}
+	}
+
+	for group, bodies := range received {
+		assert.Len(t, bodies, published, "group %s", group)
+
+		for body, count := range bodies {
+			assert.Equal(t, 1, count, "group %s received %s more than once", group, body)
+		}
+	}
+}
+
+func TestAMQPAdapter_ClaimPendingMessages_RabbitMQ(t *testing.T) {
+	t.Parallel()
+
+	adapter := newRabbitMQAdapter(t)
+	ctx := t.Context()
+
+	_, err := adapter.DeclareConsumerGroup(ctx, "payments", "ledger")
+	require.NoError(t, err)
+
+	require.NoError(t, adapter.PublishToGroups(ctx, "payments", []byte("payment-1"), nil))
+
+	// A worker claims the message and stalls without settling it
+	var claimed []connfx.Message
+
+	require.Eventually(t, func() bool {
+		claimed, err = adapter.ClaimPendingMessages(ctx, "payments", "ledger", "worker-1", 0, 1)
+
+		return err == nil && len(claimed) == 1
+	}, 10*time.Second, 50*time.Millisecond)
+
+	stalled := claimed[0]
+
+	// Another worker requeues the idle delivery and takes it over
+	claimed, err = adapter.ClaimPendingMessages(ctx, "payments", "ledger", "worker-2", 0, 1)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, "payment-1", string(claimed[0].Body))
+	assert.NotEqual(t, stalled.ReceiptHandle, claimed[0].ReceiptHandle)
+
+	err = adapter.AckMessage(ctx, "payments", "ledger", stalled.ReceiptHandle)
+	require.ErrorIs(t, err, connfx.ErrUnknownReceiptHandle)
+
+	require.NoError(t, adapter.DeleteMessage(ctx, "payments", claimed[0].ReceiptHandle))
+
+	claimed, err = adapter.ClaimPendingMessages(ctx, "payments", "ledger", "worker-2", 0, 1)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestAMQPAdapter_GroupDeliveryConformance_RabbitMQ(t *testing.T) {
+	t.Parallel()
+
+	conformance.AssertGroupDelivery(t, newRabbitMQAdapter(t), "shipments")
+}
@@ -10,7 +10,6 @@ import (
 	"maps"
 	"net/http"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	"github.com/eser/ajan/httpclient"
@@ -34,6 +33,13 @@ const (
 
 	// HTTP error threshold.
 	DefaultServerErrorThreshold = 500
+
+	// Transport pooling defaults, tuned for service-to-service calls
+	// (the standard library keeps only 2 idle connections per host).
+	DefaultHTTPMaxIdleConns        = 100
+	DefaultHTTPMaxIdleConnsPerHost = 32
+	DefaultHTTPMaxConnsPerHost     = 0 // unlimited
+	DefaultHTTPIdleConnTimeout     = 90 * time.Second
 )
 
 var (
@@ -48,6 +54,7 @@ var (
 	ErrFailedToCreateGetRequest      = errors.New("failed to create GET request")
 	ErrFailedToPerformGetRequest     = errors.New("failed to perform GET request")
 	ErrFailedToCreateResilientClient = errors.New("failed to create resilient HTTP client")
+	ErrInvalidHTTPConfig             = errors.New("invalid HTTP connection configuration")
 )
 
 // HTTPConnection represents an HTTP API connection with resilience features.
@@ -57,7 +64,16 @@ type HTTPConnection struct {
 	headers    map[string]string
 	protocol   string
 	baseURL    string
-	state      int32 // atomic field for connection state
+	endpoints  []*httpEndpoint
+	*stateTracker
+}
+
+// HTTPTransportSettings holds the connection pooling settings of an HTTP connection.
+type HTTPTransportSettings struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
 }
 
 // HTTPConnectionFactory creates HTTP connections.
@@ -76,22 +92,26 @@ func (f *HTTPConnectionFactory) CreateConnection(
 	ctx context.Context,
 	config *ConfigTarget,
 ) (Connection, error) {
+	endpoints, err := buildHTTPEndpoints(config)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create resilient HTTP client with configuration
 	client, headers, err := f.buildResilientHTTPClient(config)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrFailedToCreateHTTPClient, err)
 	}
 
-	baseURL := config.URL
-
 	// Initial health check
 	conn := &HTTPConnection{
-		protocol:   f.protocol,
-		client:     client,
-		baseURL:    baseURL,
-		headers:    headers,
-		state:      int32(ConnectionStateConnected),
-		lastHealth: time.Time{},
+		protocol:     f.protocol,
+		client:       client,
+		baseURL:      endpoints[0].url,
+		endpoints:    endpoints,
+		headers:      headers,
+		stateTracker: newStateTracker(ConnectionStateConnected),
+		lastHealth:   time.Time{},
 	}
 
 	// Perform initial health check
@@ -111,7 +131,15 @@ func (f *HTTPConnectionFactory) buildResilientHTTPClient( //nolint:cyclop
 	config *ConfigTarget,
 ) (*httpclient.Client, map[string]string, error) {
 	// Create resilient HTTP client configuration
-	clientConfig := f.buildClientConfig(config)
+	clientConfig, err := f.buildClientConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transportSettings, err := f.buildTransportSettings(config)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Create resilient client with custom transport
 	clientOptions := []httpclient.NewClientOption{
@@ -144,6 +172,11 @@ func (f *HTTPConnectionFactory) buildResilientHTTPClient( //nolint:cyclop
 
 	client := httpclient.NewClient(clientOptions...)
 
+	// Tune connection pooling of the underlying transport
+	if transport, ok := client.Transport.Transport.(*http.Transport); ok {
+		f.applyTransportSettings(transport, transportSettings)
+	}
+
 	// Set timeout
 	if config.Timeout > 0 {
 		client.Timeout = config.Timeout
@@ -173,7 +206,13 @@ func (f *HTTPConnectionFactory) buildResilientHTTPClient( //nolint:cyclop
 	return client, headers, nil
 }
 
-func (f *HTTPConnectionFactory) buildClientConfig(config *ConfigTarget) *httpclient.Config {
+// buildClientConfig reads the circuit_breaker and retry_strategy sections and
+// server_error_threshold. Integers may be given as numbers or numeric strings and
+// durations as time.Duration values or duration strings, as decoded from JSON, YAML or
+// environment variables.
+func (f *HTTPConnectionFactory) buildClientConfig(
+	config *ConfigTarget,
+) (*httpclient.Config, error) {
 	clientConfig := &httpclient.Config{
 		CircuitBreaker: httpclient.CircuitBreakerConfig{
 			Enabled:               true,
@@ -188,86 +227,146 @@ func (f *HTTPConnectionFactory) buildClientConfig(config *ConfigTarget) *httpcli
 			MaxInterval:     DefaultRetryMaxInterval,
 			Multiplier:      DefaultRetryMultiplier,
 			RandomFactor:    DefaultRetryRandomFactor,
+			MaxRetryAfter:   httpclient.DefaultMaxRetryAfter,
+			MinRetryAfter:   httpclient.DefaultMinRetryAfter,
 		},
 		ServerErrorThreshold: DefaultServerErrorThreshold,
 	}
 
-	if config.Properties != nil {
-		f.applyCircuitBreakerConfig(clientConfig, config.Properties)
-		f.applyRetryStrategyConfig(clientConfig, config.Properties)
-		f.applyServerErrorThreshold(clientConfig, config.Properties)
+	if config.Properties == nil {
+		return clientConfig, nil
+	}
+
+	if err := f.applyCircuitBreakerConfig(clientConfig, config.Properties); err != nil {
+		return nil, err
+	}
+
+	if err := f.applyRetryStrategyConfig(clientConfig, config.Properties); err != nil {
+		return nil, err
 	}
 
-	return clientConfig
+	err := setIntProperty(
+		config.Properties,
+		"server_error_threshold",
+		&clientConfig.ServerErrorThreshold,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHTTPConfig, err)
+	}
+
+	return clientConfig, nil
 }
 
 func (f *HTTPConnectionFactory) applyCircuitBreakerConfig(
 	clientConfig *httpclient.Config,
 	properties map[string]any,
-) {
+) error {
 	cbConfig, ok := properties["circuit_breaker"].(map[string]any)
 	if !ok {
-		return
+		return nil
 	}
 
 	if enabled, ok := cbConfig["enabled"].(bool); ok {
 		clientConfig.CircuitBreaker.Enabled = enabled
 	}
 
-	if threshold, ok := cbConfig["failure_threshold"].(int); ok && threshold >= 0 {
-		clientConfig.CircuitBreaker.FailureThreshold = uint(threshold)
-	}
-
-	if timeout, ok := cbConfig["reset_timeout"].(time.Duration); ok {
-		clientConfig.CircuitBreaker.ResetTimeout = timeout
+	err := errors.Join(
+		setIntProperty(
+			cbConfig,
+			"failure_threshold",
+			&clientConfig.CircuitBreaker.FailureThreshold,
+		),
+		setDurationProperty(
+			cbConfig,
+			"reset_timeout",
+			&clientConfig.CircuitBreaker.ResetTimeout,
+		),
+		setIntProperty(
+			cbConfig,
+			"half_open_success_needed",
+			&clientConfig.CircuitBreaker.HalfOpenSuccessNeeded,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("%w (section=%q) %w", ErrInvalidHTTPConfig, "circuit_breaker", err)
 	}
 
-	if success, ok := cbConfig["half_open_success_needed"].(int); ok && success >= 0 {
-		clientConfig.CircuitBreaker.HalfOpenSuccessNeeded = uint(success)
-	}
+	return nil
 }
 
 func (f *HTTPConnectionFactory) applyRetryStrategyConfig(
 	clientConfig *httpclient.Config,
 	properties map[string]any,
-) {
+) error {
 	retryConfig, ok := properties["retry_strategy"].(map[string]any)
 	if !ok {
-		return
+		return nil
 	}
 
 	if enabled, ok := retryConfig["enabled"].(bool); ok {
 		clientConfig.RetryStrategy.Enabled = enabled
 	}
 
-	if maxAttempts, ok := retryConfig["max_attempts"].(int); ok && maxAttempts >= 0 {
-		clientConfig.RetryStrategy.MaxAttempts = uint(maxAttempts)
+	if multiplier, ok := retryConfig["multiplier"].(float64); ok {
+		clientConfig.RetryStrategy.Multiplier = multiplier
+	}
+
+	if randomFactor, ok := retryConfig["random_factor"].(float64); ok {
+		clientConfig.RetryStrategy.RandomFactor = randomFactor
 	}
 
-	if initialInterval, ok := retryConfig["initial_interval"].(time.Duration); ok {
-		clientConfig.RetryStrategy.InitialInterval = initialInterval
+	err := errors.Join(
+		setIntProperty(retryConfig, "max_attempts", &clientConfig.RetryStrategy.MaxAttempts),
+		setDurationProperty(
+			retryConfig,
+			"initial_interval",
+			&clientConfig.RetryStrategy.InitialInterval,
+		),
+		setDurationProperty(retryConfig, "max_interval", &clientConfig.RetryStrategy.MaxInterval),
+	)
+	if err != nil {
+		return fmt.Errorf("%w (section=%q) %w", ErrInvalidHTTPConfig, "retry_strategy", err)
 	}
 
-	if maxInterval, ok := retryConfig["max_interval"].(time.Duration); ok {
-		clientConfig.RetryStrategy.MaxInterval = maxInterval
+	return nil
+}
+
+func (f *HTTPConnectionFactory) buildTransportSettings(
+	config *ConfigTarget,
+) (HTTPTransportSettings, error) {
+	settings := HTTPTransportSettings{
+		MaxIdleConns:        DefaultHTTPMaxIdleConns,
+		MaxIdleConnsPerHost: DefaultHTTPMaxIdleConnsPerHost,
+		MaxConnsPerHost:     DefaultHTTPMaxConnsPerHost,
+		IdleConnTimeout:     DefaultHTTPIdleConnTimeout,
 	}
 
-	if multiplier, ok := retryConfig["multiplier"].(float64); ok {
-		clientConfig.RetryStrategy.Multiplier = multiplier
+	transportConfig, ok := config.Properties["transport"].(map[string]any)
+	if !ok {
+		return settings, nil
 	}
 
-	if randomFactor, ok := retryConfig["random_factor"].(float64); ok {
-		clientConfig.RetryStrategy.RandomFactor = randomFactor
+	err := errors.Join(
+		setIntProperty(transportConfig, "max_idle_conns", &settings.MaxIdleConns),
+		setIntProperty(transportConfig, "max_idle_conns_per_host", &settings.MaxIdleConnsPerHost),
+		setIntProperty(transportConfig, "max_conns_per_host", &settings.MaxConnsPerHost),
+		setDurationProperty(transportConfig, "idle_conn_timeout", &settings.IdleConnTimeout),
+	)
+	if err != nil {
+		return settings, fmt.Errorf("%w (section=%q) %w", ErrInvalidHTTPConfig, "transport", err)
 	}
+
+	return settings, nil
 }
 
-func (f *HTTPConnectionFactory) applyServerErrorThreshold(
-	clientConfig *httpclient.Config,
-	properties map[string]any,
+func (f *HTTPConnectionFactory) applyTransportSettings(
+	transport *http.Transport,
+	settings HTTPTransportSettings,
 ) {
-	if threshold, ok := properties["server_error_threshold"].(int); ok {
-		clientConfig.ServerErrorThreshold = threshold
-	}
+	transport.MaxIdleConns = settings.MaxIdleConns
+	transport.MaxIdleConnsPerHost = settings.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = settings.MaxConnsPerHost
+	transport.IdleConnTimeout = settings.IdleConnTimeout
 }
 
 // Connection interface implementation
@@ -285,45 +384,53 @@ func (c *HTTPConnection) GetProtocol() string {
 }
 
 func (c *HTTPConnection) GetState() ConnectionState {
-	state := atomic.LoadInt32(&c.state)
-
-	return ConnectionState(state)
+	return c.loadState()
 }
 
+// HealthCheck checks every endpoint and reports the healthiest one. Endpoints found in
+// the error state stop receiving requests until a later check finds them healthy again.
 func (c *HTTPConnection) HealthCheck(
 	ctx context.Context,
 ) *HealthStatus {
 	start := time.Now()
-	status := &HealthStatus{ //nolint:exhaustruct
-		Timestamp: start,
-	}
 
-	// Create and perform health check request
-	resp, err := c.performHealthCheckRequest(ctx)
-	status.Latency = time.Since(start)
+	var status *HealthStatus
 
-	if err != nil {
-		atomic.StoreInt32(&c.state, int32(ConnectionStateError))
-		status.State = ConnectionStateError
-		status.Error = err
-		status.Message = fmt.Sprintf("Health check failed: %v", err)
+	healthy := 0
 
-		return status
+	for _, endpoint := range c.endpoints {
+		endpointStatus := c.checkEndpoint(ctx, endpoint.url, start)
+
+		endpoint.healthy.Store(endpointStatus.State != ConnectionStateError)
+
+		if endpointStatus.State != ConnectionStateError {
+			healthy++
+		}
+
+		if status == nil || httpHealthRank(endpointStatus.State) > httpHealthRank(status.State) {
+			status = endpointStatus
+		}
 	}
 
-	defer func() {
-		_ = resp.Body.Close() // Ignore close error for health check
-	}()
+	status.Latency = time.Since(start)
+
+	if len(c.endpoints) > 1 {
+		status.Message = fmt.Sprintf(
+			"%s (%d/%d endpoints healthy)",
+			status.Message,
+			healthy,
+			len(c.endpoints),
+		)
+	}
 
-	// Determine health state based on HTTP response status
-	c.determineHealthState(resp, status, ctx, start)
+	c.setState(status.State, status.Error)
 	c.lastHealth = start
 
 	return status
 }
 
 func (c *HTTPConnection) Close(ctx context.Context) error {
-	atomic.StoreInt32(&c.state, int32(ConnectionStateDisconnected))
+	c.setState(ConnectionStateDisconnected, nil)
 	// Resilient HTTP clients handle cleanup internally
 	if transport, ok := c.client.Transport.Transport.(*http.Transport); ok {
 		transport.CloseIdleConnections()
@@ -348,11 +455,28 @@ func (c *HTTPConnection) GetStandardClient() *http.Client {
 	return c.client.Client
 }
 
-// GetBaseURL returns the base URL for this connection.
+// GetBaseURL returns the base URL for this connection, the first endpoint when there
+// are several.
 func (c *HTTPConnection) GetBaseURL() string {
 	return c.baseURL
 }
 
+// GetEndpoints returns the endpoints requests are distributed over, with their weights
+// and the outcome of the latest health check.
+func (c *HTTPConnection) GetEndpoints() []HTTPEndpoint {
+	endpoints := make([]HTTPEndpoint, len(c.endpoints))
+
+	for i, endpoint := range c.endpoints {
+		endpoints[i] = HTTPEndpoint{
+			URL:     endpoint.url,
+			Weight:  endpoint.weight,
+			Healthy: endpoint.healthy.Load(),
+		}
+	}
+
+	return endpoints
+}
+
 // GetHeaders returns the default headers for this connection.
 func (c *HTTPConnection) GetHeaders() map[string]string {
 	headers := make(map[string]string)
@@ -361,6 +485,21 @@ func (c *HTTPConnection) GetHeaders() map[string]string {
 	return headers
 }
 
+// GetTransportSettings returns the effective connection pooling settings.
+func (c *HTTPConnection) GetTransportSettings() HTTPTransportSettings {
+	transport, ok := c.client.Transport.Transport.(*http.Transport)
+	if !ok {
+		return HTTPTransportSettings{} //nolint:exhaustruct
+	}
+
+	return HTTPTransportSettings{
+		MaxIdleConns:        transport.MaxIdleConns,
+		MaxIdleConnsPerHost: transport.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     transport.MaxConnsPerHost,
+		IdleConnTimeout:     transport.IdleConnTimeout,
+	}
+}
+
 // GetCircuitBreakerState returns the current state of the circuit breaker.
 func (c *HTTPConnection) GetCircuitBreakerState() string {
 	if c.client.Transport != nil && c.client.Transport.CircuitBreaker != nil {
@@ -370,14 +509,16 @@ func (c *HTTPConnection) GetCircuitBreakerState() string {
 	return "unknown"
 }
 
-// NewRequest creates a new HTTP request with the connection's default headers.
+// NewRequest creates a new HTTP request with the connection's default headers. With
+// several endpoints configured, each request targets one picked at random in proportion
+// to the endpoint weights, skipping those that failed their latest health check.
 func (c *HTTPConnection) NewRequest(
 	ctx context.Context,
 	method string,
 	path string,
 	body any,
 ) (*http.Request, error) {
-	url := c.baseURL
+	url := pickHTTPEndpoint(c.endpoints).url
 
 	if path != "" {
 		if path[0] != '/' {
@@ -417,9 +558,42 @@ func (c *HTTPConnection) NewRequest(
 	return req, nil
 }
 
-func (c *HTTPConnection) performHealthCheckRequest(ctx context.Context) (*http.Response, error) {
-	// Create health check request (HEAD request to base URL)
-	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.baseURL, nil)
+// checkEndpoint performs the health check request against a single endpoint.
+func (c *HTTPConnection) checkEndpoint(
+	ctx context.Context,
+	url string,
+	start time.Time,
+) *HealthStatus {
+	resp, err := c.performHealthCheckRequest(ctx, url)
+	if err != nil {
+		return &HealthStatus{ //nolint:exhaustruct
+			Timestamp: start,
+			State:     ConnectionStateError,
+			Error:     err,
+			Message:   fmt.Sprintf("Health check failed: %v", err),
+		}
+	}
+
+	defer func() {
+		_ = resp.Body.Close() // Ignore close error for health check
+	}()
+
+	// Try GET request if HEAD fails with 405 (Method Not Allowed)
+	if resp.StatusCode == http.StatusMethodNotAllowed {
+		if getStatus := c.tryGetRequest(ctx, url, start); getStatus != nil {
+			return getStatus
+		}
+	}
+
+	return httpHealthStatus(resp.StatusCode, "HEAD", start)
+}
+
+func (c *HTTPConnection) performHealthCheckRequest(
+	ctx context.Context,
+	url string,
+) (*http.Response, error) {
+	// Create health check request (HEAD request to the endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrFailedToCreateHealthCheckReq, err)
 	}
@@ -438,28 +612,13 @@ func (c *HTTPConnection) performHealthCheckRequest(ctx context.Context) (*http.R
 	return resp, nil
 }
 
-func (c *HTTPConnection) determineHealthState(
-	resp *http.Response,
-	status *HealthStatus,
+// tryGetRequest attempts a GET request when HEAD fails with 405.
+func (c *HTTPConnection) tryGetRequest(
 	ctx context.Context,
+	url string,
 	start time.Time,
-) {
-	// Try GET request if HEAD fails with 405 (Method Not Allowed)
-	if resp.StatusCode == http.StatusMethodNotAllowed {
-		if getStatus := c.tryGetRequest(ctx, start); getStatus != nil {
-			*status = *getStatus
-
-			return
-		}
-	}
-
-	// Use the common status setting logic
-	c.setStatusFromResponse(resp.StatusCode, status, "HEAD")
-}
-
-// tryGetRequest attempts a GET request when HEAD fails with 405.
-func (c *HTTPConnection) tryGetRequest(ctx context.Context, start time.Time) *HealthStatus {
-	getResp, err := c.performGetRequest(ctx)
+) *HealthStatus {
+	getResp, err := c.performGetRequest(ctx, url)
 	if err != nil || getResp == nil {
 		return nil
 	}
@@ -469,19 +628,14 @@ func (c *HTTPConnection) tryGetRequest(ctx context.Context, start time.Time) *He
 	}()
 
 	// Apply same logic as main health check for GET response
-	status := &HealthStatus{ //nolint:exhaustruct
-		Timestamp: start,
-		Latency:   time.Since(start),
-	}
-
-	c.setStatusFromResponse(getResp.StatusCode, status, "GET fallback")
-	c.lastHealth = start
-
-	return status
+	return httpHealthStatus(getResp.StatusCode, "GET fallback", start)
 }
 
-func (c *HTTPConnection) performGetRequest(ctx context.Context) (*http.Response, error) {
-	getReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL, nil)
+func (c *HTTPConnection) performGetRequest(
+	ctx context.Context,
+	url string,
+) (*http.Response, error) {
+	getReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrFailedToCreateGetRequest, err)
 	}
@@ -498,15 +652,14 @@ func (c *HTTPConnection) performGetRequest(ctx context.Context) (*http.Response,
 	return resp, nil
 }
 
-func (c *HTTPConnection) setStatusFromResponse(
-	statusCode int,
-	status *HealthStatus,
-	context string,
-) {
+func httpHealthStatus(statusCode int, context string, start time.Time) *HealthStatus {
+	status := &HealthStatus{ //nolint:exhaustruct
+		Timestamp: start,
+	}
+
 	switch {
 	case statusCode >= 200 && statusCode < 300:
 		// 2xx responses indicate service is ready
-		atomic.StoreInt32(&c.state, int32(ConnectionStateReady))
 		status.State = ConnectionStateReady
 		status.Message = fmt.Sprintf(
 			"HTTP service is live and ready (%s, status=%d)",
@@ -515,7 +668,6 @@ func (c *HTTPConnection) setStatusFromResponse(
 		)
 	case statusCode == http.StatusTooManyRequests:
 		// 429 means service is live but not ready (overloaded)
-		atomic.StoreInt32(&c.state, int32(ConnectionStateLive))
 		status.State = ConnectionStateLive
 		status.Message = fmt.Sprintf(
 			"HTTP service is live but overloaded (%s, status=%d)",
@@ -524,7 +676,6 @@ func (c *HTTPConnection) setStatusFromResponse(
 		)
 	case statusCode == http.StatusServiceUnavailable:
 		// 503 means service is connected but not live
-		atomic.StoreInt32(&c.state, int32(ConnectionStateConnected))
 		status.State = ConnectionStateConnected
 		status.Message = fmt.Sprintf(
 			"HTTP service connected but unavailable (%s, status=%d)",
@@ -533,7 +684,6 @@ func (c *HTTPConnection) setStatusFromResponse(
 		)
 	case statusCode >= 400 && statusCode < 500:
 		// 4xx errors indicate connected but configuration issues
-		atomic.StoreInt32(&c.state, int32(ConnectionStateConnected))
 		status.State = ConnectionStateConnected
 		status.Message = fmt.Sprintf(
 			"HTTP service connected with client error (%s, status=%d)",
@@ -542,8 +692,24 @@ func (c *HTTPConnection) setStatusFromResponse(
 		)
 	default:
 		// 5xx and other errors indicate service error
-		atomic.StoreInt32(&c.state, int32(ConnectionStateError))
 		status.State = ConnectionStateError
+		status.Error = fmt.Errorf("%w (status=%d)", ErrFailedToHealthCheckHTTP, statusCode)
 		status.Message = fmt.Sprintf("HTTP service error (%s, status=%d)", context, statusCode)
 	}
+
+	return status
+}
+
+// httpHealthRank orders health states from worst to best when comparing endpoints.
+func httpHealthRank(state ConnectionState) int {
+	switch state { //nolint:exhaustive
+	case ConnectionStateReady:
+		return 3 //nolint:mnd
+	case ConnectionStateLive:
+		return 2 //nolint:mnd
+	case ConnectionStateConnected:
+		return 1
+	default:
+		return 0
+	}
 }
@@ -0,0 +1,145 @@
+package connfx
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// DefaultHTTPEndpointWeight is the weight of endpoints configured without one.
+const DefaultHTTPEndpointWeight = 1
+
+var ErrInvalidHTTPEndpoint = errors.New("invalid HTTP endpoint")
+
+// HTTPEndpoint describes one of the URLs an HTTP connection distributes requests over.
+type HTTPEndpoint struct {
+	URL     string
+	Weight  int
+	Healthy bool
+}
+
+// httpEndpoint is an endpoint along with the outcome of its latest health check.
+type httpEndpoint struct {
+	url     string
+	weight  int
+	healthy atomic.Bool
+}
+
+func newHTTPEndpoint(url string, weight int) *httpEndpoint {
+	endpoint := &httpEndpoint{url: url, weight: weight} //nolint:exhaustruct
+
+	// Endpoints take traffic until a health check says otherwise
+	endpoint.healthy.Store(true)
+
+	return endpoint
+}
+
+// buildHTTPEndpoints reads the "endpoints" property, a list of URLs or of
+// {"url": ..., "weight": ...} maps, where the weight is a positive whole number given as a
+// number or a numeric string. Without it, config.URL is the only endpoint.
+func buildHTTPEndpoints(config *ConfigTarget) ([]*httpEndpoint, error) { //nolint:cyclop
+	var entries []any
+
+	switch value := config.Properties["endpoints"].(type) {
+	case nil:
+		return []*httpEndpoint{newHTTPEndpoint(config.URL, DefaultHTTPEndpointWeight)}, nil
+	case []any:
+		entries = value
+	case []string:
+		for _, url := range value {
+			entries = append(entries, url)
+		}
+	case []map[string]any:
+		for _, entry := range value {
+			entries = append(entries, entry)
+		}
+	default:
+		return nil, fmt.Errorf(
+			"%w: endpoints must be a list, got %T",
+			ErrInvalidHTTPEndpoint,
+			value,
+		)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: endpoints list is empty", ErrInvalidHTTPEndpoint)
+	}
+
+	endpoints := make([]*httpEndpoint, 0, len(entries))
+
+	for index, entry := range entries {
+		url := ""
+		weight := DefaultHTTPEndpointWeight
+
+		switch value := entry.(type) {
+		case string:
+			url = value
+		case map[string]any:
+			url, _ = value["url"].(string)
+
+			parsed, ok, err := intProperty(value, "weight")
+			if err != nil {
+				return nil, fmt.Errorf("%w (index=%d) %w", ErrInvalidHTTPEndpoint, index, err)
+			}
+
+			if ok && parsed == 0 {
+				return nil, fmt.Errorf(
+					"%w (index=%d): weight must be positive",
+					ErrInvalidHTTPEndpoint,
+					index,
+				)
+			}
+
+			if ok {
+				weight = parsed
+			}
+		}
+
+		if url == "" {
+			return nil, fmt.Errorf("%w (index=%d): missing url", ErrInvalidHTTPEndpoint, index)
+		}
+
+		endpoints = append(endpoints, newHTTPEndpoint(url, weight))
+	}
+
+	return endpoints, nil
+}
+
+// pickHTTPEndpoint chooses an endpoint at random in proportion to its weight, among the
+// healthy ones. When every endpoint is unhealthy, all of them are candidates again, so
+// requests still go out (and may succeed) rather than failing locally.
+func pickHTTPEndpoint(endpoints []*httpEndpoint) *httpEndpoint {
+	total := 0
+
+	for _, endpoint := range endpoints {
+		if endpoint.healthy.Load() {
+			total += endpoint.weight
+		}
+	}
+
+	healthyOnly := total > 0
+
+	if !healthyOnly {
+		for _, endpoint := range endpoints {
+			total += endpoint.weight
+		}
+	}
+
+	target := rand.IntN(total) //nolint:gosec
+
+	for _, endpoint := range endpoints {
+		if healthyOnly && !endpoint.healthy.Load() {
+			continue
+		}
+
+		if target < endpoint.weight {
+			return endpoint
+		}
+
+		target -= endpoint.weight
+	}
+
+	// Reachable only if health flipped between the two passes
+	return endpoints[len(endpoints)-1]
+}
@@ -0,0 +1,202 @@
+package connfx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSwitchableServer starts a server answering with the stored status code.
+func newSwitchableServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var status atomic.Int32
+
+	status.Store(http.StatusOK)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &status
+}
+
+// countRequestHosts builds n requests and counts how many target each host.
+func countRequestHosts(t *testing.T, conn *connfx.HTTPConnection, n int) map[string]int {
+	t.Helper()
+
+	counts := map[string]int{}
+
+	for range n {
+		req, err := conn.NewRequest(t.Context(), http.MethodGet, "/orders", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "/orders", req.URL.Path)
+
+		counts[req.URL.Host]++
+	}
+
+	return counts
+}
+
+func hostOf(t *testing.T, rawURL string) string {
+	t.Helper()
+
+	parsed, err := url.Parse(rawURL)
+	require.NoError(t, err)
+
+	return parsed.Host
+}
+
+func TestHTTPAdapterWeightedEndpoints(t *testing.T) {
+	t.Parallel()
+
+	small, _ := newSwitchableServer(t)
+	medium, _ := newSwitchableServer(t)
+	large, largeStatus := newSwitchableServer(t)
+
+	// No retries, so marking an endpoint unhealthy takes a single failed check
+	properties := map[string]any{
+		"endpoints": []any{
+			map[string]any{"url": small.URL, "weight": 1},
+			map[string]any{"url": medium.URL, "weight": 3.0}, // as decoded from JSON
+			map[string]any{"url": large.URL, "weight": "6"},  // as read from the environment
+		},
+		"retry_strategy": map[string]any{"enabled": false},
+	}
+
+	factory := connfx.NewHTTPConnectionFactory("http")
+
+	conn, err := factory.CreateConnection(t.Context(), &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol:   "http",
+		Properties: properties,
+	})
+	require.NoError(t, err)
+
+	httpConn, ok := conn.(*connfx.HTTPConnection)
+	require.True(t, ok)
+
+	assert.Equal(t, small.URL, httpConn.GetBaseURL())
+	assert.Equal(t, []connfx.HTTPEndpoint{
+		{URL: small.URL, Weight: 1, Healthy: true},
+		{URL: medium.URL, Weight: 3, Healthy: true},
+		{URL: large.URL, Weight: 6, Healthy: true},
+	}, httpConn.GetEndpoints())
+
+	const requests = 20000
+
+	counts := countRequestHosts(t, httpConn, requests)
+
+	assert.InDelta(t, 0.1, float64(counts[hostOf(t, small.URL)])/requests, 0.02)
+	assert.InDelta(t, 0.3, float64(counts[hostOf(t, medium.URL)])/requests, 0.02)
+	assert.InDelta(t, 0.6, float64(counts[hostOf(t, large.URL)])/requests, 0.02)
+
+	// The large endpoint fails its health check; the others split its share 1:3
+	largeStatus.Store(http.StatusInternalServerError)
+
+	status := httpConn.HealthCheck(t.Context())
+	assert.Equal(t, connfx.ConnectionStateReady, status.State)
+	assert.Contains(t, status.Message, "2/3 endpoints healthy")
+	assert.False(t, httpConn.GetEndpoints()[2].Healthy)
+
+	counts = countRequestHosts(t, httpConn, requests)
+
+	assert.Zero(t, counts[hostOf(t, large.URL)])
+	assert.InDelta(t, 0.25, float64(counts[hostOf(t, small.URL)])/requests, 0.02)
+	assert.InDelta(t, 0.75, float64(counts[hostOf(t, medium.URL)])/requests, 0.02)
+
+	// Once it recovers it takes traffic again
+	largeStatus.Store(http.StatusOK)
+
+	status = httpConn.HealthCheck(t.Context())
+	assert.Equal(t, connfx.ConnectionStateReady, status.State)
+	assert.True(t, httpConn.GetEndpoints()[2].Healthy)
+
+	counts = countRequestHosts(t, httpConn, requests)
+
+	assert.InDelta(t, 0.6, float64(counts[hostOf(t, large.URL)])/requests, 0.02)
+}
+
+func TestHTTPAdapterWeightedEndpoints_AllUnhealthy(t *testing.T) {
+	t.Parallel()
+
+	first, firstStatus := newSwitchableServer(t)
+	second, secondStatus := newSwitchableServer(t)
+
+	factory := connfx.NewHTTPConnectionFactory("http")
+
+	conn, err := factory.CreateConnection(t.Context(), &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "http",
+		Properties: map[string]any{
+			"endpoints":      []string{first.URL, second.URL},
+			"retry_strategy": map[string]any{"enabled": false},
+		},
+	})
+	require.NoError(t, err)
+
+	httpConn, ok := conn.(*connfx.HTTPConnection)
+	require.True(t, ok)
+
+	firstStatus.Store(http.StatusBadGateway)
+	secondStatus.Store(http.StatusBadGateway)
+
+	status := httpConn.HealthCheck(t.Context())
+	assert.Equal(t, connfx.ConnectionStateError, status.State)
+	require.ErrorIs(t, status.Error, connfx.ErrFailedToHealthCheckHTTP)
+	assert.Equal(t, connfx.ConnectionStateError, httpConn.GetState())
+
+	// With nothing healthy, requests still spread over every endpoint
+	counts := countRequestHosts(t, httpConn, 1000)
+
+	assert.Positive(t, counts[hostOf(t, first.URL)])
+	assert.Positive(t, counts[hostOf(t, second.URL)])
+}
+
+func TestHTTPAdapterWeightedEndpoints_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		endpoints any
+		name      string
+	}{
+		{name: "not a list", endpoints: "http://localhost"},
+		{name: "empty list", endpoints: []any{}},
+		{name: "missing url", endpoints: []any{map[string]any{"weight": 2}}},
+		{
+			name:      "zero weight",
+			endpoints: []any{map[string]any{"url": "http://localhost", "weight": 0}},
+		},
+		{
+			name:      "negative weight",
+			endpoints: []any{map[string]any{"url": "http://localhost", "weight": -2}},
+		},
+		{
+			name:      "fractional weight",
+			endpoints: []any{map[string]any{"url": "http://localhost", "weight": 1.5}},
+		},
+		{
+			name:      "non-integer weight",
+			endpoints: []any{map[string]any{"url": "http://localhost", "weight": "high"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			factory := connfx.NewHTTPConnectionFactory("http")
+
+			_, err := factory.CreateConnection(t.Context(), &connfx.ConfigTarget{ //nolint:exhaustruct
+				Protocol:   "http",
+				Properties: map[string]any{"endpoints": tt.endpoints},
+			})
+			require.ErrorIs(t, err, connfx.ErrInvalidHTTPEndpoint)
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package connfx_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/eser/ajan/connfx"
 	"github.com/eser/ajan/httpclient"
 	"github.com/eser/ajan/logfx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHTTPAdapterBasics(t *testing.T) {
@@ -67,3 +71,191 @@ func TestHTTPAdapterBasics(t *testing.T) {
 		assert.NotZero(t, client.Config.RetryStrategy.MaxAttempts)
 	})
 }
+
+func TestHTTPAdapterTransportSettings(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		properties map[string]any
+		name       string
+		expected   connfx.HTTPTransportSettings
+	}{
+		{
+			name:       "defaults",
+			properties: nil,
+			expected: connfx.HTTPTransportSettings{
+				MaxIdleConns:        connfx.DefaultHTTPMaxIdleConns,
+				MaxIdleConnsPerHost: connfx.DefaultHTTPMaxIdleConnsPerHost,
+				MaxConnsPerHost:     connfx.DefaultHTTPMaxConnsPerHost,
+				IdleConnTimeout:     connfx.DefaultHTTPIdleConnTimeout,
+			},
+		},
+		{
+			name: "from properties",
+			properties: map[string]any{
+				"transport": map[string]any{
+					"max_idle_conns":          200,
+					"max_idle_conns_per_host": 50,
+					"max_conns_per_host":      64,
+					"idle_conn_timeout":       30 * time.Second,
+				},
+			},
+			expected: connfx.HTTPTransportSettings{
+				MaxIdleConns:        200,
+				MaxIdleConnsPerHost: 50,
+				MaxConnsPerHost:     64,
+				IdleConnTimeout:     30 * time.Second,
+			},
+		},
+		{
+			name: "decoded from JSON or env",
+			properties: map[string]any{
+				"transport": map[string]any{
+					"max_idle_conns":          float64(200),
+					"max_idle_conns_per_host": "50",
+					"max_conns_per_host":      float64(64),
+					"idle_conn_timeout":       "30s",
+				},
+			},
+			expected: connfx.HTTPTransportSettings{
+				MaxIdleConns:        200,
+				MaxIdleConnsPerHost: 50,
+				MaxConnsPerHost:     64,
+				IdleConnTimeout:     30 * time.Second,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			factory := connfx.NewHTTPConnectionFactory("http")
+
+			conn, err := factory.CreateConnection(t.Context(), &connfx.ConfigTarget{ //nolint:exhaustruct
+				Protocol:   "http",
+				URL:        server.URL,
+				Properties: tt.properties,
+			})
+			require.NoError(t, err)
+
+			httpConn, ok := conn.(*connfx.HTTPConnection)
+			require.True(t, ok)
+
+			assert.Equal(t, tt.expected, httpConn.GetTransportSettings())
+
+			transport, ok := httpConn.GetClient().Transport.Transport.(*http.Transport)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected.MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+			assert.Equal(t, tt.expected.IdleConnTimeout, transport.IdleConnTimeout)
+		})
+	}
+}
+
+func TestHTTPAdapterResilienceSettings(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	factory := connfx.NewHTTPConnectionFactory("http")
+
+	conn, err := factory.CreateConnection(t.Context(), &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "http",
+		URL:      server.URL,
+		Properties: map[string]any{
+			"circuit_breaker": map[string]any{
+				"failure_threshold":        float64(7),
+				"reset_timeout":            "45s",
+				"half_open_success_needed": "3",
+			},
+			"retry_strategy": map[string]any{
+				"max_attempts":     "4",
+				"initial_interval": "250ms",
+				"max_interval":     5 * time.Second,
+			},
+			"server_error_threshold": float64(502),
+		},
+	})
+	require.NoError(t, err)
+
+	httpConn, ok := conn.(*connfx.HTTPConnection)
+	require.True(t, ok)
+
+	config := httpConn.GetClient().Config
+	assert.Equal(t, uint(7), config.CircuitBreaker.FailureThreshold)
+	assert.Equal(t, 45*time.Second, config.CircuitBreaker.ResetTimeout)
+	assert.Equal(t, uint(3), config.CircuitBreaker.HalfOpenSuccessNeeded)
+	assert.Equal(t, uint(4), config.RetryStrategy.MaxAttempts)
+	assert.Equal(t, 250*time.Millisecond, config.RetryStrategy.InitialInterval)
+	assert.Equal(t, 5*time.Second, config.RetryStrategy.MaxInterval)
+	assert.Equal(t, 502, config.ServerErrorThreshold)
+}
+
+func TestHTTPConnectionFactory_InvalidProperties(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		properties  map[string]any
+		name        string
+		expectedErr string
+	}{
+		{
+			name: "fractional_pool_size",
+			properties: map[string]any{
+				"transport": map[string]any{"max_idle_conns": 1.5},
+			},
+			expectedErr: `section="transport"`,
+		},
+		{
+			name: "malformed_idle_timeout",
+			properties: map[string]any{
+				"transport": map[string]any{"idle_conn_timeout": "soon"},
+			},
+			expectedErr: `property="idle_conn_timeout"`,
+		},
+		{
+			name: "negative_failure_threshold",
+			properties: map[string]any{
+				"circuit_breaker": map[string]any{"failure_threshold": "-1"},
+			},
+			expectedErr: `section="circuit_breaker"`,
+		},
+		{
+			name: "malformed_retry_interval",
+			properties: map[string]any{
+				"retry_strategy": map[string]any{"max_interval": "later"},
+			},
+			expectedErr: `property="max_interval"`,
+		},
+		{
+			name:        "non_numeric_threshold",
+			properties:  map[string]any{"server_error_threshold": "five hundred"},
+			expectedErr: `property="server_error_threshold"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			factory := connfx.NewHTTPConnectionFactory("http")
+
+			conn, err := factory.CreateConnection(t.Context(), &connfx.ConfigTarget{ //nolint:exhaustruct
+				Protocol:   "http",
+				URL:        "http://127.0.0.1:1",
+				Properties: tt.properties,
+			})
+			require.ErrorIs(t, err, connfx.ErrInvalidHTTPConfig)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+			assert.Nil(t, conn)
+		})
+	}
+}
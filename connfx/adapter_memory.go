@@ -0,0 +1,262 @@
+package connfx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrMemoryConnectionClosed = errors.New("memory connection is closed")
+	ErrMemoryEmptyPrefix      = errors.New("memory key prefix must not be empty")
+)
+
+// MemoryConnection is a key-value connection held in process memory. Nothing is shared
+// with other processes or kept across restarts, so it suits tests, local development and
+// single-instance deployments. Each connection has its own keyspace.
+type MemoryConnection struct {
+	adapter  *MemoryAdapter
+	protocol string
+	*stateTracker
+}
+
+// MemoryAdapter implements the repository ports of MemoryConnection on a map.
+type MemoryAdapter struct {
+	data   map[string][]byte
+	mu     sync.RWMutex
+	closed bool
+}
+
+// MemoryConnectionFactory creates memory connections.
+type MemoryConnectionFactory struct {
+	protocol string
+}
+
+// NewMemoryConnectionFactory creates a new memory connection factory for a specific protocol.
+func NewMemoryConnectionFactory(protocol string) *MemoryConnectionFactory {
+	return &MemoryConnectionFactory{
+		protocol: protocol,
+	}
+}
+
+// CreateConnection creates an empty memory connection. The configuration has nothing to
+// connect to, so it is not read.
+func (f *MemoryConnectionFactory) CreateConnection(
+	ctx context.Context,
+	config *ConfigTarget,
+) (Connection, error) {
+	return NewMemoryConnection(f.protocol), nil
+}
+
+func (f *MemoryConnectionFactory) GetProtocol() string {
+	return f.protocol
+}
+
+// NewMemoryConnection creates an empty memory connection.
+func NewMemoryConnection(protocol string) *MemoryConnection {
+	return &MemoryConnection{
+		adapter: &MemoryAdapter{
+			data:   make(map[string][]byte),
+			mu:     sync.RWMutex{},
+			closed: false,
+		},
+		protocol:     protocol,
+		stateTracker: newStateTracker(ConnectionStateReady),
+	}
+}
+
+// Connection interface implementation.
+
+func (mc *MemoryConnection) GetBehaviors() []ConnectionBehavior {
+	return []ConnectionBehavior{ConnectionBehaviorStateful}
+}
+
+func (mc *MemoryConnection) GetCapabilities() []ConnectionCapability {
+	return []ConnectionCapability{ConnectionCapabilityKeyValue}
+}
+
+func (mc *MemoryConnection) GetProtocol() string {
+	return mc.protocol
+}
+
+func (mc *MemoryConnection) GetState() ConnectionState {
+	return mc.loadState()
+}
+
+func (mc *MemoryConnection) HealthCheck(ctx context.Context) *HealthStatus {
+	status := &HealthStatus{
+		Timestamp: time.Now(),
+		State:     mc.GetState(),
+		Error:     nil,
+		Message:   "In-memory store",
+		Latency:   0,
+	}
+
+	if status.State == ConnectionStateDisconnected {
+		status.Error = ErrMemoryConnectionClosed
+		status.Message = "In-memory store is closed"
+	}
+
+	return status
+}
+
+// Close discards the stored data; later operations fail with ErrMemoryConnectionClosed.
+func (mc *MemoryConnection) Close(ctx context.Context) error {
+	mc.adapter.mu.Lock()
+	mc.adapter.data = nil
+	mc.adapter.closed = true
+	mc.adapter.mu.Unlock()
+
+	mc.setState(ConnectionStateDisconnected, nil)
+
+	return nil
+}
+
+// GetRawConnection returns the *MemoryAdapter implementing the repository ports.
+func (mc *MemoryConnection) GetRawConnection() any {
+	return mc.adapter
+}
+
+// Repository interface implementation.
+
+func (ma *MemoryAdapter) Get(ctx context.Context, key string) ([]byte, error) {
+	ma.mu.RLock()
+	defer ma.mu.RUnlock()
+
+	if ma.closed {
+		return nil, fmt.Errorf("%w (key=%q)", ErrMemoryConnectionClosed, key)
+	}
+
+	value, ok := ma.data[key]
+	if !ok {
+		return nil, nil // Key doesn't exist, return nil without error
+	}
+
+	return bytes.Clone(value), nil
+}
+
+func (ma *MemoryAdapter) Set(ctx context.Context, key string, value []byte) error {
+	ma.mu.Lock()
+	defer ma.mu.Unlock()
+
+	if ma.closed {
+		return fmt.Errorf("%w (key=%q)", ErrMemoryConnectionClosed, key)
+	}
+
+	ma.data[key] = bytes.Clone(value)
+
+	return nil
+}
+
+func (ma *MemoryAdapter) Remove(ctx context.Context, key string) error {
+	ma.mu.Lock()
+	defer ma.mu.Unlock()
+
+	if ma.closed {
+		return fmt.Errorf("%w (key=%q)", ErrMemoryConnectionClosed, key)
+	}
+
+	delete(ma.data, key)
+
+	return nil
+}
+
+func (ma *MemoryAdapter) Update(ctx context.Context, key string, value []byte) error {
+	// As for Redis, update is the same as set
+	return ma.Set(ctx, key, value)
+}
+
+func (ma *MemoryAdapter) Exists(ctx context.Context, key string) (bool, error) {
+	ma.mu.RLock()
+	defer ma.mu.RUnlock()
+
+	if ma.closed {
+		return false, fmt.Errorf("%w (key=%q)", ErrMemoryConnectionClosed, key)
+	}
+
+	_, ok := ma.data[key]
+
+	return ok, nil
+}
+
+// CASRepository interface implementation.
+
+func (ma *MemoryAdapter) CompareAndSwap(
+	ctx context.Context,
+	key string,
+	expected []byte,
+	newValue []byte,
+) (bool, error) {
+	ma.mu.Lock()
+	defer ma.mu.Unlock()
+
+	if ma.closed {
+		return false, fmt.Errorf("%w (key=%q)", ErrMemoryConnectionClosed, key)
+	}
+
+	current, ok := ma.data[key]
+
+	if expected == nil {
+		if ok {
+			return false, nil
+		}
+	} else if !ok || !bytes.Equal(current, expected) {
+		return false, nil
+	}
+
+	ma.data[key] = bytes.Clone(newValue)
+
+	return true, nil
+}
+
+// ScanRepository interface implementation.
+
+func (ma *MemoryAdapter) Keys(ctx context.Context, prefix string) ([]string, error) {
+	ma.mu.RLock()
+	defer ma.mu.RUnlock()
+
+	if ma.closed {
+		return nil, fmt.Errorf("%w (prefix=%q)", ErrMemoryConnectionClosed, prefix)
+	}
+
+	keys := make([]string, 0)
+
+	for key := range ma.data {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// DeleteByPrefix removes every key starting with prefix. An empty prefix would clear the
+// whole keyspace, so it is rejected with ErrMemoryEmptyPrefix.
+func (ma *MemoryAdapter) DeleteByPrefix(ctx context.Context, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, fmt.Errorf("%w (operation=delete_by_prefix)", ErrMemoryEmptyPrefix)
+	}
+
+	ma.mu.Lock()
+	defer ma.mu.Unlock()
+
+	if ma.closed {
+		return 0, fmt.Errorf("%w (prefix=%q)", ErrMemoryConnectionClosed, prefix)
+	}
+
+	deleted := 0
+
+	for key := range ma.data {
+		if strings.HasPrefix(key, prefix) {
+			delete(ma.data, key)
+
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
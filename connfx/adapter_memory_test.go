@@ -0,0 +1,135 @@
+package connfx_test
+
+import (
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/connfx/conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemoryAdapter(t *testing.T) (*connfx.MemoryConnection, *connfx.MemoryAdapter) {
+	t.Helper()
+
+	conn, err := connfx.NewMemoryConnectionFactory("memory").CreateConnection(
+		t.Context(),
+		&connfx.ConfigTarget{Protocol: "memory"}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	memoryConn, ok := conn.(*connfx.MemoryConnection)
+	require.True(t, ok)
+
+	adapter, ok := memoryConn.GetRawConnection().(*connfx.MemoryAdapter)
+	require.True(t, ok)
+
+	return memoryConn, adapter
+}
+
+func TestMemoryConnection_Conformance(t *testing.T) {
+	t.Parallel()
+
+	conn, _ := newMemoryAdapter(t)
+
+	conformance.AssertConnection(t, conn)
+	assert.Equal(t, connfx.ConnectionStateReady, conn.HealthCheck(t.Context()).State)
+}
+
+func TestMemoryAdapter_KeyValue(t *testing.T) {
+	t.Parallel()
+
+	conn, adapter := newMemoryAdapter(t)
+	ctx := t.Context()
+
+	value, err := adapter.Get(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	stored := []byte("alice")
+	require.NoError(t, adapter.Set(ctx, "user:1", stored))
+
+	// The stored value does not alias the caller's slice
+	stored[0] = 'A'
+
+	value, err = adapter.Get(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("alice"), value)
+
+	require.NoError(t, adapter.Update(ctx, "user:1", []byte("bob")))
+
+	exists, err := adapter.Exists(ctx, "user:1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, adapter.Remove(ctx, "user:1"))
+
+	exists, err = adapter.Exists(ctx, "user:1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, conn.Close(ctx))
+	assert.Equal(t, connfx.ConnectionStateDisconnected, conn.GetState())
+
+	_, err = adapter.Get(ctx, "user:1")
+	require.ErrorIs(t, err, connfx.ErrMemoryConnectionClosed)
+}
+
+func TestMemoryAdapter_CompareAndSwap(t *testing.T) {
+	t.Parallel()
+
+	_, adapter := newMemoryAdapter(t)
+	ctx := t.Context()
+
+	// A nil expected value only creates a missing key
+	swapped, err := adapter.CompareAndSwap(ctx, "counter", nil, []byte("1"))
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	swapped, err = adapter.CompareAndSwap(ctx, "counter", nil, []byte("9"))
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = adapter.CompareAndSwap(ctx, "counter", []byte("1"), []byte("2"))
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	// A stale expected value conflicts and leaves the key alone
+	swapped, err = adapter.CompareAndSwap(ctx, "counter", []byte("1"), []byte("3"))
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	value, err := adapter.Get(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2"), value)
+}
+
+func TestMemoryAdapter_ScanByPrefix(t *testing.T) {
+	t.Parallel()
+
+	_, adapter := newMemoryAdapter(t)
+	ctx := t.Context()
+
+	for _, key := range []string{"user:1", "user:2", "User:3", "order:1"} {
+		require.NoError(t, adapter.Set(ctx, key, []byte("v")))
+	}
+
+	keys, err := adapter.Keys(ctx, "user:")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "user:2"}, keys)
+
+	keys, err = adapter.Keys(ctx, "missing:")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	deleted, err := adapter.DeleteByPrefix(ctx, "user:")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	keys, err = adapter.Keys(ctx, "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"User:3", "order:1"}, keys)
+
+	_, err = adapter.DeleteByPrefix(ctx, "")
+	require.ErrorIs(t, err, connfx.ErrMemoryEmptyPrefix)
+}
@@ -0,0 +1,107 @@
+//go:build integration
+
+package connfx_test
+
+import (
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/mongodb"
+)
+
+// newMongoConnection starts a MongoDB container and connects to it.
+// Run with: go test -tags integration ./connfx/...
+func newMongoConnection(t *testing.T) connfx.Connection {
+	t.Helper()
+
+	container, err := mongodb.Run(t.Context(), "mongo:7")
+	testcontainers.CleanupContainer(t, container)
+	require.NoError(t, err)
+
+	uri, err := container.ConnectionString(t.Context())
+	require.NoError(t, err)
+
+	conn, err := connfx.NewMongoConnectionFactory("mongo").CreateConnection(
+		t.Context(),
+		&connfx.ConfigTarget{ //nolint:exhaustruct
+			Protocol:   "mongo",
+			DSN:        uri,
+			Properties: map[string]any{"database": "app", "collection": "invoices"},
+		},
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close(t.Context()) })
+
+	return conn
+}
+
+func TestMongoAdapter_Store(t *testing.T) {
+	t.Parallel()
+
+	conn := newMongoConnection(t)
+	ctx := t.Context()
+
+	assert.Equal(t, connfx.ConnectionStateReady, conn.HealthCheck(ctx).State)
+
+	store, err := datafx.NewStore(conn)
+	require.NoError(t, err)
+
+	type invoice struct {
+		Customer string `json:"customer"`
+		Total    int    `json:"total"`
+	}
+
+	require.NoError(t, store.Set(ctx, "invoice-1", invoice{Customer: "acme", Total: 42}))
+
+	var loaded invoice
+
+	require.NoError(t, store.Get(ctx, "invoice-1", &loaded))
+	assert.Equal(t, invoice{Customer: "acme", Total: 42}, loaded)
+
+	require.NoError(t, store.Update(ctx, "invoice-1", invoice{Customer: "acme", Total: 50}))
+	require.NoError(t, store.Get(ctx, "invoice-1", &loaded))
+	assert.Equal(t, 50, loaded.Total)
+
+	exists, err := store.Exists(ctx, "invoice-1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, store.Remove(ctx, "invoice-1"))
+
+	exists, err = store.Exists(ctx, "invoice-1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.ErrorIs(t, store.Get(ctx, "invoice-1", &loaded), datafx.ErrKeyNotFound)
+	require.ErrorIs(t, store.Update(ctx, "invoice-1", loaded), connfx.ErrMongoDocumentNotFound)
+}
+
+func TestMongoAdapter_NonObjectValues(t *testing.T) {
+	t.Parallel()
+
+	adapter, err := connfx.GetTypedConnection[*connfx.MongoAdapter](newMongoConnection(t))
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	for key, value := range map[string][]byte{
+		"text":   []byte("plain text"),
+		"array":  []byte(`[1,2,3]`),
+		"binary": {0x00, 0xff, 0x10},
+	} {
+		require.NoError(t, adapter.Set(ctx, key, value))
+
+		stored, err := adapter.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, value, stored, key)
+	}
+
+	missing, err := adapter.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
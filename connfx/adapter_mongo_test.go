@@ -0,0 +1,97 @@
+package connfx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/connfx/conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoConnection_Conformance(t *testing.T) {
+	t.Parallel()
+
+	conn := connfx.NewMongoConnection("mongo", nil)
+
+	conformance.AssertConnection(t, conn)
+
+	// The raw connection is the adapter implementing the repository ports
+	adapter, err := connfx.GetTypedConnection[*connfx.MongoAdapter](conn)
+	require.NoError(t, err)
+	assert.NotNil(t, adapter)
+}
+
+func TestMongoConnectionFactory_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expectedErr error
+		config      *connfx.ConfigTarget
+		name        string
+	}{
+		{
+			name: "missing DSN",
+			config: &connfx.ConfigTarget{ //nolint:exhaustruct
+				Properties: map[string]any{"database": "app"},
+			},
+			expectedErr: connfx.ErrInvalidMongoConfig,
+		},
+		{
+			name: "missing database",
+			config: &connfx.ConfigTarget{ //nolint:exhaustruct
+				DSN: "mongodb://localhost:27017",
+			},
+			expectedErr: connfx.ErrInvalidMongoConfig,
+		},
+		{
+			name: "malformed DSN",
+			config: &connfx.ConfigTarget{ //nolint:exhaustruct
+				DSN:        "mongodb://user:secret@",
+				Properties: map[string]any{"database": "app"},
+			},
+			expectedErr: connfx.ErrMongoConnectionFailed,
+		},
+		{
+			name: "unreachable server",
+			config: &connfx.ConfigTarget{ //nolint:exhaustruct
+				DSN:        "mongodb://127.0.0.1:1",
+				Timeout:    200 * time.Millisecond,
+				Properties: map[string]any{"database": "app"},
+			},
+			expectedErr: connfx.ErrMongoConnectionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			factory := connfx.NewMongoConnectionFactory("mongo")
+
+			conn, err := factory.CreateConnection(t.Context(), tt.config)
+			require.ErrorIs(t, err, tt.expectedErr)
+			assert.Nil(t, conn)
+			assert.NotContains(t, err.Error(), "secret")
+		})
+	}
+}
+
+func TestMongoAdapter_RejectsReservedIDField(t *testing.T) {
+	t.Parallel()
+
+	adapter, err := connfx.GetTypedConnection[*connfx.MongoAdapter](
+		connfx.NewMongoConnection("mongo", nil),
+	)
+	require.NoError(t, err)
+
+	// The key is the document's _id, so a value cannot bring its own
+	value := []byte(`{"_id": "other", "total": 42}`)
+
+	err = adapter.Set(t.Context(), "invoice-42", value)
+	require.ErrorIs(t, err, connfx.ErrMongoReservedField)
+
+	err = adapter.Update(t.Context(), "invoice-42", value)
+	require.ErrorIs(t, err, connfx.ErrMongoReservedField)
+}
@@ -0,0 +1,199 @@
+package connfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsPubSubBuffer is how many received messages a session holds for a slow reader.
+const natsPubSubBuffer = 256
+
+// PubSubRepository interface implementation.
+
+// PublishToChannel publishes a payload to the NATS subject channel with core NATS, so
+// only current subscribers receive it; unlike Publish, it does not go through JetStream.
+func (na *NATSAdapter) PublishToChannel(
+	ctx context.Context,
+	channel string,
+	payload []byte,
+) error {
+	if na.client == nil {
+		return fmt.Errorf("%w (channel=%q)", ErrNATSClientNotInitialized, channel)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf(
+			"%w (operation=publish_to_channel, channel=%q): %w",
+			ErrNATSOperation,
+			channel,
+			err,
+		)
+	}
+
+	if err := na.client.Publish(channel, payload); err != nil {
+		return fmt.Errorf(
+			"%w (operation=publish_to_channel, channel=%q): %w",
+			ErrNATSOperation,
+			channel,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Subscribe subscribes to NATS subjects; channels may use the "*" and ">" wildcards.
+// The NATS client restores the subscriptions itself after reconnecting.
+func (na *NATSAdapter) Subscribe(ctx context.Context, channels ...string) (*Subscription, error) {
+	if na.client == nil {
+		return nil, fmt.Errorf("%w (channels=%q)", ErrNATSClientNotInitialized, channels)
+	}
+
+	return NewSubscription(ctx, na.dialPubSub, channels...)
+}
+
+func (na *NATSAdapter) dialPubSub(ctx context.Context) (PubSubSession, error) {
+	if na.client.IsClosed() {
+		return nil, fmt.Errorf(
+			"%w (operation=subscribe): %w",
+			ErrNATSOperation,
+			nats.ErrConnectionClosed,
+		)
+	}
+
+	return &natsPubSubSession{
+		client:        na.client,
+		flushTimeout:  getOrDefault(na.config.Timeout, nats.DefaultTimeout),
+		messages:      make(chan *nats.Msg, natsPubSubBuffer),
+		subscriptions: make(map[string]*nats.Subscription),
+		closed:        make(chan struct{}),
+		closeOnce:     sync.Once{},
+		mu:            sync.Mutex{},
+	}, nil
+}
+
+// natsPubSubSession adapts core NATS subscriptions sharing one message channel to
+// PubSubSession.
+type natsPubSubSession struct {
+	client        *nats.Conn
+	flushTimeout  time.Duration
+	messages      chan *nats.Msg
+	subscriptions map[string]*nats.Subscription
+	closed        chan struct{}
+	closeOnce     sync.Once
+	mu            sync.Mutex
+}
+
+func (s *natsPubSubSession) Subscribe(ctx context.Context, channels ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, channel := range channels {
+		if _, exists := s.subscriptions[channel]; exists {
+			continue
+		}
+
+		subscription, err := s.client.ChanSubscribe(channel, s.messages)
+		if err != nil {
+			return fmt.Errorf(
+				"%w (operation=subscribe, channel=%q): %w",
+				ErrNATSOperation,
+				channel,
+				err,
+			)
+		}
+
+		s.subscriptions[channel] = subscription
+	}
+
+	// Make sure the server registered the interest before publishers are told to go
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, s.flushTimeout)
+		defer cancel()
+	}
+
+	if err := s.client.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("%w (operation=subscribe): %w", ErrNATSOperation, err)
+	}
+
+	return nil
+}
+
+func (s *natsPubSubSession) Unsubscribe(ctx context.Context, channels ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+
+	for _, channel := range channels {
+		subscription, exists := s.subscriptions[channel]
+		if !exists {
+			continue
+		}
+
+		delete(s.subscriptions, channel)
+
+		if err := subscription.Unsubscribe(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w (operation=unsubscribe): %w", ErrNATSOperation, err)
+	}
+
+	return nil
+}
+
+func (s *natsPubSubSession) Receive(ctx context.Context) (PubSubMessage, error) {
+	select {
+	case msg := <-s.messages:
+		return PubSubMessage{
+			Channel: msg.Subject,
+			Payload: msg.Data,
+		}, nil
+	case <-s.closed:
+		return PubSubMessage{}, ErrSubscriptionClosed
+	case <-ctx.Done():
+		return PubSubMessage{}, fmt.Errorf(
+			"%w (operation=receive): %w",
+			ErrNATSOperation,
+			ctx.Err(),
+		)
+	}
+}
+
+func (s *natsPubSubSession) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+
+	for channel, subscription := range s.subscriptions {
+		delete(s.subscriptions, channel)
+
+		// The subscription is gone already if the client was closed
+		err := subscription.Unsubscribe()
+		if err != nil && !errors.Is(err, nats.ErrConnectionClosed) &&
+			!errors.Is(err, nats.ErrBadSubscription) {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w (operation=close_pubsub): %w", ErrNATSOperation, err)
+	}
+
+	return nil
+}
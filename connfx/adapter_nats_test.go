@@ -0,0 +1,443 @@
+package connfx_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/connfx/conformance"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runNATSServer starts an in-process NATS server with JetStream on a random port.
+func runNATSServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	natsServer, err := server.NewServer(&server.Options{ //nolint:exhaustruct
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	go natsServer.Start()
+
+	t.Cleanup(natsServer.Shutdown)
+
+	require.True(t, natsServer.ReadyForConnections(5*time.Second), "NATS server did not start")
+
+	return natsServer
+}
+
+func newNATSConnection(
+	t *testing.T,
+	config *connfx.ConfigTarget,
+) (connfx.Connection, *connfx.NATSAdapter) {
+	t.Helper()
+
+	conn, err := connfx.NewNATSConnectionFactory("nats").CreateConnection(t.Context(), config)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close(context.Background())
+	})
+
+	adapter, err := connfx.GetTypedConnection[*connfx.NATSAdapter](conn)
+	require.NoError(t, err)
+
+	return conn, adapter
+}
+
+func receiveMessage(
+	t *testing.T,
+	messages <-chan connfx.Message,
+	errs <-chan error,
+) connfx.Message {
+	t.Helper()
+
+	select {
+	case msg := <-messages:
+		return msg
+	case err := <-errs:
+		require.FailNow(t, "unexpected consumer error", err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for a message")
+	}
+
+	return connfx.Message{} //nolint:exhaustruct
+}
+
+func TestNATSConnection_Conformance(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+
+	conn, _ := newNATSConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		DSN: natsServer.ClientURL(),
+	})
+
+	conformance.AssertConnection(t, conn)
+
+	var _ connfx.QueueStreamRepository = (*connfx.NATSAdapter)(nil)
+	var _ connfx.PubSubRepository = (*connfx.NATSAdapter)(nil)
+
+	assert.Equal(t, "nats", conn.GetProtocol())
+	assert.ElementsMatch(t, []connfx.ConnectionBehavior{
+		connfx.ConnectionBehaviorStateful,
+		connfx.ConnectionBehaviorStreaming,
+	}, conn.GetBehaviors())
+	assert.Equal(t, connfx.ConnectionStateReady, conn.GetState())
+}
+
+func TestNATSAdapter_GroupDeliveryConformance(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+
+	_, adapter := newNATSConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		DSN: natsServer.ClientURL(),
+	})
+
+	conformance.AssertGroupDelivery(t, adapter, "orders")
+}
+
+func TestNATSConnectionFactory_HostPort(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+	host, port, err := net.SplitHostPort(natsServer.Addr().String())
+	require.NoError(t, err)
+
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	conn, _ := newNATSConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		Host: host,
+		Port: portNumber,
+	})
+
+	status := conn.HealthCheck(t.Context())
+	require.NoError(t, status.Error)
+	assert.Equal(t, connfx.ConnectionStateReady, status.State)
+}
+
+func TestNATSConnection_HealthCheckWhileReconnecting(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+
+	conn, _ := newNATSConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		DSN: natsServer.ClientURL(),
+	})
+
+	natsServer.Shutdown()
+
+	require.Eventually(t, func() bool {
+		return conn.GetState() == connfx.ConnectionStateReconnecting
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status := conn.HealthCheck(t.Context())
+	assert.Equal(t, connfx.ConnectionStateReconnecting, status.State)
+}
+
+func TestNATSAdapter_ConsumeWithGroup(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+	_, adapter := newNATSConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		DSN: natsServer.ClientURL(),
+	})
+
+	ctx := t.Context()
+
+	const published = 4
+
+	for i := range published {
+		err := adapter.PublishWithHeaders(
+			ctx,
+			"orders.created",
+			fmt.Appendf(nil, "order-%d", i),
+			map[string]any{"attempt": i},
+		)
+		require.NoError(t, err)
+	}
+
+	// Each group receives every message, starting from the beginning of the stream
+	for _, group := range []string{"billing", "shipping"} {
+		messages, errs := adapter.ConsumeWithGroup(
+			ctx,
+			"orders.created",
+			group,
+			group+"-1",
+			connfx.DefaultConsumerConfig(),
+		)
+
+		for i := range published {
+			msg := receiveMessage(t, messages, errs)
+
+			assert.Equal(t, fmt.Sprintf("order-%d", i), string(msg.Body))
+			assert.Equal(t, strconv.Itoa(i), msg.Headers["attempt"])
+			assert.Equal(t, group, msg.ConsumerGroup)
+			assert.Equal(t, "orders.created", msg.StreamName)
+			assert.Equal(t, strconv.Itoa(i+1), msg.MessageID)
+			assert.Equal(t, 1, msg.DeliveryCount)
+
+			require.NoError(t, adapter.AckMessage(ctx, "orders.created", group, msg.ReceiptHandle))
+		}
+	}
+
+	groups, err := adapter.ConsumerGroupInfo(ctx, "orders.created")
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+
+	for _, group := range groups {
+		assert.Equal(t, int64(0), group.Pending, "group %s", group.Name)
+		assert.Equal(t, int64(0), group.Lag, "group %s", group.Name)
+		assert.Equal(t, strconv.Itoa(published), group.LastDeliveredID, "group %s", group.Name)
+	}
+}
+
+func TestNATSAdapter_Consume(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+	_, adapter := newNATSConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		DSN: natsServer.ClientURL(),
+	})
+
+	ctx := t.Context()
+
+	require.NoError(t, adapter.Publish(ctx, "events", []byte("before")))
+
+	messages, errs := adapter.Consume(ctx, "events", connfx.DefaultConsumerConfig())
+
+	// Plain consumers only receive messages published after they start
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.NoError(c, adapter.Publish(ctx, "events", []byte("after")))
+
+		select {
+		case msg := <-messages:
+			assert.Equal(c, "after", string(msg.Body))
+			assert.NoError(c, msg.Ack())
+		case err := <-errs:
+			assert.NoError(c, err)
+		case <-time.After(100 * time.Millisecond):
+			assert.Fail(c, "no message yet")
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNATSAdapter_PubSub(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+	_, adapter := newNATSConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		DSN: natsServer.ClientURL(),
+	})
+
+	ctx := t.Context()
+
+	subscription, err := adapter.Subscribe(ctx, "orders.*")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = subscription.Close()
+	})
+
+	// Subscribing flushes, so messages published right after are delivered
+	require.NoError(t, adapter.PublishToChannel(ctx, "orders.created", []byte("7")))
+	require.NoError(t, adapter.PublishToChannel(ctx, "invoices.paid", []byte("8")))
+
+	select {
+	case msg := <-subscription.Messages():
+		assert.Equal(t, "orders.created", msg.Channel)
+		assert.Equal(t, "7", string(msg.Payload))
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for a message")
+	}
+
+	require.NoError(t, subscription.Subscribe(ctx, "invoices.paid"))
+	require.NoError(t, subscription.Unsubscribe(ctx, "orders.*"))
+
+	require.NoError(t, adapter.PublishToChannel(ctx, "orders.created", []byte("9")))
+	require.NoError(t, adapter.PublishToChannel(ctx, "invoices.paid", []byte("10")))
+
+	select {
+	case msg := <-subscription.Messages():
+		assert.Equal(t, "invoices.paid", msg.Channel)
+		assert.Equal(t, "10", string(msg.Payload))
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for a message")
+	}
+
+	require.NoError(t, subscription.Close())
+
+	_, open := <-subscription.Messages()
+	assert.False(t, open)
+}
+
+func TestNATSAdapter_ClaimPendingMessages(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+	_, adapter := newNATSConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		DSN: natsServer.ClientURL(),
+	})
+
+	ctx := t.Context()
+
+	require.NoError(t, adapter.Publish(ctx, "payments", []byte("payment-1")))
+
+	config := connfx.DefaultConsumerConfig()
+	config.VisibilityTimeout = 200 * time.Millisecond
+
+	// A worker takes the message and stalls without settling it
+	consumerCtx, cancel := context.WithCancel(ctx)
+	messages, errs := adapter.ConsumeWithGroup(
+		consumerCtx,
+		"payments",
+		"ledger",
+		"worker-1",
+		config,
+	)
+	stalled := receiveMessage(t, messages, errs)
+	cancel()
+
+	// Once the ack wait has passed, another worker claims it
+	var claimed []connfx.Message
+
+	require.Eventually(t, func() bool {
+		var err error
+
+		claimed, err = adapter.ClaimPendingMessages(ctx, "payments", "ledger", "worker-2", 0, 10)
+
+		return err == nil && len(claimed) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	assert.Equal(t, "payment-1", string(claimed[0].Body))
+	assert.Equal(t, 2, claimed[0].DeliveryCount)
+	assert.Equal(t, stalled.MessageID, claimed[0].MessageID)
+
+	err := adapter.AckMessage(ctx, "payments", "billing", claimed[0].ReceiptHandle)
+	require.ErrorIs(t, err, connfx.ErrInvalidNATSReceiptHandle)
+
+	require.NoError(t, adapter.AckMessage(ctx, "payments", "ledger", claimed[0].ReceiptHandle))
+
+	claimed, err = adapter.ClaimPendingMessages(ctx, "payments", "ledger", "worker-2", 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestNATSAdapter_Streams(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+	_, adapter := newNATSConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		DSN: natsServer.ClientURL(),
+	})
+
+	ctx := t.Context()
+
+	for i := range 5 {
+		require.NoError(t, adapter.Publish(ctx, "audit.log", fmt.Appendf(nil, "entry-%d", i)))
+	}
+
+	require.NoError(t, adapter.CreateConsumerGroup(ctx, "audit.log", "archiver", "3"))
+	require.NoError(t, adapter.CreateConsumerGroup(ctx, "audit.log", "archiver", "3"))
+
+	err := adapter.CreateConsumerGroup(ctx, "audit.log", "indexer", "latest")
+	require.ErrorIs(t, err, connfx.ErrInvalidNATSStartID)
+
+	info, err := adapter.StreamInfo(ctx, "audit.log")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), info.Length)
+	assert.Equal(t, int64(1), info.Groups)
+	assert.Equal(t, "1", info.RecordedFirstID)
+	assert.Equal(t, "5", info.LastGeneratedID)
+	assert.Equal(t, "audit_2Elog", info.Metadata["stream"])
+
+	// The group starts at sequence 3
+	groups, err := adapter.ConsumerGroupInfo(ctx, "audit.log")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "archiver", groups[0].Name)
+	assert.Equal(t, int64(3), groups[0].Lag)
+
+	claimed, err := adapter.ClaimPendingMessages(ctx, "audit.log", "archiver", "worker", 0, 1)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, "entry-2", string(claimed[0].Body))
+
+	require.NoError(t, adapter.DeleteMessage(ctx, "audit.log", claimed[0].ReceiptHandle))
+
+	require.NoError(t, adapter.TrimStream(ctx, "audit.log", 2))
+
+	info, err = adapter.StreamInfo(ctx, "audit.log")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), info.Length)
+	assert.Equal(t, "4", info.RecordedFirstID)
+}
+
+func TestNATSAdapter_KeepsExistingStream(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+	config := &connfx.ConfigTarget{DSN: natsServer.ClientURL()} //nolint:exhaustruct
+	ctx := t.Context()
+
+	// An operator sets up the stream with its own storage and limits
+	_, operator := newNATSConnection(t, config)
+
+	_, err := operator.QueueDeclareWithConfig(ctx, "orders.created", connfx.QueueConfig{
+		Durable:   false,
+		MaxLength: 3,
+	})
+	require.NoError(t, err)
+
+	// A fresh client publishes and declares with the defaults
+	_, adapter := newNATSConnection(t, config)
+
+	for i := range 5 {
+		require.NoError(t, adapter.Publish(ctx, "orders.created", fmt.Appendf(nil, "order-%d", i)))
+	}
+
+	_, err = adapter.QueueDeclare(ctx, "orders.created")
+	require.NoError(t, err)
+
+	info, err := adapter.StreamInfo(ctx, "orders.created")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), info.Length, "the stream keeps its message limit")
+}
+
+func TestNATSAdapter_StreamNamesDoNotCollide(t *testing.T) {
+	t.Parallel()
+
+	natsServer := runNATSServer(t)
+	_, adapter := newNATSConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		DSN: natsServer.ClientURL(),
+	})
+	ctx := t.Context()
+
+	require.NoError(t, adapter.Publish(ctx, "a.b", []byte("dotted")))
+	require.NoError(t, adapter.Publish(ctx, "a_b", []byte("underscored")))
+
+	dotted, err := adapter.StreamInfo(ctx, "a.b")
+	require.NoError(t, err)
+
+	underscored, err := adapter.StreamInfo(ctx, "a_b")
+	require.NoError(t, err)
+
+	assert.Equal(t, "a_2Eb", dotted.Metadata["stream"])
+	assert.Equal(t, "a_5Fb", underscored.Metadata["stream"])
+	assert.Equal(t, int64(1), dotted.Length)
+	assert.Equal(t, int64(1), underscored.Length)
+}
@@ -2,9 +2,13 @@ package connfx
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
-	"sync/atomic"
+	"maps"
+	"os"
+	"slices"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -16,7 +20,9 @@ import (
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -27,6 +33,31 @@ const (
 	DefaultSampleRatio     = 1.0
 	HealthCheckRequestPath = "/v1/traces" // Standard OTLP path for health check
 	MinimumReadMemInterval = 15 * time.Second
+
+	// healthCheckSpanName names the span exported by deep health checks.
+	healthCheckSpanName = "connfx.otlp.health_check"
+)
+
+// Transports selected with the transport property of an OTLP connection.
+const (
+	// OTLPTransportHTTP exports over OTLP/HTTP, usually on port 4318 (the default).
+	OTLPTransportHTTP = "http"
+	// OTLPTransportGRPC exports over OTLP/gRPC, usually on port 4317.
+	OTLPTransportGRPC = "grpc"
+)
+
+// Trace samplers selected with the sampler property of an OTLP connection.
+const (
+	// OTLPSamplerAlwaysOn records every trace.
+	OTLPSamplerAlwaysOn = "always_on"
+	// OTLPSamplerAlwaysOff records no traces.
+	OTLPSamplerAlwaysOff = "always_off"
+	// OTLPSamplerTraceIDRatio records the sample_ratio share of traces, deciding for each
+	// span on its own (the default).
+	OTLPSamplerTraceIDRatio = "traceidratio"
+	// OTLPSamplerParentBasedTraceIDRatio follows the sampling decision of the parent span,
+	// local or remote, and applies sample_ratio only to root spans.
+	OTLPSamplerParentBasedTraceIDRatio = "parentbased_traceidratio"
 )
 
 // Add missing connection capabilities for observability.
@@ -61,6 +92,14 @@ var (
 	ErrFailedToShutdownTraceExporter    = errors.New("failed to shutdown trace exporter")
 	ErrFailedToCreateTestExporter       = errors.New("failed to create test exporter")
 	ErrFailedToMergeResources           = errors.New("failed to merge resources")
+	ErrFailedToLoadCAFile               = errors.New("failed to load CA file")
+	ErrOTLPTestExportFailed             = errors.New("OTLP test export failed")
+	ErrInvalidOTLPSampler               = errors.New("invalid OTLP trace sampler")
+	ErrInvalidOTLPTransport             = errors.New("invalid OTLP transport")
+	ErrFailedToFlushOTLPClient          = errors.New("failed to flush OTLP client")
+	ErrFailedToFlushLogProvider         = errors.New("failed to flush log provider")
+	ErrFailedToFlushMeterProvider       = errors.New("failed to flush meter provider")
+	ErrFailedToFlushTracerProvider      = errors.New("failed to flush tracer provider")
 )
 
 // OTLPConnection represents an OpenTelemetry Protocol connection.
@@ -71,8 +110,8 @@ type OTLPConnection struct {
 	resource *resource.Resource
 
 	// Exporters
-	logExporter    *otlploghttp.Exporter
-	metricExporter *otlpmetrichttp.Exporter
+	logExporter    sdklog.Exporter
+	metricExporter sdkmetric.Exporter
 	traceExporter  *otlptrace.Exporter
 
 	// Providers
@@ -80,9 +119,14 @@ type OTLPConnection struct {
 	meterProvider  *sdkmetric.MeterProvider
 	tracerProvider *sdktrace.TracerProvider
 
-	config   *ConfigTarget
-	endpoint string
-	protocol string
+	config    *ConfigTarget
+	endpoint  string
+	protocol  string
+	transport string
+
+	// Transport and authentication, shared by exporters and health checks
+	headers   map[string]string
+	tlsConfig *tls.Config
 
 	// Configuration
 	serviceName    string
@@ -91,8 +135,10 @@ type OTLPConnection struct {
 	exportInterval time.Duration
 	batchSize      int
 	sampleRatio    float64
-	state          int32 // atomic field for connection state
-	insecure       bool
+	sampler        sdktrace.Sampler
+	*stateTracker
+	insecure        bool
+	deepHealthCheck bool
 }
 
 // OTLPConnectionFactory creates OTLP connections.
@@ -117,36 +163,59 @@ func (f *OTLPConnectionFactory) CreateConnection(
 	}
 
 	// Extract configuration
+	transport, err := f.extractTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
 	insecure := f.extractInsecureFlag(config)
+
+	tlsConfig, err := f.extractTLSConfig(config, insecure)
+	if err != nil {
+		return nil, err
+	}
+
 	serviceName := f.extractServiceName(config)
 	serviceVersion := f.extractServiceVersion(config)
 
+	sampleRatio := f.extractSampleRatio(config)
+
+	sampler, err := f.extractSampler(config, sampleRatio)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create resource for telemetry attribution
-	res, err := f.createResource(serviceName, serviceVersion)
+	res, err := f.createResource(serviceName, serviceVersion, f.extractResourceAttributes(config))
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrFailedToCreateResource, err)
 	}
 
 	conn := &OTLPConnection{
-		config:         config,
-		state:          int32(ConnectionStateNotInitialized),
-		lastHealth:     time.Time{},
-		logExporter:    nil,
-		metricExporter: nil,
-		traceExporter:  nil,
-		loggerProvider: nil,
-		meterProvider:  nil,
-		tracerProvider: nil,
-		endpoint:       endpoint,
-		insecure:       insecure,
-		protocol:       f.protocol,
-		resource:       res,
-		serviceName:    serviceName,
-		serviceVersion: serviceVersion,
-		batchTimeout:   f.extractBatchTimeout(config),
-		exportInterval: f.extractExportInterval(config),
-		batchSize:      f.extractBatchSize(config),
-		sampleRatio:    f.extractSampleRatio(config),
+		config:          config,
+		stateTracker:    newStateTracker(ConnectionStateNotInitialized),
+		lastHealth:      time.Time{},
+		logExporter:     nil,
+		metricExporter:  nil,
+		traceExporter:   nil,
+		loggerProvider:  nil,
+		meterProvider:   nil,
+		tracerProvider:  nil,
+		endpoint:        endpoint,
+		insecure:        insecure,
+		protocol:        f.protocol,
+		transport:       transport,
+		headers:         f.extractHeaders(config),
+		tlsConfig:       tlsConfig,
+		deepHealthCheck: f.extractDeepHealthCheckFlag(config),
+		resource:        res,
+		serviceName:     serviceName,
+		serviceVersion:  serviceVersion,
+		batchTimeout:    f.extractBatchTimeout(config),
+		exportInterval:  f.extractExportInterval(config),
+		batchSize:       f.extractBatchSize(config),
+		sampleRatio:     sampleRatio,
+		sampler:         sampler,
 	}
 
 	// Initialize exporters
@@ -190,7 +259,7 @@ func (c *OTLPConnection) GetProtocol() string {
 }
 
 func (c *OTLPConnection) GetState() ConnectionState {
-	return ConnectionState(atomic.LoadInt32(&c.state))
+	return c.loadState()
 }
 
 func (c *OTLPConnection) HealthCheck(ctx context.Context) *HealthStatus {
@@ -209,7 +278,7 @@ func (c *OTLPConnection) HealthCheck(ctx context.Context) *HealthStatus {
 	status.Latency = time.Since(start)
 
 	if err != nil {
-		atomic.StoreInt32(&c.state, int32(ConnectionStateError))
+		c.setState(ConnectionStateError, err)
 		status.State = ConnectionStateError
 		status.Error = err
 		status.Message = fmt.Sprintf("OTLP health check failed: %v", err)
@@ -218,17 +287,18 @@ func (c *OTLPConnection) HealthCheck(ctx context.Context) *HealthStatus {
 	}
 
 	// If health check passed, connection is ready
-	atomic.StoreInt32(&c.state, int32(ConnectionStateReady))
+	c.setState(ConnectionStateReady, nil)
 	status.State = ConnectionStateReady
-	status.Message = fmt.Sprintf("OTLP connection is ready (endpoint=%s, secure=%t, check=%s)",
-		c.endpoint, !c.insecure, healthCheck)
+	status.Message = fmt.Sprintf(
+		"OTLP connection is ready (endpoint=%s, transport=%s, secure=%t, check=%s)",
+		RedactDSN(c.endpoint), c.transport, !c.insecure, healthCheck)
 	c.lastHealth = start
 
 	return status
 }
 
 func (c *OTLPConnection) Close(ctx context.Context) error { //nolint:cyclop
-	atomic.StoreInt32(&c.state, int32(ConnectionStateDisconnected))
+	c.setState(ConnectionStateDisconnected, nil)
 
 	var errs []error
 
@@ -277,6 +347,37 @@ func (c *OTLPConnection) Close(ctx context.Context) error { //nolint:cyclop
 	return nil
 }
 
+// ForceFlush exports the logs, metrics and spans buffered by the providers of the
+// connection, e.g. before a short-lived job exits. Unlike Close, the connection remains
+// usable.
+func (c *OTLPConnection) ForceFlush(ctx context.Context) error {
+	var errs []error
+
+	if c.loggerProvider != nil {
+		if err := c.loggerProvider.ForceFlush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w", ErrFailedToFlushLogProvider, err))
+		}
+	}
+
+	if c.meterProvider != nil {
+		if err := c.meterProvider.ForceFlush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w", ErrFailedToFlushMeterProvider, err))
+		}
+	}
+
+	if c.tracerProvider != nil {
+		if err := c.tracerProvider.ForceFlush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w", ErrFailedToFlushTracerProvider, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrFailedToFlushOTLPClient, errors.Join(errs...))
+	}
+
+	return nil
+}
+
 func (c *OTLPConnection) GetRawConnection() any {
 	return c
 }
@@ -298,13 +399,15 @@ func (c *OTLPConnection) GetTracerProvider() *sdktrace.TracerProvider {
 	return c.tracerProvider
 }
 
-// GetLogExporter returns the OTLP log exporter.
-func (c *OTLPConnection) GetLogExporter() *otlploghttp.Exporter {
+// GetLogExporter returns the OTLP log exporter, an *otlploghttp.Exporter or an
+// *otlploggrpc.Exporter depending on the transport.
+func (c *OTLPConnection) GetLogExporter() sdklog.Exporter {
 	return c.logExporter
 }
 
-// GetMetricExporter returns the OTLP metric exporter.
-func (c *OTLPConnection) GetMetricExporter() *otlpmetrichttp.Exporter {
+// GetMetricExporter returns the OTLP metric exporter, an *otlpmetrichttp.Exporter or an
+// *otlpmetricgrpc.Exporter depending on the transport.
+func (c *OTLPConnection) GetMetricExporter() sdkmetric.Exporter {
 	return c.metricExporter
 }
 
@@ -323,6 +426,11 @@ func (c *OTLPConnection) GetEndpoint() string {
 	return c.endpoint
 }
 
+// GetTransport returns the transport exports use, OTLPTransportHTTP or OTLPTransportGRPC.
+func (c *OTLPConnection) GetTransport() string {
+	return c.transport
+}
+
 // IsInsecure returns whether the connection uses insecure transport.
 func (c *OTLPConnection) IsInsecure() bool {
 	return c.insecure
@@ -354,13 +462,23 @@ func (c *OTLPConnection) initializeExporters(ctx context.Context) error {
 	return nil
 }
 
-func (c *OTLPConnection) createLogExporter(ctx context.Context) (*otlploghttp.Exporter, error) {
+func (c *OTLPConnection) createLogExporter(ctx context.Context) (sdklog.Exporter, error) {
+	if c.transport == OTLPTransportGRPC {
+		return c.createGRPCLogExporter(ctx)
+	}
+
 	opts := []otlploghttp.Option{
 		otlploghttp.WithEndpoint(c.endpoint),
 	}
 
 	if c.insecure {
 		opts = append(opts, otlploghttp.WithInsecure())
+	} else if c.tlsConfig != nil {
+		opts = append(opts, otlploghttp.WithTLSClientConfig(c.tlsConfig))
+	}
+
+	if len(c.headers) > 0 {
+		opts = append(opts, otlploghttp.WithHeaders(c.headers))
 	}
 
 	exporter, err := otlploghttp.New(ctx, opts...)
@@ -371,15 +489,23 @@ func (c *OTLPConnection) createLogExporter(ctx context.Context) (*otlploghttp.Ex
 	return exporter, nil
 }
 
-func (c *OTLPConnection) createMetricExporter(
-	ctx context.Context,
-) (*otlpmetrichttp.Exporter, error) {
+func (c *OTLPConnection) createMetricExporter(ctx context.Context) (sdkmetric.Exporter, error) {
+	if c.transport == OTLPTransportGRPC {
+		return c.createGRPCMetricExporter(ctx)
+	}
+
 	opts := []otlpmetrichttp.Option{
 		otlpmetrichttp.WithEndpoint(c.endpoint),
 	}
 
 	if c.insecure {
 		opts = append(opts, otlpmetrichttp.WithInsecure())
+	} else if c.tlsConfig != nil {
+		opts = append(opts, otlpmetrichttp.WithTLSClientConfig(c.tlsConfig))
+	}
+
+	if len(c.headers) > 0 {
+		opts = append(opts, otlpmetrichttp.WithHeaders(c.headers))
 	}
 
 	exporter, err := otlpmetrichttp.New(ctx, opts...)
@@ -391,20 +517,44 @@ func (c *OTLPConnection) createMetricExporter(
 }
 
 func (c *OTLPConnection) createTraceExporter(ctx context.Context) (*otlptrace.Exporter, error) {
+	exporter, err := otlptrace.New(ctx, c.traceClient(true))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCreateOTLPTraceExporter, err)
+	}
+
+	return exporter, nil
+}
+
+// traceClient returns a trace client for the connection's transport, endpoint, headers
+// and TLS settings, so health checks use exactly the same configuration as real exports.
+// Health checks disable retries to fail fast.
+func (c *OTLPConnection) traceClient(retry bool) otlptrace.Client {
+	if c.transport == OTLPTransportGRPC {
+		return c.grpcTraceClient(retry)
+	}
+
 	opts := []otlptracehttp.Option{
 		otlptracehttp.WithEndpoint(c.endpoint),
 	}
 
 	if c.insecure {
 		opts = append(opts, otlptracehttp.WithInsecure())
+	} else if c.tlsConfig != nil {
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(c.tlsConfig))
 	}
 
-	exporter, err := otlptracehttp.New(ctx, opts...)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrFailedToCreateOTLPTraceExporter, err)
+	if len(c.headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(c.headers))
 	}
 
-	return exporter, nil
+	if !retry {
+		opts = append(
+			opts,
+			otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}), //nolint:exhaustruct
+		)
+	}
+
+	return otlptracehttp.NewClient(opts...)
 }
 
 func (c *OTLPConnection) createProviders() {
@@ -439,24 +589,17 @@ func (c *OTLPConnection) createProviders() {
 		c.tracerProvider = sdktrace.NewTracerProvider(
 			sdktrace.WithResource(c.resource),
 			sdktrace.WithSpanProcessor(processor),
-			sdktrace.WithSampler(sdktrace.TraceIDRatioBased(c.sampleRatio)),
+			sdktrace.WithSampler(c.sampler),
 		)
 	}
 }
 
+// performHealthCheck creates an exporter with the connection's transport, endpoint,
+// headers and TLS settings. With the deep_health_check property set, it also exports a
+// test span, which validates reachability, TLS and authentication end to end.
 func (c *OTLPConnection) performHealthCheck(ctx context.Context) (string, error) {
-	// For OTLP health check, we try to create a minimal exporter
-	// This validates connectivity and configuration
-	testOpts := []otlptracehttp.Option{
-		otlptracehttp.WithEndpoint(c.endpoint),
-	}
-
-	if c.insecure {
-		testOpts = append(testOpts, otlptracehttp.WithInsecure())
-	}
-
 	// Create a temporary exporter for health check
-	testExporter, err := otlptracehttp.New(ctx, testOpts...)
+	testExporter, err := otlptrace.New(ctx, c.traceClient(false))
 	if err != nil {
 		return "", fmt.Errorf("%w: %w", ErrFailedToCreateTestExporter, err)
 	}
@@ -466,7 +609,57 @@ func (c *OTLPConnection) performHealthCheck(ctx context.Context) (string, error)
 		_ = testExporter.Shutdown(ctx) // Ignore shutdown errors for health check
 	}()
 
-	return "connection_validated", nil
+	if !c.deepHealthCheck {
+		return "connection_validated", nil
+	}
+
+	if err := testExporter.ExportSpans(ctx, c.healthCheckSpans()); err != nil {
+		return "", fmt.Errorf(
+			"%w (endpoint=%q): %w",
+			ErrOTLPTestExportFailed,
+			RedactDSN(c.endpoint),
+			err,
+		)
+	}
+
+	return "export_validated", nil
+}
+
+// healthCheckSpans returns the single test span exported by deep health checks.
+func (c *OTLPConnection) healthCheckSpans() []sdktrace.ReadOnlySpan {
+	now := time.Now()
+
+	stub := tracetest.SpanStub{ //nolint:exhaustruct
+		Name: healthCheckSpanName,
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{ //nolint:exhaustruct
+			TraceID: trace.TraceID{0x01},
+			SpanID:  trace.SpanID{0x01},
+		}),
+		SpanKind:  trace.SpanKindInternal,
+		StartTime: now,
+		EndTime:   now,
+		Resource:  c.resource,
+	}
+
+	return tracetest.SpanStubs{stub}.Snapshots()
+}
+
+// extractTransport reads the transport property, OTLPTransportHTTP when absent.
+func (f *OTLPConnectionFactory) extractTransport(config *ConfigTarget) (string, error) {
+	if config.Properties == nil {
+		return OTLPTransportHTTP, nil
+	}
+
+	transport, ok := config.Properties["transport"].(string)
+	if !ok || transport == "" {
+		return OTLPTransportHTTP, nil
+	}
+
+	if transport != OTLPTransportHTTP && transport != OTLPTransportGRPC {
+		return "", fmt.Errorf("%w (transport=%q)", ErrInvalidOTLPTransport, transport)
+	}
+
+	return transport, nil
 }
 
 func (f *OTLPConnectionFactory) extractInsecureFlag(config *ConfigTarget) bool {
@@ -483,6 +676,89 @@ func (f *OTLPConnectionFactory) extractInsecureFlag(config *ConfigTarget) bool {
 	return true // Default to insecure for development
 }
 
+func (f *OTLPConnectionFactory) extractDeepHealthCheckFlag(config *ConfigTarget) bool {
+	if config.Properties != nil {
+		if deep, ok := config.Properties["deep_health_check"].(bool); ok {
+			return deep
+		}
+	}
+
+	return false
+}
+
+// extractHeaders reads the headers sent with every export, e.g. an authorization token.
+func (f *OTLPConnectionFactory) extractHeaders(config *ConfigTarget) map[string]string {
+	if config.Properties == nil {
+		return nil
+	}
+
+	switch headers := config.Properties["headers"].(type) {
+	case map[string]string:
+		return headers
+	case map[string]any:
+		result := make(map[string]string, len(headers))
+		for key, value := range headers {
+			result[key] = fmt.Sprint(value)
+		}
+
+		return result
+	default:
+		return nil
+	}
+}
+
+// extractTLSConfig builds the TLS configuration for secure connections from the CA file,
+// client certificate and verification settings. It returns nil to use system defaults.
+func (f *OTLPConnectionFactory) extractTLSConfig(
+	config *ConfigTarget,
+	insecure bool,
+) (*tls.Config, error) {
+	if insecure ||
+		(config.CAFile == "" && config.CertFile == "" && !config.TLSSkipVerify) {
+		return nil, nil //nolint:nilnil
+	}
+
+	tlsConfig := &tls.Config{ //nolint:exhaustruct
+		InsecureSkipVerify: config.TLSSkipVerify, //nolint:gosec
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if config.CAFile != "" {
+		caPEM, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w (ca_file=%q): %w", ErrFailedToLoadCAFile, config.CAFile, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf(
+				"%w (ca_file=%q): no certificates found",
+				ErrFailedToLoadCAFile,
+				config.CAFile,
+			)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" && config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%w (cert_file=%q, key_file=%q): %w",
+				ErrFailedToLoadCertificate,
+				config.CertFile,
+				config.KeyFile,
+				err,
+			)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
 func (f *OTLPConnectionFactory) extractServiceName(config *ConfigTarget) string {
 	if config.Properties != nil {
 		if name, ok := config.Properties["service_name"].(string); ok {
@@ -543,21 +819,79 @@ func (f *OTLPConnectionFactory) extractSampleRatio(config *ConfigTarget) float64
 	return DefaultSampleRatio
 }
 
+// extractSampler builds the trace sampler named by the sampler property, sampling the
+// sampleRatio share of traces by their ID when it is absent.
+func (f *OTLPConnectionFactory) extractSampler(
+	config *ConfigTarget,
+	sampleRatio float64,
+) (sdktrace.Sampler, error) {
+	name := OTLPSamplerTraceIDRatio
+
+	if config.Properties != nil {
+		if sampler, ok := config.Properties["sampler"].(string); ok && sampler != "" {
+			name = sampler
+		}
+	}
+
+	switch name {
+	case OTLPSamplerAlwaysOn:
+		return sdktrace.AlwaysSample(), nil
+	case OTLPSamplerAlwaysOff:
+		return sdktrace.NeverSample(), nil
+	case OTLPSamplerTraceIDRatio:
+		return sdktrace.TraceIDRatioBased(sampleRatio), nil
+	case OTLPSamplerParentBasedTraceIDRatio:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio)), nil
+	default:
+		return nil, fmt.Errorf("%w (sampler=%q)", ErrInvalidOTLPSampler, name)
+	}
+}
+
+// extractResourceAttributes reads the resource_attributes property, additional attributes
+// of the resource such as deployment.environment or a tenant ID.
+func (f *OTLPConnectionFactory) extractResourceAttributes(config *ConfigTarget) map[string]string {
+	if config.Properties == nil {
+		return nil
+	}
+
+	switch attributes := config.Properties["resource_attributes"].(type) {
+	case map[string]string:
+		return attributes
+	case map[string]any:
+		result := make(map[string]string, len(attributes))
+		for key, value := range attributes {
+			result[key] = fmt.Sprint(value)
+		}
+
+		return result
+	default:
+		return nil
+	}
+}
+
 func (f *OTLPConnectionFactory) createResource(
 	serviceName, serviceVersion string,
+	resourceAttributes map[string]string,
 ) (*resource.Resource, error) {
 	attributes := []attribute.KeyValue{
 		semconv.ServiceName(serviceName),
 		semconv.ServiceVersion(serviceVersion),
 	}
 
+	// Explicit attributes come last, as the last value of a duplicated key wins
+	for _, key := range slices.Sorted(maps.Keys(resourceAttributes)) {
+		attributes = append(attributes, attribute.String(key, resourceAttributes[key]))
+	}
+
 	// Create resource without explicit schema URL to avoid conflicts
 	customResource := resource.NewWithAttributes("", attributes...)
 
 	res, err := resource.Merge(resource.Default(), customResource)
-	if err != nil {
+	if err != nil && !errors.Is(err, resource.ErrSchemaURLConflict) {
 		return nil, fmt.Errorf("%w: %w", ErrFailedToMergeResources, err)
 	}
 
+	// On a schema URL conflict the merged resource is still complete, only without a
+	// schema URL
 	return res, nil
 }
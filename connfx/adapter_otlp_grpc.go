@@ -0,0 +1,88 @@
+package connfx
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// The exporters of OTLP connections with the grpc transport. They mirror the HTTP ones in
+// adapter_otlp.go: same endpoint, headers and insecure/TLS handling.
+
+func (c *OTLPConnection) createGRPCLogExporter(ctx context.Context) (*otlploggrpc.Exporter, error) {
+	opts := []otlploggrpc.Option{
+		otlploggrpc.WithEndpoint(c.endpoint),
+	}
+
+	if c.insecure {
+		opts = append(opts, otlploggrpc.WithInsecure())
+	} else if c.tlsConfig != nil {
+		opts = append(opts, otlploggrpc.WithTLSCredentials(credentials.NewTLS(c.tlsConfig)))
+	}
+
+	if len(c.headers) > 0 {
+		opts = append(opts, otlploggrpc.WithHeaders(c.headers))
+	}
+
+	exporter, err := otlploggrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCreateOTLPLogExporter, err)
+	}
+
+	return exporter, nil
+}
+
+func (c *OTLPConnection) createGRPCMetricExporter(
+	ctx context.Context,
+) (*otlpmetricgrpc.Exporter, error) {
+	opts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(c.endpoint),
+	}
+
+	if c.insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	} else if c.tlsConfig != nil {
+		opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(c.tlsConfig)))
+	}
+
+	if len(c.headers) > 0 {
+		opts = append(opts, otlpmetricgrpc.WithHeaders(c.headers))
+	}
+
+	exporter, err := otlpmetricgrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCreateOTLPMetricExporter, err)
+	}
+
+	return exporter, nil
+}
+
+func (c *OTLPConnection) grpcTraceClient(retry bool) otlptrace.Client {
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(c.endpoint),
+	}
+
+	if c.insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	} else if c.tlsConfig != nil {
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(c.tlsConfig)))
+	}
+
+	if len(c.headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(c.headers))
+	}
+
+	if !retry {
+		opts = append(
+			opts,
+			otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{Enabled: false}), //nolint:exhaustruct
+		)
+	}
+
+	return otlptracegrpc.NewClient(opts...)
+}
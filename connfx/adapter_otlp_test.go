@@ -0,0 +1,475 @@
+package connfx_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeOTLPCollector accepts OTLP/HTTP trace exports that carry the expected token.
+type fakeOTLPCollector struct {
+	authorization string
+	exports       int
+	mu            sync.Mutex
+}
+
+func (c *fakeOTLPCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != connfx.HealthCheckRequestPath {
+		w.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	if c.authorization != "" && r.Header.Get("Authorization") != c.authorization {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	c.mu.Lock()
+	c.exports++
+	c.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *fakeOTLPCollector) exportCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.exports
+}
+
+// writeServerCA stores the test server's certificate as a PEM CA file.
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+
+	return caFile
+}
+
+func createOTLPConnection(t *testing.T, config *connfx.ConfigTarget) (connfx.Connection, error) {
+	t.Helper()
+
+	conn, err := connfx.NewOTLPConnectionFactory("otlp").CreateConnection(t.Context(), config)
+	if err == nil {
+		t.Cleanup(func() { _ = conn.Close(t.Context()) })
+	}
+
+	return conn, err
+}
+
+func TestOTLPConnection_HealthCheck_Insecure(t *testing.T) {
+	t.Parallel()
+
+	collector := &fakeOTLPCollector{} //nolint:exhaustruct
+	server := httptest.NewServer(collector)
+	t.Cleanup(server.Close)
+
+	conn, err := createOTLPConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "otlp",
+		DSN:      server.Listener.Addr().String(),
+		Properties: map[string]any{
+			"insecure":          true,
+			"deep_health_check": true,
+		},
+	})
+	require.NoError(t, err)
+
+	status := conn.HealthCheck(t.Context())
+	require.NoError(t, status.Error)
+	assert.Equal(t, connfx.ConnectionStateReady, status.State)
+	assert.Contains(t, status.Message, "secure=false")
+	assert.Contains(t, status.Message, "check=export_validated")
+
+	// One test span from connection creation and one from the explicit check
+	assert.Equal(t, 2, collector.exportCount())
+}
+
+func TestOTLPConnection_HealthCheck_SecureWithHeaders(t *testing.T) {
+	t.Parallel()
+
+	collector := &fakeOTLPCollector{authorization: "Bearer secret-token"} //nolint:exhaustruct
+	server := httptest.NewTLSServer(collector)
+	t.Cleanup(server.Close)
+
+	caFile := writeServerCA(t, server)
+
+	newConfig := func(token string, deep bool) *connfx.ConfigTarget {
+		return &connfx.ConfigTarget{ //nolint:exhaustruct
+			Protocol: "otlp",
+			DSN:      server.Listener.Addr().String(),
+			TLS:      true,
+			CAFile:   caFile,
+			Properties: map[string]any{
+				"headers":           map[string]any{"Authorization": token},
+				"deep_health_check": deep,
+			},
+		}
+	}
+
+	t.Run("valid_token", func(t *testing.T) {
+		t.Parallel()
+
+		conn, err := createOTLPConnection(t, newConfig("Bearer secret-token", true))
+		require.NoError(t, err)
+
+		status := conn.HealthCheck(t.Context())
+		require.NoError(t, status.Error)
+		assert.Contains(t, status.Message, "secure=true")
+		assert.Contains(t, status.Message, "check=export_validated")
+	})
+
+	t.Run("invalid_token", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := createOTLPConnection(t, newConfig("Bearer wrong-token", true))
+		require.ErrorIs(t, err, connfx.ErrOTLPHealthCheckFailed)
+		require.ErrorIs(t, err, connfx.ErrOTLPTestExportFailed)
+	})
+
+	t.Run("invalid_token_without_deep_check", func(t *testing.T) {
+		t.Parallel()
+
+		// Without an actual export, only the exporter configuration is validated
+		conn, err := createOTLPConnection(t, newConfig("Bearer wrong-token", false))
+		require.NoError(t, err)
+
+		status := conn.HealthCheck(t.Context())
+		require.NoError(t, status.Error)
+		assert.Contains(t, status.Message, "check=connection_validated")
+	})
+
+	t.Run("unknown_certificate_authority", func(t *testing.T) {
+		t.Parallel()
+
+		config := newConfig("Bearer secret-token", true)
+		config.CAFile = ""
+
+		_, err := createOTLPConnection(t, config)
+		require.ErrorIs(t, err, connfx.ErrOTLPTestExportFailed)
+	})
+}
+
+func TestOTLPConnection_Sampler(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(&fakeOTLPCollector{}) //nolint:exhaustruct
+	t.Cleanup(server.Close)
+
+	sampledParent := trace.ContextWithRemoteSpanContext(
+		t.Context(),
+		trace.NewSpanContext(trace.SpanContextConfig{ //nolint:exhaustruct
+			TraceID:    trace.TraceID{0x01},
+			SpanID:     trace.SpanID{0x01},
+			TraceFlags: trace.FlagsSampled,
+			Remote:     true,
+		}),
+	)
+
+	tests := []struct {
+		name         string
+		properties   map[string]any
+		sampledRoot  bool
+		sampledChild bool
+
+		expectedError error
+	}{
+		{
+			name:         "default_ratio",
+			properties:   map[string]any{"sample_ratio": 0.0},
+			sampledRoot:  false,
+			sampledChild: false,
+
+			expectedError: nil,
+		},
+		{
+			name:         "always_on",
+			properties:   map[string]any{"sampler": "always_on", "sample_ratio": 0.0},
+			sampledRoot:  true,
+			sampledChild: true,
+
+			expectedError: nil,
+		},
+		{
+			name:         "always_off",
+			properties:   map[string]any{"sampler": "always_off"},
+			sampledRoot:  false,
+			sampledChild: false,
+
+			expectedError: nil,
+		},
+		{
+			name:         "traceidratio",
+			properties:   map[string]any{"sampler": "traceidratio", "sample_ratio": 1.0},
+			sampledRoot:  true,
+			sampledChild: true,
+
+			expectedError: nil,
+		},
+		{
+			// A sampled parent forces its children to be sampled despite the local ratio
+			name: "parentbased_traceidratio",
+			properties: map[string]any{
+				"sampler":      "parentbased_traceidratio",
+				"sample_ratio": 0.0,
+			},
+			sampledRoot:  false,
+			sampledChild: true,
+
+			expectedError: nil,
+		},
+		{
+			name:         "unknown",
+			properties:   map[string]any{"sampler": "sometimes"},
+			sampledRoot:  false,
+			sampledChild: false,
+
+			expectedError: connfx.ErrInvalidOTLPSampler,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.properties["insecure"] = true
+
+			conn, err := createOTLPConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+				Protocol:   "otlp",
+				DSN:        server.Listener.Addr().String(),
+				Properties: tt.properties,
+			})
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			otlpConn, ok := conn.(*connfx.OTLPConnection)
+			require.True(t, ok)
+
+			tracer := otlpConn.GetTracerProvider().Tracer("test")
+
+			_, root := tracer.Start(t.Context(), "root")
+			defer root.End()
+
+			_, child := tracer.Start(sampledParent, "child")
+			defer child.End()
+
+			assert.Equal(t, tt.sampledRoot, root.SpanContext().IsSampled())
+			assert.Equal(t, tt.sampledChild, child.SpanContext().IsSampled())
+		})
+	}
+}
+
+func TestOTLPConnection_ResourceAttributes(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(&fakeOTLPCollector{}) //nolint:exhaustruct
+	t.Cleanup(server.Close)
+
+	conn, err := createOTLPConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "otlp",
+		DSN:      server.Listener.Addr().String(),
+		Properties: map[string]any{
+			"insecure":        true,
+			"service_name":    "checkout",
+			"service_version": "1.0.0",
+			"resource_attributes": map[string]any{
+				"deployment.environment": "production",
+				"service.namespace":      "ecommerce",
+				"tenant.id":              42,
+				// Explicit values override the service attributes
+				"service.version": "1.0.1",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	otlpConn, ok := conn.(*connfx.OTLPConnection)
+	require.True(t, ok)
+
+	res := otlpConn.GetResource()
+	expected := map[attribute.Key]string{
+		"service.name":           "checkout",
+		"service.version":        "1.0.1",
+		"deployment.environment": "production",
+		"service.namespace":      "ecommerce",
+		"tenant.id":              "42",
+	}
+
+	for key, value := range expected {
+		actual, ok := res.Set().Value(key)
+		require.True(t, ok, key)
+		assert.Equal(t, value, actual.AsString(), key)
+	}
+
+	// The defaults of the SDK are kept
+	_, ok = res.Set().Value("telemetry.sdk.name")
+	assert.True(t, ok)
+}
+
+func TestOTLPConnection_ForceFlush(t *testing.T) {
+	t.Parallel()
+
+	collector := &fakeOTLPCollector{} //nolint:exhaustruct
+	server := httptest.NewServer(collector)
+	t.Cleanup(server.Close)
+
+	conn, err := createOTLPConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "otlp",
+		DSN:      server.Listener.Addr().String(),
+		Properties: map[string]any{
+			"insecure":      true,
+			"batch_timeout": time.Hour,
+		},
+	})
+	require.NoError(t, err)
+
+	otlpConn, ok := conn.(*connfx.OTLPConnection)
+	require.True(t, ok)
+
+	tracer := otlpConn.GetTracerProvider().Tracer("test")
+
+	// Spans wait for the batch timeout until flushed
+	_, span := tracer.Start(t.Context(), "job")
+	span.End()
+	assert.Equal(t, 0, collector.exportCount())
+
+	require.NoError(t, otlpConn.ForceFlush(t.Context()))
+	assert.Equal(t, 1, collector.exportCount())
+
+	// The connection remains usable after a flush
+	_, span = tracer.Start(t.Context(), "job")
+	span.End()
+
+	require.NoError(t, otlpConn.ForceFlush(t.Context()))
+	assert.Equal(t, 2, collector.exportCount())
+	assert.NotEqual(t, connfx.ConnectionStateDisconnected, otlpConn.GetState())
+}
+
+// fakeOTLPGRPCCollector accepts OTLP/gRPC trace exports that carry the expected token.
+type fakeOTLPGRPCCollector struct {
+	coltracepb.UnimplementedTraceServiceServer
+
+	authorization string
+	exports       int
+	mu            sync.Mutex
+}
+
+func (c *fakeOTLPGRPCCollector) Export(
+	ctx context.Context,
+	req *coltracepb.ExportTraceServiceRequest,
+) (*coltracepb.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) == 0 || values[0] != c.authorization {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	c.mu.Lock()
+	c.exports++
+	c.mu.Unlock()
+
+	return &coltracepb.ExportTraceServiceResponse{}, nil //nolint:exhaustruct
+}
+
+func (c *fakeOTLPGRPCCollector) exportCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.exports
+}
+
+func TestOTLPConnection_GRPCTransport(t *testing.T) {
+	t.Parallel()
+
+	collector := &fakeOTLPGRPCCollector{authorization: "Bearer secret-token"} //nolint:exhaustruct
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(server, collector)
+
+	go server.Serve(listener) //nolint:errcheck
+
+	t.Cleanup(server.Stop)
+
+	newConfig := func(token string) *connfx.ConfigTarget {
+		return &connfx.ConfigTarget{ //nolint:exhaustruct
+			Protocol: "otlp",
+			DSN:      listener.Addr().String(),
+			Properties: map[string]any{
+				"transport":         "grpc",
+				"insecure":          true,
+				"headers":           map[string]any{"Authorization": token},
+				"deep_health_check": true,
+			},
+		}
+	}
+
+	// The subtests run in order, as they share the collector's export count
+	t.Run("valid_token", func(t *testing.T) { //nolint:paralleltest
+		conn, err := createOTLPConnection(t, newConfig("Bearer secret-token"))
+		require.NoError(t, err)
+
+		otlpConn, ok := conn.(*connfx.OTLPConnection)
+		require.True(t, ok)
+		assert.Equal(t, connfx.OTLPTransportGRPC, otlpConn.GetTransport())
+		assert.IsType(t, &otlpmetricgrpc.Exporter{}, otlpConn.GetMetricExporter())
+		assert.IsType(t, &otlploggrpc.Exporter{}, otlpConn.GetLogExporter())
+
+		health := conn.HealthCheck(t.Context())
+		require.NoError(t, health.Error)
+		assert.Contains(t, health.Message, "transport=grpc")
+		assert.Contains(t, health.Message, "check=export_validated")
+
+		// One test span from connection creation and one from the explicit check
+		assert.Equal(t, 2, collector.exportCount())
+	})
+
+	t.Run("invalid_token", func(t *testing.T) { //nolint:paralleltest
+		_, err := createOTLPConnection(t, newConfig("Bearer wrong-token"))
+		require.ErrorIs(t, err, connfx.ErrOTLPHealthCheckFailed)
+		require.ErrorIs(t, err, connfx.ErrOTLPTestExportFailed)
+	})
+}
+
+func TestOTLPConnection_InvalidTransport(t *testing.T) {
+	t.Parallel()
+
+	_, err := createOTLPConnection(t, &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol:   "otlp",
+		DSN:        "localhost:4317",
+		Properties: map[string]any{"transport": "udp"},
+	})
+	require.ErrorIs(t, err, connfx.ErrInvalidOTLPTransport)
+}
@@ -0,0 +1,90 @@
+package connfx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PubSubRepository interface implementation.
+
+// PublishToChannel publishes a payload to a Redis pub/sub channel.
+func (ra *RedisAdapter) PublishToChannel(
+	ctx context.Context,
+	channel string,
+	payload []byte,
+) error {
+	if ra.client == nil {
+		return fmt.Errorf("%w (channel=%q)", ErrRedisClientNotInitialized, channel)
+	}
+
+	err := ra.client.Publish(ctx, channel, payload).Err()
+	if err != nil {
+		return fmt.Errorf(
+			"%w (operation=publish_to_channel, channel=%q): %w",
+			ErrRedisOperation,
+			channel,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Subscribe subscribes to Redis pub/sub channels. The returned Subscription re-subscribes
+// to all of its channels after a connection drop and reports it as a ReconnectEvent.
+func (ra *RedisAdapter) Subscribe(ctx context.Context, channels ...string) (*Subscription, error) {
+	if ra.client == nil {
+		return nil, fmt.Errorf("%w (channels=%q)", ErrRedisClientNotInitialized, channels)
+	}
+
+	return NewSubscription(ctx, ra.dialPubSub, channels...)
+}
+
+func (ra *RedisAdapter) dialPubSub(ctx context.Context) (PubSubSession, error) {
+	// Subscribing without channels only creates the handle; the connection is opened by
+	// the first SUBSCRIBE, which surfaces connection errors to the caller
+	return &redisPubSubSession{pubsub: ra.client.Subscribe(ctx)}, nil
+}
+
+// redisPubSubSession adapts a go-redis PubSub to PubSubSession.
+type redisPubSubSession struct {
+	pubsub *redis.PubSub
+}
+
+func (s *redisPubSubSession) Subscribe(ctx context.Context, channels ...string) error {
+	if err := s.pubsub.Subscribe(ctx, channels...); err != nil {
+		return fmt.Errorf("%w (operation=subscribe): %w", ErrRedisOperation, err)
+	}
+
+	return nil
+}
+
+func (s *redisPubSubSession) Unsubscribe(ctx context.Context, channels ...string) error {
+	if err := s.pubsub.Unsubscribe(ctx, channels...); err != nil {
+		return fmt.Errorf("%w (operation=unsubscribe): %w", ErrRedisOperation, err)
+	}
+
+	return nil
+}
+
+func (s *redisPubSubSession) Receive(ctx context.Context) (PubSubMessage, error) {
+	msg, err := s.pubsub.ReceiveMessage(ctx)
+	if err != nil {
+		return PubSubMessage{}, fmt.Errorf("%w (operation=receive): %w", ErrRedisOperation, err)
+	}
+
+	return PubSubMessage{
+		Channel: msg.Channel,
+		Payload: []byte(msg.Payload),
+	}, nil
+}
+
+func (s *redisPubSubSession) Close() error {
+	if err := s.pubsub.Close(); err != nil {
+		return fmt.Errorf("%w (operation=close_pubsub): %w", ErrRedisOperation, err)
+	}
+
+	return nil
+}
@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"sync/atomic"
 	"time"
 )
 
@@ -16,6 +15,8 @@ var (
 	ErrUnsupportedSQLProtocol    = errors.New("unsupported SQL protocol")
 	ErrFailedToCloseSQLDB        = errors.New("failed to close SQL database")
 	ErrSQLConnectionNil          = errors.New("SQL connection is nil")
+	ErrFailedToQuerySQL          = errors.New("failed to query SQL database")
+	ErrFailedToExecuteSQL        = errors.New("failed to execute SQL command")
 )
 
 // SQLConnection represents a SQL database connection.
@@ -23,41 +24,78 @@ type SQLConnection struct {
 	lastHealth time.Time
 	db         *sql.DB
 	protocol   string
-	state      int32 // atomic field for connection state
+	tables     sqlTableConfig
+	*stateTracker
 }
 
 // SQLConnectionFactory creates SQL connections.
 type SQLConnectionFactory struct {
-	protocol string
+	credentialProvider        CredentialProvider
+	protocol                  string
+	credentialRefreshInterval time.Duration
 }
 
 // NewSQLConnectionFactory creates a new SQL connection factory for a specific protocol.
-func NewSQLConnectionFactory(protocol string) *SQLConnectionFactory {
-	return &SQLConnectionFactory{
-		protocol: protocol,
+func NewSQLConnectionFactory(
+	protocol string,
+	options ...SQLConnectionFactoryOption,
+) *SQLConnectionFactory {
+	factory := &SQLConnectionFactory{
+		credentialProvider:        nil,
+		protocol:                  protocol,
+		credentialRefreshInterval: 0,
 	}
+
+	for _, option := range options {
+		option(factory)
+	}
+
+	return factory
 }
 
 func (f *SQLConnectionFactory) CreateConnection(
 	ctx context.Context,
 	config *ConfigTarget,
 ) (Connection, error) {
-	db, err := sql.Open(f.protocol, config.DSN)
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	poolConfig, err := parseSQLPoolConfig(config.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := parseSQLTableConfig(config.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.credentialProvider != nil {
+		db, err = f.openWithCredentials(config.DSN)
+	} else {
+		db, err = sql.Open(f.protocol, config.DSN)
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf(
 			"%w (protocol=%q, dsn=%q): %w",
 			ErrFailedToOpenSQLConnection,
 			f.protocol,
-			config.DSN,
+			RedactDSN(config.DSN),
 			err,
 		)
 	}
 
-	// Configure connection pool if timeout is specified
-	if config.Timeout > 0 {
+	// Configure connection pool if timeout is specified, without outliving rotated credentials
+	if config.Timeout > 0 &&
+		(f.credentialProvider == nil || config.Timeout < f.credentialRefreshInterval) {
 		db.SetConnMaxLifetime(config.Timeout)
 	}
 
+	poolConfig.apply(db)
+
 	// Initial ping to verify connection
 	if err := db.PingContext(ctx); err != nil {
 		_ = db.Close() // Ignore close error if ping fails
@@ -66,10 +104,11 @@ func (f *SQLConnectionFactory) CreateConnection(
 	}
 
 	conn := &SQLConnection{
-		protocol:   f.protocol,
-		db:         db,
-		state:      int32(ConnectionStateConnected),
-		lastHealth: time.Time{},
+		protocol:     f.protocol,
+		db:           db,
+		tables:       tables,
+		stateTracker: newStateTracker(ConnectionStateConnected),
+		lastHealth:   time.Time{},
 	}
 
 	// Perform initial health check to set correct state
@@ -100,9 +139,7 @@ func (c *SQLConnection) GetProtocol() string {
 }
 
 func (c *SQLConnection) GetState() ConnectionState {
-	state := atomic.LoadInt32(&c.state)
-
-	return ConnectionState(state)
+	return c.loadState()
 }
 
 func (c *SQLConnection) HealthCheck(ctx context.Context) *HealthStatus {
@@ -113,7 +150,7 @@ func (c *SQLConnection) HealthCheck(ctx context.Context) *HealthStatus {
 
 	// Check if database connection exists
 	if c.db == nil {
-		atomic.StoreInt32(&c.state, int32(ConnectionStateError))
+		c.setState(ConnectionStateError, ErrSQLConnectionNil)
 		status.State = ConnectionStateError
 		status.Error = ErrSQLConnectionNil
 		status.Message = "Database connection not initialized"
@@ -130,7 +167,7 @@ func (c *SQLConnection) HealthCheck(ctx context.Context) *HealthStatus {
 	status.Latency = time.Since(start)
 
 	if err != nil {
-		atomic.StoreInt32(&c.state, int32(ConnectionStateError))
+		c.setState(ConnectionStateError, err)
 		status.State = ConnectionStateError
 		status.Error = err
 		status.Message = fmt.Sprintf("Health check failed: %v", err)
@@ -146,7 +183,7 @@ func (c *SQLConnection) HealthCheck(ctx context.Context) *HealthStatus {
 }
 
 func (c *SQLConnection) Close(ctx context.Context) error {
-	atomic.StoreInt32(&c.state, int32(ConnectionStateDisconnected))
+	c.setState(ConnectionStateDisconnected, nil)
 
 	if err := c.db.Close(); err != nil {
 		return fmt.Errorf("%w: %w", ErrFailedToCloseSQLDB, err)
@@ -171,16 +208,52 @@ func (c *SQLConnection) Stats() sql.DBStats {
 	return c.db.Stats()
 }
 
+// QueryRepository interface implementation
+
+// Query executes a query and returns the resulting rows.
+func (c *SQLConnection) Query(ctx context.Context, query string, args ...any) (QueryResult, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%w (protocol=%q): %w",
+			ErrFailedToQuerySQL,
+			c.protocol,
+			NormalizeSQLError(err),
+		)
+	}
+
+	return rows, nil
+}
+
+// Execute runs a command (INSERT, UPDATE, DELETE) and returns its result.
+func (c *SQLConnection) Execute(
+	ctx context.Context,
+	command string,
+	args ...any,
+) (ExecuteResult, error) {
+	result, err := c.db.ExecContext(ctx, command, args...)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%w (protocol=%q): %w",
+			ErrFailedToExecuteSQL,
+			c.protocol,
+			NormalizeSQLError(err),
+		)
+	}
+
+	return result, nil
+}
+
 func (c *SQLConnection) determineConnectionState(stats sql.DBStats, status *HealthStatus) {
 	switch {
 	case stats.OpenConnections == 0:
 		// No connections available - disconnected
-		atomic.StoreInt32(&c.state, int32(ConnectionStateDisconnected))
+		c.setState(ConnectionStateDisconnected, nil)
 		status.State = ConnectionStateDisconnected
 		status.Message = "No open connections available"
 	case stats.OpenConnections > 0 && stats.InUse == stats.OpenConnections:
 		// All connections are in use - connected but not ready for new requests
-		atomic.StoreInt32(&c.state, int32(ConnectionStateLive))
+		c.setState(ConnectionStateLive, nil)
 		status.State = ConnectionStateLive
 		status.Message = fmt.Sprintf(
 			"Connected and live but at capacity (protocol=%s, open=%d, inuse=%d, idle=%d)",
@@ -191,7 +264,7 @@ func (c *SQLConnection) determineConnectionState(stats sql.DBStats, status *Heal
 		)
 	case stats.OpenConnections > 0 && stats.Idle > 0:
 		// Has idle connections available - ready to serve requests
-		atomic.StoreInt32(&c.state, int32(ConnectionStateReady))
+		c.setState(ConnectionStateReady, nil)
 		status.State = ConnectionStateReady
 		status.Message = fmt.Sprintf(
 			"Connected, live and ready (protocol=%s, open=%d, inuse=%d, idle=%d)",
@@ -202,7 +275,7 @@ func (c *SQLConnection) determineConnectionState(stats sql.DBStats, status *Heal
 		)
 	default:
 		// Basic connected state - ping successful but connection state unclear
-		atomic.StoreInt32(&c.state, int32(ConnectionStateConnected))
+		c.setState(ConnectionStateConnected, nil)
 		status.State = ConnectionStateConnected
 		status.Message = fmt.Sprintf("Connected (protocol=%s, open=%d, inuse=%d, idle=%d)",
 			c.protocol, stats.OpenConnections, stats.InUse, stats.Idle)
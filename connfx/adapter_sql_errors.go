@@ -0,0 +1,210 @@
+package connfx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/eser/ajan/lib"
+)
+
+// Portable sentinel errors for SQL driver errors. Driver errors passed through
+// NormalizeSQLError wrap one of these in addition to the original driver error.
+// ErrReadOnly, returned for writes to a read-only database or transaction (such as a
+// replica during failover), falls into lib.ErrCategoryUnavailable.
+var (
+	ErrConstraintViolation  = errors.New("constraint violation")
+	ErrDuplicateKey         = errors.New("duplicate key")
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrSerializationFailure = errors.New("serialization failure")
+	ErrDeadlock             = errors.New("deadlock detected")
+	ErrRecordNotFound       = errors.New("record not found")
+	ErrReadOnly             = lib.NewCategorizedError(
+		lib.ErrCategoryUnavailable,
+		"database is read-only",
+	)
+)
+
+// SQLite result codes (https://www.sqlite.org/rescode.html).
+const (
+	sqliteReadOnly             = 8
+	sqliteConstraint           = 19
+	sqliteBusySnapshot         = 517
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+	sqlitePrimaryResultMask    = 0xff
+)
+
+// MySQL server error numbers.
+const (
+	mysqlBadNull                   = 1048
+	mysqlDupEntry                  = 1062
+	mysqlLockDeadlock              = 1213
+	mysqlOptionPreventsStatement   = 1290
+	mysqlNoReferencedRowWithFKName = 1216
+	mysqlRowIsReferencedWithFKName = 1217
+	mysqlRowIsReferenced           = 1451
+	mysqlNoReferencedRow           = 1452
+	mysqlDupEntryWithKeyName       = 1586
+	mysqlReadOnlyTransaction       = 1792
+	mysqlCheckConstraintViolated   = 3819
+
+	sqlStateSerializationFailure = "40001"
+)
+
+// mysqlErrorPattern matches the message format of go-sql-driver/mysql's MySQLError,
+// e.g. "Error 1062 (23000): Duplicate entry 'a' for key 'users.email'".
+var mysqlErrorPattern = regexp.MustCompile(`^Error (\d+)(?: \(([0-9A-Z]{5})\))?: `)
+
+// sqlStateError is implemented by Postgres drivers (lib/pq and pgx).
+type sqlStateError interface {
+	SQLState() string
+}
+
+// sqliteCodeError is implemented by modernc.org/sqlite.
+type sqliteCodeError interface {
+	Code() int
+}
+
+// NormalizeSQLError maps well-known Postgres, MySQL and SQLite driver errors to the
+// portable sentinel errors above, so callers can branch with errors.Is regardless of
+// the driver. The original error stays in the chain; unknown errors are returned as is.
+func NormalizeSQLError(err error) error {
+	if err == nil || isNormalizedSQLError(err) {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrRecordNotFound, err)
+	}
+
+	var stateErr sqlStateError
+	if errors.As(err, &stateErr) {
+		return wrapSQLError(classifyPostgresError(stateErr.SQLState()), stateErr.SQLState(), err)
+	}
+
+	var codeErr sqliteCodeError
+	if errors.As(err, &codeErr) {
+		code := codeErr.Code()
+
+		return wrapSQLError(classifySQLiteError(code), strconv.Itoa(code), err)
+	}
+
+	if matches := mysqlErrorPattern.FindStringSubmatch(err.Error()); matches != nil {
+		number, _ := strconv.Atoi(matches[1])
+
+		return wrapSQLError(classifyMySQLError(number, matches[2]), matches[1], err)
+	}
+
+	// Drivers that expose neither codes nor a known message format (e.g. mattn/go-sqlite3)
+	return wrapSQLError(classifySQLiteMessage(err.Error()), "", err)
+}
+
+func isNormalizedSQLError(err error) bool {
+	for _, sentinel := range []error{
+		ErrConstraintViolation,
+		ErrSerializationFailure,
+		ErrDeadlock,
+		ErrRecordNotFound,
+		ErrReadOnly,
+	} {
+		if errors.Is(err, sentinel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func wrapSQLError(sentinel error, code string, err error) error {
+	switch {
+	case sentinel == nil:
+		return err
+	case errors.Is(sentinel, ErrDuplicateKey), errors.Is(sentinel, ErrForeignKeyViolation):
+		return fmt.Errorf("%w: %w (code=%q): %w", sentinel, ErrConstraintViolation, code, err)
+	default:
+		return fmt.Errorf("%w (code=%q): %w", sentinel, code, err)
+	}
+}
+
+func classifyPostgresError(state string) error {
+	switch {
+	case state == "23505":
+		return ErrDuplicateKey
+	case state == "23503":
+		return ErrForeignKeyViolation
+	case strings.HasPrefix(state, "23"):
+		return ErrConstraintViolation
+	case state == sqlStateSerializationFailure:
+		return ErrSerializationFailure
+	case state == "40P01":
+		return ErrDeadlock
+	case state == "25006":
+		return ErrReadOnly
+	default:
+		return nil
+	}
+}
+
+func classifyMySQLError(number int, state string) error {
+	switch number {
+	case mysqlDupEntry, mysqlDupEntryWithKeyName:
+		return ErrDuplicateKey
+	case mysqlRowIsReferenced,
+		mysqlNoReferencedRow,
+		mysqlRowIsReferencedWithFKName,
+		mysqlNoReferencedRowWithFKName:
+		return ErrForeignKeyViolation
+	case mysqlBadNull, mysqlCheckConstraintViolated:
+		return ErrConstraintViolation
+	case mysqlLockDeadlock:
+		return ErrDeadlock
+	case mysqlOptionPreventsStatement, mysqlReadOnlyTransaction:
+		// 1290 is raised for any server option preventing a statement, which for writes
+		// is read_only or super_read_only
+		return ErrReadOnly
+	}
+
+	if state == sqlStateSerializationFailure {
+		return ErrSerializationFailure
+	}
+
+	return nil
+}
+
+func classifySQLiteError(code int) error {
+	switch {
+	case code == sqliteConstraintUnique, code == sqliteConstraintPrimaryKey:
+		return ErrDuplicateKey
+	case code == sqliteConstraintForeignKey:
+		return ErrForeignKeyViolation
+	case code&sqlitePrimaryResultMask == sqliteConstraint:
+		return ErrConstraintViolation
+	case code == sqliteBusySnapshot:
+		return ErrSerializationFailure
+	case code&sqlitePrimaryResultMask == sqliteReadOnly:
+		return ErrReadOnly
+	default:
+		return nil
+	}
+}
+
+func classifySQLiteMessage(message string) error {
+	switch {
+	case strings.Contains(message, "UNIQUE constraint failed"),
+		strings.Contains(message, "PRIMARY KEY constraint failed"):
+		return ErrDuplicateKey
+	case strings.Contains(message, "FOREIGN KEY constraint failed"):
+		return ErrForeignKeyViolation
+	case strings.Contains(message, "constraint failed"):
+		return ErrConstraintViolation
+	case strings.Contains(message, "attempt to write a readonly database"):
+		return ErrReadOnly
+	default:
+		return nil
+	}
+}
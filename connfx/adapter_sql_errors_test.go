@@ -0,0 +1,192 @@
+package connfx_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postgresError mimics lib/pq's *Error and pgx's *PgError, which expose SQLState().
+type postgresError struct {
+	code    string
+	message string
+}
+
+func (e *postgresError) Error() string    { return "pq: " + e.message }
+func (e *postgresError) SQLState() string { return e.code }
+
+// mysqlError mimics go-sql-driver/mysql's *MySQLError message format.
+type mysqlError struct {
+	sqlState string
+	message  string
+	number   uint16
+}
+
+func (e *mysqlError) Error() string {
+	return fmt.Sprintf("Error %d (%s): %s", e.number, e.sqlState, e.message)
+}
+
+func TestNormalizeSQLError(t *testing.T) {
+	t.Parallel()
+
+	errPlain := errors.New("connection refused") //nolint:err113
+
+	tests := []struct {
+		err      error
+		expected []error
+		name     string
+	}{
+		{
+			name: "postgres_unique_violation",
+			err: &postgresError{
+				code:    "23505",
+				message: `duplicate key value violates unique constraint "users_email_key"`,
+			},
+			expected: []error{connfx.ErrDuplicateKey, connfx.ErrConstraintViolation},
+		},
+		{
+			name:     "postgres_foreign_key_violation",
+			err:      &postgresError{code: "23503", message: "insert or update violates foreign key"},
+			expected: []error{connfx.ErrForeignKeyViolation, connfx.ErrConstraintViolation},
+		},
+		{
+			name:     "postgres_check_violation",
+			err:      &postgresError{code: "23514", message: "violates check constraint"},
+			expected: []error{connfx.ErrConstraintViolation},
+		},
+		{
+			name:     "postgres_serialization_failure",
+			err:      &postgresError{code: "40001", message: "could not serialize access"},
+			expected: []error{connfx.ErrSerializationFailure},
+		},
+		{
+			name:     "postgres_deadlock",
+			err:      &postgresError{code: "40P01", message: "deadlock detected"},
+			expected: []error{connfx.ErrDeadlock},
+		},
+		{
+			name: "mysql_unique_violation",
+			err: &mysqlError{
+				number:   1062,
+				sqlState: "23000",
+				message:  "Duplicate entry 'a@b.c' for key 'users.email'",
+			},
+			expected: []error{connfx.ErrDuplicateKey, connfx.ErrConstraintViolation},
+		},
+		{
+			name: "mysql_foreign_key_violation",
+			err: &mysqlError{
+				number:   1452,
+				sqlState: "23000",
+				message:  "Cannot add or update a child row",
+			},
+			expected: []error{connfx.ErrForeignKeyViolation, connfx.ErrConstraintViolation},
+		},
+		{
+			name:     "mysql_deadlock",
+			err:      &mysqlError{number: 1213, sqlState: "40001", message: "Deadlock found"},
+			expected: []error{connfx.ErrDeadlock},
+		},
+		{
+			name: "postgres_read_only",
+			err: &postgresError{
+				code:    "25006",
+				message: "cannot execute INSERT in a read-only transaction",
+			},
+			expected: []error{connfx.ErrReadOnly, lib.ErrCategoryUnavailable},
+		},
+		{
+			name: "mysql_read_only",
+			err: &mysqlError{
+				number:   1290,
+				sqlState: "HY000",
+				message:  "The MySQL server is running with the --read-only option",
+			},
+			expected: []error{connfx.ErrReadOnly},
+		},
+		{
+			name:     "sqlite_read_only_message",
+			err:      errors.New("attempt to write a readonly database"), //nolint:err113
+			expected: []error{connfx.ErrReadOnly},
+		},
+		{
+			name:     "sqlite_unique_violation_message",
+			err:      errors.New("UNIQUE constraint failed: users.email"), //nolint:err113
+			expected: []error{connfx.ErrDuplicateKey, connfx.ErrConstraintViolation},
+		},
+		{
+			name:     "no_rows",
+			err:      fmt.Errorf("lookup: %w", sql.ErrNoRows),
+			expected: []error{connfx.ErrRecordNotFound},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			normalized := connfx.NormalizeSQLError(tt.err)
+
+			for _, expected := range tt.expected {
+				require.ErrorIs(t, normalized, expected)
+			}
+
+			// The driver error stays reachable for callers needing driver details
+			require.ErrorIs(t, normalized, tt.err)
+
+			// Normalizing twice does not wrap again
+			assert.Equal(t, normalized, connfx.NormalizeSQLError(normalized))
+		})
+	}
+
+	t.Run("unknown_error", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, errPlain, connfx.NormalizeSQLError(errPlain))
+		assert.NoError(t, connfx.NormalizeSQLError(nil))
+	})
+}
+
+func TestSQLConnection_NormalizesSQLiteErrors(t *testing.T) {
+	t.Parallel()
+
+	factory := connfx.NewSQLConnectionFactory("sqlite")
+
+	conn, err := factory.CreateConnection(t.Context(), &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "sqlite",
+		DSN:      ":memory:",
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close(t.Context()) })
+
+	sqlConn, ok := conn.(*connfx.SQLConnection)
+	require.True(t, ok)
+
+	// A single connection keeps the in-memory database alive across statements
+	sqlConn.GetDB().SetMaxOpenConns(1)
+
+	_, err = sqlConn.Execute(
+		t.Context(),
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE)",
+	)
+	require.NoError(t, err)
+
+	_, err = sqlConn.Execute(t.Context(), "INSERT INTO users (email) VALUES (?)", "a@b.c")
+	require.NoError(t, err)
+
+	_, err = sqlConn.Execute(t.Context(), "INSERT INTO users (email) VALUES (?)", "a@b.c")
+	require.ErrorIs(t, err, connfx.ErrFailedToExecuteSQL)
+	require.ErrorIs(t, err, connfx.ErrDuplicateKey)
+	require.ErrorIs(t, err, connfx.ErrConstraintViolation)
+
+	_, err = sqlConn.Execute(t.Context(), "INSERT INTO users (email) VALUES (NULL)")
+	require.ErrorIs(t, err, connfx.ErrConstraintViolation)
+	require.NotErrorIs(t, err, connfx.ErrDuplicateKey)
+}
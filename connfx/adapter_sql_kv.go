@@ -0,0 +1,417 @@
+package connfx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultSQLKeyValueTable is the table SQLConnection keeps key-value data in unless the
+	// key_value_table property names another.
+	DefaultSQLKeyValueTable = "key_values"
+
+	// DefaultSQLLockTable is the table SQLConnection keeps locks in unless the lock_table
+	// property names another.
+	DefaultSQLLockTable = "locks"
+
+	// sqlDeleteBatchSize is how many keys DeleteByPrefix removes per statement.
+	sqlDeleteBatchSize = 100
+)
+
+var (
+	ErrInvalidSQLTableConfig = errors.New("invalid SQL table configuration")
+	ErrSQLKeyValueOperation  = errors.New("SQL key-value operation failed")
+	ErrSQLEmptyPrefix        = errors.New("SQL key prefix must not be empty")
+)
+
+// sqlLikeEscaper escapes the LIKE wildcards of a prefix, and the "!" escape character.
+var sqlLikeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// sqlTableConfig holds the names of the tables backing the repository ports of
+// SQLConnection, read from ConfigTarget.Properties.
+type sqlTableConfig struct {
+	keyValue string
+	lock     string
+}
+
+// parseSQLTableConfig reads key_value_table and lock_table. Table names may be schema-qualified
+// ("app.key_values") but are otherwise plain identifiers, since they are written into the
+// statements as they are.
+func parseSQLTableConfig(properties map[string]any) (sqlTableConfig, error) {
+	tables := sqlTableConfig{
+		keyValue: DefaultSQLKeyValueTable,
+		lock:     DefaultSQLLockTable,
+	}
+
+	if err := setSQLTableProperty(properties, "key_value_table", &tables.keyValue); err != nil {
+		return tables, err
+	}
+
+	if err := setSQLTableProperty(properties, "lock_table", &tables.lock); err != nil {
+		return tables, err
+	}
+
+	return tables, nil
+}
+
+func setSQLTableProperty(properties map[string]any, key string, target *string) error {
+	value, ok, err := stringProperty(properties, key)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSQLTableConfig, err)
+	}
+
+	if !ok {
+		return nil
+	}
+
+	for part := range strings.SplitSeq(value, ".") {
+		if part == "" || !isSQLIdentifierStart(part[0]) || sqlIdentifierLength(part) != len(part) {
+			return fmt.Errorf(
+				"%w (property=%q, value=%q): not an identifier",
+				ErrInvalidSQLTableConfig,
+				key,
+				value,
+			)
+		}
+	}
+
+	*target = value
+
+	return nil
+}
+
+// Repository interface implementation.
+//
+// Keys and values live in the key-value table, which has a kv_key primary key column and
+// a binary kv_value column (see the README for its schema).
+
+func (c *SQLConnection) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+
+	err := c.queryRowNamed(
+		ctx,
+		"SELECT kv_value FROM "+c.tables.keyValue+" WHERE kv_key = :key",
+		map[string]any{"key": key},
+		&value,
+	)
+	if errors.Is(err, ErrRecordNotFound) {
+		return nil, nil // Key doesn't exist, return nil without error
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w (operation=get, key=%q): %w", ErrSQLKeyValueOperation, key, err)
+	}
+
+	// Drivers may return an empty value as nil, which would read as a missing key
+	if value == nil {
+		value = []byte{}
+	}
+
+	return value, nil
+}
+
+// Set inserts the key, or updates it if it exists already. If the key is removed between
+// the two statements, it starts over with the insert, so the write is never lost.
+func (c *SQLConnection) Set(ctx context.Context, key string, value []byte) error {
+	if err := c.set(ctx, key, value); err != nil {
+		return fmt.Errorf("%w (operation=set, key=%q): %w", ErrSQLKeyValueOperation, key, err)
+	}
+
+	return nil
+}
+
+func (c *SQLConnection) Remove(ctx context.Context, key string) error {
+	_, err := c.executeNamed(
+		ctx,
+		"DELETE FROM "+c.tables.keyValue+" WHERE kv_key = :key",
+		map[string]any{"key": key},
+	)
+	if err != nil {
+		return fmt.Errorf("%w (operation=remove, key=%q): %w", ErrSQLKeyValueOperation, key, err)
+	}
+
+	return nil
+}
+
+func (c *SQLConnection) Update(ctx context.Context, key string, value []byte) error {
+	// As for Redis, update is the same as set
+	return c.Set(ctx, key, value)
+}
+
+func (c *SQLConnection) Exists(ctx context.Context, key string) (bool, error) {
+	var count int
+
+	err := c.queryRowNamed(
+		ctx,
+		"SELECT COUNT(*) FROM "+c.tables.keyValue+" WHERE kv_key = :key",
+		map[string]any{"key": key},
+		&count,
+	)
+	if err != nil {
+		return false, fmt.Errorf(
+			"%w (operation=exists, key=%q): %w",
+			ErrSQLKeyValueOperation,
+			key,
+			err,
+		)
+	}
+
+	return count > 0, nil
+}
+
+// CASRepository interface implementation.
+
+// CompareAndSwap inserts the key when expected is nil, and otherwise updates it with
+// "UPDATE ... WHERE kv_value = expected", so the database decides atomically which of
+// several concurrent writers wins.
+func (c *SQLConnection) CompareAndSwap(
+	ctx context.Context,
+	key string,
+	expected []byte,
+	newValue []byte,
+) (bool, error) {
+	swapped, err := c.compareAndSwap(ctx, key, expected, newValue)
+	if err != nil {
+		return false, fmt.Errorf(
+			"%w (operation=compare_and_swap, key=%q): %w",
+			ErrSQLKeyValueOperation,
+			key,
+			err,
+		)
+	}
+
+	return swapped, nil
+}
+
+func (c *SQLConnection) compareAndSwap(
+	ctx context.Context,
+	key string,
+	expected []byte,
+	newValue []byte,
+) (bool, error) {
+	if expected == nil {
+		return c.insertKeyValue(ctx, key, newValue)
+	}
+
+	result, err := c.executeNamed(
+		ctx,
+		"UPDATE "+c.tables.keyValue+
+			" SET kv_value = :value WHERE kv_key = :key AND kv_value = :expected",
+		map[string]any{"key": key, "value": newValue, "expected": expected},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err //nolint:wrapcheck
+	}
+
+	if affected > 0 || !bytes.Equal(expected, newValue) {
+		return affected > 0, nil
+	}
+
+	// MySQL counts changed rows rather than matched ones, so writing back the stored value
+	// affects none; the swap still succeeded if the value matches
+	var count int
+
+	err = c.queryRowNamed(
+		ctx,
+		"SELECT COUNT(*) FROM "+c.tables.keyValue+" WHERE kv_key = :key AND kv_value = :expected",
+		map[string]any{"key": key, "expected": expected},
+		&count,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (c *SQLConnection) set(ctx context.Context, key string, value []byte) error {
+	params := map[string]any{"key": key, "value": value}
+
+	for {
+		inserted, err := c.insertKeyValue(ctx, key, value)
+		if err != nil || inserted {
+			return err
+		}
+
+		result, err := c.executeNamed(
+			ctx,
+			"UPDATE "+c.tables.keyValue+" SET kv_value = :value WHERE kv_key = :key",
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		if affected > 0 {
+			return nil
+		}
+
+		// No row changed: either the key was removed since the insert, or (as MySQL counts
+		// changed rows rather than matched ones) it holds value already
+		var count int
+
+		err = c.queryRowNamed(
+			ctx,
+			"SELECT COUNT(*) FROM "+c.tables.keyValue+" WHERE kv_key = :key AND kv_value = :value",
+			params,
+			&count,
+		)
+		if err != nil || count > 0 {
+			return err
+		}
+	}
+}
+
+// insertKeyValue inserts the key, reporting false if it exists already.
+func (c *SQLConnection) insertKeyValue(
+	ctx context.Context,
+	key string,
+	value []byte,
+) (bool, error) {
+	_, err := c.executeNamed(
+		ctx,
+		"INSERT INTO "+c.tables.keyValue+" (kv_key, kv_value) VALUES (:key, :value)",
+		map[string]any{"key": key, "value": value},
+	)
+	if errors.Is(err, ErrDuplicateKey) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ScanRepository interface implementation.
+
+// Keys returns every key starting with prefix, matched with LIKE on the key column. The
+// wildcards in prefix are matched literally. Since LIKE ignores case on SQLite and on
+// MySQL's default collations, the matches are checked again here.
+func (c *SQLConnection) Keys(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := c.keysWithPrefix(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%w (operation=keys, prefix=%q): %w",
+			ErrSQLKeyValueOperation,
+			prefix,
+			err,
+		)
+	}
+
+	return keys, nil
+}
+
+// DeleteByPrefix removes every key starting with prefix in batches, and returns how many
+// keys were removed. An empty prefix would match the whole table, so it is rejected with
+// ErrSQLEmptyPrefix.
+func (c *SQLConnection) DeleteByPrefix(ctx context.Context, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, fmt.Errorf("%w (operation=delete_by_prefix)", ErrSQLEmptyPrefix)
+	}
+
+	deleted, err := c.deleteByPrefix(ctx, prefix)
+	if err != nil {
+		return deleted, fmt.Errorf(
+			"%w (operation=delete_by_prefix, prefix=%q): %w",
+			ErrSQLKeyValueOperation,
+			prefix,
+			err,
+		)
+	}
+
+	return deleted, nil
+}
+
+func (c *SQLConnection) keysWithPrefix(ctx context.Context, prefix string) ([]string, error) {
+	rewritten, args, err := bindNamedParameters(
+		"SELECT kv_key FROM "+c.tables.keyValue+" WHERE kv_key LIKE :pattern ESCAPE '!'",
+		map[string]any{"pattern": sqlLikeEscaper.Replace(prefix) + "%"},
+		c.usesDollarPlaceholders(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, rewritten, args...)
+	if err != nil {
+		return nil, NormalizeSQLError(err)
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	keys := make([]string, 0)
+
+	for rows.Next() {
+		var key string
+
+		if err := rows.Scan(&key); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, NormalizeSQLError(err)
+	}
+
+	return keys, nil
+}
+
+func (c *SQLConnection) deleteByPrefix(ctx context.Context, prefix string) (int, error) {
+	keys, err := c.keysWithPrefix(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+
+	for batch := range slices.Chunk(keys, sqlDeleteBatchSize) {
+		placeholders := make([]string, len(batch))
+		params := make(map[string]any, len(batch))
+
+		for i, key := range batch {
+			name := "key" + strconv.Itoa(i)
+			placeholders[i] = ":" + name
+			params[name] = key
+		}
+
+		result, err := c.executeNamed(
+			ctx,
+			"DELETE FROM "+c.tables.keyValue+
+				" WHERE kv_key IN ("+strings.Join(placeholders, ", ")+")",
+			params,
+		)
+		if err != nil {
+			return deleted, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return deleted, err //nolint:wrapcheck
+		}
+
+		deleted += int(affected)
+	}
+
+	return deleted, nil
+}
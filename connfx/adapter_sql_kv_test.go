@@ -0,0 +1,339 @@
+package connfx_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/connfx/conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSQLiteKeyValueConnection opens an in-memory SQLite connection with the key-value table
+// created under the given name.
+func newSQLiteKeyValueConnection(t *testing.T, table string) *connfx.SQLConnection {
+	t.Helper()
+
+	conn, err := connfx.NewSQLConnectionFactory("sqlite").CreateConnection(
+		t.Context(),
+		&connfx.ConfigTarget{ //nolint:exhaustruct
+			Protocol:   "sqlite",
+			DSN:        ":memory:",
+			Properties: map[string]any{"key_value_table": table},
+		},
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close(t.Context()) })
+
+	sqlConn, ok := conn.(*connfx.SQLConnection)
+	require.True(t, ok)
+
+	// A single connection keeps the in-memory database alive across statements
+	sqlConn.GetDB().SetMaxOpenConns(1)
+
+	_, err = sqlConn.Execute(
+		t.Context(),
+		"CREATE TABLE "+table+" (kv_key TEXT PRIMARY KEY, kv_value BLOB NOT NULL)",
+	)
+	require.NoError(t, err)
+
+	return sqlConn
+}
+
+func TestSQLConnection_KeyValue(t *testing.T) {
+	t.Parallel()
+
+	conn := newSQLiteKeyValueConnection(t, "sessions")
+	ctx := t.Context()
+
+	conformance.AssertConnection(t, conn)
+
+	value, err := conn.Get(ctx, "session:1")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	require.NoError(t, conn.Set(ctx, "session:1", []byte("alice")))
+	require.NoError(t, conn.Set(ctx, "session:1", []byte("bob")))
+	require.NoError(t, conn.Set(ctx, "session:2", []byte{}))
+
+	value, err = conn.Get(ctx, "session:1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bob"), value)
+
+	// An empty value is still a stored key
+	value, err = conn.Get(ctx, "session:2")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{}, value)
+
+	require.NoError(t, conn.Update(ctx, "session:1", []byte("carol")))
+
+	value, err = conn.Get(ctx, "session:1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("carol"), value)
+
+	require.NoError(t, conn.Remove(ctx, "session:1"))
+
+	exists, err := conn.Exists(ctx, "session:1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = conn.Exists(ctx, "session:2")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+// removingConn wraps a SQLite connection and deletes the row an UPDATE of the key-value
+// table targets just before the first such UPDATE runs, as a concurrent Remove would.
+type removingConn struct {
+	driver.Conn
+
+	removed atomic.Bool
+}
+
+func (c *removingConn) ExecContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Result, error) {
+	execer, _ := c.Conn.(driver.ExecerContext)
+
+	if strings.HasPrefix(query, "UPDATE key_values") && c.removed.CompareAndSwap(false, true) {
+		key := args[len(args)-1]
+		key.Ordinal = 1
+
+		_, err := execer.ExecContext(
+			ctx,
+			"DELETE FROM key_values WHERE kv_key = ?",
+			[]driver.NamedValue{key},
+		)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+	}
+
+	return execer.ExecContext(ctx, query, args) //nolint:wrapcheck
+}
+
+func (c *removingConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	queryer, _ := c.Conn.(driver.QueryerContext)
+
+	return queryer.QueryContext(ctx, query, args) //nolint:wrapcheck
+}
+
+type removingDriver struct {
+	base driver.Driver
+}
+
+func (d *removingDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.base.Open(dsn)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &removingConn{Conn: conn}, nil //nolint:exhaustruct
+}
+
+var removingDriverSeq atomic.Int64 //nolint:gochecknoglobals
+
+func TestSQLConnection_Set_RowRemovedConcurrently(t *testing.T) {
+	t.Parallel()
+
+	base, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+
+	sqliteDriver := base.Driver()
+	require.NoError(t, base.Close())
+
+	driverName := fmt.Sprintf("connfx_sqlite_removing_%d", removingDriverSeq.Add(1))
+	sql.Register(driverName, &removingDriver{base: sqliteDriver}) //nolint:exhaustruct
+
+	conn, err := connfx.NewSQLConnectionFactory(driverName).CreateConnection(
+		t.Context(),
+		&connfx.ConfigTarget{Protocol: driverName, DSN: ":memory:"}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close(t.Context()) })
+
+	sqlConn, ok := conn.(*connfx.SQLConnection)
+	require.True(t, ok)
+
+	// A single connection keeps the in-memory database alive across statements
+	sqlConn.GetDB().SetMaxOpenConns(1)
+
+	_, err = sqlConn.Execute(
+		t.Context(),
+		"CREATE TABLE key_values (kv_key TEXT PRIMARY KEY, kv_value BLOB NOT NULL)",
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	require.NoError(t, sqlConn.Set(ctx, "session:1", []byte("alice")))
+
+	// The row is removed between the insert finding the key and the update, so Set
+	// inserts it again instead of losing the write
+	require.NoError(t, sqlConn.Set(ctx, "session:1", []byte("bob")))
+
+	value, err := sqlConn.Get(ctx, "session:1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bob"), value)
+
+	// Writing back the stored value matches the row without changing it
+	require.NoError(t, sqlConn.Set(ctx, "session:1", []byte("bob")))
+}
+
+func TestSQLConnection_CompareAndSwap(t *testing.T) {
+	t.Parallel()
+
+	conn := newSQLiteKeyValueConnection(t, connfx.DefaultSQLKeyValueTable)
+	ctx := t.Context()
+
+	// A nil expected value only creates a missing key
+	swapped, err := conn.CompareAndSwap(ctx, "counter", nil, []byte("1"))
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	swapped, err = conn.CompareAndSwap(ctx, "counter", nil, []byte("9"))
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = conn.CompareAndSwap(ctx, "counter", []byte("1"), []byte("2"))
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	// A stale expected value conflicts and leaves the key alone
+	swapped, err = conn.CompareAndSwap(ctx, "counter", []byte("1"), []byte("3"))
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = conn.CompareAndSwap(ctx, "missing", []byte("1"), []byte("2"))
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	// Writing back the stored value still succeeds
+	swapped, err = conn.CompareAndSwap(ctx, "counter", []byte("2"), []byte("2"))
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	value, err := conn.Get(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2"), value)
+}
+
+func TestSQLConnectionFactory_TableProperties(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value any
+		name  string
+	}{
+		{name: "empty", value: ""},
+		{name: "not_a_string", value: 42},
+		{name: "injection", value: "kv; DROP TABLE users"},
+		{name: "empty_schema", value: ".kv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, property := range []string{"key_value_table", "lock_table"} {
+				_, err := connfx.NewSQLConnectionFactory("sqlite").CreateConnection(
+					t.Context(),
+					&connfx.ConfigTarget{ //nolint:exhaustruct
+						Protocol:   "sqlite",
+						DSN:        ":memory:",
+						Properties: map[string]any{property: tt.value},
+					},
+				)
+				require.ErrorIs(t, err, connfx.ErrInvalidSQLTableConfig)
+				assert.Contains(t, err.Error(), `property="`+property+`"`)
+			}
+		})
+	}
+}
+
+func TestSQLConnection_ScanByPrefix(t *testing.T) {
+	t.Parallel()
+
+	conn := newSQLiteKeyValueConnection(t, connfx.DefaultSQLKeyValueTable)
+	ctx := t.Context()
+
+	for _, key := range []string{
+		"user:1", "user:2", "User:3", "userx1", "user_1", "100%:a", "1000:b", "a!b:1", "a!!b:2",
+	} {
+		require.NoError(t, conn.Set(ctx, key, []byte("v")))
+	}
+
+	// The LIKE wildcards and the escape character in a prefix match literally
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "user:", want: []string{"user:1", "user:2"}},
+		{prefix: "user_", want: []string{"user_1"}},
+		{prefix: "100%", want: []string{"100%:a"}},
+		{prefix: "a!b", want: []string{"a!b:1"}},
+		{prefix: "missing:", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		keys, err := conn.Keys(ctx, tt.prefix)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, tt.want, keys, "prefix %q", tt.prefix)
+	}
+
+	deleted, err := conn.DeleteByPrefix(ctx, "user:")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	// Case-insensitive LIKE matches are left alone
+	exists, err := conn.Exists(ctx, "User:3")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	keys, err := conn.Keys(ctx, "user")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"userx1", "user_1"}, keys)
+
+	_, err = conn.DeleteByPrefix(ctx, "")
+	require.ErrorIs(t, err, connfx.ErrSQLEmptyPrefix)
+}
+
+func TestSQLConnection_DeleteByPrefix_Batches(t *testing.T) {
+	t.Parallel()
+
+	conn := newSQLiteKeyValueConnection(t, connfx.DefaultSQLKeyValueTable)
+	ctx := t.Context()
+
+	for i := range 250 {
+		require.NoError(t, conn.Set(ctx, "job:"+strconv.Itoa(i), []byte("v")))
+	}
+
+	require.NoError(t, conn.Set(ctx, "other", []byte("v")))
+
+	deleted, err := conn.DeleteByPrefix(ctx, "job:")
+	require.NoError(t, err)
+	assert.Equal(t, 250, deleted)
+
+	keys, err := conn.Keys(ctx, "job:")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	exists, err := conn.Exists(ctx, "other")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
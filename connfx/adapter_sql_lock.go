@@ -0,0 +1,176 @@
+package connfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrSQLLockOperation = errors.New("SQL lock operation failed")
+
+// LockRepository interface implementation.
+//
+// Each held lock is a row of the lock table, which has a lock_key primary key column, the
+// lock_token of the holder and expires_at, the end of the lease in Unix milliseconds (see
+// the README for its schema). Leases are measured on the clock of the application rather
+// than the database, so the clocks of the instances sharing a lock must agree to well
+// within the TTL.
+
+// AcquireLock removes the row of key if its lease ran out, then inserts one holding token;
+// the primary key lets a single contender in.
+func (c *SQLConnection) AcquireLock(
+	ctx context.Context,
+	key string,
+	token string,
+	ttl time.Duration,
+) (bool, error) {
+	acquired, err := c.acquireLock(ctx, key, token, ttl)
+	if err != nil {
+		return false, fmt.Errorf(
+			"%w (operation=acquire_lock, key=%q): %w",
+			ErrSQLLockOperation,
+			key,
+			err,
+		)
+	}
+
+	return acquired, nil
+}
+
+// RenewLock extends the lease of key to ttl from now if it holds token and has not
+// expired yet.
+func (c *SQLConnection) RenewLock(
+	ctx context.Context,
+	key string,
+	token string,
+	ttl time.Duration,
+) (bool, error) {
+	renewed, err := c.renewLock(ctx, key, token, ttl)
+	if err != nil {
+		return false, fmt.Errorf(
+			"%w (operation=renew_lock, key=%q): %w",
+			ErrSQLLockOperation,
+			key,
+			err,
+		)
+	}
+
+	return renewed, nil
+}
+
+// ReleaseLock deletes the row of key if it holds token and has not expired yet.
+func (c *SQLConnection) ReleaseLock(ctx context.Context, key string, token string) (bool, error) {
+	released, err := c.executeLockStatement(
+		ctx,
+		"DELETE FROM "+c.tables.lock+
+			" WHERE lock_key = :key AND lock_token = :token AND expires_at > :now",
+		map[string]any{"key": key, "token": token, "now": time.Now().UnixMilli()},
+	)
+	if err != nil {
+		return false, fmt.Errorf(
+			"%w (operation=release_lock, key=%q): %w",
+			ErrSQLLockOperation,
+			key,
+			err,
+		)
+	}
+
+	return released, nil
+}
+
+func (c *SQLConnection) acquireLock(
+	ctx context.Context,
+	key string,
+	token string,
+	ttl time.Duration,
+) (bool, error) {
+	now := time.Now()
+
+	_, err := c.executeNamed(
+		ctx,
+		"DELETE FROM "+c.tables.lock+" WHERE lock_key = :key AND expires_at <= :now",
+		map[string]any{"key": key, "now": now.UnixMilli()},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = c.executeNamed(
+		ctx,
+		"INSERT INTO "+c.tables.lock+" (lock_key, lock_token, expires_at)"+
+			" VALUES (:key, :token, :expires_at)",
+		map[string]any{"key": key, "token": token, "expires_at": now.Add(ttl).UnixMilli()},
+	)
+	if errors.Is(err, ErrDuplicateKey) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *SQLConnection) renewLock(
+	ctx context.Context,
+	key string,
+	token string,
+	ttl time.Duration,
+) (bool, error) {
+	now := time.Now()
+	params := map[string]any{
+		"key":        key,
+		"token":      token,
+		"now":        now.UnixMilli(),
+		"expires_at": now.Add(ttl).UnixMilli(),
+	}
+
+	renewed, err := c.executeLockStatement(
+		ctx,
+		"UPDATE "+c.tables.lock+" SET expires_at = :expires_at"+
+			" WHERE lock_key = :key AND lock_token = :token AND expires_at > :now",
+		params,
+	)
+	if err != nil || renewed {
+		return renewed, err
+	}
+
+	// MySQL counts changed rows rather than matched ones, so a renewal within the
+	// millisecond of the previous one affects none; the lease is still held if the row is
+	var count int
+
+	err = c.queryRowNamed(
+		ctx,
+		"SELECT COUNT(*) FROM "+c.tables.lock+
+			" WHERE lock_key = :key AND lock_token = :token AND expires_at = :expires_at",
+		params,
+		&count,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// executeLockStatement runs a statement on the row of a lock, reporting whether it
+// matched the row.
+func (c *SQLConnection) executeLockStatement(
+	ctx context.Context,
+	statement string,
+	params map[string]any,
+) (bool, error) {
+	result, err := c.executeNamed(ctx, statement, params)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err //nolint:wrapcheck
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,109 @@
+package connfx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSQLiteLockConnection opens an in-memory SQLite connection with the lock table created
+// under the given name.
+func newSQLiteLockConnection(t *testing.T, table string) *connfx.SQLConnection {
+	t.Helper()
+
+	conn, err := connfx.NewSQLConnectionFactory("sqlite").CreateConnection(
+		t.Context(),
+		&connfx.ConfigTarget{ //nolint:exhaustruct
+			Protocol:   "sqlite",
+			DSN:        ":memory:",
+			Properties: map[string]any{"lock_table": table},
+		},
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close(t.Context()) })
+
+	sqlConn, ok := conn.(*connfx.SQLConnection)
+	require.True(t, ok)
+
+	// A single connection keeps the in-memory database alive across statements
+	sqlConn.GetDB().SetMaxOpenConns(1)
+
+	_, err = sqlConn.Execute(
+		t.Context(),
+		"CREATE TABLE "+table+
+			" (lock_key TEXT PRIMARY KEY, lock_token TEXT NOT NULL, expires_at BIGINT NOT NULL)",
+	)
+	require.NoError(t, err)
+
+	return sqlConn
+}
+
+func TestSQLConnection_Lock(t *testing.T) {
+	t.Parallel()
+
+	conn := newSQLiteLockConnection(t, "job_locks")
+	ctx := t.Context()
+
+	acquired, err := conn.AcquireLock(ctx, "report", "first", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// The row is held until it is released or its lease runs out
+	acquired, err = conn.AcquireLock(ctx, "report", "second", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	renewed, err := conn.RenewLock(ctx, "report", "second", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, renewed)
+
+	released, err := conn.ReleaseLock(ctx, "report", "second")
+	require.NoError(t, err)
+	assert.False(t, released)
+
+	renewed, err = conn.RenewLock(ctx, "report", "first", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+
+	released, err = conn.ReleaseLock(ctx, "report", "first")
+	require.NoError(t, err)
+	assert.True(t, released)
+
+	acquired, err = conn.AcquireLock(ctx, "report", "second", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestSQLConnection_LockExpiry(t *testing.T) {
+	t.Parallel()
+
+	conn := newSQLiteLockConnection(t, connfx.DefaultSQLLockTable)
+	ctx := t.Context()
+
+	acquired, err := conn.AcquireLock(ctx, "report", "stale", 50*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	time.Sleep(100 * time.Millisecond)
+
+	// An expired lease can neither be renewed nor released by its holder
+	renewed, err := conn.RenewLock(ctx, "report", "stale", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, renewed)
+
+	acquired, err = conn.AcquireLock(ctx, "report", "current", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	released, err := conn.ReleaseLock(ctx, "report", "stale")
+	require.NoError(t, err)
+	assert.False(t, released)
+
+	released, err = conn.ReleaseLock(ctx, "report", "current")
+	require.NoError(t, err)
+	assert.True(t, released)
+}
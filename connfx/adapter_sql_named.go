@@ -0,0 +1,203 @@
+package connfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrMissingNamedParameter = errors.New("missing named parameter")
+	ErrUnterminatedSQLQuote  = errors.New("unterminated quote in SQL query")
+)
+
+// QueryNamed executes a query with :name placeholders, rewritten to the driver's
+// positional form: $1, $2, ... for PostgreSQL drivers, "?" otherwise. A parameter used
+// more than once is bound to every occurrence. Placeholders inside string literals,
+// quoted identifiers and comments are left alone, as are PostgreSQL "::" casts. Other
+// drivers also honor backslash-escaped quotes inside string literals, as MySQL does.
+func (c *SQLConnection) QueryNamed(
+	ctx context.Context,
+	query string,
+	params map[string]any,
+) (QueryResult, error) {
+	rewritten, args, err := bindNamedParameters(query, params, c.usesDollarPlaceholders())
+	if err != nil {
+		return nil, fmt.Errorf("%w (protocol=%q): %w", ErrFailedToQuerySQL, c.protocol, err)
+	}
+
+	return c.Query(ctx, rewritten, args...)
+}
+
+// executeNamed runs a command with :name placeholders, like QueryNamed.
+func (c *SQLConnection) executeNamed(
+	ctx context.Context,
+	command string,
+	params map[string]any,
+) (ExecuteResult, error) {
+	rewritten, args, err := bindNamedParameters(command, params, c.usesDollarPlaceholders())
+	if err != nil {
+		return nil, fmt.Errorf("%w (protocol=%q): %w", ErrFailedToExecuteSQL, c.protocol, err)
+	}
+
+	return c.Execute(ctx, rewritten, args...)
+}
+
+// queryRowNamed runs a query with :name placeholders, like QueryNamed, and scans its first
+// row into dest. A query without rows fails with ErrRecordNotFound.
+func (c *SQLConnection) queryRowNamed(
+	ctx context.Context,
+	query string,
+	params map[string]any,
+	dest ...any,
+) error {
+	rewritten, args, err := bindNamedParameters(query, params, c.usesDollarPlaceholders())
+	if err == nil {
+		err = NormalizeSQLError(c.db.QueryRowContext(ctx, rewritten, args...).Scan(dest...))
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w (protocol=%q): %w", ErrFailedToQuerySQL, c.protocol, err)
+	}
+
+	return nil
+}
+
+// usesDollarPlaceholders reports whether the driver expects $n placeholders.
+func (c *SQLConnection) usesDollarPlaceholders() bool {
+	return strings.Contains(c.protocol, "postgres") || strings.HasPrefix(c.protocol, "pgx")
+}
+
+// bindNamedParameters rewrites the :name placeholders of query into positional ones and
+// returns the arguments in placeholder order.
+func bindNamedParameters( //nolint:cyclop,funlen
+	query string,
+	params map[string]any,
+	dollar bool,
+) (string, []any, error) {
+	var (
+		rewritten strings.Builder
+		args      []any
+		// positions maps parameter names to their $n index when dollar is set
+		positions = map[string]int{}
+	)
+
+	rewritten.Grow(len(query))
+
+	for i := 0; i < len(query); {
+		char := query[i]
+
+		switch {
+		case char == '\'' || char == '"' || char == '`':
+			// Only MySQL-style drivers treat a backslash as an escape inside strings;
+			// PostgreSQL reads it literally
+			end := closingQuoteIndex(query[i+1:], char, !dollar && char != '`')
+			if end < 0 {
+				return "", nil, fmt.Errorf("%w (offset=%d)", ErrUnterminatedSQLQuote, i)
+			}
+
+			// A doubled quote escapes it and simply reads as two adjacent literals
+			rewritten.WriteString(query[i : i+end+2])
+			i += end + 2
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+
+			rewritten.WriteString(query[i : i+end])
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 4 //nolint:mnd
+			}
+
+			rewritten.WriteString(query[i : i+end+4])
+			i += end + 4 //nolint:mnd
+		case dollar && char == '$':
+			// PostgreSQL dollar-quoted strings: $$...$$ or $tag$...$tag$
+			tagLength := sqlIdentifierLength(query[i+1:])
+			if i+1+tagLength >= len(query) || query[i+1+tagLength] != '$' {
+				rewritten.WriteByte(char)
+				i++
+
+				continue
+			}
+
+			tag := query[i : i+tagLength+2]
+
+			end := strings.Index(query[i+len(tag):], tag)
+			if end < 0 {
+				return "", nil, fmt.Errorf("%w (offset=%d)", ErrUnterminatedSQLQuote, i)
+			}
+
+			rewritten.WriteString(query[i : i+len(tag)+end+len(tag)])
+			i += len(tag) + end + len(tag)
+		case char == ':' && strings.HasPrefix(query[i+1:], ":"):
+			rewritten.WriteString("::")
+			i += 2
+		case char == ':' && i+1 < len(query) && isSQLIdentifierStart(query[i+1]):
+			nameLength := sqlIdentifierLength(query[i+1:])
+			name := query[i+1 : i+1+nameLength]
+
+			value, ok := params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("%w (name=%q)", ErrMissingNamedParameter, name)
+			}
+
+			switch {
+			case !dollar:
+				args = append(args, value)
+				rewritten.WriteByte('?')
+			case positions[name] > 0:
+				rewritten.WriteString("$" + strconv.Itoa(positions[name]))
+			default:
+				args = append(args, value)
+				positions[name] = len(args)
+				rewritten.WriteString("$" + strconv.Itoa(len(args)))
+			}
+
+			i += 1 + nameLength
+		default:
+			rewritten.WriteByte(char)
+			i++
+		}
+	}
+
+	return rewritten.String(), args, nil
+}
+
+// closingQuoteIndex returns the index of the first quote in s that is not escaped by a
+// backslash (when backslashEscapes is set), or -1 if there is none.
+func closingQuoteIndex(s string, quote byte, backslashEscapes bool) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func isSQLIdentifierStart(char byte) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+// sqlIdentifierLength returns the length of the identifier at the start of s.
+func sqlIdentifierLength(s string) int {
+	for i := range len(s) {
+		if !isSQLIdentifierStart(s[i]) && (s[i] < '0' || s[i] > '9') {
+			return i
+		}
+	}
+
+	return len(s)
+}
@@ -0,0 +1,246 @@
+package connfx_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLiteNamedConnection(t *testing.T) *connfx.SQLConnection {
+	t.Helper()
+
+	factory := connfx.NewSQLConnectionFactory("sqlite")
+
+	conn, err := factory.CreateConnection(t.Context(), &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "sqlite",
+		DSN:      ":memory:",
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close(t.Context()) })
+
+	sqlConn, ok := conn.(*connfx.SQLConnection)
+	require.True(t, ok)
+
+	// A single connection keeps the in-memory database alive across statements
+	sqlConn.GetDB().SetMaxOpenConns(1)
+
+	return sqlConn
+}
+
+func TestSQLConnection_QueryNamed(t *testing.T) {
+	t.Parallel()
+
+	conn := newSQLiteNamedConnection(t)
+	ctx := t.Context()
+
+	_, err := conn.Execute(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, nick TEXT)")
+	require.NoError(t, err)
+
+	_, err = conn.Execute(ctx, `INSERT INTO users (name, nick) VALUES
+		('alice', 'al'), ('bob', 'alice'), ('carol', ':name'), ('dave', 'dave')`)
+	require.NoError(t, err)
+
+	rows, err := conn.QueryNamed(ctx, `
+		SELECT id FROM users -- skips :ignored
+		WHERE (name = :name OR nick = :name OR nick = ':name') /* :ignored */
+		AND id <> :excluded
+		ORDER BY id`,
+		map[string]any{"name": "alice", "excluded": 4, "unused": true},
+	)
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	var ids []int
+
+	for rows.Next() {
+		var id int
+
+		require.NoError(t, rows.Scan(&id))
+
+		ids = append(ids, id)
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, ids)
+
+	_, err = conn.QueryNamed(ctx, "SELECT id FROM users WHERE name = :name", nil)
+	require.ErrorIs(t, err, connfx.ErrFailedToQuerySQL)
+	require.ErrorIs(t, err, connfx.ErrMissingNamedParameter)
+
+	_, err = conn.QueryNamed(ctx, "SELECT id FROM users WHERE name = ':name", nil)
+	require.ErrorIs(t, err, connfx.ErrUnterminatedSQLQuote)
+}
+
+// capturingDriver is a database/sql driver that records the queries it receives and
+// answers them with no rows.
+type capturingDriver struct {
+	queries []string
+	args    [][]any
+	mu      sync.Mutex
+}
+
+func (d *capturingDriver) Open(dsn string) (driver.Conn, error) {
+	return &capturingConn{driver: d}, nil
+}
+
+type capturingConn struct {
+	driver *capturingDriver
+}
+
+func (c *capturingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errRecordingDriverUnsupported
+}
+
+func (c *capturingConn) Close() error {
+	return nil
+}
+
+func (c *capturingConn) Begin() (driver.Tx, error) {
+	return nil, errRecordingDriverUnsupported
+}
+
+func (c *capturingConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+
+	c.driver.queries = append(c.driver.queries, query)
+	c.driver.args = append(c.driver.args, values)
+
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestSQLConnection_QueryNamed_DollarPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	// Drivers named after PostgreSQL get $n placeholders
+	driverName := fmt.Sprintf("connfx_postgres_capturing_%d", recordingDriverSeq.Add(1))
+	capture := &capturingDriver{} //nolint:exhaustruct
+	sql.Register(driverName, capture)
+
+	conn, err := connfx.NewSQLConnectionFactory(driverName).CreateConnection(
+		t.Context(),
+		&connfx.ConfigTarget{Protocol: driverName, DSN: "capture"}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close(t.Context()) })
+
+	sqlConn, ok := conn.(*connfx.SQLConnection)
+	require.True(t, ok)
+
+	rows, err := sqlConn.QueryNamed(
+		t.Context(),
+		"SELECT :id::text, $$ :id $$, $tag$ :name $tag$ WHERE owner = :owner OR creator = :id",
+		map[string]any{"id": 7, "owner": "alice"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+
+	assert.Equal(t, []string{
+		"SELECT $1::text, $$ :id $$, $tag$ :name $tag$ WHERE owner = $2 OR creator = $1",
+	}, capture.queries)
+	assert.Equal(t, [][]any{{int64(7), "alice"}}, capture.args)
+}
+
+func TestSQLConnection_QueryNamed_QuoteEscapes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		driver   string
+		query    string
+		expected string
+		args     []any
+	}{
+		{
+			name:     "doubled_quote",
+			driver:   "mysql",
+			query:    "SELECT 'it''s :name' WHERE id = :id",
+			expected: "SELECT 'it''s :name' WHERE id = ?",
+			args:     []any{int64(7)},
+		},
+		{
+			name:     "backslash_escaped_quote",
+			driver:   "mysql",
+			query:    `SELECT 'it\'s :name' WHERE id = :id`,
+			expected: `SELECT 'it\'s :name' WHERE id = ?`,
+			args:     []any{int64(7)},
+		},
+		{
+			name:     "escaped_backslash_before_quote",
+			driver:   "mysql",
+			query:    `SELECT 'C:\\' WHERE id = :id`,
+			expected: `SELECT 'C:\\' WHERE id = ?`,
+			args:     []any{int64(7)},
+		},
+		{
+			name:     "postgres_literal_backslash",
+			driver:   "postgres",
+			query:    `SELECT 'C:\' WHERE id = :id`,
+			expected: `SELECT 'C:\' WHERE id = $1`,
+			args:     []any{int64(7)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			driverName := fmt.Sprintf(
+				"connfx_%s_capturing_%d",
+				tt.driver,
+				recordingDriverSeq.Add(1),
+			)
+			capture := &capturingDriver{} //nolint:exhaustruct
+			sql.Register(driverName, capture)
+
+			conn, err := connfx.NewSQLConnectionFactory(driverName).CreateConnection(
+				t.Context(),
+				&connfx.ConfigTarget{Protocol: driverName, DSN: "capture"}, //nolint:exhaustruct
+			)
+			require.NoError(t, err)
+
+			t.Cleanup(func() { _ = conn.Close(t.Context()) })
+
+			sqlConn, ok := conn.(*connfx.SQLConnection)
+			require.True(t, ok)
+
+			rows, err := sqlConn.QueryNamed(t.Context(), tt.query, map[string]any{"id": 7})
+			require.NoError(t, err)
+			require.NoError(t, rows.Close())
+
+			capture.mu.Lock()
+			defer capture.mu.Unlock()
+
+			assert.Equal(t, []string{tt.expected}, capture.queries)
+			assert.Equal(t, [][]any{tt.args}, capture.args)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package connfx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidSQLPoolConfig = errors.New("invalid SQL connection pool configuration")
+
+// sqlPoolConfig holds the pool settings read from ConfigTarget.Properties. Nil fields were
+// not configured and keep the database/sql defaults.
+type sqlPoolConfig struct {
+	maxOpenConns    *int
+	maxIdleConns    *int
+	connMaxIdleTime *time.Duration
+}
+
+// parseSQLPoolConfig reads max_open_conns, max_idle_conns and conn_max_idle_time.
+// Integers may be given as an int, int32 or int64, a whole float64 (as decoded from JSON or
+// YAML) or a numeric string; the idle time as a time.Duration or a duration string.
+func parseSQLPoolConfig(properties map[string]any) (sqlPoolConfig, error) {
+	var poolConfig sqlPoolConfig
+
+	maxOpenConns, ok, err := intProperty(properties, "max_open_conns")
+	if err != nil {
+		return poolConfig, fmt.Errorf("%w: %w", ErrInvalidSQLPoolConfig, err)
+	}
+
+	if ok {
+		poolConfig.maxOpenConns = &maxOpenConns
+	}
+
+	maxIdleConns, ok, err := intProperty(properties, "max_idle_conns")
+	if err != nil {
+		return poolConfig, fmt.Errorf("%w: %w", ErrInvalidSQLPoolConfig, err)
+	}
+
+	if ok {
+		poolConfig.maxIdleConns = &maxIdleConns
+	}
+
+	connMaxIdleTime, ok, err := durationProperty(properties, "conn_max_idle_time")
+	if err != nil {
+		return poolConfig, fmt.Errorf("%w: %w", ErrInvalidSQLPoolConfig, err)
+	}
+
+	if ok {
+		poolConfig.connMaxIdleTime = &connMaxIdleTime
+	}
+
+	return poolConfig, nil
+}
+
+func (c sqlPoolConfig) apply(db *sql.DB) {
+	if c.maxOpenConns != nil {
+		db.SetMaxOpenConns(*c.maxOpenConns)
+	}
+
+	if c.maxIdleConns != nil {
+		db.SetMaxIdleConns(*c.maxIdleConns)
+	}
+
+	if c.connMaxIdleTime != nil {
+		db.SetConnMaxIdleTime(*c.connMaxIdleTime)
+	}
+}
@@ -0,0 +1,96 @@
+package connfx_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLConnectionFactory_PoolProperties(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		properties      map[string]any
+		name            string
+		expectedErr     string
+		expectedMaxOpen int
+	}{
+		{
+			name:            "absent_properties_keep_defaults",
+			properties:      nil,
+			expectedMaxOpen: 0,
+		},
+		{
+			name: "typed_values",
+			properties: map[string]any{
+				"max_open_conns":     20,
+				"max_idle_conns":     5,
+				"conn_max_idle_time": 5 * time.Minute,
+			},
+			expectedMaxOpen: 20,
+		},
+		{
+			name: "decoded_config_values",
+			properties: map[string]any{
+				"max_open_conns":     float64(12),
+				"max_idle_conns":     "4",
+				"conn_max_idle_time": "90s",
+			},
+			expectedMaxOpen: 12,
+		},
+		{
+			name:        "negative_max_open_conns",
+			properties:  map[string]any{"max_open_conns": -1},
+			expectedErr: `property="max_open_conns", value=-1`,
+		},
+		{
+			name:        "fractional_max_idle_conns",
+			properties:  map[string]any{"max_idle_conns": 2.5},
+			expectedErr: `property="max_idle_conns", value=2.5`,
+		},
+		{
+			name:        "negative_conn_max_idle_time",
+			properties:  map[string]any{"conn_max_idle_time": "-1m"},
+			expectedErr: `property="conn_max_idle_time", value=-1m`,
+		},
+		{
+			name:        "unsupported_type",
+			properties:  map[string]any{"max_open_conns": true},
+			expectedErr: `property="max_open_conns", value=true`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			factory := connfx.NewSQLConnectionFactory("sqlite")
+
+			conn, err := factory.CreateConnection(t.Context(), &connfx.ConfigTarget{ //nolint:exhaustruct
+				Protocol:   "sqlite",
+				DSN:        ":memory:",
+				Properties: tt.properties,
+			})
+
+			if tt.expectedErr != "" {
+				require.ErrorIs(t, err, connfx.ErrInvalidSQLPoolConfig)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, conn)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			defer func() { require.NoError(t, conn.Close(t.Context())) }()
+
+			db, err := connfx.GetTypedConnection[*sql.DB](conn)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMaxOpen, db.Stats().MaxOpenConnections)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package connfx
+
+import (
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/eser/ajan/logfx"
+)
+
+const (
+	auditEventCreated     = "created"
+	auditEventReconnected = "reconnected"
+	auditEventRemoved     = "removed"
+	auditEventClosed      = "closed"
+)
+
+// WithAuditLogger makes the registry write an audit entry to logger for every connection
+// lifecycle event: created, reconnected, removed and closed (by Close). Entries carry the
+// connection name and protocol, the target with credentials redacted, and, once the
+// connection is gone, how long it was alive. Without it, no audit entries are written.
+func WithAuditLogger(logger *logfx.Logger) RegistryOption {
+	return func(registry *Registry) {
+		registry.auditLogger = logger
+	}
+}
+
+// auditCreated records a connection added to the registry.
+func (registry *Registry) auditCreated(name string, config *ConfigTarget) {
+	if registry.auditLogger == nil {
+		return
+	}
+
+	registry.auditMu.Lock()
+	registry.openedAt[name] = time.Now()
+	registry.auditMu.Unlock()
+
+	registry.auditLogger.Info(
+		"connection audit",
+		slog.String("event", auditEventCreated),
+		slog.String("name", name),
+		slog.String("protocol", config.Protocol),
+		slog.String("target", auditTarget(config)),
+	)
+}
+
+// auditReconnected records a connection that recovered after losing its backend.
+func (registry *Registry) auditReconnected(name string, protocol string) {
+	if registry.auditLogger == nil {
+		return
+	}
+
+	registry.auditLogger.Info(
+		"connection audit",
+		slog.String("event", auditEventReconnected),
+		slog.String("name", name),
+		slog.String("protocol", protocol),
+	)
+}
+
+// auditEnded records a connection that left the registry, along with its lifetime.
+func (registry *Registry) auditEnded(event string, name string, protocol string, err error) {
+	if registry.auditLogger == nil {
+		return
+	}
+
+	registry.auditMu.Lock()
+	openedAt, tracked := registry.openedAt[name]
+	delete(registry.openedAt, name)
+	registry.auditMu.Unlock()
+
+	attrs := []any{
+		slog.String("event", event),
+		slog.String("name", name),
+		slog.String("protocol", protocol),
+	}
+
+	if tracked {
+		attrs = append(attrs, slog.Duration("alive", time.Since(openedAt)))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	registry.auditLogger.Info("connection audit", attrs...)
+}
+
+// auditTarget describes where a connection points, without its credentials.
+func auditTarget(config *ConfigTarget) string {
+	switch {
+	case config.DSN != "":
+		return RedactDSN(config.DSN)
+	case config.URL != "":
+		return RedactDSN(config.URL)
+	case config.Host != "" && config.Port > 0:
+		return net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	default:
+		return config.Host
+	}
+}
@@ -0,0 +1,149 @@
+package connfx_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditBuffer collects the JSON entries written by an audit logger.
+type auditBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *auditBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p) //nolint:wrapcheck
+}
+
+func (b *auditBuffer) raw() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func (b *auditBuffer) entries(t *testing.T) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+
+	for line := range strings.SplitSeq(strings.TrimSpace(b.raw()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func newAuditLogger(output *auditBuffer) *logfx.Logger {
+	return logfx.NewLogger(logfx.WithFromSlog(slog.New(slog.NewJSONHandler(output, nil))))
+}
+
+func TestRegistry_AuditLogger(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	output := &auditBuffer{} //nolint:exhaustruct
+	registry := connfx.NewRegistry(
+		newMockLogger(),
+		connfx.WithAuditLogger(newAuditLogger(output)),
+	)
+	registry.RegisterFactory(&mockConnectionFactory{}) //nolint:exhaustruct
+
+	config := &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "mock",
+		DSN:      "postgres://app:s3cret@db:5432/orders?sslmode=disable&password=s3cret",
+	}
+
+	conn, err := registry.AddConnection(t.Context(), "orders", config)
+	require.NoError(t, err)
+
+	mock, ok := conn.(*mockConnection)
+	require.True(t, ok)
+
+	mock.transition(connfx.ConnectionStateReconnecting, errMockConnectionLost)
+	mock.transition(connfx.ConnectionStateReady, nil)
+
+	require.NoError(t, registry.RemoveConnection(t.Context(), "orders"))
+
+	_, err = registry.AddConnection(t.Context(), "cache", &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "mock",
+		Host:     "cache.internal",
+		Port:     6379,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, registry.Close(t.Context()))
+
+	assert.NotContains(t, output.raw(), "s3cret")
+
+	entries := output.entries(t)
+	require.Len(t, entries, 5)
+
+	events := make([]string, len(entries))
+	for i, entry := range entries {
+		assert.Equal(t, "connection audit", entry["msg"])
+		assert.Equal(t, "mock", entry["protocol"])
+
+		events[i], _ = entry["event"].(string)
+	}
+
+	assert.Equal(t, []string{"created", "reconnected", "removed", "created", "closed"}, events)
+
+	assert.Equal(t, "orders", entries[0]["name"])
+	assert.Equal(
+		t,
+		"postgres://app:****@db:5432/orders?sslmode=disable&password=****",
+		entries[0]["target"],
+	)
+	assert.NotContains(t, entries[0], "alive")
+
+	assert.Equal(t, "orders", entries[1]["name"])
+
+	assert.Equal(t, "orders", entries[2]["name"])
+	assert.Contains(t, entries[2], "alive")
+	assert.NotContains(t, entries[2], "error")
+
+	assert.Equal(t, "cache", entries[3]["name"])
+	assert.Equal(t, "cache.internal:6379", entries[3]["target"])
+
+	assert.Equal(t, "cache", entries[4]["name"])
+	assert.Contains(t, entries[4], "alive")
+}
+
+func TestRegistry_AuditLogger_Disabled(t *testing.T) {
+	t.Parallel()
+
+	output := &auditBuffer{} //nolint:exhaustruct
+
+	// The regular logger does not receive audit entries
+	registry := connfx.NewRegistry(newAuditLogger(output))
+	registry.RegisterFactory(&mockConnectionFactory{}) //nolint:exhaustruct
+
+	_, err := registry.AddConnection(
+		t.Context(),
+		"orders",
+		&connfx.ConfigTarget{Protocol: "mock"}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+	require.NoError(t, registry.RemoveConnection(t.Context(), "orders"))
+
+	assert.NotContains(t, output.raw(), "connection audit")
+}
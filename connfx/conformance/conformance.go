@@ -0,0 +1,163 @@
+// Package conformance checks that connfx adapters implement the repository ports implied by
+// the capabilities they declare, so an adapter can't claim a capability it doesn't support.
+// Queue adapters are also checked for the delivery contract they share with each other
+// (see CheckGroupDelivery).
+//
+// Adapters run it from their tests:
+//
+//	conn, err := factory.CreateConnection(ctx, config)
+//	require.NoError(t, err)
+//
+//	conformance.AssertConnection(t, conn)
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+)
+
+var (
+	ErrCapabilityNotImplemented = errors.New("declared capability is not implemented")
+	ErrUnknownBehavior          = errors.New("unknown connection behavior")
+	ErrConflictingBehaviors     = errors.New("conflicting connection behaviors")
+	ErrNoBehaviors              = errors.New("connection declares no behaviors")
+)
+
+// Requirement maps a declared capability to the repository port it requires.
+type Requirement struct {
+	// Port is the interface type the raw connection (or the connection itself) implements
+	Port reflect.Type
+	// Applies reports whether the requirement holds for the declared capabilities; nil
+	// means it always holds
+	Applies func(capabilities []connfx.ConnectionCapability) bool
+	// Capability is the declared capability
+	Capability connfx.ConnectionCapability
+}
+
+// Requirements lists the repository ports each capability requires. Capabilities without
+// an entry, such as the observability ones, are not checked.
+func Requirements() []Requirement {
+	return []Requirement{
+		{
+			Capability: connfx.ConnectionCapabilityKeyValue,
+			Port:       portOf[connfx.Repository](),
+			Applies:    nil,
+		},
+		{
+			Capability: connfx.ConnectionCapabilityDocument,
+			Port:       portOf[connfx.Repository](),
+			Applies:    nil,
+		},
+		{
+			Capability: connfx.ConnectionCapabilityCache,
+			Port:       portOf[connfx.CacheRepository](),
+			Applies:    nil,
+		},
+		{
+			Capability: connfx.ConnectionCapabilityQueue,
+			Port:       portOf[connfx.QueueRepository](),
+			Applies:    nil,
+		},
+		{
+			Capability: connfx.ConnectionCapabilityRelational,
+			Port:       portOf[connfx.QueryRepository](),
+			Applies:    nil,
+		},
+		{
+			// Relational adapters run transactions through their native driver; key-value
+			// and document stores must expose them as a TransactionalRepository
+			Capability: connfx.ConnectionCapabilityTransactional,
+			Port:       portOf[connfx.TransactionalRepository](),
+			Applies: func(capabilities []connfx.ConnectionCapability) bool {
+				return slices.Contains(capabilities, connfx.ConnectionCapabilityKeyValue) ||
+					slices.Contains(capabilities, connfx.ConnectionCapabilityDocument)
+			},
+		},
+	}
+}
+
+// Check returns an error for every declared capability whose repository port is
+// implemented by neither the raw connection nor the connection itself, and for invalid
+// behavior declarations. It returns nil for a conforming connection.
+func Check(conn connfx.Connection) error {
+	errs := checkBehaviors(conn.GetBehaviors())
+
+	capabilities := conn.GetCapabilities()
+	raw := conn.GetRawConnection()
+
+	for _, requirement := range Requirements() {
+		if !slices.Contains(capabilities, requirement.Capability) {
+			continue
+		}
+
+		if requirement.Applies != nil && !requirement.Applies(capabilities) {
+			continue
+		}
+
+		if implements(raw, requirement.Port) || implements(conn, requirement.Port) {
+			continue
+		}
+
+		errs = append(errs, fmt.Errorf(
+			"%w (protocol=%q, capability=%q, port=%s, raw=%T)",
+			ErrCapabilityNotImplemented,
+			conn.GetProtocol(),
+			requirement.Capability,
+			requirement.Port,
+			raw,
+		))
+	}
+
+	return errors.Join(errs...)
+}
+
+// AssertConnection fails the test for every conformance problem Check reports.
+func AssertConnection(t testing.TB, conn connfx.Connection) {
+	t.Helper()
+
+	if err := Check(conn); err != nil {
+		t.Errorf("connection does not conform to its declared capabilities:\n%v", err)
+	}
+}
+
+func checkBehaviors(behaviors []connfx.ConnectionBehavior) []error {
+	if len(behaviors) == 0 {
+		return []error{ErrNoBehaviors}
+	}
+
+	var errs []error
+
+	for _, behavior := range behaviors {
+		switch behavior {
+		case connfx.ConnectionBehaviorStateful,
+			connfx.ConnectionBehaviorStateless,
+			connfx.ConnectionBehaviorStreaming:
+		default:
+			errs = append(errs, fmt.Errorf("%w (behavior=%q)", ErrUnknownBehavior, behavior))
+		}
+	}
+
+	if slices.Contains(behaviors, connfx.ConnectionBehaviorStateful) &&
+		slices.Contains(behaviors, connfx.ConnectionBehaviorStateless) {
+		errs = append(errs, fmt.Errorf(
+			"%w (behaviors=%q)",
+			ErrConflictingBehaviors,
+			behaviors,
+		))
+	}
+
+	return errs
+}
+
+func implements(value any, port reflect.Type) bool {
+	return value != nil && reflect.TypeOf(value).Implements(port)
+}
+
+func portOf[T any]() reflect.Type {
+	return reflect.TypeFor[T]()
+}
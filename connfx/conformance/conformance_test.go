@@ -0,0 +1,323 @@
+package conformance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/connfx/conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubConnection declares the given behaviors and capabilities and exposes raw as its
+// raw connection.
+type stubConnection struct {
+	raw          any
+	behaviors    []connfx.ConnectionBehavior
+	capabilities []connfx.ConnectionCapability
+}
+
+func (c *stubConnection) GetBehaviors() []connfx.ConnectionBehavior {
+	return c.behaviors
+}
+
+func (c *stubConnection) GetCapabilities() []connfx.ConnectionCapability {
+	return c.capabilities
+}
+
+func (c *stubConnection) GetProtocol() string {
+	return "stub"
+}
+
+func (c *stubConnection) GetState() connfx.ConnectionState {
+	return connfx.ConnectionStateReady
+}
+
+func (c *stubConnection) HealthCheck(ctx context.Context) *connfx.HealthStatus {
+	return &connfx.HealthStatus{ //nolint:exhaustruct
+		Timestamp: time.Now(),
+		State:     connfx.ConnectionStateReady,
+	}
+}
+
+func (c *stubConnection) Close(ctx context.Context) error {
+	return nil
+}
+
+func (c *stubConnection) GetRawConnection() any {
+	return c.raw
+}
+
+// keyValueStore implements connfx.Repository only.
+type keyValueStore struct{}
+
+func (s *keyValueStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, nil
+}
+
+func (s *keyValueStore) Set(ctx context.Context, key string, value []byte) error {
+	return nil
+}
+
+func (s *keyValueStore) Remove(ctx context.Context, key string) error {
+	return nil
+}
+
+func (s *keyValueStore) Update(ctx context.Context, key string, value []byte) error {
+	return nil
+}
+
+func (s *keyValueStore) Exists(ctx context.Context, key string) (bool, error) {
+	return false, nil
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		conn    *stubConnection
+		wantErr error
+		name    string
+	}{
+		{
+			name: "conforming_key_value_store",
+			conn: &stubConnection{
+				raw:          &keyValueStore{},
+				behaviors:    []connfx.ConnectionBehavior{connfx.ConnectionBehaviorStateful},
+				capabilities: []connfx.ConnectionCapability{connfx.ConnectionCapabilityKeyValue},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "unchecked_capabilities_are_ignored",
+			conn: &stubConnection{
+				raw:       nil,
+				behaviors: []connfx.ConnectionBehavior{connfx.ConnectionBehaviorStateless},
+				capabilities: []connfx.ConnectionCapability{
+					connfx.ConnectionCapabilityObservability,
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "declared_cache_without_cache_repository",
+			conn: &stubConnection{
+				raw:       &keyValueStore{},
+				behaviors: []connfx.ConnectionBehavior{connfx.ConnectionBehaviorStateful},
+				capabilities: []connfx.ConnectionCapability{
+					connfx.ConnectionCapabilityKeyValue,
+					connfx.ConnectionCapabilityCache,
+				},
+			},
+			wantErr: conformance.ErrCapabilityNotImplemented,
+		},
+		{
+			name: "transactional_key_value_store_without_transactions",
+			conn: &stubConnection{
+				raw:       &keyValueStore{},
+				behaviors: []connfx.ConnectionBehavior{connfx.ConnectionBehaviorStateful},
+				capabilities: []connfx.ConnectionCapability{
+					connfx.ConnectionCapabilityKeyValue,
+					connfx.ConnectionCapabilityTransactional,
+				},
+			},
+			wantErr: conformance.ErrCapabilityNotImplemented,
+		},
+		{
+			name: "conflicting_behaviors",
+			conn: &stubConnection{
+				raw: &keyValueStore{},
+				behaviors: []connfx.ConnectionBehavior{
+					connfx.ConnectionBehaviorStateful,
+					connfx.ConnectionBehaviorStateless,
+				},
+				capabilities: []connfx.ConnectionCapability{connfx.ConnectionCapabilityKeyValue},
+			},
+			wantErr: conformance.ErrConflictingBehaviors,
+		},
+		{
+			name: "unknown_behavior",
+			conn: &stubConnection{
+				raw:          &keyValueStore{},
+				behaviors:    []connfx.ConnectionBehavior{"telepathic"},
+				capabilities: []connfx.ConnectionCapability{connfx.ConnectionCapabilityKeyValue},
+			},
+			wantErr: conformance.ErrUnknownBehavior,
+		},
+		{
+			name: "no_behaviors",
+			conn: &stubConnection{
+				raw:          &keyValueStore{},
+				behaviors:    nil,
+				capabilities: []connfx.ConnectionCapability{connfx.ConnectionCapabilityKeyValue},
+			},
+			wantErr: conformance.ErrNoBehaviors,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := conformance.Check(tt.conn)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCheck_ReportsEveryMissingPort(t *testing.T) {
+	t.Parallel()
+
+	err := conformance.Check(&stubConnection{
+		raw:       nil,
+		behaviors: []connfx.ConnectionBehavior{connfx.ConnectionBehaviorStateful},
+		capabilities: []connfx.ConnectionCapability{
+			connfx.ConnectionCapabilityCache,
+			connfx.ConnectionCapabilityQueue,
+		},
+	})
+	require.ErrorIs(t, err, conformance.ErrCapabilityNotImplemented)
+	assert.Contains(t, err.Error(), `capability="cache"`)
+	assert.Contains(t, err.Error(), `capability="queue"`)
+}
+
+// stubQueue delivers published messages to a single group consumer unless dropPublishes
+// is set, in which case they are lost like plain AMQP publishes to a grouped queue.
+type stubQueue struct {
+	messages      chan connfx.Message
+	dropPublishes bool
+}
+
+func newStubQueue(dropPublishes bool) *stubQueue {
+	return &stubQueue{messages: make(chan connfx.Message, 1), dropPublishes: dropPublishes}
+}
+
+func (q *stubQueue) QueueDeclare(ctx context.Context, name string) (string, error) {
+	return name, nil
+}
+
+func (q *stubQueue) QueueDeclareWithConfig(
+	ctx context.Context,
+	name string,
+	config connfx.QueueConfig,
+) (string, error) {
+	return name, nil
+}
+
+func (q *stubQueue) Publish(ctx context.Context, queueName string, body []byte) error {
+	return q.PublishWithHeaders(ctx, queueName, body, nil)
+}
+
+func (q *stubQueue) PublishWithHeaders(
+	ctx context.Context,
+	queueName string,
+	body []byte,
+	headers map[string]any,
+) error {
+	if q.dropPublishes {
+		return nil
+	}
+
+	msg := connfx.Message{Body: body} //nolint:exhaustruct
+	msg.SetAckFunc(func() error { return nil })
+
+	q.messages <- msg
+
+	return nil
+}
+
+func (q *stubQueue) Consume(
+	ctx context.Context,
+	queueName string,
+	config connfx.ConsumerConfig,
+) (<-chan connfx.Message, <-chan error) {
+	return q.messages, make(chan error)
+}
+
+func (q *stubQueue) ConsumeWithGroup(
+	ctx context.Context,
+	queueName string,
+	consumerGroup string,
+	consumerName string,
+	config connfx.ConsumerConfig,
+) (<-chan connfx.Message, <-chan error) {
+	return q.messages, make(chan error)
+}
+
+func (q *stubQueue) ClaimPendingMessages(
+	ctx context.Context,
+	queueName string,
+	consumerGroup string,
+	consumerName string,
+	minIdleTime time.Duration,
+	count int,
+) ([]connfx.Message, error) {
+	return nil, nil
+}
+
+func (q *stubQueue) AckMessage(
+	ctx context.Context,
+	queueName, consumerGroup, receiptHandle string,
+) error {
+	return nil
+}
+
+func (q *stubQueue) DeleteMessage(ctx context.Context, queueName, receiptHandle string) error {
+	return nil
+}
+
+// stubGroupQueue drops plain publishes but delivers those sent with PublishToGroups, as
+// the AMQP adapter does.
+type stubGroupQueue struct {
+	*stubQueue
+}
+
+func (q *stubGroupQueue) PublishToGroups(
+	ctx context.Context,
+	queueName string,
+	body []byte,
+	headers map[string]any,
+) error {
+	msg := connfx.Message{Body: body} //nolint:exhaustruct
+	msg.SetAckFunc(func() error { return nil })
+
+	q.messages <- msg
+
+	return nil
+}
+
+func TestCheckGroupDelivery(t *testing.T) {
+	t.Parallel()
+
+	t.Run("delivered", func(t *testing.T) {
+		t.Parallel()
+
+		require.NoError(t, conformance.CheckGroupDelivery(t.Context(), newStubQueue(false), "orders"))
+	})
+
+	t.Run("published_to_groups", func(t *testing.T) {
+		t.Parallel()
+
+		queue := &stubGroupQueue{stubQueue: newStubQueue(true)}
+
+		require.NoError(t, conformance.CheckGroupDelivery(t.Context(), queue, "orders"))
+	})
+
+	t.Run("lost", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+
+		err := conformance.CheckGroupDelivery(ctx, newStubQueue(true), "orders")
+		require.ErrorIs(t, err, conformance.ErrGroupMessageNotDelivered)
+	})
+}
@@ -0,0 +1,147 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+)
+
+var ErrGroupMessageNotDelivered = errors.New("published message was not delivered to the group")
+
+// GroupDeliveryTimeout bounds how long CheckGroupDelivery waits for the published message.
+const GroupDeliveryTimeout = 5 * time.Second
+
+const conformanceGroup = "conformance"
+
+// groupDeclarer is implemented by queue adapters that declare consumer groups up front,
+// such as the AMQP adapter.
+type groupDeclarer interface {
+	DeclareConsumerGroup(ctx context.Context, queueName, consumerGroup string) (string, error)
+}
+
+// groupPublisher is implemented by queue adapters whose plain Publish does not reach
+// consumer groups, such as the AMQP adapter.
+type groupPublisher interface {
+	PublishToGroups(ctx context.Context, queueName string, body []byte, headers map[string]any) error
+}
+
+// CheckGroupDelivery checks the consumer group contract every queue adapter shares: once a
+// consumer group of queueName exists, a message published to queueName reaches the group's
+// consumers. The group is created through DeclareConsumerGroup or, for stream adapters,
+// QueueStreamRepository.CreateConsumerGroup before publishing. Adapters that route groups
+// explicitly are published to with PublishToGroups, the others with the plain Publish.
+func CheckGroupDelivery(ctx context.Context, repo connfx.QueueRepository, queueName string) error {
+	if err := createGroup(ctx, repo, queueName); err != nil {
+		return err
+	}
+
+	body := []byte("conformance:" + queueName)
+
+	if err := publish(ctx, repo, queueName, body); err != nil {
+		return fmt.Errorf(
+			"%w (operation=publish, queue=%q): %w",
+			ErrGroupMessageNotDelivered,
+			queueName,
+			err,
+		)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, GroupDeliveryTimeout)
+	defer cancel()
+
+	messages, errs := repo.ConsumeWithGroup(
+		ctx,
+		queueName,
+		conformanceGroup,
+		conformanceGroup+"-1",
+		connfx.DefaultConsumerConfig(),
+	)
+
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return fmt.Errorf("%w (queue=%q)", ErrGroupMessageNotDelivered, queueName)
+			}
+
+			if err := msg.Ack(); err != nil {
+				return fmt.Errorf(
+					"%w (operation=ack, queue=%q): %w",
+					ErrGroupMessageNotDelivered,
+					queueName,
+					err,
+				)
+			}
+
+			if bytes.Equal(msg.Body, body) {
+				return nil
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+
+				continue
+			}
+
+			if err != nil {
+				return fmt.Errorf(
+					"%w (operation=consume, queue=%q): %w",
+					ErrGroupMessageNotDelivered,
+					queueName,
+					err,
+				)
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("%w (queue=%q)", ErrGroupMessageNotDelivered, queueName)
+		}
+	}
+}
+
+// AssertGroupDelivery fails the test when CheckGroupDelivery reports a problem.
+func AssertGroupDelivery(t testing.TB, repo connfx.QueueRepository, queueName string) {
+	t.Helper()
+
+	if err := CheckGroupDelivery(t.Context(), repo, queueName); err != nil {
+		t.Errorf("queue does not deliver published messages to its consumer groups:\n%v", err)
+	}
+}
+
+func publish(
+	ctx context.Context,
+	repo connfx.QueueRepository,
+	queueName string,
+	body []byte,
+) error {
+	if publisher, ok := repo.(groupPublisher); ok {
+		return publisher.PublishToGroups(ctx, queueName, body, nil)
+	}
+
+	return repo.Publish(ctx, queueName, body)
+}
+
+func createGroup(ctx context.Context, repo connfx.QueueRepository, queueName string) error {
+	var err error
+
+	switch typed := repo.(type) {
+	case groupDeclarer:
+		_, err = typed.DeclareConsumerGroup(ctx, queueName, conformanceGroup)
+	case connfx.QueueStreamRepository:
+		err = typed.CreateConsumerGroup(ctx, queueName, conformanceGroup, "$")
+	}
+
+	if err != nil {
+		return fmt.Errorf(
+			"%w (operation=create_group, queue=%q): %w",
+			ErrGroupMessageNotDelivered,
+			queueName,
+			err,
+		)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 )
 
@@ -125,3 +126,35 @@ func GetTypedConnection[T any](conn Connection) (T, error) {
 
 	return typed, nil
 }
+
+// GetTypedConnectionWithCapability is GetTypedConnection for a connection that must
+// advertise the given capability. A connection without it fails with
+// ErrConnectionNotSupported naming the capability, before the type assertion.
+//
+// Example usage:
+//
+//	db, err := connfx.GetTypedConnectionWithCapability[*sql.DB](
+//		conn,
+//		connfx.ConnectionCapabilityRelational,
+//	)
+func GetTypedConnectionWithCapability[T any](
+	conn Connection,
+	capability ConnectionCapability,
+) (T, error) {
+	var zero T
+
+	if conn == nil {
+		return zero, ErrConnectionIsNil
+	}
+
+	if !slices.Contains(conn.GetCapabilities(), capability) {
+		return zero, fmt.Errorf(
+			"%w (protocol=%q, capability=%q)",
+			ErrConnectionNotSupported,
+			conn.GetProtocol(),
+			capability,
+		)
+	}
+
+	return GetTypedConnection[T](conn)
+}
@@ -1,6 +1,7 @@
 package connfx_test
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/connfx/conformance"
 	"github.com/eser/ajan/logfx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -49,6 +51,7 @@ func TestRegistry_SQLiteConnection(t *testing.T) {
 	assert.Contains(t, conn.GetCapabilities(), connfx.ConnectionCapabilityRelational)
 	assert.Equal(t, "sqlite", conn.GetProtocol())
 	assert.Equal(t, connfx.ConnectionStateReady, conn.GetState())
+	conformance.AssertConnection(t, conn)
 
 	// Test health check
 	status := conn.HealthCheck(ctx)
@@ -298,6 +301,71 @@ func TestRegistry_BehaviorFiltering(t *testing.T) {
 	assert.Len(t, sqliteConnections, 1)
 }
 
+// cacheConnection is a mockConnection advertising cache capabilities.
+type cacheConnection struct {
+	mockConnection
+}
+
+func (c *cacheConnection) GetCapabilities() []connfx.ConnectionCapability {
+	return []connfx.ConnectionCapability{
+		connfx.ConnectionCapabilityKeyValue,
+		connfx.ConnectionCapabilityCache,
+	}
+}
+
+// cacheConnectionFactory hands out cacheConnections for the "memcache" protocol.
+type cacheConnectionFactory struct{}
+
+func (f *cacheConnectionFactory) CreateConnection(
+	ctx context.Context,
+	config *connfx.ConfigTarget,
+) (connfx.Connection, error) {
+	return &cacheConnection{
+		mockConnection: mockConnection{ //nolint:exhaustruct
+			protocol: "memcache",
+			state:    connfx.ConnectionStateReady,
+		},
+	}, nil
+}
+
+func (f *cacheConnectionFactory) GetProtocol() string {
+	return "memcache"
+}
+
+func TestRegistry_CapabilityFiltering(t *testing.T) {
+	t.Parallel()
+
+	logger := newMockLogger()
+	registry := connfx.NewRegistry(logger)
+
+	registry.RegisterFactory(connfx.NewSQLConnectionFactory("sqlite"))
+	registry.RegisterFactory(&cacheConnectionFactory{})
+
+	ctx := t.Context()
+
+	_, err := registry.AddConnection(ctx, "db", &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "sqlite",
+		DSN:      ":memory:",
+	})
+	require.NoError(t, err)
+
+	cache, err := registry.AddConnection(ctx, "cache", &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "memcache",
+	})
+	require.NoError(t, err)
+
+	// Only the cache-capable connection is found, without knowing its protocol
+	cacheConnections := registry.GetByCapability(connfx.ConnectionCapabilityCache)
+	require.Len(t, cacheConnections, 1)
+	assert.Same(t, cache, cacheConnections[0])
+
+	relationalConnections := registry.GetByCapability(connfx.ConnectionCapabilityRelational)
+	require.Len(t, relationalConnections, 1)
+	assert.Equal(t, "sqlite", relationalConnections[0].GetProtocol())
+
+	assert.Empty(t, registry.GetByCapability(connfx.ConnectionCapabilityQueue))
+}
+
 func TestRegistry_AdapterRegistration(t *testing.T) {
 	t.Parallel()
 
@@ -355,6 +423,52 @@ func TestGetTypedConnection(t *testing.T) {
 	assert.ErrorIs(t, err, connfx.ErrConnectionIsNil)
 }
 
+func TestGetTypedConnectionWithCapability(t *testing.T) {
+	t.Parallel()
+
+	logger := newMockLogger()
+	registry := connfx.NewRegistry(logger)
+
+	registry.RegisterFactory(connfx.NewSQLConnectionFactory("sqlite"))
+
+	config := &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "sqlite",
+		DSN:      ":memory:",
+	}
+
+	conn, err := registry.AddConnection(t.Context(), "db", config)
+	require.NoError(t, err)
+
+	db, err := connfx.GetTypedConnectionWithCapability[*sql.DB](
+		conn,
+		connfx.ConnectionCapabilityRelational,
+	)
+	require.NoError(t, err)
+	require.NoError(t, db.Ping())
+
+	// A missing capability is reported before the type is checked
+	_, err = connfx.GetTypedConnectionWithCapability[*sql.DB](
+		conn,
+		connfx.ConnectionCapabilityCache,
+	)
+	require.ErrorIs(t, err, connfx.ErrConnectionNotSupported)
+	require.NotErrorIs(t, err, connfx.ErrInvalidType)
+	assert.Contains(t, err.Error(), `capability="cache"`)
+
+	// With the capability in place, a wrong type still fails the assertion
+	_, err = connfx.GetTypedConnectionWithCapability[*http.Client](
+		conn,
+		connfx.ConnectionCapabilityRelational,
+	)
+	require.ErrorIs(t, err, connfx.ErrInvalidType)
+
+	_, err = connfx.GetTypedConnectionWithCapability[*sql.DB](
+		nil,
+		connfx.ConnectionCapabilityRelational,
+	)
+	require.ErrorIs(t, err, connfx.ErrConnectionIsNil)
+}
+
 func TestRegistry_ErrorHandling(t *testing.T) {
 	t.Parallel()
 
@@ -2,6 +2,7 @@ package connfx
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -67,6 +68,72 @@ type CacheRepository interface {
 	Expire(ctx context.Context, key string, expiration time.Duration) error
 }
 
+// CASRepository extends Repository with an atomic compare-and-swap, the building block
+// for optimistic concurrency on a single key. The Redis, SQL and memory adapters implement
+// it; the MongoDB adapter does not.
+type CASRepository interface {
+	Repository
+
+	// CompareAndSwap stores newValue only if the current value equals expected, reporting
+	// whether it did. A nil expected value means the key must not exist yet.
+	CompareAndSwap(ctx context.Context, key string, expected []byte, newValue []byte) (bool, error)
+}
+
+// LockRepository defines the port for leased locks: a key held under a unique token that
+// expires after its TTL unless renewed, so a crashed holder cannot keep it forever. The
+// Redis adapter implements it with SET NX PX, and the SQL adapter with a row per lock.
+type LockRepository interface {
+	// AcquireLock sets key to token with the given TTL only if key does not exist,
+	// reporting whether it did
+	AcquireLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+
+	// RenewLock resets the TTL of key only if it still holds token, reporting whether it did
+	RenewLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+
+	// ReleaseLock removes key only if it still holds token, reporting whether it did
+	ReleaseLock(ctx context.Context, key string, token string) (bool, error)
+}
+
+// ScanRepository extends Repository with operations over every key sharing a prefix.
+// Implementations iterate incrementally rather than blocking the server, and results
+// come back in no particular order. The Redis, SQL and memory adapters implement it.
+type ScanRepository interface {
+	Repository
+
+	// Keys returns every key starting with prefix
+	Keys(ctx context.Context, prefix string) ([]string, error)
+
+	// DeleteByPrefix removes every key starting with prefix and returns how many were
+	// removed. Implementations reject an empty prefix rather than removing every key
+	DeleteByPrefix(ctx context.Context, prefix string) (int, error)
+}
+
+// PubSubRepository defines the port for fire-and-forget publish/subscribe messaging.
+// Unlike queues, messages are only delivered to subscribers connected at publish time.
+type PubSubRepository interface {
+	// PublishToChannel publishes a payload to every current subscriber of a channel
+	PublishToChannel(ctx context.Context, channel string, payload []byte) error
+
+	// Subscribe starts receiving messages published to the given channels
+	Subscribe(ctx context.Context, channels ...string) (*Subscription, error)
+}
+
+// HashRepository defines the port for field-level operations on hashes, allowing parts
+// of a stored object to be read or updated without rewriting the whole value.
+type HashRepository interface {
+	// HSet sets the given fields of a hash, creating the hash if needed
+	HSet(ctx context.Context, key string, fields map[string][]byte) error
+
+	// HGet returns a single field of a hash (nil if the hash or field doesn't exist)
+	HGet(ctx context.Context, key string, field string) ([]byte, error)
+
+	// HGetAll returns every field of a hash (empty if the hash doesn't exist)
+	HGetAll(ctx context.Context, key string) (map[string][]byte, error)
+
+	// HDel removes the given fields from a hash
+	HDel(ctx context.Context, key string, fields ...string) error
+}
+
 // TransactionalRepository extends Repository with transaction support.
 type TransactionalRepository interface {
 	Repository
@@ -87,6 +154,22 @@ type TransactionContext interface {
 	GetRepository() Repository
 }
 
+// SavepointTransactionContext extends TransactionContext with savepoints (e.g. SQL
+// SAVEPOINT), which let a nested unit of work roll back its own changes while the
+// enclosing transaction continues.
+type SavepointTransactionContext interface {
+	TransactionContext
+
+	// CreateSavepoint marks the current state of the transaction under name
+	CreateSavepoint(name string) error
+
+	// RollbackToSavepoint discards the changes made since the savepoint was created
+	RollbackToSavepoint(name string) error
+
+	// ReleaseSavepoint forgets the savepoint, keeping the changes made since
+	ReleaseSavepoint(name string) error
+}
+
 // QueryRepository defines the port for query operations (for SQL-like storages).
 type QueryRepository interface {
 	// Query executes a query and returns raw results
@@ -96,6 +179,13 @@ type QueryRepository interface {
 	Execute(ctx context.Context, command string, args ...any) (ExecuteResult, error)
 }
 
+// NamedQueryRepository is an optional extension of QueryRepository for queries with
+// :name placeholders instead of positional ones.
+type NamedQueryRepository interface {
+	// QueryNamed executes a query, binding each :name placeholder to params[name]
+	QueryNamed(ctx context.Context, query string, params map[string]any) (QueryResult, error)
+}
+
 // QueryResult represents query results.
 type QueryResult interface {
 	// Next advances to the next row
@@ -104,6 +194,9 @@ type QueryResult interface {
 	// Scan scans the current row into destinations
 	Scan(dest ...any) error
 
+	// Columns returns the column names of the result set
+	Columns() ([]string, error)
+
 	// Close closes the result set
 	Close() error
 }
@@ -202,10 +295,42 @@ type QueueConfig struct {
 	Exclusive bool
 }
 
+// DeadLetterQueueRepository is implemented by queue backends that can route rejected
+// and expired messages to a dead-letter queue.
+type DeadLetterQueueRepository interface {
+	QueueRepository
+
+	// QueueDeclareWithDeadLetter declares the dead-letter exchange and queue, binds them,
+	// and declares queue name with its dead-letter arguments pointing at the exchange
+	QueueDeclareWithDeadLetter(
+		ctx context.Context,
+		name string,
+		config DeadLetterConfig,
+	) (string, error)
+}
+
+// DeadLetterConfig holds configuration for declaring a queue with a dead-letter queue.
+type DeadLetterConfig struct {
+	// Exchange is the dead-letter exchange (default "<queue>.dlx")
+	Exchange string
+	// Queue is the dead-letter queue (default "<queue>.dlq")
+	Queue string
+	// RoutingKey binds the dead-letter queue to the exchange (default: Queue)
+	RoutingKey string
+	// MainQueue configures the main queue; its dead-letter arguments are set automatically
+	MainQueue QueueConfig
+	// DeadLetterQueue configures the dead-letter queue; the exchange shares its durability
+	DeadLetterQueue QueueConfig
+}
+
 // ConsumerConfig holds configuration for message consumption.
 type ConsumerConfig struct {
 	// Args additional arguments for queue declaration
 	Args map[string]any
+	// HeaderFilter only delivers messages whose headers contain all of these key/value pairs
+	HeaderFilter map[string]string
+	// Filter only delivers messages for which the predicate returns true
+	Filter func(msg *Message) bool
 	// AutoAck when true, the server will automatically acknowledge messages
 	AutoAck bool
 	// Exclusive when true, only this consumer can access the queue
@@ -222,6 +347,27 @@ type ConsumerConfig struct {
 	MaxRetries int
 	// RetryDelay sets delay between retries
 	RetryDelay time.Duration
+	// VisibilityTimeout sets how long a delivered message may stay unacknowledged
+	// before it is requeued for redelivery (0 = no deadline). datafx enforces it with a
+	// client-side timer, which requires AutoAck to be false
+	VisibilityTimeout time.Duration
+}
+
+// Matches reports whether a message passes the configured HeaderFilter and Filter.
+// Header values are compared by their string representation.
+func (c ConsumerConfig) Matches(msg *Message) bool {
+	for key, expected := range c.HeaderFilter {
+		value, ok := msg.Headers[key]
+		if !ok || fmt.Sprint(value) != expected {
+			return false
+		}
+	}
+
+	if c.Filter != nil && !c.Filter(msg) {
+		return false
+	}
+
+	return true
 }
 
 // StreamInfo provides information about a stream.
@@ -302,15 +448,18 @@ func (m *Message) SetNackFunc(nackFunc func(requeue bool) error) {
 // DefaultConsumerConfig returns a default configuration for consuming messages.
 func DefaultConsumerConfig() ConsumerConfig {
 	return ConsumerConfig{
-		Args:          make(map[string]any),
-		AutoAck:       false,
-		Exclusive:     false,
-		NoLocal:       false,
-		NoWait:        false,
-		PrefetchCount: DefaultPrefetchCount,
-		BlockTimeout:  DefaultBlockTimeout,
-		MaxRetries:    DefaultMaxRetries,
-		RetryDelay:    1 * time.Second,
+		Args:              make(map[string]any),
+		HeaderFilter:      nil,
+		Filter:            nil,
+		AutoAck:           false,
+		Exclusive:         false,
+		NoLocal:           false,
+		NoWait:            false,
+		PrefetchCount:     DefaultPrefetchCount,
+		BlockTimeout:      DefaultBlockTimeout,
+		MaxRetries:        DefaultMaxRetries,
+		RetryDelay:        1 * time.Second,
+		VisibilityTimeout: 0, // No deadline
 	}
 }
 
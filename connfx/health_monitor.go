@@ -0,0 +1,129 @@
+package connfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/eser/ajan/lib"
+)
+
+var ErrInvalidHealthMonitorInterval = errors.New("health monitor interval must be positive")
+
+// UnhealthyFunc is invoked when a health check finds a connection in the error state
+// after it was not in it before.
+type UnhealthyFunc func(name string, status *HealthStatus)
+
+// OnUnhealthy sets the callback invoked when HealthCheck, whether run by the health
+// monitor or called directly, sees a connection enter ConnectionStateError. Passing nil
+// disables it.
+func (registry *Registry) OnUnhealthy(handler UnhealthyFunc) {
+	registry.healthMu.Lock()
+	defer registry.healthMu.Unlock()
+
+	registry.onUnhealthy = handler
+}
+
+// LastHealth returns the status of the named connection from the most recent
+// HealthCheck, or nil when it has not been checked since it was added.
+func (registry *Registry) LastHealth(name string) *HealthStatus {
+	registry.healthMu.RLock()
+	defer registry.healthMu.RUnlock()
+
+	return registry.lastHealth[name]
+}
+
+// StartHealthMonitor runs HealthCheck right away and then every interval until ctx is done
+// or stop is called, so LastHealth can answer from cache, e.g. for a readiness endpoint.
+// Each round is bounded by the interval. stop waits for a running round to finish.
+// A non-positive interval returns ErrInvalidHealthMonitorInterval.
+func (registry *Registry) StartHealthMonitor(
+	ctx context.Context,
+	interval time.Duration,
+) (func(), error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("%w (interval=%s)", ErrInvalidHealthMonitorInterval, interval)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	check := func() {
+		checkCtx, checkCancel := context.WithTimeout(ctx, interval)
+		defer checkCancel()
+
+		registry.HealthCheck(checkCtx)
+	}
+
+	done := lib.SafeGo(func() error {
+		check()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				check()
+			}
+		}
+	}, func(recovered any, stack []byte) {
+		registry.logger.Error(
+			"health monitor panicked",
+			slog.Any("panic", recovered),
+			slog.String("stack", string(stack)),
+		)
+	})
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}, nil
+}
+
+// recordHealth caches the results of a health check and reports connections that
+// entered the error state.
+func (registry *Registry) recordHealth(results map[string]*HealthStatus) {
+	registry.healthMu.Lock()
+
+	previous := registry.lastHealth
+	registry.lastHealth = maps.Clone(results)
+	handler := registry.onUnhealthy
+
+	registry.healthMu.Unlock()
+
+	if handler == nil {
+		return
+	}
+
+	for name, status := range results {
+		if status == nil || status.State != ConnectionStateError {
+			continue
+		}
+
+		if last := previous[name]; last != nil && last.State == ConnectionStateError {
+			continue
+		}
+
+		handler(name, status)
+	}
+}
+
+// forgetHealth drops the cached status of removed connections.
+func (registry *Registry) forgetHealth(names ...string) {
+	registry.healthMu.Lock()
+	defer registry.healthMu.Unlock()
+
+	for _, name := range names {
+		delete(registry.lastHealth, name)
+	}
+}
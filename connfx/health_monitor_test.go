@@ -0,0 +1,82 @@
+package connfx_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_StartHealthMonitor(t *testing.T) {
+	t.Parallel()
+
+	mocks := &mockConnectionFactory{} //nolint:exhaustruct
+
+	registry := connfx.NewRegistry(newMockLogger())
+	registry.RegisterFactory(mocks)
+
+	_, err := registry.AddConnection(
+		t.Context(),
+		"cache",
+		&connfx.ConfigTarget{Protocol: "mock"}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	var (
+		unhealthy []string
+		mu        sync.Mutex
+	)
+
+	registry.OnUnhealthy(func(name string, status *connfx.HealthStatus) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		unhealthy = append(unhealthy, name+":"+status.State.String())
+	})
+
+	stop, err := registry.StartHealthMonitor(t.Context(), 5*time.Millisecond)
+	require.NoError(t, err)
+
+	defer stop()
+
+	// The first round runs right away
+	require.Eventually(t, func() bool {
+		return registry.LastHealth("cache") != nil
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, connfx.ConnectionStateReady, registry.LastHealth("cache").State)
+	assert.Nil(t, registry.LastHealth("missing"))
+
+	mocks.created[0].transition(connfx.ConnectionStateError, errMockConnectionLost)
+
+	require.Eventually(t, func() bool {
+		return registry.LastHealth("cache").State == connfx.ConnectionStateError
+	}, time.Second, time.Millisecond)
+
+	// Later rounds still in the error state do not report it again
+	time.Sleep(30 * time.Millisecond)
+
+	stop()
+	stop()
+
+	mu.Lock()
+	assert.Equal(t, []string{"cache:Error"}, unhealthy)
+	mu.Unlock()
+
+	require.NoError(t, registry.RemoveConnection(t.Context(), "cache"))
+	assert.Nil(t, registry.LastHealth("cache"))
+}
+
+func TestRegistry_StartHealthMonitor_InvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	registry := connfx.NewRegistry(newMockLogger())
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		stop, err := registry.StartHealthMonitor(t.Context(), interval)
+		require.ErrorIs(t, err, connfx.ErrInvalidHealthMonitorInterval)
+		assert.Nil(t, stop)
+	}
+}
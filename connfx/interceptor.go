@@ -0,0 +1,57 @@
+package connfx
+
+// ConnectionInterceptor decorates a connection created by the registry, for example to add
+// tracing or metrics around it. It returns the connection to register in place of the
+// given one.
+type ConnectionInterceptor func(conn Connection) Connection
+
+// ConnectionDecorator passes every Connection method through to the wrapped connection.
+// Interceptors embed it and override only the methods they instrument, so callers that
+// type-assert GetRawConnection (e.g. datafx) keep working unchanged.
+type ConnectionDecorator struct {
+	Connection
+}
+
+// Unwrap returns the decorated connection.
+func (d *ConnectionDecorator) Unwrap() Connection {
+	return d.Connection
+}
+
+// UnwrapConnection strips every decorator that exposes an Unwrap method and returns the
+// connection created by the adapter.
+func UnwrapConnection(conn Connection) Connection {
+	for {
+		wrapper, ok := conn.(interface{ Unwrap() Connection })
+		if !ok {
+			return conn
+		}
+
+		inner := wrapper.Unwrap()
+		if inner == nil {
+			return conn
+		}
+
+		conn = inner
+	}
+}
+
+// RegisterConnectionInterceptor appends an interceptor to the chain applied to every
+// connection added afterwards. Interceptors run in registration order, so the last one
+// registered becomes the outermost decorator. A nil result keeps the connection as is.
+func (registry *Registry) RegisterConnectionInterceptor(interceptor ConnectionInterceptor) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.interceptors = append(registry.interceptors, interceptor)
+}
+
+// intercept applies the registered interceptors to a newly created connection.
+func (registry *Registry) intercept(conn Connection) Connection {
+	for _, interceptor := range registry.interceptors {
+		if decorated := interceptor(conn); decorated != nil {
+			conn = decorated
+		}
+	}
+
+	return conn
+}
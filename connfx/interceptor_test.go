@@ -0,0 +1,110 @@
+package connfx_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingConnection counts the health checks and closes of the connection it decorates.
+type countingConnection struct {
+	connfx.ConnectionDecorator
+
+	healthChecks atomic.Int64
+	closes       atomic.Int64
+}
+
+func (c *countingConnection) HealthCheck(ctx context.Context) *connfx.HealthStatus {
+	c.healthChecks.Add(1)
+
+	return c.Connection.HealthCheck(ctx)
+}
+
+func (c *countingConnection) Close(ctx context.Context) error {
+	c.closes.Add(1)
+
+	return c.Connection.Close(ctx) //nolint:wrapcheck
+}
+
+func TestRegistry_RegisterConnectionInterceptor(t *testing.T) {
+	t.Parallel()
+
+	factory := &mockConnectionFactory{} //nolint:exhaustruct
+
+	registry := connfx.NewRegistry(newMockLogger())
+	registry.RegisterFactory(factory)
+
+	var (
+		order    []string
+		counters []*countingConnection
+		mu       sync.Mutex
+	)
+
+	registry.RegisterConnectionInterceptor(func(conn connfx.Connection) connfx.Connection {
+		order = append(order, "counting")
+
+		counting := &countingConnection{ //nolint:exhaustruct
+			ConnectionDecorator: connfx.ConnectionDecorator{Connection: conn},
+		}
+		counters = append(counters, counting)
+
+		return counting
+	})
+	registry.RegisterConnectionInterceptor(func(conn connfx.Connection) connfx.Connection {
+		order = append(order, "passthrough")
+
+		return nil
+	})
+
+	var events []connfx.ConnectionState
+
+	registry.OnStateChange(func(name string, from, to connfx.ConnectionState, reason error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		events = append(events, to)
+	})
+
+	conn, err := registry.AddConnection(
+		t.Context(),
+		"primary",
+		&connfx.ConfigTarget{Protocol: "mock"}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"counting", "passthrough"}, order)
+	require.Len(t, counters, 1)
+	assert.Same(t, counters[0], conn)
+	assert.Same(t, conn, registry.GetNamed("primary"))
+
+	// Raw connection and adapter type remain reachable through the decorator
+	mock, err := connfx.GetTypedConnection[*mockConnection](conn)
+	require.NoError(t, err)
+	assert.Same(t, factory.created[0], mock)
+	assert.Same(t, factory.created[0], connfx.UnwrapConnection(conn))
+	assert.Equal(t, "mock", conn.GetProtocol())
+
+	registry.HealthCheck(t.Context())
+	registry.HealthCheck(t.Context())
+	assert.Equal(t, int64(2), counters[0].healthChecks.Load())
+
+	// State transitions of the adapter are still reported
+	mock.transition(connfx.ConnectionStateError, errMockConnectionLost)
+
+	require.NoError(t, registry.Close(t.Context()))
+	assert.Equal(t, int64(1), counters[0].closes.Load())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(
+		t,
+		[]connfx.ConnectionState{connfx.ConnectionStateError, connfx.ConnectionStateDisconnected},
+		events,
+	)
+}
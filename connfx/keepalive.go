@@ -0,0 +1,53 @@
+package connfx
+
+import (
+	"context"
+	"time"
+
+	"github.com/eser/ajan/lib"
+)
+
+// keepalive periodically pings a backend so idle TCP connections aren't silently dropped
+// by load balancers or NAT gateways, and so drops are detected between operations.
+type keepalive struct {
+	cancel context.CancelFunc
+	done   <-chan error
+}
+
+// startKeepalive calls ping every interval until stopped. Each ping is bounded by the
+// interval, so a hung backend can't stall the heartbeat.
+func startKeepalive(interval time.Duration, ping func(ctx context.Context)) *keepalive {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := lib.SafeGo(func() error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				pingCtx, pingCancel := context.WithTimeout(ctx, interval)
+				ping(pingCtx)
+				pingCancel()
+			}
+		}
+	}, nil)
+
+	return &keepalive{cancel: cancel, done: done}
+}
+
+// stop ends the heartbeat and waits for an in-progress ping to finish.
+func (k *keepalive) stop() {
+	k.cancel()
+	<-k.done
+}
+
+// heartbeatIntervalProperty reads the "heartbeat_interval" property, given either as a
+// time.Duration or as a duration string such as "30s". It returns 0 when unset.
+func heartbeatIntervalProperty(properties map[string]any) (time.Duration, error) {
+	interval, _, err := durationProperty(properties, "heartbeat_interval")
+
+	return interval, err
+}
@@ -0,0 +1,311 @@
+package connfx_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedisServer answers PING over RESP2 and counts the pings it receives.
+type fakeRedisServer struct {
+	listener net.Listener
+	pings    atomic.Int64
+}
+
+func newFakeRedisServer(t *testing.T) *fakeRedisServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeRedisServer{listener: listener, pings: atomic.Int64{}}
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *fakeRedisServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		command, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+
+		var reply string
+
+		switch strings.ToUpper(command[0]) {
+		case "PING":
+			s.pings.Add(1)
+
+			reply = "+PONG\r\n"
+		case "HELLO":
+			// Make the client fall back to RESP2
+			reply = "-ERR unknown command 'HELLO'\r\n"
+		default:
+			reply = "+OK\r\n"
+		}
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "*")))
+	if err != nil {
+		return nil, err
+	}
+
+	command := make([]string, 0, count)
+
+	for range count {
+		if _, err := reader.ReadString('\n'); err != nil { // $<length>
+			return nil, err
+		}
+
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		command = append(command, strings.TrimSuffix(arg, "\r\n"))
+	}
+
+	return command, nil
+}
+
+func TestRedisConnection_Heartbeat(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeRedisServer(t)
+
+	conn, err := connfx.NewRedisConnectionFactory("redis").CreateConnection(
+		t.Context(),
+		&connfx.ConfigTarget{ //nolint:exhaustruct
+			Protocol: "redis",
+			DSN:      server.listener.Addr().String(),
+			Properties: map[string]any{
+				"heartbeat_interval": "20ms",
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	redisConn, ok := conn.(*connfx.RedisConnection)
+	require.True(t, ok)
+
+	config, ok := redisConn.GetStats()["config"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "20ms", config["heartbeat_interval"])
+
+	// The initial health check pings once; the heartbeat keeps pinging while idle
+	assert.Eventually(t, func() bool {
+		return server.pings.Load() >= 4
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, conn.Close(t.Context()))
+
+	// No pings are sent once the connection is closed
+	pings := server.pings.Load()
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, pings, server.pings.Load())
+}
+
+var errAMQPHandshakeIncomplete = errors.New("AMQP handshake incomplete")
+
+// amqpFrame encodes a frame of the given type (1 method, 2 header, 3 body) on a channel.
+func amqpFrame(frameType byte, channel uint16, payload []byte) []byte {
+	frame := []byte{frameType}
+	frame = binary.BigEndian.AppendUint16(frame, channel)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload))) //nolint:gosec
+	frame = append(frame, payload...)
+
+	return append(frame, 0xCE)
+}
+
+// amqpMethodFrame encodes a method frame.
+func amqpMethodFrame(channel, classID, methodID uint16, args []byte) []byte {
+	payload := binary.BigEndian.AppendUint16(nil, classID)
+	payload = binary.BigEndian.AppendUint16(payload, methodID)
+	payload = append(payload, args...)
+
+	return amqpFrame(1, channel, payload)
+}
+
+// readAMQPFrame reads a frame and returns its type, channel and payload.
+func readAMQPFrame(reader *bufio.Reader) (byte, uint16, []byte, error) {
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, 0, nil, err
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint32(header[3:])+1) // includes frame end
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, 0, nil, err
+	}
+
+	return header[0], binary.BigEndian.Uint16(header[1:3]), payload[:len(payload)-1], nil
+}
+
+// amqpConnectionStart encodes connection.start: version 0-9, no server properties,
+// PLAIN auth and the en_US locale.
+func amqpConnectionStart() []byte {
+	startArgs := []byte{0, 9, 0, 0, 0, 0}
+	startArgs = append(startArgs, 0, 0, 0, 5)
+	startArgs = append(startArgs, "PLAIN"...)
+	startArgs = append(startArgs, 0, 0, 0, 5)
+	startArgs = append(startArgs, "en_US"...)
+
+	return amqpMethodFrame(0, 10, 10, startArgs)
+}
+
+// amqpConnectionTune encodes connection.tune: no channel limit, 128 KiB frames and no
+// heartbeat preference.
+func amqpConnectionTune() []byte {
+	tuneArgs := binary.BigEndian.AppendUint16(nil, 0)
+	tuneArgs = binary.BigEndian.AppendUint32(tuneArgs, 131072)
+	tuneArgs = binary.BigEndian.AppendUint16(tuneArgs, 0)
+
+	return amqpMethodFrame(0, 10, 30, tuneArgs)
+}
+
+// negotiateAMQPHeartbeat runs the start of an AMQP 0-9-1 handshake on a single connection,
+// offering no heartbeat preference, and returns the heartbeat the client settles on.
+func negotiateAMQPHeartbeat(listener net.Listener) (uint16, error) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	protocolHeader := make([]byte, 8)
+	if _, err := io.ReadFull(reader, protocolHeader); err != nil {
+		return 0, err
+	}
+
+	if _, err := conn.Write(amqpConnectionStart()); err != nil {
+		return 0, err
+	}
+
+	if _, _, _, err := readAMQPFrame(reader); err != nil { // connection.start-ok
+		return 0, err
+	}
+
+	if _, err := conn.Write(amqpConnectionTune()); err != nil {
+		return 0, err
+	}
+
+	_, _, tuneOk, err := readAMQPFrame(reader)
+	if err != nil {
+		return 0, err
+	}
+
+	// class (2) + method (2) + channel-max (2) + frame-max (4) + heartbeat (2)
+	if len(tuneOk) < 12 || !bytes.Equal(tuneOk[:4], []byte{0, 10, 0, 31}) {
+		return 0, fmt.Errorf("%w: unexpected frame %x", errAMQPHandshakeIncomplete, tuneOk)
+	}
+
+	return binary.BigEndian.Uint16(tuneOk[10:12]), nil
+}
+
+func TestAMQPConnection_Heartbeat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		properties map[string]any
+		want       uint16
+	}{
+		{
+			name:       "default",
+			properties: nil,
+			want:       10,
+		},
+		{
+			name:       "duration",
+			properties: map[string]any{"heartbeat_interval": 7 * time.Second},
+			want:       7,
+		},
+		{
+			name:       "string",
+			properties: map[string]any{"heartbeat_interval": "45s"},
+			want:       45,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+
+			t.Cleanup(func() { _ = listener.Close() })
+
+			type result struct {
+				err       error
+				heartbeat uint16
+			}
+
+			negotiated := make(chan result, 1)
+
+			go func() {
+				heartbeat, err := negotiateAMQPHeartbeat(listener)
+				negotiated <- result{err: err, heartbeat: heartbeat}
+			}()
+
+			// The handshake is cut short, so connecting fails after the heartbeat is tuned
+			_, err = connfx.NewAMQPConnectionFactory("amqp").CreateConnection(
+				t.Context(),
+				&connfx.ConfigTarget{ //nolint:exhaustruct
+					Protocol:   "amqp",
+					DSN:        "amqp://guest:guest@" + listener.Addr().String() + "/",
+					Properties: tt.properties,
+				},
+			)
+			require.Error(t, err)
+
+			got := <-negotiated
+			require.NoError(t, got.err)
+			assert.Equal(t, tt.want, got.heartbeat)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package connfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/eser/ajan/metricsfx"
+)
+
+var (
+	ErrFailedToBuildStateTransitionsCounter = errors.New(
+		"failed to build connection state transitions counter",
+	)
+	ErrFailedToBuildHealthCheckFailuresCounter = errors.New(
+		"failed to build connection health check failures counter",
+	)
+	ErrFailedToBuildRegistryOperationsCounter = errors.New(
+		"failed to build connection registry operations counter",
+	)
+	ErrFailedToBuildHealthyConnectionsGauge = errors.New(
+		"failed to build healthy connections gauge",
+	)
+)
+
+const (
+	registryOperationAdded   = "added"
+	registryOperationRemoved = "removed"
+)
+
+// RegistryOption configures a Registry created by NewRegistry.
+type RegistryOption func(*Registry)
+
+// WithMetricsBuilder makes the registry record metrics on the given builder: connection
+// state transitions, failed health checks, added and removed connections, and the number
+// of healthy connections as of the last HealthCheck.
+func WithMetricsBuilder(builder *metricsfx.MetricsBuilder) RegistryOption {
+	return func(registry *Registry) {
+		registry.metricsBuilder = builder
+	}
+}
+
+// registryMetrics holds the metrics recorded by the registry. A nil *registryMetrics
+// records nothing.
+type registryMetrics struct {
+	stateTransitions    *metricsfx.CounterMetric
+	healthCheckFailures *metricsfx.CounterMetric
+	registryOperations  *metricsfx.CounterMetric
+	healthyConnections  *metricsfx.GaugeMetric
+}
+
+func newRegistryMetrics(builder *metricsfx.MetricsBuilder) (*registryMetrics, error) {
+	stateTransitions, err := builder.Counter(
+		"connfx_connection_state_transitions_total",
+		"Total number of connection state transitions, by the state entered",
+	).WithUnit("{transition}").Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBuildStateTransitionsCounter, err)
+	}
+
+	healthCheckFailures, err := builder.Counter(
+		"connfx_connection_health_check_failures_total",
+		"Total number of connection health checks that did not report a healthy connection",
+	).WithUnit("{check}").Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBuildHealthCheckFailuresCounter, err)
+	}
+
+	registryOperations, err := builder.Counter(
+		"connfx_connection_registry_operations_total",
+		"Total number of connections added to and removed from the registry",
+	).WithUnit("{operation}").Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBuildRegistryOperationsCounter, err)
+	}
+
+	healthyConnections, err := builder.Gauge(
+		"connfx_healthy_connections",
+		"Number of connections reported healthy by the last registry health check",
+	).WithUnit("{connection}").Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBuildHealthyConnectionsGauge, err)
+	}
+
+	return &registryMetrics{
+		stateTransitions:    stateTransitions,
+		healthCheckFailures: healthCheckFailures,
+		registryOperations:  registryOperations,
+		healthyConnections:  healthyConnections,
+	}, nil
+}
+
+func (metrics *registryMetrics) recordStateTransition(
+	name string,
+	protocol string,
+	to ConnectionState,
+) {
+	if metrics == nil {
+		return
+	}
+
+	metrics.stateTransitions.Inc(
+		context.Background(),
+		metricsfx.StringAttr("name", name),
+		metricsfx.StringAttr("protocol", protocol),
+		metricsfx.StringAttr("state", to.String()),
+	)
+}
+
+func (metrics *registryMetrics) recordOperation(
+	ctx context.Context,
+	name string,
+	protocol string,
+	operation string,
+) {
+	if metrics == nil {
+		return
+	}
+
+	metrics.registryOperations.Inc(
+		ctx,
+		metricsfx.StringAttr("name", name),
+		metricsfx.StringAttr("protocol", protocol),
+		metricsfx.StringAttr("operation", operation),
+	)
+}
+
+func (metrics *registryMetrics) recordHealthCheck(
+	ctx context.Context,
+	connections map[string]Connection,
+	results map[string]*HealthStatus,
+) {
+	if metrics == nil {
+		return
+	}
+
+	var healthy int64
+
+	for name, status := range results {
+		if isHealthyStatus(status) {
+			healthy++
+
+			continue
+		}
+
+		state := ConnectionStateError
+		if status != nil {
+			state = status.State
+		}
+
+		metrics.healthCheckFailures.Inc(
+			ctx,
+			metricsfx.StringAttr("name", name),
+			metricsfx.StringAttr("protocol", connections[name].GetProtocol()),
+			metricsfx.StringAttr("state", state.String()),
+		)
+	}
+
+	metrics.healthyConnections.Set(ctx, healthy)
+}
+
+// isHealthyStatus reports whether a health check found the connection usable.
+func isHealthyStatus(status *HealthStatus) bool {
+	if status == nil || status.Error != nil {
+		return false
+	}
+
+	return isHealthyState(status.State)
+}
+
+// isHealthyState reports whether a connection in the given state can serve requests.
+func isHealthyState(state ConnectionState) bool {
+	switch state { //nolint:exhaustive
+	case ConnectionStateConnected, ConnectionStateLive, ConnectionStateReady:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,153 @@
+package connfx_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/metricsfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// recordingExporter keeps the exported int64 data points by metric name and encoded
+// attributes. The SDK reuses the exported data after Export returns, so it is copied.
+type recordingExporter struct {
+	points map[string]map[string]int64
+	mu     sync.Mutex
+}
+
+func (e *recordingExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (e *recordingExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *recordingExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	encoder := attribute.DefaultEncoder()
+
+	for _, scope := range metrics.ScopeMetrics {
+		for _, metric := range scope.Metrics {
+			var dataPoints []metricdata.DataPoint[int64]
+
+			switch data := metric.Data.(type) {
+			case metricdata.Sum[int64]:
+				dataPoints = data.DataPoints
+			case metricdata.Gauge[int64]:
+				dataPoints = data.DataPoints
+			default:
+				continue
+			}
+
+			points := make(map[string]int64, len(dataPoints))
+			for _, point := range dataPoints {
+				points[point.Attributes.Encoded(encoder)] = point.Value
+			}
+
+			e.points[metric.Name] = points
+		}
+	}
+
+	return nil
+}
+
+func (e *recordingExporter) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) metric(name string) map[string]int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.points[name]
+}
+
+// exporterConnection exposes an exporter the way OTLP connections do.
+type exporterConnection struct {
+	exporter sdkmetric.Exporter
+}
+
+func (c *exporterConnection) GetMetricExporter() sdkmetric.Exporter {
+	return c.exporter
+}
+
+type exporterRegistry struct {
+	conn *exporterConnection
+}
+
+func (r *exporterRegistry) GetNamed(name string) any {
+	return r.conn
+}
+
+func TestRegistry_Metrics(t *testing.T) {
+	t.Parallel()
+
+	exporter := &recordingExporter{points: make(map[string]map[string]int64), mu: sync.Mutex{}}
+	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{ //nolint:exhaustruct
+		ServiceName:                   "test-service",
+		OTLPConnectionName:            "otel",
+		ExportInterval:                time.Hour,
+		NoNativeCollectorRegistration: true,
+	}, &exporterRegistry{conn: &exporterConnection{exporter: exporter}})
+	require.NoError(t, provider.Init())
+
+	mocks := &mockConnectionFactory{} //nolint:exhaustruct
+
+	registry := connfx.NewRegistry(
+		newMockLogger(),
+		connfx.WithMetricsBuilder(provider.NewBuilder()),
+	)
+	registry.RegisterFactory(mocks)
+
+	_, err := registry.AddConnection(
+		t.Context(),
+		"cache",
+		&connfx.ConfigTarget{Protocol: "mock"}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	conn := mocks.created[0]
+
+	// The connection flaps: it fails, is caught by a health check, then recovers
+	conn.transition(connfx.ConnectionStateError, errMockConnectionLost)
+	registry.HealthCheck(t.Context())
+
+	conn.transition(connfx.ConnectionStateReady, nil)
+	registry.HealthCheck(t.Context())
+
+	require.NoError(t, registry.RemoveConnection(t.Context(), "cache"))
+
+	// Shutting down flushes the periodic reader to the exporter
+	require.NoError(t, provider.Shutdown(t.Context()))
+
+	assert.Equal(t, map[string]int64{
+		"name=cache,protocol=mock,state=Error":        1,
+		"name=cache,protocol=mock,state=Ready":        1,
+		"name=cache,protocol=mock,state=Disconnected": 1,
+	}, exporter.metric("connfx_connection_state_transitions_total"))
+
+	assert.Equal(t, map[string]int64{
+		"name=cache,protocol=mock,state=Error": 1,
+	}, exporter.metric("connfx_connection_health_check_failures_total"))
+
+	assert.Equal(t, map[string]int64{
+		"name=cache,operation=added,protocol=mock":   1,
+		"name=cache,operation=removed,protocol=mock": 1,
+	}, exporter.metric("connfx_connection_registry_operations_total"))
+
+	assert.Equal(t, map[string]int64{"": 1}, exporter.metric("connfx_healthy_connections"))
+}
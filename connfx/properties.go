@@ -0,0 +1,152 @@
+package connfx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrPropertyNotWholeNumber  = errors.New("must be a whole number")
+	ErrPropertyNegative        = errors.New("must not be negative")
+	ErrPropertyUnsupportedType = errors.New("unsupported type")
+	ErrPropertyEmpty           = errors.New("must not be empty")
+)
+
+// intProperty reads a non-negative whole number given as an int, int32 or int64, a whole
+// float64 (as decoded from JSON or YAML) or a numeric string (as read from environment
+// variables). ok is false when the property is unset.
+func intProperty(properties map[string]any, key string) (int, bool, error) {
+	raw, ok := properties[key]
+	if !ok || raw == nil {
+		return 0, false, nil
+	}
+
+	var (
+		value int64
+		err   error
+	)
+
+	switch typed := raw.(type) {
+	case int:
+		value = int64(typed)
+	case int32:
+		value = int64(typed)
+	case int64:
+		value = typed
+	case float64:
+		if typed != math.Trunc(typed) || math.Abs(typed) > math.MaxInt32 {
+			err = ErrPropertyNotWholeNumber
+		}
+
+		value = int64(typed)
+	case string:
+		value, err = strconv.ParseInt(typed, 10, 64)
+	default:
+		err = fmt.Errorf("%w %T", ErrPropertyUnsupportedType, raw)
+	}
+
+	if err == nil && value < 0 {
+		err = ErrPropertyNegative
+	}
+
+	if err == nil && value > math.MaxInt32 {
+		err = ErrIntegerOverflow
+	}
+
+	if err != nil {
+		return 0, false, fmt.Errorf("(property=%q, value=%v): %w", key, raw, err)
+	}
+
+	return int(value), true, nil
+}
+
+// durationProperty reads a non-negative duration given either as a time.Duration or as a
+// duration string such as "30s". ok is false when the property is unset.
+func durationProperty(properties map[string]any, key string) (time.Duration, bool, error) {
+	raw, ok := properties[key]
+	if !ok || raw == nil {
+		return 0, false, nil
+	}
+
+	var (
+		value time.Duration
+		err   error
+	)
+
+	switch typed := raw.(type) {
+	case time.Duration:
+		value = typed
+	case string:
+		value, err = time.ParseDuration(typed)
+	default:
+		err = fmt.Errorf("%w %T", ErrPropertyUnsupportedType, raw)
+	}
+
+	if err == nil && value < 0 {
+		err = ErrPropertyNegative
+	}
+
+	if err != nil {
+		return 0, false, fmt.Errorf("(property=%q, value=%v): %w", key, raw, err)
+	}
+
+	return value, true, nil
+}
+
+// stringProperty reads a non-empty string. ok is false when the property is unset.
+func stringProperty(properties map[string]any, key string) (string, bool, error) {
+	raw, ok := properties[key]
+	if !ok || raw == nil {
+		return "", false, nil
+	}
+
+	value, ok := raw.(string)
+	if !ok {
+		return "", false, fmt.Errorf(
+			"(property=%q, value=%v): %w %T",
+			key,
+			raw,
+			ErrPropertyUnsupportedType,
+			raw,
+		)
+	}
+
+	if value == "" {
+		return "", false, fmt.Errorf("(property=%q): %w", key, ErrPropertyEmpty)
+	}
+
+	return value, true, nil
+}
+
+// setIntProperty stores the intProperty value of key in target, leaving target untouched
+// when the property is unset.
+func setIntProperty[T int | uint](properties map[string]any, key string, target *T) error {
+	value, ok, err := intProperty(properties, key)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		*target = T(value) //nolint:gosec // intProperty rejects negative values
+	}
+
+	return nil
+}
+
+// setDurationProperty stores the durationProperty value of key in target, leaving target
+// untouched when the property is unset.
+func setDurationProperty(properties map[string]any, key string, target *time.Duration) error {
+	value, ok, err := durationProperty(properties, key)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		*target = value
+	}
+
+	return nil
+}
@@ -0,0 +1,327 @@
+package connfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/eser/ajan/lib"
+)
+
+const (
+	// subscriptionReconnectEventBuffer is how many reconnect events are kept for a slow reader.
+	subscriptionReconnectEventBuffer = 16
+	// subscriptionMaxBackoffRetry caps the exponent used for reconnect backoff.
+	subscriptionMaxBackoffRetry = 8
+)
+
+var (
+	ErrSubscriptionClosed = errors.New("subscription closed")
+	ErrSubscribeFailed    = errors.New("failed to subscribe")
+	ErrUnsubscribeFailed  = errors.New("failed to unsubscribe")
+)
+
+// PubSubMessage is a message received on a pub/sub channel.
+type PubSubMessage struct {
+	Channel string
+	Payload []byte
+}
+
+// ReconnectEvent reports that a subscription lost its connection and re-subscribed.
+// Messages published while the connection was down are not redelivered.
+type ReconnectEvent struct {
+	// Timestamp is when the subscription was restored
+	Timestamp time.Time
+	// Err is the error that dropped the previous connection
+	Err error
+	// Channels are the channels re-subscribed on the new connection
+	Channels []string
+	// Attempts is the number of connection attempts it took to recover
+	Attempts int
+}
+
+// PubSubSession is a single pub/sub connection. Subscribe and Unsubscribe may be called
+// while Receive is blocked in another goroutine.
+type PubSubSession interface {
+	Subscribe(ctx context.Context, channels ...string) error
+	Unsubscribe(ctx context.Context, channels ...string) error
+	// Receive blocks until a message arrives; an error means the connection is unusable
+	Receive(ctx context.Context) (PubSubMessage, error)
+	Close() error
+}
+
+// PubSubDialer opens a new PubSubSession.
+type PubSubDialer func(ctx context.Context) (PubSubSession, error)
+
+// Subscription receives messages from a set of pub/sub channels and survives connection
+// drops: it tracks every active channel and, when the connection fails, dials a new
+// session, re-subscribes to all of them and emits a ReconnectEvent.
+type Subscription struct {
+	dial       PubSubDialer
+	session    PubSubSession
+	channels   map[string]struct{}
+	messages   chan PubSubMessage
+	reconnects chan ReconnectEvent
+	cancel     context.CancelFunc
+	done       <-chan error
+	backoff    lib.RetryPolicy
+	mu         sync.Mutex
+	closeOnce  sync.Once
+	closed     bool
+}
+
+// NewSubscription dials a session, subscribes to the given channels and starts receiving.
+// The subscription stops when ctx is canceled or Close is called.
+func NewSubscription(
+	ctx context.Context,
+	dial PubSubDialer,
+	channels ...string,
+) (*Subscription, error) {
+	session, err := dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w (channels=%q): %w", ErrSubscribeFailed, channels, err)
+	}
+
+	if len(channels) > 0 {
+		if err := session.Subscribe(ctx, channels...); err != nil {
+			_ = session.Close()
+
+			return nil, fmt.Errorf("%w (channels=%q): %w", ErrSubscribeFailed, channels, err)
+		}
+	}
+
+	loopCtx, cancel := context.WithCancel(ctx)
+
+	subscription := &Subscription{
+		dial:       dial,
+		session:    session,
+		channels:   make(map[string]struct{}, len(channels)),
+		messages:   make(chan PubSubMessage),
+		reconnects: make(chan ReconnectEvent, subscriptionReconnectEventBuffer),
+		cancel:     cancel,
+		done:       nil,
+		backoff:    lib.DefaultRetryPolicy(),
+		mu:         sync.Mutex{},
+		closeOnce:  sync.Once{},
+		closed:     false,
+	}
+
+	for _, channel := range channels {
+		subscription.channels[channel] = struct{}{}
+	}
+
+	subscription.done = lib.SafeGo(func() error {
+		defer close(subscription.messages)
+		defer close(subscription.reconnects)
+
+		subscription.receiveLoop(loopCtx)
+
+		return nil
+	}, nil)
+
+	return subscription, nil
+}
+
+// Messages returns the channel of received messages. It is closed once the
+// subscription stops.
+func (s *Subscription) Messages() <-chan PubSubMessage {
+	return s.messages
+}
+
+// Reconnects returns the channel of reconnect events, signaling a possible gap in the
+// received messages. Events are dropped if the channel is not drained.
+func (s *Subscription) Reconnects() <-chan ReconnectEvent {
+	return s.reconnects
+}
+
+// Channels returns the currently subscribed channels, sorted.
+func (s *Subscription) Channels() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.channelList()
+}
+
+// Subscribe adds channels to the subscription. They are re-subscribed after reconnects;
+// while reconnecting, the channels are only recorded and subscribed once connected.
+func (s *Subscription) Subscribe(ctx context.Context, channels ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fmt.Errorf("%w (channels=%q)", ErrSubscriptionClosed, channels)
+	}
+
+	if s.session != nil {
+		if err := s.session.Subscribe(ctx, channels...); err != nil {
+			return fmt.Errorf("%w (channels=%q): %w", ErrSubscribeFailed, channels, err)
+		}
+	}
+
+	for _, channel := range channels {
+		s.channels[channel] = struct{}{}
+	}
+
+	return nil
+}
+
+// Unsubscribe removes channels from the subscription.
+func (s *Subscription) Unsubscribe(ctx context.Context, channels ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, channel := range channels {
+		delete(s.channels, channel)
+	}
+
+	if s.session == nil {
+		return nil
+	}
+
+	if err := s.session.Unsubscribe(ctx, channels...); err != nil {
+		return fmt.Errorf("%w (channels=%q): %w", ErrUnsubscribeFailed, channels, err)
+	}
+
+	return nil
+}
+
+// Close stops receiving, closes the session and waits for the receive loop to exit.
+func (s *Subscription) Close() error {
+	var err error
+
+	s.closeOnce.Do(func() {
+		s.cancel()
+
+		s.mu.Lock()
+		session := s.session
+		s.session = nil
+		s.closed = true
+		s.mu.Unlock()
+
+		if session != nil {
+			err = session.Close()
+		}
+
+		<-s.done
+	})
+
+	return err
+}
+
+func (s *Subscription) receiveLoop(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		session := s.session
+		s.mu.Unlock()
+
+		if session == nil {
+			return
+		}
+
+		msg, err := session.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil || !s.reconnect(ctx, session, err) {
+				return
+			}
+
+			continue
+		}
+
+		select {
+		case s.messages <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reconnect replaces a failed session, retrying with backoff until it succeeds or ctx is
+// done. It reports whether the subscription can continue.
+func (s *Subscription) reconnect(ctx context.Context, failed PubSubSession, cause error) bool {
+	s.mu.Lock()
+	if s.session == failed {
+		s.session = nil
+	}
+	s.mu.Unlock()
+
+	_ = failed.Close()
+
+	for attempt := 1; ; attempt++ {
+		channels, err := s.resubscribe(ctx)
+		if err == nil {
+			s.emitReconnect(ReconnectEvent{
+				Timestamp: time.Now(),
+				Err:       cause,
+				Channels:  channels,
+				Attempts:  attempt,
+			})
+
+			return true
+		}
+
+		if errors.Is(err, ErrSubscriptionClosed) {
+			return false
+		}
+
+		delay := s.backoff.Backoff(uint(min(attempt, subscriptionMaxBackoffRetry))) //nolint:gosec
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+	}
+}
+
+// resubscribe dials a new session, subscribes it to every tracked channel and makes it
+// the current session.
+func (s *Subscription) resubscribe(ctx context.Context) ([]string, error) {
+	session, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		_ = session.Close()
+
+		return nil, ErrSubscriptionClosed
+	}
+
+	channels := s.channelList()
+
+	if len(channels) > 0 {
+		if err := session.Subscribe(ctx, channels...); err != nil {
+			_ = session.Close()
+
+			return nil, err
+		}
+	}
+
+	s.session = session
+
+	return channels, nil
+}
+
+func (s *Subscription) emitReconnect(event ReconnectEvent) {
+	select {
+	case s.reconnects <- event:
+	default:
+	}
+}
+
+func (s *Subscription) channelList() []string {
+	channels := make([]string, 0, len(s.channels))
+	for channel := range s.channels {
+		channels = append(channels, channel)
+	}
+
+	slices.Sort(channels)
+
+	return channels
+}
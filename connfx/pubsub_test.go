@@ -0,0 +1,244 @@
+package connfx_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errConnectionDropped = errors.New("connection reset by peer")
+	errDialRefused       = errors.New("connection refused")
+)
+
+// fakePubSubBroker is an in-memory pub/sub server whose connections can be dropped.
+type fakePubSubBroker struct {
+	sessions []*fakePubSubSession
+	dials    int
+	// refuseDials is the number of upcoming dials that fail
+	refuseDials int
+	mu          sync.Mutex
+}
+
+func (b *fakePubSubBroker) dial(ctx context.Context) (connfx.PubSubSession, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.dials++
+
+	if b.refuseDials > 0 {
+		b.refuseDials--
+
+		return nil, errDialRefused
+	}
+
+	session := &fakePubSubSession{
+		channels: make(map[string]bool),
+		inbox:    make(chan connfx.PubSubMessage, 16),
+		dropped:  make(chan struct{}),
+		mu:       sync.Mutex{},
+	}
+	b.sessions = append(b.sessions, session)
+
+	return session, nil
+}
+
+func (b *fakePubSubBroker) publish(channel string, payload string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delivered := 0
+
+	for _, session := range b.sessions {
+		if session.deliver(connfx.PubSubMessage{Channel: channel, Payload: []byte(payload)}) {
+			delivered++
+		}
+	}
+
+	return delivered
+}
+
+// dropAll simulates the server closing every connection.
+func (b *fakePubSubBroker) dropAll(refuseDials int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, session := range b.sessions {
+		session.drop()
+	}
+
+	b.sessions = nil
+	b.refuseDials = refuseDials
+}
+
+type fakePubSubSession struct {
+	channels map[string]bool
+	inbox    chan connfx.PubSubMessage
+	dropped  chan struct{}
+	mu       sync.Mutex
+}
+
+func (s *fakePubSubSession) Subscribe(ctx context.Context, channels ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, channel := range channels {
+		s.channels[channel] = true
+	}
+
+	return nil
+}
+
+func (s *fakePubSubSession) Unsubscribe(ctx context.Context, channels ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, channel := range channels {
+		delete(s.channels, channel)
+	}
+
+	return nil
+}
+
+func (s *fakePubSubSession) Receive(ctx context.Context) (connfx.PubSubMessage, error) {
+	select {
+	case msg := <-s.inbox:
+		return msg, nil
+	case <-s.dropped:
+		return connfx.PubSubMessage{}, errConnectionDropped
+	case <-ctx.Done():
+		return connfx.PubSubMessage{}, ctx.Err()
+	}
+}
+
+func (s *fakePubSubSession) Close() error {
+	return nil
+}
+
+func (s *fakePubSubSession) deliver(msg connfx.PubSubMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.channels[msg.Channel] {
+		return false
+	}
+
+	s.inbox <- msg
+
+	return true
+}
+
+func (s *fakePubSubSession) drop() {
+	close(s.dropped)
+}
+
+func receivePubSubMessage(t *testing.T, subscription *connfx.Subscription) connfx.PubSubMessage {
+	t.Helper()
+
+	select {
+	case msg := <-subscription.Messages():
+		return msg
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timed out waiting for a message")
+
+		return connfx.PubSubMessage{}
+	}
+}
+
+func receiveReconnectEvent(t *testing.T, subscription *connfx.Subscription) connfx.ReconnectEvent {
+	t.Helper()
+
+	select {
+	case event := <-subscription.Reconnects():
+		return event
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timed out waiting for a reconnect event")
+
+		return connfx.ReconnectEvent{} //nolint:exhaustruct
+	}
+}
+
+func TestSubscription_ResubscribesAfterConnectionDrop(t *testing.T) {
+	t.Parallel()
+
+	broker := &fakePubSubBroker{} //nolint:exhaustruct
+
+	subscription, err := connfx.NewSubscription(t.Context(), broker.dial, "orders")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = subscription.Close() })
+
+	require.NoError(t, subscription.Subscribe(t.Context(), "payments"))
+
+	assert.Equal(t, 1, broker.publish("orders", "order-1"))
+	assert.Equal(t, "order-1", string(receivePubSubMessage(t, subscription).Payload))
+
+	broker.dropAll(0)
+
+	event := receiveReconnectEvent(t, subscription)
+	require.ErrorIs(t, event.Err, errConnectionDropped)
+	assert.Equal(t, []string{"orders", "payments"}, event.Channels)
+	assert.Equal(t, 1, event.Attempts)
+
+	// Both channels keep flowing on the new connection
+	assert.Equal(t, 1, broker.publish("orders", "order-2"))
+	assert.Equal(t, "order-2", string(receivePubSubMessage(t, subscription).Payload))
+
+	assert.Equal(t, 1, broker.publish("payments", "payment-1"))
+
+	msg := receivePubSubMessage(t, subscription)
+	assert.Equal(t, "payments", msg.Channel)
+	assert.Equal(t, "payment-1", string(msg.Payload))
+}
+
+func TestSubscription_RetriesUntilReconnected(t *testing.T) {
+	t.Parallel()
+
+	broker := &fakePubSubBroker{} //nolint:exhaustruct
+
+	subscription, err := connfx.NewSubscription(t.Context(), broker.dial, "orders")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = subscription.Close() })
+
+	// The server is briefly unreachable after the drop
+	broker.dropAll(2)
+
+	event := receiveReconnectEvent(t, subscription)
+	assert.Equal(t, 3, event.Attempts)
+	assert.Equal(t, []string{"orders"}, event.Channels)
+
+	assert.Equal(t, 1, broker.publish("orders", "order-1"))
+	assert.Equal(t, "order-1", string(receivePubSubMessage(t, subscription).Payload))
+}
+
+func TestSubscription_UnsubscribedChannelsAreNotRestored(t *testing.T) {
+	t.Parallel()
+
+	broker := &fakePubSubBroker{} //nolint:exhaustruct
+
+	subscription, err := connfx.NewSubscription(t.Context(), broker.dial, "orders", "payments")
+	require.NoError(t, err)
+
+	require.NoError(t, subscription.Unsubscribe(t.Context(), "payments"))
+
+	broker.dropAll(0)
+
+	event := receiveReconnectEvent(t, subscription)
+	assert.Equal(t, []string{"orders"}, event.Channels)
+	assert.Zero(t, broker.publish("payments", "payment-1"))
+
+	require.NoError(t, subscription.Close())
+
+	_, open := <-subscription.Messages()
+	assert.False(t, open)
+
+	err = subscription.Subscribe(t.Context(), "refunds")
+	require.ErrorIs(t, err, connfx.ErrSubscriptionClosed)
+}
@@ -0,0 +1,80 @@
+package connfx
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RedactedSecret replaces credentials in redacted DSNs.
+const RedactedSecret = "****"
+
+// secretParamPattern matches key=value parameters that carry secrets, both in URL query
+// strings ("?password=x&sslmode=disable") and keyword/value DSNs ("user=app password=x").
+var secretParamPattern = regexp.MustCompile( //nolint:gochecknoglobals
+	`(?i)(^|[\s?&;])(\w*(?:password|passwd|pwd|secret|token|api_?key)\w*)\s*=\s*` +
+		`('(?:[^'\\]|\\.)*'|[^\s&;]*)`,
+)
+
+// RedactDSN masks the credentials in a DSN so it can be logged or put in an error: the
+// password in the user info ("postgres://app:****@db:5432/orders",
+// "app:****@tcp(db:3306)/orders") and the values of secret parameters such as password,
+// token or api_key. DSNs without credentials, like SQLite paths, are returned unchanged.
+func RedactDSN(dsn string) string {
+	return secretParamPattern.ReplaceAllString(
+		redactUserInfo(dsn),
+		"${1}${2}="+RedactedSecret,
+	)
+}
+
+// redactUserInfo masks the password of "user:password@" in URLs and in MySQL-style DSNs.
+func redactUserInfo(dsn string) string {
+	start := 0
+	if idx := strings.Index(dsn, "://"); idx >= 0 {
+		start = idx + len("://")
+	}
+
+	// The user name runs to the first ":"; a path, query or keyword/value DSN in front of it
+	// means there is no user info
+	colon := strings.IndexByte(dsn[start:], ':')
+	if colon < 0 || strings.ContainsAny(dsn[start:start+colon], "/?#@= \t\n") {
+		return dsn
+	}
+
+	colon += start
+
+	at := strings.IndexByte(dsn[colon:], '@')
+	if at < 0 {
+		return dsn
+	}
+
+	at += colon
+
+	// The password may contain "@", "?" or "/", so the user info ends at the last "@"
+	// before the query; one that follows "key=value" parameters is part of the query
+	for {
+		next := strings.IndexByte(dsn[at+1:], '@')
+		if next < 0 || strings.ContainsAny(dsn[at+1:at+1+next], "=&") {
+			break
+		}
+
+		at += 1 + next
+	}
+
+	return dsn[:colon+1] + RedactedSecret + dsn[at:]
+}
+
+// redactURLError masks the credentials in the URL that *url.Error values quote, such as
+// the ones url.Parse returns for a malformed DSN. Other errors are returned unchanged.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+
+	redacted := *urlErr
+	redacted.URL = RedactDSN(urlErr.URL)
+
+	return &redacted
+}
@@ -7,10 +7,12 @@ import (
 	"log/slog"
 	"maps"
 	"slices"
-	"strings"
 	"sync"
+	"time"
 
+	"github.com/eser/ajan/lib"
 	"github.com/eser/ajan/logfx"
+	"github.com/eser/ajan/metricsfx"
 )
 
 var (
@@ -22,30 +24,75 @@ var (
 	ErrFailedToAddConnection    = errors.New("failed to add connection")
 	ErrConnectionNotSupported   = errors.New("connection does not support required operations")
 	ErrInterfaceNotImplemented  = errors.New("connection does not implement required interface")
+	ErrFailedToLoadConnections  = errors.New("failed to load connections")
+	ErrConnectionCloseTimedOut  = errors.New("connection did not close in time")
 )
 
 const DefaultConnection = "default"
 
+// StateChangeFunc is invoked when a registered connection transitions between states.
+type StateChangeFunc func(name string, from, to ConnectionState, reason error)
+
 // Registry manages all connections in the system.
 type Registry struct {
-	connections map[string]Connection
-	factories   map[string]ConnectionFactory // protocol -> factory
-	logger      *logfx.Logger
-	mu          sync.RWMutex
+	connections    map[string]Connection
+	factories      map[string]ConnectionFactory // protocol -> factory
+	logger         *logfx.Logger
+	auditLogger    *logfx.Logger
+	metricsBuilder *metricsfx.MetricsBuilder
+	metrics        *registryMetrics
+	onStateChange  StateChangeFunc
+	onUnhealthy    UnhealthyFunc
+	lastHealth     map[string]*HealthStatus
+	openedAt       map[string]time.Time
+	interceptors   []ConnectionInterceptor
+	mu             sync.RWMutex
+	stateMu        sync.RWMutex
+	healthMu       sync.RWMutex
+	auditMu        sync.Mutex
 }
 
 // NewRegistry creates a new connection registry.
-func NewRegistry(logger *logfx.Logger) *Registry {
-	return &Registry{
-		connections: make(map[string]Connection),
-		factories:   make(map[string]ConnectionFactory),
-		logger:      logger,
-		mu:          sync.RWMutex{},
+// State transitions of connections are logged via LogStateChange by default.
+func NewRegistry(logger *logfx.Logger, opts ...RegistryOption) *Registry {
+	registry := &Registry{
+		connections:    make(map[string]Connection),
+		factories:      make(map[string]ConnectionFactory),
+		logger:         logger,
+		auditLogger:    nil,
+		metricsBuilder: nil,
+		metrics:        nil,
+		onStateChange:  nil,
+		onUnhealthy:    nil,
+		lastHealth:     make(map[string]*HealthStatus),
+		openedAt:       make(map[string]time.Time),
+		interceptors:   nil,
+		mu:             sync.RWMutex{},
+		stateMu:        sync.RWMutex{},
+		healthMu:       sync.RWMutex{},
+		auditMu:        sync.Mutex{},
+	}
+
+	registry.onStateChange = registry.LogStateChange
+
+	for _, opt := range opts {
+		opt(registry)
+	}
+
+	if registry.metricsBuilder != nil {
+		metrics, err := newRegistryMetrics(registry.metricsBuilder)
+		if err != nil {
+			logger.Warn("registry metrics are disabled", slog.String("error", err.Error()))
+		}
+
+		registry.metrics = metrics
 	}
+
+	return registry
 }
 
-func NewRegistryWithDefaults(logger *logfx.Logger) *Registry {
-	registry := NewRegistry(logger)
+func NewRegistryWithDefaults(logger *logfx.Logger, opts ...RegistryOption) *Registry {
+	registry := NewRegistry(logger, opts...)
 
 	// adapter_sql.go
 	registry.RegisterFactory(NewSQLConnectionFactory("sqlite"))
@@ -65,6 +112,15 @@ func NewRegistryWithDefaults(logger *logfx.Logger) *Registry {
 	// adapter_otlp.go
 	registry.RegisterFactory(NewOTLPConnectionFactory("otlp"))
 
+	// adapter_nats.go
+	registry.RegisterFactory(NewNATSConnectionFactory("nats"))
+
+	// adapter_mongo.go
+	registry.RegisterFactory(NewMongoConnectionFactory("mongo"))
+
+	// adapter_memory.go
+	registry.RegisterFactory(NewMemoryConnectionFactory("memory"))
+
 	return registry
 }
 
@@ -158,6 +214,28 @@ func (registry *Registry) ListConnections() []string {
 	return names
 }
 
+// Describe reports the registered protocols and the protocol of each connection, e.g.
+// for a startup banner.
+func (registry *Registry) Describe() slog.Attr {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	protocols := slices.Sorted(maps.Keys(registry.factories))
+	names := slices.Sorted(maps.Keys(registry.connections))
+
+	connections := make([]any, 0, len(names))
+	for _, name := range names {
+		protocol := registry.connections[name].GetProtocol()
+		connections = append(connections, slog.String(name, protocol))
+	}
+
+	return slog.Group(
+		"registry",
+		slog.Any("protocols", protocols),
+		slog.Group("connections", connections...),
+	)
+}
+
 // ListRegisteredProtocols returns all registered protocols.
 func (registry *Registry) ListRegisteredProtocols() []string {
 	registry.mu.RLock()
@@ -210,8 +288,15 @@ func (registry *Registry) AddConnection(
 		return nil, fmt.Errorf("%w (name=%q): %w", ErrFailedToCreateConnection, name, err)
 	}
 
+	// State hooks go to the adapter itself, as decorators may hide StateObservable
+	registry.observeState(name, conn)
+
+	conn = registry.intercept(conn)
 	registry.connections[name] = conn
 
+	registry.metrics.recordOperation(ctx, name, config.Protocol, registryOperationAdded)
+	registry.auditCreated(name, config)
+
 	registry.logger.Info(
 		"successfully added connection",
 		slog.String("name", name),
@@ -221,6 +306,41 @@ func (registry *Registry) AddConnection(
 	return conn, nil
 }
 
+// OnStateChange sets the callback invoked when a connection transitions between states.
+// Passing nil disables state change notifications.
+func (registry *Registry) OnStateChange(handler StateChangeFunc) {
+	registry.stateMu.Lock()
+	defer registry.stateMu.Unlock()
+
+	registry.onStateChange = handler
+}
+
+// LogStateChange is the default state change callback. It logs transitions into a
+// degraded state as warnings and all other transitions (e.g. recoveries) as info.
+func (registry *Registry) LogStateChange(
+	name string,
+	from, to ConnectionState,
+	reason error,
+) {
+	attrs := []any{
+		slog.String("name", name),
+		slog.String("from", from.String()),
+		slog.String("to", to.String()),
+	}
+
+	if reason != nil {
+		attrs = append(attrs, slog.String("reason", reason.Error()))
+	}
+
+	if isDegradedState(to, reason) {
+		registry.logger.Warn("connection state degraded", attrs...)
+
+		return
+	}
+
+	registry.logger.Info("connection state changed", attrs...)
+}
+
 // RemoveConnection removes a connection from the registry.
 func (registry *Registry) RemoveConnection(ctx context.Context, name string) error {
 	registry.mu.Lock()
@@ -232,15 +352,20 @@ func (registry *Registry) RemoveConnection(ctx context.Context, name string) err
 	}
 
 	// Close the connection
-	if err := conn.Close(ctx); err != nil {
+	closeErr := conn.Close(ctx)
+	if closeErr != nil {
 		registry.logger.Warn(
 			"error closing connection",
-			slog.String("error", err.Error()),
+			slog.String("error", closeErr.Error()),
 			slog.String("name", name),
 		)
 	}
 
 	delete(registry.connections, name)
+	registry.forgetHealth(name)
+
+	registry.metrics.recordOperation(ctx, name, conn.GetProtocol(), registryOperationRemoved)
+	registry.auditEnded(auditEventRemoved, name, conn.GetProtocol(), closeErr)
 
 	registry.logger.Info(
 		"removed connection",
@@ -250,16 +375,105 @@ func (registry *Registry) RemoveConnection(ctx context.Context, name string) err
 	return nil
 }
 
+// LoadFromConfigOptions controls how LoadFromConfigWithOptions handles connections that
+// fail to connect.
+type LoadFromConfigOptions struct {
+	// RetryInitialInterval is the delay before the first retry, doubling for later ones
+	// (lib.DefaultRetryInitialInterval when zero)
+	RetryInitialInterval time.Duration
+	// RetryAttempts is how many times a failed connection is retried
+	RetryAttempts int
+	// ContinueOnError loads the remaining connections when one fails
+	ContinueOnError bool
+}
+
+// LoadFromConfig adds the configured connections, stopping at the first one that fails.
 func (registry *Registry) LoadFromConfig(ctx context.Context, config *Config) error {
-	for name, target := range config.Targets {
-		if _, err := registry.AddConnection(ctx, name, &target); err != nil {
-			return fmt.Errorf("%w (name=%q): %w", ErrFailedToAddConnection, name, err)
+	return registry.LoadFromConfigWithOptions(ctx, config, LoadFromConfigOptions{
+		RetryInitialInterval: 0,
+		RetryAttempts:        0,
+		ContinueOnError:      false,
+	})
+}
+
+// LoadFromConfigWithOptions adds the configured connections in name order, retrying
+// failed ones with backoff. With ContinueOnError, a connection that still fails is logged
+// and skipped: the others are registered and usable, and the returned error lists every
+// connection that failed.
+func (registry *Registry) LoadFromConfigWithOptions(
+	ctx context.Context,
+	config *Config,
+	options LoadFromConfigOptions,
+) error {
+	var failures []error
+
+	for _, name := range slices.Sorted(maps.Keys(config.Targets)) {
+		target := config.Targets[name]
+
+		err := registry.addConnectionWithRetry(ctx, name, &target, options)
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("%w (name=%q): %w", ErrFailedToAddConnection, name, err)
+
+		if !options.ContinueOnError {
+			return err
 		}
+
+		registry.logger.Warn(
+			"skipping connection that failed to load",
+			slog.String("error", err.Error()),
+			slog.String("name", name),
+		)
+
+		failures = append(failures, err)
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf(
+			"%w (failed=%d): %w",
+			ErrFailedToLoadConnections,
+			len(failures),
+			errors.Join(failures...),
+		)
 	}
 
 	return nil
 }
 
+// addConnectionWithRetry retries AddConnection while the failure may be transient.
+// Configuration errors such as unsupported protocols are returned right away.
+func (registry *Registry) addConnectionWithRetry(
+	ctx context.Context,
+	name string,
+	target *ConfigTarget,
+	options LoadFromConfigOptions,
+) error {
+	if options.RetryAttempts <= 0 {
+		_, err := registry.AddConnection(ctx, name, target)
+
+		return err
+	}
+
+	policy := lib.DefaultRetryPolicy()
+	policy.MaxAttempts = uint(options.RetryAttempts) + 1 //nolint:gosec
+	policy.IsRetryable = func(err error) bool {
+		return !errors.Is(err, ErrUnsupportedProtocol) &&
+			!errors.Is(err, ErrConnectionAlreadyExists)
+	}
+
+	if options.RetryInitialInterval > 0 {
+		policy.InitialInterval = options.RetryInitialInterval
+	}
+
+	return lib.Retry(ctx, policy, func(ctx context.Context) error {
+		_, err := registry.AddConnection(ctx, name, target)
+
+		return err
+	})
+}
+
 // HealthCheck performs health checks on all connections.
 func (registry *Registry) HealthCheck(ctx context.Context) map[string]*HealthStatus {
 	registry.mu.RLock()
@@ -292,43 +506,127 @@ func (registry *Registry) HealthCheck(ctx context.Context) map[string]*HealthSta
 		results[result.name] = result.status
 	}
 
+	registry.metrics.recordHealthCheck(ctx, connections, results)
+	registry.recordHealth(results)
+
 	return results
 }
 
+// CloseOptions controls how CloseWithOptions drains the registry.
+type CloseOptions struct {
+	// Timeout bounds how long each connection may take to close (no limit when zero)
+	Timeout time.Duration
+}
+
 // Close closes all connections in the registry.
 func (registry *Registry) Close(ctx context.Context) error {
+	return registry.CloseWithOptions(ctx, CloseOptions{
+		Timeout: 0,
+	})
+}
+
+// CloseWithOptions removes all connections from the registry and closes them
+// concurrently. A connection that does not close within options.Timeout is abandoned with
+// a logged warning, so a stuck connection cannot hold up process exit.
+func (registry *Registry) CloseWithOptions(ctx context.Context, options CloseOptions) error {
 	registry.mu.Lock()
-	defer registry.mu.Unlock()
 
-	var errors []error
+	connections := registry.connections
+	registry.connections = make(map[string]Connection)
+	registry.mu.Unlock()
+
+	registry.forgetHealth(slices.Collect(maps.Keys(connections))...)
 
-	for name, conn := range registry.connections {
-		if err := conn.Close(ctx); err != nil {
-			errors = append(
-				errors,
-				fmt.Errorf("%w (name=%q): %w", ErrFailedToCloseConnection, name, err),
-			)
-		}
+	// Use a channel to collect results
+	type closeResult struct {
+		err  error
+		name string
 	}
 
-	// Clear the connections map
-	registry.connections = make(map[string]Connection)
+	resultChan := make(chan closeResult, len(connections))
+
+	for name, conn := range connections {
+		registry.metrics.recordOperation(ctx, name, conn.GetProtocol(), registryOperationRemoved)
+
+		go func(name string, conn Connection) {
+			err := registry.closeWithTimeout(ctx, name, conn, options.Timeout)
+			registry.auditEnded(auditEventClosed, name, conn.GetProtocol(), err)
+
+			resultChan <- closeResult{
+				err:  err,
+				name: name,
+			}
+		}(name, conn)
+	}
+
+	// Collect results
+	closeErrs := make(map[string]error, len(connections))
 
-	if len(errors) > 0 {
-		errStrs := make([]string, len(errors))
-		for i, err := range errors {
-			errStrs[i] = err.Error()
+	for range len(connections) {
+		result := <-resultChan
+		if result.err != nil {
+			closeErrs[result.name] = result.err
 		}
+	}
 
-		// Combine all errors into one
-		errMsg := strings.Join(errStrs, "; ")
+	if len(closeErrs) > 0 {
+		errs := make([]error, 0, len(closeErrs))
+		for _, name := range slices.Sorted(maps.Keys(closeErrs)) {
+			errs = append(errs, closeErrs[name])
+		}
 
-		return fmt.Errorf("%w: %s", ErrFailedToCloseConnections, errMsg)
+		return fmt.Errorf("%w: %w", ErrFailedToCloseConnections, errors.Join(errs...))
 	}
 
 	return nil
 }
 
+func (registry *Registry) closeWithTimeout(
+	ctx context.Context,
+	name string,
+	conn Connection,
+	timeout time.Duration,
+) error {
+	closeCtx := ctx
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		closeCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Buffered, so the goroutine can finish after the connection is abandoned
+	done := make(chan error, 1)
+
+	go func() {
+		done <- conn.Close(closeCtx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%w (name=%q): %w", ErrFailedToCloseConnection, name, err)
+		}
+
+		return nil
+	case <-closeCtx.Done():
+		registry.logger.Warn(
+			"abandoning connection that did not close in time",
+			slog.String("name", name),
+			slog.String("protocol", conn.GetProtocol()),
+			slog.Duration("timeout", timeout),
+		)
+
+		return fmt.Errorf(
+			"%w (name=%q): %w",
+			ErrConnectionCloseTimedOut,
+			name,
+			context.Cause(closeCtx),
+		)
+	}
+}
+
 // GetRepository returns a Repository from a connection if it supports it.
 func (registry *Registry) GetRepository(name string) (Repository, error) {
 	registry.mu.RLock()
@@ -357,3 +655,42 @@ func (registry *Registry) GetRepository(name string) (Repository, error) {
 
 	return repo, nil
 }
+
+// observeState subscribes to state transitions of connections that report them.
+func (registry *Registry) observeState(name string, conn Connection) {
+	observable, ok := conn.(StateObservable)
+	if !ok {
+		return
+	}
+
+	protocol := conn.GetProtocol()
+
+	observable.SetStateChangeHook(func(from, to ConnectionState, reason error) {
+		registry.metrics.recordStateTransition(name, protocol, to)
+
+		if from == ConnectionStateReconnecting && isHealthyState(to) {
+			registry.auditReconnected(name, protocol)
+		}
+
+		registry.stateMu.RLock()
+		handler := registry.onStateChange
+		registry.stateMu.RUnlock()
+
+		if handler != nil {
+			handler(name, from, to, reason)
+		}
+	})
+}
+
+// isDegradedState reports whether a transition into the given state is a degradation.
+// Disconnecting without a reason is treated as a regular shutdown.
+func isDegradedState(state ConnectionState, reason error) bool {
+	switch state { //nolint:exhaustive
+	case ConnectionStateError, ConnectionStateReconnecting:
+		return true
+	case ConnectionStateDisconnected:
+		return reason != nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,432 @@
+package connfx_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errMockConnectionLost = errors.New("connection lost")
+
+// mockConnection is a controllable connfx.Connection for registry tests.
+type mockConnection struct {
+	hook     connfx.StateChangeHook
+	protocol string
+	state    connfx.ConnectionState
+	mu       sync.Mutex
+}
+
+func (c *mockConnection) GetBehaviors() []connfx.ConnectionBehavior {
+	return []connfx.ConnectionBehavior{connfx.ConnectionBehaviorStateful}
+}
+
+func (c *mockConnection) GetCapabilities() []connfx.ConnectionCapability {
+	return []connfx.ConnectionCapability{connfx.ConnectionCapabilityKeyValue}
+}
+
+func (c *mockConnection) GetProtocol() string {
+	return c.protocol
+}
+
+func (c *mockConnection) GetState() connfx.ConnectionState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state
+}
+
+func (c *mockConnection) HealthCheck(ctx context.Context) *connfx.HealthStatus {
+	return &connfx.HealthStatus{ //nolint:exhaustruct
+		Timestamp: time.Now(),
+		State:     c.GetState(),
+	}
+}
+
+func (c *mockConnection) Close(ctx context.Context) error {
+	c.transition(connfx.ConnectionStateDisconnected, nil)
+
+	return nil
+}
+
+func (c *mockConnection) GetRawConnection() any {
+	return c
+}
+
+func (c *mockConnection) SetStateChangeHook(hook connfx.StateChangeHook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hook = hook
+}
+
+func (c *mockConnection) transition(to connfx.ConnectionState, reason error) {
+	c.mu.Lock()
+	from := c.state
+	c.state = to
+	hook := c.hook
+	c.mu.Unlock()
+
+	if hook != nil && from != to {
+		hook(from, to, reason)
+	}
+}
+
+// mockConnectionFactory hands out mockConnections for the "mock" protocol.
+type mockConnectionFactory struct {
+	created []*mockConnection
+	mu      sync.Mutex
+}
+
+func (f *mockConnectionFactory) CreateConnection(
+	ctx context.Context,
+	config *connfx.ConfigTarget,
+) (connfx.Connection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	conn := &mockConnection{ //nolint:exhaustruct
+		protocol: "mock",
+		state:    connfx.ConnectionStateReady,
+	}
+	f.created = append(f.created, conn)
+
+	return conn, nil
+}
+
+func (f *mockConnectionFactory) GetProtocol() string {
+	return "mock"
+}
+
+type stateChangeEvent struct {
+	reason error
+	name   string
+	from   connfx.ConnectionState
+	to     connfx.ConnectionState
+}
+
+func TestRegistry_OnStateChange(t *testing.T) {
+	t.Parallel()
+
+	registry := connfx.NewRegistry(newMockLogger())
+	registry.RegisterFactory(&mockConnectionFactory{}) //nolint:exhaustruct
+
+	var (
+		events []stateChangeEvent
+		mu     sync.Mutex
+	)
+
+	registry.OnStateChange(func(name string, from, to connfx.ConnectionState, reason error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		events = append(events, stateChangeEvent{name: name, from: from, to: to, reason: reason})
+	})
+
+	conn, err := registry.AddConnection(
+		t.Context(),
+		"primary",
+		&connfx.ConfigTarget{Protocol: "mock"}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	mock, ok := conn.(*mockConnection)
+	require.True(t, ok)
+
+	mock.transition(connfx.ConnectionStateError, errMockConnectionLost)
+	mock.transition(connfx.ConnectionStateReconnecting, errMockConnectionLost)
+	mock.transition(connfx.ConnectionStateReady, nil)
+	mock.transition(connfx.ConnectionStateReady, nil) // no-op, not reported
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, events, 3)
+
+	assert.Equal(t, "primary", events[0].name)
+	assert.Equal(t, connfx.ConnectionStateReady, events[0].from)
+	assert.Equal(t, connfx.ConnectionStateError, events[0].to)
+	require.ErrorIs(t, events[0].reason, errMockConnectionLost)
+
+	assert.Equal(t, connfx.ConnectionStateError, events[1].from)
+	assert.Equal(t, connfx.ConnectionStateReconnecting, events[1].to)
+
+	assert.Equal(t, connfx.ConnectionStateReconnecting, events[2].from)
+	assert.Equal(t, connfx.ConnectionStateReady, events[2].to)
+	assert.NoError(t, events[2].reason)
+}
+
+func TestRegistry_OnStateChange_DefaultLogs(t *testing.T) {
+	t.Parallel()
+
+	registry := connfx.NewRegistry(newMockLogger())
+	registry.RegisterFactory(&mockConnectionFactory{}) //nolint:exhaustruct
+
+	conn, err := registry.AddConnection(
+		t.Context(),
+		"primary",
+		&connfx.ConfigTarget{Protocol: "mock"}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	mock, ok := conn.(*mockConnection)
+	require.True(t, ok)
+
+	// The default handler must not panic on degrade or recover
+	assert.NotPanics(t, func() {
+		mock.transition(connfx.ConnectionStateError, errMockConnectionLost)
+		mock.transition(connfx.ConnectionStateReady, nil)
+	})
+
+	// Disabling the callback stops notifications
+	registry.OnStateChange(nil)
+	assert.NotPanics(t, func() {
+		mock.transition(connfx.ConnectionStateError, errMockConnectionLost)
+	})
+}
+
+func TestRegistry_OnStateChange_SQLAdapter(t *testing.T) {
+	t.Parallel()
+
+	registry := connfx.NewRegistry(newMockLogger())
+	registry.RegisterFactory(connfx.NewSQLConnectionFactory("sqlite"))
+
+	var (
+		events []stateChangeEvent
+		mu     sync.Mutex
+	)
+
+	registry.OnStateChange(func(name string, from, to connfx.ConnectionState, reason error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		events = append(events, stateChangeEvent{name: name, from: from, to: to, reason: reason})
+	})
+
+	conn, err := registry.AddConnection(
+		t.Context(),
+		"db",
+		&connfx.ConfigTarget{Protocol: "sqlite", DSN: ":memory:"}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	from := conn.GetState()
+
+	require.NoError(t, conn.Close(t.Context()))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.NotEmpty(t, events)
+
+	last := events[len(events)-1]
+	assert.Equal(t, "db", last.name)
+	assert.Equal(t, from, last.from)
+	assert.Equal(t, connfx.ConnectionStateDisconnected, last.to)
+}
+
+var errMockCollectorDown = errors.New("collector down")
+
+// flakyConnectionFactory fails the first failures attempts, then hands out mockConnections.
+type flakyConnectionFactory struct {
+	failures int
+	attempts int
+	mu       sync.Mutex
+}
+
+func (f *flakyConnectionFactory) CreateConnection(
+	ctx context.Context,
+	config *connfx.ConfigTarget,
+) (connfx.Connection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.attempts++
+	if f.attempts <= f.failures {
+		return nil, errMockCollectorDown
+	}
+
+	return &mockConnection{ //nolint:exhaustruct
+		protocol: "flaky",
+		state:    connfx.ConnectionStateReady,
+	}, nil
+}
+
+func (f *flakyConnectionFactory) GetProtocol() string {
+	return "flaky"
+}
+
+func (f *flakyConnectionFactory) attemptCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.attempts
+}
+
+func TestRegistry_LoadFromConfigWithOptions(t *testing.T) {
+	t.Parallel()
+
+	config := &connfx.Config{
+		Targets: map[string]connfx.ConfigTarget{
+			"cache":  {Protocol: "mock"},  //nolint:exhaustruct
+			"otel":   {Protocol: "flaky"}, //nolint:exhaustruct
+			"stream": {Protocol: "kafka"}, //nolint:exhaustruct
+		},
+	}
+
+	newRegistry := func(failures int) (*connfx.Registry, *flakyConnectionFactory) {
+		flaky := &flakyConnectionFactory{failures: failures, attempts: 0, mu: sync.Mutex{}}
+
+		registry := connfx.NewRegistry(newMockLogger())
+		registry.RegisterFactory(&mockConnectionFactory{}) //nolint:exhaustruct
+		registry.RegisterFactory(flaky)
+
+		return registry, flaky
+	}
+
+	t.Run("continues past failures", func(t *testing.T) {
+		t.Parallel()
+
+		registry, flaky := newRegistry(100)
+
+		err := registry.LoadFromConfigWithOptions(t.Context(), config, connfx.LoadFromConfigOptions{
+			RetryInitialInterval: time.Millisecond,
+			RetryAttempts:        2,
+			ContinueOnError:      true,
+		})
+		require.ErrorIs(t, err, connfx.ErrFailedToLoadConnections)
+		require.ErrorIs(t, err, errMockCollectorDown)
+		require.ErrorIs(t, err, connfx.ErrUnsupportedProtocol)
+		assert.Contains(t, err.Error(), `name="otel"`)
+		assert.Contains(t, err.Error(), `name="stream"`)
+
+		// Transient failures are retried, configuration errors are not
+		assert.Equal(t, 3, flaky.attemptCount())
+
+		assert.Equal(t, []string{"cache"}, registry.ListConnections())
+
+		status := registry.HealthCheck(t.Context())["cache"]
+		require.NotNil(t, status)
+		assert.Equal(t, connfx.ConnectionStateReady, status.State)
+	})
+
+	t.Run("retries until the connection succeeds", func(t *testing.T) {
+		t.Parallel()
+
+		registry, flaky := newRegistry(2)
+
+		err := registry.LoadFromConfigWithOptions(t.Context(), config, connfx.LoadFromConfigOptions{
+			RetryInitialInterval: time.Millisecond,
+			RetryAttempts:        2,
+			ContinueOnError:      true,
+		})
+		require.ErrorIs(t, err, connfx.ErrUnsupportedProtocol)
+		require.NotErrorIs(t, err, errMockCollectorDown)
+
+		assert.Equal(t, 3, flaky.attemptCount())
+		assert.ElementsMatch(t, []string{"cache", "otel"}, registry.ListConnections())
+	})
+
+	t.Run("stops at the first failure by default", func(t *testing.T) {
+		t.Parallel()
+
+		registry, flaky := newRegistry(1)
+
+		err := registry.LoadFromConfig(t.Context(), config)
+		require.ErrorIs(t, err, connfx.ErrFailedToAddConnection)
+		require.ErrorIs(t, err, errMockCollectorDown)
+		require.NotErrorIs(t, err, connfx.ErrFailedToLoadConnections)
+
+		// Connections load in name order, so "stream" is never attempted
+		assert.Equal(t, 1, flaky.attemptCount())
+		assert.Equal(t, []string{"cache"}, registry.ListConnections())
+	})
+}
+
+// stuckConnection never finishes closing on its own, ignoring the close context.
+type stuckConnection struct {
+	mockConnection
+
+	closing chan struct{}
+	release chan struct{}
+}
+
+func (c *stuckConnection) Close(ctx context.Context) error {
+	close(c.closing)
+	<-c.release
+
+	return nil
+}
+
+// stuckConnectionFactory hands out the stuckConnection for the "stuck" protocol.
+type stuckConnectionFactory struct {
+	conn *stuckConnection
+}
+
+func (f *stuckConnectionFactory) CreateConnection(
+	ctx context.Context,
+	config *connfx.ConfigTarget,
+) (connfx.Connection, error) {
+	return f.conn, nil
+}
+
+func (f *stuckConnectionFactory) GetProtocol() string {
+	return "stuck"
+}
+
+func TestRegistry_CloseWithOptions(t *testing.T) {
+	t.Parallel()
+
+	stuck := &stuckConnection{ //nolint:exhaustruct
+		mockConnection: mockConnection{protocol: "stuck"}, //nolint:exhaustruct
+		closing:        make(chan struct{}),
+		release:        make(chan struct{}),
+	}
+	t.Cleanup(func() { close(stuck.release) })
+
+	mocks := &mockConnectionFactory{} //nolint:exhaustruct
+
+	registry := connfx.NewRegistry(newMockLogger())
+	registry.RegisterFactory(mocks)
+	registry.RegisterFactory(&stuckConnectionFactory{conn: stuck})
+
+	for name, protocol := range map[string]string{"cache": "mock", "hung": "stuck"} {
+		_, err := registry.AddConnection(
+			t.Context(),
+			name,
+			&connfx.ConfigTarget{Protocol: protocol}, //nolint:exhaustruct
+		)
+		require.NoError(t, err)
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- registry.CloseWithOptions(t.Context(), connfx.CloseOptions{
+			Timeout: 50 * time.Millisecond,
+		})
+	}()
+
+	// The registry stays usable while connections are closing
+	<-stuck.closing
+	assert.Empty(t, registry.ListConnections())
+
+	select {
+	case err := <-errCh:
+		require.ErrorIs(t, err, connfx.ErrFailedToCloseConnections)
+		require.ErrorIs(t, err, connfx.ErrConnectionCloseTimedOut)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), `name="hung"`)
+		assert.NotContains(t, err.Error(), `name="cache"`)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "CloseWithOptions waited for the stuck connection")
+	}
+
+	require.Len(t, mocks.created, 1)
+	assert.Equal(t, connfx.ConnectionStateDisconnected, mocks.created[0].GetState())
+}
@@ -0,0 +1,55 @@
+package connfx
+
+import (
+	"sync/atomic"
+)
+
+// StateChangeHook is invoked by a connection when it transitions between states.
+type StateChangeHook func(from, to ConnectionState, reason error)
+
+// StateObservable is implemented by connections that report their own state transitions.
+type StateObservable interface {
+	// SetStateChangeHook registers the hook invoked on every state transition
+	SetStateChangeHook(hook StateChangeHook)
+}
+
+// stateTracker holds a connection state and notifies a hook when it changes.
+// Adapters embed it to satisfy StateObservable.
+type stateTracker struct {
+	hook  atomic.Pointer[StateChangeHook]
+	state atomic.Int32
+}
+
+func newStateTracker(initial ConnectionState) *stateTracker {
+	tracker := &stateTracker{} //nolint:exhaustruct
+	tracker.state.Store(int32(initial))
+
+	return tracker
+}
+
+// SetStateChangeHook registers the hook invoked on every state transition.
+func (t *stateTracker) SetStateChangeHook(hook StateChangeHook) {
+	if hook == nil {
+		t.hook.Store(nil)
+
+		return
+	}
+
+	t.hook.Store(&hook)
+}
+
+func (t *stateTracker) loadState() ConnectionState {
+	return ConnectionState(t.state.Load())
+}
+
+// setState stores the new state and notifies the hook if the state actually changed.
+func (t *stateTracker) setState(to ConnectionState, reason error) {
+	from := ConnectionState(t.state.Swap(int32(to)))
+	if from == to {
+		return
+	}
+
+	if hook := t.hook.Load(); hook != nil {
+		(*hook)(from, to, reason)
+	}
+}
@@ -2,30 +2,53 @@ package datafx
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"slices"
 	"time"
 
 	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/lib"
+	"github.com/eser/ajan/metricsfx"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
 	ErrCacheNotSupported = errors.New("connection does not support cache operations")
-	ErrKeyExpired        = errors.New("key has expired")
+	ErrKeyExpired        = lib.NewCategorizedError(lib.ErrCategoryNotFound, "key has expired")
 	ErrCacheOperation    = errors.New("cache operation failed")
+	ErrCacheFallback     = errors.New("cache fallback failed")
+	ErrCacheLoader       = errors.New("cache loader failed")
 )
 
+// CoalescedLoadTimeout bounds a load shared by concurrent cache misses, which no longer
+// follows the context of the caller that started it.
+const CoalescedLoadTimeout = 30 * time.Second
+
+// FallbackRepopulateTimeout bounds the background write of a fallback value back to the
+// cache, which no longer follows the context of the read that produced it.
+const FallbackRepopulateTimeout = 5 * time.Second
+
 // Cache provides high-level cache operations with expiration support.
 type Cache struct {
 	conn       connfx.Connection
 	repository connfx.CacheRepository
+	codec      Codec
+	tracer     operationTracer
+	metrics    *CacheMetrics
+
+	namespace          keyNamespace
+	fallbackRepopulate time.Duration
+
+	// loads coalesces concurrent GetOrSet misses when coalesceLoads is set
+	loads         singleflight.Group
+	coalesceLoads bool
 }
 
 // NewCache creates a new Cache instance from a connfx connection.
 // The connection must support cache operations.
-func NewCache(conn connfx.Connection) (*Cache, error) {
+func NewCache(conn connfx.Connection, opts ...Option) (*Cache, error) {
 	if conn == nil {
 		return nil, fmt.Errorf("%w: connection is nil", ErrConnectionNotSupported)
 	}
@@ -49,24 +72,49 @@ func NewCache(conn connfx.Connection) (*Cache, error) {
 		)
 	}
 
+	options := newOptions(opts)
+
+	tracer, err := newOperationTracer(conn, options)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Cache{
 		conn:       conn,
 		repository: repo,
+		codec:      options.codec,
+		tracer:     tracer,
+		metrics:    options.cacheMetrics,
+
+		namespace:          keyNamespace(options.keyPrefix),
+		fallbackRepopulate: options.fallbackRepopulate,
+
+		loads:         singleflight.Group{},
+		coalesceLoads: options.singleflight,
 	}, nil
 }
 
-// Set stores a value with the given key and expiration time after marshaling it to JSON.
+// Set stores a value with the given key and expiration time after encoding it with the cache codec.
 func (c *Cache) Set(ctx context.Context, key string, value any, expiration time.Duration) error {
-	data, err := json.Marshal(value)
-	if err != nil {
-		return fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
-	}
+	return c.tracer.run(
+		ctx,
+		"cache.set",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			data, err := c.codec.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
+			}
 
-	if err := c.repository.SetWithExpiration(ctx, key, data, expiration); err != nil {
-		return fmt.Errorf("%w (operation=set, key=%q): %w", ErrCacheOperation, key, err)
-	}
+			err = c.repository.SetWithExpiration(ctx, c.namespace.key(key), data, expiration)
+			if err != nil {
+				return fmt.Errorf("%w (operation=set, key=%q): %w", ErrCacheOperation, key, err)
+			}
 
-	return nil
+			return nil
+		},
+	)
 }
 
 // SetRaw stores raw bytes with the given key and expiration time.
@@ -76,81 +124,339 @@ func (c *Cache) SetRaw(
 	value []byte,
 	expiration time.Duration,
 ) error {
-	if err := c.repository.SetWithExpiration(ctx, key, value, expiration); err != nil {
-		return fmt.Errorf("%w (operation=set_raw, key=%q): %w", ErrCacheOperation, key, err)
-	}
+	return c.tracer.run(
+		ctx,
+		"cache.set_raw",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			err := c.repository.SetWithExpiration(ctx, c.namespace.key(key), value, expiration)
+			if err != nil {
+				return fmt.Errorf("%w (operation=set_raw, key=%q): %w", ErrCacheOperation, key, err)
+			}
 
-	return nil
+			return nil
+		},
+	)
 }
 
-// Get retrieves a value by key and unmarshals it into the provided destination.
+// Get retrieves a value by key and decodes it into the provided destination.
 func (c *Cache) Get(ctx context.Context, key string, dest any) error {
-	data, err := c.repository.Get(ctx, key)
+	return c.tracer.run(
+		ctx,
+		"cache.get",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			data, err := c.repository.Get(ctx, c.namespace.key(key))
+			if err != nil {
+				return fmt.Errorf("%w (operation=get, key=%q): %w", ErrCacheOperation, key, err)
+			}
+
+			if data == nil {
+				return fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
+			}
+
+			if err := c.codec.Unmarshal(data, dest); err != nil {
+				return fmt.Errorf("%w (key=%q): %w", ErrFailedToUnmarshal, key, err)
+			}
+
+			return nil
+		},
+	)
+}
+
+// GetWithFallback reads key into dest like Get, but degrades to fallback instead of
+// failing: on a miss and on any cache error, including an unreachable backend, the value
+// returned by fallback is decoded into dest. With WithFallbackRepopulate, the value is
+// also written back to the cache in the background, so a slow or unreachable backend does
+// not delay the read. The returned error is non-nil only when fallback fails.
+func (c *Cache) GetWithFallback(
+	ctx context.Context,
+	key string,
+	dest any,
+	fallback func() (any, error),
+) error {
+	cacheErr := c.Get(ctx, key, dest)
+	if cacheErr == nil {
+		return nil
+	}
+
+	reason := "error"
+	if errors.Is(cacheErr, ErrKeyNotFound) {
+		reason = "miss"
+	}
+
+	if c.metrics != nil && c.metrics.FallbackActivations != nil {
+		c.metrics.FallbackActivations.Inc(ctx, metricsfx.StringAttr("reason", reason))
+	}
+
+	value, err := fallback()
 	if err != nil {
-		return fmt.Errorf("%w (operation=get, key=%q): %w", ErrCacheOperation, key, err)
+		return fmt.Errorf("%w (key=%q): %w (cache: %w)", ErrCacheFallback, key, err, cacheErr)
 	}
 
-	if data == nil {
-		return fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
 	}
 
-	if err := json.Unmarshal(data, dest); err != nil {
+	if err := c.codec.Unmarshal(data, dest); err != nil {
 		return fmt.Errorf("%w (key=%q): %w", ErrFailedToUnmarshal, key, err)
 	}
 
+	if c.fallbackRepopulate > 0 {
+		c.repopulate(ctx, key, data)
+	}
+
 	return nil
 }
 
-// GetRaw retrieves raw bytes by key.
-func (c *Cache) GetRaw(ctx context.Context, key string) ([]byte, error) {
-	data, err := c.repository.Get(ctx, key)
+// repopulate writes a fallback value back to the cache without blocking the caller. The
+// write outlives the caller's context, bounded by FallbackRepopulateTimeout; failures
+// are ignored, as the next read falls back again.
+func (c *Cache) repopulate(ctx context.Context, key string, data []byte) {
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), FallbackRepopulateTimeout)
+
+	lib.SafeGo(func() error {
+		defer cancel()
+
+		return c.repository.SetWithExpiration(
+			writeCtx,
+			c.namespace.key(key),
+			data,
+			c.fallbackRepopulate,
+		)
+	}, nil)
+}
+
+// GetOrSet reads key into dest like Get; on a miss it calls loader, stores the result
+// with the given expiration and decodes it into dest. With WithSingleflight, concurrent
+// misses for the same key share a single loader call instead of stampeding the source;
+// the shared call is detached from the caller that started it and bounded by
+// CoalescedLoadTimeout.
+//
+// When loader fails, nothing is cached, dest is left untouched and the error is
+// returned wrapped in ErrCacheLoader. Cache read or write failures degrade to calling
+// the loader, as with Memoize.
+func (c *Cache) GetOrSet(
+	ctx context.Context,
+	key string,
+	expiration time.Duration,
+	dest any,
+	loader func(ctx context.Context) (any, error),
+) error {
+	var group *singleflight.Group
+	if c.coalesceLoads {
+		group = &c.loads
+	}
+
+	return c.getOrLoad(ctx, group, key, expiration, dest, func(ctx context.Context) (any, error) {
+		value, err := loader(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w (key=%q): %w", ErrCacheLoader, key, err)
+		}
+
+		return value, nil
+	})
+}
+
+// getOrLoad is the cache-aside path shared by GetOrSet and Memoize. On a miss it calls
+// loader and stores its encoded result through SetRaw. With a group, concurrent misses
+// share one load, which runs on a context detached from the caller that started it and
+// double-checks the cache first; a caller whose ctx is done stops waiting. Loader errors
+// are returned as they are.
+func (c *Cache) getOrLoad(
+	ctx context.Context,
+	group *singleflight.Group,
+	key string,
+	expiration time.Duration,
+	dest any,
+	loader func(ctx context.Context) (any, error),
+) error {
+	if err := c.Get(ctx, key, dest); err == nil {
+		return nil
+	}
+
+	if group == nil {
+		data, err := c.loadAndSet(ctx, key, expiration, loader)
+		if err != nil {
+			return err
+		}
+
+		return c.decode(key, data, dest)
+	}
+
+	flight := group.DoChan(key, func() (any, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CoalescedLoadTimeout)
+		defer cancel()
+
+		// Another flight may have filled the cache since this caller missed it
+		if data, err := c.GetRaw(loadCtx, key); err == nil {
+			return data, nil
+		}
+
+		return c.loadAndSet(loadCtx, key, expiration, loader)
+	})
+
+	select {
+	case result := <-flight:
+		if result.Err != nil {
+			return result.Err //nolint:wrapcheck
+		}
+
+		data, _ := result.Val.([]byte)
+
+		return c.decode(key, data, dest)
+	case <-ctx.Done():
+		return fmt.Errorf(
+			"%w (operation=get_or_set, key=%q): %w",
+			ErrCacheOperation,
+			key,
+			ctx.Err(),
+		)
+	}
+}
+
+// loadAndSet calls loader and caches its encoded result, returning the encoded bytes.
+func (c *Cache) loadAndSet(
+	ctx context.Context,
+	key string,
+	expiration time.Duration,
+	loader func(ctx context.Context) (any, error),
+) ([]byte, error) {
+	value, err := loader(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("%w (operation=get_raw, key=%q): %w", ErrCacheOperation, key, err)
+		return nil, err
 	}
 
-	if data == nil {
-		return nil, fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
 	}
 
+	_ = c.SetRaw(ctx, key, data, expiration)
+
 	return data, nil
 }
 
-// Delete removes a key from the cache.
-func (c *Cache) Delete(ctx context.Context, key string) error {
-	if err := c.repository.Remove(ctx, key); err != nil {
-		return fmt.Errorf("%w (operation=delete, key=%q): %w", ErrCacheOperation, key, err)
+// decode decodes data read or loaded for key into dest.
+func (c *Cache) decode(key string, data []byte, dest any) error {
+	if err := c.codec.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("%w (key=%q): %w", ErrFailedToUnmarshal, key, err)
 	}
 
 	return nil
 }
 
+// GetRaw retrieves raw bytes by key.
+func (c *Cache) GetRaw(ctx context.Context, key string) ([]byte, error) {
+	return traceOperation(
+		ctx,
+		c.tracer,
+		"cache.get_raw",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) ([]byte, error) {
+			data, err := c.repository.Get(ctx, c.namespace.key(key))
+			if err != nil {
+				return nil, fmt.Errorf(
+					"%w (operation=get_raw, key=%q): %w",
+					ErrCacheOperation,
+					key,
+					err,
+				)
+			}
+
+			if data == nil {
+				return nil, fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
+			}
+
+			return data, nil
+		},
+	)
+}
+
+// Delete removes a key from the cache.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	return c.tracer.run(
+		ctx,
+		"cache.delete",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			if err := c.repository.Remove(ctx, c.namespace.key(key)); err != nil {
+				return fmt.Errorf("%w (operation=delete, key=%q): %w", ErrCacheOperation, key, err)
+			}
+
+			return nil
+		},
+	)
+}
+
 // Exists checks if a key exists in the cache.
 func (c *Cache) Exists(ctx context.Context, key string) (bool, error) {
-	exists, err := c.repository.Exists(ctx, key)
-	if err != nil {
-		return false, fmt.Errorf("%w (operation=exists, key=%q): %w", ErrCacheOperation, key, err)
-	}
+	return traceOperation(
+		ctx,
+		c.tracer,
+		"cache.exists",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) (bool, error) {
+			exists, err := c.repository.Exists(ctx, c.namespace.key(key))
+			if err != nil {
+				return false, fmt.Errorf(
+					"%w (operation=exists, key=%q): %w",
+					ErrCacheOperation,
+					key,
+					err,
+				)
+			}
 
-	return exists, nil
+			return exists, nil
+		},
+	)
 }
 
 // GetTTL returns the time-to-live for a key.
 func (c *Cache) GetTTL(ctx context.Context, key string) (time.Duration, error) {
-	ttl, err := c.repository.GetTTL(ctx, key)
-	if err != nil {
-		return 0, fmt.Errorf("%w (operation=get_ttl, key=%q): %w", ErrCacheOperation, key, err)
-	}
+	return traceOperation(
+		ctx,
+		c.tracer,
+		"cache.get_ttl",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) (time.Duration, error) {
+			ttl, err := c.repository.GetTTL(ctx, c.namespace.key(key))
+			if err != nil {
+				return 0, fmt.Errorf(
+					"%w (operation=get_ttl, key=%q): %w",
+					ErrCacheOperation,
+					key,
+					err,
+				)
+			}
 
-	return ttl, nil
+			return ttl, nil
+		},
+	)
 }
 
 // Expire sets an expiration time for an existing key.
 func (c *Cache) Expire(ctx context.Context, key string, expiration time.Duration) error {
-	if err := c.repository.Expire(ctx, key, expiration); err != nil {
-		return fmt.Errorf("%w (operation=expire, key=%q): %w", ErrCacheOperation, key, err)
-	}
+	return c.tracer.run(
+		ctx,
+		"cache.expire",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			if err := c.repository.Expire(ctx, c.namespace.key(key), expiration); err != nil {
+				return fmt.Errorf("%w (operation=expire, key=%q): %w", ErrCacheOperation, key, err)
+			}
 
-	return nil
+			return nil
+		},
+	)
 }
 
 // GetConnection returns the underlying connfx connection.
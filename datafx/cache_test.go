@@ -0,0 +1,303 @@
+package datafx_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/datafx"
+	"github.com/eser/ajan/metricsfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errCacheDown = errors.New("cache backend unreachable")
+
+// flakyCacheConnection fails every cache read and write while it is down.
+type flakyCacheConnection struct {
+	*memoryConnection
+
+	down atomic.Bool
+}
+
+func (c *flakyCacheConnection) GetRawConnection() any {
+	return c
+}
+
+func (c *flakyCacheConnection) Get(ctx context.Context, key string) ([]byte, error) {
+	if c.down.Load() {
+		return nil, errCacheDown
+	}
+
+	return c.memoryConnection.Get(ctx, key)
+}
+
+func (c *flakyCacheConnection) SetWithExpiration(
+	ctx context.Context,
+	key string,
+	value []byte,
+	expiration time.Duration,
+) error {
+	if c.down.Load() {
+		return errCacheDown
+	}
+
+	return c.memoryConnection.SetWithExpiration(ctx, key, value, expiration)
+}
+
+func TestCache_GetWithFallback(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	t.Run("degrades to the fallback and repopulates on recovery", func(t *testing.T) {
+		t.Parallel()
+
+		exporter := &recordingExporter{values: make(map[string]exportedValue), mu: sync.Mutex{}}
+		provider := metricsfx.NewMetricsProvider(&metricsfx.Config{
+			ServiceName:                   "test-service",
+			ServiceVersion:                "1.0.0",
+			ServiceInstanceID:             "",
+			OTLPConnectionName:            "otel",
+			ExportInterval:                time.Hour,
+			NoNativeCollectorRegistration: true,
+			RequireNativeCollectors:       false,
+		}, &stubRegistry{
+			connections: map[string]any{"otel": &stubOTLPConnection{exporter: exporter}},
+		})
+		require.NoError(t, provider.Init())
+
+		metrics := datafx.NewCacheMetrics(provider)
+		require.NoError(t, metrics.Init())
+
+		conn := &flakyCacheConnection{memoryConnection: newMemoryConnection()} //nolint:exhaustruct
+		conn.down.Store(true)
+
+		cache, err := datafx.NewCache(
+			conn,
+			datafx.WithCacheMetrics(metrics),
+			datafx.WithFallbackRepopulate(time.Minute),
+		)
+		require.NoError(t, err)
+
+		var loads atomic.Int32
+
+		fallback := func() (any, error) {
+			loads.Add(1)
+
+			return memoizedUser{ID: 1, Name: "alice"}, nil
+		}
+
+		// Backend down: served from the fallback instead of failing
+		var user memoizedUser
+
+		require.NoError(t, cache.GetWithFallback(t.Context(), "user:1", &user, fallback))
+		assert.Equal(t, memoizedUser{ID: 1, Name: "alice"}, user)
+
+		// Backend recovered: the miss is filled from the fallback and written back
+		conn.down.Store(false)
+
+		user = memoizedUser{} //nolint:exhaustruct
+		require.NoError(t, cache.GetWithFallback(t.Context(), "user:1", &user, fallback))
+		assert.Equal(t, "alice", user.Name)
+
+		// The write-back runs in the background
+		require.Eventually(t, func() bool {
+			var cached memoizedUser
+
+			return cache.Get(t.Context(), "user:1", &cached) == nil
+		}, time.Second, time.Millisecond)
+
+		// Now served from the cache
+		user = memoizedUser{} //nolint:exhaustruct
+		require.NoError(t, cache.GetWithFallback(t.Context(), "user:1", &user, fallback))
+		assert.Equal(t, "alice", user.Name)
+		assert.Equal(t, int32(2), loads.Load())
+
+		require.NoError(t, provider.Shutdown(t.Context()))
+
+		activations, ok := exporter.value("cache_fallback_activations_total")
+		require.True(t, ok)
+		assert.InDelta(t, 2, activations.value, 0)
+	})
+
+	t.Run("fallback failure", func(t *testing.T) {
+		t.Parallel()
+
+		conn := &flakyCacheConnection{memoryConnection: newMemoryConnection()} //nolint:exhaustruct
+		conn.down.Store(true)
+
+		cache, err := datafx.NewCache(conn)
+		require.NoError(t, err)
+
+		var user memoizedUser
+
+		err = cache.GetWithFallback(t.Context(), "user:1", &user, func() (any, error) {
+			return nil, errLoaderFailed
+		})
+		require.ErrorIs(t, err, datafx.ErrCacheFallback)
+		require.ErrorIs(t, err, errLoaderFailed)
+		require.ErrorIs(t, err, errCacheDown)
+	})
+}
+
+func TestCache_GetOrSet(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	t.Run("loads on a miss and serves hits from the cache", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newTestCache(t)
+
+		var loads atomic.Int32
+
+		loader := func(ctx context.Context) (any, error) {
+			loads.Add(1)
+
+			return memoizedUser{ID: 1, Name: "alice"}, nil
+		}
+
+		var user memoizedUser
+
+		require.NoError(t, cache.GetOrSet(t.Context(), "user:1", time.Minute, &user, loader))
+		assert.Equal(t, memoizedUser{ID: 1, Name: "alice"}, user)
+
+		var cached memoizedUser
+
+		require.NoError(t, cache.GetOrSet(t.Context(), "user:1", time.Minute, &cached, loader))
+		assert.Equal(t, user, cached)
+		assert.Equal(t, int32(1), loads.Load())
+
+		ttl, err := cache.GetTTL(t.Context(), "user:1")
+		require.NoError(t, err)
+		assert.Positive(t, ttl)
+	})
+
+	t.Run("loader errors are not cached", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newTestCache(t)
+
+		user := memoizedUser{ID: 0, Name: "untouched"}
+
+		err := cache.GetOrSet(
+			t.Context(),
+			"user:2",
+			time.Minute,
+			&user,
+			func(ctx context.Context) (any, error) {
+				return nil, errLoaderFailed
+			},
+		)
+		require.ErrorIs(t, err, datafx.ErrCacheLoader)
+		require.ErrorIs(t, err, errLoaderFailed)
+		assert.Equal(t, "untouched", user.Name)
+
+		exists, err := cache.Exists(t.Context(), "user:2")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("singleflight coalesces concurrent misses", func(t *testing.T) {
+		t.Parallel()
+
+		cache, err := datafx.NewCache(newMemoryConnection(), datafx.WithSingleflight())
+		require.NoError(t, err)
+
+		var loads atomic.Int32
+
+		release := make(chan struct{})
+
+		loader := func(ctx context.Context) (any, error) {
+			loads.Add(1)
+			<-release
+
+			return memoizedUser{ID: 3, Name: "carol"}, nil
+		}
+
+		const callers = 10
+
+		var wg sync.WaitGroup
+
+		results := make([]memoizedUser, callers)
+
+		for i := range callers {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				assert.NoError(
+					t,
+					cache.GetOrSet(t.Context(), "user:3", time.Minute, &results[i], loader),
+				)
+			}()
+		}
+
+		// Give callers a moment to pile up behind the single flight
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads.Load())
+
+		for _, user := range results {
+			assert.Equal(t, memoizedUser{ID: 3, Name: "carol"}, user)
+		}
+	})
+
+	t.Run("cache outage degrades to the loader", func(t *testing.T) {
+		t.Parallel()
+
+		conn := &flakyCacheConnection{memoryConnection: newMemoryConnection()} //nolint:exhaustruct
+		conn.down.Store(true)
+
+		cache, err := datafx.NewCache(conn)
+		require.NoError(t, err)
+
+		var user memoizedUser
+
+		err = cache.GetOrSet(t.Context(), "user:4", time.Minute, &user,
+			func(ctx context.Context) (any, error) {
+				return memoizedUser{ID: 4, Name: "dave"}, nil
+			},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "dave", user.Name)
+	})
+}
+
+func TestCache_KeyPrefix(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	raw, err := datafx.NewCache(conn)
+	require.NoError(t, err)
+
+	cache, err := datafx.NewCache(conn, datafx.WithKeyPrefix("tenant-a"))
+	require.NoError(t, err)
+
+	err = cache.Set(t.Context(), "user:1", memoizedUser{ID: 1, Name: "alice"}, time.Minute)
+	require.NoError(t, err)
+
+	var user memoizedUser
+
+	require.NoError(t, raw.Get(t.Context(), "tenant-a:user:1", &user))
+	assert.Equal(t, "alice", user.Name)
+
+	exists, err := raw.Exists(t.Context(), "user:1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	ttl, err := cache.GetTTL(t.Context(), "user:1")
+	require.NoError(t, err)
+	assert.Positive(t, ttl)
+
+	require.NoError(t, cache.Delete(t.Context(), "user:1"))
+
+	exists, err = raw.Exists(t.Context(), "tenant-a:user:1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
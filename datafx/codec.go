@@ -0,0 +1,43 @@
+package datafx
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec encodes values to bytes and back for Store and Cache.
+type Codec interface {
+	Marshal(value any) ([]byte, error)
+	Unmarshal(data []byte, dest any) error
+}
+
+// JSONCodec encodes values as JSON. It is the default codec.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(value any) ([]byte, error) {
+	return json.Marshal(value) //nolint:wrapcheck
+}
+
+func (JSONCodec) Unmarshal(data []byte, dest any) error {
+	return json.Unmarshal(data, dest) //nolint:wrapcheck
+}
+
+// GobCodec encodes values with encoding/gob, which is faster and more compact than
+// JSON but only readable by Go programs with the same types. Concrete types stored
+// behind interface values (e.g. in map[string]any) must be registered with gob.Register.
+type GobCodec struct{}
+
+func (GobCodec) Marshal(value any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, dest any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(dest) //nolint:wrapcheck
+}
@@ -0,0 +1,117 @@
+package datafx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type leaderboardEntry struct {
+	PlayerName string
+	PlayerID   int64
+	Score      float64
+	Active     bool
+}
+
+type leaderboard struct {
+	UpdatedAt time.Time
+	Name      string
+	Entries   []leaderboardEntry
+}
+
+func newLeaderboard() leaderboard {
+	entries := make([]leaderboardEntry, 1000)
+	for i := range entries {
+		entries[i] = leaderboardEntry{
+			PlayerName: "player",
+			PlayerID:   int64(i) * 7919,
+			Score:      float64(i) * 1.5,
+			Active:     i%2 == 0,
+		}
+	}
+
+	return leaderboard{
+		UpdatedAt: time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC),
+		Name:      "weekly",
+		Entries:   entries,
+	}
+}
+
+func TestStore_GobCodec(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	jsonStore, err := datafx.NewStore(conn)
+	require.NoError(t, err)
+
+	gobStore, err := datafx.NewStore(conn, datafx.WithCodec(datafx.GobCodec{}))
+	require.NoError(t, err)
+
+	value := newLeaderboard()
+
+	require.NoError(t, jsonStore.Set(t.Context(), "leaderboard:json", value))
+	require.NoError(t, gobStore.Set(t.Context(), "leaderboard:gob", value))
+
+	var decoded leaderboard
+
+	require.NoError(t, gobStore.Get(t.Context(), "leaderboard:gob", &decoded))
+	assert.Equal(t, value, decoded)
+
+	jsonData, err := jsonStore.GetRaw(t.Context(), "leaderboard:json")
+	require.NoError(t, err)
+
+	gobData, err := gobStore.GetRaw(t.Context(), "leaderboard:gob")
+	require.NoError(t, err)
+
+	assert.Less(t, len(gobData), len(jsonData)/2)
+
+	// Values written with one codec cannot be read with another
+	require.ErrorIs(
+		t,
+		jsonStore.Get(t.Context(), "leaderboard:gob", &decoded),
+		datafx.ErrFailedToUnmarshal,
+	)
+}
+
+func TestCache_GobCodec(t *testing.T) {
+	t.Parallel()
+
+	cache, err := datafx.NewCache(newMemoryConnection(), datafx.WithCodec(datafx.GobCodec{}))
+	require.NoError(t, err)
+
+	value := newLeaderboard()
+
+	require.NoError(t, cache.Set(t.Context(), "leaderboard", value, time.Minute))
+
+	var decoded leaderboard
+
+	require.NoError(t, cache.Get(t.Context(), "leaderboard", &decoded))
+	assert.Equal(t, value, decoded)
+}
+
+func TestTransactionalStore_GobCodec(t *testing.T) {
+	t.Parallel()
+
+	store, err := datafx.NewTransactionalStore(
+		newMemoryConnection(),
+		datafx.WithCodec(datafx.GobCodec{}),
+	)
+	require.NoError(t, err)
+
+	value := newLeaderboard()
+
+	err = store.ExecuteTransaction(t.Context(), func(tx *datafx.TransactionStore) error {
+		return tx.Set(t.Context(), "leaderboard", value)
+	})
+	require.NoError(t, err)
+
+	// Values written within a transaction use the store codec
+	var decoded leaderboard
+
+	require.NoError(t, store.Get(t.Context(), "leaderboard", &decoded))
+	assert.Equal(t, value, decoded)
+}
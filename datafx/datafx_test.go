@@ -0,0 +1,621 @@
+package datafx_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/connfx/conformance"
+)
+
+var (
+	_ connfx.CacheRepository         = (*memoryConnection)(nil)
+	_ connfx.CASRepository           = (*memoryConnection)(nil)
+	_ connfx.HashRepository          = (*memoryConnection)(nil)
+	_ connfx.QueueRepository         = (*memoryConnection)(nil)
+	_ connfx.ScanRepository          = (*memoryConnection)(nil)
+	_ connfx.TransactionalRepository = (*memoryConnection)(nil)
+)
+
+var (
+	errMemoryPublishFailed = errors.New("publish failed")
+	errUnknownSavepoint    = errors.New("unknown savepoint")
+)
+
+const memoryQueueBufferSize = 100
+
+// memoryConnection is an in-memory connfx.Connection used by datafx tests.
+// It implements the repository ports that datafx consumes.
+type memoryConnection struct {
+	data    map[string][]byte
+	expires map[string]time.Time
+	hashes  map[string]map[string][]byte
+	queues  map[string]chan connfx.Message
+
+	acked    map[string]int
+	requeued map[string]int
+	dropped  map[string]int
+
+	// publishFailures is the number of upcoming publishes that fail
+	publishFailures int
+
+	nextID int
+	mu     sync.Mutex
+	txMu   sync.Mutex
+}
+
+func newMemoryConnection() *memoryConnection {
+	return &memoryConnection{
+		data:     make(map[string][]byte),
+		expires:  make(map[string]time.Time),
+		hashes:   make(map[string]map[string][]byte),
+		queues:   make(map[string]chan connfx.Message),
+		acked:    make(map[string]int),
+		requeued: make(map[string]int),
+		dropped:  make(map[string]int),
+		nextID:   0,
+		mu:       sync.Mutex{},
+		txMu:     sync.Mutex{},
+
+		publishFailures: 0,
+	}
+}
+
+func TestMemoryConnection_Conformance(t *testing.T) {
+	t.Parallel()
+
+	conformance.AssertConnection(t, newMemoryConnection())
+}
+
+// Connection interface.
+
+func (c *memoryConnection) GetBehaviors() []connfx.ConnectionBehavior {
+	return []connfx.ConnectionBehavior{
+		connfx.ConnectionBehaviorStateful,
+		connfx.ConnectionBehaviorStreaming,
+	}
+}
+
+func (c *memoryConnection) GetCapabilities() []connfx.ConnectionCapability {
+	return []connfx.ConnectionCapability{
+		connfx.ConnectionCapabilityKeyValue,
+		connfx.ConnectionCapabilityCache,
+		connfx.ConnectionCapabilityQueue,
+		connfx.ConnectionCapabilityTransactional,
+	}
+}
+
+func (c *memoryConnection) GetProtocol() string {
+	return "memory"
+}
+
+func (c *memoryConnection) GetState() connfx.ConnectionState {
+	return connfx.ConnectionStateReady
+}
+
+func (c *memoryConnection) HealthCheck(ctx context.Context) *connfx.HealthStatus {
+	return &connfx.HealthStatus{ //nolint:exhaustruct
+		Timestamp: time.Now(),
+		State:     connfx.ConnectionStateReady,
+	}
+}
+
+func (c *memoryConnection) Close(ctx context.Context) error {
+	return nil
+}
+
+func (c *memoryConnection) GetRawConnection() any {
+	return c
+}
+
+// Repository interface.
+
+func (c *memoryConnection) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired(key)
+
+	value, ok := c.data[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return value, nil
+}
+
+func (c *memoryConnection) Set(ctx context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data[key] = value
+	delete(c.expires, key)
+
+	return nil
+}
+
+func (c *memoryConnection) Remove(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.data, key)
+	delete(c.expires, key)
+
+	return nil
+}
+
+func (c *memoryConnection) Update(ctx context.Context, key string, value []byte) error {
+	return c.Set(ctx, key, value)
+}
+
+func (c *memoryConnection) Exists(ctx context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired(key)
+
+	_, ok := c.data[key]
+
+	return ok, nil
+}
+
+// CASRepository interface.
+
+func (c *memoryConnection) CompareAndSwap(
+	ctx context.Context,
+	key string,
+	expected []byte,
+	newValue []byte,
+) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired(key)
+
+	current, ok := c.data[key]
+
+	if expected == nil {
+		if ok {
+			return false, nil
+		}
+	} else if !ok || !bytes.Equal(current, expected) {
+		return false, nil
+	}
+
+	c.data[key] = newValue
+	delete(c.expires, key)
+
+	return true, nil
+}
+
+// ScanRepository interface.
+
+func (c *memoryConnection) Keys(ctx context.Context, prefix string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]string, 0)
+
+	for key := range c.data {
+		c.evictExpired(key)
+
+		if _, ok := c.data[key]; ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (c *memoryConnection) DeleteByPrefix(ctx context.Context, prefix string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deleted := 0
+
+	for key := range c.data {
+		c.evictExpired(key)
+
+		if _, ok := c.data[key]; ok && strings.HasPrefix(key, prefix) {
+			delete(c.data, key)
+			delete(c.expires, key)
+
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// HashRepository interface.
+
+func (c *memoryConnection) HSet(ctx context.Context, key string, fields map[string][]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hash, ok := c.hashes[key]
+	if !ok {
+		hash = make(map[string][]byte, len(fields))
+		c.hashes[key] = hash
+	}
+
+	maps.Copy(hash, fields)
+
+	return nil
+}
+
+func (c *memoryConnection) HGet(ctx context.Context, key string, field string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hashes[key][field], nil
+}
+
+func (c *memoryConnection) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return maps.Clone(c.hashes[key]), nil
+}
+
+func (c *memoryConnection) HDel(ctx context.Context, key string, fields ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, field := range fields {
+		delete(c.hashes[key], field)
+	}
+
+	return nil
+}
+
+// CacheRepository interface.
+
+func (c *memoryConnection) SetWithExpiration(
+	ctx context.Context,
+	key string,
+	value []byte,
+	expiration time.Duration,
+) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data[key] = value
+
+	if expiration > 0 {
+		c.expires[key] = time.Now().Add(expiration)
+	}
+
+	return nil
+}
+
+func (c *memoryConnection) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired(key)
+
+	expiresAt, ok := c.expires[key]
+	if !ok {
+		return 0, nil
+	}
+
+	return time.Until(expiresAt), nil
+}
+
+func (c *memoryConnection) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expires[key] = time.Now().Add(expiration)
+
+	return nil
+}
+
+// evictExpired removes key if its expiration has passed. Callers must hold c.mu.
+func (c *memoryConnection) evictExpired(key string) {
+	expiresAt, ok := c.expires[key]
+	if ok && time.Now().After(expiresAt) {
+		delete(c.data, key)
+		delete(c.expires, key)
+	}
+}
+
+// TransactionalRepository interface.
+
+// BeginTransaction starts a transaction; transactions are serialized and their
+// writes are buffered until commit.
+func (c *memoryConnection) BeginTransaction(ctx context.Context) (connfx.TransactionContext, error) {
+	c.txMu.Lock()
+
+	return &memoryTransaction{
+		conn:       c,
+		writes:     make(map[string][]byte),
+		savepoints: make(map[string]map[string][]byte),
+		done:       false,
+	}, nil
+}
+
+// memoryTransaction buffers writes; a nil value marks a removed key. Savepoints are
+// snapshots of the buffered writes.
+type memoryTransaction struct {
+	conn       *memoryConnection
+	writes     map[string][]byte
+	savepoints map[string]map[string][]byte
+	done       bool
+}
+
+func (tx *memoryTransaction) CreateSavepoint(name string) error {
+	tx.savepoints[name] = maps.Clone(tx.writes)
+
+	return nil
+}
+
+func (tx *memoryTransaction) RollbackToSavepoint(name string) error {
+	writes, ok := tx.savepoints[name]
+	if !ok {
+		return errUnknownSavepoint
+	}
+
+	tx.writes = maps.Clone(writes)
+
+	return nil
+}
+
+func (tx *memoryTransaction) ReleaseSavepoint(name string) error {
+	if _, ok := tx.savepoints[name]; !ok {
+		return errUnknownSavepoint
+	}
+
+	delete(tx.savepoints, name)
+
+	return nil
+}
+
+func (tx *memoryTransaction) Commit() error {
+	tx.conn.mu.Lock()
+
+	for key, value := range tx.writes {
+		if value == nil {
+			delete(tx.conn.data, key)
+		} else {
+			tx.conn.data[key] = value
+		}
+
+		delete(tx.conn.expires, key)
+	}
+
+	tx.conn.mu.Unlock()
+
+	return tx.finish()
+}
+
+func (tx *memoryTransaction) Rollback() error {
+	return tx.finish()
+}
+
+func (tx *memoryTransaction) finish() error {
+	if !tx.done {
+		tx.done = true
+		tx.conn.txMu.Unlock()
+	}
+
+	return nil
+}
+
+func (tx *memoryTransaction) GetRepository() connfx.Repository {
+	return tx
+}
+
+func (tx *memoryTransaction) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, ok := tx.writes[key]; ok {
+		return value, nil
+	}
+
+	return tx.conn.Get(ctx, key)
+}
+
+func (tx *memoryTransaction) Set(ctx context.Context, key string, value []byte) error {
+	tx.writes[key] = value
+
+	return nil
+}
+
+func (tx *memoryTransaction) Remove(ctx context.Context, key string) error {
+	tx.writes[key] = nil
+
+	return nil
+}
+
+func (tx *memoryTransaction) Update(ctx context.Context, key string, value []byte) error {
+	return tx.Set(ctx, key, value)
+}
+
+func (tx *memoryTransaction) Exists(ctx context.Context, key string) (bool, error) {
+	value, err := tx.Get(ctx, key)
+
+	return value != nil, err
+}
+
+// QueueRepository interface.
+
+func (c *memoryConnection) queue(name string) chan connfx.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, ok := c.queues[name]
+	if !ok {
+		queue = make(chan connfx.Message, memoryQueueBufferSize)
+		c.queues[name] = queue
+	}
+
+	return queue
+}
+
+func (c *memoryConnection) QueueDeclare(ctx context.Context, name string) (string, error) {
+	c.queue(name)
+
+	return name, nil
+}
+
+func (c *memoryConnection) QueueDeclareWithConfig(
+	ctx context.Context,
+	name string,
+	config connfx.QueueConfig,
+) (string, error) {
+	return c.QueueDeclare(ctx, name)
+}
+
+func (c *memoryConnection) Publish(ctx context.Context, queueName string, body []byte) error {
+	return c.PublishWithHeaders(ctx, queueName, body, nil)
+}
+
+func (c *memoryConnection) PublishWithHeaders(
+	ctx context.Context,
+	queueName string,
+	body []byte,
+	headers map[string]any,
+) error {
+	c.mu.Lock()
+	if c.publishFailures > 0 {
+		c.publishFailures--
+		c.mu.Unlock()
+
+		return errMemoryPublishFailed
+	}
+
+	c.nextID++
+	messageID := strconv.Itoa(c.nextID)
+	c.mu.Unlock()
+
+	c.queue(queueName) <- connfx.Message{ //nolint:exhaustruct
+		Timestamp:     time.Now(),
+		Headers:       headers,
+		ReceiptHandle: messageID,
+		MessageID:     messageID,
+		Body:          body,
+		DeliveryCount: 1,
+	}
+
+	return nil
+}
+
+func (c *memoryConnection) Consume(
+	ctx context.Context,
+	queueName string,
+	config connfx.ConsumerConfig,
+) (<-chan connfx.Message, <-chan error) {
+	queue := c.queue(queueName)
+	messages := make(chan connfx.Message)
+	errs := make(chan error)
+
+	go func() {
+		defer close(messages)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-queue:
+				c.bindAcknowledgers(&msg, queue)
+
+				select {
+				case messages <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, errs
+}
+
+func (c *memoryConnection) ConsumeWithGroup(
+	ctx context.Context,
+	queueName string,
+	consumerGroup string,
+	consumerName string,
+	config connfx.ConsumerConfig,
+) (<-chan connfx.Message, <-chan error) {
+	return c.Consume(ctx, queueName, config)
+}
+
+func (c *memoryConnection) ClaimPendingMessages(
+	ctx context.Context,
+	queueName string,
+	consumerGroup string,
+	consumerName string,
+	minIdleTime time.Duration,
+	count int,
+) ([]connfx.Message, error) {
+	return nil, nil
+}
+
+func (c *memoryConnection) AckMessage(
+	ctx context.Context,
+	queueName, consumerGroup, receiptHandle string,
+) error {
+	return nil
+}
+
+func (c *memoryConnection) DeleteMessage(
+	ctx context.Context,
+	queueName, receiptHandle string,
+) error {
+	return nil
+}
+
+func (c *memoryConnection) bindAcknowledgers(msg *connfx.Message, queue chan connfx.Message) {
+	delivered := *msg
+
+	msg.SetAckFunc(func() error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.acked[delivered.MessageID]++
+
+		return nil
+	})
+
+	msg.SetNackFunc(func(requeue bool) error {
+		c.mu.Lock()
+
+		if !requeue {
+			c.dropped[delivered.MessageID]++
+			c.mu.Unlock()
+
+			return nil
+		}
+
+		c.requeued[delivered.MessageID]++
+		c.mu.Unlock()
+
+		redelivery := delivered
+		redelivery.DeliveryCount++
+		queue <- redelivery
+
+		return nil
+	})
+}
+
+func (c *memoryConnection) counts(messageID string) (int, int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.acked[messageID], c.requeued[messageID], c.dropped[messageID]
+}
+
+func (c *memoryConnection) failNextPublishes(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.publishFailures = n
+}
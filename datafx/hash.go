@@ -0,0 +1,227 @@
+package datafx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/eser/ajan/connfx"
+)
+
+var (
+	ErrHashNotSupported = errors.New("connection does not support hash operations")
+	ErrHashOperation    = errors.New("hash operation failed")
+	ErrInvalidHashValue = errors.New("hash values must be structs or pointers to structs")
+)
+
+// Hash stores structs as hashes, one hash field per struct field, so individual fields
+// can be read or updated without rewriting the whole object.
+//
+// Field names come from the `hash` struct tag, falling back to the Go field name;
+// `hash:"-"` skips a field. Each field value is encoded with the configured codec.
+type Hash struct {
+	conn       connfx.Connection
+	repository connfx.HashRepository
+	codec      Codec
+	namespace  keyNamespace
+}
+
+// NewHash creates a new Hash instance from a connfx connection.
+// The connection must implement connfx.HashRepository.
+func NewHash(conn connfx.Connection, opts ...Option) (*Hash, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("%w: connection is nil", ErrConnectionNotSupported)
+	}
+
+	repo, ok := conn.GetRawConnection().(connfx.HashRepository)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: connection does not implement HashRepository interface (protocol=%q)",
+			ErrHashNotSupported,
+			conn.GetProtocol(),
+		)
+	}
+
+	options := newOptions(opts)
+
+	return &Hash{
+		conn:       conn,
+		repository: repo,
+		codec:      options.codec,
+		namespace:  keyNamespace(options.keyPrefix),
+	}, nil
+}
+
+// Set writes every field of value, a struct or a pointer to one, into the hash.
+func (h *Hash) Set(ctx context.Context, key string, value any) error {
+	structValue, err := hashStructValue(value)
+	if err != nil {
+		return fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
+	}
+
+	fields := make(map[string][]byte)
+
+	for name, fieldValue := range hashFields(structValue) {
+		data, err := h.codec.Marshal(fieldValue.Interface())
+		if err != nil {
+			return fmt.Errorf("%w (key=%q, field=%q): %w", ErrFailedToMarshal, key, name, err)
+		}
+
+		fields[name] = data
+	}
+
+	if err := h.repository.HSet(ctx, h.namespace.key(key), fields); err != nil {
+		return fmt.Errorf("%w (operation=set, key=%q): %w", ErrHashOperation, key, err)
+	}
+
+	return nil
+}
+
+// SetFields writes only the given hash fields, leaving the others untouched.
+func (h *Hash) SetFields(ctx context.Context, key string, fields map[string]any) error {
+	encoded := make(map[string][]byte, len(fields))
+
+	for name, value := range fields {
+		data, err := h.codec.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("%w (key=%q, field=%q): %w", ErrFailedToMarshal, key, name, err)
+		}
+
+		encoded[name] = data
+	}
+
+	if err := h.repository.HSet(ctx, h.namespace.key(key), encoded); err != nil {
+		return fmt.Errorf("%w (operation=set_fields, key=%q): %w", ErrHashOperation, key, err)
+	}
+
+	return nil
+}
+
+// Get reads the hash into dest, a pointer to a struct. Struct fields without a
+// matching hash field are left unchanged.
+func (h *Hash) Get(ctx context.Context, key string, dest any) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Pointer || destValue.IsNil() {
+		return fmt.Errorf("%w (key=%q): %w", ErrFailedToUnmarshal, key, ErrInvalidHashValue)
+	}
+
+	structValue, err := hashStructValue(dest)
+	if err != nil {
+		return fmt.Errorf("%w (key=%q): %w", ErrFailedToUnmarshal, key, err)
+	}
+
+	stored, err := h.repository.HGetAll(ctx, h.namespace.key(key))
+	if err != nil {
+		return fmt.Errorf("%w (operation=get, key=%q): %w", ErrHashOperation, key, err)
+	}
+
+	if len(stored) == 0 {
+		return fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
+	}
+
+	for name, fieldValue := range hashFields(structValue) {
+		data, ok := stored[name]
+		if !ok {
+			continue
+		}
+
+		if err := h.codec.Unmarshal(data, fieldValue.Addr().Interface()); err != nil {
+			return fmt.Errorf("%w (key=%q, field=%q): %w", ErrFailedToUnmarshal, key, name, err)
+		}
+	}
+
+	return nil
+}
+
+// GetField reads a single hash field into dest.
+func (h *Hash) GetField(ctx context.Context, key string, field string, dest any) error {
+	data, err := h.repository.HGet(ctx, h.namespace.key(key), field)
+	if err != nil {
+		return fmt.Errorf(
+			"%w (operation=get_field, key=%q, field=%q): %w",
+			ErrHashOperation,
+			key,
+			field,
+			err,
+		)
+	}
+
+	if data == nil {
+		return fmt.Errorf("%w (key=%q, field=%q)", ErrKeyNotFound, key, field)
+	}
+
+	if err := h.codec.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("%w (key=%q, field=%q): %w", ErrFailedToUnmarshal, key, field, err)
+	}
+
+	return nil
+}
+
+// DeleteFields removes the given fields from the hash.
+func (h *Hash) DeleteFields(ctx context.Context, key string, fields ...string) error {
+	if err := h.repository.HDel(ctx, h.namespace.key(key), fields...); err != nil {
+		return fmt.Errorf("%w (operation=delete_fields, key=%q): %w", ErrHashOperation, key, err)
+	}
+
+	return nil
+}
+
+// GetConnection returns the underlying connfx connection.
+func (h *Hash) GetConnection() connfx.Connection {
+	return h.conn
+}
+
+// GetRepository returns the underlying hash repository.
+func (h *Hash) GetRepository() connfx.HashRepository {
+	return h.repository
+}
+
+func hashStructValue(value any) (reflect.Value, error) {
+	structValue := reflect.ValueOf(value)
+
+	for structValue.Kind() == reflect.Pointer {
+		if structValue.IsNil() {
+			return reflect.Value{}, ErrInvalidHashValue
+		}
+
+		structValue = structValue.Elem()
+	}
+
+	if structValue.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%w (kind=%s)", ErrInvalidHashValue, structValue.Kind())
+	}
+
+	return structValue, nil
+}
+
+// hashFields maps hash field names to the exported fields of a struct value.
+func hashFields(structValue reflect.Value) map[string]reflect.Value {
+	structType := structValue.Type()
+	fields := make(map[string]reflect.Value, structType.NumField())
+
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+
+		if tag, ok := field.Tag.Lookup("hash"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		fields[name] = structValue.Field(i)
+	}
+
+	return fields
+}
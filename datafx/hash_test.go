@@ -0,0 +1,105 @@
+package datafx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userProfile struct {
+	UpdatedAt time.Time         `hash:"updated_at"`
+	Tags      []string          `hash:"tags"`
+	Settings  map[string]string `hash:"settings"`
+	Name      string            `hash:"name"`
+	Email     string            `hash:"email"`
+	Password  string            `hash:"-"`
+	Visits    int
+}
+
+func TestHash_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	hash, err := datafx.NewHash(conn)
+	require.NoError(t, err)
+
+	profile := userProfile{
+		UpdatedAt: time.Date(2026, time.March, 4, 5, 6, 7, 0, time.UTC),
+		Tags:      []string{"admin", "beta"},
+		Settings:  map[string]string{"theme": "dark"},
+		Name:      "Eser",
+		Email:     "eser@example.com",
+		Password:  "secret",
+		Visits:    42,
+	}
+
+	require.NoError(t, hash.Set(t.Context(), "user:1", &profile))
+
+	// Each struct field is stored as its own hash field
+	stored, err := conn.HGetAll(t.Context(), "user:1")
+	require.NoError(t, err)
+	assert.Len(t, stored, 6)
+	assert.Contains(t, stored, "Visits")
+	assert.NotContains(t, stored, "Password")
+
+	var decoded userProfile
+
+	require.NoError(t, hash.Get(t.Context(), "user:1", &decoded))
+
+	profile.Password = ""
+	assert.Equal(t, profile, decoded)
+}
+
+func TestHash_PartialUpdate(t *testing.T) {
+	t.Parallel()
+
+	hash, err := datafx.NewHash(newMemoryConnection())
+	require.NoError(t, err)
+
+	require.NoError(t, hash.Set(t.Context(), "user:2", userProfile{ //nolint:exhaustruct
+		Name:   "Eser",
+		Email:  "old@example.com",
+		Visits: 1,
+	}))
+
+	require.NoError(t, hash.SetFields(t.Context(), "user:2", map[string]any{
+		"email":  "new@example.com",
+		"Visits": 2,
+	}))
+
+	var email string
+
+	require.NoError(t, hash.GetField(t.Context(), "user:2", "email", &email))
+	assert.Equal(t, "new@example.com", email)
+
+	var decoded userProfile
+
+	require.NoError(t, hash.Get(t.Context(), "user:2", &decoded))
+	assert.Equal(t, "Eser", decoded.Name)
+	assert.Equal(t, "new@example.com", decoded.Email)
+	assert.Equal(t, 2, decoded.Visits)
+
+	require.NoError(t, hash.DeleteFields(t.Context(), "user:2", "email"))
+	require.ErrorIs(
+		t,
+		hash.GetField(t.Context(), "user:2", "email", &email),
+		datafx.ErrKeyNotFound,
+	)
+}
+
+func TestHash_Errors(t *testing.T) {
+	t.Parallel()
+
+	hash, err := datafx.NewHash(newMemoryConnection())
+	require.NoError(t, err)
+
+	var decoded userProfile
+
+	require.ErrorIs(t, hash.Get(t.Context(), "user:missing", &decoded), datafx.ErrKeyNotFound)
+	require.ErrorIs(t, hash.Set(t.Context(), "user:3", "not a struct"), datafx.ErrInvalidHashValue)
+	require.ErrorIs(t, hash.Get(t.Context(), "user:3", decoded), datafx.ErrInvalidHashValue)
+}
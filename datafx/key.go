@@ -0,0 +1,112 @@
+package datafx
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// KeySeparator joins the parts of keys built by Key and KeyBuilder.
+	KeySeparator = ":"
+
+	keyEscape = `\`
+)
+
+//nolint:gochecknoglobals
+var keyPartEscaper = strings.NewReplacer(
+	keyEscape, keyEscape+keyEscape,
+	KeySeparator, keyEscape+KeySeparator,
+)
+
+// Key joins parts with KeySeparator, escaping separators inside each part so that
+// different part lists never produce the same key: ("a", "b:c") becomes `a:b\:c` while
+// ("a:b", "c") becomes `a\:b:c`. Non-string parts are formatted with fmt.Sprint.
+func Key(parts ...any) string {
+	var sb strings.Builder
+
+	writeKeyParts(&sb, parts)
+
+	return sb.String()
+}
+
+// KeyBuilder builds keys under a fixed namespace prefix. The prefix is written verbatim,
+// so it may itself contain separators (e.g. "app:users"); the parts are escaped as in Key.
+//
+// KeyBuilder is an immutable value, so a shared builder can be extended safely:
+//
+//	users := datafx.NewKeyBuilder("users")
+//	key := users.With(userID).Key("profile") // users:42:profile
+type KeyBuilder struct {
+	prefix string
+}
+
+// NewKeyBuilder creates a KeyBuilder for the given namespace prefix.
+func NewKeyBuilder(prefix string) KeyBuilder {
+	return KeyBuilder{prefix: prefix}
+}
+
+// With returns a builder whose prefix is extended with the given parts.
+func (b KeyBuilder) With(parts ...any) KeyBuilder {
+	return KeyBuilder{prefix: b.Key(parts...)}
+}
+
+// Key returns the prefix followed by the given parts.
+func (b KeyBuilder) Key(parts ...any) string {
+	var sb strings.Builder
+
+	sb.WriteString(b.prefix)
+
+	if b.prefix != "" && len(parts) > 0 {
+		sb.WriteString(KeySeparator)
+	}
+
+	writeKeyParts(&sb, parts)
+
+	return sb.String()
+}
+
+// String returns the builder's prefix.
+func (b KeyBuilder) String() string {
+	return b.prefix
+}
+
+func writeKeyParts(sb *strings.Builder, parts []any) {
+	for i, part := range parts {
+		if i > 0 {
+			sb.WriteString(KeySeparator)
+		}
+
+		str, ok := part.(string)
+		if !ok {
+			str = fmt.Sprint(part)
+		}
+
+		_, _ = keyPartEscaper.WriteString(sb, str)
+	}
+}
+
+// keyNamespace is the prefix set with WithKeyPrefix. It is prepended to every key sent to
+// the backend, and stripped from keys read back from it.
+type keyNamespace string
+
+// key returns where key is stored in the namespace.
+func (n keyNamespace) key(key string) string {
+	if n == "" {
+		return key
+	}
+
+	return string(n) + KeySeparator + key
+}
+
+// strip returns the keys as seen from inside the namespace.
+func (n keyNamespace) strip(keys []string) []string {
+	if n == "" {
+		return keys
+	}
+
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, string(n)+KeySeparator)
+	}
+
+	return keys
+}
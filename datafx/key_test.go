@@ -0,0 +1,68 @@
+package datafx_test
+
+import (
+	"testing"
+
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		parts    []any
+		expected string
+	}{
+		{name: "no parts", parts: nil, expected: ""},
+		{name: "single part", parts: []any{"users"}, expected: "users"},
+		{name: "joins parts", parts: []any{"users", "42", "profile"}, expected: "users:42:profile"},
+		{name: "formats non-strings", parts: []any{"orders", 7, true}, expected: "orders:7:true"},
+		{name: "escapes separator", parts: []any{"a", "b:c"}, expected: `a:b\:c`},
+		{name: "escapes escape char", parts: []any{`a\`, "b"}, expected: `a\\:b`},
+		{name: "keeps empty parts", parts: []any{"a", "", "b"}, expected: "a::b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, datafx.Key(tt.parts...))
+		})
+	}
+}
+
+func TestKey_AvoidsCollisions(t *testing.T) {
+	t.Parallel()
+
+	collidingPairs := [][2][]any{
+		{{"a", "b:c"}, {"a:b", "c"}},
+		{{`a\`, "b"}, {`a\:b`}},
+		{{`a\`, ":b"}, {`a\:`, "b"}},
+		{{"a", ""}, {"a:"}},
+	}
+
+	for _, pair := range collidingPairs {
+		assert.NotEqual(t, datafx.Key(pair[0]...), datafx.Key(pair[1]...),
+			"parts %q and %q", pair[0], pair[1])
+	}
+}
+
+func TestKeyBuilder(t *testing.T) {
+	t.Parallel()
+
+	users := datafx.NewKeyBuilder("app:users")
+
+	assert.Equal(t, "app:users", users.String())
+	assert.Equal(t, "app:users", users.Key())
+	assert.Equal(t, "app:users:42:profile", users.Key(42, "profile"))
+	assert.Equal(t, `app:users:a\:b`, users.Key("a:b"))
+
+	user := users.With(42)
+	assert.Equal(t, "app:users:42:sessions", user.Key("sessions"))
+	assert.Equal(t, "app:users", users.String(), "With must not modify the original builder")
+
+	assert.Equal(t, "x:y", datafx.NewKeyBuilder("").Key("x", "y"))
+	assert.NotEqual(t, users.Key("a", "b:c"), users.Key("a:b", "c"))
+}
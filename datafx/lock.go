@@ -0,0 +1,285 @@
+package datafx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/lib"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// LockRetryInterval is how long Acquire waits between attempts on a held lock.
+	LockRetryInterval = 100 * time.Millisecond
+	// MinLockTTL is the shortest lease NewLock accepts. Leases are renewed every third of
+	// the TTL, so this leaves each renewal about 333ms for its round trip, and time for
+	// a retry, before the lease runs out.
+	MinLockTTL = time.Second
+)
+
+var (
+	ErrLockNotSupported = errors.New("connection does not support locks")
+	ErrInvalidLockTTL   = errors.New("lock TTL is below the minimum")
+	ErrLockOperation    = errors.New("lock operation failed")
+	ErrLockNotHeld      = errors.New("lock is not held")
+	ErrLockAlreadyHeld  = errors.New("lock is already held by this instance")
+)
+
+// Lock is a distributed lock on a cache or store key. It is leased: the key expires after
+// the TTL, so a crashed holder cannot block others forever, while a live holder keeps
+// renewing it in the background. Each acquisition stores a unique token, and renewal and
+// release only touch the key while it still holds that token, so a holder whose lease ran
+// out never extends or releases a lock someone else acquired since.
+//
+// A Lock is safe for concurrent use, but it represents a single holder: to contend for
+// the same key, use one Lock per contender.
+type Lock struct {
+	repository connfx.LockRepository
+	tracer     operationTracer
+	namespace  keyNamespace
+	key        string
+	ttl        time.Duration
+
+	token       string
+	stopRenewal context.CancelFunc
+	renewalDone <-chan error
+	mu          sync.Mutex
+}
+
+// NewLock creates a lock on key, leased for ttl at a time, which must be at least
+// MinLockTTL. The cache's connection must implement connfx.LockRepository, as Redis
+// connections do.
+func NewLock(cache *Cache, key string, ttl time.Duration) (*Lock, error) {
+	return newLock(cache.repository, cache.conn, cache.tracer, cache.namespace, key, ttl)
+}
+
+// NewStoreLock creates a lock on key like NewLock, for stores whose connection implements
+// connfx.LockRepository without being a cache, such as SQL connections, which keep each
+// lock in a row.
+func NewStoreLock(store *Store, key string, ttl time.Duration) (*Lock, error) {
+	return newLock(store.repository, store.conn, store.tracer, store.namespace, key, ttl)
+}
+
+func newLock(
+	repository connfx.Repository,
+	conn connfx.Connection,
+	tracer operationTracer,
+	namespace keyNamespace,
+	key string,
+	ttl time.Duration,
+) (*Lock, error) {
+	if ttl < MinLockTTL {
+		return nil, fmt.Errorf(
+			"%w (key=%q, ttl=%s, min=%s)",
+			ErrInvalidLockTTL,
+			key,
+			ttl,
+			MinLockTTL,
+		)
+	}
+
+	repo, ok := repository.(connfx.LockRepository)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: connection does not implement LockRepository interface (protocol=%q)",
+			ErrLockNotSupported,
+			conn.GetProtocol(),
+		)
+	}
+
+	return &Lock{
+		repository: repo,
+		tracer:     tracer,
+		namespace:  namespace,
+		key:        key,
+		ttl:        ttl,
+
+		token:       "",
+		stopRenewal: nil,
+		renewalDone: nil,
+		mu:          sync.Mutex{},
+	}, nil
+}
+
+// TryAcquire attempts to acquire the lock once, reporting whether it did. It returns
+// ErrLockAlreadyHeld if this Lock holds it already.
+func (l *Lock) TryAcquire(ctx context.Context) (bool, error) {
+	var acquired bool
+
+	err := l.tracer.run(
+		ctx,
+		"lock.acquire",
+		trace.SpanKindClient,
+		AttributeKey.String(l.key),
+		func(ctx context.Context) error {
+			var err error
+
+			acquired, err = l.tryAcquire(ctx)
+
+			return err
+		},
+	)
+
+	return acquired, err
+}
+
+// Acquire blocks until the lock is acquired, retrying every LockRetryInterval, or until
+// ctx is done.
+func (l *Lock) Acquire(ctx context.Context) error {
+	for {
+		acquired, err := l.TryAcquire(ctx)
+		if err != nil {
+			return err
+		}
+
+		if acquired {
+			return nil
+		}
+
+		timer := time.NewTimer(LockRetryInterval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return fmt.Errorf(
+				"%w (operation=acquire, key=%q): %w",
+				ErrLockOperation,
+				l.key,
+				ctx.Err(),
+			)
+		case <-timer.C:
+		}
+	}
+}
+
+// Release stops renewing the lock and removes it. It returns ErrLockNotHeld if this Lock
+// does not hold it, including when its lease expired and the key was taken over.
+func (l *Lock) Release(ctx context.Context) error {
+	return l.tracer.run(
+		ctx,
+		"lock.release",
+		trace.SpanKindClient,
+		AttributeKey.String(l.key),
+		l.release,
+	)
+}
+
+// Held reports whether this Lock holds the lock as far as it knows: it turns false on
+// Release, and when a renewal finds the lease expired.
+func (l *Lock) Held() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.token != ""
+}
+
+func (l *Lock) tryAcquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.token != "" {
+		return false, fmt.Errorf("%w (key=%q)", ErrLockAlreadyHeld, l.key)
+	}
+
+	token := lib.IDsGenerateUnique()
+
+	acquired, err := l.repository.AcquireLock(ctx, l.namespace.key(l.key), token, l.ttl)
+	if err != nil {
+		return false, fmt.Errorf(
+			"%w (operation=acquire, key=%q): %w",
+			ErrLockOperation,
+			l.key,
+			err,
+		)
+	}
+
+	if !acquired {
+		return false, nil
+	}
+
+	// Clean up after a lease that was lost rather than released
+	if l.stopRenewal != nil {
+		l.stopRenewal()
+	}
+
+	// The renewal outlives the acquiring request, so it does not inherit its cancellation
+	renewalCtx, stopRenewal := context.WithCancel(context.WithoutCancel(ctx))
+
+	l.token = token
+	l.stopRenewal = stopRenewal
+	l.renewalDone = lib.SafeGo(func() error {
+		l.renew(renewalCtx, token)
+
+		return nil
+	}, nil)
+
+	return true, nil
+}
+
+func (l *Lock) release(ctx context.Context) error {
+	l.mu.Lock()
+	token, stopRenewal, renewalDone := l.token, l.stopRenewal, l.renewalDone
+	l.token, l.stopRenewal, l.renewalDone = "", nil, nil
+	l.mu.Unlock()
+
+	if stopRenewal != nil {
+		stopRenewal()
+		<-renewalDone
+	}
+
+	if token == "" {
+		return fmt.Errorf("%w (key=%q)", ErrLockNotHeld, l.key)
+	}
+
+	released, err := l.repository.ReleaseLock(ctx, l.namespace.key(l.key), token)
+	if err != nil {
+		return fmt.Errorf(
+			"%w (operation=release, key=%q): %w",
+			ErrLockOperation,
+			l.key,
+			err,
+		)
+	}
+
+	if !released {
+		return fmt.Errorf("%w (key=%q): lease expired", ErrLockNotHeld, l.key)
+	}
+
+	return nil
+}
+
+// renew extends the lease every third of the TTL, leaving room for two failed attempts
+// before it runs out. It stops once the key no longer holds token.
+func (l *Lock) renew(ctx context.Context, token string) {
+	ticker := time.NewTicker(l.ttl / 3) //nolint:mnd
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewed, err := l.repository.RenewLock(ctx, l.namespace.key(l.key), token, l.ttl)
+		if err != nil {
+			// Transient failure; the next tick tries again while the lease lasts
+			continue
+		}
+
+		if !renewed {
+			l.mu.Lock()
+			if l.token == token {
+				l.token = ""
+			}
+			l.mu.Unlock()
+
+			return
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package datafx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedisCache(t *testing.T) (*datafx.Cache, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+
+	conn, err := connfx.NewRedisConnectionFactory("redis").CreateConnection(
+		t.Context(),
+		&connfx.ConfigTarget{DSN: "redis://" + server.Addr()}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close(context.Background())
+	})
+
+	cache, err := datafx.NewCache(conn)
+	require.NoError(t, err)
+
+	return cache, server
+}
+
+func newTestLock(t *testing.T, cache *datafx.Cache, ttl time.Duration) *datafx.Lock {
+	t.Helper()
+
+	lock, err := datafx.NewLock(cache, "locks:report", ttl)
+	require.NoError(t, err)
+
+	return lock
+}
+
+// newSQLiteLockStore opens a store over an in-memory SQLite database with the lock table
+// created.
+func newSQLiteLockStore(t *testing.T) *datafx.Store {
+	t.Helper()
+
+	conn := newSQLiteConnection(t)
+
+	query, err := datafx.NewQuery(conn)
+	require.NoError(t, err)
+
+	_, err = query.Execute(
+		t.Context(),
+		"CREATE TABLE locks"+
+			" (lock_key TEXT PRIMARY KEY, lock_token TEXT NOT NULL, expires_at BIGINT NOT NULL)",
+	)
+	require.NoError(t, err)
+
+	store, err := datafx.NewStore(conn, datafx.WithKeyPrefix("app"))
+	require.NoError(t, err)
+
+	return store
+}
+
+func TestLock_Contention(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := newRedisCache(t)
+	first := newTestLock(t, cache, time.Minute)
+	second := newTestLock(t, cache, time.Minute)
+
+	acquired, err := first.TryAcquire(t.Context())
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	assert.True(t, first.Held())
+
+	acquired, err = second.TryAcquire(t.Context())
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	assert.False(t, second.Held())
+
+	_, err = first.TryAcquire(t.Context())
+	require.ErrorIs(t, err, datafx.ErrLockAlreadyHeld)
+
+	// Acquire waits for the holder to let go
+	acquiredCh := make(chan error, 1)
+
+	go func() {
+		acquiredCh <- second.Acquire(t.Context())
+	}()
+
+	select {
+	case err := <-acquiredCh:
+		require.FailNow(t, "acquired a held lock", err)
+	case <-time.After(250 * time.Millisecond):
+	}
+
+	require.NoError(t, first.Release(t.Context()))
+	assert.False(t, first.Held())
+
+	select {
+	case err := <-acquiredCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for the lock")
+	}
+
+	assert.True(t, second.Held())
+	require.NoError(t, second.Release(t.Context()))
+}
+
+func TestLock_AcquireHonorsContext(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := newRedisCache(t)
+	holder := newTestLock(t, cache, time.Minute)
+	waiter := newTestLock(t, cache, time.Minute)
+
+	require.NoError(t, holder.Acquire(t.Context()))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+	defer cancel()
+
+	err := waiter.Acquire(ctx)
+	require.ErrorIs(t, err, datafx.ErrLockOperation)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, waiter.Held())
+}
+
+func TestLock_RenewsWhileHeld(t *testing.T) {
+	t.Parallel()
+
+	cache, server := newRedisCache(t)
+	lock := newTestLock(t, cache, datafx.MinLockTTL)
+
+	require.NoError(t, lock.Acquire(t.Context()))
+
+	server.FastForward(700 * time.Millisecond)
+	require.LessOrEqual(t, server.TTL("locks:report"), 300*time.Millisecond)
+
+	// The renewal resets the lease to the full TTL
+	require.Eventually(t, func() bool {
+		return server.TTL("locks:report") > 700*time.Millisecond
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, lock.Release(t.Context()))
+	assert.False(t, server.Exists("locks:report"))
+}
+
+func TestLock_ExpiryAndSafeRelease(t *testing.T) {
+	t.Parallel()
+
+	cache, server := newRedisCache(t)
+	stale := newTestLock(t, cache, datafx.MinLockTTL)
+	current := newTestLock(t, cache, datafx.MinLockTTL)
+
+	require.NoError(t, stale.Acquire(t.Context()))
+
+	// The lease runs out before the holder renews it, e.g. while it was paused
+	server.FastForward(2 * datafx.MinLockTTL)
+
+	acquired, err := current.TryAcquire(t.Context())
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	token, err := server.Get("locks:report")
+	require.NoError(t, err)
+
+	// The stale holder notices on its next renewal, without extending the new lease
+	require.Eventually(t, func() bool {
+		return !stale.Held()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	err = stale.Release(t.Context())
+	require.ErrorIs(t, err, datafx.ErrLockNotHeld)
+
+	stillHeld, err := server.Get("locks:report")
+	require.NoError(t, err)
+	assert.Equal(t, token, stillHeld)
+	assert.True(t, current.Held())
+
+	require.NoError(t, current.Release(t.Context()))
+	assert.False(t, server.Exists("locks:report"))
+
+	err = current.Release(t.Context())
+	require.ErrorIs(t, err, datafx.ErrLockNotHeld)
+}
+
+func TestNewLock_Validation(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := newRedisCache(t)
+
+	_, err := datafx.NewLock(cache, "locks:report", 0)
+	require.ErrorIs(t, err, datafx.ErrInvalidLockTTL)
+
+	// A lease shorter than the minimum leaves renewals too little time to complete
+	_, err = datafx.NewLock(cache, "locks:report", time.Millisecond)
+	require.ErrorIs(t, err, datafx.ErrInvalidLockTTL)
+
+	_, err = datafx.NewLock(cache, "locks:report", datafx.MinLockTTL-time.Nanosecond)
+	require.ErrorIs(t, err, datafx.ErrInvalidLockTTL)
+
+	_, err = datafx.NewLock(cache, "locks:report", datafx.MinLockTTL)
+	require.NoError(t, err)
+
+	_, err = datafx.NewLock(newTestCache(t), "locks:report", time.Minute)
+	require.ErrorIs(t, err, datafx.ErrLockNotSupported)
+}
+
+func TestStoreLock_SQL(t *testing.T) {
+	t.Parallel()
+
+	store := newSQLiteLockStore(t)
+
+	first, err := datafx.NewStoreLock(store, "locks:report", datafx.MinLockTTL)
+	require.NoError(t, err)
+
+	second, err := datafx.NewStoreLock(store, "locks:report", datafx.MinLockTTL)
+	require.NoError(t, err)
+
+	acquired, err := first.TryAcquire(t.Context())
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// The renewal keeps the row held well past the first lease
+	time.Sleep(2 * datafx.MinLockTTL)
+
+	assert.True(t, first.Held())
+
+	acquired, err = second.TryAcquire(t.Context())
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, first.Release(t.Context()))
+
+	err = first.Release(t.Context())
+	require.ErrorIs(t, err, datafx.ErrLockNotHeld)
+
+	acquired, err = second.TryAcquire(t.Context())
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	require.NoError(t, second.Release(t.Context()))
+}
+
+func TestNewStoreLock_Validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := datafx.NewStoreLock(newSQLiteLockStore(t), "locks:report", 0)
+	require.ErrorIs(t, err, datafx.ErrInvalidLockTTL)
+
+	store, err := datafx.NewStore(newMemoryConnection())
+	require.NoError(t, err)
+
+	_, err = datafx.NewStoreLock(store, "locks:report", time.Minute)
+	require.ErrorIs(t, err, datafx.ErrLockNotSupported)
+}
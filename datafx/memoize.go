@@ -0,0 +1,51 @@
+package datafx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var ErrMemoizedLoader = errors.New("memoized loader failed")
+
+// Memoize wraps loader with a cache-aside layer backed by cache.
+//
+// Results are encoded with the cache's codec and stored under keyFn(arg) for ttl.
+// Concurrent calls for the same key share a single loader invocation, so a cold key does
+// not cause a stampede. The shared load runs detached from the caller that started it,
+// bounded by CoalescedLoadTimeout, so one caller canceling does not fail the others; a
+// canceled caller stops waiting and returns its context error. Cache read or write
+// failures degrade to calling the loader; loader errors are never cached.
+func Memoize[K comparable, V any](
+	cache *Cache,
+	ttl time.Duration,
+	keyFn func(K) string,
+	loader func(ctx context.Context, arg K) (V, error),
+) func(ctx context.Context, arg K) (V, error) {
+	var group singleflight.Group
+
+	return func(ctx context.Context, arg K) (V, error) {
+		key := keyFn(arg)
+
+		var value V
+
+		err := cache.getOrLoad(ctx, &group, key, ttl, &value, func(ctx context.Context) (any, error) {
+			loaded, err := loader(ctx, arg)
+			if err != nil {
+				return nil, fmt.Errorf("%w (key=%q): %w", ErrMemoizedLoader, key, err)
+			}
+
+			return loaded, nil
+		})
+		if err != nil {
+			var zero V
+
+			return zero, err
+		}
+
+		return value, nil
+	}
+}
@@ -0,0 +1,206 @@
+package datafx_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errLoaderFailed = errors.New("loader failed")
+
+type memoizedUser struct {
+	Name string `json:"name"`
+	ID   int    `json:"id"`
+}
+
+func newTestCache(t *testing.T) *datafx.Cache {
+	t.Helper()
+
+	cache, err := datafx.NewCache(newMemoryConnection())
+	require.NoError(t, err)
+
+	return cache
+}
+
+func TestMemoize_LoadsOncePerKey(t *testing.T) {
+	t.Parallel()
+
+	cache := newTestCache(t)
+
+	var loads atomic.Int32
+
+	release := make(chan struct{})
+
+	getUser := datafx.Memoize(
+		cache,
+		time.Minute,
+		func(id int) string { return "user:" + strconv.Itoa(id) },
+		func(ctx context.Context, id int) (memoizedUser, error) {
+			loads.Add(1)
+			<-release
+
+			return memoizedUser{ID: id, Name: "user-" + strconv.Itoa(id)}, nil
+		},
+	)
+
+	const callers = 10
+
+	var wg sync.WaitGroup
+
+	results := make([]memoizedUser, callers)
+
+	for i := range callers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			user, err := getUser(t.Context(), 42)
+			assert.NoError(t, err)
+
+			results[i] = user
+		}()
+	}
+
+	// Give callers a moment to pile up behind the single flight
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+
+	for _, user := range results {
+		assert.Equal(t, memoizedUser{ID: 42, Name: "user-42"}, user)
+	}
+
+	// Subsequent calls are served from the cache
+	user, err := getUser(t.Context(), 42)
+	require.NoError(t, err)
+	assert.Equal(t, 42, user.ID)
+	assert.Equal(t, int32(1), loads.Load())
+
+	// Other keys load independently
+	_, err = getUser(t.Context(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), loads.Load())
+}
+
+func TestMemoize_ExpiresAfterTTL(t *testing.T) {
+	t.Parallel()
+
+	cache := newTestCache(t)
+
+	var loads atomic.Int32
+
+	getValue := datafx.Memoize(
+		cache,
+		30*time.Millisecond,
+		func(key string) string { return "value:" + key },
+		func(ctx context.Context, key string) (int32, error) {
+			return loads.Add(1), nil
+		},
+	)
+
+	first, err := getValue(t.Context(), "a")
+	require.NoError(t, err)
+
+	cached, err := getValue(t.Context(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, first, cached)
+
+	time.Sleep(50 * time.Millisecond)
+
+	reloaded, err := getValue(t.Context(), "a")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, reloaded)
+	assert.Equal(t, int32(2), loads.Load())
+}
+
+func TestMemoize_DoesNotCacheErrors(t *testing.T) {
+	t.Parallel()
+
+	cache := newTestCache(t)
+
+	var loads atomic.Int32
+
+	getValue := datafx.Memoize(
+		cache,
+		time.Minute,
+		func(key string) string { return "value:" + key },
+		func(ctx context.Context, key string) (string, error) {
+			if loads.Add(1) == 1 {
+				return "", errLoaderFailed
+			}
+
+			return "ok", nil
+		},
+	)
+
+	_, err := getValue(t.Context(), "a")
+	require.ErrorIs(t, err, datafx.ErrMemoizedLoader)
+	require.ErrorIs(t, err, errLoaderFailed)
+
+	value, err := getValue(t.Context(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", value)
+}
+
+func TestMemoize_CanceledCallerDoesNotFailOthers(t *testing.T) {
+	t.Parallel()
+
+	cache := newTestCache(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	getValue := datafx.Memoize(
+		cache,
+		time.Minute,
+		func(key string) string { return "value:" + key },
+		func(ctx context.Context, key string) (string, error) {
+			close(started)
+
+			select {
+			case <-release:
+				return "ok", nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		},
+	)
+
+	firstCtx, cancelFirst := context.WithCancel(t.Context())
+	firstErr := make(chan error, 1)
+
+	go func() {
+		_, err := getValue(firstCtx, "a")
+		firstErr <- err
+	}()
+
+	<-started
+
+	secondResult := make(chan string, 1)
+
+	go func() {
+		value, err := getValue(t.Context(), "a")
+		assert.NoError(t, err)
+
+		secondResult <- value
+	}()
+
+	// The caller that started the load gives up; the load itself carries on
+	time.Sleep(20 * time.Millisecond)
+	cancelFirst()
+	require.ErrorIs(t, <-firstErr, context.Canceled)
+
+	close(release)
+	assert.Equal(t, "ok", <-secondResult)
+}
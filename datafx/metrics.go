@@ -0,0 +1,221 @@
+package datafx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/eser/ajan/metricsfx"
+)
+
+var (
+	ErrFailedToBuildQueueMessageSizeHistogram = errors.New(
+		"failed to build queue message size histogram",
+	)
+	ErrFailedToBuildQueueHandlerDurationHistogram = errors.New(
+		"failed to build queue handler duration histogram",
+	)
+	ErrFailedToBuildQueueMessagesInFlightGauge = errors.New(
+		"failed to build queue messages in flight gauge",
+	)
+	ErrFailedToBuildQueueMessagesProcessedCounter = errors.New(
+		"failed to build queue messages processed counter",
+	)
+	ErrFailedToBuildQueueConsumerLagGauge = errors.New(
+		"failed to build queue consumer lag gauge",
+	)
+	ErrFailedToBuildQueueDeserializeErrorsCounter = errors.New(
+		"failed to build queue deserialize errors counter",
+	)
+	ErrFailedToBuildCacheFallbackActivationsCounter = errors.New(
+		"failed to build cache fallback activations counter",
+	)
+	ErrFailedToBuildOperationDurationHistogram = errors.New(
+		"failed to build operation duration histogram",
+	)
+	ErrFailedToBuildOperationErrorsCounter = errors.New(
+		"failed to build operation errors counter",
+	)
+)
+
+// QueueMetrics holds queue-specific metrics using the simplified MetricsBuilder approach.
+type QueueMetrics struct {
+	Provider *metricsfx.MetricsProvider
+
+	MessageSize       *metricsfx.HistogramMetric
+	HandlerDuration   *metricsfx.HistogramMetric
+	MessagesInFlight  *metricsfx.GaugeMetric
+	MessagesProcessed *metricsfx.CounterMetric
+	ConsumerLag       *metricsfx.GaugeMetric
+	DeserializeErrors *metricsfx.CounterMetric
+}
+
+// NewQueueMetrics creates queue metrics using the simplified MetricsBuilder.
+func NewQueueMetrics(provider *metricsfx.MetricsProvider) *QueueMetrics {
+	return &QueueMetrics{
+		Provider: provider,
+
+		MessageSize:       nil,
+		HandlerDuration:   nil,
+		MessagesInFlight:  nil,
+		MessagesProcessed: nil,
+		ConsumerLag:       nil,
+		DeserializeErrors: nil,
+	}
+}
+
+func (metrics *QueueMetrics) Init() error {
+	builder := metrics.Provider.NewBuilder()
+
+	messageSize, err := builder.Histogram(
+		"queue_message_size_bytes",
+		"Size of published queue messages in bytes",
+	).WithUnit("By").WithBuckets(
+		256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216,
+	).Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildQueueMessageSizeHistogram, err)
+	}
+
+	metrics.MessageSize = messageSize
+
+	handlerDuration, err := builder.Histogram(
+		"queue_handler_duration_seconds",
+		"Time spent in queue message handlers in seconds",
+	).WithUnit("s").WithDurationBuckets().Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildQueueHandlerDurationHistogram, err)
+	}
+
+	metrics.HandlerDuration = handlerDuration
+
+	messagesInFlight, err := builder.Gauge(
+		"queue_messages_in_flight",
+		"Number of queue messages currently being handled",
+	).WithUnit("{message}").Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildQueueMessagesInFlightGauge, err)
+	}
+
+	metrics.MessagesInFlight = messagesInFlight
+
+	messagesProcessed, err := builder.Counter(
+		"queue_messages_processed_total",
+		"Total number of queue messages handled",
+	).WithUnit("{message}").Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildQueueMessagesProcessedCounter, err)
+	}
+
+	metrics.MessagesProcessed = messagesProcessed
+
+	consumerLag, err := builder.Gauge(
+		"queue_consumer_lag",
+		"Number of messages a consumer group has yet to read",
+	).WithUnit("{message}").Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildQueueConsumerLagGauge, err)
+	}
+
+	metrics.ConsumerLag = consumerLag
+
+	deserializeErrors, err := builder.Counter(
+		"queue_deserialize_errors_total",
+		"Total number of consumed queue messages that failed to decode",
+	).WithUnit("{message}").Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildQueueDeserializeErrorsCounter, err)
+	}
+
+	metrics.DeserializeErrors = deserializeErrors
+
+	return nil
+}
+
+// CacheMetrics holds cache-specific metrics.
+type CacheMetrics struct {
+	Provider *metricsfx.MetricsProvider
+
+	FallbackActivations *metricsfx.CounterMetric
+}
+
+// NewCacheMetrics creates cache metrics using the simplified MetricsBuilder.
+func NewCacheMetrics(provider *metricsfx.MetricsProvider) *CacheMetrics {
+	return &CacheMetrics{
+		Provider: provider,
+
+		FallbackActivations: nil,
+	}
+}
+
+func (metrics *CacheMetrics) Init() error {
+	builder := metrics.Provider.NewBuilder()
+
+	fallbackActivations, err := builder.Counter(
+		"cache_fallback_activations_total",
+		"Total number of cache reads served by a fallback, by reason (miss or error)",
+	).WithUnit("{read}").Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildCacheFallbackActivationsCounter, err)
+	}
+
+	metrics.FallbackActivations = fallbackActivations
+
+	return nil
+}
+
+// operationMetrics records the latency and failures of operations, set with WithMetrics.
+type operationMetrics struct {
+	duration *metricsfx.HistogramMetric
+	errors   *metricsfx.CounterMetric
+}
+
+func newOperationMetrics(builder *metricsfx.MetricsBuilder) (*operationMetrics, error) {
+	duration, err := builder.Histogram(
+		"datafx_operation_duration_seconds",
+		"Time spent in datafx operations in seconds",
+	).WithUnit("s").WithDurationBuckets().Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBuildOperationDurationHistogram, err)
+	}
+
+	errorsCounter, err := builder.Counter(
+		"datafx_operation_errors_total",
+		"Total number of failed datafx operations",
+	).WithUnit("{operation}").Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBuildOperationErrorsCounter, err)
+	}
+
+	return &operationMetrics{
+		duration: duration,
+		errors:   errorsCounter,
+	}, nil
+}
+
+// measureOperation runs fn, recording how long it took and whether it failed.
+func measureOperation[T any](
+	ctx context.Context,
+	metrics *operationMetrics,
+	name string,
+	protocol string,
+	fn func(ctx context.Context) (T, error),
+) (T, error) {
+	start := time.Now()
+
+	value, err := fn(ctx)
+
+	attrs := []metricsfx.Attribute{
+		metricsfx.StringAttr("operation", name),
+		metricsfx.StringAttr("protocol", protocol),
+	}
+
+	metrics.duration.RecordDuration(ctx, time.Since(start), attrs...)
+
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		metrics.errors.Inc(ctx, attrs...)
+	}
+
+	return value, err
+}
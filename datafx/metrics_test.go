@@ -0,0 +1,248 @@
+package datafx_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/eser/ajan/logfx"
+	"github.com/eser/ajan/metricsfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// exportedValue summarizes an exported metric: the number of recorded observations and
+// their total for histograms, or the current value for counters and gauges.
+type exportedValue struct {
+	count uint64
+	value float64
+}
+
+// recordingExporter keeps a summary of every exported metric. The SDK reuses the
+// exported data after Export returns, so it is summarized right away.
+type recordingExporter struct {
+	values map[string]exportedValue
+	mu     sync.Mutex
+}
+
+func (e *recordingExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (e *recordingExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *recordingExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, scope := range metrics.ScopeMetrics {
+		for _, metric := range scope.Metrics {
+			var summary exportedValue
+
+			switch data := metric.Data.(type) {
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					summary.count += point.Count
+					summary.value += point.Sum
+				}
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					summary.value += float64(point.Value)
+				}
+			case metricdata.Gauge[int64]:
+				for _, point := range data.DataPoints {
+					summary.value += float64(point.Value)
+				}
+			default:
+				continue
+			}
+
+			e.values[metric.Name] = summary
+		}
+	}
+
+	return nil
+}
+
+func (e *recordingExporter) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) value(name string) (exportedValue, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	value, ok := e.values[name]
+
+	return value, ok
+}
+
+// stubOTLPConnection exposes an exporter the way connfx OTLP connections do.
+type stubOTLPConnection struct {
+	exporter sdkmetric.Exporter
+}
+
+func (c *stubOTLPConnection) GetMetricExporter() sdkmetric.Exporter {
+	return c.exporter
+}
+
+type stubRegistry struct {
+	connections map[string]any
+}
+
+func (r *stubRegistry) GetNamed(name string) any {
+	return r.connections[name]
+}
+
+// newRecordingQueueMetrics creates queue metrics whose values reach the returned exporter
+// once the provider is shut down.
+func newRecordingQueueMetrics(
+	t *testing.T,
+) (*datafx.QueueMetrics, *metricsfx.MetricsProvider, *recordingExporter) {
+	t.Helper()
+
+	exporter := &recordingExporter{values: make(map[string]exportedValue), mu: sync.Mutex{}}
+	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{
+		ServiceName:                   "test-service",
+		ServiceVersion:                "1.0.0",
+		ServiceInstanceID:             "",
+		OTLPConnectionName:            "otel",
+		ExportInterval:                time.Hour,
+		NoNativeCollectorRegistration: true,
+		RequireNativeCollectors:       false,
+	}, &stubRegistry{
+		connections: map[string]any{
+			"otel": &stubOTLPConnection{exporter: exporter},
+		},
+	})
+	require.NoError(t, provider.Init())
+
+	metrics := datafx.NewQueueMetrics(provider)
+	require.NoError(t, metrics.Init())
+
+	return metrics, provider, exporter
+}
+
+func TestQueue_ProcessMessages_SlowConsumer(t *testing.T) {
+	t.Parallel()
+
+	metrics, provider, exporter := newRecordingQueueMetrics(t)
+
+	var buf bytes.Buffer
+
+	logger := logfx.NewLogger(logfx.WithFromSlog(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	queue, err := datafx.NewQueue(
+		newMemoryConnection(),
+		datafx.WithLogger(logger),
+		datafx.WithQueueMetrics(metrics),
+		datafx.WithSlowHandlerThreshold(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	require.NoError(t, queue.Publish(ctx, "events", testEvent{Name: "slow"}))
+	require.NoError(t, queue.Publish(ctx, "events", testEvent{Name: "fast"}))
+
+	calls := 0
+
+	handler := func(handlerCtx context.Context, message any) bool {
+		calls++
+
+		if calls == 1 {
+			time.Sleep(40 * time.Millisecond)
+
+			return true
+		}
+
+		cancel()
+
+		return false
+	}
+
+	err = queue.ProcessMessages(ctx, "events", connfx.DefaultConsumerConfig(), handler, nil)
+	require.ErrorIs(t, err, datafx.ErrContextCanceled)
+
+	// Only the slow handler is reported
+	assert.Equal(t, 1, strings.Count(buf.String(), "queue message handler exceeded latency"))
+	assert.Contains(t, buf.String(), "level=WARN")
+	assert.Contains(t, buf.String(), "queue=events")
+	assert.Contains(t, buf.String(), "threshold=20ms")
+
+	// Shutting down flushes the periodic reader to the exporter
+	require.NoError(t, provider.Shutdown(t.Context()))
+
+	duration, ok := exporter.value("queue_handler_duration_seconds")
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), duration.count)
+	assert.GreaterOrEqual(t, duration.value, (40 * time.Millisecond).Seconds())
+
+	processed, ok := exporter.value("queue_messages_processed_total")
+	require.True(t, ok)
+	assert.InDelta(t, 2, processed.value, 0)
+
+	inFlight, ok := exporter.value("queue_messages_in_flight")
+	require.True(t, ok)
+	assert.Zero(t, inFlight.value)
+}
+
+func TestWithMetrics(t *testing.T) {
+	t.Parallel()
+
+	_, provider, exporter := newRecordingQueueMetrics(t)
+	builder := provider.NewBuilder()
+
+	conn := newMemoryConnection()
+
+	store, err := datafx.NewStore(conn, datafx.WithMetrics(builder))
+	require.NoError(t, err)
+
+	queue, err := datafx.NewQueue(conn, datafx.WithMetrics(builder))
+	require.NoError(t, err)
+
+	flaky := &flakyCacheConnection{memoryConnection: newMemoryConnection()} //nolint:exhaustruct
+	flaky.down.Store(true)
+
+	cache, err := datafx.NewCache(flaky, datafx.WithMetrics(builder))
+	require.NoError(t, err)
+
+	var account testAccount
+
+	require.NoError(t, store.Set(t.Context(), "account:1", testAccount{Owner: "alice", Balance: 0}))
+	require.NoError(t, store.Get(t.Context(), "account:1", &account))
+
+	// A missing key is an expected outcome, not a failure
+	err = store.Get(t.Context(), "account:2", &account)
+	require.ErrorIs(t, err, datafx.ErrKeyNotFound)
+
+	require.NoError(t, queue.Publish(t.Context(), "events", testEvent{Name: "created"}))
+
+	err = cache.Get(t.Context(), "account:1", &account)
+	require.ErrorIs(t, err, errCacheDown)
+
+	require.NoError(t, provider.Shutdown(t.Context()))
+
+	duration, ok := exporter.value("datafx_operation_duration_seconds")
+	require.True(t, ok)
+	assert.Equal(t, uint64(5), duration.count)
+
+	failures, ok := exporter.value("datafx_operation_errors_total")
+	require.True(t, ok)
+	assert.InDelta(t, 1, failures.value, 0)
+}
@@ -0,0 +1,223 @@
+package datafx
+
+import (
+	"context"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/logfx"
+	"github.com/eser/ajan/metricsfx"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Option configures a Store, Cache or Queue.
+type Option func(*options)
+
+// DeserializeErrorHandler decides what happens to a consumed message whose body can't be
+// decoded: it receives the raw body and the decoding error, and returns true to
+// acknowledge the message or false to drop it without requeueing.
+type DeserializeErrorHandler func(ctx context.Context, raw []byte, err error) bool
+
+type options struct {
+	codec        Codec
+	logger       *logfx.Logger
+	queueMetrics *QueueMetrics
+	cacheMetrics *CacheMetrics
+	metrics      *metricsfx.MetricsBuilder
+
+	onDeserializeError DeserializeErrorHandler
+	propagator         propagation.TextMapPropagator
+
+	readReplicas []connfx.Connection
+	keyPrefix    string
+
+	messageSizeWarning   int
+	maxMessageSize       int
+	slowHandlerThreshold time.Duration
+	lagCheckInterval     time.Duration
+	lagWarning           int64
+	watchdogThreshold    time.Duration
+	fallbackRepopulate   time.Duration
+	tracing              bool
+	singleflight         bool
+	lifecycleLogging     bool
+}
+
+// WithCodec sets the codec used by Store and Cache to encode values (JSONCodec by default).
+func WithCodec(codec Codec) Option {
+	return func(opts *options) {
+		opts.codec = codec
+	}
+}
+
+// WithTracing wraps every operation in a span created with the global OpenTelemetry
+// tracer, e.g. "store.get", "cache.set" or "queue.publish".
+func WithTracing() Option {
+	return func(opts *options) {
+		opts.tracing = true
+	}
+}
+
+// WithPropagator sets how a traced Queue carries the trace context in message headers
+// (W3C Trace Context, i.e. traceparent and tracestate, by default).
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(opts *options) {
+		opts.propagator = propagator
+	}
+}
+
+// WithLogger sets the logger used for operational warnings such as oversized queue
+// messages (slog's default logger otherwise).
+func WithLogger(logger *logfx.Logger) Option {
+	return func(opts *options) {
+		opts.logger = logger
+	}
+}
+
+// WithQueueMetrics records the size of every message published by a Queue, and the
+// handler latency, in-flight messages and processed messages of its consumers.
+func WithQueueMetrics(metrics *QueueMetrics) Option {
+	return func(opts *options) {
+		opts.queueMetrics = metrics
+	}
+}
+
+// WithCacheMetrics records how often Cache.GetWithFallback serves a read from its fallback.
+func WithCacheMetrics(metrics *CacheMetrics) Option {
+	return func(opts *options) {
+		opts.cacheMetrics = metrics
+	}
+}
+
+// WithMetrics records the latency of every operation in datafx_operation_duration_seconds
+// and its failures in datafx_operation_errors_total, both with the operation (e.g.
+// "store.get") and the connection protocol as attributes. Missing keys are not counted as
+// failures.
+func WithMetrics(builder *metricsfx.MetricsBuilder) Option {
+	return func(opts *options) {
+		opts.metrics = builder
+	}
+}
+
+// WithFallbackRepopulate makes Cache.GetWithFallback write fallback values back to the
+// cache with the given expiration (0 leaves the cache untouched). Writes that fail while
+// the backend is still down are ignored; the next read after it recovers fills the key.
+func WithFallbackRepopulate(expiration time.Duration) Option {
+	return func(opts *options) {
+		opts.fallbackRepopulate = expiration
+	}
+}
+
+// WithSingleflight makes concurrent Cache.GetOrSet misses for the same key wait for a
+// single loader call and share its result, so a hot key expiring does not send every
+// caller to the underlying source at once.
+func WithSingleflight() Option {
+	return func(opts *options) {
+		opts.singleflight = true
+	}
+}
+
+// WithKeyPrefix places every key of a Store, TransactionalStore, Cache or Hash under a
+// namespace, so that services or tenants sharing a backend can't collide: with the
+// prefix "billing", the key "user:1" is stored as "billing:user:1". Callers keep using
+// unprefixed keys; Keys returns them without the prefix, and Keys and DeleteByPrefix only
+// see the namespace.
+func WithKeyPrefix(prefix string) Option {
+	return func(opts *options) {
+		opts.keyPrefix = prefix
+	}
+}
+
+// WithMessageSizeWarning makes a Queue log a warning for published messages larger than
+// the given number of bytes (0 disables the warning).
+func WithMessageSizeWarning(bytes int) Option {
+	return func(opts *options) {
+		opts.messageSizeWarning = bytes
+	}
+}
+
+// WithMaxMessageSize makes a Queue reject messages larger than the given number of bytes
+// with ErrMessageTooLarge instead of publishing them (0 disables the cap).
+func WithMaxMessageSize(bytes int) Option {
+	return func(opts *options) {
+		opts.maxMessageSize = bytes
+	}
+}
+
+// WithSlowHandlerThreshold makes a Queue log a warning whenever a message handler takes
+// longer than the given duration, a sign that the consumer can't keep up (0 disables it).
+func WithSlowHandlerThreshold(threshold time.Duration) Option {
+	return func(opts *options) {
+		opts.slowHandlerThreshold = threshold
+	}
+}
+
+// WithConsumerLagCheck makes ProcessMessagesWithGroup check the consumer group's lag every
+// interval on backends exposing it (connfx.QueueStreamRepository), recording it as a metric
+// and logging a warning when it exceeds warningThreshold messages (0 disables the warning).
+func WithConsumerLagCheck(interval time.Duration, warningThreshold int64) Option {
+	return func(opts *options) {
+		opts.lagCheckInterval = interval
+		opts.lagWarning = warningThreshold
+	}
+}
+
+// WithReadReplicas makes a Query balance reads across the given relational connections,
+// round-robin, while commands still go to the primary. Replicas may lag behind the
+// primary; see Query.QueryPrimary for reads that must observe the caller's own writes.
+func WithReadReplicas(replicas ...connfx.Connection) Option {
+	return func(opts *options) {
+		opts.readReplicas = append(opts.readReplicas, replicas...)
+	}
+}
+
+// WithDeserializeErrorHandler sets the hook a Queue calls for consumed messages that fail
+// to decode, e.g. to log them or route them to a dead letter queue. Without it, such
+// messages are dropped without requeueing.
+func WithDeserializeErrorHandler(handler DeserializeErrorHandler) Option {
+	return func(opts *options) {
+		opts.onDeserializeError = handler
+	}
+}
+
+// WithWatchdog logs a warning with the stacks of all goroutines whenever a Store, Cache
+// or Queue operation has not completed within threshold, to help locate hangs (0
+// disables it).
+func WithWatchdog(threshold time.Duration) Option {
+	return func(opts *options) {
+		opts.watchdogThreshold = threshold
+	}
+}
+
+func newOptions(opts []Option) options {
+	result := options{
+		codec:        JSONCodec{},
+		logger:       nil,
+		queueMetrics: nil,
+		cacheMetrics: nil,
+		metrics:      nil,
+		tracing:      false,
+
+		onDeserializeError: nil,
+		propagator:         propagation.TraceContext{},
+
+		readReplicas: nil,
+		keyPrefix:    "",
+
+		messageSizeWarning:   0,
+		maxMessageSize:       0,
+		slowHandlerThreshold: 0,
+		lagCheckInterval:     0,
+		lagWarning:           0,
+		watchdogThreshold:    0,
+		fallbackRepopulate:   0,
+		singleflight:         false,
+		lifecycleLogging:     false,
+	}
+
+	for _, opt := range opts {
+		opt(&result)
+	}
+
+	return result
+}
@@ -0,0 +1,215 @@
+package datafx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/eser/ajan/lib"
+)
+
+const DefaultOutboxRelayInterval = time.Second
+
+var ErrOutboxOperation = errors.New("outbox operation failed")
+
+// OutboxEvent is an event recorded in the outbox, waiting to be published.
+type OutboxEvent struct {
+	CreatedAt time.Time       `json:"created_at"`
+	Headers   map[string]any  `json:"headers,omitempty"`
+	ID        string          `json:"id"`
+	QueueName string          `json:"queue_name"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// Outbox implements the transactional outbox pattern on top of a TransactionalStore.
+//
+// Events are recorded within the same transaction as the business data, so they are
+// persisted if and only if the transaction commits. An OutboxRelay then publishes
+// them to a Queue.
+//
+// Each event is stored under its own key, so concurrent transactions recording events
+// never overwrite each other. Listing them requires a connection implementing
+// connfx.ScanRepository.
+type Outbox struct {
+	store *TransactionalStore
+	keys  KeyBuilder
+}
+
+// NewOutbox creates an Outbox storing its events under the given key prefix.
+func NewOutbox(store *TransactionalStore, prefix string) *Outbox {
+	return &Outbox{
+		store: store,
+		keys:  NewKeyBuilder(prefix),
+	}
+}
+
+// Record adds an event for queueName to the outbox within the given transaction.
+func (o *Outbox) Record(
+	ctx context.Context,
+	tx *TransactionStore,
+	queueName string,
+	payload any,
+) error {
+	return o.RecordWithHeaders(ctx, tx, queueName, payload, nil)
+}
+
+// RecordWithHeaders adds an event with headers for queueName to the outbox within
+// the given transaction.
+func (o *Outbox) RecordWithHeaders(
+	ctx context.Context,
+	tx *TransactionStore,
+	queueName string,
+	payload any,
+	headers map[string]any,
+) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w (queue=%q): %w", ErrFailedToMarshal, queueName, err)
+	}
+
+	event := OutboxEvent{
+		CreatedAt: time.Now(),
+		Headers:   headers,
+		ID:        lib.IDsGenerateUnique(),
+		QueueName: queueName,
+		Payload:   data,
+	}
+
+	if err := tx.Set(ctx, o.eventKey(event.ID), event); err != nil {
+		return fmt.Errorf("%w (operation=record, id=%q): %w", ErrOutboxOperation, event.ID, err)
+	}
+
+	return nil
+}
+
+// Pending returns the unpublished events in the order they were recorded.
+func (o *Outbox) Pending(ctx context.Context) ([]OutboxEvent, error) {
+	keys, err := o.store.Keys(ctx, o.eventKey(""))
+	if err != nil {
+		return nil, fmt.Errorf("%w (operation=pending): %w", ErrOutboxOperation, err)
+	}
+
+	events := make([]OutboxEvent, 0, len(keys))
+
+	for _, key := range keys {
+		var event OutboxEvent
+
+		err := o.store.Get(ctx, key, &event)
+		if errors.Is(err, ErrKeyNotFound) {
+			// Published by another relay in the meantime
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w (operation=pending, key=%q): %w", ErrOutboxOperation, key, err)
+		}
+
+		events = append(events, event)
+	}
+
+	// IDs are ULIDs, which sort in the order they were generated
+	slices.SortFunc(events, func(a, b OutboxEvent) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return events, nil
+}
+
+// MarkPublished removes a published event from the outbox.
+func (o *Outbox) MarkPublished(ctx context.Context, id string) error {
+	if err := o.store.Remove(ctx, o.eventKey(id)); err != nil {
+		return fmt.Errorf("%w (operation=mark_published, id=%q): %w", ErrOutboxOperation, id, err)
+	}
+
+	return nil
+}
+
+// eventKey returns the key of the event with the given ID, or, for an empty ID, the
+// prefix shared by every event key.
+func (o *Outbox) eventKey(id string) string {
+	return o.keys.Key("event", id)
+}
+
+// OutboxRelay publishes the events recorded in an Outbox to a Queue.
+//
+// An event is marked published only after the queue accepted it, so delivery is
+// at-least-once: a crash between publishing and marking results in the event
+// being published again, and consumers must be idempotent.
+type OutboxRelay struct {
+	outbox *Outbox
+	queue  *Queue
+
+	// OnError is called with errors from background relay passes (optional)
+	OnError func(err error)
+
+	interval time.Duration
+}
+
+// NewOutboxRelay creates a relay polling the outbox at the given interval.
+func NewOutboxRelay(outbox *Outbox, queue *Queue, interval time.Duration) *OutboxRelay {
+	if interval <= 0 {
+		interval = DefaultOutboxRelayInterval
+	}
+
+	return &OutboxRelay{
+		outbox:   outbox,
+		queue:    queue,
+		OnError:  nil,
+		interval: interval,
+	}
+}
+
+// RelayPending publishes all pending events once and returns how many were published.
+// It stops at the first failure, so events are published in the order they were recorded.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	events, err := r.outbox.Pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+
+	for _, event := range events {
+		err := r.queue.PublishRawWithHeaders(ctx, event.QueueName, event.Payload, event.Headers)
+		if err != nil {
+			return published, fmt.Errorf(
+				"%w (operation=relay, id=%q, queue=%q): %w",
+				ErrOutboxOperation,
+				event.ID,
+				event.QueueName,
+				err,
+			)
+		}
+
+		if err := r.outbox.MarkPublished(ctx, event.ID); err != nil {
+			return published, err
+		}
+
+		published++
+	}
+
+	return published, nil
+}
+
+// Run relays pending events at every interval until the context is canceled.
+// Failed passes are reported to OnError and retried on the next interval.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayPending(ctx); err != nil && r.OnError != nil {
+			r.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrContextCanceled, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package datafx_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBusinessRule = errors.New("business rule violated")
+
+type orderPlaced struct {
+	OrderID string `json:"order_id"`
+}
+
+func newTestOutbox(t *testing.T) (*memoryConnection, *datafx.TransactionalStore, *datafx.Queue) {
+	t.Helper()
+
+	conn := newMemoryConnection()
+
+	store, err := datafx.NewTransactionalStore(conn)
+	require.NoError(t, err)
+
+	queue, err := datafx.NewQueue(conn)
+	require.NoError(t, err)
+
+	return conn, store, queue
+}
+
+func placeOrder(t *testing.T, store *datafx.TransactionalStore, outbox *datafx.Outbox, id string) {
+	t.Helper()
+
+	err := store.ExecuteTransaction(t.Context(), func(tx *datafx.TransactionStore) error {
+		if err := tx.Set(t.Context(), "order:"+id, map[string]string{"status": "placed"}); err != nil {
+			return err
+		}
+
+		return outbox.Record(t.Context(), tx, "orders", orderPlaced{OrderID: id})
+	})
+	require.NoError(t, err)
+}
+
+func receiveOrder(t *testing.T, messages <-chan connfx.Message) orderPlaced {
+	t.Helper()
+
+	select {
+	case msg := <-messages:
+		var event orderPlaced
+
+		require.NoError(t, json.Unmarshal(msg.Body, &event))
+
+		return event
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for published event")
+	}
+
+	return orderPlaced{} //nolint:exhaustruct
+}
+
+func TestOutbox_RelayAfterCrash(t *testing.T) {
+	t.Parallel()
+
+	_, store, queue := newTestOutbox(t)
+
+	// The process crashes after the commit, before anything is published
+	placeOrder(t, store, datafx.NewOutbox(store, "outbox"), "1001")
+
+	// After a restart, a new relay finds the committed event and publishes it
+	outbox := datafx.NewOutbox(store, "outbox")
+
+	pending, err := outbox.Pending(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "orders", pending[0].QueueName)
+
+	relay := datafx.NewOutboxRelay(outbox, queue, time.Millisecond)
+
+	published, err := relay.RelayPending(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	messages, _ := queue.Consume(t.Context(), "orders", connfx.DefaultConsumerConfig())
+	assert.Equal(t, "1001", receiveOrder(t, messages).OrderID)
+
+	pending, err = outbox.Pending(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestOutbox_RolledBackTransaction(t *testing.T) {
+	t.Parallel()
+
+	_, store, _ := newTestOutbox(t)
+	outbox := datafx.NewOutbox(store, "outbox")
+
+	err := store.ExecuteTransaction(t.Context(), func(tx *datafx.TransactionStore) error {
+		if err := outbox.Record(t.Context(), tx, "orders", orderPlaced{OrderID: "1002"}); err != nil {
+			return err
+		}
+
+		return errBusinessRule
+	})
+	require.ErrorIs(t, err, errBusinessRule)
+
+	pending, err := outbox.Pending(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestOutbox_ConcurrentRecords(t *testing.T) {
+	t.Parallel()
+
+	_, store, _ := newTestOutbox(t)
+	outbox := datafx.NewOutbox(store, "outbox")
+
+	const recordCount = 20
+
+	var wg sync.WaitGroup
+
+	for i := range recordCount {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			placeOrder(t, store, outbox, strconv.Itoa(i))
+		}()
+	}
+
+	wg.Wait()
+
+	pending, err := outbox.Pending(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, recordCount)
+
+	ids := make([]string, 0, len(pending))
+	for _, event := range pending {
+		ids = append(ids, event.ID)
+	}
+
+	assert.IsIncreasing(t, ids)
+}
+
+func TestOutboxRelay_RelayPending_PublishFailure(t *testing.T) {
+	t.Parallel()
+
+	conn, store, queue := newTestOutbox(t)
+	outbox := datafx.NewOutbox(store, "outbox")
+	relay := datafx.NewOutboxRelay(outbox, queue, time.Millisecond)
+
+	placeOrder(t, store, outbox, "1003")
+	placeOrder(t, store, outbox, "1004")
+
+	conn.failNextPublishes(1)
+
+	published, err := relay.RelayPending(t.Context())
+	require.ErrorIs(t, err, datafx.ErrOutboxOperation)
+	assert.Zero(t, published)
+
+	pending, err := outbox.Pending(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+
+	published, err = relay.RelayPending(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+
+	// Events are published in the order they were recorded
+	messages, _ := queue.Consume(t.Context(), "orders", connfx.DefaultConsumerConfig())
+	assert.Equal(t, "1003", receiveOrder(t, messages).OrderID)
+	assert.Equal(t, "1004", receiveOrder(t, messages).OrderID)
+}
+
+func TestOutboxRelay_Run(t *testing.T) {
+	t.Parallel()
+
+	conn, store, queue := newTestOutbox(t)
+	outbox := datafx.NewOutbox(store, "outbox")
+
+	placeOrder(t, store, outbox, "1005")
+
+	conn.failNextPublishes(2)
+
+	var failures atomic.Int32
+
+	relay := datafx.NewOutboxRelay(outbox, queue, 10*time.Millisecond)
+	relay.OnError = func(err error) {
+		failures.Add(1)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- relay.Run(ctx)
+	}()
+
+	messages, _ := queue.Consume(ctx, "orders", connfx.DefaultConsumerConfig())
+	assert.Equal(t, "1005", receiveOrder(t, messages).OrderID)
+	assert.Equal(t, int32(2), failures.Load())
+
+	cancel()
+	require.ErrorIs(t, <-done, datafx.ErrContextCanceled)
+}
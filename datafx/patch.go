@@ -0,0 +1,130 @@
+package datafx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrInvalidPatchTarget = errors.New("patch target is not an object")
+
+// Patch applies a partial update to the object stored at key, following JSON Merge Patch
+// (RFC 7396) semantics: nested objects are merged recursively, a nil value deletes the
+// key, and any other value replaces the stored one. The key must already exist.
+//
+// The read and the write are separate repository calls, so two concurrent patches of the
+// same key race and the last write wins; a field changed by the other patch may be lost.
+// Use TransactionalStore.Patch when concurrent patches must not overwrite each other.
+func (s *Store) Patch(ctx context.Context, key string, patch map[string]any) error {
+	return s.tracer.run(
+		ctx,
+		"store.patch",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			data, err := s.repository.Get(ctx, s.namespace.key(key))
+			if err != nil {
+				return fmt.Errorf("%w (operation=patch, key=%q): %w", ErrRepositoryOperation, key, err)
+			}
+
+			patched, err := applyPatch(s.codec, key, data, patch)
+			if err != nil {
+				return err
+			}
+
+			if err := s.repository.Update(ctx, s.namespace.key(key), patched); err != nil {
+				return fmt.Errorf("%w (operation=patch, key=%q): %w", ErrRepositoryOperation, key, err)
+			}
+
+			return nil
+		},
+	)
+}
+
+// Patch applies a partial update inside a single transaction, so the read, merge and
+// write happen atomically with respect to other transactions on the same connection.
+// See Store.Patch for the merge semantics.
+func (ts *TransactionalStore) Patch(ctx context.Context, key string, patch map[string]any) error {
+	return ts.ExecuteTransaction(ctx, func(tx *TransactionStore) error {
+		return tx.Patch(ctx, key, patch)
+	})
+}
+
+// Patch applies a partial update within the transaction. See Store.Patch for the merge
+// semantics.
+func (ts *TransactionStore) Patch(ctx context.Context, key string, patch map[string]any) error {
+	data, err := ts.Repository.Get(ctx, ts.namespace.key(key))
+	if err != nil {
+		return fmt.Errorf("%w (operation=patch, key=%q): %w", ErrTransactionOperation, key, err)
+	}
+
+	patched, err := applyPatch(ts.getCodec(), key, data, patch)
+	if err != nil {
+		return err
+	}
+
+	if err := ts.Repository.Update(ctx, ts.namespace.key(key), patched); err != nil {
+		return fmt.Errorf("%w (operation=patch, key=%q): %w", ErrTransactionOperation, key, err)
+	}
+
+	return nil
+}
+
+// applyPatch decodes the stored object, merges the patch into it and encodes the result.
+func applyPatch(codec Codec, key string, data []byte, patch map[string]any) ([]byte, error) {
+	if data == nil {
+		return nil, fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
+	}
+
+	var target map[string]any
+
+	if err := codec.Unmarshal(data, &target); err != nil {
+		return nil, fmt.Errorf(
+			"%w (key=%q): %w: %w",
+			ErrFailedToUnmarshal,
+			key,
+			ErrInvalidPatchTarget,
+			err,
+		)
+	}
+
+	if target == nil {
+		return nil, fmt.Errorf("%w (key=%q)", ErrInvalidPatchTarget, key)
+	}
+
+	patched, err := codec.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return nil, fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
+	}
+
+	return patched, nil
+}
+
+// mergePatch merges patch into target in place and returns target.
+func mergePatch(target map[string]any, patch map[string]any) map[string]any {
+	for name, value := range patch {
+		if value == nil {
+			delete(target, name)
+
+			continue
+		}
+
+		patchObject, isObject := value.(map[string]any)
+		if !isObject {
+			target[name] = value
+
+			continue
+		}
+
+		targetObject, _ := target[name].(map[string]any)
+		if targetObject == nil {
+			targetObject = make(map[string]any, len(patchObject))
+		}
+
+		target[name] = mergePatch(targetObject, patchObject)
+	}
+
+	return target
+}
@@ -0,0 +1,141 @@
+package datafx_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Patch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		initial  map[string]any
+		patch    map[string]any
+		expected map[string]any
+		name     string
+	}{
+		{
+			name:     "replaces_top_level_fields",
+			initial:  map[string]any{"name": "alice", "age": 30.0},
+			patch:    map[string]any{"age": 31.0, "email": "alice@example.com"},
+			expected: map[string]any{"name": "alice", "age": 31.0, "email": "alice@example.com"},
+		},
+		{
+			name: "merges_nested_objects",
+			initial: map[string]any{
+				"profile": map[string]any{"city": "Istanbul", "theme": "dark"},
+			},
+			patch: map[string]any{
+				"profile": map[string]any{"theme": "light", "lang": "tr"},
+			},
+			expected: map[string]any{
+				"profile": map[string]any{"city": "Istanbul", "theme": "light", "lang": "tr"},
+			},
+		},
+		{
+			name: "deletes_keys_with_nil",
+			initial: map[string]any{
+				"name":    "alice",
+				"legacy":  true,
+				"profile": map[string]any{"city": "Istanbul", "theme": "dark"},
+			},
+			patch: map[string]any{
+				"legacy":  nil,
+				"profile": map[string]any{"theme": nil},
+				"missing": nil,
+			},
+			expected: map[string]any{
+				"name":    "alice",
+				"profile": map[string]any{"city": "Istanbul"},
+			},
+		},
+		{
+			name:    "replaces_non_object_with_object",
+			initial: map[string]any{"settings": "default"},
+			patch: map[string]any{
+				"settings": map[string]any{"mode": "custom", "unset": nil},
+			},
+			expected: map[string]any{"settings": map[string]any{"mode": "custom"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := datafx.NewStore(newMemoryConnection())
+			require.NoError(t, err)
+
+			require.NoError(t, store.Set(t.Context(), "user:1", tt.initial))
+			require.NoError(t, store.Patch(t.Context(), "user:1", tt.patch))
+
+			var result map[string]any
+
+			require.NoError(t, store.Get(t.Context(), "user:1", &result))
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestStore_Patch_Errors(t *testing.T) {
+	t.Parallel()
+
+	store, err := datafx.NewStore(newMemoryConnection())
+	require.NoError(t, err)
+
+	err = store.Patch(t.Context(), "missing", map[string]any{"name": "alice"})
+	require.ErrorIs(t, err, datafx.ErrKeyNotFound)
+
+	require.NoError(t, store.Set(t.Context(), "list", []int{1, 2, 3}))
+
+	err = store.Patch(t.Context(), "list", map[string]any{"name": "alice"})
+	require.ErrorIs(t, err, datafx.ErrInvalidPatchTarget)
+}
+
+func TestTransactionalStore_Patch_ConcurrentUpdates(t *testing.T) {
+	t.Parallel()
+
+	store, err := datafx.NewTransactionalStore(newMemoryConnection())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set(t.Context(), "counters", map[string]any{}))
+
+	const writers = 20
+
+	var wg sync.WaitGroup
+
+	for i := range writers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Each writer patches a different field; none may be lost
+			assert.NoError(t, store.Patch(
+				t.Context(),
+				"counters",
+				map[string]any{
+					"writers": map[string]any{"w" + strconv.Itoa(i): float64(i)},
+				},
+			))
+		}()
+	}
+
+	wg.Wait()
+
+	var result struct {
+		Writers map[string]float64 `json:"writers"`
+	}
+
+	require.NoError(t, store.Get(t.Context(), "counters", &result))
+	assert.Len(t, result.Writers, writers)
+
+	for i := range writers {
+		assert.InDelta(t, float64(i), result.Writers["w"+strconv.Itoa(i)], 0)
+	}
+}
@@ -0,0 +1,204 @@
+package datafx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/lib"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrPubSubNotSupported = errors.New("connection does not support pub/sub operations")
+	ErrPubSubOperation    = errors.New("pub/sub operation failed")
+	ErrPubSubReconnected  = errors.New("pub/sub subscription reconnected")
+)
+
+// pubSubErrorBuffer is how many subscription errors are kept for a slow reader.
+const pubSubErrorBuffer = 16
+
+// PubSub provides lightweight publish/subscribe messaging over streaming connections
+// such as Redis and NATS. Messages are delivered only to subscribers connected when they
+// are published; use Queue when they must not be lost.
+type PubSub struct {
+	conn       connfx.Connection
+	repository connfx.PubSubRepository
+	codec      Codec
+	tracer     operationTracer
+}
+
+// NewPubSub creates a new PubSub instance from a connfx connection.
+// The connection must have the streaming behavior and implement connfx.PubSubRepository.
+func NewPubSub(conn connfx.Connection, opts ...Option) (*PubSub, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("%w: connection is nil", ErrConnectionNotSupported)
+	}
+
+	// Check if the connection supports streaming
+	behaviors := conn.GetBehaviors()
+	supportsStreaming := slices.Contains(behaviors, connfx.ConnectionBehaviorStreaming)
+
+	if !supportsStreaming {
+		return nil, fmt.Errorf("%w: connection does not have streaming behavior (protocol=%q)",
+			ErrPubSubNotSupported, conn.GetProtocol())
+	}
+
+	// Get the pub/sub repository from the raw connection
+	repo, ok := conn.GetRawConnection().(connfx.PubSubRepository)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: connection does not implement PubSubRepository interface (protocol=%q)",
+			ErrPubSubNotSupported,
+			conn.GetProtocol(),
+		)
+	}
+
+	options := newOptions(opts)
+
+	tracer, err := newOperationTracer(conn, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PubSub{
+		conn:       conn,
+		repository: repo,
+		codec:      options.codec,
+		tracer:     tracer,
+	}, nil
+}
+
+// Publish encodes msg with the codec and publishes it to every current subscriber of
+// channel.
+func (p *PubSub) Publish(ctx context.Context, channel string, msg any) error {
+	return p.tracer.run(
+		ctx,
+		"pubsub.publish",
+		trace.SpanKindProducer,
+		AttributeChannel.String(channel),
+		func(ctx context.Context) error {
+			data, err := p.codec.Marshal(msg)
+			if err != nil {
+				return fmt.Errorf("%w (channel=%q): %w", ErrFailedToMarshal, channel, err)
+			}
+
+			if err := p.repository.PublishToChannel(ctx, channel, data); err != nil {
+				return fmt.Errorf(
+					"%w (operation=publish, channel=%q): %w",
+					ErrPubSubOperation,
+					channel,
+					err,
+				)
+			}
+
+			return nil
+		},
+	)
+}
+
+// Subscribe receives the encoded messages published to channel until ctx is canceled,
+// then closes both returned channels. Decode payloads with the codec the publisher used.
+//
+// The error channel carries an ErrPubSubOperation if subscribing fails or forwarding
+// messages panics (wrapping lib.ErrGoroutinePanicked), after which both channels are
+// closed, and an ErrPubSubReconnected whenever the subscription recovered from a dropped
+// connection, since messages published in the meantime were missed.
+// Errors are dropped if the error channel is not drained.
+func (p *PubSub) Subscribe(ctx context.Context, channel string) (<-chan []byte, <-chan error) {
+	messages := make(chan []byte)
+	errs := make(chan error, pubSubErrorBuffer)
+
+	subscription, err := p.repository.Subscribe(ctx, channel)
+	if err != nil {
+		errs <- fmt.Errorf(
+			"%w (operation=subscribe, channel=%q): %w",
+			ErrPubSubOperation,
+			channel,
+			err,
+		)
+
+		close(messages)
+		close(errs)
+
+		return messages, errs
+	}
+
+	forwarded := lib.SafeGo(func() error {
+		p.forward(ctx, channel, subscription, messages, errs)
+
+		return nil
+	}, nil)
+
+	// The channels are closed only once the forwarder is done, so a panic in it can
+	// still be reported
+	lib.SafeGo(func() error {
+		defer close(errs)
+		defer close(messages)
+		defer func() { _ = subscription.Close() }()
+
+		if err := <-forwarded; err != nil {
+			select {
+			case errs <- fmt.Errorf(
+				"%w (operation=subscribe, channel=%q): %w",
+				ErrPubSubOperation,
+				channel,
+				err,
+			):
+			default:
+			}
+		}
+
+		return nil
+	}, nil)
+
+	return messages, errs
+}
+
+func (p *PubSub) forward(
+	ctx context.Context,
+	channel string,
+	subscription *connfx.Subscription,
+	messages chan<- []byte,
+	errs chan<- error,
+) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-subscription.Reconnects():
+			if !ok {
+				return
+			}
+
+			err := fmt.Errorf("%w (channel=%q): %w", ErrPubSubReconnected, channel, event.Err)
+
+			select {
+			case errs <- err:
+			default:
+			}
+		case msg, ok := <-subscription.Messages():
+			if !ok {
+				return
+			}
+
+			select {
+			case messages <- msg.Payload:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// GetConnection returns the underlying connfx connection.
+func (p *PubSub) GetConnection() connfx.Connection {
+	return p.conn
+}
+
+// GetRepository returns the underlying pub/sub repository.
+func (p *PubSub) GetRepository() connfx.PubSubRepository {
+	return p.repository
+}
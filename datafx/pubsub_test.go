@@ -0,0 +1,142 @@
+package datafx_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pubSubEvent struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+// statelessConnection hides the streaming behavior of a memoryConnection.
+type statelessConnection struct {
+	*memoryConnection
+}
+
+func (c *statelessConnection) GetBehaviors() []connfx.ConnectionBehavior {
+	return []connfx.ConnectionBehavior{connfx.ConnectionBehaviorStateless}
+}
+
+func newRedisPubSub(t *testing.T) (*datafx.PubSub, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+
+	conn, err := connfx.NewRedisConnectionFactory("redis").CreateConnection(
+		t.Context(),
+		&connfx.ConfigTarget{DSN: "redis://" + server.Addr()}, //nolint:exhaustruct
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close(context.Background())
+	})
+
+	pubsub, err := datafx.NewPubSub(conn)
+	require.NoError(t, err)
+
+	return pubsub, server
+}
+
+func TestPubSub_PublishSubscribe(t *testing.T) {
+	t.Parallel()
+
+	pubsub, server := newRedisPubSub(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	messages, errs := pubsub.Subscribe(ctx, "orders")
+
+	// Both subscribers receive every message
+	otherMessages, _ := pubsub.Subscribe(ctx, "orders")
+
+	// Subscribing completes asynchronously on the server
+	require.Eventually(t, func() bool {
+		return server.PubSubNumSub("orders")["orders"] == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, pubsub.Publish(t.Context(), "orders", pubSubEvent{Type: "created", ID: 7}))
+	require.NoError(t, pubsub.Publish(t.Context(), "invoices", pubSubEvent{Type: "paid", ID: 8}))
+
+	for _, received := range []<-chan []byte{messages, otherMessages} {
+		select {
+		case payload := <-received:
+			var event pubSubEvent
+			require.NoError(t, json.Unmarshal(payload, &event))
+			assert.Equal(t, pubSubEvent{Type: "created", ID: 7}, event)
+		case err := <-errs:
+			require.FailNow(t, "unexpected subscription error", err)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for a message")
+		}
+	}
+
+	// Canceling the context closes both channels
+	cancel()
+
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-messages:
+			return !ok
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, ok := <-errs
+	assert.False(t, ok)
+}
+
+func TestPubSub_SubscribeFailure(t *testing.T) {
+	t.Parallel()
+
+	pubsub, _ := newRedisPubSub(t)
+
+	require.NoError(t, pubsub.GetConnection().Close(t.Context()))
+
+	messages, errs := pubsub.Subscribe(t.Context(), "orders")
+
+	err, ok := <-errs
+	require.True(t, ok)
+	require.ErrorIs(t, err, datafx.ErrPubSubOperation)
+
+	_, ok = <-messages
+	assert.False(t, ok)
+
+	err = pubsub.Publish(t.Context(), "orders", pubSubEvent{Type: "created", ID: 1})
+	require.ErrorIs(t, err, datafx.ErrPubSubOperation)
+}
+
+func TestNewPubSub_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		conn connfx.Connection
+		name string
+	}{
+		{name: "without streaming behavior", conn: &statelessConnection{newMemoryConnection()}},
+		{name: "without pub/sub repository", conn: newMemoryConnection()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := datafx.NewPubSub(tt.conn)
+			require.ErrorIs(t, err, datafx.ErrPubSubNotSupported)
+		})
+	}
+
+	_, err := datafx.NewPubSub(nil)
+	require.ErrorIs(t, err, datafx.ErrConnectionNotSupported)
+}
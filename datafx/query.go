@@ -0,0 +1,437 @@
+package datafx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+)
+
+var (
+	ErrQueryNotSupported      = errors.New("connection does not support query operations")
+	ErrQueryOperation         = errors.New("query operation failed")
+	ErrColumnNotMapped        = errors.New("column has no matching struct field")
+	ErrColumnTypeMismatch     = errors.New("column value does not match struct field type")
+	ErrInvalidScanDestination = errors.New("scan destination must be a non-nil struct pointer")
+	ErrNoReadReplicas         = errors.New("no read replicas configured")
+	ErrNamedQueryNotSupported = errors.New("connection does not support named parameters")
+)
+
+// Query provides high-level operations for SQL-like storages.
+type Query struct {
+	conn       connfx.Connection
+	repository connfx.QueryRepository
+	replicas   []connfx.QueryRepository
+	next       atomic.Uint64
+}
+
+// NewQuery creates a new Query instance from a connfx connection.
+// The connection must support relational operations, as must any read replicas given
+// with WithReadReplicas.
+func NewQuery(conn connfx.Connection, opts ...Option) (*Query, error) {
+	repo, err := queryRepository(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	options := newOptions(opts)
+	replicas := make([]connfx.QueryRepository, 0, len(options.readReplicas))
+
+	for i, replica := range options.readReplicas {
+		replicaRepo, err := queryRepository(replica)
+		if err != nil {
+			return nil, fmt.Errorf("%w (read_replica=%d)", err, i)
+		}
+
+		replicas = append(replicas, replicaRepo)
+	}
+
+	return &Query{
+		conn:       conn,
+		repository: repo,
+		replicas:   replicas,
+		next:       atomic.Uint64{},
+	}, nil
+}
+
+// queryRepository returns the QueryRepository of a relational connection.
+func queryRepository(conn connfx.Connection) (connfx.QueryRepository, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("%w: connection is nil", ErrConnectionNotSupported)
+	}
+
+	// Check if the connection supports relational operations
+	capabilities := conn.GetCapabilities()
+	supportsQuery := slices.Contains(capabilities, connfx.ConnectionCapabilityRelational)
+
+	if !supportsQuery {
+		return nil, fmt.Errorf("%w: connection does not support relational operations (protocol=%q)",
+			ErrQueryNotSupported, conn.GetProtocol())
+	}
+
+	// Get the query repository from the raw connection, or from the connection itself
+	// for adapters exposing their native driver handle as the raw connection
+	repo, ok := conn.GetRawConnection().(connfx.QueryRepository)
+	if !ok {
+		repo, ok = conn.(connfx.QueryRepository)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: connection does not implement QueryRepository interface (protocol=%q)",
+			ErrQueryNotSupported,
+			conn.GetProtocol(),
+		)
+	}
+
+	return repo, nil
+}
+
+// QueryAll executes a query and calls scan for each resulting row.
+// Iteration stops at the first error returned by scan. With read replicas the query goes
+// to the next replica in turn, and may not observe recent writes; otherwise it goes to
+// the primary.
+func (q *Query) QueryAll(
+	ctx context.Context,
+	scan func(row connfx.QueryResult) error,
+	query string,
+	args ...any,
+) error {
+	return queryAll(ctx, q.reader(), "query_all", scan, query, args...)
+}
+
+// QueryPrimary is QueryAll on the primary, for reads that must observe writes made
+// just before, e.g. re-reading a row after updating it.
+func (q *Query) QueryPrimary(
+	ctx context.Context,
+	scan func(row connfx.QueryResult) error,
+	query string,
+	args ...any,
+) error {
+	return queryAll(ctx, q.repository, "query_primary", scan, query, args...)
+}
+
+// QueryReplica is QueryAll on the next read replica, keeping the read off the primary.
+// It fails with ErrNoReadReplicas when none are configured.
+func (q *Query) QueryReplica(
+	ctx context.Context,
+	scan func(row connfx.QueryResult) error,
+	query string,
+	args ...any,
+) error {
+	if len(q.replicas) == 0 {
+		return fmt.Errorf("%w (operation=query_replica)", ErrNoReadReplicas)
+	}
+
+	return queryAll(ctx, q.reader(), "query_replica", scan, query, args...)
+}
+
+// QueryNamed is QueryAll for a query with :name placeholders, bound from params. A
+// parameter may appear more than once. It fails with ErrNamedQueryNotSupported when the
+// connection does not implement connfx.NamedQueryRepository.
+func (q *Query) QueryNamed(
+	ctx context.Context,
+	scan func(row connfx.QueryResult) error,
+	query string,
+	params map[string]any,
+) error {
+	repository, ok := q.reader().(connfx.NamedQueryRepository)
+	if !ok {
+		return fmt.Errorf(
+			"%w (operation=query_named, protocol=%q)",
+			ErrNamedQueryNotSupported,
+			q.conn.GetProtocol(),
+		)
+	}
+
+	rows, err := repository.QueryNamed(ctx, query, params)
+	if err != nil {
+		return fmt.Errorf("%w (operation=query_named): %w", ErrQueryOperation, err)
+	}
+
+	return scanRows(rows, scan)
+}
+
+// reader returns the repository for the next load-balanced read.
+func (q *Query) reader() connfx.QueryRepository {
+	if len(q.replicas) == 0 {
+		return q.repository
+	}
+
+	index := (q.next.Add(1) - 1) % uint64(len(q.replicas))
+
+	return q.replicas[index]
+}
+
+func queryAll(
+	ctx context.Context,
+	repository connfx.QueryRepository,
+	operation string,
+	scan func(row connfx.QueryResult) error,
+	query string,
+	args ...any,
+) error {
+	rows, err := repository.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%w (operation=%s): %w", ErrQueryOperation, operation, err)
+	}
+
+	return scanRows(rows, scan)
+}
+
+// scanRows calls scan for each row and closes the result set.
+func scanRows(rows connfx.QueryResult, scan func(row connfx.QueryResult) error) error {
+	defer rows.Close() //nolint:errcheck
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Errorf("%w (operation=scan): %w", ErrQueryOperation, err)
+		}
+	}
+
+	// Surface iteration errors for results that report them (e.g. *sql.Rows)
+	if errReporter, ok := rows.(interface{ Err() error }); ok {
+		if err := errReporter.Err(); err != nil {
+			return fmt.Errorf(
+				"%w (operation=iterate): %w",
+				ErrQueryOperation,
+				connfx.NormalizeSQLError(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// Select builds the given SelectBuilder and executes it via QueryAll.
+func (q *Query) Select(
+	ctx context.Context,
+	builder *SelectBuilder,
+	scan func(row connfx.QueryResult) error,
+) error {
+	query, args := builder.Build()
+
+	return q.QueryAll(ctx, scan, query, args...)
+}
+
+// Execute runs a command (INSERT, UPDATE, DELETE) on the primary.
+func (q *Query) Execute(
+	ctx context.Context,
+	command string,
+	args ...any,
+) (connfx.ExecuteResult, error) {
+	result, err := q.repository.Execute(ctx, command, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w (operation=execute): %w", ErrQueryOperation, err)
+	}
+
+	return result, nil
+}
+
+// GetConnection returns the underlying connfx connection.
+func (q *Query) GetConnection() connfx.Connection {
+	return q.conn
+}
+
+// GetRepository returns the query repository of the primary.
+func (q *Query) GetRepository() connfx.QueryRepository {
+	return q.repository
+}
+
+// Iterate executes a query and returns an iterator yielding each row scanned into a T.
+// Structs are filled column by column, matching a field's `db` tag or, failing that, its
+// name case-insensitively; any other T receives the first column. The first error is
+// yielded with a zero T and ends the iteration. The result set is closed when the loop
+// ends, including on break. Like QueryAll, the query goes to a read replica when the
+// Query has any.
+func Iterate[T any](
+	ctx context.Context,
+	q *Query,
+	query string,
+	args ...any,
+) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		rows, err := q.reader().Query(ctx, query, args...)
+		if err != nil {
+			yield(zero, fmt.Errorf("%w (operation=iterate): %w", ErrQueryOperation, err))
+
+			return
+		}
+
+		defer rows.Close() //nolint:errcheck
+
+		for rows.Next() {
+			var value T
+
+			if err := scanRow(rows, &value); err != nil {
+				yield(zero, fmt.Errorf("%w (operation=scan): %w", ErrQueryOperation, err))
+
+				return
+			}
+
+			if !yield(value, nil) {
+				return
+			}
+		}
+
+		// Surface iteration errors for results that report them (e.g. *sql.Rows)
+		if errReporter, ok := rows.(interface{ Err() error }); ok {
+			if err := errReporter.Err(); err != nil {
+				yield(zero, fmt.Errorf(
+					"%w (operation=iterate): %w",
+					ErrQueryOperation,
+					connfx.NormalizeSQLError(err),
+				))
+			}
+		}
+	}
+}
+
+//nolint:gochecknoglobals
+var (
+	sqlScannerType = reflect.TypeFor[sql.Scanner]()
+	timeType       = reflect.TypeFor[time.Time]()
+)
+
+// scanRow scans the current row into dest, which points to a struct or a single value.
+func scanRow(rows connfx.QueryResult, dest any) error {
+	destValue := reflect.ValueOf(dest).Elem()
+
+	if !isScannableStruct(destValue.Type()) {
+		return rows.Scan(dest) //nolint:wrapcheck
+	}
+
+	return scanStructRow(rows, destValue, structScanOptions{skipUnmapped: false, confTags: false})
+}
+
+// ScanStruct scans the current row of result into the struct dest points to, e.g. inside
+// a QueryAll callback. Columns are matched like Iterate does, except that a `conf` tag is
+// used when a field has no `db` tag; columns without a matching field are skipped. A
+// value that does not convert to its field's type fails with ErrColumnTypeMismatch; other
+// scan errors are returned as is.
+func ScanStruct(result connfx.QueryResult, dest any) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Pointer || destValue.IsNil() ||
+		!isScannableStruct(destValue.Type().Elem()) {
+		return fmt.Errorf("%w (type=%T)", ErrInvalidScanDestination, dest)
+	}
+
+	return scanStructRow(
+		result,
+		destValue.Elem(),
+		structScanOptions{skipUnmapped: true, confTags: true},
+	)
+}
+
+// isScannableStruct reports whether a type is scanned field by field rather than as a
+// single value.
+func isScannableStruct(valueType reflect.Type) bool {
+	return valueType.Kind() == reflect.Struct &&
+		valueType != timeType &&
+		!reflect.PointerTo(valueType).Implements(sqlScannerType)
+}
+
+// structScanOptions relaxes the column matching of scanStructRow. Iterate uses neither
+// option, so existing structs keep matching as they always have.
+type structScanOptions struct {
+	// skipUnmapped skips columns without a matching field instead of failing
+	skipUnmapped bool
+	// confTags matches fields without a `db` tag by their `conf` tag
+	confTags bool
+}
+
+// scanStructRow scans the current row into the fields of a struct, matched by column.
+// Columns without a matching field fail with ErrColumnNotMapped unless
+// options.skipUnmapped is set.
+func scanStructRow(
+	rows connfx.QueryResult,
+	structValue reflect.Value,
+	options structScanOptions,
+) error {
+	structType := structValue.Type()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	targets := make([]any, len(columns))
+
+	for i, column := range columns {
+		field, found := columnField(structValue, column, options.confTags)
+
+		switch {
+		case found:
+			targets[i] = field.Addr().Interface()
+		case options.skipUnmapped:
+			targets[i] = new(any)
+		default:
+			return fmt.Errorf("%w (column=%q, type=%s)", ErrColumnNotMapped, column, structType)
+		}
+	}
+
+	err = rows.Scan(targets...)
+	if err != nil && isScanConversionError(err) {
+		return fmt.Errorf("%w (type=%s): %w", ErrColumnTypeMismatch, structType, err)
+	}
+
+	return err //nolint:wrapcheck
+}
+
+// isScanConversionError reports whether database/sql failed to convert a column value
+// into its destination, as opposed to a driver or connection error surfacing from Scan.
+// database/sql has no error type for it, only this message prefix.
+func isScanConversionError(err error) bool {
+	return strings.HasPrefix(err.Error(), "sql: Scan error on column")
+}
+
+// columnField finds the struct field a column is scanned into, by its `db` tag (or, with
+// confTags set, its `conf` tag) or, failing that, by name.
+func columnField(
+	structValue reflect.Value,
+	column string,
+	confTags bool,
+) (reflect.Value, bool) {
+	structType := structValue.Type()
+
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+
+		tag, ok := field.Tag.Lookup("db")
+		if !ok && confTags {
+			tag, ok = field.Tag.Lookup("conf")
+		}
+
+		if ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		if strings.EqualFold(name, column) {
+			return structValue.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
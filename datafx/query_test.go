@@ -0,0 +1,471 @@
+package datafx_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite" // Import SQLite driver
+)
+
+func newSQLiteConnection(t *testing.T) connfx.Connection {
+	t.Helper()
+
+	slogger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{ //nolint:exhaustruct
+		Level: slog.LevelError,
+	}))
+
+	registry := connfx.NewRegistry(logfx.NewLogger(logfx.WithFromSlog(slogger)))
+	registry.RegisterFactory(connfx.NewSQLConnectionFactory("sqlite"))
+
+	conn, err := registry.AddConnection(t.Context(), "db", &connfx.ConfigTarget{ //nolint:exhaustruct
+		Protocol: "sqlite",
+		DSN:      ":memory:",
+	})
+	require.NoError(t, err)
+
+	// Every pooled connection to ":memory:" is a separate database
+	db, err := connfx.GetTypedConnection[*sql.DB](conn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+
+	t.Cleanup(func() { _ = registry.Close(t.Context()) })
+
+	return conn
+}
+
+func TestQuery_Select(t *testing.T) {
+	t.Parallel()
+
+	query, err := datafx.NewQuery(newSQLiteConnection(t))
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	_, err = query.Execute(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	require.NoError(t, err)
+
+	for i, name := range []string{"alice", "bob", "carol", "dave"} {
+		_, err = query.Execute(ctx, "INSERT INTO users (name, age) VALUES (?, ?)", name, 20+i*10)
+		require.NoError(t, err)
+	}
+
+	builder := datafx.NewSelectBuilder("name").
+		From("users").
+		Where("age >= ?", 30).
+		Where("name <> ?", "dave").
+		OrderBy("age DESC").
+		Limit(10)
+
+	var names []string
+
+	err = query.Select(ctx, builder, func(row connfx.QueryResult) error {
+		var name string
+		if err := row.Scan(&name); err != nil {
+			return err
+		}
+
+		names = append(names, name)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"carol", "bob"}, names)
+}
+
+func TestNewQuery_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	_, err := datafx.NewQuery(newMemoryConnection())
+	require.ErrorIs(t, err, datafx.ErrQueryNotSupported)
+
+	_, err = datafx.NewQuery(nil)
+	require.ErrorIs(t, err, datafx.ErrConnectionNotSupported)
+}
+
+func TestIterate(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	query, err := datafx.NewQuery(newSQLiteConnection(t))
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	_, err = query.Execute(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	require.NoError(t, err)
+
+	for i, name := range []string{"alice", "bob", "carol", "dave"} {
+		_, err = query.Execute(ctx, "INSERT INTO users (name, age) VALUES (?, ?)", name, 20+i*10)
+		require.NoError(t, err)
+	}
+
+	type user struct {
+		Name string `db:"name"`
+		ID   int64
+		Age  int
+	}
+
+	t.Run("structs", func(t *testing.T) {
+		var users []user
+
+		for row, err := range datafx.Iterate[user](
+			ctx, query, "SELECT id, name, age FROM users WHERE age >= ? ORDER BY id", 30,
+		) {
+			require.NoError(t, err)
+
+			users = append(users, row)
+		}
+
+		assert.Equal(t, []user{
+			{ID: 2, Name: "bob", Age: 30},
+			{ID: 3, Name: "carol", Age: 40},
+			{ID: 4, Name: "dave", Age: 50},
+		}, users)
+	})
+
+	const namesQuery = "SELECT name FROM users ORDER BY id"
+
+	t.Run("scalars", func(t *testing.T) {
+		var names []string
+
+		for name, err := range datafx.Iterate[string](ctx, query, namesQuery) {
+			require.NoError(t, err)
+
+			names = append(names, name)
+		}
+
+		assert.Equal(t, []string{"alice", "bob", "carol", "dave"}, names)
+	})
+
+	t.Run("early_break_releases_connection", func(t *testing.T) {
+		var names []string
+
+		for name, err := range datafx.Iterate[string](ctx, query, namesQuery) {
+			require.NoError(t, err)
+
+			names = append(names, name)
+			if len(names) == 2 {
+				break
+			}
+		}
+
+		assert.Equal(t, []string{"alice", "bob"}, names)
+
+		// The pool holds a single connection, so a leaked result set would block this query
+		timeoutCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+
+		var count int
+
+		countQuery := "SELECT COUNT(*) FROM users"
+
+		for value, err := range datafx.Iterate[int](timeoutCtx, query, countQuery) {
+			require.NoError(t, err)
+
+			count = value
+		}
+
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("unmapped_column", func(t *testing.T) {
+		var iterations int
+
+		aliasedQuery := "SELECT id, name, age AS years FROM users"
+
+		for _, err := range datafx.Iterate[user](ctx, query, aliasedQuery) {
+			iterations++
+
+			require.ErrorIs(t, err, datafx.ErrColumnNotMapped)
+			assert.Contains(t, err.Error(), `column="years"`)
+		}
+
+		assert.Equal(t, 1, iterations)
+	})
+
+	t.Run("conf_tags_are_not_matched", func(t *testing.T) {
+		type configUser struct {
+			DisplayName string `conf:"name"`
+		}
+
+		for _, err := range datafx.Iterate[configUser](ctx, query, namesQuery) {
+			require.ErrorIs(t, err, datafx.ErrColumnNotMapped)
+		}
+	})
+
+	t.Run("query_error", func(t *testing.T) {
+		for _, err := range datafx.Iterate[user](ctx, query, "SELECT * FROM missing") {
+			require.ErrorIs(t, err, datafx.ErrQueryOperation)
+		}
+	})
+}
+
+func TestQuery_ReadReplicas(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	ctx := t.Context()
+
+	// Each in-memory database names itself, so every read shows where it was routed
+	newNode := func(name string) connfx.Connection {
+		conn := newSQLiteConnection(t)
+
+		query, err := datafx.NewQuery(conn)
+		require.NoError(t, err)
+
+		_, err = query.Execute(ctx, "CREATE TABLE node (name TEXT)")
+		require.NoError(t, err)
+
+		_, err = query.Execute(ctx, "INSERT INTO node (name) VALUES (?)", name)
+		require.NoError(t, err)
+
+		return conn
+	}
+
+	primary := newNode("primary")
+	replicas := []connfx.Connection{newNode("replica-1"), newNode("replica-2")}
+
+	type readFunc = func(
+		ctx context.Context,
+		scan func(row connfx.QueryResult) error,
+		query string,
+		args ...any,
+	) error
+
+	readNode := func(t *testing.T, read readFunc) string {
+		t.Helper()
+
+		var name string
+
+		err := read(ctx, func(row connfx.QueryResult) error {
+			return row.Scan(&name)
+		}, "SELECT name FROM node")
+		require.NoError(t, err)
+
+		return name
+	}
+
+	t.Run("with replicas", func(t *testing.T) {
+		t.Parallel()
+
+		query, err := datafx.NewQuery(primary, datafx.WithReadReplicas(replicas...))
+		require.NoError(t, err)
+
+		// Default reads are balanced across the replicas
+		assert.Equal(t, "replica-1", readNode(t, query.QueryAll))
+		assert.Equal(t, "replica-2", readNode(t, query.QueryAll))
+		assert.Equal(t, "replica-1", readNode(t, query.QueryReplica))
+		assert.Equal(t, "primary", readNode(t, query.QueryPrimary))
+
+		for name, err := range datafx.Iterate[string](ctx, query, "SELECT name FROM node") {
+			require.NoError(t, err)
+			assert.Equal(t, "replica-2", name)
+		}
+
+		// Commands always go to the primary
+		_, err = query.Execute(ctx, "UPDATE node SET name = ?", "primary-updated")
+		require.NoError(t, err)
+
+		assert.Equal(t, "primary-updated", readNode(t, query.QueryPrimary))
+		assert.Equal(t, "replica-1", readNode(t, query.QueryAll))
+	})
+
+	t.Run("without replicas", func(t *testing.T) {
+		t.Parallel()
+
+		query, err := datafx.NewQuery(newNode("standalone"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "standalone", readNode(t, query.QueryAll))
+		assert.Equal(t, "standalone", readNode(t, query.QueryPrimary))
+
+		err = query.QueryReplica(ctx, func(row connfx.QueryResult) error {
+			return nil
+		}, "SELECT name FROM node")
+		require.ErrorIs(t, err, datafx.ErrNoReadReplicas)
+	})
+
+	t.Run("rejects non-relational replicas", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := datafx.NewQuery(primary, datafx.WithReadReplicas(newMemoryConnection()))
+		require.ErrorIs(t, err, datafx.ErrQueryNotSupported)
+	})
+}
+
+func TestScanStruct(t *testing.T) {
+	t.Parallel()
+
+	query, err := datafx.NewQuery(newSQLiteConnection(t))
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	_, err = query.Execute(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, age TEXT)")
+	require.NoError(t, err)
+
+	_, err = query.Execute(ctx, "INSERT INTO users (name, age) VALUES ('alice', '30')")
+	require.NoError(t, err)
+
+	_, err = query.Execute(ctx, "INSERT INTO users (name, age) VALUES ('bob', 'unknown')")
+	require.NoError(t, err)
+
+	type userView struct {
+		DisplayName string `conf:"name"`
+		ID          int64  `db:"id"`
+		Age         int
+	}
+
+	t.Run("skips unmapped columns", func(t *testing.T) {
+		t.Parallel()
+
+		var users []userView
+
+		err := query.QueryAll(ctx, func(row connfx.QueryResult) error {
+			var user userView
+
+			if err := datafx.ScanStruct(row, &user); err != nil {
+				return err
+			}
+
+			users = append(users, user)
+
+			return nil
+		}, "SELECT id, name, age, 'extra' AS note FROM users WHERE id = 1")
+		require.NoError(t, err)
+
+		assert.Equal(t, []userView{{ID: 1, DisplayName: "alice", Age: 30}}, users)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		err := query.QueryAll(ctx, func(row connfx.QueryResult) error {
+			var user userView
+
+			return datafx.ScanStruct(row, &user)
+		}, "SELECT id, name, age FROM users WHERE id = 2")
+		require.ErrorIs(t, err, datafx.ErrColumnTypeMismatch)
+		assert.Contains(t, err.Error(), `"age"`)
+	})
+
+	t.Run("driver error is not a type mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		result := &failingScanResult{err: errDriverBadConn}
+
+		err := datafx.ScanStruct(result, &userView{}) //nolint:exhaustruct
+		require.ErrorIs(t, err, errDriverBadConn)
+		assert.NotErrorIs(t, err, datafx.ErrColumnTypeMismatch)
+	})
+
+	t.Run("invalid destination", func(t *testing.T) {
+		t.Parallel()
+
+		err := query.QueryAll(ctx, func(row connfx.QueryResult) error {
+			var name string
+
+			return datafx.ScanStruct(row, &name)
+		}, "SELECT name FROM users")
+		require.ErrorIs(t, err, datafx.ErrInvalidScanDestination)
+	})
+}
+
+var errDriverBadConn = errors.New("driver: bad connection")
+
+// failingScanResult is a single-column result whose Scan fails like a lost connection.
+type failingScanResult struct {
+	err error
+}
+
+func (r *failingScanResult) Next() bool                 { return true }
+func (r *failingScanResult) Scan(dest ...any) error     { return r.err }
+func (r *failingScanResult) Columns() ([]string, error) { return []string{"id"}, nil }
+func (r *failingScanResult) Close() error               { return nil }
+
+// positionalOnlyConnection exposes a connection's QueryRepository without its optional
+// NamedQueryRepository.
+type positionalOnlyConnection struct {
+	connfx.Connection
+
+	repository connfx.QueryRepository
+}
+
+func (c *positionalOnlyConnection) Query(
+	ctx context.Context,
+	query string,
+	args ...any,
+) (connfx.QueryResult, error) {
+	return c.repository.Query(ctx, query, args...)
+}
+
+func (c *positionalOnlyConnection) Execute(
+	ctx context.Context,
+	command string,
+	args ...any,
+) (connfx.ExecuteResult, error) {
+	return c.repository.Execute(ctx, command, args...)
+}
+
+func TestQuery_QueryNamed(t *testing.T) {
+	t.Parallel()
+
+	conn := newSQLiteConnection(t)
+
+	query, err := datafx.NewQuery(conn)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	_, err = query.Execute(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	require.NoError(t, err)
+
+	for i, name := range []string{"alice", "bob", "carol"} {
+		_, err = query.Execute(ctx, "INSERT INTO users (name, age) VALUES (?, ?)", name, 20+i*10)
+		require.NoError(t, err)
+	}
+
+	const namedQuery = "SELECT name FROM users WHERE age BETWEEN :min AND :min + 10 ORDER BY id"
+
+	var names []string
+
+	err = query.QueryNamed(ctx, func(row connfx.QueryResult) error {
+		var name string
+
+		if err := row.Scan(&name); err != nil {
+			return err
+		}
+
+		names = append(names, name)
+
+		return nil
+	}, namedQuery, map[string]any{"min": 30})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob", "carol"}, names)
+
+	err = query.QueryNamed(ctx, func(row connfx.QueryResult) error {
+		return nil
+	}, namedQuery, nil)
+	require.ErrorIs(t, err, datafx.ErrQueryOperation)
+	require.ErrorIs(t, err, connfx.ErrMissingNamedParameter)
+
+	positional, err := datafx.NewQuery(&positionalOnlyConnection{
+		Connection: conn,
+		repository: query.GetRepository(),
+	})
+	require.NoError(t, err)
+
+	err = positional.QueryNamed(ctx, func(row connfx.QueryResult) error {
+		return nil
+	}, namedQuery, map[string]any{"min": 30})
+	require.ErrorIs(t, err, datafx.ErrNamedQueryNotSupported)
+}
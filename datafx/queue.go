@@ -5,10 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/lib"
+	"github.com/eser/ajan/metricsfx"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -16,17 +22,42 @@ var (
 	ErrMessageProcessing = errors.New("message processing failed")
 	ErrContextCanceled   = errors.New("context canceled")
 	ErrQueueOperation    = errors.New("queue operation failed")
+	ErrDLQNotSupported   = errors.New("connection does not support dead-letter queues")
+
+	ErrInvalidConsumerConfig = errors.New("invalid consumer configuration")
+	ErrMessageTooLarge       = lib.NewCategorizedError(
+		lib.ErrCategoryTooLarge,
+		"message exceeds the maximum size",
+	)
 )
 
 // Queue provides high-level message queue operations.
 type Queue struct {
 	conn       connfx.Connection
 	repository connfx.QueueRepository
+	logger     *slog.Logger
+	metrics    *QueueMetrics
+	tracer     operationTracer
+
+	onDeserializeError DeserializeErrorHandler
+
+	// inFlight counts the messages being handled per queue name (*atomic.Int64)
+	inFlight sync.Map
+
+	// validator checks messages sent with PublishValidated (nil means ValidateStruct)
+	validator atomic.Pointer[MessageValidator]
+
+	messageSizeWarning   int
+	maxMessageSize       int
+	slowHandlerThreshold time.Duration
+	lagCheckInterval     time.Duration
+	lagWarning           int64
+	lifecycleLogging     bool
 }
 
 // NewQueue creates a new Queue instance from a connfx connection.
 // The connection must support queue operations.
-func NewQueue(conn connfx.Connection) (*Queue, error) {
+func NewQueue(conn connfx.Connection, opts ...Option) (*Queue, error) {
 	if conn == nil {
 		return nil, fmt.Errorf("%w: connection is nil", ErrConnectionNotSupported)
 	}
@@ -50,9 +81,37 @@ func NewQueue(conn connfx.Connection) (*Queue, error) {
 		)
 	}
 
+	options := newOptions(opts)
+
+	tracer, err := newOperationTracer(conn, options)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := slog.Default()
+	if options.logger != nil {
+		logger = options.logger.Logger
+	}
+
 	return &Queue{
 		conn:       conn,
 		repository: repo,
+		logger:     logger,
+		metrics:    options.queueMetrics,
+		tracer:     tracer,
+
+		onDeserializeError: options.onDeserializeError,
+
+		inFlight: sync.Map{},
+
+		validator: atomic.Pointer[MessageValidator]{},
+
+		messageSizeWarning:   options.messageSizeWarning,
+		maxMessageSize:       options.maxMessageSize,
+		slowHandlerThreshold: options.slowHandlerThreshold,
+		lagCheckInterval:     options.lagCheckInterval,
+		lagWarning:           options.lagWarning,
+		lifecycleLogging:     options.lifecycleLogging,
 	}, nil
 }
 
@@ -85,18 +144,63 @@ func (q *Queue) DeclareQueueWithConfig(
 	return queueName, nil
 }
 
-// Publish sends a message to a queue after marshaling it to JSON.
-func (q *Queue) Publish(ctx context.Context, queueName string, message any) error {
-	data, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("%w (queue=%q): %w", ErrFailedToMarshal, queueName, err)
+// DeclareQueueWithDLQ declares a queue whose rejected and expired messages are routed to
+// a dead-letter queue, declaring and binding the dead-letter exchange and queue as well.
+// The connection must implement connfx.DeadLetterQueueRepository (AMQP does).
+func (q *Queue) DeclareQueueWithDLQ(
+	ctx context.Context,
+	name string,
+	dlqConfig connfx.DeadLetterConfig,
+) (string, error) {
+	dlqRepo, ok := q.repository.(connfx.DeadLetterQueueRepository)
+	if !ok {
+		return "", fmt.Errorf(
+			"%w (queue=%q, protocol=%q)",
+			ErrDLQNotSupported,
+			name,
+			q.conn.GetProtocol(),
+		)
 	}
 
-	if err := q.repository.Publish(ctx, queueName, data); err != nil {
-		return fmt.Errorf("%w (operation=publish, queue=%q): %w", ErrQueueOperation, queueName, err)
+	queueName, err := dlqRepo.QueueDeclareWithDeadLetter(ctx, name, dlqConfig)
+	if err != nil {
+		return "", fmt.Errorf(
+			"%w (operation=declare_with_dlq, queue=%q): %w",
+			ErrQueueOperation,
+			name,
+			err,
+		)
 	}
 
-	return nil
+	return queueName, nil
+}
+
+// Publish sends a message to a queue after marshaling it to JSON.
+func (q *Queue) Publish(ctx context.Context, queueName string, message any) error {
+	return q.tracer.run(
+		ctx,
+		"queue.publish",
+		trace.SpanKindProducer,
+		AttributeQueue.String(queueName),
+		func(ctx context.Context) error {
+			data, err := json.Marshal(message)
+			if err != nil {
+				return fmt.Errorf("%w (queue=%q): %w", ErrFailedToMarshal, queueName, err)
+			}
+
+			if err := q.checkMessageSize(ctx, queueName, data); err != nil {
+				return err
+			}
+
+			if err := q.repository.Publish(ctx, queueName, data); err != nil {
+				return fmt.Errorf("%w (operation=publish, queue=%q): %w", ErrQueueOperation, queueName, err)
+			}
+
+			q.logPublished(ctx, queueName, data, nil)
+
+			return nil
+		},
+	)
 }
 
 // PublishWithHeaders sends a message with custom headers after marshaling it to JSON.
@@ -106,35 +210,65 @@ func (q *Queue) PublishWithHeaders(
 	message any,
 	headers map[string]any,
 ) error {
-	data, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("%w (queue=%q): %w", ErrFailedToMarshal, queueName, err)
-	}
+	return q.tracer.run(
+		ctx,
+		"queue.publish_with_headers",
+		trace.SpanKindProducer,
+		AttributeQueue.String(queueName),
+		func(ctx context.Context) error {
+			data, err := json.Marshal(message)
+			if err != nil {
+				return fmt.Errorf("%w (queue=%q): %w", ErrFailedToMarshal, queueName, err)
+			}
 
-	if err := q.repository.PublishWithHeaders(ctx, queueName, data, headers); err != nil {
-		return fmt.Errorf(
-			"%w (operation=publish_with_headers, queue=%q): %w",
-			ErrQueueOperation,
-			queueName,
-			err,
-		)
-	}
+			if err := q.checkMessageSize(ctx, queueName, data); err != nil {
+				return err
+			}
 
-	return nil
+			headers = q.tracer.injectHeaders(ctx, headers)
+
+			if err := q.repository.PublishWithHeaders(ctx, queueName, data, headers); err != nil {
+				return fmt.Errorf(
+					"%w (operation=publish_with_headers, queue=%q): %w",
+					ErrQueueOperation,
+					queueName,
+					err,
+				)
+			}
+
+			q.logPublished(ctx, queueName, data, headers)
+
+			return nil
+		},
+	)
 }
 
 // PublishRaw sends raw bytes to a queue.
 func (q *Queue) PublishRaw(ctx context.Context, queueName string, data []byte) error {
-	if err := q.repository.Publish(ctx, queueName, data); err != nil {
-		return fmt.Errorf(
-			"%w (operation=publish_raw, queue=%q): %w",
-			ErrQueueOperation,
-			queueName,
-			err,
-		)
-	}
+	return q.tracer.run(
+		ctx,
+		"queue.publish_raw",
+		trace.SpanKindProducer,
+		AttributeQueue.String(queueName),
+		func(ctx context.Context) error {
+			if err := q.checkMessageSize(ctx, queueName, data); err != nil {
+				return err
+			}
 
-	return nil
+			if err := q.repository.Publish(ctx, queueName, data); err != nil {
+				return fmt.Errorf(
+					"%w (operation=publish_raw, queue=%q): %w",
+					ErrQueueOperation,
+					queueName,
+					err,
+				)
+			}
+
+			q.logPublished(ctx, queueName, data, nil)
+
+			return nil
+		},
+	)
 }
 
 // PublishRawWithHeaders sends raw bytes with custom headers to a queue.
@@ -144,16 +278,32 @@ func (q *Queue) PublishRawWithHeaders(
 	data []byte,
 	headers map[string]any,
 ) error {
-	if err := q.repository.PublishWithHeaders(ctx, queueName, data, headers); err != nil {
-		return fmt.Errorf(
-			"%w (operation=publish_raw_with_headers, queue=%q): %w",
-			ErrQueueOperation,
-			queueName,
-			err,
-		)
-	}
+	return q.tracer.run(
+		ctx,
+		"queue.publish_raw_with_headers",
+		trace.SpanKindProducer,
+		AttributeQueue.String(queueName),
+		func(ctx context.Context) error {
+			if err := q.checkMessageSize(ctx, queueName, data); err != nil {
+				return err
+			}
 
-	return nil
+			headers = q.tracer.injectHeaders(ctx, headers)
+
+			if err := q.repository.PublishWithHeaders(ctx, queueName, data, headers); err != nil {
+				return fmt.Errorf(
+					"%w (operation=publish_raw_with_headers, queue=%q): %w",
+					ErrQueueOperation,
+					queueName,
+					err,
+				)
+			}
+
+			q.logPublished(ctx, queueName, data, headers)
+
+			return nil
+		},
+	)
 }
 
 // Consume starts consuming messages from a queue with the given configuration.
@@ -190,6 +340,14 @@ func (q *Queue) ConsumeWithDefaults(
 // ProcessMessages provides a convenient way to process messages with automatic unmarshalling.
 // The messageHandler function receives the unmarshaled message and should return true to acknowledge
 // the message, or false to negatively acknowledge it.
+// If config.VisibilityTimeout is set, a message that is not settled within the deadline is
+// handled as a failed delivery and the handler's context is canceled. The deadline is
+// enforced by a client-side timer that settles the message, so it cannot be combined
+// with config.AutoAck, which leaves nothing to settle; that combination returns
+// ErrInvalidConsumerConfig.
+// Messages rejected by config.HeaderFilter or config.Filter are acknowledged and skipped.
+// Failed messages are requeued indefinitely; see ProcessMessagesWithOptions to
+// dead-letter them instead.
 func (q *Queue) ProcessMessages(
 	ctx context.Context,
 	queueName string,
@@ -197,6 +355,35 @@ func (q *Queue) ProcessMessages(
 	messageHandler func(ctx context.Context, message any) bool,
 	messageType any,
 ) error {
+	return q.ProcessMessagesWithOptions(
+		ctx,
+		queueName,
+		config,
+		ProcessOptions{
+			MaxRetries:      0,
+			DeadLetterQueue: "",
+		},
+		messageHandler,
+		messageType,
+	)
+}
+
+// ProcessMessagesWithOptions processes messages like ProcessMessages. With
+// options.MaxRetries set, a failed message is retried by republishing it with its
+// attempt count in the DeathCountHeader header, and once it has failed MaxRetries times
+// it is published to options.DeadLetterQueue instead.
+func (q *Queue) ProcessMessagesWithOptions(
+	ctx context.Context,
+	queueName string,
+	config connfx.ConsumerConfig,
+	options ProcessOptions,
+	messageHandler func(ctx context.Context, message any) bool,
+	messageType any,
+) error {
+	if err := validateProcessConfig(queueName, config); err != nil {
+		return err
+	}
+
 	messages, errors := q.repository.Consume(ctx, queueName, config)
 
 	for {
@@ -212,7 +399,16 @@ func (q *Queue) ProcessMessages(
 				return nil // Channel closed
 			}
 
-			if err := q.processMessage(ctx, msg, messageHandler, messageType); err != nil {
+			err := q.processMessage(
+				ctx,
+				queueName,
+				msg,
+				config,
+				options,
+				messageHandler,
+				messageType,
+			)
+			if err != nil {
 				return err
 			}
 		}
@@ -229,6 +425,10 @@ func (q *Queue) ProcessMessagesWithGroup(
 	messageHandler func(ctx context.Context, message any) bool,
 	messageType any,
 ) error {
+	if err := validateProcessConfig(queueName, config); err != nil {
+		return err
+	}
+
 	messages, errors := q.repository.ConsumeWithGroup(
 		ctx,
 		queueName,
@@ -237,10 +437,15 @@ func (q *Queue) ProcessMessagesWithGroup(
 		config,
 	)
 
+	lagChecks, stopLagChecks := q.startLagChecks()
+	defer stopLagChecks()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return fmt.Errorf("%w: %w", ErrContextCanceled, ctx.Err())
+		case <-lagChecks:
+			q.checkConsumerLag(ctx, queueName, consumerGroup)
 		case err := <-errors:
 			if err != nil {
 				return fmt.Errorf(
@@ -256,7 +461,19 @@ func (q *Queue) ProcessMessagesWithGroup(
 				return nil // Channel closed
 			}
 
-			if err := q.processMessage(ctx, msg, messageHandler, messageType); err != nil {
+			err := q.processMessage(
+				ctx,
+				queueName,
+				msg,
+				config,
+				ProcessOptions{
+					MaxRetries:      0,
+					DeadLetterQueue: "",
+				},
+				messageHandler,
+				messageType,
+			)
+			if err != nil {
 				return err
 			}
 		}
@@ -310,33 +527,49 @@ func (q *Queue) AckMessage(
 	ctx context.Context,
 	queueName, consumerGroup, receiptHandle string,
 ) error {
-	if err := q.repository.AckMessage(ctx, queueName, consumerGroup, receiptHandle); err != nil {
-		return fmt.Errorf(
-			"%w (operation=ack_message, queue=%q, group=%q, handle=%q): %w",
-			ErrQueueOperation,
-			queueName,
-			consumerGroup,
-			receiptHandle,
-			err,
-		)
-	}
+	return q.tracer.run(
+		ctx,
+		"queue.ack_message",
+		trace.SpanKindClient,
+		AttributeQueue.String(queueName),
+		func(ctx context.Context) error {
+			if err := q.repository.AckMessage(ctx, queueName, consumerGroup, receiptHandle); err != nil {
+				return fmt.Errorf(
+					"%w (operation=ack_message, queue=%q, group=%q, handle=%q): %w",
+					ErrQueueOperation,
+					queueName,
+					consumerGroup,
+					receiptHandle,
+					err,
+				)
+			}
 
-	return nil
+			return nil
+		},
+	)
 }
 
 // DeleteMessage removes a message from the queue.
 func (q *Queue) DeleteMessage(ctx context.Context, queueName, receiptHandle string) error {
-	if err := q.repository.DeleteMessage(ctx, queueName, receiptHandle); err != nil {
-		return fmt.Errorf(
-			"%w (operation=delete_message, queue=%q, handle=%q): %w",
-			ErrQueueOperation,
-			queueName,
-			receiptHandle,
-			err,
-		)
-	}
+	return q.tracer.run(
+		ctx,
+		"queue.delete_message",
+		trace.SpanKindClient,
+		AttributeQueue.String(queueName),
+		func(ctx context.Context) error {
+			if err := q.repository.DeleteMessage(ctx, queueName, receiptHandle); err != nil {
+				return fmt.Errorf(
+					"%w (operation=delete_message, queue=%q, handle=%q): %w",
+					ErrQueueOperation,
+					queueName,
+					receiptHandle,
+					err,
+				)
+			}
 
-	return nil
+			return nil
+		},
+	)
 }
 
 // GetConnection returns the underlying connfx connection.
@@ -366,31 +599,175 @@ func (q *Queue) GetStreamRepository() (connfx.QueueStreamRepository, error) {
 		ErrQueueNotSupported, q.conn.GetProtocol())
 }
 
+// checkMessageSize enforces the configured size limits on an outgoing message and
+// records its size. Oversized messages above the warning threshold are still published.
+func (q *Queue) checkMessageSize(ctx context.Context, queueName string, data []byte) error {
+	size := len(data)
+
+	if q.maxMessageSize > 0 && size > q.maxMessageSize {
+		return fmt.Errorf(
+			"%w (queue=%q, size=%d, max=%d)",
+			ErrMessageTooLarge,
+			queueName,
+			size,
+			q.maxMessageSize,
+		)
+	}
+
+	if q.messageSizeWarning > 0 && size > q.messageSizeWarning {
+		q.logger.WarnContext(
+			ctx,
+			"queue message exceeds size warning threshold",
+			slog.String("queue", queueName),
+			slog.Int("size", size),
+			slog.Int("threshold", q.messageSizeWarning),
+		)
+	}
+
+	if q.metrics != nil && q.metrics.MessageSize != nil {
+		q.metrics.MessageSize.Record(
+			ctx,
+			float64(size),
+			metricsfx.StringAttr("queue", queueName),
+			metricsfx.StringAttr("protocol", q.conn.GetProtocol()),
+		)
+	}
+
+	return nil
+}
+
+// validateProcessConfig rejects consumer configurations the processing loop cannot honor.
+func validateProcessConfig(queueName string, config connfx.ConsumerConfig) error {
+	if config.AutoAck && config.VisibilityTimeout > 0 {
+		return fmt.Errorf(
+			"%w (queue=%q): a visibility timeout requires manual acknowledgment, not AutoAck",
+			ErrInvalidConsumerConfig,
+			queueName,
+		)
+	}
+
+	return nil
+}
+
 // processMessage handles the processing of a single message.
 func (q *Queue) processMessage(
 	ctx context.Context,
+	queueName string,
 	msg connfx.Message,
+	config connfx.ConsumerConfig,
+	options ProcessOptions,
 	messageHandler func(ctx context.Context, message any) bool,
 	messageType any,
 ) error {
+	msg = q.traceLifecycle(ctx, queueName, msg)
+
+	// Skip messages filtered out by the consumer configuration
+	if !config.Matches(&msg) {
+		if err := msg.Ack(); err != nil {
+			return fmt.Errorf("%w (operation=ack_filtered): %w", ErrQueueOperation, err)
+		}
+
+		return nil
+	}
+
 	// Create a new instance of the message type
 	messageValue := q.createMessageInstance(messageType)
 
 	// Unmarshal the message
 	if err := json.Unmarshal(msg.Body, &messageValue); err != nil {
-		// Nack the message due to unmarshalling error
-		if nackErr := msg.Nack(false); nackErr != nil {
-			return fmt.Errorf("%w (operation=nack_after_unmarshal): %w", ErrQueueOperation, nackErr)
-		}
+		return q.handleDeserializeError(ctx, queueName, msg, err)
+	}
+
+	messageHandler = q.instrumentHandler(queueName, messageHandler)
+	messageHandler = q.tracer.traceHandler(queueName, msg.Headers, messageHandler)
 
-		return nil // Continue processing other messages
+	if config.VisibilityTimeout > 0 {
+		return q.processMessageWithDeadline(
+			ctx,
+			queueName,
+			msg,
+			options,
+			config.VisibilityTimeout,
+			messageHandler,
+			messageValue,
+		)
 	}
 
 	// Process the message
 	success := messageHandler(ctx, messageValue)
 
-	// Acknowledge or nack based on processing result
-	return q.acknowledgeMessage(msg, success)
+	// Acknowledge, retry or dead-letter based on processing result
+	return q.settleMessage(ctx, queueName, msg, options, success)
+}
+
+// processMessageWithDeadline runs the handler under a visibility timeout. A monitoring
+// timer settles the message as a failed delivery once the deadline passes, so it is
+// requeued, or retried and eventually dead-lettered like any other failure; the handler's
+// own result is discarded in that case since the message has already been settled.
+func (q *Queue) processMessageWithDeadline(
+	ctx context.Context,
+	queueName string,
+	msg connfx.Message,
+	options ProcessOptions,
+	visibilityTimeout time.Duration,
+	messageHandler func(ctx context.Context, message any) bool,
+	messageValue any,
+) error {
+	handlerCtx, cancel := context.WithTimeout(ctx, visibilityTimeout)
+	defer cancel()
+
+	var settleErr error
+
+	settleDone := make(chan struct{})
+
+	timer := time.AfterFunc(visibilityTimeout, func() {
+		defer close(settleDone)
+
+		settleErr = q.settleMessage(context.WithoutCancel(ctx), queueName, msg, options, false)
+	})
+
+	success := messageHandler(handlerCtx, messageValue)
+
+	if timer.Stop() {
+		// The deadline did not fire, so the handler result decides the outcome
+		return q.settleMessage(ctx, queueName, msg, options, success)
+	}
+
+	<-settleDone
+
+	return settleErr
+}
+
+// handleDeserializeError settles a message whose body failed to decode. The configured
+// DeserializeErrorHandler decides whether it is acknowledged; by default it is dropped
+// without requeueing. Processing continues with the next message either way.
+func (q *Queue) handleDeserializeError(
+	ctx context.Context,
+	queueName string,
+	msg connfx.Message,
+	decodeErr error,
+) error {
+	if q.metrics != nil && q.metrics.DeserializeErrors != nil {
+		q.metrics.DeserializeErrors.Inc(
+			ctx,
+			metricsfx.StringAttr("queue", queueName),
+			metricsfx.StringAttr("protocol", q.conn.GetProtocol()),
+		)
+	}
+
+	if q.onDeserializeError != nil && q.onDeserializeError(ctx, msg.Body, decodeErr) {
+		if err := msg.Ack(); err != nil {
+			return fmt.Errorf("%w (operation=ack_after_unmarshal): %w", ErrQueueOperation, err)
+		}
+
+		return nil
+	}
+
+	if err := msg.Nack(false); err != nil {
+		return fmt.Errorf("%w (operation=nack_after_unmarshal): %w", ErrQueueOperation, err)
+	}
+
+	return nil
 }
 
 // createMessageInstance creates an instance for unmarshalling the message.
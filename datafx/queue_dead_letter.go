@@ -0,0 +1,111 @@
+package datafx
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/eser/ajan/connfx"
+)
+
+// DeathCountHeader carries how many times processing a message has failed. It is set on
+// retried and dead-lettered messages by ProcessMessagesWithOptions.
+const DeathCountHeader = "x-death-count"
+
+// ProcessOptions controls how ProcessMessagesWithOptions handles messages the handler
+// fails to process. The zero value requeues them indefinitely, like ProcessMessages.
+type ProcessOptions struct {
+	// DeadLetterQueue receives messages that failed MaxRetries times, with their original
+	// headers plus DeathCountHeader. Without it, such messages are dropped by nacking
+	// them without requeueing, which brokers with a dead-letter exchange route there.
+	DeadLetterQueue string
+	// MaxRetries is how many failed processing attempts a message gets before it is
+	// dead-lettered (0 requeues failed messages indefinitely)
+	MaxRetries int
+}
+
+// settleMessage acknowledges a processed message. A failed one is requeued as is,
+// or, with options.MaxRetries set, republished with an incremented DeathCountHeader
+// until it has failed MaxRetries times, and then dead-lettered. The original delivery is
+// acknowledged once its replacement is published.
+func (q *Queue) settleMessage(
+	ctx context.Context,
+	queueName string,
+	msg connfx.Message,
+	options ProcessOptions,
+	success bool,
+) error {
+	if success || options.MaxRetries <= 0 {
+		return q.acknowledgeMessage(msg, success)
+	}
+
+	deaths := deathCount(msg.Headers) + 1
+
+	headers := maps.Clone(msg.Headers)
+	if headers == nil {
+		headers = make(map[string]any, 1)
+	}
+
+	headers[DeathCountHeader] = deaths
+
+	operation, target := "retry", queueName
+
+	if deaths >= options.MaxRetries {
+		if options.DeadLetterQueue == "" {
+			if err := msg.Nack(false); err != nil {
+				return fmt.Errorf("%w (operation=nack_exhausted): %w", ErrQueueOperation, err)
+			}
+
+			return nil
+		}
+
+		operation, target = "dead_letter", options.DeadLetterQueue
+	}
+
+	if err := q.repository.PublishWithHeaders(ctx, target, msg.Body, headers); err != nil {
+		// Hand the message back rather than lose it
+		_ = msg.Nack(true)
+
+		return fmt.Errorf(
+			"%w (operation=%s, queue=%q): %w",
+			ErrQueueOperation,
+			operation,
+			target,
+			err,
+		)
+	}
+
+	q.logPublished(ctx, target, msg.Body, headers)
+
+	if err := msg.Ack(); err != nil {
+		return fmt.Errorf("%w (operation=ack_after_%s): %w", ErrQueueOperation, operation, err)
+	}
+
+	return nil
+}
+
+// deathCount reads DeathCountHeader, whose type depends on how the backend carries
+// header values.
+func deathCount(headers map[string]any) int {
+	switch value := headers[DeathCountHeader].(type) {
+	case int:
+		return value
+	case int32:
+		return int(value)
+	case int64:
+		return int(value)
+	case float64:
+		return int(value)
+	case string:
+		count, _ := strconv.Atoi(value)
+
+		return count
+	case []byte:
+		count, _ := strconv.Atoi(string(value))
+
+		return count
+	default:
+		return 0
+	}
+}
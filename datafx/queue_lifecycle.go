@@ -0,0 +1,148 @@
+package datafx
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/tracesfx"
+)
+
+const (
+	// CorrelationIDHeader carries the ID that ties a message to the request or workflow
+	// that produced it. Lifecycle log entries include it when present.
+	CorrelationIDHeader = "x-correlation-id"
+	// DeduplicationIDHeader carries the ID producers use to recognize resent messages.
+	// Lifecycle log entries include it when present.
+	DeduplicationIDHeader = "x-deduplication-id"
+)
+
+const (
+	lifecycleOutcomeAcked    = "acked"
+	lifecycleOutcomeRequeued = "requeued"
+	lifecycleOutcomeDropped  = "dropped"
+)
+
+// WithLifecycleLogging makes a Queue log every message it publishes and consumes at debug
+// level: "queue message published" with its size, "queue message consumed" on delivery
+// and "queue message settled" with the outcome (acked, requeued or dropped) and how long
+// the message took since delivery. Entries carry the correlation and deduplication IDs
+// found in the message headers.
+func WithLifecycleLogging() Option {
+	return func(opts *options) {
+		opts.lifecycleLogging = true
+	}
+}
+
+// lifecycleLogEnabled reports whether lifecycle entries would be written.
+func (q *Queue) lifecycleLogEnabled(ctx context.Context) bool {
+	return q.lifecycleLogging && q.logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// logPublished records a message published to queueName.
+func (q *Queue) logPublished(
+	ctx context.Context,
+	queueName string,
+	data []byte,
+	headers map[string]any,
+) {
+	if !q.lifecycleLogEnabled(ctx) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("queue", queueName),
+		slog.Int("size", len(data)),
+	}
+
+	q.logger.LogAttrs(
+		ctx,
+		slog.LevelDebug,
+		"queue message published",
+		appendMessageIDAttrs(attrs, headers)...,
+	)
+}
+
+// traceLifecycle logs the delivery of msg and wraps its acknowledgers so that settling
+// it, on whichever path, is logged with the outcome and the time since delivery.
+func (q *Queue) traceLifecycle(
+	ctx context.Context,
+	queueName string,
+	msg connfx.Message,
+) connfx.Message {
+	if !q.lifecycleLogEnabled(ctx) {
+		return msg
+	}
+
+	// Clipped, so that the entries below never share what they append
+	attrs := slices.Clip(appendMessageIDAttrs([]slog.Attr{
+		slog.String("queue", queueName),
+		slog.String("message_id", msg.MessageID),
+	}, msg.Headers))
+
+	q.logger.LogAttrs(
+		ctx,
+		slog.LevelDebug,
+		"queue message consumed",
+		append(
+			attrs,
+			slog.Int("size", len(msg.Body)),
+			slog.Int("delivery_count", msg.DeliveryCount),
+		)...,
+	)
+
+	delivered := time.Now()
+	settle := func(outcome string, err error) {
+		settledAttrs := append(
+			attrs,
+			slog.String("outcome", outcome),
+			slog.Duration("duration", time.Since(delivered)),
+		)
+
+		if err != nil {
+			settledAttrs = append(settledAttrs, slog.String("error", err.Error()))
+		}
+
+		q.logger.LogAttrs(ctx, slog.LevelDebug, "queue message settled", settledAttrs...)
+	}
+
+	// The copy keeps the acknowledgers of the original delivery
+	original := msg
+
+	msg.SetAckFunc(func() error {
+		err := original.Ack()
+		settle(lifecycleOutcomeAcked, err)
+
+		return err
+	})
+
+	msg.SetNackFunc(func(requeue bool) error {
+		err := original.Nack(requeue)
+
+		outcome := lifecycleOutcomeDropped
+		if requeue {
+			outcome = lifecycleOutcomeRequeued
+		}
+
+		settle(outcome, err)
+
+		return err
+	})
+
+	return msg
+}
+
+// appendMessageIDAttrs adds the correlation and deduplication IDs found in headers.
+func appendMessageIDAttrs(attrs []slog.Attr, headers map[string]any) []slog.Attr {
+	if id := tracesfx.HeaderCarrier(headers).Get(CorrelationIDHeader); id != "" {
+		attrs = append(attrs, slog.String("correlation_id", id))
+	}
+
+	if id := tracesfx.HeaderCarrier(headers).Get(DeduplicationIDHeader); id != "" {
+		attrs = append(attrs, slog.String("deduplication_id", id))
+	}
+
+	return attrs
+}
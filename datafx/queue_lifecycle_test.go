@@ -0,0 +1,151 @@
+package datafx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLifecycleQueue(
+	t *testing.T,
+	level slog.Level,
+	opts ...datafx.Option,
+) (*datafx.Queue, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{ //nolint:exhaustruct
+		Level: level,
+	})
+
+	queue, err := datafx.NewQueue(
+		newMemoryConnection(),
+		append(opts, datafx.WithLogger(logfx.NewLogger(logfx.WithFromSlog(slog.New(handler)))))...,
+	)
+	require.NoError(t, err)
+
+	return queue, &buf
+}
+
+func lifecycleEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestQueue_LifecycleLogging(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	queue, buf := newLifecycleQueue(t, slog.LevelDebug, datafx.WithLifecycleLogging())
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	// Header names match case-insensitively
+	headers := map[string]any{
+		"X-Correlation-ID":           "req-42",
+		datafx.DeduplicationIDHeader: "order-7",
+	}
+
+	require.NoError(t, queue.PublishWithHeaders(ctx, "orders", testEvent{Name: "ok"}, headers))
+	require.NoError(t, queue.Publish(ctx, "orders", testEvent{Name: "broken"}))
+
+	handler := func(_ context.Context, message any) bool {
+		event, _ := message.(*testEvent)
+		if event.Name == "broken" {
+			cancel()
+
+			return false
+		}
+
+		return true
+	}
+
+	err := queue.ProcessMessagesWithOptions(
+		ctx,
+		"orders",
+		connfx.DefaultConsumerConfig(),
+		datafx.ProcessOptions{MaxRetries: 1, DeadLetterQueue: ""},
+		handler,
+		&testEvent{}, //nolint:exhaustruct
+	)
+	require.ErrorIs(t, err, datafx.ErrContextCanceled)
+
+	entries := lifecycleEntries(t, buf)
+	require.Len(t, entries, 6)
+
+	messages := make([]string, len(entries))
+	for i, entry := range entries {
+		assert.Equal(t, "DEBUG", entry["level"])
+		assert.Equal(t, "orders", entry["queue"])
+
+		messages[i], _ = entry["msg"].(string)
+	}
+
+	assert.Equal(t, []string{
+		"queue message published",
+		"queue message published",
+		"queue message consumed",
+		"queue message settled",
+		"queue message consumed",
+		"queue message settled",
+	}, messages)
+
+	// The first message carries its IDs through every entry
+	for _, entry := range []map[string]any{entries[0], entries[2], entries[3]} {
+		assert.Equal(t, "req-42", entry["correlation_id"])
+		assert.Equal(t, "order-7", entry["deduplication_id"])
+	}
+
+	assert.InDelta(t, float64(len(`{"name":"ok"}`)), entries[0]["size"], 0)
+	assert.NotContains(t, entries[1], "correlation_id")
+
+	assert.Equal(t, "1", entries[2]["message_id"])
+	assert.InDelta(t, 1, entries[2]["delivery_count"], 0)
+
+	assert.Equal(t, "1", entries[3]["message_id"])
+	assert.Equal(t, "acked", entries[3]["outcome"])
+	assert.Contains(t, entries[3], "duration")
+
+	assert.Equal(t, "2", entries[5]["message_id"])
+	assert.Equal(t, "dropped", entries[5]["outcome"])
+	assert.NotContains(t, entries[5], "correlation_id")
+}
+
+func TestQueue_LifecycleLogging_Disabled(t *testing.T) {
+	t.Parallel()
+
+	queue, buf := newLifecycleQueue(t, slog.LevelDebug)
+
+	require.NoError(t, queue.Publish(t.Context(), "orders", testEvent{Name: "ok"}))
+	assert.Empty(t, buf.String())
+
+	// The option logs at debug level only
+	queue, buf = newLifecycleQueue(t, slog.LevelInfo, datafx.WithLifecycleLogging())
+
+	require.NoError(t, queue.Publish(t.Context(), "orders", testEvent{Name: "ok"}))
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,226 @@
+package datafx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/eser/ajan/connfx"
+)
+
+// WeightedQueue names a queue consumed by ProcessMany along with its relative priority.
+type WeightedQueue struct {
+	// Name is the queue name
+	Name string
+	// Weight is the queue's share of processing when several queues have messages
+	// waiting (values below 1 are treated as 1)
+	Weight int
+}
+
+// weightedSource tracks a single queue's consumer inside ProcessMany.
+type weightedSource struct {
+	messages <-chan connfx.Message
+	errors   <-chan error
+	pending  *connfx.Message
+	name     string
+	weight   int
+	current  int
+	closed   bool
+}
+
+// ProcessMany consumes from several queues at once, processing messages like
+// ProcessMessages. When more than one queue has a message ready, queues are picked by
+// smooth weighted round-robin: a queue with weight 3 is served three times as often as
+// one with weight 1, and every queue with messages keeps making progress.
+// It returns when ctx is canceled, a consumer reports an error, or every queue closes.
+// config is validated for every queue before any consumer starts.
+func (q *Queue) ProcessMany(
+	ctx context.Context,
+	queues []WeightedQueue,
+	config connfx.ConsumerConfig,
+	messageHandler func(ctx context.Context, message any) bool,
+	messageType any,
+) error {
+	for _, queue := range queues {
+		if err := validateProcessConfig(queue.Name, config); err != nil {
+			return err
+		}
+	}
+
+	sources := make([]*weightedSource, len(queues))
+
+	for i, queue := range queues {
+		messages, errors := q.repository.Consume(ctx, queue.Name, config)
+
+		sources[i] = &weightedSource{
+			messages: messages,
+			errors:   errors,
+			pending:  nil,
+			name:     queue.Name,
+			weight:   max(queue.Weight, 1),
+			current:  0,
+			closed:   false,
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%w: %w", ErrContextCanceled, err)
+		}
+
+		if err := pollWeightedSources(sources); err != nil {
+			return err
+		}
+
+		source := pickWeightedSource(sources)
+		if source == nil {
+			// Nothing is ready yet; block until any queue delivers
+			done, err := waitWeightedSources(ctx, sources)
+			if err != nil || done {
+				return err
+			}
+
+			continue
+		}
+
+		msg := *source.pending
+		source.pending = nil
+
+		err := q.processMessage(
+			ctx,
+			source.name,
+			msg,
+			config,
+			ProcessOptions{
+				MaxRetries:      0,
+				DeadLetterQueue: "",
+			},
+			messageHandler,
+			messageType,
+		)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// pollWeightedSources takes a ready message, without blocking, from every open
+// source that has none pending.
+func pollWeightedSources(sources []*weightedSource) error {
+	for _, source := range sources {
+		if source.closed || source.pending != nil {
+			continue
+		}
+
+		select {
+		case err, ok := <-source.errors:
+			if !ok {
+				source.errors = nil
+
+				continue
+			}
+
+			if err != nil {
+				return fmt.Errorf("%w (queue=%q): %w", ErrMessageProcessing, source.name, err)
+			}
+		case msg, ok := <-source.messages:
+			if !ok {
+				source.closed = true
+
+				continue
+			}
+
+			source.pending = &msg
+		default:
+		}
+	}
+
+	return nil
+}
+
+// pickWeightedSource selects among sources with a pending message using smooth
+// weighted round-robin, or returns nil if none has one.
+func pickWeightedSource(sources []*weightedSource) *weightedSource {
+	var (
+		selected    *weightedSource
+		totalWeight int
+	)
+
+	for _, source := range sources {
+		if source.pending == nil {
+			continue
+		}
+
+		source.current += source.weight
+		totalWeight += source.weight
+
+		if selected == nil || source.current > selected.current {
+			selected = source
+		}
+	}
+
+	if selected != nil {
+		selected.current -= totalWeight
+	}
+
+	return selected
+}
+
+// waitWeightedSources blocks until any open source delivers a message, an error or
+// closes. It reports done once every source has closed.
+func waitWeightedSources(ctx context.Context, sources []*weightedSource) (bool, error) {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done()), Send: reflect.Value{}},
+	}
+	owners := []*weightedSource{nil}
+
+	for _, source := range sources {
+		if source.closed {
+			continue
+		}
+
+		cases = append(
+			cases,
+			reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(source.messages),
+				Send: reflect.Value{},
+			},
+			reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(source.errors),
+				Send: reflect.Value{},
+			},
+		)
+		owners = append(owners, source, source)
+	}
+
+	if len(owners) == 1 {
+		return true, nil
+	}
+
+	chosen, value, ok := reflect.Select(cases)
+	if chosen == 0 {
+		return true, fmt.Errorf("%w: %w", ErrContextCanceled, ctx.Err())
+	}
+
+	source := owners[chosen]
+	isErrorCase := chosen%2 == 0
+
+	switch {
+	case isErrorCase && !ok:
+		// A nil channel never becomes ready again
+		source.errors = nil
+	case isErrorCase:
+		if err, isErr := value.Interface().(error); isErr && err != nil {
+			return true, fmt.Errorf("%w (queue=%q): %w", ErrMessageProcessing, source.name, err)
+		}
+	case !ok:
+		source.closed = true
+	default:
+		msg, _ := value.Interface().(connfx.Message)
+		source.pending = &msg
+	}
+
+	return false, nil
+}
@@ -0,0 +1,85 @@
+package datafx_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue_ProcessMany_PrefersHigherWeights(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	queue, err := datafx.NewQueue(conn)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	const (
+		backlog   = 40
+		processed = 20
+	)
+
+	for i := range backlog {
+		require.NoError(t, queue.Publish(ctx, "high", testEvent{Name: "high-" + strconv.Itoa(i)}))
+		require.NoError(t, queue.Publish(ctx, "low", testEvent{Name: "low-" + strconv.Itoa(i)}))
+	}
+
+	var (
+		mu     sync.Mutex
+		counts = map[string]int{}
+		total  int
+	)
+
+	handler := func(handlerCtx context.Context, message any) bool {
+		event, _ := message.(map[string]any)
+		name, _ := event["name"].(string)
+
+		// Give consumers time to have their next message ready, keeping both queues loaded
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		queueName, _, _ := strings.Cut(name, "-")
+		counts[queueName]++
+		total++
+
+		if total == processed {
+			cancel()
+		}
+
+		return true
+	}
+
+	err = queue.ProcessMany(
+		ctx,
+		[]datafx.WeightedQueue{
+			{Name: "high", Weight: 3},
+			{Name: "low", Weight: 1},
+		},
+		connfx.DefaultConsumerConfig(),
+		handler,
+		nil,
+	)
+	require.ErrorIs(t, err, datafx.ErrContextCanceled)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, processed, counts["high"]+counts["low"])
+	assert.Greater(t, counts["high"], counts["low"])
+
+	// The lower weight queue is never starved while the higher one has a backlog
+	assert.Positive(t, counts["low"])
+}
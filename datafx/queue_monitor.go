@@ -0,0 +1,143 @@
+package datafx
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/metricsfx"
+)
+
+// instrumentHandler wraps a message handler to record its latency, the number of
+// messages in flight and the processing outcome, and to warn about slow handlers.
+func (q *Queue) instrumentHandler(
+	queueName string,
+	messageHandler func(ctx context.Context, message any) bool,
+) func(ctx context.Context, message any) bool {
+	if q.metrics == nil && q.slowHandlerThreshold <= 0 {
+		return messageHandler
+	}
+
+	return func(ctx context.Context, message any) bool {
+		attrs := []metricsfx.Attribute{
+			metricsfx.StringAttr("queue", queueName),
+			metricsfx.StringAttr("protocol", q.conn.GetProtocol()),
+		}
+
+		q.recordInFlight(ctx, queueName, 1, attrs)
+		defer q.recordInFlight(ctx, queueName, -1, attrs)
+
+		start := time.Now()
+		success := messageHandler(ctx, message)
+		elapsed := time.Since(start)
+
+		if q.metrics != nil {
+			if q.metrics.HandlerDuration != nil {
+				q.metrics.HandlerDuration.RecordDuration(ctx, elapsed, attrs...)
+			}
+
+			if q.metrics.MessagesProcessed != nil {
+				result := "ack"
+				if !success {
+					result = "nack"
+				}
+
+				q.metrics.MessagesProcessed.Inc(
+					ctx,
+					append(attrs, metricsfx.StringAttr("result", result))...,
+				)
+			}
+		}
+
+		if q.slowHandlerThreshold > 0 && elapsed > q.slowHandlerThreshold {
+			q.logger.WarnContext(
+				ctx,
+				"queue message handler exceeded latency threshold",
+				slog.String("queue", queueName),
+				slog.Duration("latency", elapsed),
+				slog.Duration("threshold", q.slowHandlerThreshold),
+			)
+		}
+
+		return success
+	}
+}
+
+func (q *Queue) recordInFlight(
+	ctx context.Context,
+	queueName string,
+	delta int64,
+	attrs []metricsfx.Attribute,
+) {
+	counter, _ := q.inFlight.LoadOrStore(queueName, &atomic.Int64{})
+	inFlight := counter.(*atomic.Int64).Add(delta) //nolint:forcetypeassert
+
+	if q.metrics != nil && q.metrics.MessagesInFlight != nil {
+		q.metrics.MessagesInFlight.Set(ctx, inFlight, attrs...)
+	}
+}
+
+// startLagChecks returns a channel ticking every lag check interval, or a nil channel
+// when lag checks are disabled or the backend doesn't expose consumer group lag.
+func (q *Queue) startLagChecks() (<-chan time.Time, func()) {
+	if q.lagCheckInterval <= 0 || !q.IsStreamSupported() {
+		return nil, func() {}
+	}
+
+	ticker := time.NewTicker(q.lagCheckInterval)
+
+	return ticker.C, ticker.Stop
+}
+
+// checkConsumerLag records how far a consumer group is behind its stream and warns when
+// the lag exceeds the configured threshold.
+func (q *Queue) checkConsumerLag(ctx context.Context, queueName string, consumerGroup string) {
+	streamRepo, ok := q.repository.(connfx.QueueStreamRepository)
+	if !ok {
+		return
+	}
+
+	groups, err := streamRepo.ConsumerGroupInfo(ctx, queueName)
+	if err != nil {
+		q.logger.WarnContext(
+			ctx,
+			"failed to check queue consumer lag",
+			slog.String("queue", queueName),
+			slog.String("group", consumerGroup),
+			slog.Any("error", err),
+		)
+
+		return
+	}
+
+	for _, group := range groups {
+		if group.Name != consumerGroup {
+			continue
+		}
+
+		if q.metrics != nil && q.metrics.ConsumerLag != nil {
+			q.metrics.ConsumerLag.Set(
+				ctx,
+				group.Lag,
+				metricsfx.StringAttr("queue", queueName),
+				metricsfx.StringAttr("group", consumerGroup),
+				metricsfx.StringAttr("protocol", q.conn.GetProtocol()),
+			)
+		}
+
+		if q.lagWarning > 0 && group.Lag > q.lagWarning {
+			q.logger.WarnContext(
+				ctx,
+				"queue consumer group is lagging behind",
+				slog.String("queue", queueName),
+				slog.String("group", consumerGroup),
+				slog.Int64("lag", group.Lag),
+				slog.Int64("threshold", q.lagWarning),
+			)
+		}
+
+		return
+	}
+}
@@ -0,0 +1,677 @@
+package datafx_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/eser/ajan/logfx"
+	"github.com/eser/ajan/metricsfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	Name string `json:"name"`
+}
+
+func TestQueue_ProcessMessages_VisibilityTimeout(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	queue, err := datafx.NewQueue(conn)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	err = queue.Publish(ctx, "events", testEvent{Name: "slow"})
+	require.NoError(t, err)
+
+	config := connfx.DefaultConsumerConfig()
+	config.VisibilityTimeout = 50 * time.Millisecond
+
+	var calls atomic.Int32
+
+	handler := func(handlerCtx context.Context, message any) bool {
+		if calls.Add(1) == 1 {
+			// First delivery exceeds the deadline
+			<-handlerCtx.Done()
+
+			return true
+		}
+
+		cancel()
+
+		return true
+	}
+
+	err = queue.ProcessMessages(ctx, "events", config, handler, nil)
+	require.ErrorIs(t, err, datafx.ErrContextCanceled)
+
+	acked, requeued, dropped := conn.counts("1")
+
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, 1, requeued)
+	assert.Equal(t, 1, acked)
+	assert.Zero(t, dropped)
+}
+
+func TestQueue_ProcessMessagesWithOptions_VisibilityTimeoutCountsAsFailure(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	queue, err := datafx.NewQueue(conn)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	require.NoError(t, queue.Publish(ctx, "events", testEvent{Name: "stuck"}))
+
+	deadLetters, _ := conn.Consume(ctx, "events.dlq", connfx.DefaultConsumerConfig())
+
+	config := connfx.DefaultConsumerConfig()
+	config.VisibilityTimeout = 20 * time.Millisecond
+
+	// Every delivery exceeds the deadline
+	handler := func(handlerCtx context.Context, message any) bool {
+		<-handlerCtx.Done()
+
+		return true
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- queue.ProcessMessagesWithOptions(
+			ctx,
+			"events",
+			config,
+			datafx.ProcessOptions{MaxRetries: 2, DeadLetterQueue: "events.dlq"},
+			handler,
+			&testEvent{},
+		)
+	}()
+
+	select {
+	case msg := <-deadLetters:
+		assert.Equal(t, 2, msg.Headers[datafx.DeathCountHeader])
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out message was not dead-lettered")
+	}
+
+	cancel()
+	require.ErrorIs(t, <-done, datafx.ErrContextCanceled)
+}
+
+func TestQueue_ProcessMessages_VisibilityTimeoutWithAutoAck(t *testing.T) {
+	t.Parallel()
+
+	queue, err := datafx.NewQueue(newMemoryConnection())
+	require.NoError(t, err)
+
+	config := connfx.DefaultConsumerConfig()
+	config.AutoAck = true
+	config.VisibilityTimeout = time.Second
+
+	handler := func(handlerCtx context.Context, message any) bool {
+		return true
+	}
+
+	err = queue.ProcessMessages(t.Context(), "events", config, handler, nil)
+	require.ErrorIs(t, err, datafx.ErrInvalidConsumerConfig)
+
+	err = queue.ProcessMessagesWithGroup(
+		t.Context(),
+		"events",
+		"group",
+		"consumer",
+		config,
+		handler,
+		nil,
+	)
+	require.ErrorIs(t, err, datafx.ErrInvalidConsumerConfig)
+
+	err = queue.ProcessMany(
+		t.Context(),
+		[]datafx.WeightedQueue{{Name: "events", Weight: 1}, {Name: "audit", Weight: 1}},
+		config,
+		handler,
+		nil,
+	)
+	require.ErrorIs(t, err, datafx.ErrInvalidConsumerConfig)
+}
+
+func TestQueue_ProcessMessages_WithinVisibilityTimeout(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	queue, err := datafx.NewQueue(conn)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	err = queue.Publish(ctx, "events", testEvent{Name: "fast"})
+	require.NoError(t, err)
+
+	config := connfx.DefaultConsumerConfig()
+	config.VisibilityTimeout = time.Second
+
+	handler := func(handlerCtx context.Context, message any) bool {
+		cancel()
+
+		return true
+	}
+
+	err = queue.ProcessMessages(ctx, "events", config, handler, nil)
+	require.ErrorIs(t, err, datafx.ErrContextCanceled)
+
+	acked, requeued, _ := conn.counts("1")
+
+	assert.Equal(t, 1, acked)
+	assert.Zero(t, requeued)
+}
+
+func TestQueue_ProcessMessages_HeaderFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		configure func(config *connfx.ConsumerConfig)
+		name      string
+	}{
+		{
+			name: "header match",
+			configure: func(config *connfx.ConsumerConfig) {
+				config.HeaderFilter = map[string]string{"event_type": "user_login"}
+			},
+		},
+		{
+			name: "predicate",
+			configure: func(config *connfx.ConsumerConfig) {
+				config.Filter = func(msg *connfx.Message) bool {
+					return msg.Headers["event_type"] == "user_login"
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := newMemoryConnection()
+
+			queue, err := datafx.NewQueue(conn)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			published := []struct {
+				eventType string
+				name      string
+			}{
+				{eventType: "user_login", name: "first"},
+				{eventType: "user_logout", name: "skipped"},
+				{eventType: "user_login", name: "second"},
+			}
+
+			for _, event := range published {
+				err = queue.PublishWithHeaders(
+					ctx,
+					"events",
+					testEvent{Name: event.name},
+					map[string]any{"event_type": event.eventType},
+				)
+				require.NoError(t, err)
+			}
+
+			config := connfx.DefaultConsumerConfig()
+			tt.configure(&config)
+
+			var received []string
+
+			handler := func(handlerCtx context.Context, message any) bool {
+				event, ok := message.(*testEvent)
+				require.True(t, ok)
+
+				received = append(received, event.Name)
+				if len(received) == 2 {
+					cancel()
+				}
+
+				return true
+			}
+
+			err = queue.ProcessMessages(ctx, "events", config, handler, &testEvent{})
+			require.ErrorIs(t, err, datafx.ErrContextCanceled)
+
+			assert.Equal(t, []string{"first", "second"}, received)
+
+			// The filtered-out message is acknowledged, not redelivered
+			acked, requeued, dropped := conn.counts("2")
+			assert.Equal(t, 1, acked)
+			assert.Zero(t, requeued)
+			assert.Zero(t, dropped)
+		})
+	}
+}
+
+func TestQueue_Publish_MessageSizeWarning(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger := logfx.NewLogger(logfx.WithFromSlog(slog.New(slog.NewTextHandler(&buf, nil))))
+	conn := newMemoryConnection()
+
+	queue, err := datafx.NewQueue(
+		conn,
+		datafx.WithLogger(logger),
+		datafx.WithMessageSizeWarning(64),
+		datafx.WithQueueMetrics(newTestQueueMetrics(t)),
+	)
+	require.NoError(t, err)
+
+	err = queue.Publish(t.Context(), "events", testEvent{Name: "small"})
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+
+	err = queue.Publish(t.Context(), "events", testEvent{Name: strings.Repeat("x", 100)})
+	require.NoError(t, err)
+
+	// Oversized messages are still published, with a warning
+	assert.Len(t, conn.queue("events"), 2)
+	assert.Contains(t, buf.String(), "level=WARN")
+	assert.Contains(t, buf.String(), "queue message exceeds size warning threshold")
+	assert.Contains(t, buf.String(), "queue=events")
+	assert.Contains(t, buf.String(), "threshold=64")
+}
+
+func TestQueue_Publish_MaxMessageSize(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	queue, err := datafx.NewQueue(conn, datafx.WithMaxMessageSize(64))
+	require.NoError(t, err)
+
+	err = queue.Publish(t.Context(), "events", testEvent{Name: strings.Repeat("x", 100)})
+	require.ErrorIs(t, err, datafx.ErrMessageTooLarge)
+
+	err = queue.PublishRaw(t.Context(), "events", bytes.Repeat([]byte("x"), 65))
+	require.ErrorIs(t, err, datafx.ErrMessageTooLarge)
+
+	// Messages at the cap are accepted
+	err = queue.PublishRaw(t.Context(), "events", bytes.Repeat([]byte("x"), 64))
+	require.NoError(t, err)
+
+	assert.Len(t, conn.queue("events"), 1)
+}
+
+func TestQueue_PublishValidated(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	queue, err := datafx.NewQueue(conn)
+	require.NoError(t, err)
+
+	// Without a registered validator, the struct tags are checked
+	invalid := validOrder()
+	invalid.Currency = "GBP"
+
+	err = queue.PublishValidated(t.Context(), "orders", invalid)
+	require.ErrorIs(t, err, datafx.ErrQueueOperation)
+	require.ErrorIs(t, err, datafx.ErrValidationFailed)
+	assert.Empty(t, conn.queue("orders"))
+
+	require.NoError(t, queue.PublishValidated(t.Context(), "orders", validOrder()))
+	assert.Len(t, conn.queue("orders"), 1)
+
+	errUnnamed := errors.New("event has no name")
+
+	queue.SetValidator(func(message any) error {
+		if event, ok := message.(testEvent); ok && event.Name == "" {
+			return errUnnamed
+		}
+
+		return nil
+	})
+
+	err = queue.PublishValidated(t.Context(), "events", testEvent{Name: ""})
+	require.ErrorIs(t, err, datafx.ErrQueueOperation)
+	require.ErrorIs(t, err, errUnnamed)
+
+	// The registered validator replaces the struct tags
+	require.NoError(t, queue.PublishValidated(t.Context(), "orders", invalid))
+	require.NoError(t, queue.PublishValidated(t.Context(), "events", testEvent{Name: "ok"}))
+	assert.Len(t, conn.queue("orders"), 2)
+	assert.Len(t, conn.queue("events"), 1)
+
+	queue.SetValidator(nil)
+
+	err = queue.PublishValidated(t.Context(), "orders", invalid)
+	require.ErrorIs(t, err, datafx.ErrValidationFailed)
+
+	// Publish itself never validates
+	require.NoError(t, queue.Publish(t.Context(), "orders", invalid))
+}
+
+func newTestQueueMetrics(t *testing.T) *datafx.QueueMetrics {
+	t.Helper()
+
+	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{
+		ServiceName:                   "test-service",
+		ServiceVersion:                "1.0.0",
+		ServiceInstanceID:             "",
+		OTLPConnectionName:            "",
+		ExportInterval:                30 * time.Second,
+		NoNativeCollectorRegistration: true,
+		RequireNativeCollectors:       false,
+	}, nil)
+	require.NoError(t, provider.Init())
+
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+	})
+
+	metrics := datafx.NewQueueMetrics(provider)
+	require.NoError(t, metrics.Init())
+
+	return metrics
+}
+
+func TestQueue_ProcessMessages_DeserializeError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		hookResult  bool
+		withHook    bool
+		wantAcked   int
+		wantDropped int
+	}{
+		{name: "default_drops", withHook: false, hookResult: false, wantAcked: 0, wantDropped: 1},
+		{name: "hook_acks", withHook: true, hookResult: true, wantAcked: 1, wantDropped: 0},
+		{name: "hook_drops", withHook: true, hookResult: false, wantAcked: 0, wantDropped: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				hookCalls int
+				hookRaw   []byte
+				hookErr   error
+			)
+
+			metrics, provider, exporter := newRecordingQueueMetrics(t)
+			opts := []datafx.Option{datafx.WithQueueMetrics(metrics)}
+
+			if tt.withHook {
+				opts = append(opts, datafx.WithDeserializeErrorHandler(
+					func(ctx context.Context, raw []byte, err error) bool {
+						hookCalls++
+						hookRaw = raw
+						hookErr = err
+
+						return tt.hookResult
+					},
+				))
+			}
+
+			conn := newMemoryConnection()
+
+			queue, err := datafx.NewQueue(conn, opts...)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			require.NoError(t, queue.PublishRaw(ctx, "events", []byte("{not json")))
+			require.NoError(t, queue.Publish(ctx, "events", testEvent{Name: "valid"}))
+
+			var handled []any
+
+			handler := func(handlerCtx context.Context, message any) bool {
+				// Processing continues past the malformed message
+				handled = append(handled, message)
+
+				cancel()
+
+				return true
+			}
+
+			err = queue.ProcessMessages(ctx, "events", connfx.DefaultConsumerConfig(), handler, nil)
+			require.ErrorIs(t, err, datafx.ErrContextCanceled)
+			assert.Len(t, handled, 1)
+
+			acked, requeued, dropped := conn.counts("1")
+			assert.Equal(t, tt.wantAcked, acked)
+			assert.Zero(t, requeued)
+			assert.Equal(t, tt.wantDropped, dropped)
+
+			if tt.withHook {
+				assert.Equal(t, 1, hookCalls)
+				assert.Equal(t, []byte("{not json"), hookRaw)
+				assert.Error(t, hookErr)
+			}
+
+			require.NoError(t, provider.Shutdown(t.Context()))
+
+			deserializeErrors, ok := exporter.value("queue_deserialize_errors_total")
+			require.True(t, ok)
+			assert.InDelta(t, 1, deserializeErrors.value, 0)
+		})
+	}
+}
+
+// deadLetterConnection records the dead-letter declarations it receives.
+type deadLetterConnection struct {
+	*memoryConnection
+
+	declared map[string]connfx.DeadLetterConfig
+}
+
+func (c *deadLetterConnection) GetRawConnection() any {
+	return c
+}
+
+func (c *deadLetterConnection) QueueDeclareWithDeadLetter(
+	ctx context.Context,
+	name string,
+	config connfx.DeadLetterConfig,
+) (string, error) {
+	c.declared[name] = config
+
+	return name, nil
+}
+
+func TestQueue_DeclareQueueWithDLQ(t *testing.T) {
+	t.Parallel()
+
+	t.Run("delegates to the connection", func(t *testing.T) {
+		t.Parallel()
+
+		conn := &deadLetterConnection{
+			memoryConnection: newMemoryConnection(),
+			declared:         map[string]connfx.DeadLetterConfig{},
+		}
+
+		queue, err := datafx.NewQueue(conn)
+		require.NoError(t, err)
+
+		config := connfx.DeadLetterConfig{ //nolint:exhaustruct
+			Exchange: "orders.failed",
+		}
+
+		name, err := queue.DeclareQueueWithDLQ(t.Context(), "orders", config)
+		require.NoError(t, err)
+		assert.Equal(t, "orders", name)
+		assert.Equal(t, config, conn.declared["orders"])
+	})
+
+	t.Run("unsupported connection", func(t *testing.T) {
+		t.Parallel()
+
+		queue, err := datafx.NewQueue(newMemoryConnection())
+		require.NoError(t, err)
+
+		_, err = queue.DeclareQueueWithDLQ(t.Context(), "orders", connfx.DeadLetterConfig{}) //nolint:exhaustruct
+		require.ErrorIs(t, err, datafx.ErrDLQNotSupported)
+	})
+}
+
+func TestQueue_ProcessMessagesWithOptions_DeadLetter(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	tests := []struct {
+		headers          map[string]any
+		name             string
+		expectedAttempts int32
+	}{
+		{
+			name:             "fresh message",
+			headers:          map[string]any{"tenant": "acme"},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "attempts carried over from another backend",
+			headers:          map[string]any{"tenant": "acme", datafx.DeathCountHeader: "2"},
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := newMemoryConnection()
+
+			queue, err := datafx.NewQueue(conn)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			body := []byte(`{"name":"poison"}`)
+			require.NoError(t, queue.PublishRawWithHeaders(ctx, "events", body, tt.headers))
+
+			deadLetters, _ := conn.Consume(ctx, "events.dlq", connfx.DefaultConsumerConfig())
+
+			var attempts atomic.Int32
+
+			handler := func(handlerCtx context.Context, message any) bool {
+				attempts.Add(1)
+
+				return false
+			}
+
+			done := make(chan error, 1)
+
+			go func() {
+				done <- queue.ProcessMessagesWithOptions(
+					ctx,
+					"events",
+					connfx.DefaultConsumerConfig(),
+					datafx.ProcessOptions{MaxRetries: 3, DeadLetterQueue: "events.dlq"},
+					handler,
+					&testEvent{},
+				)
+			}()
+
+			select {
+			case msg := <-deadLetters:
+				assert.JSONEq(t, `{"name":"poison"}`, string(msg.Body))
+				assert.Equal(t, "acme", msg.Headers["tenant"])
+				assert.Equal(t, 3, msg.Headers[datafx.DeathCountHeader])
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "message was not dead-lettered")
+			}
+
+			cancel()
+			require.ErrorIs(t, <-done, datafx.ErrContextCanceled)
+
+			assert.Equal(t, tt.expectedAttempts, attempts.Load())
+
+			// Every failed delivery was replaced by a republished one, never requeued
+			for id := range tt.expectedAttempts {
+				acked, requeued, dropped := conn.counts(strconv.Itoa(int(id) + 1))
+				assert.Equal(t, 1, acked)
+				assert.Zero(t, requeued)
+				assert.Zero(t, dropped)
+			}
+
+			// The publisher's headers are left untouched
+			assert.NotEqual(t, 3, tt.headers[datafx.DeathCountHeader])
+		})
+	}
+}
+
+func TestQueue_ProcessMessagesWithOptions_WithoutDeadLetterQueue(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	queue, err := datafx.NewQueue(conn)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	require.NoError(t, queue.Publish(ctx, "events", testEvent{Name: "poison"}))
+
+	handler := func(handlerCtx context.Context, message any) bool {
+		event, ok := message.(*testEvent)
+		require.True(t, ok)
+
+		if event.Name == "done" {
+			cancel()
+
+			return true
+		}
+
+		return false
+	}
+
+	go func() {
+		// Lets processing stop once the poison message is gone
+		assert.Eventually(t, func() bool {
+			_, _, dropped := conn.counts("2")
+
+			return dropped == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		assert.NoError(t, queue.Publish(ctx, "events", testEvent{Name: "done"}))
+	}()
+
+	err = queue.ProcessMessagesWithOptions(
+		ctx,
+		"events",
+		connfx.DefaultConsumerConfig(),
+		datafx.ProcessOptions{MaxRetries: 2, DeadLetterQueue: ""},
+		handler,
+		&testEvent{},
+	)
+	require.ErrorIs(t, err, datafx.ErrContextCanceled)
+
+	// The first failure is retried, the second one drops the message
+	acked, requeued, _ := conn.counts("1")
+	assert.Equal(t, 1, acked)
+	assert.Zero(t, requeued)
+}
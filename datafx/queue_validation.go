@@ -0,0 +1,44 @@
+package datafx
+
+import (
+	"context"
+	"fmt"
+)
+
+// MessageValidator checks a message before it is published, returning why it is invalid.
+type MessageValidator func(message any) error
+
+// SetValidator registers the validator PublishValidated runs. Without one, or after
+// SetValidator(nil), messages are checked against their "validate" struct tags with
+// ValidateStruct.
+func (q *Queue) SetValidator(validator MessageValidator) {
+	if validator == nil {
+		q.validator.Store(nil)
+
+		return
+	}
+
+	q.validator.Store(&validator)
+}
+
+// PublishValidated publishes a message like Publish, once it passes the registered
+// validator, so malformed messages are rejected by the producer instead of failing at
+// the consumer. A rejected message is not published; the error wraps ErrQueueOperation
+// along with the validator's error.
+func (q *Queue) PublishValidated(ctx context.Context, queueName string, message any) error {
+	validate := ValidateStruct
+	if validator := q.validator.Load(); validator != nil {
+		validate = *validator
+	}
+
+	if err := validate(message); err != nil {
+		return fmt.Errorf(
+			"%w (operation=validate, queue=%q): %w",
+			ErrQueueOperation,
+			queueName,
+			err,
+		)
+	}
+
+	return q.Publish(ctx, queueName, message)
+}
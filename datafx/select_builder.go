@@ -0,0 +1,191 @@
+package datafx
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PlaceholderStyle defines how bind parameters are rendered in generated SQL.
+type PlaceholderStyle int
+
+const (
+	// PlaceholderQuestion renders parameters as "?" (SQLite, MySQL).
+	PlaceholderQuestion PlaceholderStyle = iota
+	// PlaceholderDollar renders parameters as "$1", "$2", ... (PostgreSQL).
+	PlaceholderDollar
+)
+
+// SelectBuilder builds parameterized SELECT statements with dynamic filters.
+//
+// Values are never interpolated into the SQL string; they are returned as bind
+// arguments. Table names, columns and ORDER BY expressions are written verbatim,
+// so they must come from trusted input (e.g. an allowlist), never from users.
+type SelectBuilder struct {
+	table       string
+	columns     []string
+	conditions  []string
+	args        []any
+	orderBy     []string
+	limit       int
+	offset      int
+	placeholder PlaceholderStyle
+}
+
+// NewSelectBuilder creates a SelectBuilder for the given columns ("*" when empty).
+func NewSelectBuilder(columns ...string) *SelectBuilder {
+	return &SelectBuilder{
+		table:       "",
+		columns:     columns,
+		conditions:  make([]string, 0),
+		args:        make([]any, 0),
+		orderBy:     make([]string, 0),
+		limit:       0,
+		offset:      0,
+		placeholder: PlaceholderQuestion,
+	}
+}
+
+// WithPlaceholder sets the placeholder style used when building the statement.
+func (b *SelectBuilder) WithPlaceholder(style PlaceholderStyle) *SelectBuilder {
+	b.placeholder = style
+
+	return b
+}
+
+// From sets the table to select from.
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.table = table
+
+	return b
+}
+
+// Where adds a condition using "?" markers for its arguments. Multiple conditions
+// are combined with AND.
+func (b *SelectBuilder) Where(condition string, args ...any) *SelectBuilder {
+	b.conditions = append(b.conditions, condition)
+	b.args = append(b.args, args...)
+
+	return b
+}
+
+// OrderBy appends ORDER BY expressions such as "created_at DESC".
+func (b *SelectBuilder) OrderBy(expressions ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, expressions...)
+
+	return b
+}
+
+// Limit sets the maximum number of rows to return (0 = no limit).
+func (b *SelectBuilder) Limit(n int) *SelectBuilder {
+	b.limit = n
+
+	return b
+}
+
+// Offset sets the number of rows to skip (0 = no offset).
+func (b *SelectBuilder) Offset(m int) *SelectBuilder {
+	b.offset = m
+
+	return b
+}
+
+// Build renders the SQL statement and its bind arguments in order.
+func (b *SelectBuilder) Build() (string, []any) {
+	var sql strings.Builder
+
+	args := make([]any, 0, len(b.args)+2) //nolint:mnd
+	args = append(args, b.args...)
+
+	sql.WriteString("SELECT ")
+
+	if len(b.columns) == 0 {
+		sql.WriteString("*")
+	} else {
+		sql.WriteString(strings.Join(b.columns, ", "))
+	}
+
+	sql.WriteString(" FROM ")
+	sql.WriteString(b.table)
+
+	if len(b.conditions) > 0 {
+		sql.WriteString(" WHERE ")
+
+		for i, condition := range b.conditions {
+			if i > 0 {
+				sql.WriteString(" AND ")
+			}
+
+			if len(b.conditions) > 1 {
+				sql.WriteString("(" + condition + ")")
+
+				continue
+			}
+
+			sql.WriteString(condition)
+		}
+	}
+
+	if len(b.orderBy) > 0 {
+		sql.WriteString(" ORDER BY ")
+		sql.WriteString(strings.Join(b.orderBy, ", "))
+	}
+
+	if b.limit > 0 {
+		sql.WriteString(" LIMIT ?")
+
+		args = append(args, b.limit)
+	}
+
+	if b.offset > 0 {
+		// SQLite and MySQL only accept OFFSET after a LIMIT, so an offset on its own
+		// gets the largest limit both of them accept. PostgreSQL needs none.
+		if b.limit <= 0 && b.placeholder != PlaceholderDollar {
+			sql.WriteString(" LIMIT " + strconv.FormatInt(math.MaxInt64, 10))
+		}
+
+		sql.WriteString(" OFFSET ?")
+
+		args = append(args, b.offset)
+	}
+
+	return b.renderPlaceholders(sql.String()), args
+}
+
+// renderPlaceholders rewrites "?" markers into the configured placeholder style. Markers
+// inside quoted literals or identifiers (doubled quotes escape a quote) are left as is.
+func (b *SelectBuilder) renderPlaceholders(query string) string {
+	if b.placeholder != PlaceholderDollar {
+		return query
+	}
+
+	var (
+		rendered strings.Builder
+		quote    rune
+	)
+
+	index := 0
+
+	for _, char := range query {
+		switch {
+		case quote != 0:
+			// A doubled quote closes and immediately reopens the literal, which keeps
+			// the state in step without special-casing the escape
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'', char == '"', char == '`':
+			quote = char
+		case char == '?':
+			index++
+
+			rendered.WriteString("$" + strconv.Itoa(index))
+
+			continue
+		}
+
+		rendered.WriteRune(char)
+	}
+
+	return rendered.String()
+}
@@ -0,0 +1,130 @@
+package datafx_test
+
+import (
+	"testing"
+
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		build        func() *datafx.SelectBuilder
+		name         string
+		expectedSQL  string
+		expectedArgs []any
+	}{
+		{
+			name: "all_columns",
+			build: func() *datafx.SelectBuilder {
+				return datafx.NewSelectBuilder().From("users")
+			},
+			expectedSQL:  "SELECT * FROM users",
+			expectedArgs: []any{},
+		},
+		{
+			name: "single_condition",
+			build: func() *datafx.SelectBuilder {
+				return datafx.NewSelectBuilder("id", "name").
+					From("users").
+					Where("status = ?", "active")
+			},
+			expectedSQL:  "SELECT id, name FROM users WHERE status = ?",
+			expectedArgs: []any{"active"},
+		},
+		{
+			name: "multiple_conditions_keep_argument_order",
+			build: func() *datafx.SelectBuilder {
+				return datafx.NewSelectBuilder("id").
+					From("users").
+					Where("status = ?", "active").
+					Where("age BETWEEN ? AND ?", 18, 65).
+					Where("name = ? OR email = ?", "x", "y")
+			},
+			expectedSQL: "SELECT id FROM users WHERE (status = ?) AND (age BETWEEN ? AND ?) " +
+				"AND (name = ? OR email = ?)",
+			expectedArgs: []any{"active", 18, 65, "x", "y"},
+		},
+		{
+			name: "order_limit_offset",
+			build: func() *datafx.SelectBuilder {
+				return datafx.NewSelectBuilder("id").
+					From("users").
+					Where("status = ?", "active").
+					OrderBy("created_at DESC", "id").
+					Limit(10).
+					Offset(20)
+			},
+			expectedSQL:  "SELECT id FROM users WHERE status = ? ORDER BY created_at DESC, id LIMIT ? OFFSET ?",
+			expectedArgs: []any{"active", 10, 20},
+		},
+		{
+			name: "dollar_placeholders",
+			build: func() *datafx.SelectBuilder {
+				return datafx.NewSelectBuilder("id").
+					WithPlaceholder(datafx.PlaceholderDollar).
+					From("users").
+					Where("status = ?", "active").
+					Where("age > ?", 18).
+					Limit(5)
+			},
+			expectedSQL:  "SELECT id FROM users WHERE (status = $1) AND (age > $2) LIMIT $3",
+			expectedArgs: []any{"active", 18, 5},
+		},
+		{
+			name: "offset_without_limit",
+			build: func() *datafx.SelectBuilder {
+				return datafx.NewSelectBuilder("id").From("users").Offset(20)
+			},
+			expectedSQL:  "SELECT id FROM users LIMIT 9223372036854775807 OFFSET ?",
+			expectedArgs: []any{20},
+		},
+		{
+			name: "offset_without_limit_dollar_placeholders",
+			build: func() *datafx.SelectBuilder {
+				return datafx.NewSelectBuilder("id").
+					WithPlaceholder(datafx.PlaceholderDollar).
+					From("users").
+					Offset(20)
+			},
+			expectedSQL:  "SELECT id FROM users OFFSET $1",
+			expectedArgs: []any{20},
+		},
+		{
+			name: "dollar_placeholders_skip_quoted_literals",
+			build: func() *datafx.SelectBuilder {
+				return datafx.NewSelectBuilder("id").
+					WithPlaceholder(datafx.PlaceholderDollar).
+					From("users").
+					Where("note <> 'why?' AND \"what?\" = ?", "x").
+					Where("title <> 'it''s ?' AND age > ?", 18)
+			},
+			expectedSQL: "SELECT id FROM users WHERE (note <> 'why?' AND \"what?\" = $1) " +
+				"AND (title <> 'it''s ?' AND age > $2)",
+			expectedArgs: []any{"x", 18},
+		},
+		{
+			name: "values_are_not_interpolated",
+			build: func() *datafx.SelectBuilder {
+				return datafx.NewSelectBuilder().
+					From("users").
+					Where("name = ?", "'; DROP TABLE users; --")
+			},
+			expectedSQL:  "SELECT * FROM users WHERE name = ?",
+			expectedArgs: []any{"'; DROP TABLE users; --"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sql, args := tt.build().Build()
+
+			assert.Equal(t, tt.expectedSQL, sql)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
@@ -2,31 +2,38 @@ package datafx
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/lib"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	ErrConnectionNotSupported = errors.New("connection does not support required operations")
-	ErrKeyNotFound            = errors.New("key not found")
+	ErrKeyNotFound            = lib.NewCategorizedError(lib.ErrCategoryNotFound, "key not found")
 	ErrFailedToMarshal        = errors.New("failed to marshal data")
 	ErrFailedToUnmarshal      = errors.New("failed to unmarshal data")
 	ErrInvalidData            = errors.New("invalid data")
 	ErrRepositoryOperation    = errors.New("repository operation failed")
+	ErrCASNotSupported        = errors.New("connection does not support compare-and-swap")
+	ErrScanNotSupported       = errors.New("connection does not support key scans")
+	ErrEmptyKeyPrefix         = errors.New("key prefix must not be empty")
 )
 
 // Store provides high-level data persistence operations.
 type Store struct {
 	conn       connfx.Connection
 	repository connfx.Repository
+	codec      Codec
+	tracer     operationTracer
+	namespace  keyNamespace
 }
 
 // New creates a new Store instance from a connfx connection.
 // The connection must support data repository operations.
-func NewStore(conn connfx.Connection) (*Store, error) {
+func NewStore(conn connfx.Connection, opts ...Option) (*Store, error) {
 	if conn == nil {
 		return nil, fmt.Errorf("%w: connection is nil", ErrConnectionNotSupported)
 	}
@@ -50,8 +57,13 @@ func NewStore(conn connfx.Connection) (*Store, error) {
 			ErrConnectionNotSupported, conn.GetProtocol())
 	}
 
-	// Get the repository from the raw connection
+	// Get the repository from the raw connection, or from the connection itself for
+	// adapters exposing their native driver handle as the raw connection (SQL)
 	repo, ok := conn.GetRawConnection().(connfx.Repository)
+	if !ok {
+		repo, ok = connfx.UnwrapConnection(conn).(connfx.Repository)
+	}
+
 	if !ok {
 		return nil, fmt.Errorf(
 			"%w: connection does not implement Repository interface (protocol=%q)",
@@ -60,117 +72,343 @@ func NewStore(conn connfx.Connection) (*Store, error) {
 		)
 	}
 
+	options := newOptions(opts)
+
+	tracer, err := newOperationTracer(conn, options)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Store{
 		conn:       conn,
 		repository: repo,
+		codec:      options.codec,
+		tracer:     tracer,
+		namespace:  keyNamespace(options.keyPrefix),
 	}, nil
 }
 
-// Get retrieves a value by key and unmarshals it into the provided destination.
+// Get retrieves a value by key and decodes it into the provided destination.
 func (s *Store) Get(ctx context.Context, key string, dest any) error {
-	data, err := s.repository.Get(ctx, key)
-	if err != nil {
-		return fmt.Errorf("%w (operation=get, key=%q): %w", ErrRepositoryOperation, key, err)
-	}
-
-	if data == nil {
-		return fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
-	}
-
-	if err := json.Unmarshal(data, dest); err != nil {
-		return fmt.Errorf("%w (key=%q): %w", ErrFailedToUnmarshal, key, err)
-	}
-
-	return nil
+	return s.tracer.run(
+		ctx,
+		"store.get",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			data, err := s.repository.Get(ctx, s.namespace.key(key))
+			if err != nil {
+				return fmt.Errorf("%w (operation=get, key=%q): %w", ErrRepositoryOperation, key, err)
+			}
+
+			if data == nil {
+				return fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
+			}
+
+			if err := s.codec.Unmarshal(data, dest); err != nil {
+				return fmt.Errorf("%w (key=%q): %w", ErrFailedToUnmarshal, key, err)
+			}
+
+			return nil
+		},
+	)
 }
 
 // GetRaw retrieves raw bytes by key.
 func (s *Store) GetRaw(ctx context.Context, key string) ([]byte, error) {
-	data, err := s.repository.Get(ctx, key)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"%w (operation=get_raw, key=%q): %w",
-			ErrRepositoryOperation,
-			key,
-			err,
-		)
-	}
-
-	if data == nil {
-		return nil, fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
-	}
-
-	return data, nil
+	return traceOperation(
+		ctx,
+		s.tracer,
+		"store.get_raw",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) ([]byte, error) {
+			data, err := s.repository.Get(ctx, s.namespace.key(key))
+			if err != nil {
+				return nil, fmt.Errorf(
+					"%w (operation=get_raw, key=%q): %w",
+					ErrRepositoryOperation,
+					key,
+					err,
+				)
+			}
+
+			if data == nil {
+				return nil, fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
+			}
+
+			return data, nil
+		},
+	)
 }
 
-// Set stores a value with the given key after marshaling it to JSON.
+// Set stores a value with the given key after encoding it with the store codec.
 func (s *Store) Set(ctx context.Context, key string, value any) error {
-	data, err := json.Marshal(value)
-	if err != nil {
-		return fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
-	}
-
-	if err := s.repository.Set(ctx, key, data); err != nil {
-		return fmt.Errorf("%w (operation=set, key=%q): %w", ErrRepositoryOperation, key, err)
-	}
-
-	return nil
+	return s.tracer.run(
+		ctx,
+		"store.set",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			data, err := s.codec.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
+			}
+
+			if err := s.repository.Set(ctx, s.namespace.key(key), data); err != nil {
+				return fmt.Errorf("%w (operation=set, key=%q): %w", ErrRepositoryOperation, key, err)
+			}
+
+			return nil
+		},
+	)
 }
 
 // SetRaw stores raw bytes with the given key.
 func (s *Store) SetRaw(ctx context.Context, key string, value []byte) error {
-	if err := s.repository.Set(ctx, key, value); err != nil {
-		return fmt.Errorf("%w (operation=set_raw, key=%q): %w", ErrRepositoryOperation, key, err)
-	}
-
-	return nil
+	return s.tracer.run(
+		ctx,
+		"store.set_raw",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			if err := s.repository.Set(ctx, s.namespace.key(key), value); err != nil {
+				return fmt.Errorf(
+					"%w (operation=set_raw, key=%q): %w",
+					ErrRepositoryOperation,
+					key,
+					err,
+				)
+			}
+
+			return nil
+		},
+	)
 }
 
-// Update updates an existing value by key after marshaling it to JSON.
+// Update updates an existing value by key after encoding it with the store codec.
 func (s *Store) Update(ctx context.Context, key string, value any) error {
-	data, err := json.Marshal(value)
-	if err != nil {
-		return fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
-	}
-
-	if err := s.repository.Update(ctx, key, data); err != nil {
-		return fmt.Errorf("%w (operation=update, key=%q): %w", ErrRepositoryOperation, key, err)
-	}
-
-	return nil
+	return s.tracer.run(
+		ctx,
+		"store.update",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			data, err := s.codec.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
+			}
+
+			if err := s.repository.Update(ctx, s.namespace.key(key), data); err != nil {
+				return fmt.Errorf(
+					"%w (operation=update, key=%q): %w",
+					ErrRepositoryOperation,
+					key,
+					err,
+				)
+			}
+
+			return nil
+		},
+	)
 }
 
 // UpdateRaw updates an existing value with raw bytes by key.
 func (s *Store) UpdateRaw(ctx context.Context, key string, value []byte) error {
-	if err := s.repository.Update(ctx, key, value); err != nil {
-		return fmt.Errorf("%w (operation=update_raw, key=%q): %w", ErrRepositoryOperation, key, err)
-	}
-
-	return nil
+	return s.tracer.run(
+		ctx,
+		"store.update_raw",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			if err := s.repository.Update(ctx, s.namespace.key(key), value); err != nil {
+				return fmt.Errorf(
+					"%w (operation=update_raw, key=%q): %w",
+					ErrRepositoryOperation,
+					key,
+					err,
+				)
+			}
+
+			return nil
+		},
+	)
 }
 
 // Remove deletes a value by key.
 func (s *Store) Remove(ctx context.Context, key string) error {
-	if err := s.repository.Remove(ctx, key); err != nil {
-		return fmt.Errorf("%w (operation=remove, key=%q): %w", ErrRepositoryOperation, key, err)
-	}
-
-	return nil
+	return s.tracer.run(
+		ctx,
+		"store.remove",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) error {
+			if err := s.repository.Remove(ctx, s.namespace.key(key)); err != nil {
+				return fmt.Errorf(
+					"%w (operation=remove, key=%q): %w",
+					ErrRepositoryOperation,
+					key,
+					err,
+				)
+			}
+
+			return nil
+		},
+	)
 }
 
 // Exists checks if a key exists.
 func (s *Store) Exists(ctx context.Context, key string) (bool, error) {
-	exists, err := s.repository.Exists(ctx, key)
-	if err != nil {
-		return false, fmt.Errorf(
-			"%w (operation=exists, key=%q): %w",
-			ErrRepositoryOperation,
-			key,
-			err,
-		)
-	}
+	return traceOperation(
+		ctx,
+		s.tracer,
+		"store.exists",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) (bool, error) {
+			exists, err := s.repository.Exists(ctx, s.namespace.key(key))
+			if err != nil {
+				return false, fmt.Errorf(
+					"%w (operation=exists, key=%q): %w",
+					ErrRepositoryOperation,
+					key,
+					err,
+				)
+			}
+
+			return exists, nil
+		},
+	)
+}
+
+// CompareAndSwap stores newValue at key only if the current value equals expected, and
+// reports whether the swap happened. A nil expected value succeeds only when the key does
+// not exist yet. Values are compared in their encoded form, so expected must encode to
+// exactly the stored bytes (e.g. a value previously read with Get).
+//
+// A false result means another writer changed the key first; re-read it and retry.
+// The connection must implement connfx.CASRepository, as the Redis, SQL and memory adapters
+// do; other connections return ErrCASNotSupported.
+func (s *Store) CompareAndSwap(
+	ctx context.Context,
+	key string,
+	expected any,
+	newValue any,
+) (bool, error) {
+	return traceOperation(
+		ctx,
+		s.tracer,
+		"store.compare_and_swap",
+		trace.SpanKindClient,
+		AttributeKey.String(key),
+		func(ctx context.Context) (bool, error) {
+			casRepo, ok := s.repository.(connfx.CASRepository)
+			if !ok {
+				return false, fmt.Errorf(
+					"%w (protocol=%q)",
+					ErrCASNotSupported,
+					s.conn.GetProtocol(),
+				)
+			}
+
+			var expectedData []byte
+
+			if expected != nil {
+				data, err := s.codec.Marshal(expected)
+				if err != nil {
+					return false, fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
+				}
+
+				expectedData = data
+			}
+
+			newData, err := s.codec.Marshal(newValue)
+			if err != nil {
+				return false, fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
+			}
+
+			swapped, err := casRepo.CompareAndSwap(ctx, s.namespace.key(key), expectedData, newData)
+			if err != nil {
+				return false, fmt.Errorf(
+					"%w (operation=compare_and_swap, key=%q): %w",
+					ErrRepositoryOperation,
+					key,
+					err,
+				)
+			}
+
+			return swapped, nil
+		},
+	)
+}
 
-	return exists, nil
+// Keys returns every key starting with prefix. Ordering isn't guaranteed, and keys
+// written or removed while the scan runs may or may not be included.
+// The connection must implement connfx.ScanRepository.
+func (s *Store) Keys(ctx context.Context, prefix string) ([]string, error) {
+	return traceOperation(
+		ctx,
+		s.tracer,
+		"store.keys",
+		trace.SpanKindClient,
+		AttributeKeyPrefix.String(prefix),
+		func(ctx context.Context) ([]string, error) {
+			scanRepo, err := s.scanRepository()
+			if err != nil {
+				return nil, err
+			}
+
+			keys, err := scanRepo.Keys(ctx, s.namespace.key(prefix))
+			if err != nil {
+				return nil, fmt.Errorf(
+					"%w (operation=keys, prefix=%q): %w",
+					ErrRepositoryOperation,
+					prefix,
+					err,
+				)
+			}
+
+			return s.namespace.strip(keys), nil
+		},
+	)
+}
+
+// DeleteByPrefix removes every key starting with prefix (e.g. "session:") and returns
+// how many keys were removed. Keys are removed in no particular order, and the operation
+// is not atomic: a failure may leave some matching keys in place. An empty prefix is
+// rejected with ErrEmptyKeyPrefix unless the Store has a key prefix (see WithKeyPrefix),
+// in which case it removes every key of that namespace.
+// The connection must implement connfx.ScanRepository.
+func (s *Store) DeleteByPrefix(ctx context.Context, prefix string) (int, error) {
+	return traceOperation(
+		ctx,
+		s.tracer,
+		"store.delete_by_prefix",
+		trace.SpanKindClient,
+		AttributeKeyPrefix.String(prefix),
+		func(ctx context.Context) (int, error) {
+			if s.namespace.key(prefix) == "" {
+				return 0, fmt.Errorf("%w (operation=delete_by_prefix)", ErrEmptyKeyPrefix)
+			}
+
+			scanRepo, err := s.scanRepository()
+			if err != nil {
+				return 0, err
+			}
+
+			deleted, err := scanRepo.DeleteByPrefix(ctx, s.namespace.key(prefix))
+			if err != nil {
+				return deleted, fmt.Errorf(
+					"%w (operation=delete_by_prefix, prefix=%q): %w",
+					ErrRepositoryOperation,
+					prefix,
+					err,
+				)
+			}
+
+			return deleted, nil
+		},
+	)
 }
 
 // GetConnection returns the underlying connfx connection.
@@ -178,7 +416,21 @@ func (s *Store) GetConnection() connfx.Connection {
 	return s.conn
 }
 
+// GetCodec returns the codec used to encode values.
+func (s *Store) GetCodec() Codec {
+	return s.codec
+}
+
 // GetRepository returns the underlying data repository.
 func (s *Store) GetRepository() connfx.Repository {
 	return s.repository
 }
+
+func (s *Store) scanRepository() (connfx.ScanRepository, error) {
+	scanRepo, ok := s.repository.(connfx.ScanRepository)
+	if !ok {
+		return nil, fmt.Errorf("%w (protocol=%q)", ErrScanNotSupported, s.conn.GetProtocol())
+	}
+
+	return scanRepo, nil
+}
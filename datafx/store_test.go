@@ -0,0 +1,311 @@
+package datafx_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAccount struct {
+	Owner   string `json:"owner"`
+	Balance int    `json:"balance"`
+}
+
+func TestStore_CompareAndSwap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("swaps_when_value_matches", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := datafx.NewStore(newMemoryConnection())
+		require.NoError(t, err)
+
+		initial := testAccount{Owner: "alice", Balance: 100}
+		require.NoError(t, store.Set(t.Context(), "account:1", initial))
+
+		swapped, err := store.CompareAndSwap(
+			t.Context(),
+			"account:1",
+			initial,
+			testAccount{Owner: "alice", Balance: 150},
+		)
+		require.NoError(t, err)
+		assert.True(t, swapped)
+
+		var result testAccount
+
+		require.NoError(t, store.Get(t.Context(), "account:1", &result))
+		assert.Equal(t, 150, result.Balance)
+	})
+
+	t.Run("rejects_stale_expected_value", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := datafx.NewStore(newMemoryConnection())
+		require.NoError(t, err)
+
+		stale := testAccount{Owner: "alice", Balance: 100}
+		require.NoError(t, store.Set(t.Context(), "account:1", stale))
+
+		// Another writer changes the value after it was read
+		changed := testAccount{Owner: "alice", Balance: 80}
+		require.NoError(t, store.Set(t.Context(), "account:1", changed))
+
+		swapped, err := store.CompareAndSwap(
+			t.Context(),
+			"account:1",
+			stale,
+			testAccount{Owner: "alice", Balance: 150},
+		)
+		require.NoError(t, err)
+		assert.False(t, swapped)
+
+		var result testAccount
+
+		require.NoError(t, store.Get(t.Context(), "account:1", &result))
+		assert.Equal(t, 80, result.Balance)
+	})
+
+	t.Run("nil_expected_creates_missing_key_only", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := datafx.NewStore(newMemoryConnection())
+		require.NoError(t, err)
+
+		alice := testAccount{Owner: "alice", Balance: 0}
+		bob := testAccount{Owner: "bob", Balance: 0}
+
+		swapped, err := store.CompareAndSwap(t.Context(), "account:1", nil, alice)
+		require.NoError(t, err)
+		assert.True(t, swapped)
+
+		swapped, err = store.CompareAndSwap(t.Context(), "account:1", nil, bob)
+		require.NoError(t, err)
+		assert.False(t, swapped)
+
+		var result testAccount
+
+		require.NoError(t, store.Get(t.Context(), "account:1", &result))
+		assert.Equal(t, "alice", result.Owner)
+	})
+
+	t.Run("concurrent_read_modify_write_loses_no_updates", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := datafx.NewStore(newMemoryConnection())
+		require.NoError(t, err)
+
+		require.NoError(t, store.Set(t.Context(), "account:1", testAccount{Owner: "alice"}))
+
+		const writers = 20
+
+		var wg sync.WaitGroup
+
+		for range writers {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for {
+					var current testAccount
+
+					if !assert.NoError(t, store.Get(t.Context(), "account:1", &current)) {
+						return
+					}
+
+					next := current
+					next.Balance++
+
+					swapped, err := store.CompareAndSwap(t.Context(), "account:1", current, next)
+					if !assert.NoError(t, err) || swapped {
+						return
+					}
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		var result testAccount
+
+		require.NoError(t, store.Get(t.Context(), "account:1", &result))
+		assert.Equal(t, writers, result.Balance)
+	})
+}
+
+func TestStore_CompareAndSwap_SQL(t *testing.T) {
+	t.Parallel()
+
+	conn := newSQLiteConnection(t)
+
+	query, err := datafx.NewQuery(conn)
+	require.NoError(t, err)
+
+	_, err = query.Execute(
+		t.Context(),
+		"CREATE TABLE key_values (kv_key TEXT PRIMARY KEY, kv_value BLOB NOT NULL)",
+	)
+	require.NoError(t, err)
+
+	store, err := datafx.NewStore(conn)
+	require.NoError(t, err)
+
+	initial := testAccount{Owner: "alice", Balance: 100}
+	require.NoError(t, store.Set(t.Context(), "account:1", initial))
+
+	swapped, err := store.CompareAndSwap(
+		t.Context(),
+		"account:1",
+		initial,
+		testAccount{Owner: "alice", Balance: 150},
+	)
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	// The first swap made initial stale
+	swapped, err = store.CompareAndSwap(
+		t.Context(),
+		"account:1",
+		initial,
+		testAccount{Owner: "alice", Balance: 200},
+	)
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	var result testAccount
+
+	require.NoError(t, store.Get(t.Context(), "account:1", &result))
+	assert.Equal(t, 150, result.Balance)
+}
+
+func TestStore_DeleteByPrefix_SQL(t *testing.T) {
+	t.Parallel()
+
+	conn := newSQLiteConnection(t)
+
+	query, err := datafx.NewQuery(conn)
+	require.NoError(t, err)
+
+	_, err = query.Execute(
+		t.Context(),
+		"CREATE TABLE key_values (kv_key TEXT PRIMARY KEY, kv_value BLOB NOT NULL)",
+	)
+	require.NoError(t, err)
+
+	store, err := datafx.NewStore(conn)
+	require.NoError(t, err)
+
+	for _, key := range []string{"account:1", "account:2", "account_3"} {
+		require.NoError(t, store.Set(t.Context(), key, testAccount{Owner: key, Balance: 1}))
+	}
+
+	keys, err := store.Keys(t.Context(), "account:")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"account:1", "account:2"}, keys)
+
+	deleted, err := store.DeleteByPrefix(t.Context(), "account:")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	keys, err = store.Keys(t.Context(), "account")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"account_3"}, keys)
+}
+
+func TestStore_KeysAndDeleteByPrefix(t *testing.T) {
+	t.Parallel()
+
+	store, err := datafx.NewStore(newMemoryConnection())
+	require.NoError(t, err)
+
+	for _, key := range []string{"session:1", "session:2", "session:3", "sessions", "user:1"} {
+		require.NoError(t, store.Set(t.Context(), key, testAccount{Owner: key, Balance: 0}))
+	}
+
+	keys, err := store.Keys(t.Context(), "session:")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"session:1", "session:2", "session:3"}, keys)
+
+	deleted, err := store.DeleteByPrefix(t.Context(), "session:")
+	require.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	keys, err = store.Keys(t.Context(), "session")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sessions"}, keys)
+
+	exists, err := store.Exists(t.Context(), "user:1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	deleted, err = store.DeleteByPrefix(t.Context(), "session:")
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	// An empty prefix would remove every key
+	_, err = store.DeleteByPrefix(t.Context(), "")
+	require.ErrorIs(t, err, datafx.ErrEmptyKeyPrefix)
+
+	exists, err = store.Exists(t.Context(), "user:1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestStore_KeyPrefix(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	raw, err := datafx.NewStore(conn)
+	require.NoError(t, err)
+
+	billing, err := datafx.NewStore(conn, datafx.WithKeyPrefix("billing"))
+	require.NoError(t, err)
+
+	shipping, err := datafx.NewStore(conn, datafx.WithKeyPrefix("shipping"))
+	require.NoError(t, err)
+
+	require.NoError(t, billing.Set(t.Context(), "user:1", testAccount{Owner: "alice", Balance: 10}))
+	require.NoError(t, billing.Set(t.Context(), "user:2", testAccount{Owner: "bob", Balance: 20}))
+	require.NoError(t, shipping.Set(t.Context(), "user:1", testAccount{Owner: "carol", Balance: 0}))
+	require.NoError(t, raw.Set(t.Context(), "user:3", testAccount{Owner: "dave", Balance: 0}))
+
+	// Keys are written under the namespace
+	keys, err := raw.Keys(t.Context(), "")
+	require.NoError(t, err)
+	assert.ElementsMatch(
+		t,
+		[]string{"billing:user:1", "billing:user:2", "shipping:user:1", "user:3"},
+		keys,
+	)
+
+	// The same key in two namespaces does not collide
+	var account testAccount
+
+	require.NoError(t, billing.Get(t.Context(), "user:1", &account))
+	assert.Equal(t, "alice", account.Owner)
+
+	require.NoError(t, shipping.Get(t.Context(), "user:1", &account))
+	assert.Equal(t, "carol", account.Owner)
+
+	// Listing is scoped to the namespace and returns unprefixed keys
+	keys, err = billing.Keys(t.Context(), "user:")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "user:2"}, keys)
+
+	exists, err := billing.Exists(t.Context(), "user:3")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	deleted, err := billing.DeleteByPrefix(t.Context(), "user:")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	keys, err = raw.Keys(t.Context(), "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"shipping:user:1", "user:3"}, keys)
+}
@@ -0,0 +1,188 @@
+package datafx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/lib"
+	"github.com/eser/ajan/tracesfx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/eser/ajan/datafx"
+
+// Span attribute keys set on datafx operation spans.
+const (
+	AttributeKey       = attribute.Key("datafx.key")
+	AttributeKeyPrefix = attribute.Key("datafx.key_prefix")
+	AttributeQueue     = attribute.Key("datafx.queue")
+	AttributeChannel   = attribute.Key("datafx.channel")
+	AttributeProtocol  = attribute.Key("datafx.protocol")
+)
+
+// operationTracer wraps datafx operations in spans when tracing is enabled, in a
+// watchdog when a watchdog threshold is set, and measures them when metrics are set.
+type operationTracer struct {
+	logger            *slog.Logger
+	propagator        propagation.TextMapPropagator
+	metrics           *operationMetrics
+	protocol          string
+	watchdogThreshold time.Duration
+	enabled           bool
+}
+
+func newOperationTracer(conn connfx.Connection, opts options) (operationTracer, error) {
+	logger := slog.Default()
+	if opts.logger != nil {
+		logger = opts.logger.Logger
+	}
+
+	var metrics *operationMetrics
+
+	if opts.metrics != nil {
+		var err error
+
+		metrics, err = newOperationMetrics(opts.metrics)
+		if err != nil {
+			return operationTracer{}, err //nolint:exhaustruct
+		}
+	}
+
+	return operationTracer{
+		logger:            logger,
+		propagator:        opts.propagator,
+		metrics:           metrics,
+		protocol:          conn.GetProtocol(),
+		watchdogThreshold: opts.watchdogThreshold,
+		enabled:           opts.tracing,
+	}, nil
+}
+
+func (t operationTracer) run(
+	ctx context.Context,
+	name string,
+	kind trace.SpanKind,
+	attr attribute.KeyValue,
+	fn func(ctx context.Context) error,
+) error {
+	_, err := traceOperation(ctx, t, name, kind, attr, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+
+	return err
+}
+
+// traceOperation runs fn inside a span named after the operation, created with the
+// global OpenTelemetry tracer. Errors are recorded on the span, except for missing
+// keys which are an expected outcome of lookups.
+func traceOperation[T any](
+	ctx context.Context,
+	t operationTracer,
+	name string,
+	kind trace.SpanKind,
+	attr attribute.KeyValue,
+	fn func(ctx context.Context) (T, error),
+) (T, error) {
+	if t.watchdogThreshold > 0 {
+		defer lib.WatchdogContext(ctx, t.watchdogThreshold, func(stack []byte) {
+			t.logger.WarnContext(
+				ctx,
+				"datafx operation exceeded watchdog threshold",
+				slog.String("operation", name),
+				slog.String("protocol", t.protocol),
+				slog.Duration("threshold", t.watchdogThreshold),
+				slog.String("stack", string(stack)),
+			)
+		})()
+	}
+
+	if t.metrics != nil {
+		measured := fn
+
+		fn = func(ctx context.Context) (T, error) {
+			return measureOperation(ctx, t.metrics, name, t.protocol, measured)
+		}
+	}
+
+	if !t.enabled {
+		return fn(ctx)
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(
+		ctx,
+		name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(AttributeProtocol.String(t.protocol), attr),
+	)
+	defer span.End()
+
+	value, err := fn(ctx)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return value, err
+}
+
+// injectHeaders returns the message headers with the trace context of ctx added, so
+// consumers can continue the trace. The caller's headers are not modified, and take
+// precedence over the injected ones.
+func (t operationTracer) injectHeaders(
+	ctx context.Context,
+	headers map[string]any,
+) map[string]any {
+	if !t.enabled {
+		return headers
+	}
+
+	carrier := make(tracesfx.HeaderCarrier, len(headers)+2) //nolint:mnd
+	t.propagator.Inject(ctx, carrier)
+
+	maps.Copy(carrier, headers)
+
+	return carrier
+}
+
+// traceHandler runs the message handler under a consumer span, a child of the span that
+// published the message when its headers carry a trace context. A handler reporting
+// failure marks the span as failed.
+func (t operationTracer) traceHandler(
+	queueName string,
+	headers map[string]any,
+	messageHandler func(ctx context.Context, message any) bool,
+) func(ctx context.Context, message any) bool {
+	if !t.enabled {
+		return messageHandler
+	}
+
+	return func(ctx context.Context, message any) bool {
+		ctx = t.propagator.Extract(ctx, tracesfx.HeaderCarrier(headers))
+
+		ctx, span := otel.Tracer(tracerName).Start(
+			ctx,
+			"queue.process",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				AttributeProtocol.String(t.protocol),
+				AttributeQueue.String(queueName),
+			),
+		)
+		defer span.End()
+
+		success := messageHandler(ctx, message)
+		if !success {
+			span.SetStatus(codes.Error, "message handler failed")
+		}
+
+		return success
+	}
+}
@@ -0,0 +1,326 @@
+package datafx_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	globalSpanRecorder     *tracetest.SpanRecorder //nolint:gochecknoglobals
+	globalSpanRecorderOnce sync.Once               //nolint:gochecknoglobals
+)
+
+// spanRecorder installs a recording global tracer provider once for the package, since
+// datafx uses the global tracer. Tests tell their spans apart by key or queue name.
+func spanRecorder() *tracetest.SpanRecorder {
+	globalSpanRecorderOnce.Do(func() {
+		globalSpanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(
+			sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(globalSpanRecorder)),
+		)
+	})
+
+	return globalSpanRecorder
+}
+
+func findSpans(
+	recorder *tracetest.SpanRecorder,
+	attr attribute.KeyValue,
+) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+
+	for _, span := range recorder.Ended() {
+		for _, spanAttr := range span.Attributes() {
+			if spanAttr == attr {
+				spans[span.Name()] = span
+			}
+		}
+	}
+
+	return spans
+}
+
+func TestStore_WithTracing(t *testing.T) {
+	t.Parallel()
+
+	recorder := spanRecorder()
+
+	store, err := datafx.NewStore(newMemoryConnection(), datafx.WithTracing())
+	require.NoError(t, err)
+
+	key := "tracing:store:user"
+
+	require.NoError(t, store.Set(t.Context(), key, map[string]string{"name": "eser"}))
+
+	var dest map[string]string
+
+	require.NoError(t, store.Get(t.Context(), key, &dest))
+
+	spans := findSpans(recorder, datafx.AttributeKey.String(key))
+	require.Contains(t, spans, "store.set")
+	require.Contains(t, spans, "store.get")
+
+	getSpan := spans["store.get"]
+	assert.Equal(t, trace.SpanKindClient, getSpan.SpanKind())
+	assert.Contains(t, getSpan.Attributes(), datafx.AttributeProtocol.String("memory"))
+	assert.Equal(t, codes.Unset, getSpan.Status().Code)
+}
+
+func TestStore_WithTracing_MissingKeyIsNotAnError(t *testing.T) {
+	t.Parallel()
+
+	recorder := spanRecorder()
+
+	store, err := datafx.NewStore(newMemoryConnection(), datafx.WithTracing())
+	require.NoError(t, err)
+
+	key := "tracing:store:missing"
+
+	var dest map[string]string
+
+	require.ErrorIs(t, store.Get(t.Context(), key, &dest), datafx.ErrKeyNotFound)
+
+	spans := findSpans(recorder, datafx.AttributeKey.String(key))
+	require.Contains(t, spans, "store.get")
+	assert.Equal(t, codes.Unset, spans["store.get"].Status().Code)
+}
+
+func TestStore_WithoutTracing(t *testing.T) {
+	t.Parallel()
+
+	recorder := spanRecorder()
+
+	store, err := datafx.NewStore(newMemoryConnection())
+	require.NoError(t, err)
+
+	key := "tracing:store:untraced"
+
+	require.NoError(t, store.Set(t.Context(), key, "value"))
+
+	assert.Empty(t, findSpans(recorder, datafx.AttributeKey.String(key)))
+}
+
+func TestCache_WithTracing(t *testing.T) {
+	t.Parallel()
+
+	recorder := spanRecorder()
+
+	cache, err := datafx.NewCache(newMemoryConnection(), datafx.WithTracing())
+	require.NoError(t, err)
+
+	key := "tracing:cache:session"
+
+	require.NoError(t, cache.Set(t.Context(), key, "value", time.Minute))
+
+	spans := findSpans(recorder, datafx.AttributeKey.String(key))
+	require.Contains(t, spans, "cache.set")
+	assert.Contains(t, spans["cache.set"].Attributes(), datafx.AttributeProtocol.String("memory"))
+}
+
+func TestCache_GetOrSet_WithTracing(t *testing.T) {
+	t.Parallel()
+
+	recorder := spanRecorder()
+
+	cache, err := datafx.NewCache(newMemoryConnection(), datafx.WithTracing())
+	require.NoError(t, err)
+
+	key := "tracing:cache:loaded"
+
+	var dest string
+
+	require.NoError(t, cache.GetOrSet(t.Context(), key, time.Minute, &dest,
+		func(ctx context.Context) (any, error) { return "value", nil },
+	))
+
+	// The loaded value is written through the cache, not the repository directly
+	spans := findSpans(recorder, datafx.AttributeKey.String(key))
+	require.Contains(t, spans, "cache.get")
+	require.Contains(t, spans, "cache.set_raw")
+}
+
+func TestQueue_WithTracing_RecordsErrors(t *testing.T) {
+	t.Parallel()
+
+	recorder := spanRecorder()
+
+	conn := newMemoryConnection()
+	conn.failNextPublishes(1)
+
+	queue, err := datafx.NewQueue(conn, datafx.WithTracing())
+	require.NoError(t, err)
+
+	queueName := "tracing-orders"
+
+	require.ErrorIs(
+		t,
+		queue.Publish(t.Context(), queueName, map[string]int{"id": 1}),
+		datafx.ErrQueueOperation,
+	)
+
+	spans := findSpans(recorder, datafx.AttributeQueue.String(queueName))
+	require.Contains(t, spans, "queue.publish")
+
+	publishSpan := spans["queue.publish"]
+	assert.Equal(t, trace.SpanKindProducer, publishSpan.SpanKind())
+	assert.Equal(t, codes.Error, publishSpan.Status().Code)
+	require.Len(t, publishSpan.Events(), 1)
+	assert.Equal(t, "exception", publishSpan.Events()[0].Name)
+}
+
+func TestQueue_WithTracing_PropagatesTraceContext(t *testing.T) {
+	t.Parallel()
+
+	recorder := spanRecorder()
+
+	queue, err := datafx.NewQueue(newMemoryConnection(), datafx.WithTracing())
+	require.NoError(t, err)
+
+	queueName := "tracing-propagation"
+	headers := map[string]any{"event_type": "order_placed"}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	require.NoError(t, queue.PublishWithHeaders(ctx, queueName, testEvent{Name: "order"}, headers))
+
+	// The caller's headers are left as they were
+	assert.Equal(t, map[string]any{"event_type": "order_placed"}, headers)
+
+	var handlerSpan trace.SpanContext
+
+	handler := func(handlerCtx context.Context, message any) bool {
+		handlerSpan = trace.SpanContextFromContext(handlerCtx)
+
+		cancel()
+
+		return true
+	}
+
+	config := connfx.DefaultConsumerConfig()
+	config.HeaderFilter = map[string]string{"event_type": "order_placed"}
+
+	err = queue.ProcessMessages(ctx, queueName, config, handler, &testEvent{})
+	require.ErrorIs(t, err, datafx.ErrContextCanceled)
+
+	spans := findSpans(recorder, datafx.AttributeQueue.String(queueName))
+	require.Contains(t, spans, "queue.publish_with_headers")
+	require.Contains(t, spans, "queue.process")
+
+	publishSpan := spans["queue.publish_with_headers"]
+	processSpan := spans["queue.process"]
+
+	assert.Equal(t, trace.SpanKindConsumer, processSpan.SpanKind())
+	assert.Equal(t, publishSpan.SpanContext().TraceID(), processSpan.SpanContext().TraceID())
+	assert.Equal(t, publishSpan.SpanContext().SpanID(), processSpan.Parent().SpanID())
+	assert.True(t, processSpan.Parent().IsRemote())
+	assert.Equal(t, processSpan.SpanContext().SpanID(), handlerSpan.SpanID())
+}
+
+// lockedBuffer is a bytes.Buffer safe for the concurrent writes of watchdog callbacks.
+type lockedBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// slowConnection delays every Get, standing in for a backend that hangs.
+type slowConnection struct {
+	*memoryConnection
+
+	delay time.Duration
+}
+
+func (c *slowConnection) GetRawConnection() any {
+	return c
+}
+
+func (c *slowConnection) Get(ctx context.Context, key string) ([]byte, error) {
+	time.Sleep(c.delay)
+
+	return c.memoryConnection.Get(ctx, key)
+}
+
+func TestStore_WithWatchdog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		delay      time.Duration
+		wantLogged bool
+	}{
+		{name: "slow_operation", delay: 100 * time.Millisecond, wantLogged: true},
+		{name: "fast_operation", delay: 0, wantLogged: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var logBuffer lockedBuffer
+
+			logger := logfx.NewLogger(
+				logfx.WithFromSlog(slog.New(slog.NewJSONHandler(&logBuffer, nil))),
+			)
+
+			conn := &slowConnection{memoryConnection: newMemoryConnection(), delay: tt.delay}
+
+			store, err := datafx.NewStore(
+				conn,
+				datafx.WithWatchdog(20*time.Millisecond),
+				datafx.WithLogger(logger),
+			)
+			require.NoError(t, err)
+
+			_, err = store.GetRaw(t.Context(), "watchdog:key")
+			require.ErrorIs(t, err, datafx.ErrKeyNotFound)
+
+			if !tt.wantLogged {
+				time.Sleep(40 * time.Millisecond)
+				assert.Empty(t, logBuffer.String())
+
+				return
+			}
+
+			require.Eventually(t, func() bool {
+				return logBuffer.String() != ""
+			}, 5*time.Second, 10*time.Millisecond)
+
+			output := logBuffer.String()
+
+			assert.Contains(t, output, "datafx operation exceeded watchdog threshold")
+			assert.Contains(t, output, `"operation":"store.get_raw"`)
+			// The captured stacks include the goroutine stuck in the slow backend
+			assert.Contains(t, output, "slowConnection")
+		})
+	}
+}
@@ -2,10 +2,11 @@ package datafx
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"slices"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/eser/ajan/connfx"
 )
@@ -16,6 +17,19 @@ var (
 	ErrTransactionOperation    = errors.New("transaction operation failed")
 )
 
+// transactionKey is the context key under which ExecuteTransaction stores the
+// transaction it runs, so that nested calls join it.
+type transactionKey struct{}
+
+// activeTransaction is a transaction and the repository it was begun on. Once it is
+// done, contexts still carrying it no longer nest.
+type activeTransaction struct {
+	repository connfx.TransactionalRepository
+	tx         connfx.TransactionContext
+	savepoints atomic.Int64
+	done       atomic.Bool
+}
+
 // TransactionalStore provides transactional store operations.
 type TransactionalStore struct {
 	*Store
@@ -24,9 +38,9 @@ type TransactionalStore struct {
 
 // NewTransactionalStore creates a new TransactionalStore instance from a connfx connection.
 // The connection must support transactional operations.
-func NewTransactionalStore(conn connfx.Connection) (*TransactionalStore, error) {
+func NewTransactionalStore(conn connfx.Connection, opts ...Option) (*TransactionalStore, error) {
 	// First create a regular Data instance
-	store, err := NewStore(conn)
+	store, err := NewStore(conn, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -62,19 +76,43 @@ func NewTransactionalStore(conn connfx.Connection) (*TransactionalStore, error)
 // ExecuteTransaction executes a function within a transaction context.
 // If the function returns an error, the transaction is rolled back.
 // If the function succeeds, the transaction is committed.
+//
+// Called with the context of a running transaction on the same connection (see
+// TransactionStore.Context), it nests instead of beginning a new transaction: the
+// function runs inside the enclosing transaction, under a savepoint that is rolled back
+// if it returns an error. Nesting requires a transaction implementing
+// connfx.SavepointTransactionContext; otherwise the nested call returns
+// ErrTransactionNotSupported without running the function.
 func (ts *TransactionalStore) ExecuteTransaction(
 	ctx context.Context,
 	fn func(*TransactionStore) error,
 ) error {
+	active, ok := ctx.Value(transactionKey{}).(*activeTransaction)
+	if ok && active.repository == ts.transactionalRepo && !active.done.Load() {
+		return ts.executeNested(ctx, active, fn)
+	}
+
 	// Begin transaction
 	txCtx, err := ts.transactionalRepo.BeginTransaction(ctx)
 	if err != nil {
 		return fmt.Errorf("%w: failed to begin transaction: %w", ErrTransactionFailed, err)
 	}
 
+	active = &activeTransaction{
+		repository: ts.transactionalRepo,
+		tx:         txCtx,
+		savepoints: atomic.Int64{},
+		done:       atomic.Bool{},
+	}
+
+	defer active.done.Store(true)
+
 	// Create transaction-scoped data instance
 	txData := &TransactionStore{
 		Repository: txCtx.GetRepository(),
+		codec:      ts.codec,
+		namespace:  ts.namespace,
+		ctx:        context.WithValue(ctx, transactionKey{}, active),
 	}
 
 	// Execute the function
@@ -96,14 +134,87 @@ func (ts *TransactionalStore) ExecuteTransaction(
 	return nil
 }
 
+// executeNested runs fn inside the active transaction, under a savepoint.
+func (ts *TransactionalStore) executeNested(
+	ctx context.Context,
+	active *activeTransaction,
+	fn func(*TransactionStore) error,
+) error {
+	savepoints, ok := active.tx.(connfx.SavepointTransactionContext)
+	if !ok {
+		return fmt.Errorf(
+			"%w: nested transactions need savepoint support (protocol=%q)",
+			ErrTransactionNotSupported,
+			ts.conn.GetProtocol(),
+		)
+	}
+
+	name := "datafx_savepoint_" + strconv.FormatInt(active.savepoints.Add(1), 10)
+
+	if err := savepoints.CreateSavepoint(name); err != nil {
+		return fmt.Errorf(
+			"%w: failed to create savepoint (savepoint=%q): %w",
+			ErrTransactionFailed,
+			name,
+			err,
+		)
+	}
+
+	txData := &TransactionStore{
+		Repository: active.tx.GetRepository(),
+		codec:      ts.codec,
+		namespace:  ts.namespace,
+		ctx:        ctx,
+	}
+
+	if err := fn(txData); err != nil {
+		if rollbackErr := savepoints.RollbackToSavepoint(name); rollbackErr != nil {
+			return fmt.Errorf(
+				"%w: nested transaction failed with error %q and rollback to savepoint failed: %w",
+				ErrTransactionFailed,
+				err.Error(),
+				rollbackErr,
+			)
+		}
+
+		return fmt.Errorf("%w (savepoint=%q): %w", ErrTransactionFailed, name, err)
+	}
+
+	if err := savepoints.ReleaseSavepoint(name); err != nil {
+		return fmt.Errorf(
+			"%w: failed to release savepoint (savepoint=%q): %w",
+			ErrTransactionFailed,
+			name,
+			err,
+		)
+	}
+
+	return nil
+}
+
 // TransactionStore provides data operations within a transaction context.
 type TransactionStore struct {
 	Repository connfx.Repository
+	codec      Codec
+	namespace  keyNamespace
+
+	ctx context.Context //nolint:containedctx
 }
 
-// Get retrieves a value by key and unmarshals it into the provided destination.
+// Context returns a context carrying this transaction. Passing it to
+// ExecuteTransaction, directly or through code that may start its own transaction,
+// nests that call inside this transaction instead of beginning another one.
+func (ts *TransactionStore) Context() context.Context {
+	if ts.ctx == nil {
+		return context.Background()
+	}
+
+	return ts.ctx
+}
+
+// Get retrieves a value by key and decodes it into the provided destination.
 func (ts *TransactionStore) Get(ctx context.Context, key string, dest any) error {
-	data, err := ts.Repository.Get(ctx, key)
+	data, err := ts.Repository.Get(ctx, ts.namespace.key(key))
 	if err != nil {
 		return fmt.Errorf("%w (operation=get, key=%q): %w", ErrTransactionOperation, key, err)
 	}
@@ -112,7 +223,7 @@ func (ts *TransactionStore) Get(ctx context.Context, key string, dest any) error
 		return fmt.Errorf("%w (key=%q)", ErrKeyNotFound, key)
 	}
 
-	if err := json.Unmarshal(data, dest); err != nil {
+	if err := ts.getCodec().Unmarshal(data, dest); err != nil {
 		return fmt.Errorf("%w (key=%q): %w", ErrFailedToUnmarshal, key, err)
 	}
 
@@ -121,7 +232,7 @@ func (ts *TransactionStore) Get(ctx context.Context, key string, dest any) error
 
 // GetRaw retrieves raw bytes by key.
 func (ts *TransactionStore) GetRaw(ctx context.Context, key string) ([]byte, error) {
-	data, err := ts.Repository.Get(ctx, key)
+	data, err := ts.Repository.Get(ctx, ts.namespace.key(key))
 	if err != nil {
 		return nil, fmt.Errorf(
 			"%w (operation=get_raw, key=%q): %w",
@@ -138,14 +249,14 @@ func (ts *TransactionStore) GetRaw(ctx context.Context, key string) ([]byte, err
 	return data, nil
 }
 
-// Set stores a value with the given key after marshaling it to JSON.
+// Set stores a value with the given key after encoding it with the store codec.
 func (ts *TransactionStore) Set(ctx context.Context, key string, value any) error {
-	data, err := json.Marshal(value)
+	data, err := ts.getCodec().Marshal(value)
 	if err != nil {
 		return fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
 	}
 
-	if err := ts.Repository.Set(ctx, key, data); err != nil {
+	if err := ts.Repository.Set(ctx, ts.namespace.key(key), data); err != nil {
 		return fmt.Errorf("%w (operation=set, key=%q): %w", ErrTransactionOperation, key, err)
 	}
 
@@ -154,21 +265,21 @@ func (ts *TransactionStore) Set(ctx context.Context, key string, value any) erro
 
 // SetRaw stores raw bytes with the given key.
 func (ts *TransactionStore) SetRaw(ctx context.Context, key string, value []byte) error {
-	if err := ts.Repository.Set(ctx, key, value); err != nil {
+	if err := ts.Repository.Set(ctx, ts.namespace.key(key), value); err != nil {
 		return fmt.Errorf("%w (operation=set_raw, key=%q): %w", ErrTransactionOperation, key, err)
 	}
 
 	return nil
 }
 
-// Update updates an existing value by key after marshaling it to JSON.
+// Update updates an existing value by key after encoding it with the store codec.
 func (ts *TransactionStore) Update(ctx context.Context, key string, value any) error {
-	data, err := json.Marshal(value)
+	data, err := ts.getCodec().Marshal(value)
 	if err != nil {
 		return fmt.Errorf("%w (key=%q): %w", ErrFailedToMarshal, key, err)
 	}
 
-	if err := ts.Repository.Update(ctx, key, data); err != nil {
+	if err := ts.Repository.Update(ctx, ts.namespace.key(key), data); err != nil {
 		return fmt.Errorf("%w (operation=update, key=%q): %w", ErrTransactionOperation, key, err)
 	}
 
@@ -177,7 +288,7 @@ func (ts *TransactionStore) Update(ctx context.Context, key string, value any) e
 
 // UpdateRaw updates an existing value with raw bytes by key.
 func (ts *TransactionStore) UpdateRaw(ctx context.Context, key string, value []byte) error {
-	if err := ts.Repository.Update(ctx, key, value); err != nil {
+	if err := ts.Repository.Update(ctx, ts.namespace.key(key), value); err != nil {
 		return fmt.Errorf(
 			"%w (operation=update_raw, key=%q): %w",
 			ErrTransactionOperation,
@@ -191,7 +302,7 @@ func (ts *TransactionStore) UpdateRaw(ctx context.Context, key string, value []b
 
 // Remove deletes a value by key.
 func (ts *TransactionStore) Remove(ctx context.Context, key string) error {
-	if err := ts.Repository.Remove(ctx, key); err != nil {
+	if err := ts.Repository.Remove(ctx, ts.namespace.key(key)); err != nil {
 		return fmt.Errorf("%w (operation=remove, key=%q): %w", ErrTransactionOperation, key, err)
 	}
 
@@ -200,7 +311,7 @@ func (ts *TransactionStore) Remove(ctx context.Context, key string) error {
 
 // Exists checks if a key exists.
 func (ts *TransactionStore) Exists(ctx context.Context, key string) (bool, error) {
-	exists, err := ts.Repository.Exists(ctx, key)
+	exists, err := ts.Repository.Exists(ctx, ts.namespace.key(key))
 	if err != nil {
 		return false, fmt.Errorf(
 			"%w (operation=exists, key=%q): %w",
@@ -212,3 +323,12 @@ func (ts *TransactionStore) Exists(ctx context.Context, key string) (bool, error
 
 	return exists, nil
 }
+
+// getCodec returns the codec of the owning store, defaulting to JSON.
+func (ts *TransactionStore) getCodec() Codec {
+	if ts.codec == nil {
+		return JSONCodec{}
+	}
+
+	return ts.codec
+}
@@ -0,0 +1,182 @@
+package datafx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNestedFailed = errors.New("nested work failed")
+
+// savepointlessConnection begins transactions that do not support savepoints.
+type savepointlessConnection struct {
+	*memoryConnection
+}
+
+func (c *savepointlessConnection) GetRawConnection() any {
+	return c
+}
+
+func (c *savepointlessConnection) BeginTransaction(
+	ctx context.Context,
+) (connfx.TransactionContext, error) {
+	tx, err := c.memoryConnection.BeginTransaction(ctx)
+
+	return struct{ connfx.TransactionContext }{tx}, err
+}
+
+func assertStored(t *testing.T, store *datafx.TransactionalStore, keys map[string]bool) {
+	t.Helper()
+
+	for key, stored := range keys {
+		exists, err := store.Exists(t.Context(), key)
+		require.NoError(t, err)
+		assert.Equal(t, stored, exists, "key %q", key)
+	}
+}
+
+func TestTransactionalStore_ExecuteTransaction_Nested(t *testing.T) {
+	t.Parallel()
+
+	store, err := datafx.NewTransactionalStore(newMemoryConnection())
+	require.NoError(t, err)
+
+	err = store.ExecuteTransaction(t.Context(), func(tx *datafx.TransactionStore) error {
+		ctx := tx.Context()
+
+		require.NoError(t, tx.Set(ctx, "outer", "a"))
+
+		// A nested call joins the transaction instead of beginning (and waiting for) another
+		err := store.ExecuteTransaction(ctx, func(inner *datafx.TransactionStore) error {
+			return inner.Set(inner.Context(), "kept", "b")
+		})
+		require.NoError(t, err)
+
+		// A failing nested call only rolls back its own changes
+		err = store.ExecuteTransaction(ctx, func(inner *datafx.TransactionStore) error {
+			require.NoError(t, inner.Set(inner.Context(), "discarded", "c"))
+
+			// Nesting goes deeper, too
+			nestedErr := store.ExecuteTransaction(
+				inner.Context(),
+				func(innermost *datafx.TransactionStore) error {
+					return innermost.Set(innermost.Context(), "innermost", "d")
+				},
+			)
+			require.NoError(t, nestedErr)
+
+			return errNestedFailed
+		})
+		require.ErrorIs(t, err, datafx.ErrTransactionFailed)
+		require.ErrorIs(t, err, errNestedFailed)
+
+		exists, err := tx.Exists(ctx, "discarded")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		return tx.Set(ctx, "after", "e")
+	})
+	require.NoError(t, err)
+
+	assertStored(t, store, map[string]bool{
+		"outer":     true,
+		"kept":      true,
+		"discarded": false,
+		"innermost": false,
+		"after":     true,
+	})
+}
+
+func TestTransactionalStore_ExecuteTransaction_NestedOuterRollback(t *testing.T) {
+	t.Parallel()
+
+	store, err := datafx.NewTransactionalStore(newMemoryConnection())
+	require.NoError(t, err)
+
+	var staleCtx context.Context
+
+	err = store.ExecuteTransaction(t.Context(), func(tx *datafx.TransactionStore) error {
+		staleCtx = tx.Context()
+
+		nestedErr := store.ExecuteTransaction(staleCtx, func(inner *datafx.TransactionStore) error {
+			return inner.Set(inner.Context(), "nested", "a")
+		})
+		require.NoError(t, nestedErr)
+
+		return errNestedFailed
+	})
+	require.ErrorIs(t, err, errNestedFailed)
+
+	// Released savepoints still belong to the enclosing transaction
+	assertStored(t, store, map[string]bool{"nested": false})
+
+	// Once the transaction is over, its context begins a new one
+	err = store.ExecuteTransaction(staleCtx, func(tx *datafx.TransactionStore) error {
+		return tx.Set(tx.Context(), "later", "b")
+	})
+	require.NoError(t, err)
+
+	assertStored(t, store, map[string]bool{"later": true})
+}
+
+func TestTransactionalStore_ExecuteTransaction_NestedWithoutSavepoints(t *testing.T) {
+	t.Parallel()
+
+	store, err := datafx.NewTransactionalStore(
+		&savepointlessConnection{memoryConnection: newMemoryConnection()},
+	)
+	require.NoError(t, err)
+
+	err = store.ExecuteTransaction(t.Context(), func(tx *datafx.TransactionStore) error {
+		nestedErr := store.ExecuteTransaction(
+			tx.Context(),
+			func(*datafx.TransactionStore) error {
+				require.FailNow(t, "nested function ran without a savepoint")
+
+				return nil
+			},
+		)
+		require.ErrorIs(t, nestedErr, datafx.ErrTransactionNotSupported)
+
+		return tx.Set(tx.Context(), "outer", "a")
+	})
+	require.NoError(t, err)
+
+	assertStored(t, store, map[string]bool{"outer": true})
+}
+
+func TestTransactionalStore_KeyPrefix(t *testing.T) {
+	t.Parallel()
+
+	conn := newMemoryConnection()
+
+	store, err := datafx.NewTransactionalStore(conn, datafx.WithKeyPrefix("orders"))
+	require.NoError(t, err)
+
+	err = store.ExecuteTransaction(t.Context(), func(tx *datafx.TransactionStore) error {
+		ctx := tx.Context()
+
+		require.NoError(t, tx.Set(ctx, "outer", "a"))
+
+		return store.ExecuteTransaction(ctx, func(inner *datafx.TransactionStore) error {
+			return inner.Set(inner.Context(), "inner", "b")
+		})
+	})
+	require.NoError(t, err)
+
+	raw, err := datafx.NewStore(conn)
+	require.NoError(t, err)
+
+	keys, err := raw.Keys(t.Context(), "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"orders:outer", "orders:inner"}, keys)
+
+	keys, err = store.Keys(t.Context(), "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"outer", "inner"}, keys)
+}
@@ -0,0 +1,224 @@
+package datafx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eser/ajan/lib"
+)
+
+// ValidateTag is the struct tag read by ValidateStruct.
+const ValidateTag = "validate"
+
+var (
+	ErrValidationFailed  = lib.NewCategorizedError(lib.ErrCategoryInvalidInput, "validation failed")
+	ErrInvalidValidation = errors.New("invalid validation rule")
+)
+
+// ValidateStruct checks message against the rules in the "validate" tags of its fields,
+// descending into nested structs, pointers to structs and slices of them. Rules are
+// separated by commas:
+//
+//   - required: the value is not the zero value, nor an empty slice or map
+//   - omitempty: the remaining rules are skipped for a zero value
+//   - min=N, max=N: bounds the length of strings (in characters), slices and maps, or
+//     the value of numbers
+//   - oneof=a b c: the value, formatted as text, is one of the space-separated options
+//
+// For example:
+//
+//	type OrderCreated struct {
+//		ID       string  `validate:"required"`
+//		Currency string  `validate:"required,oneof=EUR USD TRY"`
+//		Amount   float64 `validate:"min=0.01"`
+//		Items    []Item  `validate:"min=1,max=100"`
+//		Note     string  `validate:"omitempty,max=500"`
+//	}
+//
+// Every violation is reported, joined, each wrapping ErrValidationFailed with the field
+// path. A malformed rule returns ErrInvalidValidation. Messages that are not structs
+// pass as they are. Each pointer is followed once, so cyclic values are safe.
+func ValidateStruct(message any) error {
+	visited := map[uintptr]struct{}{}
+
+	value := reflect.ValueOf(message)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+
+		visited[value.Pointer()] = struct{}{}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return errors.Join(validateStruct(value, "", visited)...)
+}
+
+func validateStruct(value reflect.Value, prefix string, visited map[uintptr]struct{}) []error {
+	var errs []error
+
+	valueType := value.Type()
+
+	for i := range valueType.NumField() {
+		field := valueType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		path := prefix + field.Name
+		fieldValue := value.Field(i)
+
+		if tag, ok := field.Tag.Lookup(ValidateTag); ok {
+			errs = append(errs, validateField(fieldValue, path, tag)...)
+		}
+
+		errs = append(errs, validateNested(fieldValue, path, visited)...)
+	}
+
+	return errs
+}
+
+// validateNested descends into the structs held by a field, skipping pointers already
+// followed.
+func validateNested(value reflect.Value, path string, visited map[uintptr]struct{}) []error {
+	switch value.Kind() { //nolint:exhaustive
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+
+		if _, ok := visited[value.Pointer()]; ok {
+			return nil
+		}
+
+		visited[value.Pointer()] = struct{}{}
+
+		return validateNested(value.Elem(), path, visited)
+	case reflect.Struct:
+		return validateStruct(value, path+".", visited)
+	case reflect.Slice, reflect.Array:
+		var errs []error
+
+		for i := range value.Len() {
+			errs = append(
+				errs,
+				validateNested(value.Index(i), fmt.Sprintf("%s[%d]", path, i), visited)...,
+			)
+		}
+
+		return errs
+	default:
+		return nil
+	}
+}
+
+func validateField(value reflect.Value, path string, tag string) []error {
+	var errs []error
+
+	for rule := range strings.SplitSeq(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "":
+			continue
+		case "omitempty":
+			if isEmptyValue(value) {
+				return nil
+			}
+
+			continue
+		}
+
+		if err := validateRule(value, name, param); err != nil {
+			errs = append(errs, fmt.Errorf("%w (field=%q, rule=%q)", err, path, rule))
+		}
+	}
+
+	return errs
+}
+
+func validateRule(value reflect.Value, name string, param string) error {
+	// Only required applies to a nil pointer; the others check what it points to
+	if value.Kind() == reflect.Pointer && value.IsNil() && name != "required" {
+		return nil
+	}
+
+	switch name {
+	case "required":
+		if isEmptyValue(value) {
+			return fmt.Errorf("%w: value is required", ErrValidationFailed)
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s needs a number", ErrInvalidValidation, name)
+		}
+
+		measure, ok := measureValue(value)
+		if !ok {
+			return fmt.Errorf(
+				"%w: %s does not apply to %s",
+				ErrInvalidValidation,
+				name,
+				value.Kind(),
+			)
+		}
+
+		if name == "min" && measure < limit {
+			return fmt.Errorf("%w: %v is below the minimum", ErrValidationFailed, measure)
+		}
+
+		if name == "max" && measure > limit {
+			return fmt.Errorf("%w: %v is above the maximum", ErrValidationFailed, measure)
+		}
+	case "oneof":
+		text := fmt.Sprint(reflect.Indirect(value).Interface())
+
+		if !slices.Contains(strings.Fields(param), text) {
+			return fmt.Errorf("%w: %q is not an allowed value", ErrValidationFailed, text)
+		}
+	default:
+		return fmt.Errorf("%w: unknown rule", ErrInvalidValidation)
+	}
+
+	return nil
+}
+
+// measureValue returns what min and max compare: a length or a number.
+func measureValue(value reflect.Value) (float64, bool) {
+	value = reflect.Indirect(value)
+
+	switch value.Kind() { //nolint:exhaustive
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() { //nolint:exhaustive
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	default:
+		return value.IsZero()
+	}
+}
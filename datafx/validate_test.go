@@ -0,0 +1,149 @@
+package datafx_test
+
+import (
+	"testing"
+
+	"github.com/eser/ajan/datafx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validatedItem struct {
+	SKU      string `validate:"required"`
+	Quantity int    `validate:"min=1,max=10"`
+}
+
+type validatedOrder struct {
+	Customer *validatedCustomer
+	ID       string          `validate:"required"`
+	Currency string          `validate:"required,oneof=EUR USD TRY"`
+	Note     string          `validate:"omitempty,min=3"`
+	Items    []validatedItem `validate:"min=1"`
+	Amount   float64         `validate:"min=0.01"`
+}
+
+type validatedCustomer struct {
+	Name string `validate:"required,max=5"`
+}
+
+func validOrder() validatedOrder {
+	return validatedOrder{
+		Customer: &validatedCustomer{Name: "Ayşe"},
+		ID:       "order-1",
+		Currency: "TRY",
+		Note:     "",
+		Items:    []validatedItem{{SKU: "sku-1", Quantity: 2}},
+		Amount:   9.99,
+	}
+}
+
+func TestValidateStruct(t *testing.T) {
+	t.Parallel()
+
+	order := validOrder()
+	require.NoError(t, datafx.ValidateStruct(order))
+	require.NoError(t, datafx.ValidateStruct(&order))
+
+	// Values without tags, and non-struct messages, have nothing to violate
+	require.NoError(t, datafx.ValidateStruct(testEvent{Name: ""}))
+	require.NoError(t, datafx.ValidateStruct("plain"))
+	require.NoError(t, datafx.ValidateStruct(nil))
+	require.NoError(t, datafx.ValidateStruct((*validatedOrder)(nil)))
+}
+
+func TestValidateStruct_Violations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		mutate func(order *validatedOrder)
+		field  string
+	}{
+		{"required", func(o *validatedOrder) { o.ID = "" }, `field="ID", rule="required"`},
+		{"oneof", func(o *validatedOrder) { o.Currency = "GBP" }, `field="Currency"`},
+		{"omitempty", func(o *validatedOrder) { o.Note = "ok" }, `field="Note", rule="min=3"`},
+		{"min length", func(o *validatedOrder) { o.Items = nil }, `field="Items", rule="min=1"`},
+		{"min value", func(o *validatedOrder) { o.Amount = 0 }, `field="Amount"`},
+		{"nested", func(o *validatedOrder) { o.Customer.Name = "Mehmet" }, `field="Customer.Name"`},
+		{
+			"slice element",
+			func(o *validatedOrder) { o.Items[0].Quantity = 11 },
+			`field="Items[0].Quantity", rule="max=10"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			order := validOrder()
+			tt.mutate(&order)
+
+			err := datafx.ValidateStruct(order)
+			require.ErrorIs(t, err, datafx.ErrValidationFailed)
+			assert.Contains(t, err.Error(), tt.field)
+		})
+	}
+}
+
+func TestValidateStruct_ReportsEveryViolation(t *testing.T) {
+	t.Parallel()
+
+	err := datafx.ValidateStruct(validatedOrder{}) //nolint:exhaustruct
+	require.ErrorIs(t, err, datafx.ErrValidationFailed)
+
+	for _, field := range []string{"ID", "Currency", "Items", "Amount"} {
+		assert.Contains(t, err.Error(), `field="`+field+`"`)
+	}
+
+	assert.NotContains(t, err.Error(), `field="Note"`)
+}
+
+func TestValidateStruct_InvalidRule(t *testing.T) {
+	t.Parallel()
+
+	err := datafx.ValidateStruct(struct {
+		Name string `validate:"required,email"`
+	}{Name: "someone"})
+	require.ErrorIs(t, err, datafx.ErrInvalidValidation)
+
+	err = datafx.ValidateStruct(struct {
+		Enabled bool `validate:"min=1"`
+	}{Enabled: true})
+	require.ErrorIs(t, err, datafx.ErrInvalidValidation)
+}
+
+type validatedNode struct {
+	Next   *validatedNode
+	Parent *validatedNode
+	Name   string `validate:"required"`
+}
+
+func TestValidateStruct_CyclicValues(t *testing.T) {
+	t.Parallel()
+
+	// A self-referencing node and a parent↔child pair are followed only once
+	node := &validatedNode{Name: "self"} //nolint:exhaustruct
+	node.Next = node
+
+	require.NoError(t, datafx.ValidateStruct(node))
+	require.NoError(t, datafx.ValidateStruct(*node))
+
+	parent := &validatedNode{Name: "parent"}          //nolint:exhaustruct
+	child := &validatedNode{Name: "", Parent: parent} //nolint:exhaustruct
+	parent.Next = child
+
+	err := datafx.ValidateStruct(parent)
+	require.ErrorIs(t, err, datafx.ErrValidationFailed)
+	assert.Contains(t, err.Error(), `field="Next.Name"`)
+
+	// The cycle reaches the publisher's encoder rather than crashing the validation
+	queue, err := datafx.NewQueue(newMemoryConnection())
+	require.NoError(t, err)
+
+	child.Name = "child"
+
+	err = queue.PublishValidated(t.Context(), "nodes", parent)
+	require.ErrorIs(t, err, datafx.ErrFailedToMarshal)
+	require.NotErrorIs(t, err, datafx.ErrValidationFailed)
+}
@@ -0,0 +1,8 @@
+package healthfx
+
+import "time"
+
+type Config struct {
+	CheckTimeout time.Duration `conf:"check_timeout" default:"5s"`
+	CacheTTL     time.Duration `conf:"cache_ttl"     default:"10s"`
+}
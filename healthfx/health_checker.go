@@ -0,0 +1,253 @@
+package healthfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+)
+
+var (
+	ErrCheckTimedOut       = errors.New("health check timed out")
+	ErrCheckPanicked       = errors.New("health check panicked")
+	ErrConnectionUnhealthy = errors.New("connection is unhealthy")
+)
+
+// Status represents the outcome of a health check or a whole report.
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// CheckFunc reports whether a dependency is healthy. It should honor ctx cancellation,
+// but a check that does not is still abandoned once its timeout elapses.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of a single named check.
+type CheckResult struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Status    Status        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Latency   time.Duration `json:"latency"`
+}
+
+// Report is the aggregated outcome of all registered checks.
+type Report struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Checks    map[string]CheckResult `json:"checks"`
+	Status    Status                 `json:"status"`
+}
+
+type check struct {
+	fn      CheckFunc
+	timeout time.Duration
+}
+
+// HealthChecker runs registered dependency checks in parallel, each bounded by its
+// own timeout, and caches the aggregated report for Config.CacheTTL.
+type HealthChecker struct {
+	cachedAt time.Time
+	config   *Config
+	checks   map[string]check
+	cached   *Report
+
+	mu    sync.RWMutex
+	runMu sync.Mutex
+}
+
+func NewHealthChecker(config *Config) *HealthChecker {
+	return &HealthChecker{ //nolint:exhaustruct
+		config: config,
+		checks: make(map[string]check),
+	}
+}
+
+// Register adds a named check using the default timeout from the config.
+func (hc *HealthChecker) Register(name string, fn CheckFunc) {
+	hc.RegisterWithTimeout(name, hc.config.CheckTimeout, fn)
+}
+
+// RegisterWithTimeout adds a named check with its own timeout.
+func (hc *HealthChecker) RegisterWithTimeout(name string, timeout time.Duration, fn CheckFunc) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	hc.checks[name] = check{fn: fn, timeout: timeout}
+	hc.cached = nil
+}
+
+// RegisterConnection adds a check that reports the health of a connfx connection.
+func (hc *HealthChecker) RegisterConnection(name string, conn connfx.Connection) {
+	hc.Register(name, ConnectionCheck(conn))
+}
+
+// RegisterRegistry adds a check for every connection currently in the registry.
+func (hc *HealthChecker) RegisterRegistry(registry *connfx.Registry) {
+	for _, name := range registry.ListConnections() {
+		conn := registry.GetNamed(name)
+		if conn == nil {
+			continue
+		}
+
+		hc.RegisterConnection(name, conn)
+	}
+}
+
+// ConnectionCheck adapts a connfx connection's HealthCheck to a CheckFunc.
+func ConnectionCheck(conn connfx.Connection) CheckFunc {
+	return func(ctx context.Context) error {
+		status := conn.HealthCheck(ctx)
+
+		if status.Error != nil {
+			return fmt.Errorf("%w (state=%q): %w", ErrConnectionUnhealthy, status.State, status.Error)
+		}
+
+		switch status.State { //nolint:exhaustive
+		case connfx.ConnectionStateConnected, connfx.ConnectionStateLive, connfx.ConnectionStateReady:
+			return nil
+		default:
+			return fmt.Errorf("%w (state=%q)", ErrConnectionUnhealthy, status.State)
+		}
+	}
+}
+
+// Liveness reports whether the process itself is healthy. It never runs dependency
+// checks, so an unavailable dependency does not get the process restarted.
+func (hc *HealthChecker) Liveness() Status {
+	return StatusUp
+}
+
+// Readiness runs all checks, or returns the cached report if it is younger than
+// Config.CacheTTL. Concurrent callers share a single run. A report produced while ctx
+// ended is returned but not cached, since its failures may only reflect the caller
+// giving up.
+func (hc *HealthChecker) Readiness(ctx context.Context) *Report {
+	if report := hc.getCached(); report != nil {
+		return report
+	}
+
+	hc.runMu.Lock()
+	defer hc.runMu.Unlock()
+
+	if report := hc.getCached(); report != nil {
+		return report
+	}
+
+	report := hc.Run(ctx)
+	if ctx.Err() != nil {
+		return report
+	}
+
+	hc.mu.Lock()
+	hc.cached = report
+	hc.cachedAt = time.Now()
+	hc.mu.Unlock()
+
+	return report
+}
+
+// Run executes all checks in parallel, bypassing the cache.
+func (hc *HealthChecker) Run(ctx context.Context) *Report {
+	hc.mu.RLock()
+
+	checks := make(map[string]check, len(hc.checks))
+	maps.Copy(checks, hc.checks)
+	hc.mu.RUnlock()
+
+	report := &Report{
+		Timestamp: time.Now(),
+		Checks:    make(map[string]CheckResult, len(checks)),
+		Status:    StatusUp,
+	}
+
+	var (
+		wg        sync.WaitGroup
+		resultsMu sync.Mutex
+	)
+
+	for name, c := range checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			result := runCheck(ctx, c)
+
+			resultsMu.Lock()
+			defer resultsMu.Unlock()
+
+			report.Checks[name] = result
+
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func (hc *HealthChecker) getCached() *Report {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+
+	if hc.cached == nil || time.Since(hc.cachedAt) >= hc.config.CacheTTL {
+		return nil
+	}
+
+	return hc.cached
+}
+
+func runCheck(ctx context.Context, c check) CheckResult {
+	start := time.Now()
+
+	checkCtx := ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+
+		checkCtx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("%w: %v", ErrCheckPanicked, r)
+			}
+		}()
+
+		done <- c.fn(checkCtx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-checkCtx.Done():
+		err = fmt.Errorf("%w (timeout=%s): %w", ErrCheckTimedOut, c.timeout, checkCtx.Err())
+	}
+
+	result := CheckResult{
+		Timestamp: start,
+		Status:    StatusUp,
+		Error:     "",
+		Latency:   time.Since(start),
+	}
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,259 @@
+package healthfx_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/healthfx"
+	"github.com/eser/ajan/httpfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDatabaseDown = errors.New("database down")
+
+type stubConnection struct {
+	err   error
+	state connfx.ConnectionState
+}
+
+func (c *stubConnection) GetBehaviors() []connfx.ConnectionBehavior      { return nil }
+func (c *stubConnection) GetCapabilities() []connfx.ConnectionCapability { return nil }
+func (c *stubConnection) GetProtocol() string                            { return "stub" }
+func (c *stubConnection) GetState() connfx.ConnectionState               { return c.state }
+func (c *stubConnection) Close(ctx context.Context) error                { return nil }
+func (c *stubConnection) GetRawConnection() any                          { return nil }
+
+func (c *stubConnection) HealthCheck(ctx context.Context) *connfx.HealthStatus {
+	return &connfx.HealthStatus{ //nolint:exhaustruct
+		Timestamp: time.Now(),
+		Error:     c.err,
+		State:     c.state,
+	}
+}
+
+func newTestChecker(cacheTTL time.Duration) *healthfx.HealthChecker {
+	return healthfx.NewHealthChecker(&healthfx.Config{
+		CheckTimeout: time.Second,
+		CacheTTL:     cacheTTL,
+	})
+}
+
+func TestHealthChecker_RunsChecksInParallel(t *testing.T) {
+	t.Parallel()
+
+	checker := newTestChecker(0)
+
+	const checkCount = 5
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		checker.Register(name, func(ctx context.Context) error {
+			time.Sleep(100 * time.Millisecond)
+
+			return nil
+		})
+	}
+
+	start := time.Now()
+	report := checker.Run(t.Context())
+
+	assert.Less(t, time.Since(start), checkCount*100*time.Millisecond)
+	assert.Equal(t, healthfx.StatusUp, report.Status)
+	assert.Len(t, report.Checks, checkCount)
+}
+
+func TestHealthChecker_TimeoutIsolation(t *testing.T) {
+	t.Parallel()
+
+	checker := newTestChecker(0)
+
+	checker.Register("fast", func(ctx context.Context) error {
+		return nil
+	})
+
+	// Ignores ctx entirely, so only the checker's own timeout can stop it
+	checker.RegisterWithTimeout("stuck", 50*time.Millisecond, func(ctx context.Context) error {
+		time.Sleep(time.Second)
+
+		return nil
+	})
+
+	checker.Register("failing", func(ctx context.Context) error {
+		return errDatabaseDown
+	})
+
+	start := time.Now()
+	report := checker.Run(t.Context())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, healthfx.StatusDown, report.Status)
+
+	assert.Equal(t, healthfx.StatusUp, report.Checks["fast"].Status)
+
+	assert.Equal(t, healthfx.StatusDown, report.Checks["stuck"].Status)
+	assert.Contains(t, report.Checks["stuck"].Error, healthfx.ErrCheckTimedOut.Error())
+
+	assert.Equal(t, healthfx.StatusDown, report.Checks["failing"].Status)
+	assert.Contains(t, report.Checks["failing"].Error, errDatabaseDown.Error())
+}
+
+func TestHealthChecker_RecoversPanics(t *testing.T) {
+	t.Parallel()
+
+	checker := newTestChecker(0)
+
+	checker.Register("panicking", func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	report := checker.Run(t.Context())
+
+	assert.Equal(t, healthfx.StatusDown, report.Status)
+	assert.Contains(t, report.Checks["panicking"].Error, healthfx.ErrCheckPanicked.Error())
+}
+
+func TestHealthChecker_CachesReadiness(t *testing.T) {
+	t.Parallel()
+
+	checker := newTestChecker(100 * time.Millisecond)
+
+	var calls atomic.Int32
+
+	checker.Register("counted", func(ctx context.Context) error {
+		calls.Add(1)
+
+		return nil
+	})
+
+	first := checker.Readiness(t.Context())
+	second := checker.Readiness(t.Context())
+
+	assert.Same(t, first, second)
+	assert.Equal(t, int32(1), calls.Load())
+
+	time.Sleep(150 * time.Millisecond)
+
+	third := checker.Readiness(t.Context())
+
+	assert.NotSame(t, first, third)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestHealthChecker_DoesNotCacheCanceledReadiness(t *testing.T) {
+	t.Parallel()
+
+	checker := newTestChecker(time.Minute)
+
+	checker.Register("slow", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		}
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	canceled := checker.Readiness(ctx)
+	assert.Equal(t, healthfx.StatusDown, canceled.Status)
+
+	report := checker.Readiness(t.Context())
+	assert.Equal(t, healthfx.StatusUp, report.Status)
+}
+
+func TestHealthChecker_HTTPHandlers(t *testing.T) {
+	t.Parallel()
+
+	checker := newTestChecker(0)
+
+	var healthy atomic.Bool
+
+	checker.Register("database", func(ctx context.Context) error {
+		if !healthy.Load() {
+			return errDatabaseDown
+		}
+
+		return nil
+	})
+
+	router := httpfx.NewRouter("/")
+	healthfx.RegisterHTTPRoutes(router, checker)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		recorder := httptest.NewRecorder()
+		router.GetMux().ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	// Liveness does not depend on the failing dependency
+	live := serve(healthfx.DefaultLivenessPath)
+	assert.Equal(t, http.StatusOK, live.Code)
+	assert.JSONEq(t, `{"status":"up"}`, live.Body.String())
+
+	notReady := serve(healthfx.DefaultReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, notReady.Code)
+
+	var report healthfx.Report
+
+	require.NoError(t, json.Unmarshal(notReady.Body.Bytes(), &report))
+	assert.Equal(t, healthfx.StatusDown, report.Status)
+	assert.Equal(t, healthfx.StatusDown, report.Checks["database"].Status)
+
+	healthy.Store(true)
+
+	ready := serve(healthfx.DefaultReadinessPath)
+	assert.Equal(t, http.StatusOK, ready.Code)
+}
+
+func TestConnectionCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		conn        *stubConnection
+		name        string
+		expectedErr bool
+	}{
+		{
+			name:        "ready",
+			conn:        &stubConnection{err: nil, state: connfx.ConnectionStateReady},
+			expectedErr: false,
+		},
+		{
+			name:        "disconnected",
+			conn:        &stubConnection{err: nil, state: connfx.ConnectionStateDisconnected},
+			expectedErr: true,
+		},
+		{
+			name:        "error",
+			conn:        &stubConnection{err: errDatabaseDown, state: connfx.ConnectionStateError},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := healthfx.ConnectionCheck(tt.conn)(t.Context())
+
+			if !tt.expectedErr {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, healthfx.ErrConnectionUnhealthy)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package healthfx
+
+import (
+	"net/http"
+
+	"github.com/eser/ajan/httpfx"
+)
+
+const (
+	DefaultLivenessPath  = "/health/live"
+	DefaultReadinessPath = "/health/ready"
+)
+
+// LivenessResponse is the body returned by the liveness handler.
+type LivenessResponse struct {
+	Status Status `json:"status"`
+}
+
+// LivenessHandler responds with the process status without running any checks.
+func (hc *HealthChecker) LivenessHandler() httpfx.Handler {
+	return func(ctx *httpfx.Context) httpfx.Result {
+		return ctx.Results.JSON(LivenessResponse{Status: hc.Liveness()})
+	}
+}
+
+// ReadinessHandler responds with the detailed report, using 503 Service Unavailable
+// when any check is down.
+func (hc *HealthChecker) ReadinessHandler() httpfx.Handler {
+	return func(ctx *httpfx.Context) httpfx.Result {
+		report := hc.Readiness(ctx.Request.Context())
+
+		if report.Status != StatusUp {
+			return ctx.Results.Error(http.StatusServiceUnavailable, httpfx.WithJSON(report))
+		}
+
+		return ctx.Results.JSON(report)
+	}
+}
+
+// RegisterHTTPRoutes registers the liveness and readiness endpoints.
+func RegisterHTTPRoutes(routes *httpfx.Router, checker *HealthChecker) {
+	routes.
+		Route("GET "+DefaultLivenessPath, checker.LivenessHandler()).
+		HasSummary("Liveness").
+		HasDescription("Reports whether the process is running").
+		HasResponse(http.StatusOK)
+
+	routes.
+		Route("GET "+DefaultReadinessPath, checker.ReadinessHandler()).
+		HasSummary("Readiness").
+		HasDescription("Reports whether all dependencies are healthy").
+		HasResponse(http.StatusOK).
+		HasResponse(http.StatusServiceUnavailable)
+}
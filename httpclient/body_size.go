@@ -0,0 +1,153 @@
+package httpclient
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/eser/ajan/metricsfx"
+)
+
+// Host classes returned by ClassifyHost.
+const (
+	HostClassLoopback = "loopback"
+	HostClassPrivate  = "private"
+	HostClassPublic   = "public"
+)
+
+// ClassifyHost groups a host (optionally with a port) into loopback, private or public.
+// Private covers private and link-local IP ranges as well as single-label names and the
+// .local, .internal and .svc suffixes used for in-cluster services.
+func ClassifyHost(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	host = strings.ToLower(strings.Trim(host, "[]"))
+
+	if ip := net.ParseIP(host); ip != nil {
+		switch {
+		case ip.IsLoopback():
+			return HostClassLoopback
+		case ip.IsPrivate(), ip.IsLinkLocalUnicast():
+			return HostClassPrivate
+		default:
+			return HostClassPublic
+		}
+	}
+
+	host = strings.TrimSuffix(host, ".")
+
+	switch {
+	case host == "localhost" || strings.HasSuffix(host, ".localhost"):
+		return HostClassLoopback
+	case !strings.Contains(host, "."),
+		strings.HasSuffix(host, ".local"),
+		strings.HasSuffix(host, ".internal"),
+		strings.HasSuffix(host, ".svc"),
+		strings.Contains(host, ".svc."):
+		return HostClassPrivate
+	default:
+		return HostClassPublic
+	}
+}
+
+// countingReadCloser counts the bytes read from a body and reports the total once, when
+// the body reaches EOF or is closed, whichever comes first.
+type countingReadCloser struct {
+	io.ReadCloser
+
+	report func(size int64)
+	once   sync.Once
+	read   atomic.Int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.read.Add(int64(n))
+
+	if err == io.EOF {
+		c.done()
+	}
+
+	return n, err //nolint:wrapcheck
+}
+
+func (c *countingReadCloser) Close() error {
+	c.done()
+
+	return c.ReadCloser.Close() //nolint:wrapcheck
+}
+
+func (c *countingReadCloser) done() {
+	c.once.Do(func() {
+		c.report(c.read.Load())
+	})
+}
+
+// sizeAttrs returns the labels of the size histograms for req.
+func (t *ResilientTransport) sizeAttrs(req *http.Request) []metricsfx.Attribute {
+	classify := t.Metrics.HostClassifier
+	if classify == nil {
+		classify = ClassifyHost
+	}
+
+	return []metricsfx.Attribute{
+		metricsfx.StringAttr("method", req.Method),
+		metricsfx.StringAttr("host_class", classify(req.URL.Host)),
+	}
+}
+
+// measureRequest records the request body size. A body of unknown length (e.g. chunked
+// uploads) is counted as it is sent, on a shallow copy so the caller's request is untouched.
+func (t *ResilientTransport) measureRequest(req *http.Request) *http.Request {
+	if t.Metrics == nil || t.Metrics.RequestSize == nil {
+		return req
+	}
+
+	ctx := req.Context()
+	attrs := t.sizeAttrs(req)
+
+	if req.Body == nil || req.Body == http.NoBody || req.ContentLength > 0 {
+		t.Metrics.RequestSize.Record(ctx, float64(max(req.ContentLength, 0)), attrs...)
+
+		return req
+	}
+
+	measured := req.Clone(ctx)
+	measured.Body = &countingReadCloser{ //nolint:exhaustruct
+		ReadCloser: req.Body,
+		report: func(size int64) {
+			t.Metrics.RequestSize.Record(ctx, float64(size), attrs...)
+		},
+	}
+
+	return measured
+}
+
+// measureResponse records the response body size, from Content-Length when the server
+// sent one and otherwise by counting the bytes the caller reads.
+func (t *ResilientTransport) measureResponse(req *http.Request, resp *http.Response) {
+	if t.Metrics == nil || t.Metrics.ResponseSize == nil || resp == nil {
+		return
+	}
+
+	ctx := req.Context()
+	attrs := t.sizeAttrs(req)
+
+	if resp.ContentLength >= 0 || resp.Body == nil {
+		t.Metrics.ResponseSize.Record(ctx, float64(max(resp.ContentLength, 0)), attrs...)
+
+		return
+	}
+
+	resp.Body = &countingReadCloser{ //nolint:exhaustruct
+		ReadCloser: resp.Body,
+		report: func(size int64) {
+			t.Metrics.ResponseSize.Record(ctx, float64(size), attrs...)
+		},
+	}
+}
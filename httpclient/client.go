@@ -3,6 +3,8 @@ package httpclient
 import (
 	"crypto/tls"
 	"net/http"
+
+	"github.com/eser/ajan/logfx"
 )
 
 // Client is a drop-in replacement for http.Client with built-in circuit breaker and retry mechanisms.
@@ -12,6 +14,8 @@ type Client struct {
 	Config          *Config
 	Transport       *ResilientTransport
 	TLSClientConfig *tls.Config
+	Logger          *logfx.Logger
+	Metrics         *Metrics
 }
 
 // NewClient creates a new http client with the specified circuit breaker and retry strategy.
@@ -19,6 +23,8 @@ func NewClient(options ...NewClientOption) *Client {
 	client := &Client{
 		Client:          nil,
 		TLSClientConfig: nil,
+		Logger:          nil,
+		Metrics:         nil,
 
 		Config: &Config{
 			CircuitBreaker: CircuitBreakerConfig{
@@ -34,9 +40,12 @@ func NewClient(options ...NewClientOption) *Client {
 				MaxInterval:     DefaultMaxInterval,
 				Multiplier:      DefaultMultiplier,
 				RandomFactor:    DefaultRandomFactor,
+				MaxRetryAfter:   DefaultMaxRetryAfter,
+				MinRetryAfter:   DefaultMinRetryAfter,
 			},
 
-			ServerErrorThreshold: DefaultServerErrorThreshold,
+			ServerErrorThreshold:  DefaultServerErrorThreshold,
+			SlowResponseThreshold: 0,
 		},
 		Transport: nil,
 	}
@@ -64,6 +73,8 @@ func NewClient(options ...NewClientOption) *Client {
 			transport,
 			client.Config,
 		)
+		resilientTransport.Logger = client.Logger
+		resilientTransport.Metrics = client.Metrics
 
 		client.Transport = resilientTransport
 	}
@@ -1,8 +1,10 @@
 package httpclient_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
@@ -10,6 +12,8 @@ import (
 	"time"
 
 	"github.com/eser/ajan/httpclient"
+	"github.com/eser/ajan/logfx"
+	"github.com/eser/ajan/metricsfx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -393,3 +397,76 @@ func TestClientContextCancellation(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), context.DeadlineExceeded.Error())
 }
+
+func TestClientSlowResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		delay      time.Duration
+		expectWarn bool
+	}{
+		{name: "response slower than threshold", delay: 80 * time.Millisecond, expectWarn: true},
+		{name: "response within threshold", delay: 0, expectWarn: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(tt.delay)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			var logBuffer bytes.Buffer
+
+			logger := logfx.NewLogger(
+				logfx.WithFromSlog(slog.New(slog.NewJSONHandler(&logBuffer, nil))),
+			)
+
+			provider := metricsfx.NewMetricsProvider(&metricsfx.Config{ //nolint:exhaustruct
+				ServiceName:                   "test-service",
+				NoNativeCollectorRegistration: true,
+			}, nil)
+			require.NoError(t, provider.Init())
+
+			metrics := httpclient.NewMetrics(provider)
+			require.NoError(t, metrics.Init())
+
+			client := httpclient.NewClient(
+				httpclient.WithConfig(&httpclient.Config{ //nolint:exhaustruct
+					ServerErrorThreshold:  500,
+					SlowResponseThreshold: 40 * time.Millisecond,
+				}),
+				httpclient.WithLogger(logger),
+				httpclient.WithMetrics(metrics),
+			)
+
+			req, err := http.NewRequestWithContext(
+				t.Context(),
+				http.MethodGet,
+				server.URL+"/slow",
+				nil,
+			)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			defer closeBody(t, resp)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			if !tt.expectWarn {
+				assert.Empty(t, logBuffer.String())
+
+				return
+			}
+
+			output := logBuffer.String()
+			assert.Contains(t, output, `"msg":"slow HTTP response"`)
+			assert.Contains(t, output, `"url":"`+server.URL+`/slow"`)
+			assert.Contains(t, output, `"duration":`)
+		})
+	}
+}
@@ -9,6 +9,10 @@ type Config struct {
 	RetryStrategy  RetryStrategyConfig  `conf:"retry_strategy"`
 
 	ServerErrorThreshold int `conf:"server_error_threshold" default:"500"`
+
+	// SlowResponseThreshold flags successful responses that take longer than this,
+	// retries included. Zero disables the check.
+	SlowResponseThreshold time.Duration `conf:"slow_response_threshold"`
 }
 
 type CircuitBreakerConfig struct {
@@ -25,4 +29,6 @@ type RetryStrategyConfig struct {
 	MaxInterval     time.Duration `conf:"max_interval"     default:"10s"`
 	Multiplier      float64       `conf:"multiplier"       default:"2"`
 	RandomFactor    float64       `conf:"random_factor"    default:"0.1"`
+	MaxRetryAfter   time.Duration `conf:"max_retry_after"  default:"30s"`
+	MinRetryAfter   time.Duration `conf:"min_retry_after"  default:"100ms"`
 }
@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eser/ajan/metricsfx"
+)
+
+var (
+	ErrFailedToBuildSlowResponsesCounter = errors.New(
+		"failed to build HTTP client slow responses counter",
+	)
+	ErrFailedToBuildRequestSizeHistogram = errors.New(
+		"failed to build HTTP client request size histogram",
+	)
+	ErrFailedToBuildResponseSizeHistogram = errors.New(
+		"failed to build HTTP client response size histogram",
+	)
+)
+
+// Metrics holds the metrics recorded by the resilient transport.
+type Metrics struct {
+	Provider *metricsfx.MetricsProvider
+
+	SlowResponses *metricsfx.CounterMetric
+	RequestSize   *metricsfx.HistogramMetric
+	ResponseSize  *metricsfx.HistogramMetric
+
+	// HostClassifier maps a request host to the low-cardinality host_class label of the
+	// size histograms. Defaults to ClassifyHost.
+	HostClassifier func(host string) string
+}
+
+// NewMetrics creates HTTP client metrics backed by the given provider. Call Init before use.
+func NewMetrics(provider *metricsfx.MetricsProvider) *Metrics {
+	return &Metrics{
+		Provider: provider,
+
+		SlowResponses: nil,
+		RequestSize:   nil,
+		ResponseSize:  nil,
+
+		HostClassifier: ClassifyHost,
+	}
+}
+
+func (metrics *Metrics) Init() error {
+	builder := metrics.Provider.NewBuilder()
+
+	slowResponses, err := builder.Counter(
+		"http_client_slow_responses_total",
+		"Total number of successful HTTP client responses slower than the configured threshold",
+	).WithUnit("{response}").Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildSlowResponsesCounter, err)
+	}
+
+	metrics.SlowResponses = slowResponses
+
+	requestSize, err := builder.Histogram(
+		"http_client_request_size_bytes",
+		"Size of HTTP client request bodies in bytes",
+	).WithUnit("By").WithBuckets(bodySizeBuckets()...).Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildRequestSizeHistogram, err)
+	}
+
+	metrics.RequestSize = requestSize
+
+	responseSize, err := builder.Histogram(
+		"http_client_response_size_bytes",
+		"Size of HTTP client response bodies in bytes",
+	).WithUnit("By").WithBuckets(bodySizeBuckets()...).Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildResponseSizeHistogram, err)
+	}
+
+	metrics.ResponseSize = responseSize
+
+	return nil
+}
+
+func bodySizeBuckets() []float64 {
+	return []float64{0, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216}
+}
@@ -0,0 +1,216 @@
+package httpclient_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/httpclient"
+	"github.com/eser/ajan/metricsfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// histogramSummary totals the observations of an exported histogram and keeps the
+// attribute sets it was recorded with.
+type histogramSummary struct {
+	attrs []string
+	count uint64
+	sum   float64
+}
+
+// recordingExporter summarizes exported histograms. The SDK reuses the exported data
+// after Export returns, so it is summarized right away.
+type recordingExporter struct {
+	histograms map[string]histogramSummary
+	mu         sync.Mutex
+}
+
+func (e *recordingExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (e *recordingExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *recordingExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, scope := range metrics.ScopeMetrics {
+		for _, metric := range scope.Metrics {
+			data, ok := metric.Data.(metricdata.Histogram[float64])
+			if !ok {
+				continue
+			}
+
+			var summary histogramSummary
+
+			encoder := attribute.DefaultEncoder()
+
+			for _, point := range data.DataPoints {
+				summary.count += point.Count
+				summary.sum += point.Sum
+				summary.attrs = append(summary.attrs, point.Attributes.Encoded(encoder))
+			}
+
+			e.histograms[metric.Name] = summary
+		}
+	}
+
+	return nil
+}
+
+func (e *recordingExporter) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) histogram(name string) (histogramSummary, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	summary, ok := e.histograms[name]
+
+	return summary, ok
+}
+
+// stubOTLPConnection exposes an exporter the way connfx OTLP connections do.
+type stubOTLPConnection struct {
+	exporter sdkmetric.Exporter
+}
+
+func (c *stubOTLPConnection) GetMetricExporter() sdkmetric.Exporter {
+	return c.exporter
+}
+
+type stubRegistry struct {
+	connections map[string]any
+}
+
+func (r *stubRegistry) GetNamed(name string) any {
+	return r.connections[name]
+}
+
+func TestClientBodySizeMetrics(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+
+		if r.URL.Path == "/chunked" {
+			// Flushing before the handler returns forces a chunked response
+			_, _ = io.WriteString(w, "chunk-one,")
+			w.(http.Flusher).Flush() //nolint:forcetypeassert
+			_, _ = io.WriteString(w, "chunk-two")
+
+			return
+		}
+
+		_, _ = io.WriteString(w, "hello world")
+	}))
+	defer server.Close()
+
+	exporter := &recordingExporter{histograms: make(map[string]histogramSummary), mu: sync.Mutex{}}
+	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{
+		ServiceName:                   "test-service",
+		ServiceVersion:                "1.0.0",
+		ServiceInstanceID:             "",
+		OTLPConnectionName:            "otel",
+		ExportInterval:                time.Hour,
+		NoNativeCollectorRegistration: true,
+		RequireNativeCollectors:       false,
+	}, &stubRegistry{
+		connections: map[string]any{"otel": &stubOTLPConnection{exporter: exporter}},
+	})
+	require.NoError(t, provider.Init())
+
+	metrics := httpclient.NewMetrics(provider)
+	require.NoError(t, metrics.Init())
+
+	client := httpclient.NewClient(httpclient.WithMetrics(metrics))
+
+	send := func(path string, body io.Reader, getBody func() (io.ReadCloser, error)) string {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, server.URL+path, body)
+		require.NoError(t, err)
+
+		if getBody != nil {
+			req.GetBody = getBody
+		}
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+
+		defer closeBody(t, resp)
+
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return string(respBody)
+	}
+
+	// Known request length, Content-Length response
+	assert.Equal(t, "hello world", send("/fixed", strings.NewReader("hello"), nil))
+
+	// Unknown request length (streamed), chunked response without Content-Length
+	streamed := io.MultiReader(strings.NewReader("streamed "), strings.NewReader("body"))
+	getBody := func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("streamed body")), nil
+	}
+	assert.Equal(t, "chunk-one,chunk-two", send("/chunked", streamed, getBody))
+
+	// Shutting down flushes the periodic reader to the exporter
+	require.NoError(t, provider.Shutdown(t.Context()))
+
+	requestSize, ok := exporter.histogram("http_client_request_size_bytes")
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), requestSize.count)
+	assert.InDelta(t, float64(len("hello")+len("streamed body")), requestSize.sum, 0)
+	assert.Equal(t, []string{"host_class=loopback,method=POST"}, requestSize.attrs)
+
+	responseSize, ok := exporter.histogram("http_client_response_size_bytes")
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), responseSize.count)
+	assert.InDelta(t, float64(len("hello world")+len("chunk-one,chunk-two")), responseSize.sum, 0)
+	assert.Equal(t, []string{"host_class=loopback,method=POST"}, responseSize.attrs)
+}
+
+func TestClassifyHost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{host: "localhost:8080", expected: httpclient.HostClassLoopback},
+		{host: "127.0.0.1", expected: httpclient.HostClassLoopback},
+		{host: "[::1]:443", expected: httpclient.HostClassLoopback},
+		{host: "10.1.2.3:5432", expected: httpclient.HostClassPrivate},
+		{host: "192.168.0.10", expected: httpclient.HostClassPrivate},
+		{host: "payments", expected: httpclient.HostClassPrivate},
+		{host: "payments.default.svc.cluster.local", expected: httpclient.HostClassPrivate},
+		{host: "metadata.google.internal", expected: httpclient.HostClassPrivate},
+		{host: "api.example.com:443", expected: httpclient.HostClassPublic},
+		{host: "8.8.8.8", expected: httpclient.HostClassPublic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, httpclient.ClassifyHost(tt.host))
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package httpclient
 
-import "crypto/tls"
+import (
+	"crypto/tls"
+
+	"github.com/eser/ajan/logfx"
+)
 
 type NewClientOption func(*Client)
 
@@ -15,3 +19,17 @@ func WithTLSClientConfig(tlsConfig *tls.Config) NewClientOption {
 		client.TLSClientConfig = tlsConfig
 	}
 }
+
+func WithLogger(logger *logfx.Logger) NewClientOption {
+	return func(client *Client) {
+		client.Logger = logger
+	}
+}
+
+// WithMetrics records transport metrics such as slow responses. The metrics must
+// already be initialized with Init.
+func WithMetrics(metrics *Metrics) NewClientOption {
+	return func(client *Client) {
+		client.Metrics = metrics
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"crypto/rand"
 	"math"
 	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +16,8 @@ const (
 	DefaultMaxInterval     = 10 * time.Second
 	DefaultMultiplier      = 2.0
 	DefaultRandomFactor    = 0.1
+	DefaultMaxRetryAfter   = 30 * time.Second
+	DefaultMinRetryAfter   = 100 * time.Millisecond
 	randomNumberRange      = 1000 // Range for random number generation in jitter calculation
 )
 
@@ -55,3 +60,58 @@ func (r *RetryStrategy) NextBackoff(attempt uint) time.Duration {
 
 	return time.Duration(backoff)
 }
+
+// RetryAfter parses a server-provided Retry-After header value (delay-seconds or HTTP-date)
+// and clamps it to the configured bounds. Unset bounds fall back to DefaultMinRetryAfter
+// and DefaultMaxRetryAfter, so "Retry-After: 0" never retries in a hot loop. It returns
+// the requested delay, the delay to apply, and whether the header was present and valid.
+func (r *RetryStrategy) RetryAfter(
+	header string,
+	now time.Time,
+) (time.Duration, time.Duration, bool) {
+	requested, ok := parseRetryAfter(header, now)
+	if !ok {
+		return 0, 0, false
+	}
+
+	minDelay := r.Config.MinRetryAfter
+	if minDelay <= 0 {
+		minDelay = DefaultMinRetryAfter
+	}
+
+	maxDelay := r.Config.MaxRetryAfter
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxRetryAfter
+	}
+
+	applied := min(max(requested, minDelay), max(maxDelay, minDelay))
+
+	return requested, applied, true
+}
+
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(header, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		// Avoid overflowing time.Duration on absurd values
+		if seconds > int64(math.MaxInt64/int64(time.Second)) {
+			return time.Duration(math.MaxInt64), true
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
@@ -0,0 +1,323 @@
+package httpclient_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/httpclient"
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryStrategy_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	strategy := httpclient.NewRetryStrategy(&httpclient.RetryStrategyConfig{ //nolint:exhaustruct
+		MaxInterval:   10 * time.Second,
+		MaxRetryAfter: 5 * time.Second,
+		MinRetryAfter: 100 * time.Millisecond,
+	})
+
+	tests := []struct {
+		name              string
+		header            string
+		expectedRequested time.Duration
+		expectedApplied   time.Duration
+		expectedOk        bool
+	}{
+		{
+			name:              "within_bounds",
+			header:            "2",
+			expectedRequested: 2 * time.Second,
+			expectedApplied:   2 * time.Second,
+			expectedOk:        true,
+		},
+		{
+			name:              "oversized_is_capped",
+			header:            "3600",
+			expectedRequested: time.Hour,
+			expectedApplied:   5 * time.Second,
+			expectedOk:        true,
+		},
+		{
+			name:              "zero_is_floored",
+			header:            "0",
+			expectedRequested: 0,
+			expectedApplied:   100 * time.Millisecond,
+			expectedOk:        true,
+		},
+		{
+			name:              "http_date",
+			header:            now.Add(3 * time.Second).Format(http.TimeFormat),
+			expectedRequested: 3 * time.Second,
+			expectedApplied:   3 * time.Second,
+			expectedOk:        true,
+		},
+		{
+			name:       "missing",
+			header:     "",
+			expectedOk: false,
+		},
+		{
+			name:       "invalid",
+			header:     "soon",
+			expectedOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			requested, applied, ok := strategy.RetryAfter(tt.header, now)
+
+			assert.Equal(t, tt.expectedOk, ok)
+			assert.Equal(t, tt.expectedRequested, requested)
+			assert.Equal(t, tt.expectedApplied, applied)
+		})
+	}
+}
+
+func TestRetryStrategy_RetryAfter_UnsetBounds(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// A config built as a struct literal, as WithConfig callers do, leaves the bounds unset
+	strategy := httpclient.NewRetryStrategy(&httpclient.RetryStrategyConfig{ //nolint:exhaustruct
+		MaxInterval: 10 * time.Second,
+	})
+
+	_, applied, ok := strategy.RetryAfter("0", now)
+	require.True(t, ok)
+	assert.Equal(t, httpclient.DefaultMinRetryAfter, applied)
+
+	_, applied, ok = strategy.RetryAfter("3600", now)
+	require.True(t, ok)
+	assert.Equal(t, httpclient.DefaultMaxRetryAfter, applied)
+}
+
+func TestClientRetryAfterClamping(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		retryAfter string
+		minDelay   time.Duration
+		maxDelay   time.Duration
+	}{
+		{
+			name:       "oversized_retry_after_is_capped",
+			retryAfter: "3600",
+			minDelay:   0,
+			maxDelay:   time.Second,
+		},
+		{
+			name:       "zero_retry_after_is_floored",
+			retryAfter: "0",
+			minDelay:   50 * time.Millisecond,
+			maxDelay:   time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				attempts []time.Time
+				mu       sync.Mutex
+			)
+
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					mu.Lock()
+					attempts = append(attempts, time.Now())
+					count := len(attempts)
+					mu.Unlock()
+
+					if count == 1 {
+						w.Header().Set("Retry-After", tt.retryAfter)
+						w.WriteHeader(http.StatusServiceUnavailable)
+
+						return
+					}
+
+					w.WriteHeader(http.StatusOK)
+				}),
+			)
+			defer server.Close()
+
+			var logs bytes.Buffer
+
+			logger := logfx.NewLogger(
+				logfx.WithFromSlog(slog.New(slog.NewTextHandler(&logs, nil))),
+			)
+
+			client := httpclient.NewClient(
+				httpclient.WithLogger(logger),
+				httpclient.WithConfig(&httpclient.Config{
+					CircuitBreaker: httpclient.CircuitBreakerConfig{ //nolint:exhaustruct
+						Enabled: false,
+					},
+					RetryStrategy: httpclient.RetryStrategyConfig{
+						Enabled:         true,
+						MaxAttempts:     3,
+						InitialInterval: time.Millisecond,
+						MaxInterval:     time.Second,
+						Multiplier:      1.0,
+						RandomFactor:    0,
+						MaxRetryAfter:   50 * time.Millisecond,
+						MinRetryAfter:   50 * time.Millisecond,
+					},
+					ServerErrorThreshold: 500,
+				}),
+			)
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			defer closeBody(t, resp)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			require.Len(t, attempts, 2)
+
+			delay := attempts[1].Sub(attempts[0])
+			assert.GreaterOrEqual(t, delay, tt.minDelay)
+			assert.Less(t, delay, tt.maxDelay)
+
+			assert.Contains(t, logs.String(), "clamped server Retry-After delay")
+		})
+	}
+}
+
+func TestClientRetriesTooManyRequestsWithRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		attempts    []time.Time
+		connections int
+		mu          sync.Mutex
+	)
+
+	server := httptest.NewUnstartedServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			attempts = append(attempts, time.Now())
+			count := len(attempts)
+			mu.Unlock()
+
+			if count == 1 {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte("slow down"))
+
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			connections++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := httpclient.NewClient(
+		httpclient.WithConfig(&httpclient.Config{
+			CircuitBreaker: httpclient.CircuitBreakerConfig{ //nolint:exhaustruct
+				Enabled: false,
+			},
+			RetryStrategy: httpclient.RetryStrategyConfig{
+				Enabled:         true,
+				MaxAttempts:     3,
+				InitialInterval: time.Millisecond,
+				MaxInterval:     time.Second,
+				Multiplier:      1.0,
+				RandomFactor:    0,
+				MaxRetryAfter:   50 * time.Millisecond,
+				MinRetryAfter:   time.Millisecond,
+			},
+			ServerErrorThreshold: 500,
+		}),
+	)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	defer closeBody(t, resp)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, attempts, 2)
+
+	delay := attempts[1].Sub(attempts[0])
+	assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+	assert.Less(t, delay, time.Second)
+
+	// The throttled response body is drained and closed, so the retry reuses its connection.
+	assert.Equal(t, 1, connections)
+}
+
+func TestClientReturnsTooManyRequestsWithoutRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var attempts int
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+
+			w.WriteHeader(http.StatusTooManyRequests)
+		}),
+	)
+	defer server.Close()
+
+	client := httpclient.NewClient(
+		httpclient.WithConfig(&httpclient.Config{
+			CircuitBreaker: httpclient.CircuitBreakerConfig{ //nolint:exhaustruct
+				Enabled: false,
+			},
+			RetryStrategy: httpclient.RetryStrategyConfig{ //nolint:exhaustruct
+				Enabled:         true,
+				MaxAttempts:     3,
+				InitialInterval: time.Millisecond,
+				MaxInterval:     time.Second,
+				Multiplier:      1.0,
+			},
+			ServerErrorThreshold: 500,
+		}),
+	)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	defer closeBody(t, resp)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, 1, attempts)
+}
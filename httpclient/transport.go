@@ -3,8 +3,13 @@ package httpclient
 import (
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/eser/ajan/logfx"
+	"github.com/eser/ajan/metricsfx"
 )
 
 const (
@@ -13,6 +18,10 @@ const (
 	DefaultFailureThreshold = 5
 	DefaultResetTimeout     = 10 * time.Second
 	DefaultHalfOpenSuccess  = 2
+
+	// maxDrainBytes bounds how much of a discarded response body is read so its
+	// connection can be reused.
+	maxDrainBytes = 64 << 10
 )
 
 var (
@@ -35,6 +44,8 @@ var (
 type ResilientTransport struct {
 	Transport http.RoundTripper
 	Config    *Config
+	Logger    *logfx.Logger
+	Metrics   *Metrics
 
 	CircuitBreaker *CircuitBreaker
 	RetryStrategy  *RetryStrategy
@@ -50,13 +61,28 @@ func NewResilientTransport(
 	return &ResilientTransport{
 		Transport: transport,
 		Config:    config,
+		Logger:    nil,
+		Metrics:   nil,
 
 		CircuitBreaker: cb,
 		RetryStrategy:  rs,
 	}
 }
 
-func (t *ResilientTransport) RoundTrip( //nolint:cyclop,gocognit,funlen
+// RoundTrip sends req with the configured circuit breaker and retry strategy, recording
+// body sizes when metrics are enabled.
+func (t *ResilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = t.measureRequest(req)
+
+	resp, err := t.roundTrip(req)
+	if err == nil {
+		t.measureResponse(req, resp)
+	}
+
+	return resp, err
+}
+
+func (t *ResilientTransport) roundTrip( //nolint:cyclop,gocognit,funlen
 	req *http.Request,
 ) (*http.Response, error) {
 	// Check circuit breaker before starting (only if enabled)
@@ -68,6 +94,8 @@ func (t *ResilientTransport) RoundTrip( //nolint:cyclop,gocognit,funlen
 		return nil, ErrRequestBodyNotRetriable
 	}
 
+	startedAt := time.Now()
+
 	var lastErr error
 
 	var resp *http.Response
@@ -85,7 +113,7 @@ func (t *ResilientTransport) RoundTrip( //nolint:cyclop,gocognit,funlen
 		if attempt > 0 && t.Config.RetryStrategy.Enabled {
 			var err error
 
-			req, err = t.handleRetry(req, attempt)
+			req, err = t.handleRetry(req, attempt, resp)
 			if err != nil {
 				return nil, err
 			}
@@ -95,12 +123,16 @@ func (t *ResilientTransport) RoundTrip( //nolint:cyclop,gocognit,funlen
 		resp, lastErr = t.handleRequest(req)
 
 		// If request was successful, return immediately
-		if lastErr == nil && resp.StatusCode < t.Config.ServerErrorThreshold {
+		if lastErr == nil && !t.isRetryable(resp) {
+			t.reportSlowResponse(req, resp, time.Since(startedAt))
+
 			return resp, nil
 		}
 
 		// Check circuit breaker after failure (only if enabled)
 		if t.Config.CircuitBreaker.Enabled && !t.CircuitBreaker.IsAllowed() {
+			discardBody(resp)
+
 			return nil, ErrCircuitOpen
 		}
 
@@ -120,10 +152,12 @@ func (t *ResilientTransport) RoundTrip( //nolint:cyclop,gocognit,funlen
 		return nil, fmt.Errorf("%w: %w", ErrTransportError, lastErr)
 	}
 
-	// We have a response but it's a server error
-	if resp != nil && resp.StatusCode >= t.Config.ServerErrorThreshold {
+	// We have a response but it's a server error or a throttling response
+	if resp != nil && t.isRetryable(resp) {
 		// If retries were enabled and exhausted, return retry error
 		if t.Config.RetryStrategy.Enabled && maxAttempts > 1 {
+			discardBody(resp)
+
 			return nil, ErrMaxRetries
 		}
 		// Otherwise return the server error response
@@ -175,13 +209,40 @@ func (t *ResilientTransport) handleRequest(req *http.Request) (*http.Response, e
 	return resp, nil
 }
 
+// isRetryable reports whether resp should be retried: any status at or above the server
+// error threshold, and 429 or 503 responses that carry a Retry-After header.
+func (t *ResilientTransport) isRetryable(resp *http.Response) bool {
+	if resp.StatusCode >= t.Config.ServerErrorThreshold {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return resp.Header.Get("Retry-After") != ""
+	default:
+		return false
+	}
+}
+
 // handleRetry manages the retry backoff and request cloning.
-func (t *ResilientTransport) handleRetry(req *http.Request, attempt uint) (*http.Request, error) {
+// A Retry-After header on the previous response takes precedence over the computed backoff.
+// The previous response body is drained and closed so its connection can be reused.
+func (t *ResilientTransport) handleRetry(
+	req *http.Request,
+	attempt uint,
+	prevResp *http.Response,
+) (*http.Request, error) {
+	discardBody(prevResp)
+
 	backoff := t.RetryStrategy.NextBackoff(attempt)
 	if backoff <= 0 {
 		return nil, ErrMaxRetries
 	}
 
+	if prevResp != nil {
+		backoff = t.retryAfterBackoff(req, prevResp, backoff)
+	}
+
 	timer := time.NewTimer(backoff)
 	defer timer.Stop()
 
@@ -193,3 +254,77 @@ func (t *ResilientTransport) handleRetry(req *http.Request, attempt uint) (*http
 
 	return req.Clone(req.Context()), nil
 }
+
+// retryAfterBackoff returns the delay requested by the response's Retry-After header,
+// clamped to the configured bounds, or the given fallback when the header is absent.
+func (t *ResilientTransport) retryAfterBackoff(
+	req *http.Request,
+	resp *http.Response,
+	fallback time.Duration,
+) time.Duration {
+	requested, applied, ok := t.RetryStrategy.RetryAfter(
+		resp.Header.Get("Retry-After"),
+		time.Now(),
+	)
+	if !ok {
+		return fallback
+	}
+
+	if requested != applied && t.Logger != nil {
+		t.Logger.WarnContext(
+			req.Context(),
+			"clamped server Retry-After delay",
+			slog.String("url", req.URL.Redacted()),
+			slog.Duration("requested", requested),
+			slog.Duration("applied", applied),
+		)
+	}
+
+	return applied
+}
+
+// discardBody drains (up to maxDrainBytes) and closes the body of a response that will
+// not be returned to the caller.
+func discardBody(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	_ = resp.Body.Close()
+}
+
+// reportSlowResponse logs and counts a successful response that took longer than the
+// configured SlowResponseThreshold.
+func (t *ResilientTransport) reportSlowResponse(
+	req *http.Request,
+	resp *http.Response,
+	elapsed time.Duration,
+) {
+	threshold := t.Config.SlowResponseThreshold
+	if threshold <= 0 || elapsed <= threshold {
+		return
+	}
+
+	ctx := req.Context()
+
+	if t.Logger != nil {
+		t.Logger.WarnContext(
+			ctx,
+			"slow HTTP response",
+			slog.String("url", req.URL.Redacted()),
+			slog.String("method", req.Method),
+			slog.Int("status", resp.StatusCode),
+			slog.Duration("duration", elapsed),
+			slog.Duration("threshold", threshold),
+		)
+	}
+
+	if t.Metrics != nil && t.Metrics.SlowResponses != nil {
+		t.Metrics.SlowResponses.Inc(
+			ctx,
+			metricsfx.StringAttr("host", req.URL.Host),
+			metricsfx.StringAttr("method", req.Method),
+		)
+	}
+}
@@ -0,0 +1,172 @@
+package httpfx
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/eser/ajan/httpfx/uris"
+)
+
+const pathTagName = "path"
+
+var (
+	ErrInvalidBindTarget      = errors.New("bind target must be a non-nil pointer to a struct")
+	ErrUnknownPathParameter   = errors.New("path parameter is not in the route pattern")
+	ErrMissingPathParameter   = errors.New("required path parameter is missing")
+	ErrInvalidPathParameter   = errors.New("invalid path parameter")
+	ErrUnsupportedPathBinding = errors.New("unsupported field type for path binding")
+)
+
+// BindPath copies the values of the route's wildcards into the fields of dest tagged
+// with `path:"name"`, converting them to the field types. The route's validators for
+// each parameter run on the raw value first, and parameters declared required with
+// HasPathParameter must not be empty. For example, with the pattern
+// "GET /users/{id}/posts/{postID}":
+//
+//	var params struct {
+//		UserID int    `path:"id"`
+//		PostID uint64 `path:"postID"`
+//	}
+//
+//	if err := ctx.BindPath(&params); err != nil {
+//		return ctx.Results.BadRequest(httpfx.WithPlainText(err.Error()))
+//	}
+func (c *Context) BindPath(dest any) error {
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Pointer || target.IsNil() ||
+		target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w (type=%T)", ErrInvalidBindTarget, dest)
+	}
+
+	target = target.Elem()
+	targetType := target.Type()
+
+	for i := range targetType.NumField() {
+		fieldType := targetType.Field(i)
+
+		name, ok := fieldType.Tag.Lookup(pathTagName)
+		if !ok || name == "" || name == "-" || !fieldType.IsExported() {
+			continue
+		}
+
+		value, err := c.pathParameter(name)
+		if err != nil {
+			return err
+		}
+
+		err = setPathField(target.Field(i), value)
+		if err != nil {
+			return fmt.Errorf(
+				"%w (param=%q, field=%s): %w",
+				ErrInvalidPathParameter,
+				name,
+				fieldType.Name,
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// pathParameter returns the validated value of the named wildcard.
+func (c *Context) pathParameter(name string) (string, error) {
+	if c.routeDef == nil {
+		return c.Request.PathValue(name), nil
+	}
+
+	isWildcard := slices.ContainsFunc(c.routeDef.Pattern.Segments, func(segment uris.Segment) bool {
+		return segment.Wild && segment.Str == name
+	})
+	if !isWildcard {
+		return "", fmt.Errorf(
+			"%w (param=%q, pattern=%q)",
+			ErrUnknownPathParameter,
+			name,
+			c.routeDef.Pattern.Str,
+		)
+	}
+
+	value := c.Request.PathValue(name)
+
+	for _, param := range c.routeDef.Parameters {
+		if param.Type != RouteParameterTypePath || param.Name != name {
+			continue
+		}
+
+		if param.IsRequired && value == "" {
+			return "", fmt.Errorf("%w (param=%q)", ErrMissingPathParameter, name)
+		}
+
+		for _, validator := range param.Validators {
+			validated, err := validator(value)
+			if err != nil {
+				return "", fmt.Errorf("%w (param=%q): %w", ErrInvalidPathParameter, name, err)
+			}
+
+			value = validated
+		}
+	}
+
+	return value, nil
+}
+
+// setPathField parses value strictly into field, allocating pointer fields as needed.
+func setPathField(field reflect.Value, value string) error { //nolint:cyclop
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+
+		if err := setPathField(ptr.Elem(), value); err != nil {
+			return err
+		}
+
+		field.Set(ptr)
+
+		return nil
+	}
+
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value)) //nolint:wrapcheck
+	}
+
+	switch field.Kind() { //nolint:exhaustive
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		field.SetFloat(parsed)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		field.SetBool(parsed)
+	default:
+		return fmt.Errorf("%w (type=%s)", ErrUnsupportedPathBinding, field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package httpfx_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type postPathParams struct {
+	Ignored string
+	Slug    *string `path:"slug"`
+	UserID  int     `path:"id"`
+	PostID  uint64  `path:"postID"`
+}
+
+var errNotNumeric = errors.New("must be numeric")
+
+func TestContext_BindPath(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	slug := "hello"
+
+	tests := []struct {
+		expectedErr error
+		dest        func() any
+		expected    any
+		name        string
+		pattern     string
+		path        string
+	}{
+		{
+			name:    "typed_fields",
+			pattern: "GET /users/{id}/posts/{postID}/{slug}",
+			path:    "/users/42/posts/7/hello",
+			dest:    func() any { return &postPathParams{} },
+			expected: &postPathParams{
+				Ignored: "",
+				Slug:    &slug,
+				UserID:  42,
+				PostID:  7,
+			},
+		},
+		{
+			name:        "conversion_failure",
+			pattern:     "GET /users/{id}/posts/{postID}/{slug}",
+			path:        "/users/abc/posts/7/hello",
+			dest:        func() any { return &postPathParams{} },
+			expectedErr: httpfx.ErrInvalidPathParameter,
+		},
+		{
+			name:        "negative_unsigned",
+			pattern:     "GET /users/{id}/posts/{postID}/{slug}",
+			path:        "/users/42/posts/-7/hello",
+			dest:        func() any { return &postPathParams{} },
+			expectedErr: httpfx.ErrInvalidPathParameter,
+		},
+		{
+			name:        "tag_not_in_pattern",
+			pattern:     "GET /users/{id}/posts/{postID}",
+			path:        "/users/42/posts/7",
+			dest:        func() any { return &postPathParams{} },
+			expectedErr: httpfx.ErrUnknownPathParameter,
+		},
+		{
+			name:        "non_pointer_target",
+			pattern:     "GET /users/{id}/posts/{postID}/{slug}",
+			path:        "/users/42/posts/7/hello",
+			dest:        func() any { return postPathParams{} },
+			expectedErr: httpfx.ErrInvalidBindTarget,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var bindErr error
+
+			dest := tt.dest()
+
+			router := httpfx.NewRouter("/")
+			router.Route(tt.pattern, func(ctx *httpfx.Context) httpfx.Result {
+				bindErr = ctx.BindPath(dest)
+
+				return ctx.Results.Ok()
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.GetMux().ServeHTTP(w, req)
+
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, bindErr, tt.expectedErr)
+
+				return
+			}
+
+			require.NoError(t, bindErr)
+			assert.Equal(t, tt.expected, dest)
+		})
+	}
+}
+
+func TestContext_BindPath_RunsRouteValidators(t *testing.T) {
+	t.Parallel()
+
+	var bindErr error
+
+	router := httpfx.NewRouter("/")
+	route := router.Route("GET /posts/{postID}", func(ctx *httpfx.Context) httpfx.Result {
+		var params struct {
+			PostID string `path:"postID"`
+		}
+
+		bindErr = ctx.BindPath(&params)
+
+		return ctx.Results.Ok()
+	}).HasPathParameter("postID", "post identifier")
+
+	route.Parameters[0].Validators = append(route.Parameters[0].Validators,
+		func(inputString string) (string, error) {
+			for _, r := range inputString {
+				if r < '0' || r > '9' {
+					return "", errNotNumeric
+				}
+			}
+
+			return inputString, nil
+		},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/abc", nil)
+	router.GetMux().ServeHTTP(httptest.NewRecorder(), req)
+
+	require.ErrorIs(t, bindErr, httpfx.ErrInvalidPathParameter)
+	require.ErrorIs(t, bindErr, errNotNumeric)
+	assert.Contains(t, bindErr.Error(), `param="postID"`)
+}
@@ -2,6 +2,8 @@ package httpfx
 
 import (
 	"time"
+
+	"github.com/eser/ajan/lib"
 )
 
 type Config struct {
@@ -13,12 +15,19 @@ type Config struct {
 	ReadTimeout       time.Duration `conf:"read_timeout"        default:"10s"`
 	WriteTimeout      time.Duration `conf:"write_timeout"       default:"10s"`
 	IdleTimeout       time.Duration `conf:"idle_timeout"        default:"120s"`
+	MaxHeaderBytes    lib.ByteSize  `conf:"max_header_bytes"    default:"1MiB"`
 
 	InitializationTimeout   time.Duration `conf:"init_timeout"     default:"25s"`
 	GracefulShutdownTimeout time.Duration `conf:"shutdown_timeout" default:"5s"`
 
 	SelfSigned bool `conf:"self_signed" default:"false"`
 
+	// ExposeTraceID returns the active trace ID in the X-Trace-Id response header
+	ExposeTraceID bool `conf:"expose_trace_id" default:"false"`
+
+	// PrettyJSON indents JSON response bodies; meant for development only
+	PrettyJSON bool `conf:"pretty_json" default:"false"`
+
 	HealthCheckEnabled bool `conf:"health_check" default:"true"`
 	OpenAPIEnabled     bool `conf:"openapi"      default:"true"`
 	ProfilingEnabled   bool `conf:"profiling"    default:"false"`
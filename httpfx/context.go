@@ -20,6 +20,9 @@ type Context struct {
 	routeDef *Route
 	handlers HandlerChain
 	index    int
+
+	// trailers holds the response trailers set with SetTrailer
+	trailers http.Header
 	// isAborted bool
 }
 
@@ -0,0 +1,112 @@
+package httpfx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/eser/ajan/lib"
+)
+
+// defaultErrorMapper serves Results that were not created by a Router, such as those of
+// contexts built by hand in tests.
+var defaultErrorMapper = NewDefaultErrorMapper() //nolint:gochecknoglobals
+
+// ErrorMapping is the response given to errors that match Err.
+type ErrorMapping struct {
+	// Err is compared against the error and the errors it wraps with errors.Is
+	Err error
+	// Message is the user-facing response body (the status text when empty). The error
+	// itself is never sent, as it may reveal internals.
+	Message    string
+	StatusCode int
+}
+
+// ErrorMapper maps the errors handlers return to HTTP statuses and user-facing messages,
+// so that handlers can pass their domain errors on instead of choosing a status for each.
+// Results.Error consults it for the error attached with WithError. Mappings registered
+// later take precedence, which lets them override the defaults. It is safe for concurrent
+// use.
+type ErrorMapper struct {
+	mappings []ErrorMapping
+	mu       sync.RWMutex
+}
+
+// NewErrorMapper creates an ErrorMapper without any mappings.
+func NewErrorMapper() *ErrorMapper {
+	return &ErrorMapper{
+		mappings: make([]ErrorMapping, 0),
+		mu:       sync.RWMutex{},
+	}
+}
+
+// NewDefaultErrorMapper creates an ErrorMapper with mappings for the errors of httpfx, the
+// standard library and the error categories of lib, which the sentinel errors of other
+// packages fall into (such as datafx.ErrKeyNotFound and connfx.ErrReadOnly):
+//
+//   - ErrMissingPathParameter, ErrInvalidPathParameter, lib.ErrInvalidCursor: 400 Bad Request
+//   - lib.ErrCategoryNotFound: 404 Not Found
+//   - lib.ErrCategoryTooLarge: 413 Content Too Large
+//   - lib.ErrCategoryInvalidInput: 422 Unprocessable Entity
+//   - lib.ErrCategoryUnavailable: 503 Service Unavailable
+//   - context.DeadlineExceeded: 504 Gateway Timeout
+func NewDefaultErrorMapper() *ErrorMapper {
+	mapper := NewErrorMapper()
+
+	mapper.Register(lib.ErrCategoryNotFound, http.StatusNotFound, "")
+	mapper.Register(lib.ErrCategoryTooLarge, http.StatusRequestEntityTooLarge, "")
+	mapper.Register(lib.ErrCategoryInvalidInput, http.StatusUnprocessableEntity, "")
+	mapper.Register(lib.ErrCategoryUnavailable, http.StatusServiceUnavailable, "")
+	mapper.Register(context.DeadlineExceeded, http.StatusGatewayTimeout, "")
+	mapper.Register(ErrMissingPathParameter, http.StatusBadRequest, "")
+	mapper.Register(ErrInvalidPathParameter, http.StatusBadRequest, "")
+	mapper.Register(lib.ErrInvalidCursor, http.StatusBadRequest, "")
+
+	return mapper
+}
+
+// Register maps errors matching err to statusCode, answered with message (the status
+// text when empty).
+func (m *ErrorMapper) Register(err error, statusCode int, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.mappings = append(m.mappings, ErrorMapping{
+		Err:        err,
+		Message:    message,
+		StatusCode: statusCode,
+	})
+}
+
+// Map returns the mapping for err, preferring the latest registered one when several
+// match, and false when none does.
+func (m *ErrorMapper) Map(err error) (ErrorMapping, bool) {
+	if err == nil {
+		return ErrorMapping{}, false //nolint:exhaustruct
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, mapping := range slices.Backward(m.mappings) {
+		if errors.Is(err, mapping.Err) {
+			if mapping.Message == "" {
+				mapping.Message = http.StatusText(mapping.StatusCode)
+			}
+
+			return mapping, true
+		}
+	}
+
+	return ErrorMapping{}, false //nolint:exhaustruct
+}
+
+// WithError attaches the error a result reports. Results.Error answers it as its
+// ErrorMapper maps it.
+func WithError(err error) ResultOption {
+	return func(result *Result) {
+		result.Result = result.Result.WithError(err)
+	}
+}
@@ -0,0 +1,269 @@
+package httpfx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eser/ajan/connfx"
+	"github.com/eser/ajan/datafx"
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errOrderNotPaid   = errors.New("order is not paid")
+	errOrderNotFound  = errors.New("order not found")
+	errInternalDetail = errors.New("connection to 10.0.0.5 refused")
+)
+
+func TestResults_Error_DefaultMappings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "invalid_path_parameter",
+			err:            fmt.Errorf("%w (name=%q)", httpfx.ErrInvalidPathParameter, "id"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Bad Request",
+		},
+		{
+			name:           "deadline_exceeded",
+			err:            context.DeadlineExceeded,
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   "Gateway Timeout",
+		},
+		{
+			// Unmapped errors keep the given status and are not revealed
+			name:           "unmapped",
+			err:            errInternalDetail,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			results := &httpfx.Results{}
+			result := results.Error(http.StatusInternalServerError, httpfx.WithError(tt.err))
+
+			assert.Equal(t, tt.expectedStatus, result.StatusCode())
+			assert.Equal(t, tt.expectedBody, string(result.Body()))
+			require.ErrorIs(t, result, tt.err)
+		})
+	}
+}
+
+func TestResults_Error_BodyOption(t *testing.T) {
+	t.Parallel()
+
+	results := &httpfx.Results{}
+	result := results.Error(
+		http.StatusInternalServerError,
+		httpfx.WithError(httpfx.ErrMissingPathParameter),
+		httpfx.WithPlainText("order id is required"),
+	)
+
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode())
+	assert.Equal(t, "order id is required", string(result.Body()))
+}
+
+func TestRouter_ErrorMapper(t *testing.T) {
+	t.Parallel()
+
+	router := httpfx.NewRouter("/")
+	router.GetErrorMapper().Register(errOrderNotPaid, http.StatusPaymentRequired, "pay first")
+	router.GetErrorMapper().Register(errOrderNotFound, http.StatusNotFound, "")
+	// Later registrations override the defaults
+	router.GetErrorMapper().Register(httpfx.ErrInvalidPathParameter, http.StatusNotFound, "")
+
+	handlerErrs := map[string]error{
+		"/orders/unpaid":  fmt.Errorf("checkout: %w", errOrderNotPaid),
+		"/orders/missing": errOrderNotFound,
+		"/orders/invalid": httpfx.ErrInvalidPathParameter,
+		"/orders/timeout": context.DeadlineExceeded,
+	}
+
+	route := router.Route("GET /orders/{id}", func(ctx *httpfx.Context) httpfx.Result {
+		return ctx.Results.Error(
+			http.StatusInternalServerError,
+			httpfx.WithError(handlerErrs[ctx.Request.URL.Path]),
+		)
+	})
+	require.NotNil(t, route)
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			path:           "/orders/unpaid",
+			expectedStatus: http.StatusPaymentRequired,
+			expectedBody:   "pay first",
+		},
+		{
+			path:           "/orders/missing",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Not Found",
+		},
+		{
+			path:           "/orders/invalid",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Not Found",
+		},
+		{
+			path:           "/orders/timeout",
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   "Gateway Timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		w := httptest.NewRecorder()
+
+		router.GetMux().ServeHTTP(w, req)
+
+		assert.Equal(t, tt.expectedStatus, w.Code, tt.path)
+		assert.Equal(t, tt.expectedBody, w.Body.String(), tt.path)
+	}
+
+	// Groups share the mapper of their router
+	group := router.Group("/api")
+	assert.Same(t, router.GetErrorMapper(), group.GetErrorMapper())
+}
+
+func TestErrorMapper_Map(t *testing.T) {
+	t.Parallel()
+
+	mapper := httpfx.NewErrorMapper()
+
+	_, ok := mapper.Map(httpfx.ErrInvalidPathParameter)
+	assert.False(t, ok)
+
+	_, ok = mapper.Map(nil)
+	assert.False(t, ok)
+
+	mapper.Register(errOrderNotPaid, http.StatusPaymentRequired, "pay first")
+
+	mapping, ok := mapper.Map(fmt.Errorf("checkout: %w", errOrderNotPaid))
+	require.True(t, ok)
+	assert.Equal(t, http.StatusPaymentRequired, mapping.StatusCode)
+	assert.Equal(t, "pay first", mapping.Message)
+}
+
+type validatedOrder struct {
+	ID string `validate:"required"`
+}
+
+func TestRouter_DefaultErrorMapper_Categories(t *testing.T) {
+	t.Parallel()
+
+	router := httpfx.NewRouter("/")
+
+	handlerErrs := map[string]error{
+		"/orders/missing": fmt.Errorf("%w (key=%q)", datafx.ErrKeyNotFound, "order:1"),
+		"/orders/readonly": fmt.Errorf(
+			"%w: %w",
+			datafx.ErrRepositoryOperation,
+			connfx.NormalizeSQLError(errors.New("attempt to write a readonly database")), //nolint:err113
+		),
+		"/orders/invalid": datafx.ValidateStruct(validatedOrder{ID: ""}),
+		"/orders/large":   datafx.ErrMessageTooLarge,
+	}
+
+	route := router.Route("GET /orders/{id}", func(ctx *httpfx.Context) httpfx.Result {
+		return ctx.Results.Error(
+			http.StatusInternalServerError,
+			httpfx.WithError(handlerErrs[ctx.Request.URL.Path]),
+		)
+	})
+	require.NotNil(t, route)
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{path: "/orders/missing", expectedStatus: http.StatusNotFound},
+		{path: "/orders/readonly", expectedStatus: http.StatusServiceUnavailable},
+		{path: "/orders/invalid", expectedStatus: http.StatusUnprocessableEntity},
+		{path: "/orders/large", expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		w := httptest.NewRecorder()
+
+		router.GetMux().ServeHTTP(w, req)
+
+		assert.Equal(t, tt.expectedStatus, w.Code, tt.path)
+		assert.Equal(t, http.StatusText(tt.expectedStatus), w.Body.String(), tt.path)
+	}
+}
+
+func TestRouter_DefaultErrorMapper_InvalidCursor(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	router := httpfx.NewRouter("/")
+
+	route := router.Route("GET /orders", func(ctx *httpfx.Context) httpfx.Result {
+		var after struct {
+			ID string `json:"id"`
+		}
+
+		err := lib.DecodeCursor(key, ctx.Request.URL.Query().Get("cursor"), &after)
+		if err != nil {
+			return ctx.Results.Error(http.StatusInternalServerError, httpfx.WithError(err))
+		}
+
+		return ctx.Results.PlainText([]byte(after.ID))
+	})
+	require.NotNil(t, route)
+
+	cursor, err := lib.EncodeCursor(key, map[string]string{"id": "42"})
+	require.NoError(t, err)
+
+	payload, signature, _ := strings.Cut(cursor, ".")
+	forged, err := lib.EncodeCursor(
+		[]byte("fedcba9876543210fedcba9876543210"),
+		map[string]string{"id": "1"},
+	)
+	require.NoError(t, err)
+
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		cursor         string
+		expectedStatus int
+	}{
+		{cursor: cursor, expectedStatus: http.StatusOK},
+		{cursor: forgedPayload + "." + signature, expectedStatus: http.StatusBadRequest},
+		{cursor: forged, expectedStatus: http.StatusBadRequest},
+		{cursor: payload, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/orders?cursor="+tt.cursor, nil)
+		w := httptest.NewRecorder()
+
+		router.GetMux().ServeHTTP(w, req)
+
+		assert.Equal(t, tt.expectedStatus, w.Code, tt.cursor)
+	}
+}
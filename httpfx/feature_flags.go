@@ -0,0 +1,138 @@
+package httpfx
+
+import (
+	"context"
+	"sync"
+)
+
+// ContextKeyFlagScope is the request context key holding the request's *FlagScope.
+const ContextKeyFlagScope ContextKey = "flag-scope"
+
+// FlagContext holds the request attributes feature flags are evaluated against.
+type FlagContext struct {
+	// Attributes carries additional, application-specific evaluation attributes
+	Attributes map[string]string
+
+	UserID   string
+	TenantID string
+	Region   string
+}
+
+// FlagEvaluator decides feature flags for an evaluation context.
+type FlagEvaluator interface {
+	// Enabled reports whether the flag is on for flagCtx
+	Enabled(ctx context.Context, name string, flagCtx *FlagContext) bool
+
+	// Variant returns the variant of the flag served to flagCtx
+	Variant(ctx context.Context, name string, flagCtx *FlagContext) string
+}
+
+// FlagEvaluationHook is called once per flag and kind ("enabled" or "variant") the
+// first time a request evaluates it, e.g. to log or count the decision.
+type FlagEvaluationHook func(ctx context.Context, name string, kind string, result string)
+
+// FlagScope evaluates feature flags for a single request. Every decision is made once
+// and then reused, so a request sees a consistent answer however often it asks.
+type FlagScope struct {
+	evaluator FlagEvaluator
+	flagCtx   *FlagContext
+	hook      FlagEvaluationHook
+
+	decisions map[string]string
+	mu        sync.Mutex
+}
+
+// NewFlagScope creates a FlagScope evaluating flags against flagCtx. The hook may be nil.
+func NewFlagScope(
+	evaluator FlagEvaluator,
+	flagCtx *FlagContext,
+	hook FlagEvaluationHook,
+) *FlagScope {
+	return &FlagScope{
+		evaluator: evaluator,
+		flagCtx:   flagCtx,
+		hook:      hook,
+
+		decisions: make(map[string]string),
+		mu:        sync.Mutex{},
+	}
+}
+
+// FlagScopeFromContext returns the FlagScope stored in ctx, or nil.
+func FlagScopeFromContext(ctx context.Context) *FlagScope {
+	scope, _ := ctx.Value(ContextKeyFlagScope).(*FlagScope)
+
+	return scope
+}
+
+// FlagContext returns the evaluation context of the scope.
+func (s *FlagScope) FlagContext() *FlagContext {
+	return s.flagCtx
+}
+
+// Enabled reports whether the flag is on for this request.
+func (s *FlagScope) Enabled(ctx context.Context, name string) bool {
+	result := s.decide(ctx, name, "enabled", func() string {
+		if s.evaluator.Enabled(ctx, name, s.flagCtx) {
+			return "true"
+		}
+
+		return "false"
+	})
+
+	return result == "true"
+}
+
+// Variant returns the variant of the flag served to this request.
+func (s *FlagScope) Variant(ctx context.Context, name string) string {
+	return s.decide(ctx, name, "variant", func() string {
+		return s.evaluator.Variant(ctx, name, s.flagCtx)
+	})
+}
+
+func (s *FlagScope) decide(
+	ctx context.Context,
+	name string,
+	kind string,
+	evaluate func() string,
+) string {
+	key := kind + ":" + name
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if result, ok := s.decisions[key]; ok {
+		return result
+	}
+
+	result := evaluate()
+	s.decisions[key] = result
+
+	if s.hook != nil {
+		s.hook(ctx, name, kind, result)
+	}
+
+	return result
+}
+
+// FlagEnabled reports whether the feature flag is on for this request. It returns false
+// unless a FlagScope was set up, e.g. by middlewares.FeatureFlagsMiddleware.
+func (c *Context) FlagEnabled(name string) bool {
+	scope := FlagScopeFromContext(c.Request.Context())
+	if scope == nil {
+		return false
+	}
+
+	return scope.Enabled(c.Request.Context(), name)
+}
+
+// FlagVariant returns the feature flag variant served to this request, or "" unless a
+// FlagScope was set up, e.g. by middlewares.FeatureFlagsMiddleware.
+func (c *Context) FlagVariant(name string) string {
+	scope := FlagScopeFromContext(c.Request.Context())
+	if scope == nil {
+		return ""
+	}
+
+	return scope.Variant(c.Request.Context(), name)
+}
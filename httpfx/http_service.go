@@ -8,6 +8,8 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"slices"
+	"sync"
 
 	"github.com/eser/ajan/lib"
 	"github.com/eser/ajan/logfx"
@@ -19,8 +21,12 @@ var (
 	ErrFailedToGenerateSelfSignedCert = errors.New("failed to generate self-signed certificate")
 	ErrFailedToCreateHTTPMetrics      = errors.New("failed to create HTTP metrics")
 	ErrHTTPServiceNetListenError      = errors.New("HTTP service net listen error")
+	ErrShutdownHookFailed             = errors.New("HTTP service shutdown hook failed")
 )
 
+// ShutdownHook is a teardown step run when an HTTPService shuts down.
+type ShutdownHook func(ctx context.Context) error
+
 type HTTPService struct {
 	InnerServer  *http.Server
 	InnerRouter  *Router
@@ -28,6 +34,9 @@ type HTTPService struct {
 
 	Config *Config
 	logger *logfx.Logger
+
+	shutdownHooks []ShutdownHook
+	hooksMu       sync.Mutex
 }
 
 func NewHTTPService(
@@ -41,12 +50,21 @@ func NewHTTPService(
 		ReadTimeout:       config.ReadTimeout,
 		WriteTimeout:      config.WriteTimeout,
 		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    int(config.MaxHeaderBytes),
 
 		Addr: config.Addr,
 
 		Handler: router.GetMux(),
 	}
 
+	if config.PrettyJSON {
+		router.SetPrettyJSON(true)
+	}
+
+	if router.GetLogger() == nil {
+		router.SetLogger(logger)
+	}
+
 	metrics := NewMetrics(metricsProvider)
 
 	return &HTTPService{
@@ -55,6 +73,9 @@ func NewHTTPService(
 		InnerMetrics: metrics,
 		Config:       config,
 		logger:       logger,
+
+		shutdownHooks: nil,
+		hooksMu:       sync.Mutex{},
 	}
 }
 
@@ -66,6 +87,18 @@ func (hs *HTTPService) Router() *Router {
 	return hs.InnerRouter
 }
 
+// OnShutdown registers a hook run by the cleanup function Start returns, once the server
+// stops accepting connections and has drained them. Hooks run in the reverse order of
+// registration, so resources acquired later are released first, and share the
+// GracefulShutdownTimeout deadline through their context. A failing hook does not stop
+// the others; their errors are logged together.
+func (hs *HTTPService) OnShutdown(hook ShutdownHook) {
+	hs.hooksMu.Lock()
+	defer hs.hooksMu.Unlock()
+
+	hs.shutdownHooks = append(hs.shutdownHooks, hook)
+}
+
 func (hs *HTTPService) SetupTLS(ctx context.Context) error {
 	switch {
 	case hs.Config.CertString != "" && hs.Config.KeyString != "":
@@ -136,15 +169,43 @@ func (hs *HTTPService) Start(ctx context.Context) (func(), error) {
 		newCtx, cancel := context.WithTimeout(ctx, hs.Config.GracefulShutdownTimeout)
 		defer cancel()
 
+		graceful := true
+
 		if err := hs.InnerServer.Shutdown(newCtx); err != nil &&
 			!errors.Is(err, http.ErrServerClosed) {
 			hs.logger.ErrorContext(ctx, "HTTPService forced to shutdown", slog.Any("error", err))
 
-			return
+			graceful = false
 		}
 
-		hs.logger.InfoContext(ctx, "HTTPService has gracefully stopped.")
+		// The hooks run even after a forced shutdown, as the server is closed either way
+		if err := hs.runShutdownHooks(newCtx); err != nil {
+			hs.logger.ErrorContext(ctx, "HTTPService shutdown hooks failed", slog.Any("error", err))
+
+			graceful = false
+		}
+
+		if graceful {
+			hs.logger.InfoContext(ctx, "HTTPService has gracefully stopped.")
+		}
 	}
 
 	return cleanup, nil
 }
+
+// runShutdownHooks runs the registered hooks, latest first, joining their errors.
+func (hs *HTTPService) runShutdownHooks(ctx context.Context) error {
+	hs.hooksMu.Lock()
+	hooks := slices.Clone(hs.shutdownHooks)
+	hs.hooksMu.Unlock()
+
+	var errs []error
+
+	for i, hook := range slices.Backward(hooks) {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%w (hook=%d): %w", ErrShutdownHookFailed, i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
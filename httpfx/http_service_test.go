@@ -1,8 +1,12 @@
 package httpfx_test
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
 	"testing"
@@ -21,9 +25,11 @@ func setupTestMetricsProvider(t *testing.T) *metricsfx.MetricsProvider {
 	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{
 		ServiceName:                   "test-service",
 		ServiceVersion:                "1.0.0",
+		ServiceInstanceID:             "",
 		OTLPConnectionName:            "", // No connection for testing
 		ExportInterval:                30 * time.Second,
 		NoNativeCollectorRegistration: true,
+		RequireNativeCollectors:       false,
 	}, nil) // nil registry for testing
 
 	err := provider.Init()
@@ -198,3 +204,62 @@ func TestHTTPService_Start(t *testing.T) { //nolint:funlen
 		resp2.Body.Close() //nolint:errcheck,gosec
 	}
 }
+
+func TestHTTPService_OnShutdown(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger := logfx.NewLogger(logfx.WithFromSlog(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	config := &httpfx.Config{ //nolint:exhaustruct
+		Addr:                    "127.0.0.1:0",
+		ReadHeaderTimeout:       time.Second * 10,
+		GracefulShutdownTimeout: time.Second * 5,
+	}
+
+	service := httpfx.NewHTTPService(
+		config,
+		httpfx.NewRouter("/"),
+		setupTestMetricsProvider(t),
+		logger,
+	)
+
+	errCacheFlush := errors.New("cache flush failed")
+
+	var order []string
+
+	hook := func(name string, err error) httpfx.ShutdownHook {
+		return func(ctx context.Context) error {
+			// Hooks share the graceful-shutdown deadline
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.WithinDuration(
+				t,
+				time.Now().Add(config.GracefulShutdownTimeout),
+				deadline,
+				time.Second,
+			)
+			require.NoError(t, ctx.Err())
+
+			order = append(order, name)
+
+			return err
+		}
+	}
+
+	service.OnShutdown(hook("close queues", nil))
+	service.OnShutdown(hook("flush caches", errCacheFlush))
+	service.OnShutdown(hook("stop workers", nil))
+
+	cleanup, err := service.Start(t.Context())
+	require.NoError(t, err)
+
+	cleanup()
+
+	// Latest registered first, and a failing hook does not stop the rest
+	assert.Equal(t, []string{"stop workers", "flush caches", "close queues"}, order)
+	assert.Contains(t, buf.String(), "HTTPService shutdown hooks failed")
+	assert.Contains(t, buf.String(), errCacheFlush.Error())
+	assert.NotContains(t, buf.String(), "HTTPService has gracefully stopped.")
+}
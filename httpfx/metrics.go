@@ -14,6 +14,9 @@ var (
 	ErrFailedToBuildHTTPRequestDurationHistogram = errors.New(
 		"failed to build HTTP request duration histogram",
 	)
+	ErrFailedToBuildFlagEvaluationsCounter = errors.New(
+		"failed to build feature flag evaluations counter",
+	)
 )
 
 // Metrics holds HTTP-specific metrics using the simplified MetricsBuilder approach.
@@ -22,6 +25,7 @@ type Metrics struct {
 
 	RequestsTotal   *metricsfx.CounterMetric
 	RequestDuration *metricsfx.HistogramMetric
+	FlagEvaluations *metricsfx.CounterMetric
 }
 
 // NewMetrics creates HTTP metrics using the simplified MetricsBuilder.
@@ -31,6 +35,7 @@ func NewMetrics(provider *metricsfx.MetricsProvider) *Metrics {
 
 		RequestsTotal:   nil,
 		RequestDuration: nil,
+		FlagEvaluations: nil,
 	}
 }
 
@@ -57,5 +62,15 @@ func (metrics *Metrics) Init() error {
 
 	metrics.RequestDuration = requestDuration
 
+	flagEvaluations, err := builder.Counter(
+		"feature_flag_evaluations_total",
+		"Total number of feature flag evaluations made while serving requests",
+	).WithUnit("{evaluation}").Build()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBuildFlagEvaluationsCounter, err)
+	}
+
+	metrics.FlagEvaluations = flagEvaluations
+
 	return nil
 }
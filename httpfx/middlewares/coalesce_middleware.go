@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/eser/ajan/httpfx"
+	"golang.org/x/sync/singleflight"
+)
+
+// CoalesceKeyFunc returns the key identical requests share, or "" to serve the request
+// on its own.
+type CoalesceKeyFunc func(ctx *httpfx.Context) string
+
+// DefaultCoalesceKey keys requests by method, path and query. Requests carrying
+// credentials (an Authorization or Cookie header) are not coalesced, since their
+// responses may differ per caller.
+func DefaultCoalesceKey(ctx *httpfx.Context) string {
+	if ctx.Request.Header.Get("Authorization") != "" || ctx.Request.Header.Get("Cookie") != "" {
+		return ""
+	}
+
+	return ctx.Request.Method + " " + ctx.Request.URL.Path + "?" + ctx.Request.URL.RawQuery
+}
+
+// CoalesceMiddleware runs the rest of the chain once for concurrent GET and HEAD
+// requests with the same key (DefaultCoalesceKey when keyFn is nil), and hands the
+// buffered result to every waiting request. Only the status code, headers and body of
+// the returned Result are shared: headers a handler sets on the ResponseWriter directly
+// only reach its own request, and streamed results are not shared, so waiters run the
+// handler themselves. Waiters also receive the result of a handler that failed or whose
+// request was canceled.
+func CoalesceMiddleware(keyFn CoalesceKeyFunc) httpfx.Handler {
+	if keyFn == nil {
+		keyFn = DefaultCoalesceKey
+	}
+
+	var group singleflight.Group
+
+	return func(ctx *httpfx.Context) httpfx.Result {
+		if ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+			return ctx.Next()
+		}
+
+		key := keyFn(ctx)
+		if key == "" {
+			return ctx.Next()
+		}
+
+		executed := false
+
+		value, _, _ := group.Do(key, func() (any, error) {
+			executed = true
+
+			return ctx.Next(), nil
+		})
+
+		result, _ := value.(httpfx.Result)
+
+		if executed {
+			return result
+		}
+
+		if result.Stream() != nil {
+			return ctx.Next()
+		}
+
+		// Waiters get their own headers, so later middlewares can change them safely
+		result.InnerHeaders = result.InnerHeaders.Clone()
+
+		return result
+	}
+}
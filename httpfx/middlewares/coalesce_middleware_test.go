@@ -0,0 +1,150 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/httpfx/middlewares"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// coalesceRouter serves GET and POST /report with a handler that counts its runs and
+// waits for release, after counting the requests that reached the middleware.
+func coalesceRouter(
+	arrivals *atomic.Int64,
+	runs *atomic.Int64,
+	release <-chan struct{},
+) *httpfx.Router {
+	router := httpfx.NewRouter("/")
+	router.Use(middlewares.CoalesceMiddleware(func(ctx *httpfx.Context) string {
+		arrivals.Add(1)
+
+		return middlewares.DefaultCoalesceKey(ctx)
+	}))
+
+	handler := func(ctx *httpfx.Context) httpfx.Result {
+		run := runs.Add(1)
+
+		<-release
+
+		return ctx.Results.PlainText([]byte("report "+ctx.Request.URL.RawQuery)).
+			WithHeader("X-Run", strconv.FormatInt(run, 10))
+	}
+
+	router.Route("GET /report", handler)
+	router.Route("POST /report", handler)
+
+	return router
+}
+
+func TestCoalesceMiddleware_SharesOneExecution(t *testing.T) {
+	t.Parallel()
+
+	const concurrent = 10
+
+	var arrivals, runs atomic.Int64
+
+	release := make(chan struct{})
+	router := coalesceRouter(&arrivals, &runs, release)
+
+	recorders := make([]*httptest.ResponseRecorder, concurrent)
+
+	var wg sync.WaitGroup
+
+	for i := range concurrent {
+		recorders[i] = httptest.NewRecorder()
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodGet, "/report?period=daily", nil)
+			router.GetMux().ServeHTTP(recorders[i], req)
+		}()
+	}
+
+	// Let every request join the running execution before it completes
+	require.Eventually(t, func() bool {
+		return arrivals.Load() == concurrent
+	}, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), runs.Load())
+
+	for _, recorder := range recorders {
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "report period=daily", recorder.Body.String())
+		assert.Equal(t, "1", recorder.Header().Get("X-Run"))
+	}
+}
+
+func TestCoalesceMiddleware_ServesSeparately(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		method  string
+		targets []string
+		header  string
+	}{
+		{name: "unsafe_method", method: http.MethodPost, targets: []string{"/report", "/report"}},
+		{
+			name:    "different_query",
+			method:  http.MethodGet,
+			targets: []string{"/report?period=daily", "/report?period=weekly"},
+		},
+		{
+			name:    "credentials",
+			method:  http.MethodGet,
+			targets: []string{"/report", "/report"},
+			header:  "Bearer token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var arrivals, runs atomic.Int64
+
+			release := make(chan struct{})
+			router := coalesceRouter(&arrivals, &runs, release)
+
+			var wg sync.WaitGroup
+
+			for _, target := range tt.targets {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					req := httptest.NewRequest(tt.method, target, nil)
+					if tt.header != "" {
+						req.Header.Set("Authorization", tt.header)
+					}
+
+					router.GetMux().ServeHTTP(httptest.NewRecorder(), req)
+				}()
+			}
+
+			// Both handlers run at once rather than one waiting for the other
+			require.Eventually(t, func() bool {
+				return runs.Load() == int64(len(tt.targets))
+			}, time.Second, time.Millisecond)
+
+			close(release)
+			wg.Wait()
+		})
+	}
+}
@@ -6,9 +6,31 @@ import (
 	"github.com/eser/ajan/httpfx"
 	"github.com/eser/ajan/lib"
 	"github.com/eser/ajan/logfx"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const CorrelationIDHeader = "X-Correlation-ID"
+const (
+	CorrelationIDHeader = "X-Correlation-ID"
+	TraceIDHeader       = "X-Trace-Id"
+)
+
+// CorrelationIDOption defines a functional option for configuring the correlation ID middleware.
+type CorrelationIDOption func(*correlationIDConfig)
+
+// correlationIDConfig holds the internal configuration for the correlation ID middleware.
+type correlationIDConfig struct {
+	ExposeTraceID bool // Whether the active trace ID is returned in the X-Trace-Id header
+}
+
+// WithTraceIDHeader returns the trace ID of the request's active span in the X-Trace-Id
+// response header, so clients can quote it when reporting problems. Trace IDs reveal
+// internal tracing details, so exposure is off unless enabled, e.g. from
+// httpfx.Config.ExposeTraceID.
+func WithTraceIDHeader(enabled bool) CorrelationIDOption {
+	return func(config *correlationIDConfig) {
+		config.ExposeTraceID = enabled
+	}
+}
 
 // GetCorrelationIDFromContext extracts correlation ID from context.
 func GetCorrelationIDFromContext(ctx context.Context) string {
@@ -19,7 +41,15 @@ func GetCorrelationIDFromContext(ctx context.Context) string {
 	return ""
 }
 
-func CorrelationIDMiddleware() httpfx.Handler {
+func CorrelationIDMiddleware(options ...CorrelationIDOption) httpfx.Handler {
+	config := &correlationIDConfig{
+		ExposeTraceID: false,
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
 	return func(ctx *httpfx.Context) httpfx.Result {
 		// FIXME(@eser) no need to check if the header is specified
 		correlationID := ctx.Request.Header.Get(CorrelationIDHeader)
@@ -35,10 +65,16 @@ func CorrelationIDMiddleware() httpfx.Handler {
 		)
 		ctx.UpdateContext(newContext)
 
-		result := ctx.Next()
-
+		// Headers are set before calling the handler, which may write the response
 		ctx.ResponseWriter.Header().Set(CorrelationIDHeader, correlationID)
 
-		return result
+		if config.ExposeTraceID {
+			spanContext := trace.SpanContextFromContext(ctx.Request.Context())
+			if spanContext.HasTraceID() {
+				ctx.ResponseWriter.Header().Set(TraceIDHeader, spanContext.TraceID().String())
+			}
+		}
+
+		return ctx.Next()
 	}
 }
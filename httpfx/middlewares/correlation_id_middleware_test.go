@@ -9,6 +9,7 @@ import (
 	"github.com/eser/ajan/httpfx/middlewares"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestCorrelationIDMiddleware(t *testing.T) {
@@ -69,3 +70,79 @@ func TestCorrelationIDMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestCorrelationIDMiddleware_TraceIDHeader(t *testing.T) {
+	t.Parallel()
+
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d}
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{ //nolint:exhaustruct
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	tests := []struct {
+		name        string
+		options     []middlewares.CorrelationIDOption
+		withSpan    bool
+		wantTraceID string
+	}{
+		{
+			name:        "enabled_with_active_span",
+			options:     []middlewares.CorrelationIDOption{middlewares.WithTraceIDHeader(true)},
+			withSpan:    true,
+			wantTraceID: traceID.String(),
+		},
+		{
+			name:        "disabled_by_default",
+			options:     nil,
+			withSpan:    true,
+			wantTraceID: "",
+		},
+		{
+			name:        "explicitly_disabled",
+			options:     []middlewares.CorrelationIDOption{middlewares.WithTraceIDHeader(false)},
+			withSpan:    true,
+			wantTraceID: "",
+		},
+		{
+			name:        "enabled_without_active_span",
+			options:     []middlewares.CorrelationIDOption{middlewares.WithTraceIDHeader(true)},
+			withSpan:    false,
+			wantTraceID: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set(middlewares.CorrelationIDHeader, "test-correlation-id")
+
+			if tt.withSpan {
+				req = req.WithContext(trace.ContextWithSpanContext(req.Context(), spanContext))
+			}
+
+			w := httptest.NewRecorder()
+
+			ctx := &httpfx.Context{
+				Request:        req,
+				ResponseWriter: w,
+				Results:        httpfx.Results{},
+			}
+
+			middleware := middlewares.CorrelationIDMiddleware(tt.options...)
+			result := middleware(ctx)
+			require.NotNil(t, result)
+
+			assert.Equal(t, "test-correlation-id", w.Header().Get(middlewares.CorrelationIDHeader))
+
+			if tt.wantTraceID == "" {
+				assert.NotContains(t, w.Header(), middlewares.TraceIDHeader)
+			} else {
+				assert.Equal(t, tt.wantTraceID, w.Header().Get(middlewares.TraceIDHeader))
+			}
+		})
+	}
+}
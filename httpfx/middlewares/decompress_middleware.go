@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/eser/ajan/httpfx"
+)
+
+// DefaultMaxDecompressedSize is the default cap on a decompressed request body (10 MiB).
+const DefaultMaxDecompressedSize = 10 << 20
+
+// DecompressOption defines a functional option for configuring the decompress middleware.
+type DecompressOption func(*decompressConfig)
+
+// decompressConfig holds the internal configuration for the decompress middleware.
+type decompressConfig struct {
+	MaxDecompressedSize int64 // Largest decompressed body handlers may read
+}
+
+// WithMaxDecompressedSize sets the largest decompressed body handlers may read.
+func WithMaxDecompressedSize(size int64) DecompressOption {
+	return func(config *decompressConfig) {
+		config.MaxDecompressedSize = size
+	}
+}
+
+// DecompressMiddleware decompresses request bodies sent with Content-Encoding gzip or
+// deflate, so handlers read them as plain content. Reading past the decompressed size cap
+// fails with *http.MaxBytesError, which keeps small payloads that inflate enormously
+// (decompression bombs) from exhausting memory. Requests with any other encoding are
+// answered with 415 Unsupported Media Type.
+func DecompressMiddleware(options ...DecompressOption) httpfx.Handler {
+	config := &decompressConfig{
+		MaxDecompressedSize: DefaultMaxDecompressedSize,
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
+	return func(ctx *httpfx.Context) httpfx.Result {
+		encoding := strings.ToLower(strings.TrimSpace(ctx.Request.Header.Get("Content-Encoding")))
+		if encoding == "" || encoding == "identity" {
+			return ctx.Next()
+		}
+
+		var (
+			decompressor io.ReadCloser
+			err          error
+		)
+
+		switch encoding {
+		case "gzip", "x-gzip":
+			decompressor, err = gzip.NewReader(ctx.Request.Body)
+		case "deflate":
+			decompressor, err = zlib.NewReader(ctx.Request.Body)
+		default:
+			ctx.ResponseWriter.Header().Set("Accept-Encoding", "gzip, deflate")
+
+			return ctx.Results.Error(
+				http.StatusUnsupportedMediaType,
+				httpfx.WithPlainText("Unsupported Content-Encoding: "+encoding),
+			)
+		}
+
+		if err != nil {
+			return ctx.Results.BadRequest(
+				httpfx.WithPlainText("request body is not valid " + encoding + " data"),
+			)
+		}
+
+		ctx.Request.Body = &decompressedBody{
+			Reader: http.MaxBytesReader(
+				ctx.ResponseWriter,
+				decompressor,
+				config.MaxDecompressedSize,
+			),
+			decompressor: decompressor,
+			original:     ctx.Request.Body,
+		}
+
+		// The body no longer matches the encoding or length the client sent
+		ctx.Request.Header.Del("Content-Encoding")
+		ctx.Request.Header.Del("Content-Length")
+		ctx.Request.ContentLength = -1
+
+		return ctx.Next()
+	}
+}
+
+// decompressedBody reads the capped, decompressed content and closes both the
+// decompressor and the original body.
+type decompressedBody struct {
+	io.Reader
+
+	decompressor io.Closer
+	original     io.Closer
+}
+
+func (b *decompressedBody) Close() error {
+	return errors.Join(b.decompressor.Close(), b.original.Close())
+}
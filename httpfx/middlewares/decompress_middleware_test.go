@@ -0,0 +1,137 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/httpfx/middlewares"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDecompressRouter(options ...middlewares.DecompressOption) *httpfx.Router {
+	router := httpfx.NewRouter("/")
+	router.Use(middlewares.DecompressMiddleware(options...))
+
+	router.Route("POST /echo", func(ctx *httpfx.Context) httpfx.Result {
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return ctx.Results.Error(http.StatusRequestEntityTooLarge)
+			}
+
+			return ctx.Results.BadRequest()
+		}
+
+		return ctx.Results.PlainText(body)
+	})
+
+	return router
+}
+
+func compressBody(t *testing.T, encoding string, content []byte) []byte {
+	t.Helper()
+
+	var (
+		buf    bytes.Buffer
+		writer io.WriteCloser
+	)
+
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "deflate":
+		writer = zlib.NewWriter(&buf)
+	default:
+		return content
+	}
+
+	_, err := writer.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+func postEncoded(router *httpfx.Router, encoding string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body))
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+
+	w := httptest.NewRecorder()
+	router.GetMux().ServeHTTP(w, req)
+
+	return w
+}
+
+func TestDecompressMiddleware(t *testing.T) {
+	t.Parallel()
+
+	router := newDecompressRouter()
+	content := []byte(`{"order":"42","items":["book","pen"]}`)
+
+	tests := []struct {
+		name     string
+		encoding string
+	}{
+		{name: "gzip", encoding: "gzip"},
+		{name: "deflate", encoding: "deflate"},
+		{name: "identity", encoding: "identity"},
+		{name: "not encoded", encoding: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := postEncoded(router, tt.encoding, compressBody(t, tt.encoding, content))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, content, w.Body.Bytes())
+		})
+	}
+}
+
+func TestDecompressMiddleware_Rejects(t *testing.T) {
+	t.Parallel()
+
+	router := newDecompressRouter()
+
+	w := postEncoded(router, "br", []byte("compressed"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Equal(t, "gzip, deflate", w.Header().Get("Accept-Encoding"))
+
+	w = postEncoded(router, "gzip", []byte("not gzip at all"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDecompressMiddleware_DecompressionBomb(t *testing.T) {
+	t.Parallel()
+
+	const maxSize = 64 << 10
+
+	router := newDecompressRouter(middlewares.WithMaxDecompressedSize(maxSize))
+
+	// 16 MiB of zeros compresses to a few kilobytes
+	bomb := compressBody(t, "gzip", make([]byte, 16<<20))
+	require.Less(t, len(bomb), maxSize)
+
+	w := postEncoded(router, "gzip", bomb)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// A body filling the cap exactly still gets through
+	content := bytes.Repeat([]byte("a"), maxSize)
+
+	w = postEncoded(router, "gzip", compressBody(t, "gzip", content))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, content, w.Body.Bytes())
+}
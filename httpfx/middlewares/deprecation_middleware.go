@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/logfx"
+	"github.com/eser/ajan/metricsfx"
+)
+
+const (
+	DeprecationHeader = "Deprecation"
+	SunsetHeader      = "Sunset"
+	LinkHeader        = "Link"
+)
+
+// DeprecatedRoute describes a deprecated endpoint.
+type DeprecatedRoute struct {
+	// Sunset is when the route stops working (zero value omits the Sunset header)
+	Sunset time.Time
+	// Pattern is the route pattern as registered, e.g. "GET /v1/users/{id}"
+	Pattern string
+	// Successor is the URL of the replacement endpoint (empty omits the Link header)
+	Successor string
+}
+
+// DeprecationConfig configures DeprecationMiddleware.
+type DeprecationConfig struct {
+	// Logger logs every call to a deprecated route (optional)
+	Logger *logfx.Logger
+	// Counter counts calls per deprecated route, labeled by "route" (optional)
+	Counter *metricsfx.CounterMetric
+
+	Routes []DeprecatedRoute
+}
+
+// DeprecationMiddleware marks responses of deprecated routes with the Deprecation,
+// Sunset and Link headers, and records their usage.
+func DeprecationMiddleware(cfg DeprecationConfig) httpfx.Handler {
+	routes := make(map[string]DeprecatedRoute, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes[route.Pattern] = route
+	}
+
+	return func(ctx *httpfx.Context) httpfx.Result {
+		route, deprecated := routes[ctx.Request.Pattern]
+		if !deprecated {
+			return ctx.Next()
+		}
+
+		headers := ctx.ResponseWriter.Header()
+		headers.Set(DeprecationHeader, "true")
+
+		if !route.Sunset.IsZero() {
+			headers.Set(SunsetHeader, route.Sunset.UTC().Format(http.TimeFormat))
+		}
+
+		if route.Successor != "" {
+			headers.Add(LinkHeader, "<"+route.Successor+`>; rel="successor-version"`)
+		}
+
+		if cfg.Counter != nil {
+			cfg.Counter.Inc(ctx.Request.Context(), metricsfx.StringAttr("route", route.Pattern))
+		}
+
+		if cfg.Logger != nil {
+			cfg.Logger.WarnContext(
+				ctx.Request.Context(),
+				"Deprecated route called",
+				slog.String("route", route.Pattern),
+				slog.String("path", ctx.Request.URL.Path),
+				slog.String("user_agent", ctx.Request.UserAgent()),
+			)
+		}
+
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,111 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/httpfx/middlewares"
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecationMiddleware(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	var logBuffer bytes.Buffer
+
+	logger := logfx.NewLogger(
+		logfx.WithWriter(&logBuffer),
+		logfx.WithConfig(&logfx.Config{
+			Level:              "INFO",
+			DefaultLogger:      false,
+			PrettyMode:         false,
+			AddSource:          false,
+			OTLPConnectionName: "",
+		}),
+	)
+
+	counter, err := setupTestMetricsProvider(t).NewBuilder().
+		Counter("http_deprecated_requests_total", "Calls to deprecated routes").
+		Build()
+	require.NoError(t, err)
+
+	sunset := time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	router := httpfx.NewRouter("/")
+	router.Use(middlewares.DeprecationMiddleware(middlewares.DeprecationConfig{
+		Logger:  logger,
+		Counter: counter,
+		Routes: []middlewares.DeprecatedRoute{
+			{
+				Pattern:   "GET /v1/users/{id}",
+				Sunset:    sunset,
+				Successor: "/v2/users/{id}",
+			},
+			{
+				Pattern:   "GET /v1/legacy",
+				Sunset:    time.Time{},
+				Successor: "",
+			},
+		},
+	}))
+
+	okHandler := func(ctx *httpfx.Context) httpfx.Result {
+		return ctx.Results.Ok()
+	}
+
+	router.Route("GET /v1/users/{id}", okHandler)
+	router.Route("GET /v1/legacy", okHandler)
+	router.Route("GET /v2/users/{id}", okHandler)
+
+	tests := []struct {
+		name              string
+		path              string
+		expectDeprecation string
+		expectSunset      string
+		expectLink        string
+	}{
+		{
+			name:              "deprecated route with sunset and successor",
+			path:              "/v1/users/42",
+			expectDeprecation: "true",
+			expectSunset:      "Thu, 31 Dec 2026 00:00:00 GMT",
+			expectLink:        `</v2/users/{id}>; rel="successor-version"`,
+		},
+		{
+			name:              "deprecated route without sunset",
+			path:              "/v1/legacy",
+			expectDeprecation: "true",
+			expectSunset:      "",
+			expectLink:        "",
+		},
+		{
+			name:              "current route",
+			path:              "/v2/users/42",
+			expectDeprecation: "",
+			expectSunset:      "",
+			expectLink:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.GetMux().ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, tt.expectDeprecation, w.Header().Get(middlewares.DeprecationHeader))
+			assert.Equal(t, tt.expectSunset, w.Header().Get(middlewares.SunsetHeader))
+			assert.Equal(t, tt.expectLink, w.Header().Get(middlewares.LinkHeader))
+		})
+	}
+
+	assert.Equal(t, 2, bytes.Count(logBuffer.Bytes(), []byte("Deprecated route called")))
+	assert.Contains(t, logBuffer.String(), `"route":"GET /v1/users/{id}"`)
+}
@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/logfx"
+	"github.com/eser/ajan/metricsfx"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DefaultFlagTenantClaim  = "tenant_id"
+	DefaultFlagRegionHeader = "X-Region"
+
+	// FlagMetricOtherVariant is the result label of variants not allow-listed with
+	// WithFlagMetricVariants, which keeps the metric's cardinality bounded.
+	FlagMetricOtherVariant = "other"
+)
+
+// FeatureFlagsOption defines a functional option for configuring the feature flags middleware.
+type FeatureFlagsOption func(*featureFlagsConfig)
+
+// featureFlagsConfig holds the internal configuration for the feature flags middleware.
+type featureFlagsConfig struct {
+	Logger         *logfx.Logger       // Logs every flag decision at debug level
+	Metrics        *httpfx.Metrics     // Counts every flag decision
+	MetricVariants map[string]struct{} // Variants recorded as metric labels as is
+	TenantClaim    string              // Auth claim carrying the tenant ID
+	TenantHeader   string              // Header carrying the tenant ID (empty = claims only)
+	RegionHeader   string              // Header carrying the region
+}
+
+// WithFlagLogger logs every flag decision made during a request at debug level.
+func WithFlagLogger(logger *logfx.Logger) FeatureFlagsOption {
+	return func(config *featureFlagsConfig) {
+		config.Logger = logger
+	}
+}
+
+// WithFlagMetrics counts every flag decision in httpfx.Metrics.FlagEvaluations, labeled
+// by flag, kind and result. Variant results are only used as labels when allow-listed
+// with WithFlagMetricVariants, and are counted as FlagMetricOtherVariant otherwise.
+func WithFlagMetrics(metrics *httpfx.Metrics) FeatureFlagsOption {
+	return func(config *featureFlagsConfig) {
+		config.Metrics = metrics
+	}
+}
+
+// WithFlagMetricVariants allow-lists the variants recorded as metric labels.
+func WithFlagMetricVariants(variants ...string) FeatureFlagsOption {
+	return func(config *featureFlagsConfig) {
+		for _, variant := range variants {
+			config.MetricVariants[variant] = struct{}{}
+		}
+	}
+}
+
+// WithFlagTenantClaim reads the tenant ID from the given auth claim instead of tenant_id.
+func WithFlagTenantClaim(claim string) FeatureFlagsOption {
+	return func(config *featureFlagsConfig) {
+		config.TenantClaim = claim
+	}
+}
+
+// WithFlagTenantHeader reads the tenant ID from the given request header instead of the
+// auth claims. Clients can send any value, so only use it behind a gateway that
+// authenticates the tenant and sets the header itself.
+func WithFlagTenantHeader(header string) FeatureFlagsOption {
+	return func(config *featureFlagsConfig) {
+		config.TenantHeader = header
+	}
+}
+
+// WithFlagRegionHeader reads the region from the given header instead of X-Region.
+func WithFlagRegionHeader(header string) FeatureFlagsOption {
+	return func(config *featureFlagsConfig) {
+		config.RegionHeader = header
+	}
+}
+
+// FeatureFlagsMiddleware builds the flag-evaluation context of each request once: the
+// user ID from the "sub" claim and the tenant ID from the "tenant_id" claim set by
+// AuthMiddleware, and the region from a request header. Handlers then call
+// ctx.FlagEnabled and ctx.FlagVariant.
+func FeatureFlagsMiddleware(
+	evaluator httpfx.FlagEvaluator,
+	options ...FeatureFlagsOption,
+) httpfx.Handler {
+	config := &featureFlagsConfig{
+		Logger:         nil,
+		Metrics:        nil,
+		MetricVariants: make(map[string]struct{}),
+		TenantClaim:    DefaultFlagTenantClaim,
+		TenantHeader:   "",
+		RegionHeader:   DefaultFlagRegionHeader,
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
+	return func(ctx *httpfx.Context) httpfx.Result {
+		flagCtx := &httpfx.FlagContext{
+			Attributes: nil,
+			UserID:     getClaimString(ctx.Request.Context(), "sub"),
+			TenantID:   config.tenantID(ctx),
+			Region:     ctx.Request.Header.Get(config.RegionHeader),
+		}
+
+		scope := httpfx.NewFlagScope(evaluator, flagCtx, config.recordEvaluation(flagCtx))
+
+		ctx.UpdateContext(context.WithValue(
+			ctx.Request.Context(),
+			httpfx.ContextKeyFlagScope,
+			scope,
+		))
+
+		return ctx.Next()
+	}
+}
+
+// tenantID returns the tenant of the request, from the trusted header when one is
+// configured and from the auth claims otherwise.
+func (config *featureFlagsConfig) tenantID(ctx *httpfx.Context) string {
+	if config.TenantHeader != "" {
+		return ctx.Request.Header.Get(config.TenantHeader)
+	}
+
+	return getClaimString(ctx.Request.Context(), config.TenantClaim)
+}
+
+func (config *featureFlagsConfig) recordEvaluation(
+	flagCtx *httpfx.FlagContext,
+) httpfx.FlagEvaluationHook {
+	if config.Logger == nil && config.Metrics == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, name string, kind string, result string) {
+		if config.Logger != nil {
+			config.Logger.DebugContext(ctx, "feature flag evaluated",
+				slog.String("flag", name),
+				slog.String("kind", kind),
+				slog.String("result", result),
+				slog.String("user_id", flagCtx.UserID),
+				slog.String("tenant_id", flagCtx.TenantID),
+				slog.String("region", flagCtx.Region),
+			)
+		}
+
+		if config.Metrics != nil && config.Metrics.FlagEvaluations != nil {
+			config.Metrics.FlagEvaluations.Inc(ctx,
+				metricsfx.StringAttr("flag", name),
+				metricsfx.StringAttr("kind", kind),
+				metricsfx.StringAttr("result", config.metricResult(kind, result)),
+			)
+		}
+	}
+}
+
+// metricResult returns the result label of a decision. Enabled results are "true" or
+// "false"; variants are free-form, so only allow-listed ones are used as is.
+func (config *featureFlagsConfig) metricResult(kind string, result string) string {
+	if kind != "variant" {
+		return result
+	}
+
+	if _, ok := config.MetricVariants[result]; ok {
+		return result
+	}
+
+	return FlagMetricOtherVariant
+}
+
+// getClaimString returns a string claim set by AuthMiddleware, if any.
+func getClaimString(ctx context.Context, name string) string {
+	claims, ok := ctx.Value(ContextKeyAuthClaims).(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+
+	value, _ := claims[name].(string)
+
+	return value
+}
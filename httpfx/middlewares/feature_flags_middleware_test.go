@@ -0,0 +1,186 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/httpfx/middlewares"
+	"github.com/eser/ajan/logfx"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantFlagEvaluator enables flags for the "acme" tenant, serves the "blue" variant in
+// the "eu" region and lets user "beta-tester" into every flag.
+type tenantFlagEvaluator struct {
+	evaluations atomic.Int32
+}
+
+func (e *tenantFlagEvaluator) Enabled(
+	ctx context.Context,
+	name string,
+	flagCtx *httpfx.FlagContext,
+) bool {
+	e.evaluations.Add(1)
+
+	return flagCtx.TenantID == "acme" || flagCtx.UserID == "beta-tester"
+}
+
+func (e *tenantFlagEvaluator) Variant(
+	ctx context.Context,
+	name string,
+	flagCtx *httpfx.FlagContext,
+) string {
+	e.evaluations.Add(1)
+
+	if flagCtx.Region == "eu" {
+		return "blue"
+	}
+
+	return "green"
+}
+
+func TestFeatureFlagsMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		subject      string
+		tenant       string
+		tenantHeader string
+		trustHeader  bool
+		region       string
+		wantEnabled  bool
+		wantVariant  string
+	}{
+		{
+			name:        "tenant_enabled",
+			tenant:      "acme",
+			region:      "eu",
+			wantEnabled: true,
+			wantVariant: "blue",
+		},
+		{
+			name:        "tenant_disabled",
+			tenant:      "globex",
+			region:      "us",
+			wantEnabled: false,
+			wantVariant: "green",
+		},
+		{
+			name:        "user_from_claims",
+			subject:     "beta-tester",
+			wantEnabled: true,
+			wantVariant: "green",
+		},
+		{
+			name:         "spoofed_tenant_header",
+			tenantHeader: "acme",
+			wantEnabled:  false,
+			wantVariant:  "green",
+		},
+		{
+			name:         "trusted_tenant_header",
+			tenantHeader: "acme",
+			trustHeader:  true,
+			wantEnabled:  true,
+			wantVariant:  "green",
+		},
+		{
+			name:        "anonymous",
+			wantEnabled: false,
+			wantVariant: "green",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var logBuffer bytes.Buffer
+
+			logger := logfx.NewLogger(
+				logfx.WithWriter(&logBuffer),
+				logfx.WithConfig(&logfx.Config{ //nolint:exhaustruct
+					Level: "DEBUG",
+				}),
+			)
+
+			evaluator := &tenantFlagEvaluator{} //nolint:exhaustruct
+
+			metrics := httpfx.NewMetrics(setupTestMetricsProvider(t))
+			require.NoError(t, metrics.Init())
+
+			router := httpfx.NewRouter("/")
+
+			// Stands in for AuthMiddleware
+			router.Use(func(ctx *httpfx.Context) httpfx.Result {
+				if tt.subject != "" || tt.tenant != "" {
+					ctx.UpdateContext(context.WithValue(
+						ctx.Request.Context(),
+						middlewares.ContextKeyAuthClaims,
+						jwt.MapClaims{"sub": tt.subject, "tenant_id": tt.tenant},
+					))
+				}
+
+				return ctx.Next()
+			})
+			options := []middlewares.FeatureFlagsOption{
+				middlewares.WithFlagLogger(logger),
+				middlewares.WithFlagMetrics(metrics),
+				middlewares.WithFlagMetricVariants("blue"),
+			}
+
+			if tt.trustHeader {
+				options = append(options, middlewares.WithFlagTenantHeader("X-Tenant-Id"))
+			}
+
+			router.Use(middlewares.FeatureFlagsMiddleware(evaluator, options...))
+
+			router.Route("GET /checkout", func(ctx *httpfx.Context) httpfx.Result {
+				// Repeated evaluations within a request reuse the first decision
+				enabled := ctx.FlagEnabled("new-checkout") && ctx.FlagEnabled("new-checkout")
+				variant := ctx.FlagVariant("theme")
+
+				return ctx.Results.PlainText(fmt.Appendf(nil, "%t %s", enabled, variant))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+			req.Header.Set("X-Tenant-Id", tt.tenantHeader)
+			req.Header.Set("X-Region", tt.region)
+
+			recorder := httptest.NewRecorder()
+			router.GetMux().ServeHTTP(recorder, req)
+
+			want := fmt.Sprintf("%t %s", tt.wantEnabled, tt.wantVariant)
+			assert.Equal(t, want, recorder.Body.String())
+			assert.Equal(t, int32(2), evaluator.evaluations.Load())
+
+			assert.Contains(t, logBuffer.String(), "feature flag evaluated")
+			assert.Contains(t, logBuffer.String(), `"flag":"new-checkout"`)
+			assert.Contains(t, logBuffer.String(), `"result":"`+tt.wantVariant+`"`)
+		})
+	}
+}
+
+func TestContext_FlagEnabled_WithoutMiddleware(t *testing.T) {
+	t.Parallel()
+
+	router := httpfx.NewRouter("/")
+	router.Route("GET /checkout", func(ctx *httpfx.Context) httpfx.Result {
+		return ctx.Results.PlainText(fmt.Appendf(nil, "%t %q",
+			ctx.FlagEnabled("new-checkout"), ctx.FlagVariant("theme")))
+	})
+
+	recorder := httptest.NewRecorder()
+	router.GetMux().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/checkout", nil))
+
+	assert.Equal(t, `false ""`, recorder.Body.String())
+}
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/logfx"
+)
+
+// maxLogLevelBodySize bounds the request body accepted by LogLevelHandler.
+const maxLogLevelBodySize = 1024
+
+// LogLevelHandler reports the handler's log level on GET and changes it on PUT, e.g.
+// PUT /admin/log-level with {"level": "DEBUG"}. The change applies at once to every logger
+// sharing the handler. It only serves requests authenticated by AuthMiddleware and
+// responds 401 Unauthorized otherwise, so a route registered without it stays closed.
+func LogLevelHandler(handler *logfx.Handler) httpfx.Handler {
+	return func(ctx *httpfx.Context) httpfx.Result {
+		if ctx.Request.Context().Value(ContextKeyAuthClaims) == nil {
+			return ctx.Results.Unauthorized(httpfx.WithPlainText("Authentication required"))
+		}
+
+		switch ctx.Request.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var body struct {
+				Level string `json:"level"`
+			}
+
+			err := json.NewDecoder(io.LimitReader(ctx.Request.Body, maxLogLevelBodySize)).
+				Decode(&body)
+			if err != nil {
+				return ctx.Results.BadRequest(
+					httpfx.WithPlainText("request body must be a JSON object with a \"level\""),
+				)
+			}
+
+			level, err := logfx.ParseLevel(body.Level, true)
+			if err != nil {
+				return ctx.Results.BadRequest(httpfx.WithPlainText(err.Error()))
+			}
+
+			handler.SetLevel(*level)
+		default:
+			return ctx.Results.Error(
+				http.StatusMethodNotAllowed,
+				httpfx.WithHeader("Allow", "GET, PUT"),
+				httpfx.WithPlainText("Method Not Allowed"),
+			)
+		}
+
+		return ctx.Results.JSON(map[string]any{
+			"level": logfx.LevelEncoder(handler.Level()),
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/httpfx/middlewares"
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLogLevelRouter(handler *logfx.Handler) *httpfx.Router {
+	router := httpfx.NewRouter("/")
+
+	router.Route(
+		"GET /admin/log-level",
+		middlewares.AuthMiddleware(),
+		middlewares.LogLevelHandler(handler),
+	)
+	router.Route(
+		"PUT /admin/log-level",
+		middlewares.AuthMiddleware(),
+		middlewares.LogLevelHandler(handler),
+	)
+
+	// Registered without AuthMiddleware by mistake
+	router.Route("PUT /unguarded/log-level", middlewares.LogLevelHandler(handler))
+
+	return router
+}
+
+func serveLogLevel(
+	router *httpfx.Router,
+	method, path, body, token string,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.GetMux().ServeHTTP(w, req)
+
+	return w
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	t.Parallel()
+
+	var output bytes.Buffer
+
+	handler := logfx.NewHandler(&output, &logfx.Config{Level: "INFO"}, nil) //nolint:exhaustruct
+	logger := slog.New(handler).With(slog.String("component", "orders"))
+	router := newLogLevelRouter(handler)
+	token := createToken("secret", time.Now().Add(time.Hour))
+
+	logger.Debug("suppressed before")
+
+	w := serveLogLevel(router, http.MethodGet, "/admin/log-level", "", token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"INFO"}`, w.Body.String())
+
+	w = serveLogLevel(router, http.MethodPut, "/admin/log-level", `{"level":"debug"}`, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"DEBUG"}`, w.Body.String())
+
+	logger.Debug("emitted after lowering")
+
+	w = serveLogLevel(router, http.MethodPut, "/admin/log-level", `{"level":"WARN"}`, token)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	logger.Debug("suppressed after raising")
+
+	logs := output.String()
+	assert.NotContains(t, logs, "suppressed")
+	assert.Contains(t, logs, "emitted after lowering")
+	assert.Equal(t, logfx.LevelWarn, handler.Level())
+}
+
+func TestLogLevelHandler_Rejects(t *testing.T) {
+	t.Parallel()
+
+	config := &logfx.Config{Level: "INFO"} //nolint:exhaustruct
+	handler := logfx.NewHandler(&bytes.Buffer{}, config, nil)
+	router := newLogLevelRouter(handler)
+	token := createToken("secret", time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		token          string
+		expectedStatus int
+	}{
+		{
+			name:           "missing token",
+			path:           "/admin/log-level",
+			body:           `{"level":"DEBUG"}`,
+			token:          "",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "route without auth",
+			path:           "/unguarded/log-level",
+			body:           `{"level":"DEBUG"}`,
+			token:          token,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "unknown level",
+			path:           "/admin/log-level",
+			body:           `{"level":"LOUD"}`,
+			token:          token,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed body",
+			path:           "/admin/log-level",
+			body:           `DEBUG`,
+			token:          token,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := serveLogLevel(router, http.MethodPut, tt.path, tt.body, tt.token)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, logfx.LevelInfo, handler.Level())
+		})
+	}
+}
@@ -3,6 +3,7 @@ package middlewares
 import (
 	"log/slog"
 	"time"
+	"unicode/utf8"
 
 	"github.com/eser/ajan/httpfx"
 	"github.com/eser/ajan/logfx"
@@ -11,21 +12,86 @@ import (
 const (
 	// HTTP status code threshold for error logging.
 	httpErrorThreshold = 400
+
+	// DefaultLoggingUserAgentMaxLength is the user agent length kept in request logs.
+	DefaultLoggingUserAgentMaxLength = 256
+
+	truncationSuffix = "..."
 )
 
+// LoggingOption defines a functional option for configuring request logging.
+type LoggingOption func(*loggingConfig)
+
+// loggingConfig holds the internal configuration for request logging.
+type loggingConfig struct {
+	SkipFunc           func(*httpfx.Context) bool // Requests for which it returns true are skipped
+	SkipPaths          []string                   // Paths that are not logged
+	UserAgentMaxLength int                        // Longer user agents are truncated
+	ClientMetadata     bool                       // Adds client metadata to completion logs
+}
+
+// WithLoggingSkipPaths excludes requests to the given paths (e.g. "/healthz", "/metrics")
+// from logging. An entry ending with "/" excludes every path under it.
+func WithLoggingSkipPaths(paths ...string) LoggingOption {
+	return func(config *loggingConfig) {
+		config.SkipPaths = append(config.SkipPaths, paths...)
+	}
+}
+
+// WithLoggingSkipFunc excludes requests for which skip returns true from logging.
+func WithLoggingSkipFunc(skip func(*httpfx.Context) bool) LoggingOption {
+	return func(config *loggingConfig) {
+		config.SkipFunc = skip
+	}
+}
+
+// WithLoggingClientMetadata adds the request's user_agent, referer and content_length to
+// the completed-request log. Fields the request does not carry are left out.
+func WithLoggingClientMetadata(enabled bool) LoggingOption {
+	return func(config *loggingConfig) {
+		config.ClientMetadata = enabled
+	}
+}
+
+// WithLoggingUserAgentMaxLength truncates logged user agents to maxLength bytes
+// (DefaultLoggingUserAgentMaxLength by default). Zero or less disables truncation.
+func WithLoggingUserAgentMaxLength(maxLength int) LoggingOption {
+	return func(config *loggingConfig) {
+		config.UserAgentMaxLength = maxLength
+	}
+}
+
 // LoggingMiddleware creates HTTP request logging middleware that integrates with correlation ID.
-func LoggingMiddleware(logger *logfx.Logger) httpfx.Handler {
+// Skipped requests are still served and still pass through the other middlewares, such as
+// metrics.
+func LoggingMiddleware(logger *logfx.Logger, options ...LoggingOption) httpfx.Handler {
+	config := &loggingConfig{
+		SkipFunc:           nil,
+		SkipPaths:          nil,
+		UserAgentMaxLength: DefaultLoggingUserAgentMaxLength,
+		ClientMetadata:     false,
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
 	return func(ctx *httpfx.Context) httpfx.Result {
+		if config.shouldSkip(ctx) {
+			return ctx.Next()
+		}
+
 		startTime := time.Now()
 
 		// Get correlation ID from context if available
 		correlationID := GetCorrelationIDFromContext(ctx.Request.Context())
+		userAgent := truncateString(ctx.Request.UserAgent(), config.UserAgentMaxLength)
 
 		// Log request start
 		startArgs := []any{
 			slog.String("method", ctx.Request.Method),
 			slog.String("path", ctx.Request.URL.Path),
-			slog.String("user_agent", ctx.Request.UserAgent()),
+			slog.String("user_agent", userAgent),
 			slog.String("remote_addr", ctx.Request.RemoteAddr),
 		}
 
@@ -53,6 +119,10 @@ func LoggingMiddleware(logger *logfx.Logger) httpfx.Handler {
 			endArgs = append(endArgs, slog.String("correlation_id", correlationID))
 		}
 
+		if config.ClientMetadata {
+			endArgs = append(endArgs, clientMetadataArgs(ctx, userAgent)...)
+		}
+
 		if result.StatusCode() >= httpErrorThreshold {
 			logger.WarnContext(
 				ctx.Request.Context(),
@@ -65,3 +135,50 @@ func LoggingMiddleware(logger *logfx.Logger) httpfx.Handler {
 		return result
 	}
 }
+
+func (config *loggingConfig) shouldSkip(ctx *httpfx.Context) bool {
+	if matchesPathList(ctx.Request.URL.Path, config.SkipPaths) {
+		return true
+	}
+
+	return config.SkipFunc != nil && config.SkipFunc(ctx)
+}
+
+// clientMetadataArgs returns the client metadata the request carries.
+func clientMetadataArgs(ctx *httpfx.Context, userAgent string) []any {
+	args := make([]any, 0, 3) //nolint:mnd
+
+	if userAgent != "" {
+		args = append(args, slog.String("user_agent", userAgent))
+	}
+
+	if referer := ctx.Request.Referer(); referer != "" {
+		args = append(args, slog.String("referer", referer))
+	}
+
+	// -1 means the length is unknown, e.g. for chunked bodies
+	if ctx.Request.ContentLength >= 0 {
+		args = append(args, slog.Int64("content_length", ctx.Request.ContentLength))
+	}
+
+	return args
+}
+
+// truncateString shortens s to at most maxLength bytes, including a "..." suffix,
+// without splitting a multi-byte character.
+func truncateString(s string, maxLength int) string {
+	if maxLength <= 0 || len(s) <= maxLength {
+		return s
+	}
+
+	if maxLength <= len(truncationSuffix) {
+		return truncationSuffix[:maxLength]
+	}
+
+	cut := maxLength - len(truncationSuffix)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return s[:cut] + truncationSuffix
+}
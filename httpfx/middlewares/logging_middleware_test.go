@@ -0,0 +1,168 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/httpfx/middlewares"
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingMiddleware_SkipsConfiguredRequests(t *testing.T) {
+	t.Parallel()
+
+	var logBuffer bytes.Buffer
+
+	logger := logfx.NewLogger(
+		logfx.WithWriter(&logBuffer),
+		logfx.WithConfig(&logfx.Config{
+			Level:              "INFO",
+			DefaultLogger:      false,
+			PrettyMode:         false,
+			AddSource:          false,
+			OTLPConnectionName: "",
+		}),
+	)
+
+	router := httpfx.NewRouter("/")
+	router.Use(middlewares.LoggingMiddleware(
+		logger,
+		middlewares.WithLoggingSkipPaths("/healthz", "/internal/"),
+		middlewares.WithLoggingSkipFunc(func(ctx *httpfx.Context) bool {
+			return ctx.Request.Header.Get("User-Agent") == "kube-probe/1.30"
+		}),
+	))
+
+	handler := func(ctx *httpfx.Context) httpfx.Result {
+		return ctx.Results.PlainText([]byte("ok"))
+	}
+
+	router.Route("GET /healthz", handler)
+	router.Route("GET /internal/metrics", handler)
+	router.Route("GET /orders", handler)
+
+	tests := []struct {
+		name      string
+		path      string
+		userAgent string
+		logged    bool
+	}{
+		{name: "skipped_path", path: "/healthz", userAgent: "", logged: false},
+		{name: "skipped_prefix", path: "/internal/metrics", userAgent: "", logged: false},
+		{name: "skipped_predicate", path: "/orders", userAgent: "kube-probe/1.30", logged: false},
+		{name: "logged", path: "/orders", userAgent: "curl/8.0", logged: true},
+	}
+
+	for _, tt := range tests {
+		logBuffer.Reset()
+
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("User-Agent", tt.userAgent)
+
+		recorder := httptest.NewRecorder()
+		router.GetMux().ServeHTTP(recorder, req)
+
+		// Skipped requests are still served
+		assert.Equal(t, http.StatusOK, recorder.Code, tt.name)
+		assert.Equal(t, "ok", recorder.Body.String(), tt.name)
+
+		logged := strings.Contains(logBuffer.String(), "HTTP request")
+		assert.Equal(t, tt.logged, logged, tt.name)
+	}
+}
+
+func TestLoggingMiddleware_ClientMetadata(t *testing.T) {
+	t.Parallel()
+
+	longUserAgent := "Mozilla/5.0 " + strings.Repeat("x", 100)
+
+	tests := []struct {
+		name     string
+		options  []middlewares.LoggingOption
+		expected map[string]any
+	}{
+		{
+			name:     "disabled",
+			options:  nil,
+			expected: map[string]any{},
+		},
+		{
+			name:    "enabled",
+			options: []middlewares.LoggingOption{middlewares.WithLoggingClientMetadata(true)},
+			expected: map[string]any{
+				"user_agent":     longUserAgent,
+				"referer":        "https://example.com/cart",
+				"content_length": float64(len(`{"id":1}`)),
+			},
+		},
+		{
+			name: "enabled_with_truncation",
+			options: []middlewares.LoggingOption{
+				middlewares.WithLoggingClientMetadata(true),
+				middlewares.WithLoggingUserAgentMaxLength(16),
+			},
+			expected: map[string]any{
+				"user_agent":     "Mozilla/5.0 x...",
+				"referer":        "https://example.com/cart",
+				"content_length": float64(len(`{"id":1}`)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var logBuffer bytes.Buffer
+
+			logger := logfx.NewLogger(
+				logfx.WithWriter(&logBuffer),
+				logfx.WithConfig(&logfx.Config{ //nolint:exhaustruct
+					Level:      "INFO",
+					PrettyMode: false,
+				}),
+			)
+
+			router := httpfx.NewRouter("/")
+			router.Use(middlewares.LoggingMiddleware(logger, tt.options...))
+			router.Route("POST /orders", func(ctx *httpfx.Context) httpfx.Result {
+				return ctx.Results.PlainText([]byte("ok"))
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"id":1}`))
+			req.Header.Set("User-Agent", longUserAgent)
+			req.Header.Set("Referer", "https://example.com/cart")
+
+			router.GetMux().ServeHTTP(httptest.NewRecorder(), req)
+
+			var completed map[string]any
+
+			for line := range strings.Lines(logBuffer.String()) {
+				var entry map[string]any
+
+				require.NoError(t, json.Unmarshal([]byte(line), &entry))
+
+				if entry["msg"] == "HTTP request completed" {
+					completed = entry
+				}
+			}
+
+			require.NotNil(t, completed)
+
+			for _, key := range []string{"user_agent", "referer", "content_length"} {
+				value, ok := completed[key]
+				expected, wanted := tt.expected[key]
+
+				assert.Equal(t, wanted, ok, key)
+				assert.Equal(t, expected, value, key)
+			}
+		})
+	}
+}
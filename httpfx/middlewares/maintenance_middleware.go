@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/eser/ajan/httpfx"
+)
+
+// MaintenanceRetryAfterSeconds is the Retry-After value sent while in maintenance mode.
+const MaintenanceRetryAfterSeconds = 120
+
+// MaintenanceMiddleware responds with 503 Service Unavailable while flag is set, except
+// for requests to allowPaths (e.g. health and admin endpoints). An entry ending with "/"
+// allows every path under it.
+func MaintenanceMiddleware(flag *atomic.Bool, allowPaths []string) httpfx.Handler {
+	return func(ctx *httpfx.Context) httpfx.Result {
+		if !flag.Load() || matchesPathList(ctx.Request.URL.Path, allowPaths) {
+			return ctx.Next()
+		}
+
+		ctx.ResponseWriter.Header().Set("Retry-After", strconv.Itoa(MaintenanceRetryAfterSeconds))
+
+		result := ctx.Results.JSON(map[string]any{
+			"error":      "Service unavailable",
+			"message":    "The service is under maintenance. Please try again later.",
+			"retryAfter": MaintenanceRetryAfterSeconds,
+		})
+		result.InnerStatusCode = http.StatusServiceUnavailable
+
+		return result
+	}
+}
+
+// MaintenanceModeHandler reports the maintenance flag and, for non-GET requests, sets it
+// from the "enabled" query parameter (e.g. POST /admin/maintenance?enabled=true).
+// Register it on an allowlisted, access-controlled path.
+func MaintenanceModeHandler(flag *atomic.Bool) httpfx.Handler {
+	return func(ctx *httpfx.Context) httpfx.Result {
+		if ctx.Request.Method != http.MethodGet {
+			enabled, err := strconv.ParseBool(ctx.Request.URL.Query().Get("enabled"))
+			if err != nil {
+				return ctx.Results.BadRequest(
+					httpfx.WithPlainText("query parameter \"enabled\" must be a boolean"),
+				)
+			}
+
+			flag.Store(enabled)
+		}
+
+		return ctx.Results.JSON(map[string]any{
+			"maintenance": flag.Load(),
+		})
+	}
+}
+
+// matchesPathList reports whether path equals an entry of paths, or falls under an
+// entry ending with "/".
+func matchesPathList(path string, paths []string) bool {
+	for _, entry := range paths {
+		if path == entry {
+			return true
+		}
+
+		if strings.HasSuffix(entry, "/") && strings.HasPrefix(path, entry) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/httpfx/middlewares"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMaintenanceRouter(flag *atomic.Bool) *httpfx.Router {
+	router := httpfx.NewRouter("/")
+	router.Use(middlewares.MaintenanceMiddleware(flag, []string{"/health-check", "/admin/"}))
+
+	okHandler := func(ctx *httpfx.Context) httpfx.Result {
+		return ctx.Results.Ok()
+	}
+
+	router.Route("GET /health-check", okHandler)
+	router.Route("GET /users", okHandler)
+	router.Route("GET /admin/maintenance", middlewares.MaintenanceModeHandler(flag))
+	router.Route("POST /admin/maintenance", middlewares.MaintenanceModeHandler(flag))
+
+	return router
+}
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		path           string
+		maintenance    bool
+		expectedStatus int
+	}{
+		{
+			name:           "regular path when live",
+			path:           "/users",
+			maintenance:    false,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "regular path in maintenance",
+			path:           "/users",
+			maintenance:    true,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "allowlisted path in maintenance",
+			path:           "/health-check",
+			maintenance:    true,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "allowlisted prefix in maintenance",
+			path:           "/admin/maintenance",
+			maintenance:    true,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var flag atomic.Bool
+
+			flag.Store(tt.maintenance)
+
+			router := newMaintenanceRouter(&flag)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.GetMux().ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusServiceUnavailable {
+				assert.Equal(
+					t,
+					strconv.Itoa(middlewares.MaintenanceRetryAfterSeconds),
+					w.Header().Get("Retry-After"),
+				)
+			} else {
+				assert.Empty(t, w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestMaintenanceModeHandler(t *testing.T) {
+	t.Parallel()
+
+	var flag atomic.Bool
+
+	router := newMaintenanceRouter(&flag)
+
+	serve := func(method string, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		w := httptest.NewRecorder()
+		router.GetMux().ServeHTTP(w, req)
+
+		return w
+	}
+
+	w := serve(http.MethodPost, "/admin/maintenance?enabled=true")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"maintenance":true}`, w.Body.String())
+	assert.True(t, flag.Load())
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodGet, "/users").Code)
+
+	w = serve(http.MethodPost, "/admin/maintenance?enabled=maybe")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.True(t, flag.Load())
+
+	w = serve(http.MethodPost, "/admin/maintenance?enabled=false")
+	assert.JSONEq(t, `{"maintenance":false}`, w.Body.String())
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodGet, "/users").Code)
+}
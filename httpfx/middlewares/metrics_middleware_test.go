@@ -19,9 +19,11 @@ func setupTestMetricsProvider(t *testing.T) *metricsfx.MetricsProvider {
 	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{
 		ServiceName:                   "test-service",
 		ServiceVersion:                "1.0.0",
+		ServiceInstanceID:             "",
 		OTLPConnectionName:            "", // No connection for testing
 		ExportInterval:                30 * time.Second,
 		NoNativeCollectorRegistration: true,
+		RequireNativeCollectors:       false,
 	}, nil) // nil registry for testing
 
 	err := provider.Init()
@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/eser/ajan/httpfx"
+)
+
+const (
+	ServerTimingHeader = "Server-Timing"
+
+	// ServerTimingTotal names the phase covering the whole request.
+	ServerTimingTotal = "total"
+)
+
+// ServerTimingMiddleware emits the phases recorded with ctx.AddTiming as a Server-Timing
+// response header, followed by the total duration of the request. The header reveals
+// backend timings to every client, so consider enabling it only where that is acceptable.
+func ServerTimingMiddleware() httpfx.Handler {
+	return func(ctx *httpfx.Context) httpfx.Result {
+		startTime := time.Now()
+		timing := httpfx.NewServerTiming()
+
+		ctx.UpdateContext(context.WithValue(
+			ctx.Request.Context(),
+			httpfx.ContextKeyServerTiming,
+			timing,
+		))
+
+		result := ctx.Next()
+
+		timing.Add(ServerTimingTotal, time.Since(startTime))
+
+		ctx.ResponseWriter.Header().Set(ServerTimingHeader, timing.HeaderValue())
+
+		return result
+	}
+}
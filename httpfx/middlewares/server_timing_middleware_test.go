@@ -0,0 +1,86 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/httpfx/middlewares"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerTimingMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		handler  httpfx.Handler
+		expected string
+	}{
+		{
+			name: "total_only",
+			handler: func(c *httpfx.Context) httpfx.Result {
+				return c.Results.Ok()
+			},
+			expected: `^total;dur=[0-9.]+$`,
+		},
+		{
+			name: "multiple_timings",
+			handler: func(c *httpfx.Context) httpfx.Result {
+				c.AddTiming("db", 12500*time.Microsecond)
+				c.AddTiming("cache", 300*time.Microsecond)
+				c.AddTiming("upstream", 2*time.Millisecond)
+
+				return c.Results.Ok()
+			},
+			expected: `^db;dur=12\.5, cache;dur=0\.3, upstream;dur=2, total;dur=[0-9.]+$`,
+		},
+		{
+			name: "sanitized_names",
+			handler: func(c *httpfx.Context) httpfx.Result {
+				c.AddTiming("db query", time.Millisecond)
+				c.AddTiming("", time.Millisecond)
+
+				return c.Results.Ok()
+			},
+			expected: `^db_query;dur=1, _;dur=1, total;dur=[0-9.]+$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := httpfx.NewRouter("/")
+			router.Use(middlewares.ServerTimingMiddleware())
+			router.Route("GET /test", tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			w := httptest.NewRecorder()
+			router.GetMux().ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Regexp(t, tt.expected, w.Header().Get(middlewares.ServerTimingHeader))
+		})
+	}
+}
+
+func TestServerTimingMiddleware_NotInstalled(t *testing.T) {
+	t.Parallel()
+
+	router := httpfx.NewRouter("/")
+	router.Route("GET /test", func(c *httpfx.Context) httpfx.Result {
+		c.AddTiming("db", time.Millisecond)
+
+		return c.Results.Ok()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	w := httptest.NewRecorder()
+	router.GetMux().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get(middlewares.ServerTimingHeader))
+}
@@ -1,14 +1,22 @@
 package httpfx
 
 import (
+	"net/http"
+
 	"github.com/eser/ajan/results"
 )
 
+// StreamFunc writes a response body incrementally, after the status code and
+// headers have been sent.
+type StreamFunc func(w http.ResponseWriter) error
+
 type Result struct { //nolint:errname
 	InnerRedirectToURI string
 	results.Result
 
-	InnerBody []byte
+	InnerHeaders http.Header
+	InnerStream  StreamFunc
+	InnerBody    []byte
 
 	InnerStatusCode int
 }
@@ -21,6 +29,15 @@ func (r Result) Body() []byte {
 	return r.InnerBody
 }
 
+func (r Result) Headers() http.Header {
+	return r.InnerHeaders
+}
+
+// Stream returns the function writing a streamed body, or nil for buffered results.
+func (r Result) Stream() StreamFunc {
+	return r.InnerStream
+}
+
 func (r Result) RedirectToURI() string {
 	return r.InnerRedirectToURI
 }
@@ -36,3 +53,15 @@ func (r Result) WithBody(body string) Result {
 
 	return r
 }
+
+func (r Result) WithHeader(key string, value string) Result {
+	headers := r.InnerHeaders.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+
+	headers.Set(key, value)
+	r.InnerHeaders = headers
+
+	return r
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/eser/ajan/results"
 )
 
+// ContentTypeJSON is the content type of JSON result bodies.
+const ContentTypeJSON = "application/json"
+
 var (
 	okResult = results.Define( //nolint:gochecknoglobals
 		results.ResultKindSuccess,
@@ -35,6 +38,16 @@ func WithPlainText(body string) ResultOption {
 	}
 }
 
+func WithHeader(key string, value string) ResultOption {
+	return func(result *Result) {
+		if result.InnerHeaders == nil {
+			result.InnerHeaders = make(http.Header)
+		}
+
+		result.InnerHeaders.Set(key, value)
+	}
+}
+
 func WithJSON(body any) ResultOption {
 	return func(result *Result) {
 		encoded, err := json.Marshal(body)
@@ -46,11 +59,18 @@ func WithJSON(body any) ResultOption {
 		}
 
 		result.InnerBody = encoded
+
+		if result.InnerHeaders.Get("Content-Type") == "" {
+			WithHeader("Content-Type", ContentTypeJSON)(result)
+		}
 	}
 }
 
 // Results With Options.
-type Results struct{}
+type Results struct {
+	// errorMapper maps the errors of Error results (defaultErrorMapper when nil)
+	errorMapper *ErrorMapper
+}
 
 func (r *Results) Ok(options ...ResultOption) Result {
 	result := Result{
@@ -58,6 +78,8 @@ func (r *Results) Ok(options ...ResultOption) Result {
 
 		InnerStatusCode:    http.StatusNoContent,
 		InnerRedirectToURI: "",
+		InnerHeaders:       nil,
+		InnerStream:        nil,
 		InnerBody:          make([]byte, 0),
 	}
 
@@ -74,6 +96,8 @@ func (r *Results) Accepted(options ...ResultOption) Result {
 
 		InnerStatusCode:    http.StatusAccepted,
 		InnerRedirectToURI: "",
+		InnerHeaders:       nil,
+		InnerStream:        nil,
 		InnerBody:          make([]byte, 0),
 	}
 
@@ -90,6 +114,8 @@ func (r *Results) NotFound(options ...ResultOption) Result {
 
 		InnerStatusCode:    http.StatusNotFound,
 		InnerRedirectToURI: "",
+		InnerHeaders:       nil,
+		InnerStream:        nil,
 		InnerBody:          []byte("Not Found"),
 	}
 
@@ -106,6 +132,8 @@ func (r *Results) Unauthorized(options ...ResultOption) Result {
 
 		InnerStatusCode:    http.StatusUnauthorized,
 		InnerRedirectToURI: "",
+		InnerHeaders:       nil,
+		InnerStream:        nil,
 		InnerBody:          make([]byte, 0),
 	}
 
@@ -122,6 +150,8 @@ func (r *Results) BadRequest(options ...ResultOption) Result {
 
 		InnerStatusCode:    http.StatusBadRequest,
 		InnerRedirectToURI: "",
+		InnerHeaders:       nil,
+		InnerStream:        nil,
 		InnerBody:          []byte("Bad Request"),
 	}
 
@@ -132,12 +162,17 @@ func (r *Results) BadRequest(options ...ResultOption) Result {
 	return result
 }
 
+// Error creates an error result. When an error attached with WithError matches a
+// mapping of the router's ErrorMapper, the mapping decides the status code, and its
+// message becomes the body unless the options set one; otherwise statusCode is used.
 func (r *Results) Error(statusCode int, options ...ResultOption) Result {
 	result := Result{
 		Result: errResult.New(),
 
 		InnerStatusCode:    statusCode,
 		InnerRedirectToURI: "",
+		InnerHeaders:       nil,
+		InnerStream:        nil,
 		InnerBody:          make([]byte, 0),
 	}
 
@@ -145,6 +180,19 @@ func (r *Results) Error(statusCode int, options ...ResultOption) Result {
 		option(&result)
 	}
 
+	mapper := r.errorMapper
+	if mapper == nil {
+		mapper = defaultErrorMapper
+	}
+
+	if mapping, ok := mapper.Map(result.InnerError); ok {
+		result.InnerStatusCode = mapping.StatusCode
+
+		if len(result.InnerBody) == 0 {
+			result.InnerBody = []byte(mapping.Message)
+		}
+	}
+
 	return result
 }
 
@@ -155,6 +203,8 @@ func (r *Results) Bytes(body []byte) Result {
 
 		InnerStatusCode:    http.StatusOK,
 		InnerRedirectToURI: "",
+		InnerHeaders:       nil,
+		InnerStream:        nil,
 		InnerBody:          body,
 	}
 }
@@ -165,6 +215,8 @@ func (r *Results) PlainText(body []byte) Result {
 
 		InnerStatusCode:    http.StatusOK,
 		InnerRedirectToURI: "",
+		InnerHeaders:       nil,
+		InnerStream:        nil,
 		InnerBody:          body,
 	}
 }
@@ -178,6 +230,8 @@ func (r *Results) JSON(body any) Result {
 
 			InnerStatusCode:    http.StatusInternalServerError,
 			InnerRedirectToURI: "",
+			InnerHeaders:       nil,
+			InnerStream:        nil,
 			InnerBody:          []byte("Failed to encode JSON"),
 		}
 	}
@@ -187,6 +241,8 @@ func (r *Results) JSON(body any) Result {
 
 		InnerStatusCode:    http.StatusOK,
 		InnerRedirectToURI: "",
+		InnerHeaders:       http.Header{"Content-Type": []string{ContentTypeJSON}},
+		InnerStream:        nil,
 		InnerBody:          encoded,
 	}
 }
@@ -197,6 +253,8 @@ func (r *Results) Redirect(uri string) Result {
 
 		InnerStatusCode:    http.StatusTemporaryRedirect,
 		InnerRedirectToURI: uri,
+		InnerHeaders:       nil,
+		InnerStream:        nil,
 		InnerBody:          make([]byte, 0),
 	}
 }
@@ -208,6 +266,8 @@ func (r *Results) Abort() Result {
 
 		InnerStatusCode:    http.StatusNotImplemented,
 		InnerRedirectToURI: "",
+		InnerHeaders:       nil,
+		InnerStream:        nil,
 		InnerBody:          []byte("Not Implemented"),
 	}
 }
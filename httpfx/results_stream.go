@@ -0,0 +1,157 @@
+package httpfx
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// StreamFlushInterval is how often streamed results are flushed to the client.
+const StreamFlushInterval = 500 * time.Millisecond
+
+var (
+	ErrStreamCanceled      = errors.New("stream canceled")
+	ErrFailedToWriteStream = errors.New("failed to write stream")
+)
+
+// NDJSON streams rows as newline-delimited JSON until the channel is closed or
+// the context is canceled. Producers should stop sending once ctx is done.
+func (r *Results) NDJSON(ctx context.Context, rows <-chan any, options ...ResultOption) Result {
+	stream := func(w http.ResponseWriter) error {
+		buffered := bufio.NewWriter(w)
+		encoder := json.NewEncoder(buffered)
+
+		flush := func() error {
+			if err := buffered.Flush(); err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			return flushResponse(w)
+		}
+
+		return streamRows(ctx, rows, encoder.Encode, flush)
+	}
+
+	return newStreamResult(
+		"application/x-ndjson",
+		stream,
+		options...,
+	)
+}
+
+// CSV streams an optional header line followed by rows as CSV until the channel is
+// closed or the context is canceled. Producers should stop sending once ctx is done.
+func (r *Results) CSV(
+	ctx context.Context,
+	header []string,
+	rows <-chan []string,
+	options ...ResultOption,
+) Result {
+	stream := func(w http.ResponseWriter) error {
+		writer := csv.NewWriter(w)
+
+		flush := func() error {
+			writer.Flush()
+
+			if err := writer.Error(); err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			return flushResponse(w)
+		}
+
+		if len(header) > 0 {
+			if err := writer.Write(header); err != nil {
+				return fmt.Errorf("%w (format=csv): %w", ErrFailedToWriteStream, err)
+			}
+		}
+
+		return streamRows(ctx, rows, writer.Write, flush)
+	}
+
+	return newStreamResult(
+		"text/csv; charset=utf-8",
+		stream,
+		options...,
+	)
+}
+
+func newStreamResult(contentType string, stream StreamFunc, options ...ResultOption) Result {
+	headers := make(http.Header)
+	headers.Set("Content-Type", contentType)
+	headers.Set("Content-Disposition", "attachment")
+
+	result := Result{
+		Result: okResult.New(),
+
+		InnerStatusCode:    http.StatusOK,
+		InnerRedirectToURI: "",
+		InnerHeaders:       headers,
+		InnerStream:        stream,
+		InnerBody:          make([]byte, 0),
+	}
+
+	for _, option := range options {
+		option(&result)
+	}
+
+	return result
+}
+
+// streamRows writes rows as they arrive, flushing periodically so memory stays bounded
+// by the writer buffers regardless of the number of rows.
+func streamRows[T any](
+	ctx context.Context,
+	rows <-chan T,
+	write func(row T) error,
+	flush func() error,
+) error {
+	ticker := time.NewTicker(StreamFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			_ = flush()
+
+			return fmt.Errorf("%w: %w", ErrStreamCanceled, ctx.Err())
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return fmt.Errorf("%w: %w", ErrFailedToWriteStream, err)
+			}
+		case row, ok := <-rows:
+			if !ok {
+				// Producers typically close the channel on cancellation as well
+				if err := ctx.Err(); err != nil {
+					_ = flush()
+
+					return fmt.Errorf("%w: %w", ErrStreamCanceled, err)
+				}
+
+				if err := flush(); err != nil {
+					return fmt.Errorf("%w: %w", ErrFailedToWriteStream, err)
+				}
+
+				return nil
+			}
+
+			if err := write(row); err != nil {
+				return fmt.Errorf("%w: %w", ErrFailedToWriteStream, err)
+			}
+		}
+	}
+}
+
+func flushResponse(w http.ResponseWriter) error {
+	err := http.NewResponseController(w).Flush()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err //nolint:wrapcheck
+	}
+
+	return nil
+}
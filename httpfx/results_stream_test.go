@@ -0,0 +1,202 @@
+package httpfx_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const streamedRowCount = 20000
+
+// maxStreamWrite bounds a single write; buffering the whole export would exceed it.
+const maxStreamWrite = 64 * 1024
+
+// streamRecorder records the streamed body along with the largest single write.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+
+	maxWrite int
+}
+
+func newStreamRecorder() *streamRecorder {
+	return &streamRecorder{ResponseRecorder: httptest.NewRecorder(), maxWrite: 0}
+}
+
+func (r *streamRecorder) Write(data []byte) (int, error) {
+	r.maxWrite = max(r.maxWrite, len(data))
+
+	return r.ResponseRecorder.Write(data)
+}
+
+type exportRow struct {
+	Name string `json:"name"`
+	ID   int    `json:"id"`
+}
+
+func TestResults_NDJSON(t *testing.T) {
+	t.Parallel()
+
+	rows := make(chan any)
+
+	go func() {
+		defer close(rows)
+
+		for i := range streamedRowCount {
+			rows <- exportRow{ID: i, Name: "row-" + strconv.Itoa(i)}
+		}
+	}()
+
+	results := &httpfx.Results{}
+	result := results.NDJSON(t.Context(), rows)
+
+	assert.Equal(t, http.StatusOK, result.StatusCode())
+	assert.Equal(t, "application/x-ndjson", result.Headers().Get("Content-Type"))
+	assert.Equal(t, "attachment", result.Headers().Get("Content-Disposition"))
+	require.NotNil(t, result.Stream())
+
+	recorder := newStreamRecorder()
+	require.NoError(t, result.Stream()(recorder))
+
+	assert.LessOrEqual(t, recorder.maxWrite, maxStreamWrite)
+	assert.True(t, recorder.Flushed)
+
+	scanner := bufio.NewScanner(recorder.Body)
+	count := 0
+
+	for scanner.Scan() {
+		var row exportRow
+
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		assert.Equal(t, count, row.ID)
+
+		count++
+	}
+
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, streamedRowCount, count)
+}
+
+func TestResults_CSV(t *testing.T) {
+	t.Parallel()
+
+	rows := make(chan []string)
+
+	go func() {
+		defer close(rows)
+
+		for i := range streamedRowCount {
+			rows <- []string{strconv.Itoa(i), "name, with comma " + strconv.Itoa(i)}
+		}
+	}()
+
+	results := &httpfx.Results{}
+	result := results.CSV(
+		t.Context(),
+		[]string{"id", "name"},
+		rows,
+		httpfx.WithHeader("Content-Disposition", `attachment; filename="export.csv"`),
+	)
+
+	assert.Equal(t, "text/csv; charset=utf-8", result.Headers().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="export.csv"`, result.Headers().Get("Content-Disposition"))
+
+	recorder := newStreamRecorder()
+	require.NoError(t, result.Stream()(recorder))
+
+	assert.LessOrEqual(t, recorder.maxWrite, maxStreamWrite)
+
+	records, err := csv.NewReader(bytes.NewReader(recorder.Body.Bytes())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, streamedRowCount+1)
+
+	assert.Equal(t, []string{"id", "name"}, records[0])
+	assert.Equal(t, []string{"41", "name, with comma 41"}, records[42])
+}
+
+func TestResults_NDJSON_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	rows := make(chan any)
+
+	go func() {
+		defer close(rows)
+
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case rows <- exportRow{ID: i, Name: "endless"}:
+			}
+
+			if i == 10 {
+				cancel()
+			}
+		}
+	}()
+
+	results := &httpfx.Results{}
+	result := results.NDJSON(ctx, rows)
+
+	err := result.Stream()(newStreamRecorder())
+	require.ErrorIs(t, err, httpfx.ErrStreamCanceled)
+}
+
+func TestRouter_StreamedResult(t *testing.T) {
+	t.Parallel()
+
+	router := httpfx.NewRouter("/")
+	router.Route("GET /export", func(ctx *httpfx.Context) httpfx.Result {
+		rows := make(chan []string, 2)
+		rows <- []string{"1", "first"}
+		rows <- []string{"2", "second"}
+		close(rows)
+
+		return ctx.Results.CSV(ctx.Request.Context(), []string{"id", "name"}, rows)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	recorder := httptest.NewRecorder()
+
+	router.GetMux().ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment", recorder.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id,name\n1,first\n2,second\n", recorder.Body.String())
+}
+
+func TestRouter_StreamedResult_LogsStreamError(t *testing.T) {
+	t.Parallel()
+
+	var logBuffer bytes.Buffer
+
+	router := httpfx.NewRouter("/")
+	router.SetLogger(logfx.NewLogger(logfx.WithWriter(&logBuffer)))
+	router.Route("GET /export", func(ctx *httpfx.Context) httpfx.Result {
+		streamCtx, cancel := context.WithCancel(ctx.Request.Context())
+		cancel()
+
+		return ctx.Results.NDJSON(streamCtx, make(chan any))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	recorder := httptest.NewRecorder()
+
+	router.GetMux().ServeHTTP(recorder, req)
+
+	assert.Contains(t, logBuffer.String(), "error streaming response body")
+	assert.Contains(t, logBuffer.String(), `"path":"/export"`)
+}
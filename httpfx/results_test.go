@@ -58,6 +58,7 @@ func TestResults_JSON(t *testing.T) {
 
 	result := results.JSON(data)
 	assert.Equal(t, http.StatusOK, result.StatusCode())
+	assert.Equal(t, httpfx.ContentTypeJSON, result.Headers().Get("Content-Type"))
 
 	// Verify JSON encoding
 	var decoded testStruct
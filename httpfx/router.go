@@ -1,19 +1,35 @@
 package httpfx
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"mime"
 	"net/http"
+	"runtime"
+	"strings"
 
 	"github.com/eser/ajan/httpfx/uris"
 	"github.com/eser/ajan/lib"
+	"github.com/eser/ajan/logfx"
 )
 
+var ErrDuplicateRoute = errors.New("duplicate route registration")
+
 type Router struct {
 	mux  *http.ServeMux
 	path string
 
-	handlers []Handler
-	routes   []*Route
+	handlers   []Handler
+	routes     []*Route
+	registered map[string]*Route // route signature -> first registration
+
+	errorMapper *ErrorMapper
+	logger      *logfx.Logger
+
+	prettyJSON bool
 }
 
 func NewRouter(path string) *Router {
@@ -23,8 +39,12 @@ func NewRouter(path string) *Router {
 		mux:  mux,
 		path: path,
 
-		handlers: make([]Handler, 0),
-		routes:   make([]*Route, 0),
+		handlers:   make([]Handler, 0),
+		routes:     make([]*Route, 0),
+		registered: make(map[string]*Route),
+
+		errorMapper: NewDefaultErrorMapper(),
+		logger:      nil,
 	}
 }
 
@@ -45,20 +65,67 @@ func (r *Router) GetRoutes() []*Route {
 }
 
 func (r *Router) Group(path string) *Router {
-	return NewRouter(r.path + path)
+	group := NewRouter(r.path + path)
+	group.prettyJSON = r.prettyJSON
+	group.errorMapper = r.errorMapper
+	group.logger = r.logger
+
+	return group
+}
+
+// SetPrettyJSON indents JSON response bodies with two spaces when enabled. It is meant
+// for development; production responses should stay compact to save bandwidth.
+func (r *Router) SetPrettyJSON(enabled bool) {
+	r.prettyJSON = enabled
+}
+
+func (r *Router) IsPrettyJSON() bool {
+	return r.prettyJSON
+}
+
+// SetErrorMapper replaces the ErrorMapper consulted by the Results.Error of the router's
+// handlers, which starts out as NewDefaultErrorMapper. Groups created afterwards share it.
+func (r *Router) SetErrorMapper(mapper *ErrorMapper) {
+	r.errorMapper = mapper
+}
+
+// GetErrorMapper returns the ErrorMapper of the router, to register mappings on.
+func (r *Router) GetErrorMapper() *ErrorMapper {
+	return r.errorMapper
+}
+
+// SetLogger sets the logger failures to write response bodies are reported to. Until one
+// is set, they go to the default slog logger. Groups created afterwards share it.
+func (r *Router) SetLogger(logger *logfx.Logger) {
+	r.logger = logger
+}
+
+// GetLogger returns the logger of the router, or nil when none is set.
+func (r *Router) GetLogger() *logfx.Logger {
+	return r.logger
 }
 
 func (r *Router) Use(handlers ...Handler) {
 	r.handlers = append(r.handlers, handlers...)
 }
 
+// Route registers handlers for the given pattern. It panics with ErrDuplicateRoute,
+// naming both registration sites, if an equivalent pattern is already registered.
 func (r *Router) Route(pattern string, handlers ...Handler) *Route {
 	parsed, err := uris.ParsePattern(pattern)
 	if err != nil {
 		panic(err)
 	}
 
-	// parsed.method
+	if _, file, line, ok := runtime.Caller(1); ok {
+		parsed.Loc = fmt.Sprintf("%s:%d", file, line)
+	}
+
+	signature := routeSignature(parsed)
+	if existing, exists := r.registered[signature]; exists {
+		panic(fmt.Errorf("%w (pattern=%q, registered_at=%q, duplicate=%q, duplicate_at=%q)",
+			ErrDuplicateRoute, existing.Pattern.Str, existing.Pattern.Loc, parsed.Str, parsed.Loc))
+	}
 
 	route := &Route{Pattern: parsed, Handlers: handlers} //nolint:exhaustruct
 	route.MuxHandlerFunc = func(responseWriter http.ResponseWriter, req *http.Request) {
@@ -68,28 +135,116 @@ func (r *Router) Route(pattern string, handlers ...Handler) *Route {
 			Request:        req,
 			ResponseWriter: responseWriter,
 
-			Results: Results{},
+			Results: Results{errorMapper: r.errorMapper},
 
 			routeDef: route,
 			handlers: routeHandlers,
 			index:    0,
+
+			trailers: nil,
 		}
 
 		result := routeHandlers[0](ctx)
 
+		for key, values := range result.Headers() {
+			for _, value := range values {
+				responseWriter.Header().Add(key, value)
+			}
+		}
+
+		announcedTrailers := ctx.announceTrailers(responseWriter)
+
 		responseWriter.WriteHeader(result.StatusCode())
 
-		_, err := responseWriter.Write(result.Body())
+		if stream := result.Stream(); stream != nil {
+			if err := stream(responseWriter); err != nil {
+				r.logError(req, "error streaming response body", err)
+			}
+
+			ctx.writeTrailers(responseWriter, announcedTrailers)
+
+			return
+		}
+
+		body := result.Body()
+		if r.prettyJSON && isJSONContentType(result.Headers().Get("Content-Type")) {
+			body = indentJSON(body)
+		}
+
+		_, err := responseWriter.Write(body)
 		if err != nil {
-			// TODO(@eser) replace it with logger
-			fmt.Println("error writing response body: %w", err) //nolint:forbidigo
+			r.logError(req, "error writing response body", err)
 		}
+
+		ctx.writeTrailers(responseWriter, announcedTrailers)
 	}
 
 	// TODO(@eser) r.Path+route.Pattern
 	r.mux.HandleFunc(route.Pattern.Str, route.MuxHandlerFunc)
 
 	r.routes = append(r.routes, route)
+	r.registered[signature] = route
 
 	return route
 }
+
+// routeSignature builds a key that is identical for patterns the mux treats as the same
+// route, ignoring wildcard names (e.g. "GET /users/{id}" and "GET /users/{name}").
+func routeSignature(pattern *uris.Pattern) string {
+	var builder strings.Builder
+
+	builder.WriteString(pattern.Method)
+	builder.WriteByte(' ')
+	builder.WriteString(pattern.Host)
+
+	for _, segment := range pattern.Segments {
+		builder.WriteByte('/')
+
+		switch {
+		case segment.Multi:
+			builder.WriteString("{...}")
+		case segment.Wild:
+			builder.WriteString("{}")
+		default:
+			builder.WriteString(segment.Str)
+		}
+	}
+
+	return builder.String()
+}
+
+// isJSONContentType reports whether the content type is application/json or a
+// structured "+json" type such as application/problem+json.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// indentJSON indents a JSON body with two spaces, returning it unchanged if it is not
+// valid JSON.
+func indentJSON(body []byte) []byte {
+	var buffer bytes.Buffer
+
+	if err := json.Indent(&buffer, body, "", "  "); err != nil {
+		return body
+	}
+
+	return buffer.Bytes()
+}
+
+func (r *Router) logError(req *http.Request, msg string, err error) {
+	logger := slog.Default()
+	if r.logger != nil {
+		logger = r.logger.Logger
+	}
+
+	logger.ErrorContext(req.Context(), msg,
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Any("error", err),
+	)
+}
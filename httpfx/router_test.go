@@ -176,3 +176,162 @@ func TestRouter_RouteWithMiddleware(t *testing.T) {
 	assert.Equal(t, "test", w.Body.String())
 	assert.Equal(t, "middleware", w.Header().Get("X-Test"))
 }
+
+func TestRouter_RouteDuplicate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		first     string
+		second    string
+		duplicate bool
+	}{
+		{
+			name:      "identical_pattern",
+			first:     "GET /users",
+			second:    "GET /users",
+			duplicate: true,
+		},
+		{
+			name:      "renamed_wildcard",
+			first:     "GET /users/{id}",
+			second:    "GET /users/{name}",
+			duplicate: true,
+		},
+		{
+			name:      "different_method",
+			first:     "GET /users",
+			second:    "POST /users",
+			duplicate: false,
+		},
+		{
+			name:      "different_path",
+			first:     "GET /users",
+			second:    "GET /posts",
+			duplicate: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := httpfx.NewRouter("/")
+
+			handler := func(ctx *httpfx.Context) httpfx.Result {
+				return ctx.Results.Ok()
+			}
+
+			router.Route(tt.first, handler)
+
+			register := func() { router.Route(tt.second, handler) }
+
+			if !tt.duplicate {
+				assert.NotPanics(t, register)
+				assert.Len(t, router.GetRoutes(), 2)
+
+				return
+			}
+
+			var recovered any
+
+			func() {
+				defer func() { recovered = recover() }()
+
+				register()
+			}()
+
+			err, ok := recovered.(error)
+			require.True(t, ok, "expected an error panic, got %v", recovered)
+			require.ErrorIs(t, err, httpfx.ErrDuplicateRoute)
+			assert.Contains(t, err.Error(), tt.first)
+			assert.Contains(t, err.Error(), "router_test.go")
+			assert.Len(t, router.GetRoutes(), 1)
+		})
+	}
+}
+
+func TestRouter_PrettyJSON(t *testing.T) {
+	t.Parallel()
+
+	payload := map[string]any{"name": "test", "value": 42}
+
+	tests := []struct {
+		handler  httpfx.Handler
+		name     string
+		wantBody string
+		pretty   bool
+	}{
+		{
+			name: "json_result_compact",
+			handler: func(ctx *httpfx.Context) httpfx.Result {
+				return ctx.Results.JSON(payload)
+			},
+			pretty:   false,
+			wantBody: `{"name":"test","value":42}`,
+		},
+		{
+			name: "json_result_pretty",
+			handler: func(ctx *httpfx.Context) httpfx.Result {
+				return ctx.Results.JSON(payload)
+			},
+			pretty:   true,
+			wantBody: "{\n  \"name\": \"test\",\n  \"value\": 42\n}",
+		},
+		{
+			name: "json_option_pretty",
+			handler: func(ctx *httpfx.Context) httpfx.Result {
+				return ctx.Results.Error(http.StatusConflict, httpfx.WithJSON(payload))
+			},
+			pretty:   true,
+			wantBody: "{\n  \"name\": \"test\",\n  \"value\": 42\n}",
+		},
+		{
+			name: "problem_json_pretty",
+			handler: func(ctx *httpfx.Context) httpfx.Result {
+				return ctx.Results.BadRequest(
+					httpfx.WithHeader("Content-Type", "application/problem+json"),
+					httpfx.WithJSON(payload),
+				)
+			},
+			pretty:   true,
+			wantBody: "{\n  \"name\": \"test\",\n  \"value\": 42\n}",
+		},
+		{
+			name: "plain_text_untouched",
+			handler: func(ctx *httpfx.Context) httpfx.Result {
+				return ctx.Results.PlainText([]byte(`{"name":"test"}`))
+			},
+			pretty:   true,
+			wantBody: `{"name":"test"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := httpfx.NewRouter("/")
+			router.SetPrettyJSON(tt.pretty)
+
+			route := router.Route("GET /test", tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			w := httptest.NewRecorder()
+
+			route.MuxHandlerFunc(w, req)
+
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestRouter_PrettyJSON_Group(t *testing.T) {
+	t.Parallel()
+
+	router := httpfx.NewRouter("/api")
+	router.SetPrettyJSON(true)
+
+	assert.True(t, router.Group("/v1").IsPrettyJSON())
+	assert.False(t, httpfx.NewRouter("/").IsPrettyJSON())
+}
@@ -0,0 +1,107 @@
+package httpfx
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// ContextKeyServerTiming is the request context key holding the request's *ServerTiming.
+const ContextKeyServerTiming ContextKey = "server-timing"
+
+// ServerTimingEntry is a named phase of a request and how long it took.
+type ServerTimingEntry struct {
+	Name     string
+	Duration time.Duration
+}
+
+// ServerTiming collects the phase durations of a single request, rendered as the
+// Server-Timing response header.
+type ServerTiming struct {
+	entries []ServerTimingEntry
+	mu      sync.Mutex
+}
+
+// NewServerTiming creates an empty ServerTiming.
+func NewServerTiming() *ServerTiming {
+	return &ServerTiming{
+		entries: nil,
+		mu:      sync.Mutex{},
+	}
+}
+
+// ServerTimingFromContext returns the ServerTiming stored in ctx, or nil.
+func ServerTimingFromContext(ctx context.Context) *ServerTiming {
+	timing, _ := ctx.Value(ContextKeyServerTiming).(*ServerTiming)
+
+	return timing
+}
+
+// Add records a phase. Phases recorded more than once appear once per call, in order.
+func (s *ServerTiming) Add(name string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, ServerTimingEntry{Name: name, Duration: d})
+}
+
+// Entries returns the recorded phases in the order they were added.
+func (s *ServerTiming) Entries() []ServerTimingEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]ServerTimingEntry, len(s.entries))
+	copy(entries, s.entries)
+
+	return entries
+}
+
+// HeaderValue renders the phases as a Server-Timing header value, e.g.
+// "db;dur=12.5, cache;dur=0.3", with durations in milliseconds. Characters that are not
+// allowed in a metric name are replaced with "_".
+func (s *ServerTiming) HeaderValue() string {
+	entries := s.Entries()
+	metrics := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		milliseconds := float64(entry.Duration.Microseconds()) /
+			float64(time.Millisecond/time.Microsecond)
+
+		metrics = append(metrics,
+			serverTimingName(entry.Name)+";dur="+strconv.FormatFloat(milliseconds, 'f', -1, 64),
+		)
+	}
+
+	return strings.Join(metrics, ", ")
+}
+
+// serverTimingName turns name into an HTTP token, as Server-Timing metric names must be.
+func serverTimingName(name string) string {
+	if name == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		if httpguts.IsTokenRune(r) {
+			return r
+		}
+
+		return '_'
+	}, name)
+}
+
+// AddTiming records a named phase of the request, such as "db", "cache" or "upstream",
+// for the Server-Timing response header. It does nothing unless a ServerTiming was set
+// up, e.g. by middlewares.ServerTimingMiddleware.
+func (c *Context) AddTiming(name string, d time.Duration) {
+	timing := ServerTimingFromContext(c.Request.Context())
+	if timing == nil {
+		return
+	}
+
+	timing.Add(name, d)
+}
@@ -0,0 +1,56 @@
+package httpfx
+
+import (
+	"net/http"
+	"slices"
+)
+
+// SetTrailer sets a response trailer, sent after the body, e.g. a checksum or the status
+// of a streamed response as gRPC-Web does. Trailers set before the handler returns its
+// result are announced in the Trailer header ahead of the body. Trailers set later, from
+// a streamed result, are sent unannounced, which HTTP/1.1 chunked and HTTP/2 responses
+// support.
+func (c *Context) SetTrailer(key string, value string) {
+	if c.trailers == nil {
+		c.trailers = make(http.Header)
+	}
+
+	c.trailers.Set(key, value)
+}
+
+// RequestTrailer returns a trailer sent by the client. Trailers arrive after the request
+// body, so it returns "" until the body has been read to the end.
+func (c *Context) RequestTrailer(key string) string {
+	return c.Request.Trailer.Get(key)
+}
+
+// announceTrailers lists the trailers set so far in the Trailer header, which must be
+// written before the body. It returns the announced keys.
+func (c *Context) announceTrailers(w http.ResponseWriter) []string {
+	keys := make([]string, 0, len(c.trailers))
+	for key := range c.trailers {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		w.Header().Add("Trailer", key)
+	}
+
+	return keys
+}
+
+// writeTrailers sets the trailer values once the body is written. Trailers that were not
+// announced are marked with http.TrailerPrefix.
+func (c *Context) writeTrailers(w http.ResponseWriter, announced []string) {
+	for key, values := range c.trailers {
+		if slices.Contains(announced, key) {
+			w.Header()[key] = values
+
+			continue
+		}
+
+		w.Header()[http.TrailerPrefix+key] = values
+	}
+}
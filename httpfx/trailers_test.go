@@ -0,0 +1,118 @@
+package httpfx_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eser/ajan/httpfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_SetTrailer(t *testing.T) {
+	t.Parallel()
+
+	router := httpfx.NewRouter("/")
+
+	router.Route("GET /export", func(ctx *httpfx.Context) httpfx.Result {
+		ctx.SetTrailer("X-Checksum", "5d41402a")
+
+		return ctx.Results.PlainText([]byte("hello"))
+	})
+
+	router.Route("GET /stream", func(ctx *httpfx.Context) httpfx.Result {
+		ctx.SetTrailer("Grpc-Status", "13")
+
+		result := ctx.Results.Ok()
+		result.InnerStatusCode = http.StatusOK
+		result.InnerStream = func(w http.ResponseWriter) error {
+			if _, err := io.WriteString(w, "partial"); err != nil {
+				return err
+			}
+
+			// Known only once the body is written
+			ctx.SetTrailer("Grpc-Status", "0")
+			ctx.SetTrailer("Grpc-Message", "done")
+
+			return nil
+		}
+
+		return result
+	})
+
+	server := httptest.NewServer(router.GetMux())
+	t.Cleanup(server.Close)
+
+	get := func(path string, announced string) (*http.Response, string) {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+
+		t.Cleanup(func() { _ = resp.Body.Close() })
+
+		// Trailers are announced before the body, and filled in after it
+		assert.Contains(t, resp.Trailer, announced)
+		assert.Empty(t, resp.Trailer.Get(announced))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, string(body)
+	}
+
+	resp, body := get("/export", "X-Checksum")
+	assert.Equal(t, "hello", body)
+	assert.Equal(t, "5d41402a", resp.Trailer.Get("X-Checksum"))
+
+	resp, body = get("/stream", "Grpc-Status")
+	assert.Equal(t, "partial", body)
+	assert.Equal(t, "0", resp.Trailer.Get("Grpc-Status"))
+	assert.Equal(t, "done", resp.Trailer.Get("Grpc-Message"))
+}
+
+func TestContext_RequestTrailer(t *testing.T) {
+	t.Parallel()
+
+	router := httpfx.NewRouter("/")
+
+	router.Route("POST /upload", func(ctx *httpfx.Context) httpfx.Result {
+		// Not received until the body is read
+		before := ctx.RequestTrailer("X-Checksum")
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return ctx.Results.BadRequest()
+		}
+
+		return ctx.Results.PlainText(
+			[]byte(before + "|" + string(body) + "|" + ctx.RequestTrailer("X-Checksum")),
+		)
+	})
+
+	server := httptest.NewServer(router.GetMux())
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequestWithContext(
+		t.Context(),
+		http.MethodPost,
+		server.URL+"/upload",
+		io.NopCloser(strings.NewReader("payload")),
+	)
+	require.NoError(t, err)
+
+	req.Trailer = http.Header{"X-Checksum": []string{"321c3cf4"}}
+
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "|payload|321c3cf4", string(body))
+}
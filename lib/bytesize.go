@@ -0,0 +1,133 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes that parses from human-friendly strings such as "512",
+// "10MB" or "1.5GiB".
+type ByteSize int64
+
+// Decimal (SI) units.
+const (
+	Byte     ByteSize = 1
+	Kilobyte          = 1000 * Byte
+	Megabyte          = 1000 * Kilobyte
+	Gigabyte          = 1000 * Megabyte
+	Terabyte          = 1000 * Gigabyte
+)
+
+// Binary (IEC) units.
+const (
+	Kibibyte = 1024 * Byte
+	Mebibyte = 1024 * Kibibyte
+	Gibibyte = 1024 * Mebibyte
+	Tebibyte = 1024 * Gibibyte
+)
+
+var ErrInvalidByteSize = errors.New("invalid byte size")
+
+var byteSizeUnits = map[string]ByteSize{ //nolint:gochecknoglobals
+	"":    Byte,
+	"b":   Byte,
+	"kb":  Kilobyte,
+	"mb":  Megabyte,
+	"gb":  Gigabyte,
+	"tb":  Terabyte,
+	"kib": Kibibyte,
+	"mib": Mebibyte,
+	"gib": Gibibyte,
+	"tib": Tebibyte,
+}
+
+// ParseByteSize parses a size made of a non-negative number and an optional unit. Units
+// are case-insensitive: B, KB, MB, GB and TB are powers of 1000, while KiB, MiB, GiB and
+// TiB are powers of 1024. A number without a unit is a count of bytes.
+func ParseByteSize(value string) (ByteSize, error) {
+	trimmed := strings.TrimSpace(value)
+
+	unitStart := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if unitStart == -1 {
+		unitStart = len(trimmed)
+	}
+
+	number := trimmed[:unitStart]
+	unit := strings.ToLower(strings.TrimSpace(trimmed[unitStart:]))
+
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("%w (value=%q)", ErrInvalidByteSize, value)
+	}
+
+	if whole, err := strconv.ParseInt(number, 10, 64); err == nil {
+		if whole > math.MaxInt64/int64(multiplier) {
+			return 0, fmt.Errorf("%w (value=%q): overflows int64", ErrInvalidByteSize, value)
+		}
+
+		return ByteSize(whole) * multiplier, nil
+	}
+
+	fractional, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w (value=%q): %w", ErrInvalidByteSize, value, err)
+	}
+
+	bytes := math.Round(fractional * float64(multiplier))
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("%w (value=%q): overflows int64", ErrInvalidByteSize, value)
+	}
+
+	return ByteSize(bytes), nil
+}
+
+// Bytes returns the size as a plain number of bytes.
+func (s ByteSize) Bytes() int64 {
+	return int64(s)
+}
+
+// String formats the size with the largest unit that represents it exactly, e.g. "10MB",
+// "1GiB" or "1500B".
+func (s ByteSize) String() string {
+	for _, unit := range []struct {
+		name string
+		size ByteSize
+	}{
+		{name: "TiB", size: Tebibyte},
+		{name: "TB", size: Terabyte},
+		{name: "GiB", size: Gibibyte},
+		{name: "GB", size: Gigabyte},
+		{name: "MiB", size: Mebibyte},
+		{name: "MB", size: Megabyte},
+		{name: "KiB", size: Kibibyte},
+		{name: "KB", size: Kilobyte},
+	} {
+		if s != 0 && s%unit.size == 0 {
+			return strconv.FormatInt(int64(s/unit.size), 10) + unit.name
+		}
+	}
+
+	return strconv.FormatInt(int64(s), 10) + "B"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s ByteSize) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseByteSize.
+func (s *ByteSize) UnmarshalText(text []byte) error {
+	parsed, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = parsed
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package lib_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/eser/ajan/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  lib.ByteSize
+	}{
+		{input: "0", want: 0},
+		{input: "512", want: 512},
+		{input: "512B", want: 512},
+		{input: "10KB", want: 10_000},
+		{input: "10MB", want: 10_000_000},
+		{input: "2GB", want: 2_000_000_000},
+		{input: "1TB", want: 1_000_000_000_000},
+		{input: "4KiB", want: 4096},
+		{input: "1MiB", want: 1 << 20},
+		{input: "1GiB", want: 1 << 30},
+		{input: "1TiB", want: 1 << 40},
+		{input: "1.5GiB", want: 3 << 29},
+		{input: " 64 kib ", want: 64 << 10},
+		{input: "10mb", want: 10_000_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := lib.ParseByteSize(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseByteSize_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{
+		"",
+		"MB",
+		"-1MB",
+		"10XB",
+		"1.2.3MB",
+		"10 M B",
+		"99999999999TB",
+	} {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := lib.ParseByteSize(input)
+			require.ErrorIs(t, err, lib.ErrInvalidByteSize)
+		})
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "0B", lib.ByteSize(0).String())
+	assert.Equal(t, "1500B", lib.ByteSize(1500).String())
+	assert.Equal(t, "10MB", (10 * lib.Megabyte).String())
+	assert.Equal(t, "1GiB", lib.Gibibyte.String())
+	assert.Equal(t, "4KiB", (4 * lib.Kibibyte).String())
+}
+
+func TestByteSize_JSON(t *testing.T) {
+	t.Parallel()
+
+	var config struct {
+		Limit lib.ByteSize `json:"limit"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"limit":"256KiB"}`), &config))
+	assert.Equal(t, 256*lib.Kibibyte, config.Limit)
+
+	encoded, err := json.Marshal(config)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"limit":"256KiB"}`, string(encoded))
+
+	require.ErrorIs(t, json.Unmarshal([]byte(`{"limit":"lots"}`), &config), lib.ErrInvalidByteSize)
+}
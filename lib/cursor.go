@@ -0,0 +1,79 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CursorMinKeyLength is the shortest key EncodeCursor and DecodeCursor accept.
+const CursorMinKeyLength = 32
+
+var (
+	ErrCursorKeyTooShort = errors.New("cursor key is too short")
+	ErrCursorEncoding    = errors.New("failed to encode cursor")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+)
+
+// EncodeCursor returns an opaque pagination cursor holding value as JSON, signed with
+// an HMAC-SHA256 of key so clients cannot forge or alter it. The cursor is URL-safe
+// base64 and fits in a query parameter. The payload is readable, not encrypted, so it
+// must not carry secrets.
+func EncodeCursor(key []byte, value any) (string, error) {
+	if len(key) < CursorMinKeyLength {
+		return "", fmt.Errorf("%w (length=%d)", ErrCursorKeyTooShort, len(key))
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrCursorEncoding, err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(signCursor(key, payload)), nil
+}
+
+// DecodeCursor verifies a cursor made by EncodeCursor with the same key and unmarshals
+// its value into dest. Malformed, forged or altered cursors return ErrInvalidCursor,
+// which the default error mapper of httpfx answers with 400 Bad Request.
+func DecodeCursor(key []byte, cursor string, dest any) error {
+	if len(key) < CursorMinKeyLength {
+		return fmt.Errorf("%w (length=%d)", ErrCursorKeyTooShort, len(key))
+	}
+
+	encodedPayload, encodedSignature, found := strings.Cut(cursor, ".")
+	if !found {
+		return fmt.Errorf("%w: malformed", ErrInvalidCursor)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return fmt.Errorf("%w: malformed payload", ErrInvalidCursor)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidCursor)
+	}
+
+	if !hmac.Equal(signature, signCursor(key, payload)) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidCursor)
+	}
+
+	if err := json.Unmarshal(payload, dest); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	return nil
+}
+
+func signCursor(key []byte, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
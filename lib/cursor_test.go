@@ -0,0 +1,81 @@
+package lib_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/eser/ajan/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pageCursor struct {
+	AfterID string `json:"after_id"`
+	Offset  int    `json:"offset"`
+}
+
+func TestEncodeCursor_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	key := []byte(strings.Repeat("k", lib.CursorMinKeyLength))
+
+	cursor, err := lib.EncodeCursor(key, pageCursor{AfterID: "user-42", Offset: 20})
+	require.NoError(t, err)
+	assert.NotContains(t, cursor, "user-42", "the cursor must be opaque")
+	assert.Equal(t, -1, strings.IndexAny(cursor, "+/="), "the cursor must be URL-safe")
+
+	var decoded pageCursor
+
+	require.NoError(t, lib.DecodeCursor(key, cursor, &decoded))
+	assert.Equal(t, pageCursor{AfterID: "user-42", Offset: 20}, decoded)
+}
+
+func TestDecodeCursor_Rejects(t *testing.T) {
+	t.Parallel()
+
+	key := []byte(strings.Repeat("k", lib.CursorMinKeyLength))
+	otherKey := []byte(strings.Repeat("o", lib.CursorMinKeyLength))
+
+	cursor, err := lib.EncodeCursor(key, pageCursor{AfterID: "user-42", Offset: 20})
+	require.NoError(t, err)
+
+	_, signature, _ := strings.Cut(cursor, ".")
+	forgedPayload := base64.RawURLEncoding.EncodeToString(
+		[]byte(`{"after_id":"user-1","offset":0}`),
+	)
+
+	tests := []struct {
+		name   string
+		key    []byte
+		cursor string
+	}{
+		{name: "tampered payload", key: key, cursor: forgedPayload + "." + signature},
+		{name: "signed with another key", key: otherKey, cursor: cursor},
+		{name: "missing signature", key: key, cursor: strings.Split(cursor, ".")[0]},
+		{name: "not base64", key: key, cursor: "!!!." + signature},
+		{name: "empty", key: key, cursor: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var decoded pageCursor
+
+			err := lib.DecodeCursor(tt.key, tt.cursor, &decoded)
+			require.ErrorIs(t, err, lib.ErrInvalidCursor)
+			assert.Equal(t, pageCursor{}, decoded) //nolint:exhaustruct
+		})
+	}
+}
+
+func TestEncodeCursor_ShortKey(t *testing.T) {
+	t.Parallel()
+
+	_, err := lib.EncodeCursor([]byte("short"), pageCursor{}) //nolint:exhaustruct
+	require.ErrorIs(t, err, lib.ErrCursorKeyTooShort)
+
+	err = lib.DecodeCursor(nil, "e30.c2ln", &pageCursor{}) //nolint:exhaustruct
+	require.ErrorIs(t, err, lib.ErrCursorKeyTooShort)
+}
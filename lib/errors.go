@@ -0,0 +1,36 @@
+package lib
+
+import "errors"
+
+// Error categories. Packages define their sentinel errors within a category with
+// NewCategorizedError, so that errors.Is matches both the sentinel and its category.
+// Transports answer errors by category without importing the packages defining them:
+// httpfx maps each category to an HTTP status.
+var (
+	ErrCategoryNotFound     = errors.New("not found")
+	ErrCategoryInvalidInput = errors.New("invalid input")
+	ErrCategoryTooLarge     = errors.New("too large")
+	ErrCategoryUnavailable  = errors.New("unavailable")
+)
+
+type categorizedError struct {
+	category error
+	message  string
+}
+
+// NewCategorizedError creates a sentinel error with the given message that also matches
+// category with errors.Is.
+func NewCategorizedError(category error, message string) error {
+	return &categorizedError{
+		category: category,
+		message:  message,
+	}
+}
+
+func (e *categorizedError) Error() string {
+	return e.message
+}
+
+func (e *categorizedError) Unwrap() error {
+	return e.category
+}
@@ -0,0 +1,26 @@
+package lib_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/eser/ajan/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCategorizedError(t *testing.T) {
+	t.Parallel()
+
+	errMissing := lib.NewCategorizedError(lib.ErrCategoryNotFound, "order not found")
+	err := fmt.Errorf("%w (id=%q)", errMissing, "42")
+
+	assert.Equal(t, "order not found", errMissing.Error())
+	assert.ErrorIs(t, err, errMissing)
+	assert.ErrorIs(t, err, lib.ErrCategoryNotFound)
+	assert.NotErrorIs(t, err, lib.ErrCategoryUnavailable)
+
+	// Sentinels of the same category remain distinct
+	errOther := lib.NewCategorizedError(lib.ErrCategoryNotFound, "order not found")
+	assert.False(t, errors.Is(err, errOther))
+}
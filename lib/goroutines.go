@@ -0,0 +1,59 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+var ErrGoroutinePanicked = errors.New("goroutine panicked")
+
+// SafeGo runs fn in a new goroutine, recovering any panic so it cannot crash the process.
+// A recovered panic is reported to onPanic along with the goroutine's stack trace; a nil
+// onPanic logs it with slog instead.
+//
+// The returned channel receives fn's error, or an ErrGoroutinePanicked error after a
+// panic, and is then closed. It is buffered, so callers may ignore it.
+func SafeGo(fn func() error, onPanic func(recovered any, stack []byte)) <-chan error {
+	result := make(chan error, 1)
+
+	go func() {
+		defer close(result)
+
+		result <- runRecovered(fn, onPanic)
+	}()
+
+	return result
+}
+
+func runRecovered(fn func() error, onPanic func(recovered any, stack []byte)) error {
+	var err error
+
+	func() {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			stack := debug.Stack()
+
+			if onPanic != nil {
+				onPanic(recovered, stack)
+			} else {
+				slog.Error(
+					"Recovered panic in goroutine",
+					slog.Any("panic", recovered),
+					slog.String("stack", string(stack)),
+				)
+			}
+
+			err = fmt.Errorf("%w: %v", ErrGoroutinePanicked, recovered)
+		}()
+
+		err = fn()
+	}()
+
+	return err
+}
@@ -0,0 +1,70 @@
+package lib_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/eser/ajan/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTaskFailed = errors.New("task failed")
+
+func TestSafeGo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns the function error", func(t *testing.T) {
+		t.Parallel()
+
+		result := lib.SafeGo(func() error {
+			return errTaskFailed
+		}, func(recovered any, stack []byte) {
+			t.Errorf("unexpected panic: %v", recovered)
+		})
+
+		require.ErrorIs(t, <-result, errTaskFailed)
+
+		_, open := <-result
+		assert.False(t, open)
+	})
+
+	t.Run("recovers and reports panics", func(t *testing.T) {
+		t.Parallel()
+
+		type report struct {
+			recovered any
+			stack     []byte
+		}
+
+		reports := make(chan report, 1)
+
+		result := lib.SafeGo(func() error {
+			panic("boom")
+		}, func(recovered any, stack []byte) {
+			reports <- report{recovered: recovered, stack: stack}
+		})
+
+		err := <-result
+		require.ErrorIs(t, err, lib.ErrGoroutinePanicked)
+		assert.Contains(t, err.Error(), "boom")
+
+		reported := <-reports
+		assert.Equal(t, "boom", reported.recovered)
+		assert.Contains(t, string(reported.stack), "goroutines_test.go")
+	})
+
+	t.Run("recovers panics without a callback", func(t *testing.T) {
+		t.Parallel()
+
+		result := lib.SafeGo(func() error {
+			var values map[string]int
+
+			values["key"] = 1 // assignment to a nil map panics
+
+			return nil
+		}, nil)
+
+		require.ErrorIs(t, <-result, lib.ErrGoroutinePanicked)
+	})
+}
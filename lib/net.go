@@ -62,3 +62,13 @@ func DetectLocalNetwork(requestAddr string) (bool, error) {
 
 	return false, nil
 }
+
+// ConnectionProtocol names conn for logs and error messages, preferring the protocol a
+// connfx connection reports and falling back to its type name.
+func ConnectionProtocol(conn any) string {
+	if protocolConn, ok := conn.(interface{ GetProtocol() string }); ok {
+		return protocolConn.GetProtocol()
+	}
+
+	return fmt.Sprintf("%T", conn)
+}
@@ -1,6 +1,7 @@
 package lib_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/eser/ajan/lib"
@@ -61,3 +62,16 @@ func TestDetectLocalNetwork(t *testing.T) {
 		})
 	}
 }
+
+type protocolConnection struct{}
+
+func (protocolConnection) GetProtocol() string {
+	return "otlp"
+}
+
+func TestConnectionProtocol(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "otlp", lib.ConnectionProtocol(protocolConnection{}))
+	assert.Equal(t, "*strings.Reader", lib.ConnectionProtocol(strings.NewReader("")))
+}
@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	DefaultRetryMaxAttempts     = 3
+	DefaultRetryInitialInterval = 100 * time.Millisecond
+	DefaultRetryMaxInterval     = 10 * time.Second
+	DefaultRetryMultiplier      = 2.0
+	DefaultRetryRandomFactor    = 0.1
+)
+
+var ErrRetryExhausted = errors.New("retry attempts exhausted")
+
+// RetryPolicy controls how Retry repeats a failing operation.
+type RetryPolicy struct {
+	// IsRetryable reports whether an error should be retried (nil retries every error)
+	IsRetryable func(err error) bool
+
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts uint
+	// InitialInterval is the delay before the first retry
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between attempts
+	MaxInterval time.Duration
+	// Multiplier grows the delay after every attempt
+	Multiplier float64
+	// RandomFactor adds jitter of ±RandomFactor to every delay
+	RandomFactor float64
+}
+
+// DefaultRetryPolicy returns a policy with 3 attempts and exponential backoff.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		IsRetryable:     nil,
+		MaxAttempts:     DefaultRetryMaxAttempts,
+		InitialInterval: DefaultRetryInitialInterval,
+		MaxInterval:     DefaultRetryMaxInterval,
+		Multiplier:      DefaultRetryMultiplier,
+		RandomFactor:    DefaultRetryRandomFactor,
+	}
+}
+
+// Backoff returns the delay before the given retry (1 for the first retry).
+func (p RetryPolicy) Backoff(retry uint) time.Duration {
+	if retry == 0 {
+		return 0
+	}
+
+	multiplier := max(p.Multiplier, 1)
+	backoff := float64(p.InitialInterval) * math.Pow(multiplier, float64(retry-1))
+
+	if p.MaxInterval > 0 {
+		backoff = min(backoff, float64(p.MaxInterval))
+	}
+
+	if p.RandomFactor > 0 {
+		delta := p.RandomFactor * backoff
+		backoff = backoff - delta + rand.Float64()*(2*delta) //nolint:gosec
+	}
+
+	return time.Duration(backoff)
+}
+
+// Retry calls fn until it succeeds, returns a non-retryable error, the policy's
+// attempts are exhausted or ctx is canceled. The last error from fn is wrapped in
+// the returned error.
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	maxAttempts := max(policy.MaxAttempts, 1)
+
+	var lastErr error
+
+	for attempt := uint(1); ; attempt++ {
+		lastErr = fn(ctx)
+		if lastErr == nil {
+			return nil
+		}
+
+		if policy.IsRetryable != nil && !policy.IsRetryable(lastErr) {
+			return lastErr
+		}
+
+		if attempt >= maxAttempts {
+			return fmt.Errorf("%w (attempts=%d): %w", ErrRetryExhausted, attempt, lastErr)
+		}
+
+		timer := time.NewTimer(policy.Backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return fmt.Errorf("%w (attempts=%d): %w", ctx.Err(), attempt, lastErr)
+		case <-timer.C:
+		}
+	}
+}
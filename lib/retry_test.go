@@ -0,0 +1,164 @@
+package lib_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errTemporary = errors.New("temporary failure")
+	errPermanent = errors.New("permanent failure")
+)
+
+func fastRetryPolicy(maxAttempts uint) lib.RetryPolicy {
+	policy := lib.DefaultRetryPolicy()
+	policy.MaxAttempts = maxAttempts
+	policy.InitialInterval = time.Millisecond
+	policy.MaxInterval = 5 * time.Millisecond
+
+	return policy
+}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		policy        lib.RetryPolicy
+		failures      []error
+		expectedErr   error
+		name          string
+		expectedCalls int
+	}{
+		{
+			name:          "success on first attempt",
+			policy:        fastRetryPolicy(3),
+			failures:      nil,
+			expectedErr:   nil,
+			expectedCalls: 1,
+		},
+		{
+			name:          "success after failures",
+			policy:        fastRetryPolicy(3),
+			failures:      []error{errTemporary, errTemporary},
+			expectedErr:   nil,
+			expectedCalls: 3,
+		},
+		{
+			name:          "exhausted",
+			policy:        fastRetryPolicy(3),
+			failures:      []error{errTemporary, errTemporary, errTemporary, errTemporary},
+			expectedErr:   lib.ErrRetryExhausted,
+			expectedCalls: 3,
+		},
+		{
+			name: "non-retryable error",
+			policy: func() lib.RetryPolicy {
+				policy := fastRetryPolicy(3)
+				policy.IsRetryable = func(err error) bool {
+					return !errors.Is(err, errPermanent)
+				}
+
+				return policy
+			}(),
+			failures:      []error{errTemporary, errPermanent, errTemporary},
+			expectedErr:   errPermanent,
+			expectedCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+
+			err := lib.Retry(t.Context(), tt.policy, func(ctx context.Context) error {
+				calls++
+
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+
+				return nil
+			})
+
+			assert.Equal(t, tt.expectedCalls, calls)
+
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestRetry_ExhaustedWrapsLastError(t *testing.T) {
+	t.Parallel()
+
+	err := lib.Retry(t.Context(), fastRetryPolicy(2), func(ctx context.Context) error {
+		return errTemporary
+	})
+
+	require.ErrorIs(t, err, lib.ErrRetryExhausted)
+	require.ErrorIs(t, err, errTemporary)
+}
+
+func TestRetry_ContextCanceledDuringBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := lib.DefaultRetryPolicy()
+	policy.MaxAttempts = 5
+	policy.InitialInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	calls := 0
+	start := time.Now()
+
+	err := lib.Retry(ctx, policy, func(ctx context.Context) error {
+		calls++
+
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		return errTemporary
+	})
+
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorIs(t, err, errTemporary)
+	assert.Equal(t, 1, calls)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	policy := lib.RetryPolicy{
+		IsRetryable:     nil,
+		MaxAttempts:     5,
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     300 * time.Millisecond,
+		Multiplier:      2,
+		RandomFactor:    0,
+	}
+
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 300*time.Millisecond, policy.Backoff(3))
+
+	policy.RandomFactor = 0.5
+
+	for range 100 {
+		backoff := policy.Backoff(1)
+		assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 150*time.Millisecond)
+	}
+}
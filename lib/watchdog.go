@@ -0,0 +1,59 @@
+package lib
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+const watchdogInitialStackSize = 64 << 10 // 64 KiB
+
+// WatchdogContext watches an operation that is expected to finish within threshold. If
+// the returned stop function has not been called by then, and ctx is not done, it
+// captures the stacks of all goroutines and passes them to onExceed; a nil onExceed logs
+// them with slog at warn level instead. Call stop when the operation completes:
+//
+//	defer lib.WatchdogContext(ctx, 5*time.Second, nil)()
+func WatchdogContext(
+	ctx context.Context,
+	threshold time.Duration,
+	onExceed func(stack []byte),
+) func() {
+	if onExceed == nil {
+		onExceed = func(stack []byte) {
+			slog.WarnContext(
+				ctx,
+				"Operation exceeded watchdog threshold",
+				slog.Duration("threshold", threshold),
+				slog.String("stack", string(stack)),
+			)
+		}
+	}
+
+	timer := time.AfterFunc(threshold, func() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		onExceed(captureAllStacks())
+	})
+
+	return func() {
+		timer.Stop()
+	}
+}
+
+// captureAllStacks returns the stacks of all goroutines, growing the buffer until they fit.
+func captureAllStacks() []byte {
+	buffer := make([]byte, watchdogInitialStackSize)
+
+	for {
+		n := runtime.Stack(buffer, true)
+		if n < len(buffer) {
+			return buffer[:n]
+		}
+
+		buffer = make([]byte, 2*len(buffer))
+	}
+}
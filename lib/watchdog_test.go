@@ -0,0 +1,79 @@
+package lib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func slowOperation(release <-chan struct{}) {
+	<-release
+}
+
+func TestWatchdogContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reports stacks of a slow operation", func(t *testing.T) {
+		t.Parallel()
+
+		stacks := make(chan []byte, 1)
+		release := make(chan struct{})
+
+		go slowOperation(release)
+
+		stop := lib.WatchdogContext(t.Context(), 10*time.Millisecond, func(stack []byte) {
+			stacks <- stack
+		})
+
+		select {
+		case stack := <-stacks:
+			// The stuck goroutine is part of the captured stacks
+			assert.Contains(t, string(stack), "lib_test.slowOperation")
+		case <-time.After(5 * time.Second):
+			t.Fatal("watchdog did not fire")
+		}
+
+		stop()
+		close(release)
+	})
+
+	t.Run("stays silent when the operation completes in time", func(t *testing.T) {
+		t.Parallel()
+
+		fired := make(chan struct{}, 1)
+
+		stop := lib.WatchdogContext(t.Context(), 20*time.Millisecond, func(stack []byte) {
+			fired <- struct{}{}
+		})
+		stop()
+
+		select {
+		case <-fired:
+			t.Fatal("watchdog fired for a completed operation")
+		case <-time.After(60 * time.Millisecond):
+		}
+	})
+
+	t.Run("stays silent when the context is done", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		fired := make(chan struct{}, 1)
+
+		stop := lib.WatchdogContext(ctx, 10*time.Millisecond, func(stack []byte) {
+			fired <- struct{}{}
+		})
+		defer stop()
+
+		select {
+		case <-fired:
+			t.Fatal("watchdog fired for a canceled operation")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
@@ -2,8 +2,11 @@ package logfx
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"reflect"
+
+	"github.com/eser/ajan/lib"
 )
 
 // ConnectionRegistry interface to avoid import cycle with connfx.
@@ -34,6 +37,11 @@ func (b *OTLPBridge) SendLog(ctx context.Context, connectionName string, rec slo
 		return nil // No connection found, skip OTLP sending
 	}
 
+	// A canceled context fails the send, as it would fail the export
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w (connection=%q): %w", ErrFailedToHandleLog, connectionName, err)
+	}
+
 	// Use reflection to call methods on the OTLP connection
 	// This avoids import cycles while still allowing integration
 	return b.sendLogViaReflection(conn)
@@ -49,7 +57,7 @@ func (b *OTLPBridge) sendLogViaReflection(conn any) error {
 
 	getLoggerProviderMethod := connValue.MethodByName("GetLoggerProvider")
 	if !getLoggerProviderMethod.IsValid() {
-		return nil // Method not found, skip
+		return fmt.Errorf("%w (protocol=%q)", ErrConnectionNotOTLP, lib.ConnectionProtocol(conn))
 	}
 
 	// Call GetLoggerProvider
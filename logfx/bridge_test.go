@@ -0,0 +1,43 @@
+package logfx_test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSQLConnection stands in for a non-OTLP connfx connection.
+type stubSQLConnection struct{}
+
+func (c *stubSQLConnection) GetProtocol() string {
+	return "sqlite"
+}
+
+type stubRegistry struct {
+	connections map[string]any
+}
+
+func (r *stubRegistry) GetNamed(name string) any {
+	return r.connections[name]
+}
+
+func TestOTLPBridge_SendLog_NotOTLPConnection(t *testing.T) {
+	t.Parallel()
+
+	bridge := logfx.NewOTLPBridge(&stubRegistry{
+		connections: map[string]any{"otel": &stubSQLConnection{}},
+	})
+
+	rec := slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)
+
+	err := bridge.SendLog(t.Context(), "otel", rec)
+	require.ErrorIs(t, err, logfx.ErrConnectionNotOTLP)
+	assert.Contains(t, err.Error(), `protocol="sqlite"`)
+
+	// Missing connections are still skipped silently
+	require.NoError(t, bridge.SendLog(t.Context(), "missing", rec))
+}
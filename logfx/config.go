@@ -1,12 +1,23 @@
 package logfx
 
+import "time"
+
 type Config struct {
 	Level string `conf:"level" default:"INFO"`
 
+	// SeverityScheme renders the level as slog names, syslog numbers or otel names
+	SeverityScheme SeverityScheme `conf:"severity_scheme" default:"slog"`
+
 	// Connection name for OTLP export (uses connfx registry)
 	OTLPConnectionName string `conf:"otlp_connection_name" default:""`
 
 	DefaultLogger bool `conf:"default"    default:"false"`
 	PrettyMode    bool `conf:"pretty"     default:"true"`
 	AddSource     bool `conf:"add_source" default:"false"`
+
+	// SinkErrorInterval limits how often a failing sink is reported to stderr.
+	SinkErrorInterval time.Duration `conf:"sink_error_interval" default:"1m"`
+
+	// Shipper bounds the records held for OTLP export and what happens on overflow.
+	Shipper ShipperConfig `conf:"shipper"`
 }
@@ -0,0 +1,70 @@
+package logfx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextExtractor reads a single attribute, such as a tenant or user ID, from a context.
+// It returns false as its last result when the context carries no value for it.
+type ContextExtractor func(ctx context.Context) (string, any, bool)
+
+//nolint:gochecknoglobals
+var (
+	contextExtractorsMu sync.RWMutex
+	contextExtractors   = []ContextExtractor{
+		CorrelationIDExtractor,
+		TraceIDExtractor,
+		SpanIDExtractor,
+	}
+)
+
+// RegisterContextExtractor adds an extractor that enriches every log record handled
+// afterwards, both on stdout and on OTLP export. Extractors run in registration order,
+// after the built-in correlation, trace and span ID extractors.
+func RegisterContextExtractor(extractor ContextExtractor) {
+	contextExtractorsMu.Lock()
+	defer contextExtractorsMu.Unlock()
+
+	contextExtractors = append(contextExtractors, extractor)
+}
+
+// CorrelationIDExtractor extracts the correlation ID set by the httpfx middleware.
+func CorrelationIDExtractor(ctx context.Context) (string, any, bool) {
+	correlationID := getCorrelationIDFromContext(ctx)
+
+	return "correlation_id", correlationID, correlationID != ""
+}
+
+// TraceIDExtractor extracts the trace ID of the active span.
+func TraceIDExtractor(ctx context.Context) (string, any, bool) {
+	spanCtx := trace.SpanContextFromContext(ctx)
+
+	return "trace_id", spanCtx.TraceID().String(), spanCtx.HasTraceID()
+}
+
+// SpanIDExtractor extracts the span ID of the active span.
+func SpanIDExtractor(ctx context.Context) (string, any, bool) {
+	spanCtx := trace.SpanContextFromContext(ctx)
+
+	return "span_id", spanCtx.SpanID().String(), spanCtx.HasSpanID()
+}
+
+// contextAttrs runs the registered extractors against ctx.
+func contextAttrs(ctx context.Context) []slog.Attr {
+	contextExtractorsMu.RLock()
+	defer contextExtractorsMu.RUnlock()
+
+	attrs := make([]slog.Attr, 0, len(contextExtractors))
+
+	for _, extractor := range contextExtractors {
+		if key, value, ok := extractor(ctx); ok {
+			attrs = append(attrs, slog.Any(key, value))
+		}
+	}
+
+	return attrs
+}
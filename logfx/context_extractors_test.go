@@ -0,0 +1,85 @@
+package logfx_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tenantContextKey struct{}
+
+func TestHandler_Handle_ContextExtractors(t *testing.T) {
+	t.Parallel()
+
+	logfx.RegisterContextExtractor(func(ctx context.Context) (string, any, bool) {
+		tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+
+		return "tenant_id", tenantID, ok
+	})
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{ //nolint:exhaustruct
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa},
+	})
+
+	tests := []struct {
+		ctx         context.Context //nolint:containedctx
+		name        string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name: "all_values_present",
+			ctx: trace.ContextWithSpanContext(
+				context.WithValue(
+					context.WithValue(t.Context(), tenantContextKey{}, "acme"),
+					logfx.CorrelationIDContextKey{},
+					"abc-123",
+				),
+				spanCtx,
+			),
+			contains: []string{
+				`"tenant_id":"acme"`,
+				`"correlation_id":"abc-123"`,
+				`"trace_id":"` + spanCtx.TraceID().String() + `"`,
+				`"span_id":"` + spanCtx.SpanID().String() + `"`,
+			},
+			notContains: nil,
+		},
+		{
+			name:        "no_values_present",
+			ctx:         t.Context(),
+			contains:    nil,
+			notContains: []string{"tenant_id", "correlation_id", "trace_id", "span_id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			writer := &bytes.Buffer{}
+			handler := logfx.NewHandler(writer, &logfx.Config{ //nolint:exhaustruct
+				Level: "info",
+			}, nil)
+
+			err := handler.Handle(tt.ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "test", 0))
+			require.NoError(t, err)
+
+			for _, expected := range tt.contains {
+				assert.Contains(t, writer.String(), expected)
+			}
+
+			for _, unexpected := range tt.notContains {
+				assert.NotContains(t, writer.String(), unexpected)
+			}
+		})
+	}
+}
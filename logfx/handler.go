@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"strings"
 )
 
 var (
 	ErrFailedToParseLogLevel = errors.New("failed to parse log level")
+	ErrInvalidSeverityScheme = errors.New("invalid severity scheme")
 	ErrFailedToWriteLog      = errors.New("failed to write log")
 	ErrFailedToHandleLog     = errors.New("failed to handle log")
 	ErrConnectionNotFound    = errors.New("connection not found")
@@ -27,6 +29,13 @@ type Handler struct {
 
 	// OTLP bridge for sending logs
 	OTLPBridge *OTLPBridge
+
+	// ErrorWriter receives rate-limited reports of failing sinks. Defaults to stderr.
+	ErrorWriter io.Writer
+
+	level   *slog.LevelVar
+	sinks   *sinkTracker
+	shipper *Shipper
 }
 
 func NewHandler(w io.Writer, config *Config, registry ConnectionRegistry) *Handler {
@@ -43,9 +52,25 @@ func NewHandler(w io.Writer, config *Config, registry ConnectionRegistry) *Handl
 		level = &l
 	}
 
+	scheme, err := ParseSeverityScheme(string(config.SeverityScheme))
+	if err != nil {
+		initError = errors.Join(initError, fmt.Errorf(
+			"%w (severity_scheme=%q): %w",
+			ErrInvalidSeverityScheme,
+			config.SeverityScheme,
+			err,
+		))
+
+		scheme = SeveritySchemeSlog
+	}
+
+	// A LevelVar lets SetLevel change the level of this handler and those derived from it
+	levelVar := &slog.LevelVar{}
+	levelVar.Set(*level)
+
 	opts := &slog.HandlerOptions{
-		Level:       level,
-		ReplaceAttr: ReplacerGenerator(config.PrettyMode),
+		Level:       levelVar,
+		ReplaceAttr: ReplacerGeneratorWithScheme(config.PrettyMode, scheme),
 		AddSource:   config.AddSource,
 	}
 
@@ -57,7 +82,7 @@ func NewHandler(w io.Writer, config *Config, registry ConnectionRegistry) *Handl
 		otlpBridge = NewOTLPBridge(registry)
 	}
 
-	return &Handler{
+	handler := &Handler{
 		InitError: initError,
 
 		InnerHandler: innerHandler,
@@ -65,24 +90,55 @@ func NewHandler(w io.Writer, config *Config, registry ConnectionRegistry) *Handl
 		InnerConfig:  config,
 
 		OTLPBridge: otlpBridge,
+
+		ErrorWriter: os.Stderr,
+
+		level:   levelVar,
+		sinks:   newSinkTracker(config.SinkErrorInterval),
+		shipper: nil,
+	}
+
+	if config.OTLPConnectionName != "" && otlpBridge != nil {
+		handler.shipper = NewShipper(SinkOTLP, config.Shipper, handler.shipToOTLP)
 	}
+
+	return handler
+}
+
+// Level returns the minimum level of the records the handler writes.
+func (h *Handler) Level() slog.Level {
+	return h.level.Level()
+}
+
+// SetLevel changes the minimum level at runtime, for this handler and every handler
+// derived from it with WithAttrs or WithGroup. It is safe for concurrent use.
+func (h *Handler) SetLevel(level slog.Level) {
+	h.level.Set(level)
 }
 
 func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
 	return h.InnerHandler.Enabled(ctx, level)
 }
 
+// Handle dispatches the record to the asynchronous sinks exactly once, then writes it
+// to the local writer. A local write failure does not affect the other sinks; it is
+// returned and reported to ErrorWriter at most once per Config.SinkErrorInterval.
 func (h *Handler) Handle(ctx context.Context, rec slog.Record) error {
-	// Add correlation ID from context to the record if available
-	if correlationID := getCorrelationIDFromContext(ctx); correlationID != "" {
-		rec.AddAttrs(slog.String("correlation_id", correlationID))
-	}
+	// Enrich the record with attributes from the context, e.g. correlation and trace IDs
+	rec.AddAttrs(contextAttrs(ctx)...)
 
 	// Send to OTLP collector if configured
-	if h.InnerConfig.OTLPConnectionName != "" && h.OTLPBridge != nil {
-		h.sendToOTLP(ctx, rec)
+	if h.shipper != nil {
+		h.shipper.Enqueue(ctx, rec)
 	}
 
+	err := h.writeLocal(ctx, rec)
+	h.recordSinkOutcome(SinkLocal, err)
+
+	return err
+}
+
+func (h *Handler) writeLocal(ctx context.Context, rec slog.Record) error {
 	if h.InnerConfig.PrettyMode {
 		out := strings.Builder{}
 
@@ -121,6 +177,12 @@ func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 		InnerConfig: h.InnerConfig,
 
 		OTLPBridge: h.OTLPBridge,
+
+		ErrorWriter: h.ErrorWriter,
+
+		level:   h.level,
+		sinks:   h.sinks,
+		shipper: h.shipper,
 	}
 }
 
@@ -134,22 +196,31 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 		InnerConfig: h.InnerConfig,
 
 		OTLPBridge: h.OTLPBridge,
+
+		ErrorWriter: h.ErrorWriter,
+
+		level:   h.level,
+		sinks:   h.sinks,
+		shipper: h.shipper,
 	}
 }
 
-// Shutdown gracefully shuts down any active export clients.
+// Shutdown ships the records still queued for OTLP export, waiting until ctx is done.
+// The connection registry handles shutdown of the connections themselves.
 func (h *Handler) Shutdown(ctx context.Context) error {
-	// The connection registry handles shutdown of connections
-	// No need to shutdown OTLP client directly
-	return nil
+	if h.shipper == nil {
+		return nil
+	}
+
+	return h.shipper.Close(ctx)
 }
 
-// sendToOTLP sends a log record to the OTLP connection asynchronously.
-func (h *Handler) sendToOTLP(ctx context.Context, rec slog.Record) {
-	go func() {
-		if err := h.OTLPBridge.SendLog(ctx, h.InnerConfig.OTLPConnectionName, rec); err != nil {
-			// Use slog for error logging to avoid infinite recursion
-			slog.Error("Failed to send log to OTLP collector", "error", err)
-		}
-	}()
+// shipToOTLP sends a queued record to the OTLP connection. Records are queued detached
+// from the caller's cancellation, so records logged at the end of a request still ship;
+// context values such as trace and correlation IDs are kept.
+func (h *Handler) shipToOTLP(ctx context.Context, rec slog.Record) error {
+	err := h.OTLPBridge.SendLog(ctx, h.InnerConfig.OTLPConnectionName, rec)
+	h.recordSinkOutcome(SinkOTLP, err)
+
+	return err
 }
@@ -2,8 +2,11 @@ package logfx_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"log/slog"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,6 +21,24 @@ func (m *mockFailWriter) Write(p []byte) (int, error) {
 	return 0, errors.New("failed to write") //nolint:err113
 }
 
+// stubOTLPConnection stands in for a connfx OTLP connection without a logger provider.
+type stubOTLPConnection struct{}
+
+func (c *stubOTLPConnection) GetLoggerProvider() any {
+	return nil
+}
+
+// countingRegistry counts the records handed to the OTLP sink.
+type countingRegistry struct {
+	lookups atomic.Int64
+}
+
+func (r *countingRegistry) GetNamed(name string) any {
+	r.lookups.Add(1)
+
+	return &stubOTLPConnection{}
+}
+
 func TestNewHandler(t *testing.T) {
 	t.Parallel()
 
@@ -51,6 +72,19 @@ func TestNewHandler(t *testing.T) {
 			},
 			expectedErr: logfx.ErrFailedToParseLogLevel,
 		},
+		{
+			name:   "InvalidSeverityScheme",
+			writer: &bytes.Buffer{},
+			config: &logfx.Config{
+				Level:              "INFO",
+				SeverityScheme:     "gelf",
+				PrettyMode:         true,
+				AddSource:          false,
+				DefaultLogger:      false,
+				OTLPConnectionName: "", // No connection for testing
+			},
+			expectedErr: logfx.ErrInvalidSeverityScheme,
+		},
 	}
 
 	for _, tt := range tests {
@@ -180,3 +214,157 @@ func TestHandler_WithGroup(t *testing.T) {
 	newHandler := handler.WithGroup("test")
 	assert.NotEqual(t, handler, newHandler)
 }
+
+func TestHandler_Handle_CanceledContext(t *testing.T) {
+	t.Parallel()
+
+	registry := &countingRegistry{}
+	handler := logfx.NewHandler(&bytes.Buffer{}, &logfx.Config{ //nolint:exhaustruct
+		Level:              "info",
+		PrettyMode:         false,
+		OTLPConnectionName: "otel",
+	}, registry)
+
+	// The request ends before the shipping goroutine runs
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	// Sending on the canceled context itself fails...
+	err := logfx.NewOTLPBridge(registry).SendLog(ctx, "otel", slog.Record{})
+	require.ErrorIs(t, err, context.Canceled)
+
+	// ...but records logged with it still ship
+	slog.New(handler).InfoContext(ctx, "request finished")
+
+	require.Eventually(t, func() bool {
+		return handler.SinkOutcomes()[logfx.SinkOTLP].Delivered == 1
+	}, time.Second, 5*time.Millisecond)
+
+	otlp := handler.SinkOutcomes()[logfx.SinkOTLP]
+	assert.Zero(t, otlp.Failed)
+	assert.NoError(t, otlp.LastError)
+}
+
+func TestHandler_Handle_FailingLocalWriter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("async sinks still receive records", func(t *testing.T) {
+		t.Parallel()
+
+		registry := &countingRegistry{}
+		handler := logfx.NewHandler(&mockFailWriter{}, &logfx.Config{ //nolint:exhaustruct
+			Level:              "info",
+			PrettyMode:         true,
+			OTLPConnectionName: "otel",
+		}, registry)
+
+		var errorOutput bytes.Buffer
+
+		handler.ErrorWriter = &errorOutput
+
+		for range 5 {
+			rec := slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)
+
+			err := handler.Handle(t.Context(), rec)
+			require.ErrorIs(t, err, logfx.ErrFailedToWriteLog)
+		}
+
+		require.Eventually(t, func() bool {
+			return handler.SinkOutcomes()[logfx.SinkOTLP].Delivered == 5
+		}, time.Second, 5*time.Millisecond)
+
+		assert.Equal(t, int64(5), registry.lookups.Load(), "each record is exported once")
+
+		local := handler.SinkOutcomes()[logfx.SinkLocal]
+		assert.Equal(t, uint64(5), local.Failed)
+		assert.Zero(t, local.Delivered)
+		require.ErrorIs(t, local.LastError, logfx.ErrFailedToWriteLog)
+
+		assert.Equal(t, 1, strings.Count(errorOutput.String(), "logfx: local sink failed"))
+	})
+
+	t.Run("reports are rate limited", func(t *testing.T) {
+		t.Parallel()
+
+		handler := logfx.NewHandler(&mockFailWriter{}, &logfx.Config{ //nolint:exhaustruct
+			Level:             "info",
+			SinkErrorInterval: 50 * time.Millisecond,
+		}, nil)
+
+		var errorOutput bytes.Buffer
+
+		handler.ErrorWriter = &errorOutput
+
+		handle := func() {
+			rec := slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)
+			_ = handler.Handle(t.Context(), rec)
+		}
+
+		for range 3 {
+			handle()
+		}
+
+		assert.Equal(t, 1, strings.Count(errorOutput.String(), "\n"))
+
+		time.Sleep(60 * time.Millisecond)
+		handle()
+
+		lines := strings.Split(strings.TrimSpace(errorOutput.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], "logfx: local sink failed: failed to handle log")
+		assert.Contains(t, lines[1], "(2 similar failures suppressed)")
+
+		// Derived handlers share the rate limit and outcomes
+		derived, ok := handler.WithGroup("group").(*logfx.Handler)
+		require.True(t, ok)
+
+		rec := slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)
+		_ = derived.Handle(t.Context(), rec)
+
+		assert.Equal(t, 2, strings.Count(errorOutput.String(), "\n"))
+		assert.Equal(t, uint64(5), handler.SinkOutcomes()[logfx.SinkLocal].Failed)
+	})
+}
+
+// gatedRegistry holds OTLP sends until release is closed.
+type gatedRegistry struct {
+	release chan struct{}
+}
+
+func (r *gatedRegistry) GetNamed(name string) any {
+	<-r.release
+
+	return &stubOTLPConnection{}
+}
+
+func TestHandler_Handle_ShipperOverflow(t *testing.T) {
+	t.Parallel()
+
+	registry := &gatedRegistry{release: make(chan struct{})}
+	handler := logfx.NewHandler(&bytes.Buffer{}, &logfx.Config{ //nolint:exhaustruct
+		Level:              "info",
+		PrettyMode:         false,
+		OTLPConnectionName: "otel",
+		Shipper: logfx.ShipperConfig{
+			OverflowPolicy: logfx.OverflowDropNewest,
+			MaxInFlight:    2,
+			BlockTimeout:   time.Millisecond,
+		},
+	}, registry)
+
+	logger := slog.New(handler)
+
+	for range 10 {
+		logger.InfoContext(t.Context(), "flood")
+	}
+
+	// Only MaxInFlight records are held while the export is stuck
+	assert.Equal(t, uint64(8), handler.SinkOutcomes()[logfx.SinkOTLP].Dropped)
+
+	close(registry.release)
+	require.NoError(t, handler.Shutdown(t.Context()))
+
+	otlp := handler.SinkOutcomes()[logfx.SinkOTLP]
+	assert.Equal(t, uint64(2), otlp.Delivered)
+	assert.Equal(t, uint64(8), otlp.Dropped)
+}
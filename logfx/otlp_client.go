@@ -14,7 +14,6 @@ import (
 	sdklog "go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
-	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -28,10 +27,22 @@ var (
 type OTLPClient struct {
 	loggerProvider *sdklog.LoggerProvider
 	logger         log.Logger
+	shipper        *Shipper
 }
 
-// NewOTLPClient creates a new OTLP client for sending logs to OpenTelemetry collector.
+// NewOTLPClient creates a new OTLP client for sending logs to OpenTelemetry collector,
+// using DefaultShipperConfig.
 func NewOTLPClient(endpoint string, insecure bool) (*OTLPClient, error) {
+	return NewOTLPClientWithShipper(endpoint, insecure, DefaultShipperConfig())
+}
+
+// NewOTLPClientWithShipper creates a new OTLP client whose pending records are bounded
+// by the shipper configuration.
+func NewOTLPClientWithShipper(
+	endpoint string,
+	insecure bool,
+	shipperConfig ShipperConfig,
+) (*OTLPClient, error) {
 	if endpoint == "" {
 		return nil, fmt.Errorf("%w (endpoint is empty)", ErrOTLPNotConfigured)
 	}
@@ -80,24 +91,35 @@ func NewOTLPClient(endpoint string, insecure bool) (*OTLPClient, error) {
 	// Get logger
 	logger := loggerProvider.Logger("logfx")
 
-	return &OTLPClient{
+	client := &OTLPClient{
 		loggerProvider: loggerProvider,
 		logger:         logger,
-	}, nil
+		shipper:        nil,
+	}
+
+	client.shipper = NewShipper(SinkOTLP, shipperConfig, client.shipLog)
+
+	return client, nil
 }
 
-// SendLog sends a log record to OpenTelemetry collector asynchronously.
+// SendLog sends a log record to OpenTelemetry collector asynchronously, detached from
+// the cancellation of ctx. When the shipper is full the record may be dropped; see
+// ShipperConfig.
 func (c *OTLPClient) SendLog(ctx context.Context, rec slog.Record) {
-	go func() {
-		if err := c.sendLogSync(ctx, rec); err != nil {
-			// Use slog for error logging to avoid infinite recursion
-			slog.Error("Failed to send log to OTLP collector", "error", err)
-		}
-	}()
+	c.shipper.Enqueue(ctx, rec)
 }
 
-// Shutdown gracefully shuts down the OTLP client.
+// Dropped returns how many records were dropped because the shipper was full.
+func (c *OTLPClient) Dropped() uint64 {
+	return c.shipper.Dropped()
+}
+
+// Shutdown ships the queued records, then gracefully shuts down the OTLP client.
 func (c *OTLPClient) Shutdown(ctx context.Context) error {
+	if err := c.shipper.Close(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToShutdownOTLP, err)
+	}
+
 	if c.loggerProvider != nil {
 		if err := c.loggerProvider.Shutdown(ctx); err != nil {
 			return fmt.Errorf("%w: %w", ErrFailedToShutdownOTLP, err)
@@ -107,6 +129,16 @@ func (c *OTLPClient) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+func (c *OTLPClient) shipLog(ctx context.Context, rec slog.Record) error {
+	err := c.sendLogSync(ctx, rec)
+	if err != nil {
+		// Use slog for error logging to avoid infinite recursion
+		slog.Error("Failed to send log to OTLP collector", "error", err)
+	}
+
+	return err
+}
+
 // sendLogSync sends a log record to OpenTelemetry collector synchronously.
 // Note: Always returns nil since OpenTelemetry's Emit method doesn't return errors.
 func (c *OTLPClient) sendLogSync(ctx context.Context, rec slog.Record) error { //nolint:unparam
@@ -116,18 +148,12 @@ func (c *OTLPClient) sendLogSync(ctx context.Context, rec slog.Record) error { /
 	// Build attributes
 	var attrs []log.KeyValue
 
-	// Add OpenTelemetry trace context if available
-	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
-		spanCtx := span.SpanContext()
-		attrs = append(attrs,
-			log.String("trace_id", spanCtx.TraceID().String()),
-			log.String("span_id", spanCtx.SpanID().String()),
-		)
-	}
-
-	// Add HTTP correlation ID if available (for HTTP request correlation)
-	if correlationID := getCorrelationIDFromContext(ctx); correlationID != "" {
-		attrs = append(attrs, log.String("correlation_id", correlationID))
+	// Add attributes from the context, e.g. correlation and trace IDs
+	for _, attr := range contextAttrs(ctx) {
+		otlpAttr := convertSlogAttribute(attr)
+		if otlpAttr != nil {
+			attrs = append(attrs, *otlpAttr)
+		}
 	}
 
 	// Add attributes from the record
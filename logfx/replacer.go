@@ -16,6 +16,14 @@ type (
 )
 
 func ReplacerGenerator(prettyMode bool) func([]string, slog.Attr) slog.Attr {
+	return ReplacerGeneratorWithScheme(prettyMode, SeveritySchemeSlog)
+}
+
+// ReplacerGeneratorWithScheme is ReplacerGenerator rendering levels in the given scheme.
+func ReplacerGeneratorWithScheme(
+	prettyMode bool,
+	scheme SeverityScheme,
+) func([]string, slog.Attr) slog.Attr {
 	return func(groups []string, attr slog.Attr) slog.Attr {
 		if prettyMode {
 			if attr.Key == slog.TimeKey || attr.Key == slog.LevelKey ||
@@ -30,9 +38,7 @@ func ReplacerGenerator(prettyMode bool) func([]string, slog.Attr) slog.Attr {
 		if attr.Key == slog.LevelKey {
 			level, levelOk := attr.Value.Any().(slog.Level)
 			if levelOk {
-				value := LevelEncoder(level)
-
-				attr.Value = slog.StringValue(value)
+				attr.Value = scheme.Encode(level)
 			}
 		}
 
@@ -0,0 +1,119 @@
+package logfx
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+var ErrUnknownSeverityScheme = errors.New("unknown severity scheme")
+
+// SeverityScheme selects how the level of a record is rendered in structured output.
+type SeverityScheme string
+
+const (
+	// SeveritySchemeSlog renders level names such as "INFO" or "WARN+1" (default).
+	SeveritySchemeSlog SeverityScheme = "slog"
+	// SeveritySchemeSyslog renders RFC 5424 severity numbers (0 emergency .. 7 debug).
+	SeveritySchemeSyslog SeverityScheme = "syslog"
+	// SeveritySchemeOtel renders OpenTelemetry short severity names such as "INFO2".
+	SeveritySchemeOtel SeverityScheme = "otel"
+)
+
+// RFC 5424 severities the logfx levels map onto.
+const (
+	syslogAlert    = 1
+	syslogCritical = 2
+	syslogError    = 3
+	syslogWarning  = 4
+	syslogInfo     = 6
+	syslogDebug    = 7
+)
+
+// OpenTelemetry severity numbers span 1..24, four per short name.
+const (
+	otelSeverityMin      = 1
+	otelSeverityMax      = 24
+	otelSeverityPerGroup = 4
+)
+
+var otelSeverityNames = [...]string{ //nolint:gochecknoglobals
+	"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL",
+}
+
+// ParseSeverityScheme parses a scheme name case-insensitively. An empty name selects
+// SeveritySchemeSlog.
+func ParseSeverityScheme(s string) (SeverityScheme, error) {
+	switch scheme := SeverityScheme(strings.ToLower(s)); scheme {
+	case "":
+		return SeveritySchemeSlog, nil
+	case SeveritySchemeSlog, SeveritySchemeSyslog, SeveritySchemeOtel:
+		return scheme, nil
+	default:
+		return "", fmt.Errorf("%w (s=%q)", ErrUnknownSeverityScheme, s)
+	}
+}
+
+// UnmarshalText validates the scheme when it is loaded from configuration.
+func (s *SeverityScheme) UnmarshalText(text []byte) error {
+	scheme, err := ParseSeverityScheme(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = scheme
+
+	return nil
+}
+
+// Encode renders the level in the scheme: a string for slog and otel, an integer for
+// syslog. Unknown schemes fall back to slog.
+func (s SeverityScheme) Encode(level slog.Level) slog.Value {
+	switch s { //nolint:exhaustive
+	case SeveritySchemeSyslog:
+		return slog.IntValue(SyslogSeverity(level))
+	case SeveritySchemeOtel:
+		return slog.StringValue(OtelSeverityText(level))
+	default:
+		return slog.StringValue(LevelEncoder(level))
+	}
+}
+
+// SyslogSeverity maps a level to its RFC 5424 severity. TRACE shares debug (7) with
+// DEBUG, FATAL is critical (2) and PANIC is alert (1).
+func SyslogSeverity(level slog.Level) int {
+	switch {
+	case level < LevelInfo:
+		return syslogDebug
+	case level < LevelWarn:
+		return syslogInfo
+	case level < LevelError:
+		return syslogWarning
+	case level < LevelFatal:
+		return syslogError
+	case level < LevelPanic:
+		return syslogCritical
+	default:
+		return syslogAlert
+	}
+}
+
+// OtelSeverityNumber maps a level to its OpenTelemetry severity number (level + 9),
+// clamped to 1..24, so PANIC lands on FATAL4.
+func OtelSeverityNumber(level slog.Level) int {
+	return min(max(int(level)+9, otelSeverityMin), otelSeverityMax) //nolint:mnd
+}
+
+// OtelSeverityText returns the OpenTelemetry short name of a level, e.g. "INFO" for
+// LevelInfo and "WARN2" for LevelWarn+1.
+func OtelSeverityText(level slog.Level) string {
+	offset := OtelSeverityNumber(level) - otelSeverityMin
+	name := otelSeverityNames[offset/otelSeverityPerGroup]
+
+	if step := offset % otelSeverityPerGroup; step > 0 {
+		return fmt.Sprintf("%s%d", name, step+1)
+	}
+
+	return name
+}
@@ -0,0 +1,126 @@
+package logfx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeverityScheme_Encode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		level  slog.Level
+		slog   string
+		otel   string
+		syslog int64
+	}{
+		{name: "trace", level: logfx.LevelTrace, slog: "TRACE", syslog: 7, otel: "TRACE"},
+		{name: "debug", level: logfx.LevelDebug, slog: "DEBUG", syslog: 7, otel: "DEBUG"},
+		{name: "info", level: logfx.LevelInfo, slog: "INFO", syslog: 6, otel: "INFO"},
+		{name: "warn", level: logfx.LevelWarn, slog: "WARN", syslog: 4, otel: "WARN"},
+		{name: "warn+1", level: logfx.LevelWarn + 1, slog: "WARN+1", syslog: 4, otel: "WARN2"},
+		{name: "error", level: logfx.LevelError, slog: "ERROR", syslog: 3, otel: "ERROR"},
+		{name: "fatal", level: logfx.LevelFatal, slog: "FATAL", syslog: 2, otel: "FATAL"},
+		{name: "panic", level: logfx.LevelPanic, slog: "PANIC", syslog: 1, otel: "FATAL4"},
+		{name: "trace-4", level: logfx.LevelTrace - 4, slog: "TRACE-4", syslog: 7, otel: "TRACE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.slog, logfx.SeveritySchemeSlog.Encode(tt.level).String())
+			assert.Equal(t, tt.syslog, logfx.SeveritySchemeSyslog.Encode(tt.level).Int64())
+			assert.Equal(t, tt.otel, logfx.SeveritySchemeOtel.Encode(tt.level).String())
+		})
+	}
+}
+
+func TestHandler_SeverityScheme(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scheme   logfx.SeverityScheme
+		expected []any
+	}{
+		{
+			scheme:   logfx.SeveritySchemeSlog,
+			expected: []any{"TRACE", "INFO", "WARN", "FATAL", "PANIC"},
+		},
+		{
+			scheme:   logfx.SeveritySchemeSyslog,
+			expected: []any{float64(7), float64(6), float64(4), float64(2), float64(1)},
+		},
+		{
+			scheme:   logfx.SeveritySchemeOtel,
+			expected: []any{"TRACE", "INFO", "WARN", "FATAL", "FATAL4"},
+		},
+		{
+			scheme:   "",
+			expected: []any{"TRACE", "INFO", "WARN", "FATAL", "PANIC"},
+		},
+	}
+
+	levels := []slog.Level{
+		logfx.LevelTrace,
+		logfx.LevelInfo,
+		logfx.LevelWarn,
+		logfx.LevelFatal,
+		logfx.LevelPanic,
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.scheme), func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			handler := logfx.NewHandler(&buf, &logfx.Config{ //nolint:exhaustruct
+				Level:          "TRACE",
+				SeverityScheme: tt.scheme,
+				PrettyMode:     false,
+			}, nil)
+			require.NoError(t, handler.InitError)
+
+			logger := slog.New(handler)
+
+			for _, level := range levels {
+				logger.Log(context.Background(), level, "message")
+			}
+
+			decoder := json.NewDecoder(&buf)
+			rendered := make([]any, 0, len(levels))
+
+			for decoder.More() {
+				var record map[string]any
+
+				require.NoError(t, decoder.Decode(&record))
+
+				rendered = append(rendered, record[slog.LevelKey])
+			}
+
+			assert.Equal(t, tt.expected, rendered)
+		})
+	}
+}
+
+func TestSeverityScheme_UnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	var scheme logfx.SeverityScheme
+
+	require.NoError(t, scheme.UnmarshalText([]byte("Syslog")))
+	assert.Equal(t, logfx.SeveritySchemeSyslog, scheme)
+
+	err := scheme.UnmarshalText([]byte("gelf"))
+	require.ErrorIs(t, err, logfx.ErrUnknownSeverityScheme)
+	assert.Equal(t, logfx.SeveritySchemeSyslog, scheme)
+}
@@ -0,0 +1,289 @@
+package logfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eser/ajan/lib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	ErrUnknownOverflowPolicy = errors.New("unknown overflow policy")
+	ErrFailedToCloseShipper  = errors.New("failed to close shipper")
+)
+
+const (
+	DefaultShipperMaxInFlight   = 1024
+	DefaultShipperBlockTimeout  = 100 * time.Millisecond
+	droppedLogsCounterName      = "logfx_dropped_logs_total"
+	droppedLogsCounterUnit      = "{record}"
+	shipperInstrumentationScope = "github.com/eser/ajan/logfx"
+)
+
+// OverflowPolicy decides which record a shipper gives up on when MaxInFlight records are
+// already queued or being sent.
+type OverflowPolicy string
+
+const (
+	// OverflowDropNewest drops the incoming record (default).
+	OverflowDropNewest OverflowPolicy = "drop-newest"
+	// OverflowDropOldest drops the oldest queued record to make room for the incoming one.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowBlockWithTimeout waits up to BlockTimeout for room, then drops the incoming
+	// record.
+	OverflowBlockWithTimeout OverflowPolicy = "block-with-timeout"
+)
+
+// ParseOverflowPolicy parses a policy name case-insensitively. An empty name selects
+// OverflowDropNewest.
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(strings.ToLower(s)); policy {
+	case "":
+		return OverflowDropNewest, nil
+	case OverflowDropNewest, OverflowDropOldest, OverflowBlockWithTimeout:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w (s=%q)", ErrUnknownOverflowPolicy, s)
+	}
+}
+
+// UnmarshalText validates the policy when it is loaded from configuration.
+func (p *OverflowPolicy) UnmarshalText(text []byte) error {
+	policy, err := ParseOverflowPolicy(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = policy
+
+	return nil
+}
+
+// ShipperConfig bounds the records a log shipper holds in memory.
+type ShipperConfig struct {
+	// OverflowPolicy is "drop-newest", "drop-oldest" or "block-with-timeout"
+	OverflowPolicy OverflowPolicy `conf:"overflow_policy" default:"drop-newest"`
+
+	// MaxInFlight caps the records queued or being sent at once
+	MaxInFlight int `conf:"max_in_flight" default:"1024"`
+
+	// BlockTimeout is how long "block-with-timeout" waits for room before dropping
+	BlockTimeout time.Duration `conf:"block_timeout" default:"100ms"`
+}
+
+// DefaultShipperConfig returns the configuration used when none is given.
+func DefaultShipperConfig() ShipperConfig {
+	return ShipperConfig{
+		OverflowPolicy: OverflowDropNewest,
+		MaxInFlight:    DefaultShipperMaxInFlight,
+		BlockTimeout:   DefaultShipperBlockTimeout,
+	}
+}
+
+// ShipFunc delivers one record to a remote sink.
+type ShipFunc func(ctx context.Context, rec slog.Record) error
+
+type shipment struct {
+	ctx context.Context //nolint:containedctx
+	rec slog.Record
+}
+
+// Shipper delivers records to a remote sink from a single background worker, holding at
+// most MaxInFlight records at once. Records that do not fit are dropped according to the
+// overflow policy and counted, both on the shipper and in the logfx_dropped_logs_total
+// metric of the global OpenTelemetry meter provider.
+type Shipper struct {
+	ship        ShipFunc
+	dropCounter metric.Int64Counter
+	dropAttrs   metric.MeasurementOption
+
+	// slots holds one token per record queued or being sent
+	slots chan struct{}
+	queue chan shipment
+	done  chan struct{}
+
+	sink   string
+	config ShipperConfig
+
+	dropped atomic.Uint64
+
+	// mu guards closed against Enqueue sending on a closed queue
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewShipper starts a shipper that hands records to ship. The sink name labels the
+// dropped-logs metric. Invalid limits fall back to DefaultShipperConfig values.
+func NewShipper(sink string, config ShipperConfig, ship ShipFunc) *Shipper {
+	defaults := DefaultShipperConfig()
+
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = defaults.MaxInFlight
+	}
+
+	if config.BlockTimeout <= 0 {
+		config.BlockTimeout = defaults.BlockTimeout
+	}
+
+	policy, err := ParseOverflowPolicy(string(config.OverflowPolicy))
+	if err != nil {
+		policy = defaults.OverflowPolicy
+	}
+
+	config.OverflowPolicy = policy
+
+	// Without a meter provider the global one is a no-op; errors leave the counter unset
+	dropCounter, _ := otel.Meter(shipperInstrumentationScope).Int64Counter(
+		droppedLogsCounterName,
+		metric.WithDescription("Total number of log records dropped by a full shipper"),
+		metric.WithUnit(droppedLogsCounterUnit),
+	)
+
+	shipper := &Shipper{
+		ship:        ship,
+		dropCounter: dropCounter,
+		dropAttrs: metric.WithAttributes(
+			attribute.String("sink", sink),
+			attribute.String("policy", string(policy)),
+		),
+
+		slots: make(chan struct{}, config.MaxInFlight),
+		queue: make(chan shipment, config.MaxInFlight),
+		done:  make(chan struct{}),
+
+		sink:   sink,
+		config: config,
+
+		dropped: atomic.Uint64{},
+
+		mu:     sync.RWMutex{},
+		closed: false,
+	}
+
+	lib.SafeGo(shipper.run, nil)
+
+	return shipper
+}
+
+// Config returns the effective configuration, after defaults were applied.
+func (s *Shipper) Config() ShipperConfig {
+	return s.config
+}
+
+// Dropped returns how many records the shipper has dropped.
+func (s *Shipper) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Enqueue queues a record for shipping, detached from the cancellation of ctx so records
+// logged at the end of a request still ship. It reports whether the record was queued;
+// under drop-oldest a queued record may still be dropped later to make room.
+func (s *Shipper) Enqueue(ctx context.Context, rec slog.Record) bool {
+	item := shipment{ctx: context.WithoutCancel(ctx), rec: rec}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		s.drop(ctx)
+
+		return false
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		s.queue <- item
+
+		return true
+	default:
+	}
+
+	switch s.config.OverflowPolicy {
+	case OverflowDropOldest:
+		select {
+		case <-s.queue:
+			// The incoming record takes over the slot of the oldest one
+			s.drop(ctx)
+			s.queue <- item
+
+			return true
+		default:
+			// Every slot belongs to a record being sent
+		}
+	case OverflowBlockWithTimeout:
+		timer := time.NewTimer(s.config.BlockTimeout)
+		defer timer.Stop()
+
+		select {
+		case s.slots <- struct{}{}:
+			s.queue <- item
+
+			return true
+		case <-timer.C:
+		}
+	case OverflowDropNewest:
+	}
+
+	s.drop(ctx)
+
+	return false
+}
+
+// Close stops accepting records and waits until the queued ones are shipped, or until
+// ctx is done.
+func (s *Shipper) Close(ctx context.Context) error {
+	s.mu.Lock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w (sink=%q): %w", ErrFailedToCloseShipper, s.sink, ctx.Err())
+	}
+}
+
+func (s *Shipper) run() error {
+	defer close(s.done)
+
+	for item := range s.queue {
+		s.shipOne(item)
+	}
+
+	return nil
+}
+
+// shipOne sends a record and frees its slot. A panicking sink loses the record but
+// does not stop the worker.
+func (s *Shipper) shipOne(item shipment) {
+	defer func() {
+		<-s.slots
+
+		_ = recover()
+	}()
+
+	_ = s.ship(item.ctx, item.rec)
+}
+
+func (s *Shipper) drop(ctx context.Context) {
+	s.dropped.Add(1)
+
+	if s.dropCounter != nil {
+		s.dropCounter.Add(context.WithoutCancel(ctx), 1, s.dropAttrs)
+	}
+}
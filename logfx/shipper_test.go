@@ -0,0 +1,272 @@
+package logfx_test
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/logfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// gatedSink holds every record in ship until release is closed.
+type gatedSink struct {
+	started chan struct{}
+	release chan struct{}
+	shipped []string
+	once    sync.Once
+	mu      sync.Mutex
+}
+
+func newGatedSink() *gatedSink {
+	return &gatedSink{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+		shipped: nil,
+		once:    sync.Once{},
+		mu:      sync.Mutex{},
+	}
+}
+
+func (s *gatedSink) ship(ctx context.Context, rec slog.Record) error {
+	s.once.Do(func() { close(s.started) })
+
+	<-s.release
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shipped = append(s.shipped, rec.Message)
+
+	return nil
+}
+
+func (s *gatedSink) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.shipped...)
+}
+
+func record(i int) slog.Record {
+	return slog.NewRecord(time.Now(), slog.LevelInfo, strconv.Itoa(i), 0)
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected logfx.OverflowPolicy
+		wantErr  bool
+	}{
+		{input: "", expected: logfx.OverflowDropNewest},
+		{input: "drop-newest", expected: logfx.OverflowDropNewest},
+		{input: "DROP-OLDEST", expected: logfx.OverflowDropOldest},
+		{input: "block-with-timeout", expected: logfx.OverflowBlockWithTimeout},
+		{input: "drop-everything", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := logfx.ParseOverflowPolicy(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, logfx.ErrUnknownOverflowPolicy)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
+
+func TestShipper_Overflow(t *testing.T) {
+	t.Parallel()
+
+	const (
+		maxInFlight = 4
+		flood       = 100
+	)
+
+	tests := []struct {
+		name     string
+		policy   logfx.OverflowPolicy
+		expected []string
+	}{
+		{
+			name:     "drop-newest keeps the first records",
+			policy:   logfx.OverflowDropNewest,
+			expected: []string{"0", "1", "2", "3"},
+		},
+		{
+			name:   "drop-oldest keeps the record being sent and the latest ones",
+			policy: logfx.OverflowDropOldest,
+			expected: []string{
+				"0",
+				strconv.Itoa(flood - 3),
+				strconv.Itoa(flood - 2),
+				strconv.Itoa(flood - 1),
+			},
+		},
+		{
+			name:     "block-with-timeout drops after waiting",
+			policy:   logfx.OverflowBlockWithTimeout,
+			expected: []string{"0", "1", "2", "3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sink := newGatedSink()
+			shipper := logfx.NewShipper("test", logfx.ShipperConfig{
+				OverflowPolicy: tt.policy,
+				MaxInFlight:    maxInFlight,
+				BlockTimeout:   time.Millisecond,
+			}, sink.ship)
+
+			// The worker holds the first record until released
+			require.True(t, shipper.Enqueue(t.Context(), record(0)))
+			<-sink.started
+
+			for i := 1; i < flood; i++ {
+				shipper.Enqueue(t.Context(), record(i))
+			}
+
+			assert.Equal(t, uint64(flood-maxInFlight), shipper.Dropped())
+
+			close(sink.release)
+			require.NoError(t, shipper.Close(t.Context()))
+
+			assert.Equal(t, tt.expected, sink.messages())
+		})
+	}
+}
+
+func TestShipper_BlockWithTimeout_WaitsForRoom(t *testing.T) {
+	t.Parallel()
+
+	sink := newGatedSink()
+	shipper := logfx.NewShipper("test", logfx.ShipperConfig{
+		OverflowPolicy: logfx.OverflowBlockWithTimeout,
+		MaxInFlight:    1,
+		BlockTimeout:   5 * time.Second,
+	}, sink.ship)
+
+	require.True(t, shipper.Enqueue(t.Context(), record(0)))
+	<-sink.started
+
+	time.AfterFunc(20*time.Millisecond, func() { close(sink.release) })
+
+	// The second record waits until the first one is sent
+	require.True(t, shipper.Enqueue(t.Context(), record(1)))
+	require.NoError(t, shipper.Close(t.Context()))
+
+	assert.Equal(t, []string{"0", "1"}, sink.messages())
+	assert.Zero(t, shipper.Dropped())
+}
+
+func TestShipper_Close(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ships queued records and drops later ones", func(t *testing.T) {
+		t.Parallel()
+
+		sink := newGatedSink()
+		close(sink.release)
+
+		shipper := logfx.NewShipper("test", logfx.DefaultShipperConfig(), sink.ship)
+
+		for i := range 10 {
+			require.True(t, shipper.Enqueue(t.Context(), record(i)))
+		}
+
+		require.NoError(t, shipper.Close(t.Context()))
+		assert.Len(t, sink.messages(), 10)
+
+		assert.False(t, shipper.Enqueue(t.Context(), record(10)))
+		assert.Equal(t, uint64(1), shipper.Dropped())
+	})
+
+	t.Run("gives up when the context is done", func(t *testing.T) {
+		t.Parallel()
+
+		sink := newGatedSink()
+		t.Cleanup(func() { close(sink.release) })
+
+		shipper := logfx.NewShipper("test", logfx.DefaultShipperConfig(), sink.ship)
+		require.True(t, shipper.Enqueue(t.Context(), record(0)))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		err := shipper.Close(ctx)
+		require.ErrorIs(t, err, logfx.ErrFailedToCloseShipper)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestShipper_DroppedLogsMetric(t *testing.T) { //nolint:paralleltest
+	// Swaps the global meter provider, so it cannot run alongside other tests
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	sink := newGatedSink()
+	shipper := logfx.NewShipper("loki", logfx.ShipperConfig{
+		OverflowPolicy: logfx.OverflowDropNewest,
+		MaxInFlight:    1,
+		BlockTimeout:   time.Millisecond,
+	}, sink.ship)
+
+	for i := range 5 {
+		shipper.Enqueue(t.Context(), record(i))
+	}
+
+	close(sink.release)
+	require.NoError(t, shipper.Close(t.Context()))
+
+	var metrics metricdata.ResourceMetrics
+
+	require.NoError(t, reader.Collect(t.Context(), &metrics))
+
+	var dropped int64
+
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != "logfx_dropped_logs_total" {
+				continue
+			}
+
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok)
+
+			for _, point := range sum.DataPoints {
+				sinkName, _ := point.Attributes.Value(attribute.Key("sink"))
+				policy, _ := point.Attributes.Value(attribute.Key("policy"))
+
+				assert.Equal(t, "loki", sinkName.AsString())
+				assert.Equal(t, "drop-newest", policy.AsString())
+
+				dropped += point.Value
+			}
+		}
+	}
+
+	assert.Equal(t, int64(4), dropped)
+}
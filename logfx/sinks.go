@@ -0,0 +1,140 @@
+package logfx
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// SinkLocal names the handler's own writer, usually stdout.
+	SinkLocal = "local"
+	// SinkOTLP names the asynchronous OTLP export.
+	SinkOTLP = "otlp"
+
+	DefaultSinkErrorInterval = time.Minute
+)
+
+// SinkOutcome counts the records a sink delivered, failed to deliver or dropped because
+// its shipper was full.
+type SinkOutcome struct {
+	LastError error
+	Delivered uint64
+	Failed    uint64
+	Dropped   uint64
+}
+
+// sinkTracker collects per-sink outcomes and decides when a failure is reported, so a
+// broken sink produces one report per interval instead of one per record. It is shared
+// by a handler and the handlers derived from it with WithAttrs and WithGroup.
+type sinkTracker struct {
+	outcomes     map[string]*SinkOutcome
+	lastReported map[string]time.Time
+	suppressed   map[string]uint64
+	interval     time.Duration
+	mu           sync.Mutex
+}
+
+func newSinkTracker(interval time.Duration) *sinkTracker {
+	if interval <= 0 {
+		interval = DefaultSinkErrorInterval
+	}
+
+	return &sinkTracker{
+		outcomes:     make(map[string]*SinkOutcome),
+		lastReported: make(map[string]time.Time),
+		suppressed:   make(map[string]uint64),
+		interval:     interval,
+		mu:           sync.Mutex{},
+	}
+}
+
+// record stores the outcome of one delivery. For failures it returns whether the failure
+// should be reported now, along with the number of failures suppressed since the last
+// report.
+func (t *sinkTracker) record(sink string, err error) (bool, uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	outcome, ok := t.outcomes[sink]
+	if !ok {
+		outcome = &SinkOutcome{LastError: nil, Delivered: 0, Failed: 0, Dropped: 0}
+		t.outcomes[sink] = outcome
+	}
+
+	if err == nil {
+		outcome.Delivered++
+
+		return false, 0
+	}
+
+	outcome.Failed++
+	outcome.LastError = err
+
+	now := time.Now()
+
+	last, reported := t.lastReported[sink]
+	if reported && now.Sub(last) < t.interval {
+		t.suppressed[sink]++
+
+		return false, 0
+	}
+
+	suppressed := t.suppressed[sink]
+	t.lastReported[sink] = now
+	t.suppressed[sink] = 0
+
+	return true, suppressed
+}
+
+func (t *sinkTracker) snapshot() map[string]SinkOutcome {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	outcomes := make(map[string]SinkOutcome, len(t.outcomes))
+	for sink, outcome := range t.outcomes {
+		outcomes[sink] = *outcome
+	}
+
+	return outcomes
+}
+
+// SinkOutcomes returns how many records each sink delivered, failed to deliver or
+// dropped. OTLP outcomes are recorded once the asynchronous export finishes.
+func (h *Handler) SinkOutcomes() map[string]SinkOutcome {
+	if h.sinks == nil {
+		return map[string]SinkOutcome{}
+	}
+
+	outcomes := h.sinks.snapshot()
+
+	if h.shipper != nil {
+		otlp := outcomes[SinkOTLP]
+		otlp.Dropped = h.shipper.Dropped()
+		outcomes[SinkOTLP] = otlp
+	}
+
+	return outcomes
+}
+
+// recordSinkOutcome tracks the outcome of a delivery and reports failures to
+// ErrorWriter, at most once per SinkErrorInterval for each sink. Reports are written
+// directly rather than logged, since logging them could fail the same way.
+func (h *Handler) recordSinkOutcome(sink string, err error) {
+	if h.sinks == nil {
+		return
+	}
+
+	report, suppressed := h.sinks.record(sink, err)
+	if !report || h.ErrorWriter == nil {
+		return
+	}
+
+	message := fmt.Sprintf("logfx: %s sink failed: %v", sink, err)
+	if suppressed > 0 {
+		message += fmt.Sprintf(" (%d similar failures suppressed)", suppressed)
+	}
+
+	_, _ = io.WriteString(h.ErrorWriter, message+"\n")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/eser/ajan/lib"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 )
 
@@ -14,6 +15,7 @@ var (
 	ErrMetricExporterNotFound    = errors.New("metric exporter not found")
 	ErrRegistryMissingGetNamed   = errors.New("registry does not have GetNamed method")
 	ErrConnectionIsNil           = errors.New("connection is nil")
+	ErrConnectionNotOTLP         = errors.New("connection is not an OTLP connection")
 	ErrMissingGetMetricExporter  = errors.New("connection does not have GetMetricExporter method")
 	ErrNoMetricExporterAvailable = errors.New("no metric exporter available")
 	ErrInvalidMetricExporter     = errors.New("returned value is not a metric exporter")
@@ -86,7 +88,12 @@ func (b *OTLPBridge) getMetricExporterViaReflection(conn any) (sdkmetric.Exporte
 
 	getMetricExporterMethod := connValue.MethodByName("GetMetricExporter")
 	if !getMetricExporterMethod.IsValid() {
-		return nil, fmt.Errorf("%w", ErrMissingGetMetricExporter)
+		return nil, fmt.Errorf(
+			"%w (protocol=%q): %w",
+			ErrConnectionNotOTLP,
+			lib.ConnectionProtocol(conn),
+			ErrMissingGetMetricExporter,
+		)
 	}
 
 	// Call GetMetricExporter
@@ -82,22 +82,58 @@ type MetricsBuilder struct {
 	gauges     map[string]metric.Int64Gauge
 	histograms map[string]metric.Float64Histogram
 	name       string
+	version    string
 }
 
-// NewMetricsBuilder creates a new metrics builder with the given meter and name prefix.
-func NewMetricsBuilder(provider *MetricsProvider) *MetricsBuilder {
-	meter := provider.meterProvider.Meter(
-		provider.config.ServiceName,
-		metric.WithInstrumentationVersion(provider.config.ServiceVersion),
-	)
+// BuilderOption overrides the meter a MetricsBuilder creates its instruments on.
+type BuilderOption func(*MetricsBuilder)
+
+// WithMeterName names the meter, instead of the provider's Config.ServiceName.
+func WithMeterName(name string) BuilderOption {
+	return func(mb *MetricsBuilder) {
+		mb.name = name
+	}
+}
 
-	return &MetricsBuilder{
-		meter:      meter,
+// WithMeterVersion versions the meter, instead of the provider's Config.ServiceVersion.
+func WithMeterVersion(version string) BuilderOption {
+	return func(mb *MetricsBuilder) {
+		mb.version = version
+	}
+}
+
+// NewMetricsBuilder creates a new metrics builder on a meter of the given provider. The
+// meter is named and versioned after the provider's service unless overridden by options.
+func NewMetricsBuilder(provider *MetricsProvider, opts ...BuilderOption) *MetricsBuilder {
+	builder := &MetricsBuilder{
+		meter:      nil,
 		name:       provider.config.ServiceName,
+		version:    provider.config.ServiceVersion,
 		counters:   make(map[string]metric.Int64Counter),
 		gauges:     make(map[string]metric.Int64Gauge),
 		histograms: make(map[string]metric.Float64Histogram),
 	}
+
+	for _, opt := range opts {
+		opt(builder)
+	}
+
+	builder.meter = provider.meterProvider.Meter(
+		builder.name,
+		metric.WithInstrumentationVersion(builder.version),
+	)
+
+	return builder
+}
+
+// Name returns the name of the meter the builder creates instruments on.
+func (mb *MetricsBuilder) Name() string {
+	return mb.name
+}
+
+// Version returns the version of the meter the builder creates instruments on.
+func (mb *MetricsBuilder) Version() string {
+	return mb.version
 }
 
 // Counter creates a new counter metric.
@@ -15,9 +15,11 @@ func setupMetricsProvider(t *testing.T) *metricsfx.MetricsProvider {
 	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{
 		ServiceName:                   "",
 		ServiceVersion:                "",
+		ServiceInstanceID:             "",
 		OTLPConnectionName:            "", // No connection for testing
 		ExportInterval:                30 * time.Second,
 		NoNativeCollectorRegistration: true,
+		RequireNativeCollectors:       false,
 	}, nil) // nil registry for testing
 
 	err := provider.Init()
@@ -53,6 +55,63 @@ func TestMetricsBuilder_Counter(t *testing.T) {
 	counter.Add(ctx, 5, metricsfx.StringAttr("method", "POST"))
 }
 
+func TestMetricsBuilder_MeterIdentity(t *testing.T) {
+	t.Parallel()
+
+	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{ //nolint:exhaustruct
+		ServiceName:                   "orders",
+		ServiceVersion:                "1.2.3",
+		NoNativeCollectorRegistration: true,
+	}, nil)
+	require.NoError(t, provider.Init())
+
+	t.Cleanup(func() { _ = provider.Shutdown(t.Context()) })
+
+	tests := []struct {
+		name            string
+		opts            []metricsfx.BuilderOption
+		expectedName    string
+		expectedVersion string
+	}{
+		{
+			name:            "defaults from config",
+			opts:            nil,
+			expectedName:    "orders",
+			expectedVersion: "1.2.3",
+		},
+		{
+			name: "explicit name and version",
+			opts: []metricsfx.BuilderOption{
+				metricsfx.WithMeterName("orders/checkout"),
+				metricsfx.WithMeterVersion("2.0.0"),
+			},
+			expectedName:    "orders/checkout",
+			expectedVersion: "2.0.0",
+		},
+		{
+			name:            "explicit name only",
+			opts:            []metricsfx.BuilderOption{metricsfx.WithMeterName("orders/refunds")},
+			expectedName:    "orders/refunds",
+			expectedVersion: "1.2.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			builder := provider.NewBuilder(tt.opts...)
+
+			assert.Equal(t, tt.expectedName, builder.Name())
+			assert.Equal(t, tt.expectedVersion, builder.Version())
+
+			counter, err := builder.Counter("orders_total", "Total orders").Build()
+			require.NoError(t, err)
+			counter.Inc(t.Context())
+		})
+	}
+}
+
 func TestMetricsBuilder_Gauge(t *testing.T) {
 	t.Parallel()
 
@@ -198,9 +257,11 @@ func TestMetricsBuilder_Build(t *testing.T) { //nolint:funlen
 			config: &metricsfx.Config{
 				ServiceName:                   "test-service",
 				ServiceVersion:                "1.0.0",
+				ServiceInstanceID:             "",
 				OTLPConnectionName:            "", // No connection
 				ExportInterval:                30 * time.Second,
 				NoNativeCollectorRegistration: true,
+				RequireNativeCollectors:       false,
 			},
 			expectedError:    false,
 			expectedProvider: true,
@@ -210,9 +271,11 @@ func TestMetricsBuilder_Build(t *testing.T) { //nolint:funlen
 			config: &metricsfx.Config{
 				ServiceName:                   "test-service",
 				ServiceVersion:                "1.0.0",
+				ServiceInstanceID:             "",
 				OTLPConnectionName:            "otlp-connection", // Connection configured
 				ExportInterval:                30 * time.Second,
 				NoNativeCollectorRegistration: true,
+				RequireNativeCollectors:       false,
 			},
 			expectedError:    true, // Error expected because no registry provided
 			expectedProvider: true,
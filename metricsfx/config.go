@@ -8,6 +8,9 @@ type Config struct {
 	ServiceName    string `conf:"service_name"    default:""`
 	ServiceVersion string `conf:"service_version" default:""`
 
+	// Instance identifier reported as service.instance.id (defaults to the hostname)
+	ServiceInstanceID string `conf:"service_instance_id" default:""`
+
 	// Connection name for OTLP export (uses connfx registry)
 	OTLPConnectionName string `conf:"otlp_connection_name" default:""`
 
@@ -15,4 +18,8 @@ type Config struct {
 	ExportInterval time.Duration `conf:"export_interval" default:"30s"`
 
 	NoNativeCollectorRegistration bool `conf:"no_native_collector_registration" default:"false"`
+
+	// Fail Init when the Go runtime collectors can't be started, instead of logging a
+	// warning and continuing with custom metrics only
+	RequireNativeCollectors bool `conf:"require_native_collectors" default:"false"`
 }
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 	"time"
 
+	"github.com/eser/ajan/logfx"
 	"go.opentelemetry.io/contrib/instrumentation/runtime"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
@@ -16,39 +20,82 @@ import (
 
 const (
 	minimumReadMemStatsInterval = 15 * time.Second
+
+	// instrumentationName names the meter metricsfx reports its own health on.
+	instrumentationName = "github.com/eser/ajan/metricsfx"
 )
 
 var (
 	ErrFailedToCreateResource            = errors.New("failed to create resource")
 	ErrFailedToShutdownProvider          = errors.New("failed to shutdown metrics provider")
+	ErrFailedToFlushProvider             = errors.New("failed to flush metrics provider")
 	ErrOTLPBridgeNotAvailable            = errors.New("no OTLP bridge available")
 	ErrMetricExporterNotAvailable        = errors.New("no metric exporter available")
 	ErrFailedToCreateMeterProvider       = errors.New("failed to create meter provider")
 	ErrFailedToInitializeMetricsProvider = errors.New("failed to initialize metrics provider")
+	ErrFailedToStartNativeCollectors     = errors.New("failed to start native collectors")
 )
 
+// NativeCollectorStarter starts collecting Go runtime metrics on the given meter provider.
+type NativeCollectorStarter func(meterProvider metric.MeterProvider) error
+
+// ProviderOption configures a MetricsProvider.
+type ProviderOption func(*MetricsProvider)
+
+// WithLogger sets the logger for warnings such as runtime metrics being unavailable
+// (slog's default logger otherwise).
+func WithLogger(logger *logfx.Logger) ProviderOption {
+	return func(mp *MetricsProvider) {
+		if logger != nil {
+			mp.logger = logger.Logger
+		}
+	}
+}
+
+// WithNativeCollectorStarter replaces how Init starts the Go runtime collectors, which is
+// the OpenTelemetry runtime instrumentation by default, e.g. to collect them differently.
+func WithNativeCollectorStarter(start NativeCollectorStarter) ProviderOption {
+	return func(mp *MetricsProvider) {
+		mp.startNativeCollectors = start
+	}
+}
+
 type MetricsProvider struct {
 	config *Config
 	bridge *OTLPBridge
+	logger *slog.Logger
 
-	meterProvider *sdkmetric.MeterProvider
-	shutdown      func(context.Context) error
+	meterProvider         *sdkmetric.MeterProvider
+	shutdown              func(context.Context) error
+	startNativeCollectors NativeCollectorStarter
 }
 
 // NewMetricsProvider creates a new metrics provider with the given configuration.
-func NewMetricsProvider(config *Config, registry ConnectionRegistry) *MetricsProvider {
+func NewMetricsProvider(
+	config *Config,
+	registry ConnectionRegistry,
+	opts ...ProviderOption,
+) *MetricsProvider {
 	var bridge *OTLPBridge
 	if registry != nil {
 		bridge = NewOTLPBridge(registry)
 	}
 
-	return &MetricsProvider{
+	mp := &MetricsProvider{
 		config: config,
 		bridge: bridge,
+		logger: slog.Default(),
+
+		meterProvider:         nil,
+		shutdown:              nil,
+		startNativeCollectors: startRuntimeCollectors,
+	}
 
-		meterProvider: nil,
-		shutdown:      nil,
+	for _, opt := range opts {
+		opt(mp)
 	}
+
+	return mp
 }
 
 func (mp *MetricsProvider) Init() error {
@@ -110,14 +157,64 @@ func (mp *MetricsProvider) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-func (mp *MetricsProvider) NewBuilder() *MetricsBuilder {
-	return NewMetricsBuilder(mp)
+// ForceFlush collects and exports the metrics recorded so far, e.g. before a short-lived
+// job exits. Unlike Shutdown, the provider remains usable.
+func (mp *MetricsProvider) ForceFlush(ctx context.Context) error {
+	if mp.meterProvider == nil {
+		return nil
+	}
+
+	if err := mp.meterProvider.ForceFlush(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToFlushProvider, err)
+	}
+
+	return nil
+}
+
+// NewBuilder creates a metrics builder on this provider. See NewMetricsBuilder.
+func (mp *MetricsProvider) NewBuilder(opts ...BuilderOption) *MetricsBuilder {
+	return NewMetricsBuilder(mp, opts...)
 }
 
+// registerNativeCollectors starts the runtime collectors. Unless the configuration
+// requires them, a failure only costs the runtime metrics: it is logged and counted in
+// metricsfx_native_collector_failures_total, and custom metrics keep working.
 func (mp *MetricsProvider) registerNativeCollectors() error {
-	// Start runtime metrics collection - they will use the same meter provider
-	// and be exported through the same readers (OTLP)
-	err := runtime.Start(runtime.WithMinimumReadMemStatsInterval(minimumReadMemStatsInterval))
+	// Runtime metrics use the same meter provider, so they are exported through the
+	// same readers (OTLP)
+	err := mp.startNativeCollectors(mp.meterProvider)
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("%w: %w", ErrFailedToStartNativeCollectors, err)
+
+	if mp.config.RequireNativeCollectors {
+		return err
+	}
+
+	mp.logger.Warn(
+		"runtime metrics are unavailable, continuing with custom metrics only",
+		slog.String("error", err.Error()),
+	)
+
+	failures, counterErr := mp.meterProvider.Meter(instrumentationName).Int64Counter(
+		"metricsfx_native_collector_failures_total",
+		metric.WithDescription("Times the Go runtime metric collectors failed to start"),
+		metric.WithUnit("{failure}"),
+	)
+	if counterErr == nil {
+		failures.Add(context.Background(), 1)
+	}
+
+	return nil
+}
+
+func startRuntimeCollectors(meterProvider metric.MeterProvider) error {
+	err := runtime.Start(
+		runtime.WithMeterProvider(meterProvider),
+		runtime.WithMinimumReadMemStatsInterval(minimumReadMemStatsInterval),
+	)
 	if err != nil {
 		return err //nolint:wrapcheck
 	}
@@ -136,6 +233,10 @@ func createResource(config *Config) (*resource.Resource, error) {
 		attributes = append(attributes, semconv.ServiceVersion(config.ServiceVersion))
 	}
 
+	if instanceID := resolveServiceInstanceID(config.ServiceInstanceID); instanceID != "" {
+		attributes = append(attributes, semconv.ServiceInstanceID(instanceID))
+	}
+
 	// Create resource without explicit schema URL to avoid conflicts
 	customResource := resource.NewWithAttributes("", attributes...)
 
@@ -147,6 +248,20 @@ func createResource(config *Config) (*resource.Resource, error) {
 	return res, nil
 }
 
+// resolveServiceInstanceID returns the configured instance ID, falling back to the hostname.
+func resolveServiceInstanceID(configured string) string {
+	if configured != "" {
+		return configured
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+
+	return hostname
+}
+
 func (mp *MetricsProvider) createReaders() ([]sdkmetric.Reader, []func(context.Context) error, error) {
 	var readers []sdkmetric.Reader
 
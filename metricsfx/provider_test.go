@@ -0,0 +1,290 @@
+package metricsfx_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/logfx"
+	"github.com/eser/ajan/metricsfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+// recordingExporter captures the resources and names of exported metrics.
+type recordingExporter struct {
+	resources []*resource.Resource
+	names     []string
+	mu        sync.Mutex
+}
+
+func (e *recordingExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (e *recordingExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *recordingExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.resources = append(e.resources, metrics.Resource)
+
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			e.names = append(e.names, m.Name)
+		}
+	}
+
+	return nil
+}
+
+func (e *recordingExporter) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) metricNames() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return slices.Clone(e.names)
+}
+
+func (e *recordingExporter) lastResource() *resource.Resource {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.resources) == 0 {
+		return nil
+	}
+
+	return e.resources[len(e.resources)-1]
+}
+
+// stubOTLPConnection exposes an exporter the way connfx OTLP connections do.
+type stubOTLPConnection struct {
+	exporter sdkmetric.Exporter
+}
+
+func (c *stubOTLPConnection) GetMetricExporter() sdkmetric.Exporter {
+	return c.exporter
+}
+
+type stubRegistry struct {
+	connections map[string]any
+}
+
+func (r *stubRegistry) GetNamed(name string) any {
+	return r.connections[name]
+}
+
+func TestMetricsProvider_ServiceInstanceID(t *testing.T) {
+	t.Parallel()
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		configured string
+		expected   string
+	}{
+		{name: "configured", configured: "worker-7", expected: "worker-7"},
+		{name: "hostname fallback", configured: "", expected: hostname},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter := &recordingExporter{} //nolint:exhaustruct
+			registry := &stubRegistry{
+				connections: map[string]any{
+					"otel": &stubOTLPConnection{exporter: exporter},
+				},
+			}
+
+			provider := metricsfx.NewMetricsProvider(&metricsfx.Config{
+				ServiceName:                   "test-service",
+				ServiceVersion:                "1.0.0",
+				ServiceInstanceID:             tt.configured,
+				OTLPConnectionName:            "otel",
+				ExportInterval:                time.Hour,
+				NoNativeCollectorRegistration: true,
+				RequireNativeCollectors:       false,
+			}, registry)
+
+			require.NoError(t, provider.Init())
+
+			// Shutting down flushes the periodic reader to the exporter
+			require.NoError(t, provider.Shutdown(t.Context()))
+
+			res := exporter.lastResource()
+			require.NotNil(t, res)
+
+			value, ok := res.Set().Value(semconv.ServiceInstanceIDKey)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, value.AsString())
+		})
+	}
+}
+
+// stubSQLConnection stands in for a non-OTLP connfx connection.
+type stubSQLConnection struct{}
+
+func (c *stubSQLConnection) GetProtocol() string {
+	return "sqlite"
+}
+
+func TestMetricsProvider_Init_NotOTLPConnection(t *testing.T) {
+	t.Parallel()
+
+	registry := &stubRegistry{
+		connections: map[string]any{
+			"otel": &stubSQLConnection{},
+		},
+	}
+
+	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{ //nolint:exhaustruct
+		ServiceName:                   "test-service",
+		OTLPConnectionName:            "otel",
+		NoNativeCollectorRegistration: true,
+	}, registry)
+
+	err := provider.Init()
+	require.ErrorIs(t, err, metricsfx.ErrConnectionNotOTLP)
+	assert.Contains(t, err.Error(), `protocol="sqlite"`)
+	assert.Contains(t, err.Error(), `connection="otel"`)
+}
+
+var errRuntimeCollectorsUnavailable = errors.New("runtime collectors unavailable")
+
+func failingNativeCollectors(metric.MeterProvider) error {
+	return errRuntimeCollectorsUnavailable
+}
+
+func newNativeCollectorProvider(
+	t *testing.T,
+	requireCollectors bool,
+	opts ...metricsfx.ProviderOption,
+) (*metricsfx.MetricsProvider, *recordingExporter) {
+	t.Helper()
+
+	exporter := &recordingExporter{} //nolint:exhaustruct
+	registry := &stubRegistry{
+		connections: map[string]any{
+			"otel": &stubOTLPConnection{exporter: exporter},
+		},
+	}
+
+	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{ //nolint:exhaustruct
+		ServiceName:             "test-service",
+		OTLPConnectionName:      "otel",
+		ExportInterval:          time.Hour,
+		RequireNativeCollectors: requireCollectors,
+	}, registry, opts...)
+
+	return provider, exporter
+}
+
+func TestMetricsProvider_Init_NativeCollectorFailure(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+
+	logger := logfx.NewLogger(logfx.WithFromSlog(slog.New(slog.NewTextHandler(&logs, nil))))
+
+	provider, exporter := newNativeCollectorProvider(
+		t,
+		false,
+		metricsfx.WithLogger(logger),
+		metricsfx.WithNativeCollectorStarter(failingNativeCollectors),
+	)
+
+	// Init succeeds, and custom metrics still work
+	require.NoError(t, provider.Init())
+
+	counter, err := provider.NewBuilder().Counter("orders_total", "Orders placed").Build()
+	require.NoError(t, err)
+	counter.Inc(t.Context())
+
+	require.NoError(t, provider.Shutdown(t.Context()))
+
+	assert.Contains(t, logs.String(), "level=WARN")
+	assert.Contains(t, logs.String(), "runtime metrics are unavailable")
+	assert.Contains(t, logs.String(), errRuntimeCollectorsUnavailable.Error())
+
+	names := exporter.metricNames()
+	assert.Contains(t, names, "orders_total")
+	assert.Contains(t, names, "metricsfx_native_collector_failures_total")
+}
+
+func TestMetricsProvider_Init_RequireNativeCollectors(t *testing.T) {
+	t.Parallel()
+
+	provider, _ := newNativeCollectorProvider(
+		t,
+		true,
+		metricsfx.WithNativeCollectorStarter(failingNativeCollectors),
+	)
+
+	err := provider.Init()
+	require.ErrorIs(t, err, metricsfx.ErrFailedToStartNativeCollectors)
+	require.ErrorIs(t, err, errRuntimeCollectorsUnavailable)
+
+	require.NoError(t, provider.Shutdown(t.Context()))
+}
+
+func TestMetricsProvider_ForceFlush(t *testing.T) {
+	t.Parallel()
+
+	exporter := &recordingExporter{} //nolint:exhaustruct
+	registry := &stubRegistry{
+		connections: map[string]any{
+			"otel": &stubOTLPConnection{exporter: exporter},
+		},
+	}
+
+	provider := metricsfx.NewMetricsProvider(&metricsfx.Config{ //nolint:exhaustruct
+		ServiceName:                   "test-service",
+		OTLPConnectionName:            "otel",
+		ExportInterval:                time.Hour,
+		NoNativeCollectorRegistration: true,
+	}, registry)
+
+	require.NoError(t, provider.Init())
+
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	counter, err := provider.NewBuilder().Counter("jobs_total", "Jobs run").Build()
+	require.NoError(t, err)
+
+	counter.Inc(t.Context())
+
+	// Metrics wait for the export interval until flushed
+	assert.Empty(t, exporter.metricNames())
+
+	require.NoError(t, provider.ForceFlush(t.Context()))
+	assert.Contains(t, exporter.metricNames(), "jobs_total")
+
+	// The provider remains usable after a flush
+	counter.Inc(t.Context())
+	require.NoError(t, provider.ForceFlush(t.Context()))
+}
@@ -8,6 +8,9 @@ type Config struct {
 	ServiceName    string `conf:"service_name"    default:""`
 	ServiceVersion string `conf:"service_version" default:""`
 
+	// Instance identifier reported as service.instance.id (defaults to the hostname)
+	ServiceInstanceID string `conf:"service_instance_id" default:""`
+
 	// Connection name for OTLP export (uses connfx registry)
 	OTLPConnectionName string `conf:"otlp_connection_name" default:""`
 
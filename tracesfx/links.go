@@ -0,0 +1,113 @@
+package tracesfx
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// SpanLink references a span in another trace, such as the span that produced one of the
+// messages handled by a batch.
+type SpanLink struct {
+	SpanContext oteltrace.SpanContext
+	Attributes  []attribute.KeyValue
+}
+
+// StartWithLinks creates a new span linked to the given spans, so a batch or fan-in span
+// can point at the trace of every input it handles. Links with an invalid span context
+// are skipped. The returned span must be ended by the caller.
+func (t *Tracer) StartWithLinks(
+	ctx context.Context,
+	name string,
+	links []SpanLink,
+	attrs ...attribute.KeyValue,
+) (context.Context, *Span) { //nolint:spancheck
+	otelLinks := make([]oteltrace.Link, 0, len(links))
+
+	for _, link := range links {
+		if !link.SpanContext.IsValid() {
+			continue
+		}
+
+		otelLinks = append(otelLinks, oteltrace.Link{
+			SpanContext: link.SpanContext,
+			Attributes:  link.Attributes,
+		})
+	}
+
+	return t.Start( //nolint:spancheck
+		ctx,
+		name,
+		oteltrace.WithLinks(otelLinks...),
+		oteltrace.WithAttributes(attrs...),
+	)
+}
+
+// HeaderCarrier adapts message headers to a propagation.TextMapCarrier, so any
+// propagator can read and write them. Header values may be strings or byte slices, as
+// delivered by the queue adapters; values of other types read as empty. Get matches
+// names case-insensitively when there is no exact match, since producers differ in how
+// they spell them (e.g. X-Correlation-ID over HTTP).
+type HeaderCarrier map[string]any
+
+func (c HeaderCarrier) Get(key string) string {
+	raw, ok := c[key]
+	if !ok {
+		for name, candidate := range c {
+			if strings.EqualFold(name, key) {
+				raw = candidate
+
+				break
+			}
+		}
+	}
+
+	switch value := raw.(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	default:
+		return ""
+	}
+}
+
+func (c HeaderCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c HeaderCarrier) Keys() []string {
+	return slices.Collect(maps.Keys(c))
+}
+
+// InjectHeaders writes the W3C trace context of the span in ctx into message headers,
+// for SpanContextFromHeaders to read on the consumer side.
+func InjectHeaders(ctx context.Context, headers map[string]any) {
+	propagation.TraceContext{}.Inject(ctx, HeaderCarrier(headers))
+}
+
+// SpanContextFromHeaders reads the W3C trace context (the traceparent and tracestate
+// headers) from message headers. Header values may be strings or byte slices, as
+// delivered by the queue adapters. It reports false when no valid context is present.
+func SpanContextFromHeaders(headers map[string]any) (oteltrace.SpanContext, bool) {
+	ctx := propagation.TraceContext{}.Extract(context.Background(), HeaderCarrier(headers))
+	spanContext := oteltrace.SpanContextFromContext(ctx)
+
+	return spanContext, spanContext.IsValid()
+}
+
+// LinkFromHeaders returns a SpanLink to the span whose trace context is carried by
+// message headers, reporting false when there is none.
+func LinkFromHeaders(headers map[string]any, attrs ...attribute.KeyValue) (SpanLink, bool) {
+	spanContext, ok := SpanContextFromHeaders(headers)
+	if !ok {
+		return SpanLink{}, false //nolint:exhaustruct
+	}
+
+	return SpanLink{SpanContext: spanContext, Attributes: attrs}, true
+}
@@ -0,0 +1,116 @@
+package tracesfx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eser/ajan/tracesfx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestTracer_StartWithLinks(t *testing.T) {
+	t.Parallel()
+
+	exporter := &recordingExporter{} //nolint:exhaustruct
+	provider := tracesfx.NewTracesProvider(&tracesfx.Config{
+		ServiceName:        "test-service",
+		ServiceVersion:     "",
+		ServiceInstanceID:  "",
+		OTLPConnectionName: "otel",
+		SampleRatio:        1.0,
+		BatchTimeout:       time.Hour,
+		BatchSize:          512,
+	}, &stubRegistry{
+		connections: map[string]any{"otel": &stubOTLPConnection{exporter: exporter}},
+	})
+	require.NoError(t, provider.Init())
+
+	tracer := provider.Tracer("test")
+
+	// Producers publish messages carrying their trace context in the headers
+	messageHeaders := make([]map[string]any, 0, 2)
+	producerSpans := make([]oteltrace.SpanContext, 0, 2)
+
+	for range 2 {
+		ctx, span := tracer.Start(t.Context(), "publish")
+		headers := map[string]any{"content-type": "application/json"}
+		tracesfx.InjectHeaders(ctx, headers)
+		span.End()
+
+		messageHeaders = append(messageHeaders, headers)
+		producerSpans = append(producerSpans, span.SpanContext())
+	}
+
+	// Adapters may deliver header values as bytes
+	traceparent, _ := messageHeaders[1]["traceparent"].(string)
+	messageHeaders[1]["traceparent"] = []byte(traceparent)
+
+	links := make([]tracesfx.SpanLink, 0, len(messageHeaders)+1)
+
+	for i, headers := range messageHeaders {
+		link, ok := tracesfx.LinkFromHeaders(headers, attribute.Int("message.index", i))
+		require.True(t, ok)
+
+		links = append(links, link)
+	}
+
+	// A message without trace context contributes no link
+	_, ok := tracesfx.LinkFromHeaders(map[string]any{"content-type": "text/plain"})
+	assert.False(t, ok)
+
+	links = append(links, tracesfx.SpanLink{}) //nolint:exhaustruct
+
+	_, batchSpan := tracer.StartWithLinks(
+		t.Context(),
+		"process-batch",
+		links,
+		attribute.Int("batch.size", len(messageHeaders)),
+	)
+	batchSpan.End()
+
+	require.NoError(t, provider.Shutdown(t.Context()))
+
+	var batch []oteltrace.Link
+
+	for _, span := range exporter.exported() {
+		if span.Name() != "process-batch" {
+			continue
+		}
+
+		assert.Contains(t, span.Attributes(), attribute.Int("batch.size", 2))
+
+		for _, link := range span.Links() {
+			batch = append(batch, oteltrace.Link{
+				SpanContext: link.SpanContext,
+				Attributes:  link.Attributes,
+			})
+		}
+	}
+
+	require.Len(t, batch, 2)
+
+	for i, link := range batch {
+		assert.Equal(t, producerSpans[i].TraceID(), link.SpanContext.TraceID())
+		assert.Equal(t, producerSpans[i].SpanID(), link.SpanContext.SpanID())
+		assert.True(t, link.SpanContext.IsRemote())
+		assert.Equal(t, []attribute.KeyValue{attribute.Int("message.index", i)}, link.Attributes)
+	}
+}
+
+func TestHeaderCarrier_Get(t *testing.T) {
+	t.Parallel()
+
+	carrier := tracesfx.HeaderCarrier{
+		"x-correlation-id": "exact",
+		"X-Request-ID":     []byte("bytes"),
+		"x-attempt":        3,
+	}
+
+	assert.Equal(t, "exact", carrier.Get("x-correlation-id"))
+	assert.Equal(t, "bytes", carrier.Get("x-request-id"))
+	assert.Empty(t, carrier.Get("x-attempt"))
+	assert.Empty(t, carrier.Get("x-missing"))
+}
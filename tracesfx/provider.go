@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -18,6 +19,7 @@ import (
 var (
 	ErrFailedToCreateResource   = errors.New("failed to create resource")
 	ErrFailedToShutdownProvider = errors.New("failed to shutdown trace provider")
+	ErrFailedToFlushProvider    = errors.New("failed to flush trace provider")
 	ErrTracesNotConfigured      = errors.New("traces not configured")
 	ErrConnectionNotFound       = errors.New("connection not found")
 	ErrConnectionNotOTLP        = errors.New("connection is not an OTLP connection")
@@ -129,6 +131,20 @@ func (tp *TracesProvider) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// ForceFlush exports the spans buffered so far, e.g. before a short-lived job exits.
+// Unlike Shutdown, the provider remains usable.
+func (tp *TracesProvider) ForceFlush(ctx context.Context) error {
+	if tp.tracerProvider == nil {
+		return nil
+	}
+
+	if err := tp.tracerProvider.ForceFlush(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToFlushProvider, err)
+	}
+
+	return nil
+}
+
 // Tracer returns a tracer with the given name.
 func (tp *TracesProvider) Tracer(name string) *Tracer {
 	var otelTracer oteltrace.Tracer
@@ -154,6 +170,10 @@ func createTraceResource(config *Config) (*resource.Resource, error) {
 		attributes = append(attributes, semconv.ServiceVersion(config.ServiceVersion))
 	}
 
+	if instanceID := resolveServiceInstanceID(config.ServiceInstanceID); instanceID != "" {
+		attributes = append(attributes, semconv.ServiceInstanceID(instanceID))
+	}
+
 	// Create resource without explicit schema URL to avoid conflicts
 	customResource := resource.NewWithAttributes("", attributes...)
 
@@ -165,6 +185,20 @@ func createTraceResource(config *Config) (*resource.Resource, error) {
 	return res, nil
 }
 
+// resolveServiceInstanceID returns the configured instance ID, falling back to the hostname.
+func resolveServiceInstanceID(configured string) string {
+	if configured != "" {
+		return configured
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+
+	return hostname
+}
+
 func (tp *TracesProvider) createOTLPTraceExporter() (trace.SpanExporter, error) {
 	// Get OTLP connection from bridge
 	if tp.bridge == nil {
@@ -1,14 +1,62 @@
 package tracesfx_test
 
 import (
+	"context"
+	"os"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/eser/ajan/tracesfx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
 )
 
+// recordingExporter keeps exported spans, including after shutdown.
+type recordingExporter struct {
+	spans []trace.ReadOnlySpan
+	mu    sync.Mutex
+}
+
+func (e *recordingExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, spans...)
+
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) exported() []trace.ReadOnlySpan {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.spans
+}
+
+// stubOTLPConnection exposes a trace exporter the way connfx OTLP connections do.
+type stubOTLPConnection struct {
+	exporter trace.SpanExporter
+}
+
+func (c *stubOTLPConnection) GetTraceExporter() trace.SpanExporter {
+	return c.exporter
+}
+
+type stubRegistry struct {
+	connections map[string]any
+}
+
+func (r *stubRegistry) GetNamed(name string) any {
+	return r.connections[name]
+}
+
 func TestNewTracesProvider(t *testing.T) {
 	t.Parallel()
 
@@ -22,6 +70,7 @@ func TestNewTracesProvider(t *testing.T) {
 			config: &tracesfx.Config{
 				ServiceName:        "test-service",
 				ServiceVersion:     "1.0.0",
+				ServiceInstanceID:  "",
 				OTLPConnectionName: "", // No connection for testing
 				SampleRatio:        1.0,
 				BatchTimeout:       0,
@@ -34,6 +83,7 @@ func TestNewTracesProvider(t *testing.T) {
 			config: &tracesfx.Config{
 				ServiceName:        "test-service",
 				ServiceVersion:     "1.0.0",
+				ServiceInstanceID:  "",
 				OTLPConnectionName: "otlp-connection", // Connection configured
 				SampleRatio:        1.0,
 				BatchTimeout:       5 * time.Second,
@@ -91,6 +141,7 @@ func TestCorrelationIntegration(t *testing.T) {
 	provider := tracesfx.NewTracesProvider(&tracesfx.Config{
 		ServiceName:        "test-service",
 		ServiceVersion:     "",
+		ServiceInstanceID:  "",
 		OTLPConnectionName: "", // No connection for testing
 		SampleRatio:        1.0,
 		BatchTimeout:       0,
@@ -123,3 +174,97 @@ func TestCorrelationIntegration(t *testing.T) {
 
 	span.End()
 }
+
+func TestTracesProvider_ServiceInstanceID(t *testing.T) {
+	t.Parallel()
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		configured string
+		expected   string
+	}{
+		{name: "configured", configured: "worker-7", expected: "worker-7"},
+		{name: "hostname fallback", configured: "", expected: hostname},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter := &recordingExporter{} //nolint:exhaustruct
+			registry := &stubRegistry{
+				connections: map[string]any{
+					"otel": &stubOTLPConnection{exporter: exporter},
+				},
+			}
+
+			provider := tracesfx.NewTracesProvider(&tracesfx.Config{
+				ServiceName:        "test-service",
+				ServiceVersion:     "1.0.0",
+				ServiceInstanceID:  tt.configured,
+				OTLPConnectionName: "otel",
+				SampleRatio:        1.0,
+				BatchTimeout:       time.Hour,
+				BatchSize:          512,
+			}, registry)
+
+			require.NoError(t, provider.Init())
+
+			_, span := provider.Tracer("test").Start(t.Context(), "test-span")
+			span.End()
+
+			// Shutting down flushes the batch processor to the exporter
+			require.NoError(t, provider.Shutdown(t.Context()))
+
+			spans := exporter.exported()
+			require.Len(t, spans, 1)
+
+			value, ok := spans[0].Resource().Set().Value(semconv.ServiceInstanceIDKey)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, value.AsString())
+		})
+	}
+}
+
+func TestTracesProvider_ForceFlush(t *testing.T) {
+	t.Parallel()
+
+	exporter := &recordingExporter{} //nolint:exhaustruct
+	registry := &stubRegistry{
+		connections: map[string]any{
+			"otel": &stubOTLPConnection{exporter: exporter},
+		},
+	}
+
+	provider := tracesfx.NewTracesProvider(&tracesfx.Config{ //nolint:exhaustruct
+		ServiceName:        "test-service",
+		OTLPConnectionName: "otel",
+		SampleRatio:        1.0,
+		BatchTimeout:       time.Hour,
+		BatchSize:          512,
+	}, registry)
+
+	require.NoError(t, provider.Init())
+
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	tracer := provider.Tracer("test")
+
+	// Spans wait for the batch timeout until flushed
+	_, span := tracer.Start(t.Context(), "job")
+	span.End()
+	assert.Empty(t, exporter.exported())
+
+	require.NoError(t, provider.ForceFlush(t.Context()))
+	assert.Len(t, exporter.exported(), 1)
+
+	// The provider remains usable after a flush
+	_, span = tracer.Start(t.Context(), "job")
+	span.End()
+
+	require.NoError(t, provider.ForceFlush(t.Context()))
+	assert.Len(t, exporter.exported(), 2)
+}